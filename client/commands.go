@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"realTimeChat/pkg/chatclient"
+	"realTimeChat/pkg/chatserver"
+	pb "realTimeChat/proto/chat"
+)
+
+// rpcTimeout bounds /who, /members, /rooms and completion lookups
+const rpcTimeout = 3 * time.Second
+
+// commands lists the commands shown by /help and offered on Tab
+var commands = []struct{ name, args, help string }{
+	{"/help", "", "show this help"},
+	{"/who", "[room]", "list online users, everyone when no room is given"},
+	{"/members", "[room]", "list the members of a room with their roles and when they were last seen"},
+	{"/rooms", "", "list rooms with online members"},
+	{"/join", "<room> [invite]", "switch to a room, it is created when empty, private rooms need an invite"},
+	{"/leave", "", "go back to the default room"},
+	{"/pm", "<user> <message>", "send a private message"},
+	{"/nick", "<newname>", "change your username"},
+	{"/draft", "[text|clear]", "show or save the draft of the current room, shared with your other devices"},
+	{"/drafts", "", "list the drafts you saved on any device"},
+	{"/search", "<term>", "search the local history of all conversations"},
+	{"/send", "<path>", "upload a file and share it with the room"},
+	{"/get", "<attachment_id>", "download a shared file"},
+	{"/code", "[language]", "send a code block, end it with a line containing only ```"},
+	{"/translate", "<message_id> <lang>", "translate a message for yourself"},
+	{"/quit", "", "leave the chat, same as exit"},
+}
+
+// runCommand handles one input line, anything that is not a client-side
+// command is sent to the current room
+func runCommand(client *chatclient.Client, con *console, text string) error {
+	name, arg, _ := strings.Cut(text, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "/help":
+		printHelp()
+	case "/who":
+		return listUsers(client, arg)
+	case "/members":
+		if arg == "" {
+			arg = currentRoom(client)
+		}
+		return listMembers(client, arg)
+	case "/rooms":
+		return listRooms(client)
+	case "/join":
+		if arg == "" {
+			fmt.Fprintln(out, "Usage: /join <room> [invite]")
+			return nil
+		}
+		return client.JoinRoom(arg)
+	case "/leave":
+		return client.LeaveRoom()
+	case "/pm":
+		// structure: /pm <username> <message>
+		parts := strings.SplitN(text, " ", 3)
+		if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+			fmt.Fprintln(out, "Invalid PM format. Use: /pm <username> <message>")
+			return nil
+		}
+		if err := client.SendPM(parts[1], parts[2]); err != nil {
+			return err
+		}
+		drafts.sent(client, "@"+parts[1])
+	case "/code":
+		// structure: /code [language], then lines until a line with just ```
+		fmt.Fprintln(out, "Enter code, end with a line containing only ```")
+		con.SetPrompt("")
+		defer con.SetPrompt(prompt(currentRoom(client)))
+		var lines []string
+		for {
+			line, err := con.ReadLine()
+			if err != nil || line == "```" {
+				break
+			}
+			lines = append(lines, line)
+		}
+		return sendPublic(client, &pb.ChatMessage{Payload: &pb.ChatMessage_Code{Code: &pb.Code{Language: arg, Content: strings.Join(lines, "\n")}}})
+	case "/send":
+		if arg == "" {
+			fmt.Fprintln(out, "Usage: /send <path>")
+			return nil
+		}
+		a, err := files.upload(arg)
+		if err != nil {
+			fmt.Fprintf(out, "Upload failed: %v\n", err)
+			return nil
+		}
+		return sendPublic(client, &pb.ChatMessage{Payload: &pb.ChatMessage_Attachment{Attachment: a}})
+	case "/get":
+		if arg == "" {
+			fmt.Fprintln(out, "Usage: /get <attachment_id>")
+			return nil
+		}
+		path, err := files.download(arg)
+		if err != nil {
+			fmt.Fprintf(out, "Download failed: %v\n", err)
+			return nil
+		}
+		fmt.Fprintf(out, "Saved to %s\n", path)
+	case "/draft", "/drafts":
+		return draftCommand(client, name, arg)
+	case "/search":
+		searchHistory(arg)
+	case "/nick", "/translate":
+		return client.Send(text) // handled by the server
+	default:
+		if err := sendPublic(client, &pb.ChatMessage{Text: text}); err != nil {
+			return err
+		}
+		drafts.sent(client, "#"+currentRoom(client))
+	}
+	return nil
+}
+
+// sendPublic sends msg to the current room and logs it locally, the server
+// does not echo public messages back to their sender
+func sendPublic(client *chatclient.Client, msg *pb.ChatMessage) error {
+	if err := client.SendMessage(msg); err != nil {
+		return err
+	}
+	msg.Room = currentRoom(client)
+	msg.Timestamp = time.Now().UnixMilli()
+	if err := hist.add(msg, client.Username()); err != nil {
+		log.Printf("Failed to write local history: %v", err)
+	}
+	return nil
+}
+
+func searchHistory(term string) {
+	if term == "" {
+		fmt.Fprintln(out, "Usage: /search <term>")
+		return
+	}
+	if hist == nil {
+		fmt.Fprintln(out, "Local history is disabled.")
+		return
+	}
+	found, err := hist.search(term)
+	if err != nil {
+		fmt.Fprintf(out, "Search failed: %v\n", err)
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d result(s) for %q:\n", len(found), term)
+	for _, m := range found {
+		text := m.msg.Text
+		if code := m.msg.GetCode(); code != nil {
+			text = "code: " + strings.SplitN(code.Content, "\n", 2)[0]
+		}
+		t := time.UnixMilli(m.msg.Timestamp).Local().Format("2006-01-02 15:04")
+		fmt.Fprintf(&b, "  %s %s [%s]: %s\n", t, m.conv, m.msg.User, text)
+	}
+	fmt.Fprint(out, b.String())
+}
+
+func printHelp() {
+	var b strings.Builder
+	fmt.Fprintln(&b, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  %-34s %s\n", strings.TrimSpace(c.name+" "+c.args), c.help)
+	}
+	fmt.Fprintln(&b, "Tab completes commands, usernames and room names.")
+	fmt.Fprint(out, b.String())
+}
+
+// currentRoom returns the client's room, the default room until it moves
+func currentRoom(client *chatclient.Client) string {
+	if room := client.Room(); room != "" {
+		return room
+	}
+	return chatserver.DefaultRoom
+}
+
+func listUsers(client *chatclient.Client, room string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	users, err := client.ListUsers(ctx, room)
+	if err != nil {
+		fmt.Fprintf(out, "Could not list users: %v\n", err)
+		return nil
+	}
+
+	var b strings.Builder
+	if room != "" {
+		fmt.Fprintf(&b, "Online in #%s (%d):\n", strings.TrimPrefix(room, "#"), len(users))
+	} else {
+		fmt.Fprintf(&b, "Online (%d):\n", len(users))
+	}
+	for _, u := range users {
+		fmt.Fprintf(&b, "  %s", u.Name)
+		if room == "" {
+			fmt.Fprintf(&b, "  #%s", strings.Join(u.Rooms, " #"))
+		}
+		switch u.Status {
+		case pb.PresenceStatus_PRESENCE_IN_CALL:
+			b.WriteString("  (in a call)")
+		case pb.PresenceStatus_PRESENCE_SHARING_SCREEN:
+			b.WriteString("  (sharing screen)")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprint(out, b.String())
+	return nil
+}
+
+func listMembers(client *chatclient.Client, room string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	members, err := client.RoomMembers(ctx, room)
+	if err != nil {
+		fmt.Fprintf(out, "Could not list members: %v\n", err)
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Members of #%s (%d):\n", strings.TrimPrefix(room, "#"), len(members))
+	for _, m := range members {
+		fmt.Fprintf(&b, "  %s", m.User)
+		switch m.Role {
+		case pb.RoomRole_ROLE_OWNER:
+			b.WriteString("  [owner]")
+		case pb.RoomRole_ROLE_MODERATOR:
+			b.WriteString("  [moderator]")
+		}
+		switch {
+		case m.Online && m.Status == pb.PresenceStatus_PRESENCE_AWAY:
+			b.WriteString("  away")
+		case m.Online:
+			b.WriteString("  online")
+		case m.LastSeen > 0:
+			fmt.Fprintf(&b, "  last seen %s", time.UnixMilli(m.LastSeen).Local().Format("Jan 2 15:04"))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprint(out, b.String())
+	return nil
+}
+
+func listRooms(client *chatclient.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	rooms, err := client.ListRooms(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Could not list rooms: %v\n", err)
+		return nil
+	}
+
+	current := currentRoom(client)
+	var b strings.Builder
+	fmt.Fprintln(&b, "Rooms:")
+	for _, r := range rooms {
+		mark := " "
+		if r.Name == current {
+			mark = "*"
+		}
+		fmt.Fprintf(&b, " %s #%-20s %d online\n", mark, r.Name, r.Members)
+	}
+	fmt.Fprint(out, b.String())
+	return nil
+}
+
+// completer returns the Tab candidates for the word starting at line[word:]:
+// commands for the first word, rooms after /join and /who, usernames
+// elsewhere
+func completer(client *chatclient.Client) func(line string, word int) []string {
+	return func(line string, word int) []string {
+		if word == 0 {
+			if !strings.HasPrefix(line, "/") {
+				return onlineUsers(client)
+			}
+			names := make([]string, len(commands))
+			for i, c := range commands {
+				names[i] = c.name
+			}
+			return names
+		}
+		cmd, _, _ := strings.Cut(line, " ")
+		if cmd == "/join" || cmd == "/who" || cmd == "/members" {
+			if word == len(cmd)+1 {
+				return roomNames(client)
+			}
+			return nil
+		}
+		return onlineUsers(client)
+	}
+}
+
+func onlineUsers(client *chatclient.Client) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	users, err := client.ListUsers(ctx, "")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names
+}
+
+func roomNames(client *chatclient.Client) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	rooms, err := client.ListRooms(ctx)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(rooms))
+	for _, r := range rooms {
+		names = append(names, r.Name)
+	}
+	return names
+}
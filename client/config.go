@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"realTimeChat/pkg/chatserver"
+)
+
+// config holds the client settings, read from a JSON file and overridden
+// by command-line flags
+type config struct {
+	Server       string      `json:"server"`   // chat server address, host:port
+	Username     string      `json:"username"` // prompted for when empty
+	Gateway      string      `json:"gateway"`  // web gateway base URL, used for file transfers
+	Room         string      `json:"room"`
+	Device       string      `json:"device"`     // shown in session lists, the host name by default
+	LogFile      string      `json:"logFile"`    // connection logs go here instead of the terminal
+	History      int         `json:"history"`    // messages replayed from the local log on startup
+	HistoryDir   string      `json:"historyDir"` // local conversation logs, empty disables them
+	DownloadDir  string      `json:"downloadDir"`
+	TimeFormat   string      `json:"timeFormat"`
+	RelativeTime bool        `json:"relativeTime"`
+	Notify       bool        `json:"notify"`     // desktop notifications for PMs and mentions
+	NotifyIdle   string      `json:"notifyIdle"` // notify while focused too after this long without typing
+	Colors       colorConfig `json:"colors"`
+	TLS          tlsConfig   `json:"tls"`
+
+	// headless modes, command line only
+	Once       string `json:"-"`
+	StdinPipe  bool   `json:"-"`
+	JSONOutput bool   `json:"-"`
+
+	notifyIdle time.Duration // NotifyIdle parsed
+}
+
+// tlsConfig configures the connection to servers behind TLS
+type tlsConfig struct {
+	Enabled            bool   `json:"enabled"`
+	CAFile             string `json:"caFile"`   // PEM roots, the system pool when empty
+	CertFile           string `json:"certFile"` // client certificate for mutual TLS
+	KeyFile            string `json:"keyFile"`
+	ServerName         string `json:"serverName"` // overrides the name checked in the server certificate
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
+// defaultConfigPath is the config file read when --config is not given
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "realtimechat", "client.json")
+}
+
+// defaultDevice names the device after the host
+func defaultDevice() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// loadConfig parses the command line and merges it over the config file
+func loadConfig() (config, error) {
+	cfg := config{
+		Server:      "localhost:50051",
+		Room:        chatserver.DefaultRoom,
+		Device:      defaultDevice(),
+		TimeFormat:  "15:04",
+		History:     20,
+		HistoryDir:  defaultHistoryDir(),
+		Gateway:     "http://localhost:8080",
+		DownloadDir: defaultDownloadDir(),
+		Notify:      true,
+		NotifyIdle:  "1m",
+		Colors:      colorConfig{Mention: "yellow,bold", PM: "magenta"},
+	}
+	path := flag.String("config", defaultConfigPath(), "JSON config file, flags override its values")
+	flag.StringVar(&cfg.Server, "server", cfg.Server, "chat server address")
+	flag.StringVar(&cfg.Username, "user", cfg.Username, "username, prompted for when empty")
+	flag.StringVar(&cfg.Room, "room", cfg.Room, "room to chat in")
+	flag.StringVar(&cfg.Device, "device", cfg.Device, "name of this device shown in /sessions")
+	flag.StringVar(&cfg.Gateway, "gateway", cfg.Gateway, "web gateway URL used by /send and /get")
+	flag.StringVar(&cfg.DownloadDir, "download-dir", cfg.DownloadDir, "directory /get saves files to")
+	flag.StringVar(&cfg.LogFile, "log-file", cfg.LogFile, "write connection logs to this file instead of the terminal")
+	flag.IntVar(&cfg.History, "history", cfg.History, "show the last N messages of the room from the local log on startup")
+	flag.StringVar(&cfg.HistoryDir, "history-dir", cfg.HistoryDir, `directory for the local conversation logs, "" disables them`)
+	flag.StringVar(&cfg.TimeFormat, "time-format", cfg.TimeFormat, `Go time layout for message times, e.g. "15:04:05" or "Jan 2 15:04", "none" hides them`)
+	flag.BoolVar(&cfg.RelativeTime, "relative-time", cfg.RelativeTime, `show message times as "2m ago"`)
+	flag.BoolVar(&cfg.Notify, "notify", cfg.Notify, "show a desktop notification for PMs and mentions while the window is unfocused or idle")
+	flag.StringVar(&cfg.NotifyIdle, "notify-idle", cfg.NotifyIdle, `time without typing after which the window counts as idle, "0" only notifies when unfocused`)
+	flag.StringVar(&cfg.Colors.Mention, "mention-color", cfg.Colors.Mention, `color of your name in incoming messages, e.g. "red,underline", "none" disables it`)
+	flag.StringVar(&cfg.Colors.PM, "pm-color", cfg.Colors.PM, `color of private messages, "none" disables it`)
+	flag.StringVar(&cfg.Once, "once", cfg.Once, "send this message (or command) and exit")
+	flag.BoolVar(&cfg.StdinPipe, "stdin-pipe", cfg.StdinPipe, "send every line read from stdin and exit at end of input, without prompts")
+	flag.BoolVar(&cfg.JSONOutput, "json-output", cfg.JSONOutput, "print received messages to stdout as NDJSON, other output goes to stderr")
+	flag.BoolVar(&cfg.TLS.Enabled, "tls", cfg.TLS.Enabled, "connect with TLS")
+	flag.StringVar(&cfg.TLS.CAFile, "tls-ca", cfg.TLS.CAFile, "PEM file with the CA certificates to trust, implies --tls")
+	flag.StringVar(&cfg.TLS.CertFile, "tls-cert", cfg.TLS.CertFile, "client certificate for mutual TLS, implies --tls")
+	flag.StringVar(&cfg.TLS.KeyFile, "tls-key", cfg.TLS.KeyFile, "private key for --tls-cert")
+	flag.StringVar(&cfg.TLS.ServerName, "tls-server-name", cfg.TLS.ServerName, "server name to verify instead of the host in --server")
+	flag.BoolVar(&cfg.TLS.InsecureSkipVerify, "tls-insecure-skip-verify", cfg.TLS.InsecureSkipVerify, "do not verify the server certificate (testing only)")
+	flag.Parse()
+
+	explicit := false
+	flag.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "config" })
+	if *path != "" {
+		data, err := os.ReadFile(*path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+			// no config file at the default location
+		case err != nil:
+			return cfg, err
+		default:
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return cfg, fmt.Errorf("parse %s: %w", *path, err)
+			}
+			// apply the command line again so it wins over the file
+			if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+				return cfg, err
+			}
+		}
+	}
+
+	if cfg.Once != "" && cfg.StdinPipe {
+		return cfg, errors.New("--once and --stdin-pipe cannot be combined")
+	}
+	if cfg.Server == "" {
+		return cfg, errors.New("server address cannot be empty")
+	}
+	cfg.Gateway = strings.TrimSuffix(cfg.Gateway, "/")
+	cfg.Room = strings.ToLower(strings.TrimPrefix(cfg.Room, "#"))
+	idle, err := time.ParseDuration(cfg.NotifyIdle)
+	if err != nil {
+		return cfg, fmt.Errorf("notify idle time: %w", err)
+	}
+	cfg.notifyIdle = idle
+	if _, err := cfg.Colors.resolve(); err != nil {
+		return cfg, err
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		return cfg, errors.New("tls cert and key must be given together")
+	}
+	return cfg, nil
+}
+
+// dialOption returns the transport credentials for the TLS settings
+func (c *tlsConfig) dialOption() (grpc.DialOption, error) {
+	if !c.Enabled && c.CAFile == "" && c.CertFile == "" {
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+	conf := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+		}
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(conf)), nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// console reads input lines. On a terminal it offers line editing and
+// tab completion, output must then go through it so incoming messages are
+// printed above the line being typed.
+type console struct {
+	term     *term.Terminal // nil when stdin is not a terminal
+	restore  func()
+	scanner  *bufio.Scanner
+	complete func(line string, word int) []string // candidates for the word starting at line[word:]
+	onKey    func()                               // called for every key press
+	onFocus  func(focused bool)                   // called when the terminal window gains or loses focus
+	fill     func() string                        // text Tab puts on an empty line, such as a saved draft
+}
+
+// newConsole puts the terminal into raw mode if stdin is one and
+// interactive is set, Close restores it. Otherwise input is read line by
+// line.
+func newConsole(interactive bool) (*console, error) {
+	c := &console{}
+	fd := int(os.Stdin.Fd())
+	if !interactive || !term.IsTerminal(fd) {
+		c.scanner = bufio.NewScanner(os.Stdin)
+		return c, nil
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	c.restore = func() {
+		_, _ = os.Stdout.WriteString(focusOff)
+		_ = term.Restore(fd, state)
+	}
+	in := &focusReader{r: os.Stdin, onFocus: func(focused bool) {
+		if c.onFocus != nil {
+			c.onFocus(focused)
+		}
+	}}
+	_, _ = os.Stdout.WriteString(focusOn)
+	c.term = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, os.Stdout}, "")
+	c.term.AutoCompleteCallback = c.autoComplete
+	return c, nil
+}
+
+// Interactive reports whether input comes from a terminal
+func (c *console) Interactive() bool {
+	return c.term != nil
+}
+
+// Out is where messages are printed
+func (c *console) Out() io.Writer {
+	if c.term != nil {
+		return c.term
+	}
+	return os.Stdout
+}
+
+// ReadLine returns the next input line, io.EOF at the end of input or
+// when Ctrl-D is pressed on an empty line
+func (c *console) ReadLine() (string, error) {
+	if c.term != nil {
+		return c.term.ReadLine()
+	}
+	if c.scanner.Scan() {
+		return c.scanner.Text(), nil
+	}
+	if err := c.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// SetPrompt changes the prompt shown on a terminal
+func (c *console) SetPrompt(prompt string) {
+	if c.term != nil {
+		c.term.SetPrompt(prompt)
+	}
+}
+
+// Close restores the terminal
+func (c *console) Close() {
+	if c.restore != nil {
+		c.restore()
+	}
+}
+
+// autoComplete completes the word before the cursor on Tab. A single
+// candidate is filled in, several are completed to their common prefix
+// and listed when that does not get any further. An empty line gets the
+// text of fill instead when there is any.
+func (c *console) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if c.onKey != nil {
+		c.onKey()
+	}
+	if key != '\t' {
+		return "", 0, false
+	}
+	if line == "" && c.fill != nil {
+		if text := c.fill(); text != "" {
+			return text, len(text), true
+		}
+	}
+	if c.complete == nil {
+		return "", 0, false
+	}
+	start := strings.LastIndexByte(line[:pos], ' ') + 1
+	word := line[start:pos]
+	var matches []string
+	for _, cand := range c.complete(line[:pos], start) {
+		if strings.HasPrefix(cand, word) {
+			matches = append(matches, cand)
+		}
+	}
+	if len(matches) == 0 {
+		return line, pos, true
+	}
+	sort.Strings(matches)
+	fill := matches[0]
+	if len(matches) > 1 {
+		fill = commonPrefix(matches)
+		if fill == word {
+			_, _ = c.term.Write([]byte(strings.Join(matches, "  ") + "\n"))
+			return line, pos, true
+		}
+	} else {
+		fill += " "
+	}
+	newLine := line[:start] + fill + line[pos:]
+	return newLine, start + len(fill), true
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
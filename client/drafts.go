@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"realTimeChat/pkg/chatclient"
+)
+
+// drafts caches the drafts saved on the server, so a message started in
+// the web client can be finished here and is cleared once sent
+var drafts = &draftCache{}
+
+type draftCache struct {
+	mu    sync.Mutex
+	texts map[string]string // conversation -> text
+}
+
+// load replaces the cache with the drafts on the server
+func (d *draftCache) load(client *chatclient.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	list, err := client.Drafts(ctx)
+	if err != nil {
+		return err
+	}
+	texts := make(map[string]string, len(list))
+	for _, draft := range list {
+		texts[draft.Conversation] = draft.Text
+	}
+	d.mu.Lock()
+	d.texts = texts
+	d.mu.Unlock()
+	return nil
+}
+
+// get returns the draft of conv, "" when there is none
+func (d *draftCache) get(conv string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.texts[conv]
+}
+
+// save stores text as the draft of conv, empty text deletes it. The
+// server may answer with a newer draft from another device.
+func (d *draftCache) save(client *chatclient.Client, conv, text string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	saved, err := client.SaveDraft(ctx, conv, text)
+	if err != nil {
+		return "", err
+	}
+	d.mu.Lock()
+	if d.texts == nil {
+		d.texts = make(map[string]string)
+	}
+	if saved.Text == "" {
+		delete(d.texts, saved.Conversation)
+	} else {
+		d.texts[saved.Conversation] = saved.Text
+	}
+	d.mu.Unlock()
+	return saved.Text, nil
+}
+
+// sent clears the draft of conv after a message went there
+func (d *draftCache) sent(client *chatclient.Client, conv string) {
+	if d.get(conv) == "" {
+		return
+	}
+	if _, err := d.save(client, conv, ""); err != nil {
+		log.Printf("Failed to clear the draft of %s: %v", conv, err)
+	}
+}
+
+// announce mentions the draft of room, it can be brought back with Tab
+func (d *draftCache) announce(room string) {
+	if text := d.get("#" + room); text != "" {
+		fmt.Fprintf(out, "Draft for #%s: %s (press Tab on an empty line to continue it)\n", room, text)
+	}
+}
+
+// draftCommand runs /draft [text], which shows or saves the draft of the
+// current room, and /drafts, which lists them all
+func draftCommand(client *chatclient.Client, name, arg string) error {
+	room := currentRoom(client)
+	if name == "/draft" {
+		if arg == "" {
+			if text := drafts.get("#" + room); text != "" {
+				fmt.Fprintf(out, "Draft for #%s: %s\n", room, text)
+			} else {
+				fmt.Fprintf(out, "No draft for #%s.\n", room)
+			}
+			return nil
+		}
+		if arg == "clear" {
+			arg = ""
+		}
+		text, err := drafts.save(client, "#"+room, arg)
+		switch {
+		case err != nil:
+			fmt.Fprintf(out, "Could not save the draft: %v\n", err)
+		case text != arg:
+			fmt.Fprintf(out, "A newer draft was saved elsewhere: %s\n", text)
+		case text == "":
+			fmt.Fprintf(out, "Draft for #%s deleted.\n", room)
+		default:
+			fmt.Fprintf(out, "Draft for #%s saved.\n", room)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+	list, err := client.Drafts(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Could not list drafts: %v\n", err)
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Drafts (%d):\n", len(list))
+	for _, draft := range list {
+		t := time.UnixMilli(draft.UpdatedAt).Local().Format("Jan 2 15:04")
+		fmt.Fprintf(&b, "  %-20s %s  %s\n", draft.Conversation, t, draft.Text)
+	}
+	fmt.Fprint(out, b.String())
+	return drafts.load(client)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// defaultDownloadDir is where /get saves files unless --download-dir says
+// otherwise
+func defaultDownloadDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, "Downloads")
+}
+
+// transfers moves files through the gateway's upload and download API
+type transfers struct {
+	gateway  string // base URL without trailing slash
+	dir      string // downloads go here
+	progress bool   // draw progress bars, only on a terminal
+	http     *http.Client
+}
+
+// files is used by /send and /get
+var files = &transfers{http: &http.Client{}}
+
+var attachmentIDPattern = regexp.MustCompile(`^(?:[0-9a-f]{32}|[0-9A-HJKMNP-TV-Z]{26})$`)
+
+// maxUploadAttempts bounds how often /send resumes an upload after the
+// connection dropped
+const maxUploadAttempts = 5
+
+// upload sends the file at path through the gateway's resumable upload
+// API and returns the stored attachment. An interrupted transfer carries
+// on from what the gateway already has.
+func (t *transfers) upload(path string) (*pb.Attachment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+
+	start, _ := json.Marshal(map[string]any{"name": filepath.Base(path), "size": info.Size(), "sha256": hex.EncodeToString(h.Sum(nil))})
+	resp, err := t.http.Post(t.gateway+"/api/uploads/resumable", "application/json", bytes.NewReader(start))
+	if err != nil {
+		return nil, err
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	err = decodeResponse(resp, http.StatusCreated, &created)
+	if err != nil {
+		return nil, err
+	}
+	url := t.gateway + "/api/uploads/resumable/" + created.ID
+
+	bar := t.bar("Uploading "+filepath.Base(path), info.Size())
+	defer bar.done()
+	var offset int64
+	for attempt := 1; ; attempt++ {
+		a, err := t.sendFrom(url, f, offset, bar)
+		if a != nil || attempt == maxUploadAttempts || errors.Is(err, errUploadFailed) {
+			return a, err
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+		if offset, err = t.uploadOffset(url); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// errUploadFailed marks answers that resuming cannot fix
+var errUploadFailed = errors.New("upload failed")
+
+// sendFrom PATCHes the rest of f from offset to the resumable upload at
+// url
+func (t *transfers) sendFrom(url string, f *os.File, offset int64, bar *progressBar) (*pb.Attachment, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	bar.at(offset)
+	req, err := http.NewRequest(http.MethodPatch, url, io.TeeReader(f, bar))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	var a struct {
+		ID       string `json:"id"`
+		Kind     string `json:"kind"`
+		MimeType string `json:"mimeType"`
+		Size     int64  `json:"size"`
+		URL      string `json:"url"`
+		Name     string `json:"name"`
+	}
+	err = decodeResponse(resp, http.StatusCreated, &a)
+	if err != nil {
+		if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusConflict {
+			err = fmt.Errorf("%w: %v", errUploadFailed, err)
+		}
+		return nil, err
+	}
+	return &pb.Attachment{Id: a.ID, Kind: a.Kind, MimeType: a.MimeType, Size: a.Size, Url: a.URL, Name: a.Name}, nil
+}
+
+// uploadOffset asks the gateway how much of the upload at url arrived
+func (t *transfers) uploadOffset(url string) (int64, error) {
+	resp, err := t.http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	var progress struct {
+		Offset int64 `json:"offset"`
+	}
+	err = decodeResponse(resp, http.StatusOK, &progress)
+	return progress.Offset, err
+}
+
+// decodeResponse closes resp after decoding its JSON body into v, or
+// turning it into an error when the status is not want
+func decodeResponse(resp *http.Response, want int, v any) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("bad gateway response: %w", err)
+	}
+	return nil
+}
+
+// download saves an attachment to the download directory and returns the
+// path it was written to, an existing file is never overwritten
+func (t *transfers) download(id string) (string, error) {
+	id = strings.ToLower(id)
+	if !attachmentIDPattern.MatchString(id) {
+		return "", errors.New("invalid attachment id")
+	}
+	resp, err := t.http.Get(t.gateway + "/api/attachments/" + id)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+
+	name := id
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = filepath.Base(params["filename"])
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return "", err
+	}
+	f, path, err := createUnique(t.dir, name)
+	if err != nil {
+		return "", err
+	}
+
+	bar := t.bar("Downloading "+name, resp.ContentLength)
+	_, err = io.Copy(io.MultiWriter(f, bar), resp.Body)
+	bar.done()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// createUnique creates name in dir, or "name (1).ext" and so on when it
+// is taken
+func createUnique(dir, name string) (*os.File, string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; ; i++ {
+		path := filepath.Join(dir, name)
+		if i > 0 {
+			path = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) && i < 1000 {
+			continue
+		}
+		return f, path, err
+	}
+}
+
+// responseError turns a gateway error response into an error
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body) == nil && body.Error != "" {
+		return errors.New(body.Error)
+	}
+	return errors.New(resp.Status)
+}
+
+// progressBar counts the bytes written to it and redraws a bar on the
+// current terminal line at most every 100ms
+type progressBar struct {
+	mu      sync.Mutex
+	label   string
+	total   int64 // -1 when unknown
+	written int64
+	drawn   time.Time
+	visible bool
+}
+
+func (t *transfers) bar(label string, total int64) *progressBar {
+	return &progressBar{label: label, total: total, visible: t.progress}
+}
+
+// at moves the bar back to n bytes, when a transfer resumes
+func (p *progressBar) at(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written = n
+}
+
+func (p *progressBar) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written += int64(len(b))
+	if p.visible && time.Since(p.drawn) >= 100*time.Millisecond {
+		p.draw()
+	}
+	return len(b), nil
+}
+
+// draw writes straight to stdout, the prompt is not shown while a command
+// runs
+func (p *progressBar) draw() {
+	const width = 30
+	p.drawn = time.Now()
+	line := fmt.Sprintf("%s %s", p.label, formatSize(p.written))
+	if p.total > 0 {
+		filled := int(min(p.written, p.total) * width / p.total)
+		line = fmt.Sprintf("%s [%s%s] %3d%% %s/%s", p.label,
+			strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+			p.written*100/p.total, formatSize(p.written), formatSize(p.total))
+	}
+	fmt.Fprint(os.Stdout, "\r\x1b[K"+line)
+}
+
+// done draws the final state and ends the line
+func (p *progressBar) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.visible {
+		p.draw()
+		fmt.Fprint(os.Stdout, "\r\n")
+	}
+}
+
+// formatSize renders a byte count as "512 B", "1.5 KB" or "2.3 MB"
+func formatSize(n int64) string {
+	switch {
+	case n < 1<<10:
+		return fmt.Sprintf("%d B", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"realTimeChat/pkg/chatclient"
+	pb "realTimeChat/proto/chat"
+)
+
+// jsonOut receives every incoming message as one line of protobuf JSON
+// with --json-output, nil otherwise
+var jsonOut io.Writer
+
+func printJSON(msg *pb.ChatMessage) {
+	line, err := protojson.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to encode message: %v", err)
+		return
+	}
+	_, _ = jsonOut.Write(append(line, '\n'))
+}
+
+// streamUntilStopped keeps receiving until SIGINT or SIGTERM arrives or
+// the connection ends
+func streamUntilStopped(client *chatclient.Client) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case <-ctx.Done():
+	case <-client.Done():
+	}
+}
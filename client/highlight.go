@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// colorConfig names the colors of highlighted text, e.g. "yellow,bold",
+// "none" turns a highlight off
+type colorConfig struct {
+	Mention string `json:"mention"` // your own name in incoming messages
+	PM      string `json:"pm"`      // private messages
+}
+
+// palette holds the escape sequences colorConfig resolves to, empty ones
+// leave the text alone
+type palette struct {
+	mention string
+	pm      string
+}
+
+// colors is used by writeMessage, it stays empty when the output is not a
+// terminal or NO_COLOR is set
+var colors palette
+
+const colorReset = "\x1b[0m"
+
+var sgrCodes = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4", "reverse": "7",
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"bright-black": "90", "bright-red": "91", "bright-green": "92", "bright-yellow": "93",
+	"bright-blue": "94", "bright-magenta": "95", "bright-cyan": "96", "bright-white": "97",
+}
+
+// resolve turns the color names into escape sequences
+func (c colorConfig) resolve() (palette, error) {
+	mention, err := sgr(c.Mention)
+	if err != nil {
+		return palette{}, fmt.Errorf("mention color: %w", err)
+	}
+	pm, err := sgr(c.PM)
+	if err != nil {
+		return palette{}, fmt.Errorf("pm color: %w", err)
+	}
+	return palette{mention: mention, pm: pm}, nil
+}
+
+// sgr converts comma separated names like "yellow,bold" into the escape
+// sequence selecting them
+func sgr(names string) (string, error) {
+	if names == "" || names == "none" {
+		return "", nil
+	}
+	var codes []string
+	for _, name := range strings.Split(names, ",") {
+		code, ok := sgrCodes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return "", fmt.Errorf("unknown color %q", name)
+		}
+		codes = append(codes, code)
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m", nil
+}
+
+// paint wraps text in color, text is returned as is without one
+func paint(color, text string) string {
+	if color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// highlightName colors every "name" and "@name" in text that is a whole
+// word, ignoring case
+func highlightName(text, name, color string) string {
+	if color == "" || name == "" {
+		return text
+	}
+	re, err := regexp.Compile("(?i)@?" + regexp.QuoteMeta(name))
+	if err != nil {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if (start > 0 && isNameByte(text[start-1])) || (end < len(text) && isNameByte(text[end])) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(paint(color, text[start:end]))
+		last = end
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isNameByte matches the bytes the server treats as part of a mention
+func isNameByte(c byte) bool {
+	return c == '_' || c == '@' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// maxSearchResults bounds the matches /search prints
+const maxSearchResults = 50
+
+// defaultHistoryDir is where conversation logs are kept unless
+// --history-dir says otherwise
+func defaultHistoryDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "realtimechat", "history")
+}
+
+// history appends chat messages to one JSONL file per conversation, rooms
+// as "#room.jsonl" and private chats as "@user.jsonl"
+type history struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// openHistory keeps the logs of user on server below dir
+func openHistory(dir, server, user string) (*history, error) {
+	dir = filepath.Join(dir, safeName(server), safeName(user))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &history{dir: dir, files: make(map[string]*os.File)}, nil
+}
+
+var unsafeChars = strings.NewReplacer("/", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_")
+
+// safeName makes s usable as a file name on every platform
+func safeName(s string) string {
+	return unsafeChars.Replace(s)
+}
+
+// conversation names the log msg belongs to from user's point of view,
+// "" for messages that are not kept
+func conversation(msg *pb.ChatMessage, user string) string {
+	if msg.User == "System" || pb.TypeOf(msg).IsEvent() {
+		return ""
+	}
+	if msg.Text == "" && msg.Payload == nil {
+		return ""
+	}
+	if msg.RecipientUser == "" {
+		return "#" + msg.Room
+	}
+	if msg.User == user {
+		return "@" + msg.RecipientUser
+	}
+	return "@" + msg.User
+}
+
+// add appends msg to its conversation log
+func (h *history) add(msg *pb.ChatMessage, user string) error {
+	conv := conversation(msg, user)
+	if h == nil || conv == "" {
+		return nil
+	}
+	line, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, ok := h.files[conv]
+	if !ok {
+		f, err = os.OpenFile(h.path(conv), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		h.files[conv] = f
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func (h *history) path(conv string) string {
+	return filepath.Join(h.dir, safeName(conv)+".jsonl")
+}
+
+// last returns up to n of the latest messages of a conversation, oldest first
+func (h *history) last(conv string, n int) ([]*pb.ChatMessage, error) {
+	if h == nil || n <= 0 {
+		return nil, nil
+	}
+	var ring []*pb.ChatMessage
+	err := h.scan(h.path(conv), func(msg *pb.ChatMessage) {
+		if len(ring) == n {
+			ring = ring[1:]
+		}
+		ring = append(ring, msg)
+	})
+	return ring, err
+}
+
+// match is a /search result
+type match struct {
+	conv string
+	msg  *pb.ChatMessage
+}
+
+// search finds messages containing term in any conversation, ignoring
+// case, and returns the latest maxSearchResults of them oldest first
+func (h *history) search(term string) ([]match, error) {
+	paths, err := filepath.Glob(filepath.Join(h.dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	term = strings.ToLower(term)
+	var found []match
+	for _, path := range paths {
+		conv := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		err := h.scan(path, func(msg *pb.ChatMessage) {
+			text := msg.Text
+			if code := msg.GetCode(); code != nil {
+				text = code.Content
+			}
+			if strings.Contains(strings.ToLower(text), term) || strings.EqualFold(msg.User, term) {
+				found = append(found, match{conv: conv, msg: msg})
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].msg.Timestamp < found[j].msg.Timestamp })
+	if len(found) > maxSearchResults {
+		found = found[len(found)-maxSearchResults:]
+	}
+	return found, nil
+}
+
+// scan calls fn for every message in the log at path, a missing log is empty
+func (h *history) scan(path string, fn func(*pb.ChatMessage)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		msg := &pb.ChatMessage{}
+		if protojson.Unmarshal(sc.Bytes(), msg) == nil {
+			fn(msg) // a torn last line from a crash is skipped
+		}
+	}
+	return sc.Err()
+}
+
+// Close closes the open logs
+func (h *history) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conv, f := range h.files {
+		f.Close()
+		delete(h.files, conv)
+	}
+}
@@ -1,124 +1,388 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
 	"strings"
+	"time"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
+	"realTimeChat/pkg/chatclient"
+	"realTimeChat/pkg/i18n"
 	pb "realTimeChat/proto/chat"
 )
 
-func main() {
-	// 1. read username
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Enter your username: ")
-	userName, _ := reader.ReadString('\n') // read until newline
-	userName = strings.TrimSpace(userName)
-	if userName == "" {
-		log.Fatalf("Username cannot be empty")
-	}
+// locale picks the catalog for System messages from the environment
+var locale = i18n.Match(firstNonEmpty(os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")))
 
-	// 2. connect to grpc
-	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials())) // insecure for localhost
-	if err != nil {
-		log.Fatalf("Did not connect: %v", err)
+// clk prefixes messages with their local time
+var clk = &clock{now: time.Now}
+
+// out receives everything printed, the console on a terminal
+var out io.Writer = os.Stdout
+
+// userAgent identifies the client to the server
+var userAgent = fmt.Sprintf("realTimeChat-cli (%s/%s)", runtime.GOOS, runtime.GOARCH)
+
+// hist keeps the local conversation logs, nil when they are disabled
+var hist *history
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
 	}
-	defer conn.Close()
+	return ""
+}
 
-	c := pb.NewChatServiceClient(conn) // set client
+func main() {
+	os.Exit(run())
+}
 
-	// 3. RealtimeChat RPC，to get stream
-	stream, err := c.RealtimeChat(context.Background())
+// run is the client, it returns the exit status
+func run() int {
+	cfg, err := loadConfig()
 	if err != nil {
-		log.Fatalf("Could not start chat: %v", err)
+		log.Fatalf("Invalid configuration: %v", err)
 	}
-
-	// 4. send join message
-	if err := stream.Send(&pb.ChatMessage{User: userName, Text: "has joined"}); err != nil {
-		log.Fatalf("Failed to send join message: %v", err)
+	if cfg.TimeFormat != "none" {
+		clk.layout = cfg.TimeFormat
+	}
+	clk.relative = cfg.RelativeTime
+	creds, err := cfg.TLS.dialOption()
+	if err != nil {
+		log.Fatalf("Invalid TLS settings: %v", err)
+	}
+	headless := cfg.Once != "" || cfg.StdinPipe || cfg.JSONOutput
+	if headless && cfg.Username == "" {
+		log.Fatalf("--user is required with --once, --stdin-pipe and --json-output")
 	}
-	fmt.Printf("Connected as %s. Type 'exit' to quit.\n", userName)
-	fmt.Println("---------------------------------------")
-
-	// 5. start a goroutine to *receive* messages
-	waitc := make(chan struct{}) // close signal
-	go readRoutine(stream, waitc, userName)
 
-	// 6. send message
-	// for + scanner to read from stdin
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		text := scanner.Text()
-		if strings.ToLower(text) == "exit" {
-			break
+	con, err := newConsole(!headless)
+	if err != nil {
+		log.Fatalf("Could not set up the terminal: %v", err)
+	}
+	defer con.Close()
+	out = con.Out()
+	if cfg.JSONOutput {
+		out, jsonOut = os.Stderr, os.Stdout // keep stdout for the NDJSON stream
+	}
+	log.SetOutput(out)
+	if headless {
+		log.SetOutput(os.Stderr)
+	}
+	if con.Interactive() && os.Getenv("NO_COLOR") == "" {
+		colors, _ = cfg.Colors.resolve() // validated by loadConfig
+	}
+	files.gateway, files.dir, files.progress = cfg.Gateway, cfg.DownloadDir, con.Interactive()
+	att := newAttention(cfg.notifyIdle)
+	con.onKey = att.keyPressed
+	con.onFocus = att.focus
+	if cfg.LogFile != "" {
+		f, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			con.Close()
+			log.Fatalf("Could not open log file: %v", err)
 		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
 
-		recipient := "" // empty means public message
-		messageText := text
+	// 1. read username unless configured
+	userName := cfg.Username
+	if userName == "" {
+		con.SetPrompt("Enter your username: ")
+		userName, _ = con.ReadLine()
+		userName = strings.TrimSpace(userName)
+	}
+	if userName == "" {
+		con.Close()
+		log.Fatalf("Username cannot be empty")
+	}
 
-		// structure: /pm <username> <message>
-		if strings.HasPrefix(text, "/pm ") {
-			parts := strings.SplitN(text, " ", 3)
-			if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
-				fmt.Println("Invalid PM format. Use: /pm <username> <message>")
-				continue
+	if cfg.HistoryDir != "" {
+		if hist, err = openHistory(cfg.HistoryDir, cfg.Server, userName); err != nil {
+			log.Printf("Local history disabled: %v", err)
+		} else {
+			defer hist.Close()
+			if !headless {
+				showHistory("#"+cfg.Room, userName, cfg.History)
 			}
-			recipient = parts[1]
-			messageText = parts[2]
 		}
+	}
 
-		// create message
-		msg := &pb.ChatMessage{
-			User:          userName,
-			Text:          messageText,
-			RecipientUser: recipient,
+	// 2. connect and join, messages are printed as they arrive
+	client, err := chatclient.Connect(context.Background(), cfg.Server, userName,
+		chatclient.WithDialOptions(creds),
+		chatclient.WithRoom(cfg.Room),
+		chatclient.WithDevice(cfg.Device),
+		chatclient.WithUserAgent(userAgent),
+		chatclient.WithHandler(func(msg *pb.ChatMessage) {
+			if r := msg.GetRename(); r != nil && msg.RecipientUser == r.NewUser && r.OldUser == userName {
+				userName = r.NewUser // our /nick was accepted
+			}
+			printMessage(msg, userName)
+			if msg.Notify && cfg.Notify && con.Interactive() && att.away() {
+				go func() {
+					if err := desktopNotify(notification(msg)); err != nil {
+						log.Printf("Desktop notification failed: %v", err)
+					}
+				}()
+			}
+			if rc := msg.GetRoomChange(); rc != nil {
+				con.SetPrompt(prompt(rc.To))
+				if !headless {
+					showHistory("#"+rc.To, userName, cfg.History)
+					drafts.announce(rc.To)
+				}
+			}
+			if err := hist.add(msg, userName); err != nil {
+				log.Printf("Failed to write local history: %v", err)
+			}
+		}),
+		chatclient.WithStateHandler(func(state chatclient.State, err error) {
+			if state == chatclient.Reconnecting {
+				log.Printf("Connection lost (%v), reconnecting...", err)
+			}
+		}))
+	if err != nil {
+		con.Close()
+		log.Fatalf("Could not start chat: %v", err)
+	}
+	if !headless {
+		fmt.Fprintf(out, "Connected to %s as %s in #%s. Type /help for commands, 'exit' to quit.\n", cfg.Server, client.Username(), cfg.Room)
+		fmt.Fprintln(out, "---------------------------------------")
+	}
+	con.SetPrompt(prompt(cfg.Room))
+	con.complete = completer(client)
+	if !headless {
+		if err := drafts.load(client); err != nil {
+			log.Printf("Could not load drafts: %v", err)
 		}
+		drafts.announce(cfg.Room)
+		con.fill = func() string { return drafts.get("#" + currentRoom(client)) }
+	}
 
-		if err := stream.Send(msg); err != nil {
+	// 3. send the --once message, or read commands and messages until exit
+	// or end of input, or just print what arrives with --json-output alone
+	status := 0
+	switch {
+	case cfg.Once != "":
+		if err := runCommand(client, con, cfg.Once); err != nil {
 			log.Printf("Failed to send message: %v", err)
-			break
+			status = 1
 		}
+	case cfg.StdinPipe || !cfg.JSONOutput:
+		if !readCommands(client, con) {
+			status = 1
+		}
+	default:
+		streamUntilStopped(client)
 	}
 
-	// 7. close the send direction of the stream
-	if err := stream.CloseSend(); err != nil {
-		log.Printf("Failed to close send stream: %v", err)
+	// 4. leave the chat and wait for the stream to finish
+	if err := client.Close(); err != nil {
+		log.Printf("Failed to close connection: %v", err)
 	}
-
-	// 8. wait for the read goroutine to finish
-	<-waitc
-	log.Println("Disconnected.")
+	if err := client.Err(); err != nil {
+		log.Printf("Connection ended: %v", err)
+		status = 1
+	}
+	if !headless {
+		log.Println("Disconnected.")
+	}
+	return status
 }
 
-func readRoutine(stream pb.ChatService_RealtimeChatClient, waitc chan struct{}, userName string) {
+// readCommands runs input lines until exit or end of input and reports
+// whether every message could be sent
+func readCommands(client *chatclient.Client, con *console) bool {
+	ok := true
 	for {
-		msg, err := stream.Recv()
-		if err == io.EOF {
-			log.Println("Server closed the connection")
-			close(waitc)
-			return
+		text, err := con.ReadLine()
+		if err != nil {
+			return ok
+		}
+		if cmd := strings.ToLower(text); cmd == "exit" || cmd == "/quit" {
+			return ok
+		}
+		if text == "" {
+			continue
+		}
+		err = runCommand(client, con, text)
+		if errors.Is(err, chatclient.ErrNotConnected) {
+			fmt.Fprintln(out, "Not connected, message not sent.")
+			ok = false
+			continue
 		}
 		if err != nil {
-			log.Printf("Failed to receive message: %v", err)
-			close(waitc)
-			return
+			log.Printf("Failed to send message: %v", err)
+			return false
 		}
-		if msg.RecipientUser != "" {
-			// pm
-			if msg.User == userName {
-				fmt.Printf("[You to %s (PM)]: %s\n", msg.RecipientUser, msg.Text)
-			} else {
-				fmt.Printf("[%s (PM)]: %s\n", msg.User, msg.Text)
-			}
+	}
+}
+
+// showHistory prints the last n messages of a conversation from the local log
+func showHistory(conv, userName string, n int) {
+	msgs, err := hist.last(conv, n)
+	if err != nil {
+		log.Printf("Failed to read local history: %v", err)
+		return
+	}
+	for _, msg := range msgs {
+		msg.Notify = false // no bell for old messages
+		printMessage(msg, userName)
+	}
+}
+
+// prompt is shown in front of the input line on a terminal
+func prompt(room string) string {
+	return "#" + room + "> "
+}
+
+// printMessage writes msg to out in one piece, so a terminal can redraw
+// the input line below it
+func printMessage(msg *pb.ChatMessage, userName string) {
+	if jsonOut != nil {
+		printJSON(msg)
+		return
+	}
+	var b strings.Builder
+	writeMessage(&b, msg, userName)
+	if b.Len() > 0 {
+		_, _ = io.WriteString(out, b.String())
+	}
+}
+
+func writeMessage(w io.Writer, msg *pb.ChatMessage, userName string) {
+	if p := msg.GetLinkPreview(); p != nil {
+		fmt.Fprintf(w, "  ↳ %s", p.Title)
+		if p.Description != "" {
+			fmt.Fprintf(w, " - %s", p.Description)
+		}
+		fmt.Fprintf(w, " (%s)\n", p.Url)
+		return
+	}
+	if tr := msg.GetTranslation(); tr != nil {
+		fmt.Fprintf(w, "  ↳ [%s] %s\n", tr.Lang, tr.Text)
+		return
+	}
+	if e := msg.GetEdit(); e != nil {
+		if e.Done {
+			// a terminal cannot rewrite earlier lines, show the final text
+			fmt.Fprintf(w, "  ↳ [%s] %s\n", msg.User, e.Text)
+		}
+		return
+	}
+	if msg.GetSignal() != nil {
+		return // the terminal cannot take part in calls
+	}
+	if msg.Type == pb.MessageType_TYPE_ROSTER || msg.GetMember() != nil {
+		return // /who and /members list who is around
+	}
+	if msg.GetUnread() != nil || msg.GetAck() != nil {
+		return // everything printed is read, acks are bookkeeping
+	}
+	if ev := msg.GetCallEvent(); ev != nil {
+		printCall(w, ev, userName)
+		return
+	}
+	if p := msg.GetPresence(); p != nil {
+		printPresence(w, p, userName)
+		return
+	}
+	if msg.Notify {
+		fmt.Fprint(w, "\a") // ring the terminal bell for mentions and PMs
+	}
+	if st := msg.GetSystem(); st != nil {
+		msg.Text = i18n.Render(locale, st.Key, st.Args)
+	}
+	fmt.Fprint(w, clk.stamp(msg))
+	if code := msg.GetCode(); code != nil {
+		printCode(w, msg.User, code)
+		return
+	}
+	if a := msg.GetAttachment(); a != nil && a.Kind == "voice" {
+		d := time.Duration(a.DurationMs) * time.Millisecond
+		fmt.Fprintf(w, "[%s]: voice message (%s) %s\n", msg.User, d.Round(time.Second), a.Url)
+		return
+	}
+	if a := msg.GetAttachment(); a != nil && a.Kind == "gif" {
+		fmt.Fprintf(w, "[%s]: GIF %s\n", msg.User, a.Url)
+		return
+	}
+	if a := msg.GetAttachment(); a != nil && a.Kind == "file" {
+		fmt.Fprintf(w, "[%s]: file %s (%s) %s%s, /get %s\n", msg.User, a.Name, formatSize(a.Size), files.gateway, a.Url, a.Id)
+		return
+	}
+	text := msg.Text
+	if msg.User != userName && msg.User != "System" {
+		text = highlightName(text, userName, colors.mention)
+	}
+	if msg.RecipientUser != "" && msg.GetRename() == nil {
+		// pm
+		if msg.User == userName {
+			fmt.Fprintf(w, "%s: %s\n", paint(colors.pm, "[You to "+msg.RecipientUser+" (PM)]"), text)
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", paint(colors.pm, "["+msg.User+" (PM)]"), text)
+		}
+	} else if msg.EphemeralTo != "" && msg.User != "System" {
+		fmt.Fprintf(w, "[%s, only you]: %s\n", msg.User, text)
+	} else {
+		fmt.Fprintf(w, "[%s]: %s\n", msg.User, text)
+	}
+}
+
+// printCall reports call state changes, calls are answered in the web client
+func printCall(w io.Writer, ev *pb.CallEvent, userName string) {
+	peer := ev.Callee
+	if peer == userName {
+		peer = ev.Caller
+	}
+	switch ev.State {
+	case pb.CallState_CALL_RINGING:
+		if ev.Caller == userName {
+			fmt.Fprintf(w, "[System]: Calling %s...\n", peer)
 		} else {
-			fmt.Printf("[%s]: %s\n", msg.User, msg.Text)
+			fmt.Fprintf(w, "\a[System]: %s is calling you, answer in the web client\n", peer)
 		}
+	case pb.CallState_CALL_IN_CALL:
+		fmt.Fprintf(w, "[System]: In a call with %s\n", peer)
+	case pb.CallState_CALL_ENDED:
+		fmt.Fprintf(w, "[System]: Call with %s ended (%s)\n", peer, ev.Reason)
+	}
+}
+
+// printPresence reports other users joining or leaving calls
+func printPresence(w io.Writer, p *pb.Presence, userName string) {
+	if p.User == userName {
+		return
+	}
+	switch p.Status {
+	case pb.PresenceStatus_PRESENCE_IN_CALL:
+		fmt.Fprintf(w, "[System]: %s is in a call\n", p.User)
+	case pb.PresenceStatus_PRESENCE_SHARING_SCREEN:
+		fmt.Fprintf(w, "[System]: %s is sharing their screen\n", p.User)
+	case pb.PresenceStatus_PRESENCE_AVAILABLE:
+		fmt.Fprintf(w, "[System]: %s is available\n", p.User)
+	}
+}
+
+// printCode renders a code block in a box, lines are printed verbatim
+func printCode(w io.Writer, user string, code *pb.Code) {
+	title := "code"
+	if code.Language != "" {
+		title += " (" + code.Language + ")"
+	}
+	fmt.Fprintf(w, "[%s]: %s\n", user, title)
+	fmt.Fprintln(w, "┌"+strings.Repeat("─", 40))
+	for _, line := range strings.Split(strings.TrimRight(code.Content, "\n"), "\n") {
+		fmt.Fprintln(w, "│ "+line)
 	}
+	fmt.Fprintln(w, "└"+strings.Repeat("─", 40))
 }
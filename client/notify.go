@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// notifyTimeout bounds one run of the platform notifier
+const notifyTimeout = 5 * time.Second
+
+// desktopNotify shows a desktop notification with the platform's own
+// tool: notify-send on Linux and the BSDs, osascript on macOS and a toast
+// through PowerShell on Windows. Title and body are passed in the
+// environment so they are never parsed as script.
+func desktopNotify(title, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript", "-e",
+			`display notification (system attribute "CHAT_BODY") with title (system attribute "CHAT_TITLE")`)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToast)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=realTimeChat", "--", title, body)
+	}
+	cmd.Env = append(os.Environ(), "CHAT_TITLE="+title, "CHAT_BODY="+body)
+	return cmd.Run()
+}
+
+const windowsToast = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$x = $t.GetElementsByTagName('text')
+$x.Item(0).AppendChild($t.CreateTextNode($env:CHAT_TITLE)) > $null
+$x.Item(1).AppendChild($t.CreateTextNode($env:CHAT_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('realTimeChat').Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// maxNotifyBody shortens long messages in notifications
+const maxNotifyBody = 200
+
+// notification returns the title and body announcing a PM or mention
+func notification(msg *pb.ChatMessage) (title, body string) {
+	title = fmt.Sprintf("%s mentioned you in #%s", msg.User, msg.Room)
+	if msg.RecipientUser != "" {
+		title = "PM from " + msg.User
+	}
+	body = msg.Text
+	if msg.GetCode() != nil {
+		body = "sent code"
+	}
+	if r := []rune(body); len(r) > maxNotifyBody {
+		body = string(r[:maxNotifyBody]) + "…"
+	}
+	return title, body
+}
+
+// attention tracks whether the user is looking at the chat: the terminal
+// window has focus, for terminals that report it, and a key was pressed
+// within the idle time
+type attention struct {
+	mu        sync.Mutex
+	idle      time.Duration
+	lastKey   time.Time
+	unfocused bool
+}
+
+func newAttention(idle time.Duration) *attention {
+	return &attention{idle: idle, lastKey: time.Now()}
+}
+
+// keyPressed records activity
+func (a *attention) keyPressed() {
+	a.mu.Lock()
+	a.lastKey = time.Now()
+	a.mu.Unlock()
+}
+
+// focus records a focus report from the terminal
+func (a *attention) focus(focused bool) {
+	a.mu.Lock()
+	a.unfocused = !focused
+	if focused {
+		a.lastKey = time.Now()
+	}
+	a.mu.Unlock()
+}
+
+// away reports whether a notification should be shown
+func (a *attention) away() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.unfocused || (a.idle > 0 && time.Since(a.lastKey) >= a.idle)
+}
+
+// Terminals that support focus reporting send these after focusOn
+const (
+	focusOn  = "\x1b[?1004h"
+	focusOff = "\x1b[?1004l"
+)
+
+var (
+	focusIn  = []byte("\x1b[I")
+	focusOut = []byte("\x1b[O")
+)
+
+// focusReader strips focus reports from the terminal input and passes
+// them to onFocus
+type focusReader struct {
+	r       io.Reader
+	onFocus func(focused bool)
+	pending []byte // start of a report split across reads
+}
+
+func (f *focusReader) Read(p []byte) (int, error) {
+	buf := make([]byte, max(len(p)-len(f.pending), 1))
+	n, err := f.r.Read(buf)
+	data := append(f.pending, buf[:n]...)
+	f.pending = nil
+
+	out := p[:0]
+	for i := 0; i < len(data); i++ {
+		rest := data[i:]
+		switch {
+		case bytes.HasPrefix(rest, focusIn):
+			f.onFocus(true)
+			i += len(focusIn) - 1
+			continue
+		case bytes.HasPrefix(rest, focusOut):
+			f.onFocus(false)
+			i += len(focusOut) - 1
+			continue
+		case err == nil && len(rest) < len(focusIn) && bytes.HasPrefix(focusIn, rest):
+			f.pending = append([]byte(nil), rest...) // wait for the rest
+			return len(out), nil
+		}
+		out = append(out, data[i])
+	}
+	return len(out), err
+}
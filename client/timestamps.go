@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// clock renders server timestamps in the local timezone and adds a
+// separator line whenever the day changes
+type clock struct {
+	layout   string // time.Format layout, empty hides the time
+	relative bool   // "2m ago" instead of the layout
+	now      func() time.Time
+	lastDay  string
+}
+
+// stamp returns the time prefix for msg, e.g. "14:03 ", preceded by a day
+// separator line if msg is the first message of a new day. Messages
+// without a timestamp get no prefix.
+func (c *clock) stamp(msg *pb.ChatMessage) string {
+	if msg.Timestamp == 0 {
+		return ""
+	}
+	t := time.UnixMilli(msg.Timestamp).Local()
+	var sep string
+	if day := t.Format("2006-01-02"); day != c.lastDay {
+		if c.lastDay != "" || !sameDay(t, c.now()) {
+			sep = daySeparator(t) + "\n"
+		}
+		c.lastDay = day
+	}
+	switch {
+	case c.relative:
+		return sep + relativeTime(c.now().Sub(t)) + " "
+	case c.layout != "":
+		return sep + t.Format(c.layout) + " "
+	}
+	return sep
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func daySeparator(t time.Time) string {
+	label := " " + t.Format("Mon, 02 Jan 2006") + " "
+	return strings.Repeat("─", 12) + label + strings.Repeat("─", 12)
+}
+
+// relativeTime formats an age as "just now", "5m ago", "3h ago" or "2d ago"
+func relativeTime(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
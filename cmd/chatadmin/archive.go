@@ -0,0 +1,307 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// A snapshot archive is a gzipped tar of manifest.json followed by one
+// file per section, each a sequence of length-delimited SnapshotRecords.
+// The manifest records the count and SHA-256 of every section.
+const (
+	archiveFormat  = "realtimechat-snapshot"
+	archiveVersion = 1
+	manifestName   = "manifest.json"
+	maxRecordSize  = 64 << 20 // a message with its metadata, or a room with its members
+)
+
+// sections in the order they are written and restored, rooms before
+// their messages and messages before the attachments they reference
+var sections = []string{"users", "rooms", "messages", "attachments", "bans", "block_rules", "motd", "tenant_quotas"}
+
+// manifest describes an archive
+type manifest struct {
+	Format   string    `json:"format"`
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Server   string    `json:"server"`
+	Sections []section `json:"sections"`
+}
+
+type section struct {
+	Name    string `json:"name"`
+	Records int64  `json:"records"`
+	Bytes   int64  `json:"bytes"`
+	SHA256  string `json:"sha256"`
+}
+
+// sectionOf names the section of rec, "" for kinds this version does
+// not know
+func sectionOf(rec *pb.SnapshotRecord) string {
+	switch rec.Record.(type) {
+	case *pb.SnapshotRecord_User:
+		return "users"
+	case *pb.SnapshotRecord_Room:
+		return "rooms"
+	case *pb.SnapshotRecord_Message:
+		return "messages"
+	case *pb.SnapshotRecord_Attachment:
+		return "attachments"
+	case *pb.SnapshotRecord_Ban:
+		return "bans"
+	case *pb.SnapshotRecord_BlockRule:
+		return "block_rules"
+	case *pb.SnapshotRecord_Motd:
+		return "motd"
+	case *pb.SnapshotRecord_TenantQuota:
+		return "tenant_quotas"
+	}
+	return ""
+}
+
+// archiveWriter spools each section to a temporary file, the sizes and
+// checksums must be known before the archive is written
+type archiveWriter struct {
+	dir   string
+	parts map[string]*part
+}
+
+type part struct {
+	f       *os.File
+	w       *bufio.Writer
+	h       hash.Hash
+	records int64
+	bytes   int64
+}
+
+func newArchiveWriter() (*archiveWriter, error) {
+	dir, err := os.MkdirTemp("", "chatadmin-snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	return &archiveWriter{dir: dir, parts: make(map[string]*part)}, nil
+}
+
+// add appends rec to its section
+func (a *archiveWriter) add(rec *pb.SnapshotRecord) error {
+	name := sectionOf(rec)
+	if name == "" {
+		return errors.New("the server sent a record this version does not know, update chatadmin")
+	}
+	p := a.parts[name]
+	if p == nil {
+		f, err := os.Create(filepath.Join(a.dir, name))
+		if err != nil {
+			return err
+		}
+		p = &part{f: f, h: sha256.New()}
+		p.w = bufio.NewWriter(io.MultiWriter(f, p.h))
+		a.parts[name] = p
+	}
+	n, err := protodelim.MarshalTo(p.w, rec)
+	p.records++
+	p.bytes += int64(n)
+	return err
+}
+
+// counts returns the records of each section
+func (a *archiveWriter) counts() map[string]int64 {
+	out := make(map[string]int64)
+	for name, p := range a.parts {
+		out[name] = p.records
+	}
+	return out
+}
+
+// write builds the archive at path. It is written next to path and
+// renamed, an interrupted snapshot never leaves a truncated archive.
+func (a *archiveWriter) write(path, server string) (err error) {
+	m := manifest{Format: archiveFormat, Version: archiveVersion, Created: time.Now().UTC(), Server: server}
+	for _, name := range sections {
+		p := a.parts[name]
+		if p == nil {
+			continue
+		}
+		if err := p.w.Flush(); err != nil {
+			return err
+		}
+		m.Sections = append(m.Sections, section{Name: name, Records: p.records, Bytes: p.bytes, SHA256: hex.EncodeToString(p.h.Sum(nil))})
+	}
+	head, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0o640, Size: int64(len(head)), ModTime: m.Created}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(head); err != nil {
+		return err
+	}
+	for _, s := range m.Sections {
+		if err := tw.WriteHeader(&tar.Header{Name: s.Name, Mode: 0o640, Size: s.Bytes, ModTime: m.Created}); err != nil {
+			return err
+		}
+		p := a.parts[s.Name]
+		if _, err := p.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(tw, p.f, s.Bytes); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// close removes the temporary files
+func (a *archiveWriter) close() {
+	for _, p := range a.parts {
+		p.f.Close()
+	}
+	os.RemoveAll(a.dir)
+}
+
+// readArchive checks the archive at path against its manifest, calling
+// fn with every record when fn is not nil. Records reach fn before their
+// section's checksum is checked, callers that must not act on a damaged
+// archive read it once without fn first.
+func readArchive(path string, fn func(*pb.SnapshotRecord) error) (*manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a snapshot archive: %w", err)
+	}
+	tr := tar.NewReader(zr)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return nil, errors.New("not a snapshot archive: no manifest")
+	}
+	var m manifest
+	if err := json.NewDecoder(io.LimitReader(tr, 1<<20)).Decode(&m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	switch {
+	case m.Format != archiveFormat:
+		return nil, fmt.Errorf("not a snapshot archive: format %q", m.Format)
+	case m.Version > archiveVersion:
+		return nil, fmt.Errorf("archive version %d is newer than this chatadmin supports (%d)", m.Version, archiveVersion)
+	}
+
+	for _, s := range m.Sections {
+		hdr, err := tr.Next()
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, missing(err))
+		}
+		if hdr.Name != s.Name {
+			return nil, fmt.Errorf("section %s: found %s instead", s.Name, hdr.Name)
+		}
+		if err := readSection(tr, s, fn); err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+	}
+	if hdr, err := tr.Next(); err == nil {
+		return nil, fmt.Errorf("%s is not in the manifest", hdr.Name)
+	} else if !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// readSection decodes the records of one section and checks their
+// count, size and checksum
+func readSection(r io.Reader, s section, fn func(*pb.SnapshotRecord) error) error {
+	h := sha256.New()
+	cr := &countingReader{r: io.TeeReader(r, h)}
+	br := bufio.NewReader(cr)
+	opts := protodelim.UnmarshalOptions{MaxSize: maxRecordSize}
+	var records int64
+	for {
+		rec := &pb.SnapshotRecord{}
+		err := opts.UnmarshalFrom(br, rec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", records+1, missing(err))
+		}
+		records++
+		if fn != nil {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	}
+	switch sum := hex.EncodeToString(h.Sum(nil)); {
+	case records != s.Records:
+		return fmt.Errorf("%d records, the manifest says %d", records, s.Records)
+	case cr.n != s.Bytes:
+		return fmt.Errorf("%d bytes, the manifest says %d", cr.n, s.Bytes)
+	case sum != s.SHA256:
+		return fmt.Errorf("checksum %s does not match the manifest", sum)
+	}
+	return nil
+}
+
+// missing reports a truncated archive as such
+func missing(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errors.New("the archive is truncated")
+	}
+	return err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
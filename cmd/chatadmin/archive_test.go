@@ -0,0 +1,153 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// writeTestArchive writes a small snapshot archive and returns its path
+func writeTestArchive(t *testing.T) string {
+	t.Helper()
+	a, err := newArchiveWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.close()
+	for _, rec := range []*pb.SnapshotRecord{
+		{Record: &pb.SnapshotRecord_User{User: &pb.SnapshotUser{User: "alice", Contacts: []string{"bob"}}}},
+		{Record: &pb.SnapshotRecord_Message{Message: &pb.ChatMessage{Id: "m1", User: "alice", Room: "general", Text: "hello world"}}},
+		{Record: &pb.SnapshotRecord_Message{Message: &pb.ChatMessage{Id: "m2", User: "bob", Room: "general", Text: "hi alice"}}},
+		{Record: &pb.SnapshotRecord_Ban{Ban: &pb.Ban{Id: "b1", Target: "mallory", Reason: "spam"}}},
+	} {
+		if err := a.add(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	if err := a.write(path, "test"); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	path := writeTestArchive(t)
+	var texts []string
+	m, err := readArchive(path, func(rec *pb.SnapshotRecord) error {
+		if msg := rec.GetMessage(); msg != nil {
+			texts = append(texts, msg.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Format != archiveFormat || m.Server != "test" || len(m.Sections) != 3 {
+		t.Errorf("manifest = %+v", m)
+	}
+	if strings.Join(texts, "|") != "hello world|hi alice" {
+		t.Errorf("messages = %q", texts)
+	}
+}
+
+// rewriteArchive decompresses the archive at path, lets edit change the
+// data of the named tar entry and writes it back compressed, so the
+// damage is only visible to the manifest's checksums
+func rewriteArchive(t *testing.T, path, entry string, edit func([]byte)) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	zw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(zw)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == entry {
+			edit(data)
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveFlippedByte(t *testing.T) {
+	path := writeTestArchive(t)
+	rewriteArchive(t, path, "messages", func(data []byte) {
+		i := bytes.Index(data, []byte("hello"))
+		if i < 0 {
+			t.Fatal("message text not found in the section")
+		}
+		data[i] ^= 0x01 // still a valid record, only the checksum can tell
+	})
+	_, err := readArchive(path, nil)
+	if err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("readArchive of a damaged archive = %v, want a checksum error", err)
+	}
+	if !strings.Contains(err.Error(), "section messages") {
+		t.Errorf("error %q does not name the damaged section", err)
+	}
+}
+
+func TestArchiveFlippedCompressedByte(t *testing.T) {
+	path := writeTestArchive(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readArchive(path, nil); err == nil {
+		t.Fatal("readArchive of a damaged archive succeeded")
+	}
+}
+
+func TestArchiveZeroedSection(t *testing.T) {
+	path := writeTestArchive(t)
+	rewriteArchive(t, path, "bans", func(data []byte) {
+		clear(data[len(data)/2:])
+	})
+	if _, err := readArchive(path, nil); err == nil {
+		t.Fatal("readArchive of a damaged archive succeeded")
+	}
+}
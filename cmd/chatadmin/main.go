@@ -0,0 +1,108 @@
+// cmd/chatadmin administers a chat server through AdminService
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// command is one subcommand, run gets the arguments after its name
+type command struct {
+	help string
+	run  func(args []string) error
+}
+
+var commands = map[string]command{
+	"sessions":    {"list the open sessions, of one user with --user", runSessions},
+	"kick":        {"end every session of a user, or one with --session", runKick},
+	"ban":         {"ban an account or, with --ip, an address range and end its sessions", runBan},
+	"unban":       {"remove a ban by ID", runUnban},
+	"bans":        {"list the bans", runBans},
+	"announce":    {"send an announcement to a room or everyone", runAnnounce},
+	"maintenance": {"show or switch the gateway's maintenance mode", runMaintenance},
+	"rooms":       {"show the online users, members and traffic of rooms", runRooms},
+	"audit":       {"show the admin calls that changed state, -f follows new ones", runAudit},
+	"snapshot":    {"save users, rooms, messages and the attachment index to an archive", runSnapshot},
+	"restore":     {"check an archive and load it into a server", runRestore},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].help)
+	}
+}
+
+// admin holds the flags every command takes to reach the server
+type admin struct {
+	server string
+	token  string
+	output string // table or json
+}
+
+// flags creates the flag set of a command with the connection flags,
+// args describes its arguments for the usage message
+func (a *admin) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&a.server, "server", "localhost:50051", "chat server address")
+	fs.StringVar(&a.token, "admin-token", os.Getenv("CHAT_ADMIN_TOKEN"), "the chat server's admin token (default $CHAT_ADMIN_TOKEN)")
+	fs.StringVar(&a.output, "o", "table", "output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n", os.Args[0], name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// connect dials the server, the context carries the admin token and
+// names the operator for the audit log
+func (a *admin) connect(ctx context.Context) (pb.AdminServiceClient, context.Context, func(), error) {
+	if a.output != "table" && a.output != "json" {
+		return nil, nil, nil, fmt.Errorf("-o must be table or json, not %q", a.output)
+	}
+	conn, err := grpc.NewClient(a.server, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+a.token)
+	if user := actor(); user != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-admin-actor", user)
+	}
+	return pb.NewAdminServiceClient(conn), ctx, func() { conn.Close() }, nil
+}
+
+// actor names the operator running chatadmin, for the audit log and the
+// created_by of bans
+func actor() string {
+	return os.Getenv("USER")
+}
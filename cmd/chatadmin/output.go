@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// print writes m as JSON, or as a table of header and rows
+func (a *admin) print(m proto.Message, header []string, rows [][]string) error {
+	if a.output == "json" {
+		data, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(m)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printTable(header, rows)
+	return nil
+}
+
+// printLine writes m as one line of JSON, or as one row of a table
+// whose header was printed before. Rows are aligned once tw is flushed,
+// flush shows each row as it comes at the cost of alignment.
+func (a *admin) printLine(tw *tabwriter.Writer, m proto.Message, row []string, flush bool) error {
+	if a.output == "json" {
+		data, err := protojson.Marshal(m)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Fprintln(tw, strings.Join(row, "\t"))
+	if flush {
+		return tw.Flush()
+	}
+	return nil
+}
+
+func printTable(header []string, rows [][]string) {
+	tw := newTable()
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
+
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+}
+
+// when formats a Unix millisecond time for a table, "-" for none
+func when(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Local().Format("2006-01-02 15:04:05")
+}
+
+// dash stands in for empty table cells
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// runAnnounce sends an announcement
+func runAnnounce(args []string) error {
+	var a admin
+	fs := a.flags("announce", "<text>")
+	room := fs.String("room", "", "send to this room only, every connection when empty")
+	fs.Parse(args)
+	text := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(text) == "" {
+		fs.Usage()
+		return errors.New("a text is required")
+	}
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	res, err := client.Announce(ctx, &pb.AnnounceRequest{Room: *room, Text: text})
+	if err != nil {
+		return err
+	}
+	return a.print(res, []string{"CONNECTIONS"}, [][]string{{strconv.Itoa(int(res.Connections))}})
+}
+
+// runRooms shows the statistics of rooms
+func runRooms(args []string) error {
+	var a admin
+	fs := a.flags("rooms", "[room]")
+	fs.Parse(args)
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	list, err := client.ListRoomStats(ctx, &pb.RoomStatsRequest{Room: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, r := range list.Rooms {
+		private := ""
+		if r.Private {
+			private = "yes"
+		}
+		rows = append(rows, []string{"#" + r.Room, strconv.Itoa(int(r.Online)), strconv.Itoa(int(r.Members)), strconv.FormatUint(r.Seq, 10), strconv.FormatInt(r.Messages_24H, 10), dash(private)})
+	}
+	return a.print(list, []string{"ROOM", "ONLINE", "MEMBERS", "SEQ", "MESSAGES 24H", "PRIVATE"}, rows)
+}
+
+// runAudit shows the audit log, following it with -f until interrupted
+func runAudit(args []string) error {
+	var a admin
+	fs := a.flags("audit", "")
+	limit := fs.Int("n", 20, "how many recent entries to show first")
+	follow := fs.Bool("f", false, "keep showing new entries")
+	fs.Parse(args)
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	stream, err := client.TailAuditLog(ctx, &pb.AuditLogRequest{Limit: int32(*limit), Follow: *follow})
+	if err != nil {
+		return err
+	}
+	tw := newTable()
+	if a.output == "table" {
+		fmt.Fprintln(tw, "TIME\tMETHOD\tRESULT\tACTOR\tPEER\tREQUEST")
+	}
+	for {
+		e, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			tw.Flush()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		row := []string{when(e.Time), e.Method, e.Code, dash(e.Actor), dash(e.Peer), dash(e.Request)}
+		if err := a.printLine(tw, e, row, *follow); err != nil {
+			return err
+		}
+	}
+}
+
+// maintenance is the gateway's maintenance mode as its admin API has it
+type maintenance struct {
+	Enabled bool      `json:"enabled"`
+	Message string    `json:"message,omitempty"`
+	DrainAt time.Time `json:"drainAt,omitzero"`
+	DrainIn string    `json:"drainIn,omitempty"`
+}
+
+// runMaintenance shows or switches maintenance mode, which the gateway
+// holds, through its HTTP admin API
+func runMaintenance(args []string) error {
+	var a admin
+	fs := a.flags("maintenance", "[on|off]")
+	gateway := fs.String("gateway", "http://localhost:8080", "gateway address, maintenance mode is the gateway's")
+	message := fs.String("message", "", "notice shown to clients when switching on")
+	drainIn := fs.Duration("drain-in", 0, "close every WebSocket after this long when switching on, 0 keeps them open")
+	fs.Parse(args)
+	if a.output != "table" && a.output != "json" {
+		return fmt.Errorf("-o must be table or json, not %q", a.output)
+	}
+
+	method, body := http.MethodGet, []byte(nil)
+	switch fs.Arg(0) {
+	case "":
+	case "on", "off":
+		m := maintenance{Enabled: fs.Arg(0) == "on", Message: *message}
+		if *drainIn > 0 {
+			m.DrainIn = drainIn.String()
+		}
+		method = http.MethodPut
+		body, _ = json.Marshal(m)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown mode %q", fs.Arg(0))
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(*gateway, "/")+"/api/admin/maintenance", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gateway: %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	if a.output == "json" {
+		fmt.Println(string(bytes.TrimSpace(data)))
+		return nil
+	}
+	var m maintenance
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("gateway: %w", err)
+	}
+	drain := "-"
+	if !m.DrainAt.IsZero() {
+		drain = m.DrainAt.Local().Format("2006-01-02 15:04:05")
+	}
+	enabled := "off"
+	if m.Enabled {
+		enabled = "on"
+	}
+	printTable([]string{"MAINTENANCE", "DRAIN AT", "MESSAGE"}, [][]string{{enabled, drain, dash(m.Message)}})
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// runSnapshot saves the server's state to an archive
+func runSnapshot(args []string) error {
+	var a admin
+	fs := a.flags("snapshot", "<archive>")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("an archive path is required")
+	}
+	path := fs.Arg(0)
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	stream, err := client.Snapshot(ctx, &pb.SnapshotRequest{})
+	if err != nil {
+		return err
+	}
+	w, err := newArchiveWriter()
+	if err != nil {
+		return err
+	}
+	defer w.close()
+	var n int64
+	for {
+		rec, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("after %d records: %w", n, err)
+		}
+		if err := w.add(rec); err != nil {
+			return err
+		}
+		if n++; n%100000 == 0 {
+			log.Printf("Received %d records", n)
+		}
+	}
+	if err := w.write(path, a.server); err != nil {
+		return err
+	}
+	counts := w.counts()
+	for _, name := range sections {
+		if counts[name] > 0 {
+			fmt.Printf("%-14s %d\n", name, counts[name])
+		}
+	}
+	fmt.Printf("Saved %d records to %s\n", n, path)
+	return nil
+}
+
+// runRestore checks every section of an archive, then streams it to the
+// server. Nothing is sent when any check fails.
+func runRestore(args []string) error {
+	var a admin
+	fs := a.flags("restore", "<archive>")
+	dryRun := fs.Bool("dry-run", false, "check the archive, print what it holds and stop")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("an archive path is required")
+	}
+	path := fs.Arg(0)
+
+	m, err := readArchive(path, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Snapshot of %s taken %s, version %d\n", m.Server, m.Created.Local().Format("2006-01-02 15:04:05"), m.Version)
+	for _, s := range m.Sections {
+		fmt.Printf("%-14s %d\n", s.Name, s.Records)
+	}
+	if *dryRun {
+		return nil
+	}
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	stream, err := client.Restore(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := readArchive(path, stream.Send); err != nil {
+		// a refused stream reports why on close
+		if _, cerr := stream.CloseAndRecv(); cerr != nil {
+			err = cerr
+		}
+		return err
+	}
+	sum, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d users, %d rooms, %d messages, %d attachments, %d bans and %d block rules, the server skipped %d records\n",
+		sum.Users, sum.Rooms, sum.Messages, sum.Attachments, sum.Bans, sum.BlockRules, sum.Skipped)
+	return nil
+}
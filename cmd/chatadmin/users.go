@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// runSessions lists the open sessions
+func runSessions(args []string) error {
+	var a admin
+	fs := a.flags("sessions", "")
+	user := fs.String("user", "", "only the sessions of this user")
+	fs.Parse(args)
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	list, err := client.ListSessions(ctx, &pb.ListSessionsRequest{User: *user})
+	if err != nil {
+		return err
+	}
+	return a.printSessions(list)
+}
+
+func (a *admin) printSessions(list *pb.SessionList) error {
+	var rows [][]string
+	for _, s := range list.Sessions {
+		ip := s.Ip
+		if s.ForwardedFor != "" {
+			ip = s.ForwardedFor + " via " + ip
+		}
+		rows = append(rows, []string{s.Id, s.User, "#" + s.Room, when(s.ConnectedAt), dash(ip), dash(s.Device), dash(s.UserAgent)})
+	}
+	return a.print(list, []string{"ID", "USER", "ROOM", "CONNECTED", "ADDRESS", "DEVICE", "USER AGENT"}, rows)
+}
+
+// runKick ends the sessions of a user, they may connect again
+func runKick(args []string) error {
+	var a admin
+	fs := a.flags("kick", "<user>")
+	session := fs.String("session", "", "end only this session")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a user is required")
+	}
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	list, err := client.RevokeSession(ctx, &pb.RevokeSessionRequest{User: fs.Arg(0), Id: *session})
+	if err != nil {
+		return err
+	}
+	return a.printSessions(list)
+}
+
+// runBan bans an account or an address range
+func runBan(args []string) error {
+	var a admin
+	fs := a.flags("ban", "<user or address>")
+	ip := fs.Bool("ip", false, "ban an IP address or CIDR range instead of an account")
+	reason := fs.String("reason", "", "why, shown to the banned user (required)")
+	duration := fs.Duration("duration", 0, "how long, e.g. 24h, 0 bans for good")
+	fs.Parse(args)
+	if fs.NArg() != 1 || strings.TrimSpace(*reason) == "" {
+		fs.Usage()
+		return errors.New("a target and --reason are required")
+	}
+	req := &pb.CreateBanRequest{Target: fs.Arg(0), Reason: *reason, DurationSeconds: int64(*duration / time.Second), CreatedBy: actor()}
+	if *ip {
+		req.Scope = pb.BanScope_BAN_IP
+	}
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	ban, err := client.CreateBan(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printBans(&pb.BanList{Bans: []*pb.Ban{ban}})
+}
+
+// runUnban removes a ban
+func runUnban(args []string) error {
+	var a admin
+	fs := a.flags("unban", "<ban ID>")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a ban ID is required")
+	}
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	ban, err := client.RemoveBan(ctx, &pb.BanRequest{Id: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	return a.printBans(&pb.BanList{Bans: []*pb.Ban{ban}})
+}
+
+// runBans lists the bans
+func runBans(args []string) error {
+	var a admin
+	fs := a.flags("bans", "")
+	target := fs.String("target", "", "only the bans of this user or address")
+	fs.Parse(args)
+
+	client, ctx, done, err := a.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer done()
+	list, err := client.ListBans(ctx, &pb.ListBansRequest{Target: *target})
+	if err != nil {
+		return err
+	}
+	return a.printBans(list)
+}
+
+func (a *admin) printBans(list *pb.BanList) error {
+	var rows [][]string
+	for _, b := range list.Bans {
+		scope := "account"
+		if b.Scope == pb.BanScope_BAN_IP {
+			scope = "ip"
+		}
+		expires := when(b.ExpiresAt)
+		if b.ExpiresAt == 0 {
+			expires = "never"
+		}
+		rows = append(rows, []string{b.Id, scope, b.Target, when(b.CreatedAt), expires, dash(b.CreatedBy), b.Reason})
+	}
+	return a.print(list, []string{"ID", "SCOPE", "TARGET", "CREATED", "EXPIRES", "BY", "REASON"}, rows)
+}
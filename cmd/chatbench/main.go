@@ -0,0 +1,499 @@
+// cmd/chatbench measures the hot paths of the gateway and the chat server
+// on this machine: fan-out to WebSocket clients, gRPC broadcast, frame
+// encoding and how the per-socket send queues hold up with clients that
+// stop reading. Each benchmark runs a chat server and a gateway in
+// process on loopback. Results are printed in the go test -bench format,
+// so runs before and after a change compare with benchstat.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/chatclient"
+	"realTimeChat/pkg/chatserver"
+	"realTimeChat/pkg/gateway"
+	pb "realTimeChat/proto/chat"
+)
+
+// marker is in the text of every benchmark message, receivers count it
+const marker = "bench-msg"
+
+// warmup is sent until every receiver got it, they have joined then
+const warmup = "bench-warmup"
+
+// stallTimeout ends waiting for deliveries that stopped arriving, the
+// missing ones are reported as lost
+const stallTimeout = 5 * time.Second
+
+// benchmark is one named measurement. testing.Benchmark calls fn with
+// growing b.N, so clients are set up on the first call and kept for the
+// next ones until close. An error ends the benchmark, b.Fatal only works
+// under go test.
+type benchmark struct {
+	name  string
+	fn    func(b *testing.B) error
+	close func()
+}
+
+// cleanup collects what a benchmark set up, closed in reverse after its
+// last run
+type cleanup []func()
+
+func (c *cleanup) add(fn func()) { *c = append(*c, fn) }
+
+func (c *cleanup) run() {
+	for i := len(*c) - 1; i >= 0; i-- {
+		(*c)[i]()
+	}
+	*c = nil
+}
+
+func main() {
+	clientsFlag := flag.String("clients", "10,100,1000", "comma separated receiver counts of the fan-out and broadcast benchmarks")
+	transportsFlag := flag.String("transports", "pumps,epoll", "comma separated gateway WebSocket transports to measure")
+	slowShare := flag.Float64("slow", 0.1, "share of receivers that never read in the slow client benchmarks")
+	run := flag.String("run", ".", "only run benchmarks whose name matches this regexp, like go test -bench")
+	batching := flag.Bool("write-batching", false, "batch the chat server's writes with chatserver.DefaultWriteBatching")
+	verbose := flag.Bool("v", false, "keep the logs of the chat server and the gateway")
+	flag.Parse()
+
+	pattern, err := regexp.Compile(*run)
+	if err != nil {
+		log.Fatalf("--run: %v", err)
+	}
+	var counts []int
+	for _, s := range strings.Split(*clientsFlag, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 1 {
+			log.Fatalf("--clients: invalid count %q", s)
+		}
+		counts = append(counts, n)
+	}
+	if *slowShare < 0 || *slowShare >= 1 {
+		log.Fatalf("--slow must be at least 0 and below 1")
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *batching {
+		serverOpts = append(serverOpts, chatserver.WithWriteBatching(chatserver.DefaultWriteBatching))
+	}
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	benchmarks := []benchmark{
+		{name: "BenchmarkEncode/json", fn: benchEncodeJSON},
+		{name: "BenchmarkEncode/protobuf", fn: benchEncodeProto},
+		{name: "BenchmarkDecode/json", fn: benchDecodeJSON},
+		{name: "BenchmarkDecode/protobuf", fn: benchDecodeProto},
+	}
+	for _, n := range counts {
+		benchmarks = append(benchmarks, benchBroadcast(n))
+	}
+	for _, t := range strings.Split(*transportsFlag, ",") {
+		transport := gateway.Transport(strings.TrimSpace(t))
+		for _, n := range counts {
+			benchmarks = append(benchmarks, benchFanout(transport, n, 0))
+		}
+		for _, n := range counts {
+			slow := int(float64(n) * *slowShare)
+			if slow == 0 {
+				continue
+			}
+			benchmarks = append(benchmarks, benchFanout(transport, n, slow))
+		}
+	}
+
+	fmt.Printf("goos: %s\ngoarch: %s\npkg: realTimeChat/cmd/chatbench\n", runtime.GOOS, runtime.GOARCH)
+	procs := runtime.GOMAXPROCS(0)
+	for _, bm := range benchmarks {
+		if !pattern.MatchString(bm.name) {
+			continue
+		}
+		var err error
+		r := testing.Benchmark(func(b *testing.B) {
+			if err == nil {
+				err = bm.fn(b)
+			}
+		})
+		if bm.close != nil {
+			bm.close()
+		}
+		if err != nil {
+			fmt.Printf("--- FAIL: %s: %v\n", bm.name, err)
+			continue
+		}
+		fmt.Printf("%s-%d\t%s\t%s\n", bm.name, procs, r.String(), r.MemString())
+	}
+}
+
+// sample is a typical room message as the gateway and the server see it
+func sample() (*gateway.WSMessage, *pb.ChatMessage) {
+	now := time.Now()
+	text := "Did anyone look at the flaky deploy yesterday? @bob thinks it is the cache warmup again"
+	ws := &gateway.WSMessage{
+		Type:      "chat",
+		ID:        "01J9Z3K6Q8W2V5T7X1Y4B6N8M0",
+		Room:      "general",
+		Seq:       4211,
+		User:      "alice",
+		Text:      text,
+		Timestamp: now.Format(time.RFC3339),
+	}
+	msg := &pb.ChatMessage{
+		Id:        ws.ID,
+		Room:      ws.Room,
+		Seq:       ws.Seq,
+		User:      ws.User,
+		Text:      text,
+		Timestamp: now.UnixMilli(),
+		Type:      pb.MessageType_TYPE_CHAT,
+	}
+	return ws, msg
+}
+
+func benchEncodeJSON(b *testing.B) error {
+	ws, _ := sample()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := json.Marshal(ws); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func benchEncodeProto(b *testing.B) error {
+	_, msg := sample()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := proto.Marshal(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func benchDecodeJSON(b *testing.B) error {
+	ws, _ := sample()
+	data, _ := json.Marshal(ws)
+	b.ReportAllocs()
+	for b.Loop() {
+		var out gateway.WSMessage
+		if err := json.Unmarshal(data, &out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func benchDecodeProto(b *testing.B) error {
+	_, msg := sample()
+	data, _ := proto.Marshal(msg)
+	b.ReportAllocs()
+	for b.Loop() {
+		var out pb.ChatMessage
+		if err := proto.Unmarshal(data, &out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serverOpts configure the chat server of every benchmark
+var serverOpts []chatserver.Option
+
+// env is a chat server and a gateway in front of it
+type env struct {
+	server   *chatserver.ChatServer
+	gateway  *gateway.Gateway
+	http     *http.Server
+	grpcAddr string
+	wsURL    string
+}
+
+func startEnv(transport gateway.Transport) (*env, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	e := &env{server: chatserver.NewChatServer(serverOpts...), grpcAddr: lis.Addr().String()}
+	go e.server.Serve(lis)
+	if transport == "" {
+		return e, nil
+	}
+
+	hl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		e.server.Stop()
+		return nil, err
+	}
+	e.gateway = gateway.New(gateway.WithUpstream(e.grpcAddr), gateway.WithTransport(transport))
+	e.http = &http.Server{Handler: e.gateway.Handler()}
+	go e.http.Serve(hl)
+	e.wsURL = "ws://" + hl.Addr().String() + "/ws"
+	return e, nil
+}
+
+func (e *env) close() {
+	if e.gateway != nil {
+		e.http.Close()
+		e.gateway.Close()
+	}
+	e.server.Stop()
+}
+
+// receiver counts the benchmark messages delivered to one client
+type receiver struct {
+	got    atomic.Int64
+	joined atomic.Bool // got the warmup message
+}
+
+// count records a delivered message text or frame
+func (r *receiver) count(data []byte) {
+	if bytes.Contains(data, []byte(warmup)) {
+		r.joined.Store(true)
+	}
+	r.got.Add(int64(bytes.Count(data, []byte(marker))))
+}
+
+// awaitJoined sends the warmup message until every receiver got it
+func awaitJoined(receivers []*receiver, send func(text string) error) error {
+	deadline := time.Now().Add(stallTimeout)
+	for {
+		if err := send(warmup); err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+		joined := 0
+		for _, r := range receivers {
+			if r.joined.Load() {
+				joined++
+			}
+		}
+		if joined == len(receivers) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d of %d readers did not join", len(receivers)-joined, len(receivers))
+		}
+	}
+}
+
+// waitFor waits until every receiver got want messages, or deliveries
+// stalled. It returns how many are missing.
+func waitFor(receivers []*receiver, want []int64) int64 {
+	var last int64 = -1
+	progress := time.Now()
+	for {
+		var missing int64
+		for i, r := range receivers {
+			missing += max(want[i]-r.got.Load(), 0)
+		}
+		if missing == 0 {
+			return 0
+		}
+		if missing != last {
+			last, progress = missing, time.Now()
+		} else if time.Since(progress) > stallTimeout {
+			return missing
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// snapshot returns what every receiver got so far plus n
+func snapshot(receivers []*receiver, n int) []int64 {
+	want := make([]int64, len(receivers))
+	for i, r := range receivers {
+		want[i] = r.got.Load() + int64(n)
+	}
+	return want
+}
+
+// dialWS joins a WebSocket client as user. A reading client counts the
+// benchmark messages into r, the others never read.
+func dialWS(url, user string, r *receiver) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": {gateway.Subprotocol}})
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.WriteJSON(gateway.WSMessage{Type: "join", User: user, Text: "has joined"}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if r != nil {
+		go func() {
+			for {
+				_, data, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				r.count(data)
+			}
+		}()
+	}
+	return conn, nil
+}
+
+// benchFanout measures a message sent through the gateway until it
+// reached every reading client. slow of the clients never read, so
+// their queues fill up and shed.
+func benchFanout(transport gateway.Transport, clients, slow int) benchmark {
+	name := fmt.Sprintf("BenchmarkFanout/transport=%s/clients=%d", transport, clients)
+	if slow > 0 {
+		name = fmt.Sprintf("BenchmarkSlowClients/transport=%s/clients=%d/slow=%d", transport, clients, slow)
+	}
+	var (
+		done      cleanup
+		e         *env
+		sender    *websocket.Conn
+		receivers []*receiver
+		seq       int
+	)
+	setup := func() error {
+		var err error
+		if e, err = startEnv(transport); err != nil {
+			return err
+		}
+		done.add(e.close)
+		for i := range clients {
+			var r *receiver
+			if i >= slow {
+				r = &receiver{}
+				receivers = append(receivers, r)
+			}
+			conn, err := dialWS(e.wsURL, fmt.Sprintf("reader%d", i), r)
+			if err != nil {
+				return err
+			}
+			done.add(func() { conn.Close() })
+		}
+		if sender, err = dialWS(e.wsURL, "sender", &receiver{}); err != nil {
+			return err
+		}
+		done.add(func() { sender.Close() })
+		return awaitJoined(receivers, func(text string) error {
+			return sender.WriteJSON(gateway.WSMessage{Type: "chat", User: "sender", Text: text})
+		})
+	}
+
+	fn := func(b *testing.B) error {
+		if e == nil {
+			if err := setup(); err != nil {
+				return err
+			}
+		}
+		want := snapshot(receivers, b.N)
+		shed := e.gateway.HubStats().Shed
+		b.ResetTimer()
+		for range b.N {
+			seq++
+			msg := gateway.WSMessage{Type: "chat", User: "sender", Text: marker + " " + strconv.Itoa(seq)}
+			if err := sender.WriteJSON(msg); err != nil {
+				return err
+			}
+		}
+		lost := waitFor(receivers, want)
+		b.StopTimer()
+		b.ReportMetric(float64(b.N*len(receivers))/b.Elapsed().Seconds(), "deliveries/s")
+		b.ReportMetric(float64(lost)/float64(b.N), "lost/op")
+		if slow > 0 {
+			b.ReportMetric(float64(e.gateway.HubStats().Shed-shed)/float64(b.N), "shed/op")
+		}
+		return nil
+	}
+	return benchmark{name: name, fn: fn, close: done.run}
+}
+
+// benchBroadcast measures a message sent over gRPC until it reached
+// every chatclient, without a gateway
+func benchBroadcast(clients int) benchmark {
+	var (
+		done      cleanup
+		e         *env
+		sender    *chatclient.Client
+		receivers []*receiver
+		seq       int
+	)
+	setup := func() error {
+		var err error
+		if e, err = startEnv(""); err != nil {
+			return err
+		}
+		done.add(e.close)
+		conn, err := grpc.NewClient(e.grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		done.add(func() { conn.Close() })
+		connect := func(user string, r *receiver) (*chatclient.Client, error) {
+			opts := []chatclient.Option{chatclient.WithConn(conn), chatclient.WithReconnect(false), chatclient.WithHeartbeat(0, 0)}
+			if r != nil {
+				opts = append(opts, chatclient.WithHandler(func(msg *pb.ChatMessage) {
+					r.count([]byte(msg.Text))
+				}))
+			}
+			c, err := chatclient.Connect(context.Background(), e.grpcAddr, user, opts...)
+			if err != nil {
+				return nil, err
+			}
+			done.add(func() { c.Close() })
+			return c, nil
+		}
+		for i := range clients {
+			r := &receiver{}
+			receivers = append(receivers, r)
+			if _, err := connect(fmt.Sprintf("reader%d", i), r); err != nil {
+				return err
+			}
+		}
+		if sender, err = connect("sender", nil); err != nil {
+			return err
+		}
+		return awaitJoined(receivers, sender.Send)
+	}
+
+	fn := func(b *testing.B) error {
+		if e == nil {
+			if err := setup(); err != nil {
+				return err
+			}
+		}
+		want := snapshot(receivers, b.N)
+		b.ResetTimer()
+		for range b.N {
+			seq++
+			if err := sender.Send(marker + " " + strconv.Itoa(seq)); err != nil {
+				return err
+			}
+		}
+		lost := waitFor(receivers, want)
+		b.StopTimer()
+		b.ReportMetric(float64(b.N*len(receivers))/b.Elapsed().Seconds(), "deliveries/s")
+		b.ReportMetric(float64(lost)/float64(b.N), "lost/op")
+		return nil
+	}
+	return benchmark{name: fmt.Sprintf("BenchmarkGRPCBroadcast/clients=%d", clients), fn: fn, close: done.run}
+}
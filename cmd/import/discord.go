@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// discordChannel is the "channel" object of a DiscordChatExporter file
+type discordChannel struct {
+	Name string `json:"name"`
+}
+
+// discordMessage is an entry of its "messages" array
+type discordMessage struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Content   string    `json:"content"`
+	Author    struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Nickname string `json:"nickname"`
+	} `json:"author"`
+	Attachments []struct {
+		URL      string `json:"url"`
+		FileName string `json:"fileName"`
+	} `json:"attachments"`
+}
+
+// readDiscord reads DiscordChatExporter JSON, one file per channel or a
+// directory of them
+func readDiscord(p string, _ func(id, name string) string, emit func(record) error) error {
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return readDiscordFile(p, emit)
+	}
+	files, err := filepath.Glob(filepath.Join(p, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if err := readDiscordFile(f, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDiscordFile decodes messages one at a time, channel exports can be
+// large
+func readDiscordFile(name string, emit func(record) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	fail := func(err error) error { return fmt.Errorf("%s: %w", name, err) }
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fail(errors.New("not a DiscordChatExporter JSON file"))
+	}
+	var channel discordChannel
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fail(err)
+		}
+		switch tok {
+		case "channel":
+			if err := dec.Decode(&channel); err != nil {
+				return fail(err)
+			}
+		case "messages":
+			if channel.Name == "" {
+				return fail(errors.New(`"messages" before "channel"`))
+			}
+			if _, err := dec.Token(); err != nil {
+				return fail(err)
+			}
+			for dec.More() {
+				var m discordMessage
+				if err := dec.Decode(&m); err != nil {
+					return fail(err)
+				}
+				if m.Type != "Default" && m.Type != "Reply" {
+					continue // pins, joins, calls and the like
+				}
+				text := m.Content
+				for _, a := range m.Attachments {
+					text = strings.TrimSpace(text + "\n" + a.FileName + " " + a.URL)
+				}
+				err := emit(record{
+					Room:     channel.Name,
+					User:     m.Author.ID,
+					Name:     firstNonEmpty(m.Author.Nickname, m.Author.Name),
+					Text:     text,
+					Time:     m.Timestamp,
+					SourceID: m.ID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return fail(err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	return nil
+}
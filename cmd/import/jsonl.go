@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// jsonlMessage is one line of a generic export, timestamp is RFC 3339 or
+// Unix milliseconds
+type jsonlMessage struct {
+	ID        string            `json:"id"`
+	Room      string            `json:"room"`
+	User      string            `json:"user"`
+	Text      string            `json:"text"`
+	Timestamp json.RawMessage   `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// readJSONL reads one message object per line, blank lines are skipped
+func readJSONL(p string, _ func(id, name string) string, emit func(record) error) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var m jsonlMessage
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			return fmt.Errorf("%s:%d: %w", p, line, err)
+		}
+		t, err := jsonlTime(m.Timestamp)
+		if err != nil {
+			return fmt.Errorf("%s:%d: timestamp: %w", p, line, err)
+		}
+		err = emit(record{Room: m.Room, User: m.User, Text: m.Text, Time: t, SourceID: m.ID, Metadata: m.Metadata})
+		if err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func jsonlTime(raw json.RawMessage) (time.Time, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return time.Parse(time.RFC3339, s)
+	}
+	ms, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want RFC 3339 or Unix milliseconds, got %s", raw)
+	}
+	return time.UnixMilli(ms), nil
+}
@@ -0,0 +1,203 @@
+// cmd/import copies history exported from other chat platforms into a
+// chat server's message store through AdminService.ImportMessages
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// record is one message read from an export
+type record struct {
+	Room     string // channel name on the source platform
+	User     string // user ID or name on the source platform
+	Name     string // display name, used when User is not mapped
+	Text     string
+	Time     time.Time
+	SourceID string            // ID on the source platform, kept in metadata
+	Metadata map[string]string // carried over from generic JSONL
+}
+
+// readers parse one export format, emit is called for every message.
+// username maps a user for mentions in the text.
+var readers = map[string]func(path string, username func(id, name string) string, emit func(record) error) error{
+	"slack":   readSlack,
+	"discord": readDiscord,
+	"jsonl":   readJSONL,
+}
+
+func main() {
+	format := flag.String("format", "", "export format: slack (directory or zip), discord (DiscordChatExporter JSON file or directory) or jsonl")
+	server := flag.String("server", "localhost:50051", "chat server address")
+	token := flag.String("admin-token", os.Getenv("CHAT_ADMIN_TOKEN"), "the chat server's admin token (default $CHAT_ADMIN_TOKEN)")
+	usersFile := flag.String("users", "", `JSON object mapping source user IDs or names to usernames, e.g. {"U024BE7LH": "alice"}`)
+	roomsFile := flag.String("rooms", "", `JSON object mapping source channels to rooms, e.g. {"dev-team": "dev"}`)
+	dryRun := flag.Bool("dry-run", false, "read and map the export, print what would be imported and stop")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s --format slack|discord|jsonl [flags] <path>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	read, ok := readers[*format]
+	if !ok || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	m := &mapper{users: map[string]string{}, rooms: map[string]string{}}
+	if err := loadMap(*usersFile, m.users); err != nil {
+		log.Fatalf("--users: %v", err)
+	}
+	if err := loadMap(*roomsFile, m.rooms); err != nil {
+		log.Fatalf("--rooms: %v", err)
+	}
+
+	var send func(*pb.ChatMessage) error
+	var finish func() (*pb.ImportSummary, error)
+	counts := map[string]int{}
+	if *dryRun {
+		send = func(msg *pb.ChatMessage) error { counts[msg.Room]++; return nil }
+	} else {
+		conn, err := grpc.NewClient(*server, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("Failed to connect: %v", err)
+		}
+		defer conn.Close()
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+*token)
+		stream, err := pb.NewAdminServiceClient(conn).ImportMessages(ctx)
+		if err != nil {
+			log.Fatalf("Failed to start import: %v", err)
+		}
+		send, finish = stream.Send, stream.CloseAndRecv
+	}
+
+	sent := 0
+	username := func(id, name string) string { return m.user(record{User: id, Name: name}) }
+	err := read(flag.Arg(0), username, func(r record) error {
+		msg, ok := m.message(r, *format)
+		if !ok {
+			return nil
+		}
+		if err := send(msg); err != nil {
+			return err
+		}
+		if sent++; sent%10000 == 0 {
+			log.Printf("Read %d messages", sent)
+		}
+		return nil
+	})
+	if err != nil && finish != nil {
+		// a refused stream reports why on close
+		if _, cerr := finish(); cerr != nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		log.Fatalf("Import failed after %d messages: %v", sent, err)
+	}
+
+	if *dryRun {
+		rooms := make([]string, 0, len(counts))
+		for room := range counts {
+			rooms = append(rooms, room)
+		}
+		sort.Strings(rooms)
+		for _, room := range rooms {
+			fmt.Printf("#%s\t%d\n", room, counts[room])
+		}
+		fmt.Printf("%d messages, %d users\n", sent, len(m.seen))
+		return
+	}
+	summary, err := finish()
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	fmt.Printf("Imported %d messages, the server skipped %d\n", summary.Imported, summary.Skipped)
+}
+
+// loadMap reads a JSON object of strings into m
+func loadMap(path string, m map[string]string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &m)
+}
+
+// mapper turns source users and channels into valid names
+type mapper struct {
+	users map[string]string
+	rooms map[string]string
+	seen  map[string]bool // usernames produced, for the dry run
+}
+
+var roomUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func (m *mapper) message(r record, source string) (*pb.ChatMessage, bool) {
+	user := m.user(r)
+	room := m.room(r.Room)
+	text := strings.TrimSpace(r.Text)
+	if user == "" || room == "" || text == "" || r.Time.IsZero() {
+		return nil, false
+	}
+	md := map[string]string{"import.source": source}
+	if r.SourceID != "" {
+		md["import.id"] = r.SourceID
+	}
+	for k, v := range r.Metadata {
+		md[k] = v
+	}
+	if m.seen == nil {
+		m.seen = map[string]bool{}
+	}
+	m.seen[user] = true
+	return &pb.ChatMessage{User: user, Room: room, Text: text, Timestamp: r.Time.UnixMilli(), Metadata: md}, true
+}
+
+// user maps by ID, then by display name, and otherwise uses the display
+// name without whitespace
+func (m *mapper) user(r record) string {
+	if u, ok := m.users[r.User]; ok {
+		return u
+	}
+	if u, ok := m.users[r.Name]; ok {
+		return u
+	}
+	name := r.Name
+	if name == "" {
+		name = r.User
+	}
+	return strings.Join(strings.FieldsFunc(name, unicode.IsSpace), "_")
+}
+
+// room maps a channel name, unmapped names are lowercased and stripped
+// of characters rooms cannot have
+func (m *mapper) room(channel string) string {
+	if r, ok := m.rooms[channel]; ok {
+		return r
+	}
+	room := roomUnsafe.ReplaceAllString(strings.ToLower(channel), "-")
+	room = strings.TrimLeft(room, "_-")
+	if len(room) > 32 {
+		room = room[:32]
+	}
+	return room
+}
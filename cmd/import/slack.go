@@ -0,0 +1,183 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"html"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackUser is an entry of users.json
+type slackUser struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Profile struct {
+		DisplayName string `json:"display_name"`
+		RealName    string `json:"real_name"`
+	} `json:"profile"`
+}
+
+// slackMessage is an entry of a channel's daily file
+type slackMessage struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	User     string `json:"user"`
+	Username string `json:"username"` // bots
+	Text     string `json:"text"`
+	TS       string `json:"ts"`
+	Files    []struct {
+		Name      string `json:"name"`
+		Permalink string `json:"permalink"`
+	} `json:"files"`
+}
+
+// slackSubtypes are the message subtypes that are conversation, joins,
+// topic changes and the like are left out
+var slackSubtypes = map[string]bool{"": true, "bot_message": true, "me_message": true, "thread_broadcast": true, "file_share": true}
+
+// readSlack reads a workspace export, a directory or the zip Slack
+// offers, with users.json, channels.json (and groups.json for private
+// channels) and a directory of daily files per channel
+func readSlack(p string, username func(id, name string) string, emit func(record) error) error {
+	var fsys fs.FS
+	if strings.HasSuffix(p, ".zip") {
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		fsys = zr
+	} else {
+		fsys = os.DirFS(p)
+	}
+
+	var users []slackUser
+	if err := readJSONFile(fsys, "users.json", &users); err != nil {
+		return err
+	}
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[u.ID] = firstNonEmpty(u.Profile.DisplayName, u.Name, u.Profile.RealName)
+	}
+
+	var channels []struct {
+		Name string `json:"name"`
+	}
+	if err := readJSONFile(fsys, "channels.json", &channels); err != nil {
+		return err
+	}
+	var groups []struct {
+		Name string `json:"name"`
+	}
+	if err := readJSONFile(fsys, "groups.json", &groups); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	channels = append(channels, groups...)
+
+	for _, ch := range channels {
+		// daily files are named YYYY-MM-DD.json, ReadDir sorts them
+		days, err := fs.ReadDir(fsys, ch.Name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, day := range days {
+			if day.IsDir() || path.Ext(day.Name()) != ".json" {
+				continue
+			}
+			var msgs []slackMessage
+			if err := readJSONFile(fsys, path.Join(ch.Name, day.Name()), &msgs); err != nil {
+				return err
+			}
+			for _, m := range msgs {
+				if m.Type != "message" || !slackSubtypes[m.Subtype] {
+					continue
+				}
+				r := record{
+					Room:     ch.Name,
+					User:     m.User,
+					Name:     names[m.User],
+					Text:     slackText(m.Text, names, username),
+					Time:     slackTime(m.TS),
+					SourceID: m.TS,
+				}
+				if m.User == "" {
+					r.User, r.Name = m.Username, m.Username
+				}
+				for _, f := range m.Files {
+					r.Text = strings.TrimSpace(r.Text + "\n" + f.Name + " " + f.Permalink)
+				}
+				if err := emit(r); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func readJSONFile(fsys fs.FS, name string, v any) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New(name + ": " + err.Error())
+	}
+	return nil
+}
+
+// slackMarkup matches <@U123>, <#C123|name>, <!here> and <url|label>
+var slackMarkup = regexp.MustCompile(`<([^<>|]*)(?:\|([^<>]*))?>`)
+
+// slackText turns Slack's markup into plain text, mentions become
+// @username
+func slackText(text string, names map[string]string, username func(id, name string) string) string {
+	text = slackMarkup.ReplaceAllStringFunc(text, func(s string) string {
+		parts := slackMarkup.FindStringSubmatch(s)
+		target, label := parts[1], parts[2]
+		switch {
+		case strings.HasPrefix(target, "@"):
+			id := target[1:]
+			return "@" + username(id, firstNonEmpty(names[id], label))
+		case strings.HasPrefix(target, "#"):
+			return "#" + firstNonEmpty(label, target[1:])
+		case strings.HasPrefix(target, "!"):
+			return "@" + strings.TrimPrefix(firstNonEmpty(label, target[1:]), "@")
+		case label != "" && label != target:
+			return label + " (" + target + ")"
+		}
+		return target
+	})
+	return html.UnescapeString(text)
+}
+
+// slackTime parses a ts such as "1512085950.000216"
+func slackTime(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	frac = (frac + "000000")[:6]
+	us, _ := strconv.ParseInt(frac, 10, 64)
+	return time.Unix(s, us*1000)
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
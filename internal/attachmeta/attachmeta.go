@@ -0,0 +1,99 @@
+// Package attachmeta is the metadata the chat server and the gateway keep
+// next to each stored attachment. Both write the same layout so they can
+// share a directory or object store.
+package attachmeta
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// MaxNameSize is the longest file name kept, in bytes
+const MaxNameSize = 255
+
+// Meta is stored as <id>.json next to each file
+type Meta struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"` // voice, gif or file
+	MimeType   string    `json:"mimeType"`
+	Size       int64     `json:"size"`
+	DurationMs int64     `json:"durationMs,omitempty"` // of voice messages
+	Name       string    `json:"name,omitempty"`
+	SHA256     string    `json:"sha256,omitempty"`
+	Created    time.Time `json:"created"`
+	Threat     string    `json:"threat,omitempty"` // found by the virus scanner, quarantined files only
+
+	Width      int32   `json:"width,omitempty"` // of images
+	Height     int32   `json:"height,omitempty"`
+	Thumbnails []Thumb `json:"thumbnails,omitempty"`
+}
+
+// Thumb describes a thumbnail of an image, stored as <id>_<size>
+type Thumb struct {
+	Size     int    `json:"size"`
+	Width    int32  `json:"width"`
+	Height   int32  `json:"height"`
+	MimeType string `json:"mimeType"`
+}
+
+// Proto describes m for chat messages, with the download URLs the
+// gateway serves
+func (m Meta) Proto() *pb.Attachment {
+	a := &pb.Attachment{
+		Id:         m.ID,
+		Kind:       m.Kind,
+		MimeType:   m.MimeType,
+		Size:       m.Size,
+		DurationMs: m.DurationMs,
+		Url:        "/api/attachments/" + m.ID,
+		Name:       m.Name,
+		Width:      m.Width,
+		Height:     m.Height,
+	}
+	for _, t := range m.Thumbnails {
+		a.Thumbnails = append(a.Thumbnails, &pb.Thumbnail{Size: int32(t.Size), Url: fmt.Sprintf("%s/thumbnails/%d", a.Url, t.Size), Width: t.Width, Height: t.Height})
+	}
+	if len(a.Thumbnails) > 0 {
+		a.PreviewUrl = a.Thumbnails[0].Url
+	}
+	return a
+}
+
+// Thumbnail returns the thumbnail of m at size
+func (m Meta) Thumbnail(size int) (Thumb, bool) {
+	for _, t := range m.Thumbnails {
+		if t.Size == size {
+			return t, true
+		}
+	}
+	return Thumb{}, false
+}
+
+// CleanName drops any directory and control characters from a name
+// chosen by the uploader and cuts it to MaxNameSize bytes, never in the
+// middle of a character
+func CleanName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if len(name) > MaxNameSize {
+		cut := MaxNameSize
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+	}
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,49 @@
+package attachmeta
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCleanName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\bob\photo.jpg`, "photo.jpg"},
+		{"a\x00b\nc\x7f.txt", "abc.txt"},
+		{"", "file"},
+		{"..", "file"},
+		{"dir/", "dir"},
+		{"/", "file"},
+		{strings.Repeat("a", 300), strings.Repeat("a", MaxNameSize)},
+		// 85 three byte characters are 255 bytes, the 86th must go whole
+		{strings.Repeat("文", 100), strings.Repeat("文", 85)},
+		{"a" + strings.Repeat("文", 100), "a" + strings.Repeat("文", 84)},
+	}
+	for _, tt := range tests {
+		got := CleanName(tt.in)
+		if got != tt.want {
+			t.Errorf("CleanName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if len(got) > MaxNameSize || !utf8.ValidString(got) {
+			t.Errorf("CleanName(%q) = %q: %d bytes, valid UTF-8 %v", tt.in, got, len(got), utf8.ValidString(got))
+		}
+	}
+}
+
+func TestProtoThumbnails(t *testing.T) {
+	m := Meta{ID: "abc", Kind: "file", Thumbnails: []Thumb{{Size: 64}, {Size: 256}}}
+	a := m.Proto()
+	if a.Url != "/api/attachments/abc" || a.PreviewUrl != "/api/attachments/abc/thumbnails/64" || len(a.Thumbnails) != 2 {
+		t.Errorf("Proto() = %v", a)
+	}
+	if _, ok := m.Thumbnail(256); !ok {
+		t.Error("Thumbnail(256) not found")
+	}
+	if _, ok := m.Thumbnail(128); ok {
+		t.Error("Thumbnail(128) found")
+	}
+}
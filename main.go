@@ -2,381 +2,137 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
-	pb "realTimeChat/proto/chat"
-	"sync"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"realTimeChat/pkg/avscan"
+	"realTimeChat/pkg/gateway"
+	"realTimeChat/pkg/imaging"
+	"realTimeChat/pkg/shard"
 )
 
-// WebSocket upgrader
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // 允许跨域
-	},
-}
-
-// WSClient WebSocket client connection
-type WSClient struct {
-	conn       *websocket.Conn
-	username   string
-	grpcConn   *grpc.ClientConn
-	grpcStream pb.ChatService_RealtimeChatClient
-	send       chan []byte
-	hub        *WSHub
-}
-
-// WSHub WebSocket hub to manage clients
-type WSHub struct {
-	clients    map[*WSClient]bool
-	broadcast  chan []byte
-	register   chan *WSClient // register chan for new clients
-	unregister chan *WSClient
-	mu         sync.RWMutex
-}
-
-// WSMessage WebSocket message structure
-type WSMessage struct {
-	Type          string `json:"type"`
-	User          string `json:"user"`
-	Text          string `json:"text"`
-	RecipientUser string `json:"recipientUser,omitempty"`
-	Timestamp     string `json:"timestamp"`
-}
-
-// NewWSHub creates a new WSHub
-func newWSHub() *WSHub {
-	return &WSHub{
-		clients:    make(map[*WSClient]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *WSClient),
-		unregister: make(chan *WSClient),
-	}
-}
-
-func (h *WSHub) run() {
-	for {
-		select {
-		case client := <-h.register: // new client registration
-			h.mu.Lock()
-			h.clients[client] = true
-			h.mu.Unlock()
-			log.Printf("WebSocket client registered: %s", client.username)
-
-		case client := <-h.unregister: // client unregistration
-			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send) // close send channel
-				if client.grpcConn != nil {
-					client.grpcConn.Close()
-				}
-			}
-			h.mu.Unlock()
-			log.Printf("WebSocket client unregistered: %s", client.username)
-
-		case message := <-h.broadcast: // broadcast message to all clients
-			h.mu.RLock()
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					if _, exists := h.clients[client]; exists {
-						close(client.send)        // close send channel
-						delete(h.clients, client) // remove client
-					}
-				}
-			}
-			h.mu.RUnlock()
-		}
-	}
-}
-
-func (h *WSHub) getOnlineUsers() []string {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	users := make([]string, 0, len(h.clients))
-	for client := range h.clients {
-		if client.username != "" {
-			users = append(users, client.username)
-		}
-	}
-	return users
-}
-
-func setupRouter(hub *WSHub) *gin.Engine {
-	r := gin.Default()
-
-	// static file router
-	r.Static("/static", "./web/static")
-	r.StaticFile("/", "./web/index.html")
-	r.StaticFile("/favicon.ico", "./web/static/images/favicon.ico")
-
-	// API router
-	r.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "pong!",
-		})
-	})
-
-	// WebSocket router
-	r.GET("/ws", func(c *gin.Context) {
-		handleWebSocket(hub, c.Writer, c.Request)
-	})
-
-	// users count router
-	r.GET("/api/users", func(c *gin.Context) {
-		users := hub.getOnlineUsers()
-		c.JSON(http.StatusOK, gin.H{
-			"users": users,
-			"count": len(users),
-		})
-	})
-
-	return r
-}
-
-func handleWebSocket(hub *WSHub, w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func main() {
+	webDir := flag.String("web-dir", "", "serve the web client from this directory instead of the embedded copy (for development)")
+	configFile := flag.String("config", "", "JSON runtime config (origins, rate limit, filter words, log level), reloaded on SIGHUP")
+	uploadDir := flag.String("upload-dir", "", "directory for uploaded attachments (default a directory under the system temp dir)")
+	clamav := flag.String("clamav", "", "scan uploads with the clamd listening on this TCP address, such as localhost:3310")
+	scanURL := flag.String("scan-url", "", "scan uploads by posting them to this HTTP scanning service")
+	scanToken := flag.String("scan-token", os.Getenv("SCAN_TOKEN"), "bearer token for --scan-url (default $SCAN_TOKEN)")
+	thumbnailSizes := flag.String("thumbnail-sizes", "160,480", "comma separated bounding boxes, in pixels, of the thumbnails rendered for image uploads, none when empty")
+	gifProvider := flag.String("gif-provider", "", "GIF search provider, giphy or tenor, disabled when empty")
+	gifAPIKey := flag.String("gif-api-key", os.Getenv("GIF_API_KEY"), "API key for --gif-provider, kept on the server (default $GIF_API_KEY)")
+	captchaProvider := flag.String("captcha-provider", "", "CAPTCHA for joins over the config's challenge rate, hcaptcha, turnstile or recaptcha, a proof of work when empty")
+	captchaSiteKey := flag.String("captcha-site-key", "", "site key of --captcha-provider, shown to browsers")
+	captchaSecret := flag.String("captcha-secret", os.Getenv("CAPTCHA_SECRET"), "secret of --captcha-provider, kept on the server (default $CAPTCHA_SECRET)")
+	adminToken := flag.String("admin-token", os.Getenv("CHAT_ADMIN_TOKEN"), "bearer token for /api/admin endpoints, disabled when empty (default $CHAT_ADMIN_TOKEN)")
+	wsToken := flag.String("ws-token", os.Getenv("CHAT_WS_TOKEN"), "shared token browsers must send to join, any username is allowed when empty (default $CHAT_WS_TOKEN)")
+	authTimeout := flag.Duration("auth-timeout", gateway.DefaultAuthTimeout, "close WebSockets that have not joined within this time, 0 waits forever")
+	reconnectRetries := flag.Int("reconnect-retries", gateway.DefaultReconnectRetries, "attempts to restore a client's chat server stream before closing its WebSocket, 0 retries forever")
+	ka := gateway.DefaultKeepalive
+	flag.DurationVar(&ka.Time, "keepalive-time", ka.Time, "ping the chat server after this long without activity, 0 disables pings")
+	flag.DurationVar(&ka.Timeout, "keepalive-timeout", ka.Timeout, "reconnect when a ping to the chat server is not answered in time")
+	hb := gateway.DefaultHeartbeat
+	flag.DurationVar(&hb.PingInterval, "ws-ping-interval", hb.PingInterval, "ping browsers this often, must be below --ws-pong-wait")
+	flag.DurationVar(&hb.PongWait, "ws-pong-wait", hb.PongWait, "close WebSockets that sent nothing, not even a pong, for this long")
+	flag.DurationVar(&hb.WriteWait, "ws-write-wait", hb.WriteWait, "close WebSockets a write to takes longer than this")
+	flag.DurationVar(&hb.MinPingInterval, "ws-min-ping-interval", hb.MinPingInterval, "ping new and flaky connections this often, stretching up to --ws-ping-interval while pongs are steady, 0 always pings every --ws-ping-interval")
+	flag.DurationVar(&hb.LateAfter, "ws-pong-late", hb.LateAfter, "tell browsers their connection is poor when a pong takes longer, 0 disables it")
+	shardsPath := flag.String("shards", "", "open each browser's stream on the chat server its room is assigned to among those listed in this file, the chat servers' --shards file, reread when it changes; everything goes to the upstream when empty")
+	transport := flag.String("ws-transport", string(gateway.TransportPumps), "serve WebSockets with a read and a write goroutine each (pumps) or from one epoll loop for many idle sockets (epoll, Linux only)")
+	flag.Parse()
+
+	opts := []gateway.Option{
+		gateway.WithUpstream("localhost:50051"),
+		gateway.WithAdminToken(*adminToken),
+		gateway.WithReconnectRetries(*reconnectRetries),
+		gateway.WithKeepalive(ka),
+		gateway.WithHeartbeat(hb),
+		gateway.WithAuthTimeout(*authTimeout),
+		gateway.WithTransport(gateway.Transport(*transport)),
+	}
+	if *wsToken != "" {
+		opts = append(opts, gateway.WithAuthenticator(gateway.SharedToken(*wsToken)))
+	}
+	if *uploadDir != "" {
+		opts = append(opts, gateway.WithUploadDir(*uploadDir))
+	}
+	switch {
+	case *clamav != "":
+		opts = append(opts, gateway.WithVirusScanner(avscan.NewClamAV(*clamav)))
+	case *scanURL != "":
+		opts = append(opts, gateway.WithVirusScanner(avscan.NewHTTPScanner(*scanURL, *scanToken)))
+	}
+	sizes, err := imaging.ParseSizes(*thumbnailSizes)
 	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
-		return
-	}
-
-	client := &WSClient{
-		conn: conn,
-		send: make(chan []byte, 256),
-		hub:  hub,
-	}
-
-	// register client
-	client.hub.register <- client
-
-	// handle read and write pumps
-	go client.writePump()
-	go client.readPump()
-}
-
-func (c *WSClient) readPump() {
-	defer func() {
-		c.hub.unregister <- c
-		c.conn.Close()
-	}()
-
-	c.conn.SetReadLimit(512)
-	_ = c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-	// heartbeat handler
-	c.conn.SetPongHandler(func(string) error {
-		_ = c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		return nil
-	})
-
-	for {
-		// read from WebSocket
-		_, message, err := c.conn.ReadMessage()
-		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
-			break
-		}
-
-		// parse message
-		var wsMsg WSMessage
-		if err := json.Unmarshal(message, &wsMsg); err != nil {
-			log.Printf("JSON unmarshal error: %v", err)
-			continue
-		}
-
-		// handle message based on type
-		switch wsMsg.Type {
-		case "join":
-			c.handleJoin(wsMsg)
-		case "chat":
-			c.handleChat(wsMsg)
-		}
-	}
-}
-
-// writePump pumps messages from the hub to the WebSocket connection
-func (c *WSClient) writePump() {
-	ticker := time.NewTicker(54 * time.Second)
-	defer func() {
-		ticker.Stop()
-		c.conn.Close()
-	}()
-
-	for {
-		select {
-		case message, ok := <-c.send:
-			// send message from grpc result to websocket
-			_ = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if !ok {
-				// hub closed the channel
-				_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			_, _ = w.Write(message)
-
-			// send queued messages
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				_, _ = w.Write([]byte{'\n'})
-				_, _ = w.Write(<-c.send)
-			}
-
-			if err := w.Close(); err != nil {
-				return
-			}
-
-		case <-ticker.C: // send heartbeat
-			_ = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				return
-			}
+		log.Fatalf("Invalid --thumbnail-sizes: %v", err)
+	}
+	opts = append(opts, gateway.WithThumbnailSizes(sizes...))
+	switch *gifProvider {
+	case "":
+	case "giphy":
+		opts = append(opts, gateway.WithMediaProvider(gateway.NewGiphyProvider(*gifAPIKey)))
+	case "tenor":
+		opts = append(opts, gateway.WithMediaProvider(gateway.NewTenorProvider(*gifAPIKey)))
+	default:
+		log.Fatalf("Unknown GIF provider %q, use giphy or tenor", *gifProvider)
+	}
+	switch *captchaProvider {
+	case "":
+	case "hcaptcha":
+		opts = append(opts, gateway.WithCaptcha(gateway.NewHCaptchaProvider(*captchaSiteKey, *captchaSecret)))
+	case "turnstile":
+		opts = append(opts, gateway.WithCaptcha(gateway.NewTurnstileProvider(*captchaSiteKey, *captchaSecret)))
+	case "recaptcha":
+		opts = append(opts, gateway.WithCaptcha(gateway.NewReCAPTCHAProvider(*captchaSiteKey, *captchaSecret)))
+	default:
+		log.Fatalf("Unknown CAPTCHA provider %q, use hcaptcha, turnstile or recaptcha", *captchaProvider)
+	}
+	var ring *shard.Ring
+	if *shardsPath != "" {
+		if ring, err = shard.Load(*shardsPath, 0); err != nil {
+			log.Fatalf("Failed to load shards: %v", err)
 		}
+		opts = append(opts, gateway.WithShards(ring))
 	}
-}
-
-func (c *WSClient) handleJoin(msg WSMessage) {
-	c.username = msg.User
-
-	// connect to gRPC server
-	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Printf("Failed to connect to gRPC server: %v", err)
-		c.sendError("Failed to connect to chat server")
-		return
-	}
-	c.grpcConn = conn
-
-	client := pb.NewChatServiceClient(conn)
-	stream, err := client.RealtimeChat(context.Background()) // start gRPC stream
-	if err != nil {
-		log.Printf("Failed to start gRPC stream: %v", err)
-		c.sendError("Failed to start chat stream")
-		return
+	if *webDir != "" {
+		log.Printf("Serving web client from %s", *webDir)
+		opts = append(opts, gateway.WithAssets(os.DirFS(*webDir)))
 	}
-	c.grpcStream = stream
-
-	// send join message to grpc
-	joinMsg := &pb.ChatMessage{
-		User: c.username,
-		Text: "has joined",
-	}
-
-	if err := stream.Send(joinMsg); err != nil {
-		log.Printf("Failed to send join message: %v", err)
-		c.sendError("Failed to join chat")
-		return
-	}
-
-	// handle incoming gRPC messages
-	go c.handleGRPCMessages()
-
-	// send current user list
-	c.sendUserList()
-
-	// broadcast user join
-	c.broadcastUserJoin()
-}
-
-// handleChat processes chat messages from WebSocket and sends them to gRPC
-func (c *WSClient) handleChat(msg WSMessage) {
-	if c.grpcStream == nil {
-		c.sendError("Not connected to chat server")
-		return
-	}
-
-	grpcMsg := &pb.ChatMessage{
-		User:          msg.User,
-		Text:          msg.Text,
-		RecipientUser: msg.RecipientUser,
-	}
-
-	if err := c.grpcStream.Send(grpcMsg); err != nil {
-		log.Printf("Failed to send message to gRPC: %v", err)
-		c.sendError("Failed to send message")
-	}
-}
-
-func (c *WSClient) handleGRPCMessages() {
-	for {
-		if c.grpcStream == nil {
-			break
-		}
-		// receive message from gRPC stream
-		msg, err := c.grpcStream.Recv()
+	if *configFile != "" {
+		cfg, err := gateway.LoadConfig(*configFile)
 		if err != nil {
-			log.Printf("gRPC stream receive error: %v", err)
-			break
+			log.Fatalf("Failed to load config: %v", err)
 		}
-
-		// transform to WSMessage
-		wsMsg := WSMessage{
-			Type:          "chat",
-			User:          msg.User,
-			Text:          msg.Text,
-			RecipientUser: msg.RecipientUser,
-			Timestamp:     time.Now().Format(time.RFC3339),
-		}
-
-		data, _ := json.Marshal(wsMsg)
-		c.send <- data
+		opts = append(opts, gateway.WithConfig(cfg), gateway.WithConfigFile(*configFile))
 	}
-}
-
-func (c *WSClient) sendUserList() {
-	users := c.hub.getOnlineUsers()
-	msg := map[string]interface{}{
-		"type":  "userList",
-		"users": users,
-	}
-	data, _ := json.Marshal(msg)
-	c.send <- data
-}
-
-// broadcastUserJoin notifies all clients about a new user joining
-func (c *WSClient) broadcastUserJoin() {
-	msg := map[string]interface{}{
-		"type": "userJoin",
-		"user": c.username,
-	}
-	data, _ := json.Marshal(msg)
-	c.hub.broadcast <- data
-}
 
-func (c *WSClient) sendError(message string) {
-	msg := map[string]interface{}{
-		"type": "error",
-		"text": message,
+	// create gateway in front of the chat server
+	gw := gateway.New(opts...)
+	defer gw.Close()
+	if ring != nil {
+		go shard.Watch(context.Background(), *shardsPath, 0, shard.DefaultWatchInterval, ring, gw.SetShards)
 	}
-	data, _ := json.Marshal(msg)
-	c.send <- data
-}
-func main() {
-	// create WebSocket hub
-	hub := newWSHub()
-	go hub.run()
 
-	// setup router
-	r := setupRouter(hub)
+	// reload runtime config on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := gw.Reload(); err != nil {
+				log.Printf("Config reload failed, keeping current config: %v", err)
+			}
+		}
+	}()
 
 	// start server
 	log.Println("Web server starting on :8080")
 	log.Println("访问 http://localhost:8080 使用 Web 聊天客户端")
 
-	if err := r.Run(":8080"); err != nil {
+	if err := http.ListenAndServe(":8080", gw.Handler()); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
@@ -0,0 +1,159 @@
+// Package assistant defines the Assistant that answers "@assistant"
+// questions in chat rooms, with a provider for OpenAI-compatible chat
+// completion APIs.
+package assistant
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultModel is used by OpenAI when no model is given
+const DefaultModel = "gpt-4o-mini"
+
+// Turn is one earlier message of the room, sent along as context
+type Turn struct {
+	User      string
+	Text      string
+	Assistant bool // written by the assistant itself
+}
+
+// Question is what the assistant is asked
+type Question struct {
+	User    string
+	Room    string
+	Text    string // without the @assistant mention
+	Context []Turn // oldest first
+}
+
+// Assistant answers a question, calling delta with each piece of the
+// answer as it is generated. It returns the whole answer.
+type Assistant interface {
+	Answer(ctx context.Context, q Question, delta func(string)) (string, error)
+}
+
+// Func adapts a plain function to Assistant
+type Func func(ctx context.Context, q Question, delta func(string)) (string, error)
+
+// Answer calls f
+func (f Func) Answer(ctx context.Context, q Question, delta func(string)) (string, error) {
+	return f(ctx, q, delta)
+}
+
+// OpenAI streams answers from an OpenAI-compatible chat completion API,
+// such as OpenAI itself, Azure OpenAI, vLLM or Ollama
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAI creates a provider for the API at baseURL, for example
+// "https://api.openai.com/v1". apiKey may be empty for local servers.
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	if model == "" {
+		model = DefaultModel
+	}
+	return &OpenAI{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{}, // answers stream for a while, callers bound them with ctx
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// messages turns q into a chat completion conversation
+func messages(q Question) []chatMessage {
+	out := []chatMessage{{
+		Role: "system",
+		Content: fmt.Sprintf("You are a helpful assistant taking part in the chat room #%s. "+
+			"Earlier messages are given as \"user: text\". Answer briefly, in the language of the question.", q.Room),
+	}}
+	for _, t := range q.Context {
+		if t.Assistant {
+			out = append(out, chatMessage{Role: "assistant", Content: t.Text})
+		} else {
+			out = append(out, chatMessage{Role: "user", Content: t.User + ": " + t.Text})
+		}
+	}
+	return append(out, chatMessage{Role: "user", Content: q.User + ": " + q.Text})
+}
+
+// Answer implements Assistant
+func (o *OpenAI) Answer(ctx context.Context, q Question, delta func(string)) (string, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":    o.model,
+		"messages": messages(q),
+		"stream":   true,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("assistant: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var answer strings.Builder
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			continue // blank separators, comments and other fields
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return answer.String(), nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return answer.String(), fmt.Errorf("assistant: decoding stream: %w", err)
+		}
+		if chunk.Error != nil {
+			return answer.String(), fmt.Errorf("assistant: %s", chunk.Error.Message)
+		}
+		for _, c := range chunk.Choices {
+			if c.Delta.Content != "" {
+				answer.WriteString(c.Delta.Content)
+				delta(c.Delta.Content)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return answer.String(), err
+	}
+	return answer.String(), nil
+}
@@ -0,0 +1,161 @@
+// Package avscan defines the Scanner that checks uploaded attachments
+// before they can be downloaded, with providers for clamd and for HTTP
+// scanning services.
+package avscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds one scan
+const DefaultTimeout = 30 * time.Second
+
+// Scanner scans the content of a file. It returns the name of the threat
+// found, or "" for a clean file; an error means the file was not scanned.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (threat string, err error)
+}
+
+// Func adapts a plain function to Scanner
+type Func func(ctx context.Context, r io.Reader) (string, error)
+
+// Scan calls f
+func (f Func) Scan(ctx context.Context, r io.Reader) (string, error) {
+	return f(ctx, r)
+}
+
+// clamdChunk is the size of the INSTREAM chunks sent to clamd, well
+// below its default StreamMaxLength
+const clamdChunk = 64 << 10
+
+// ClamAV streams files to a clamd daemon over TCP with the INSTREAM
+// command
+type ClamAV struct {
+	addr string
+}
+
+// NewClamAV creates a scanner for the clamd listening on addr, such as
+// "localhost:3310"
+func NewClamAV(addr string) *ClamAV {
+	return &ClamAV{addr: addr}
+}
+
+// Scan implements Scanner
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return "", fmt.Errorf("avscan: %w", err)
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return "", fmt.Errorf("avscan: %w", err)
+	}
+	buf := make([]byte, 4+clamdChunk)
+	for {
+		n, rerr := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return "", fmt.Errorf("avscan: %w", err)
+			}
+		}
+		if errors.Is(rerr, io.EOF) {
+			break
+		}
+		if rerr != nil {
+			return "", fmt.Errorf("avscan: read file: %w", rerr)
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", fmt.Errorf("avscan: %w", err)
+	}
+	reply, err := io.ReadAll(io.LimitReader(conn, 4<<10))
+	if err != nil {
+		return "", fmt.Errorf("avscan: %w", err)
+	}
+	return parseClamdReply(string(reply))
+}
+
+// parseClamdReply reads "stream: OK", "stream: <name> FOUND" or
+// "<message> ERROR"
+func parseClamdReply(reply string) (string, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return "", fmt.Errorf("avscan: clamd: %s", reply)
+}
+
+// HTTPScanner posts files to a scanning service. The service answers
+// 200 with {"infected": bool, "threat": "name"}, any other status is an
+// error.
+type HTTPScanner struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPScanner creates a scanner for the service at url, token is sent
+// as a bearer token when not empty
+func NewHTTPScanner(url, token string) *HTTPScanner {
+	return &HTTPScanner{url: url, token: token, client: &http.Client{Timeout: DefaultTimeout}}
+}
+
+// Scan implements Scanner
+func (h *HTTPScanner) Scan(ctx context.Context, r io.Reader) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, r)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("avscan: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", fmt.Errorf("avscan: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("avscan: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	var out struct {
+		Infected bool   `json:"infected"`
+		Threat   string `json:"threat"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("avscan: decode response: %w", err)
+	}
+	if !out.Infected {
+		return "", nil
+	}
+	if out.Threat == "" {
+		out.Threat = "unknown"
+	}
+	return out.Threat, nil
+}
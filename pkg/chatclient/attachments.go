@@ -0,0 +1,178 @@
+package chatclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// uploadChunkSize is the size of the chunks UploadAttachment sends, well
+// below the server's 1MB limit
+const uploadChunkSize = 256 << 10
+
+// maxTransferAttempts bounds how often a transfer is resumed
+const maxTransferAttempts = 5
+
+// ErrChecksum is returned when a transferred file does not match the
+// checksum the server reported for it
+var ErrChecksum = errors.New("chatclient: checksum mismatch")
+
+// UploadAttachment sends the content of r through AttachmentService as a
+// file called name and returns the stored attachment, ready to be sent in
+// a ChatMessage. A broken stream is resumed from where the server got to.
+func (c *Client) UploadAttachment(ctx context.Context, name string, r io.ReadSeeker) (*pb.Attachment, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+	first := &pb.Chunk{UploadId: newClientMsgID(), Name: name, Size: size, Sha256: hex.EncodeToString(h.Sum(nil))}
+	svc := pb.NewAttachmentServiceClient(c.grpcConn())
+
+	var a *pb.Attachment
+	err = c.retryTransfer(ctx, func(attempt int) error {
+		first.Offset = 0
+		if attempt > 1 {
+			resp, err := svc.GetUploadOffset(ctx, &pb.UploadOffsetRequest{UploadId: first.UploadId})
+			if err != nil {
+				return err
+			}
+			first.Offset = resp.Offset
+		}
+		if _, err := r.Seek(first.Offset, io.SeekStart); err != nil {
+			return err
+		}
+		a, err = sendChunks(ctx, svc, first, r)
+		return err
+	})
+	return a, err
+}
+
+// sendChunks streams r from first.Offset, the first chunk carries the
+// file's description
+func sendChunks(ctx context.Context, svc pb.AttachmentServiceClient, first *pb.Chunk, r io.Reader) (*pb.Attachment, error) {
+	stream, err := svc.UploadAttachment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, uploadChunkSize)
+	chunk := first
+	for {
+		n, rerr := io.ReadFull(r, buf)
+		if n > 0 || chunk == first {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				if errors.Is(err, io.EOF) {
+					_, err = stream.CloseAndRecv() // the server ended the stream, get its status
+				}
+				return nil, err
+			}
+			chunk = &pb.Chunk{UploadId: first.UploadId, Offset: chunk.Offset + int64(n)}
+		}
+		if errors.Is(rerr, io.EOF) || errors.Is(rerr, io.ErrUnexpectedEOF) {
+			return stream.CloseAndRecv()
+		}
+		if rerr != nil {
+			return nil, rerr
+		}
+	}
+}
+
+// DownloadAttachment writes the attachment with the given ID to w and
+// returns its file name. A broken stream is resumed after what was
+// written, the result is checked against the checksum when the server
+// has one.
+func (c *Client) DownloadAttachment(ctx context.Context, id string, w io.Writer) (string, error) {
+	svc := pb.NewAttachmentServiceClient(c.grpcConn())
+	h := sha256.New()
+	var written int64
+	var desc *pb.Chunk // first chunk received, describes the file
+
+	err := c.retryTransfer(ctx, func(int) error {
+		stream, err := svc.DownloadAttachment(ctx, &pb.AttachmentRequest{Id: id, Offset: written})
+		if err != nil {
+			return err
+		}
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if chunk.Offset != written {
+				return fmt.Errorf("chatclient: chunk at offset %d, expected %d", chunk.Offset, written)
+			}
+			if desc == nil {
+				desc = chunk
+			}
+			if _, err := w.Write(chunk.Data); err != nil {
+				return err
+			}
+			h.Write(chunk.Data)
+			written += int64(len(chunk.Data))
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if desc == nil || written != desc.Size {
+		return "", fmt.Errorf("chatclient: download incomplete, %d bytes received", written)
+	}
+	if desc.Sha256 != "" && hex.EncodeToString(h.Sum(nil)) != desc.Sha256 {
+		return "", ErrChecksum
+	}
+	return desc.Name, nil
+}
+
+// DownloadURL returns a presigned URL the attachment can be fetched
+// from directly, valid for a limited time. Servers keeping attachments
+// on local disk answer with codes.Unimplemented, use DownloadAttachment
+// then.
+func (c *Client) DownloadURL(ctx context.Context, id string) (string, error) {
+	resp, err := pb.NewAttachmentServiceClient(c.grpcConn()).GetDownloadUrl(ctx, &pb.AttachmentRequest{Id: id})
+	if err != nil {
+		return "", err
+	}
+	return resp.Url, nil
+}
+
+// retryTransfer runs fn until it succeeds, fails for good or
+// maxTransferAttempts is reached, backing off like reconnects
+func (c *Client) retryTransfer(ctx context.Context, fn func(attempt int) error) error {
+	delay := c.opts.minBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || ctx.Err() != nil || attempt >= maxTransferAttempts || !transferRetryable(err) {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		if delay *= 2; delay > c.opts.maxBackoff {
+			delay = c.opts.maxBackoff
+		}
+	}
+}
+
+// transferRetryable reports errors a transfer may get past by resuming,
+// broken connections and an upload still held by a dying stream
+func transferRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Aborted, codes.Internal:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,965 @@
+// Package chatclient is the Go SDK for the chat server. It wraps the
+// RealtimeChat stream with a callback API and reconnects automatically
+// when the stream drops.
+package chatclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// ErrClosed is returned when sending on a closed client
+var ErrClosed = errors.New("chatclient: client closed")
+
+// ErrNotConnected is returned when sending while the stream is being re-established
+var ErrNotConnected = errors.New("chatclient: not connected")
+
+// Handler receives every message delivered to the client
+type Handler func(msg *pb.ChatMessage)
+
+// State is the connection state reported to state handlers
+type State int
+
+const (
+	// Connected means the stream is open and joined
+	Connected State = iota
+	// Reconnecting means the stream dropped and a retry is pending
+	Reconnecting
+	// Closed means the client stopped for good, see Err
+	Closed
+)
+
+func (s State) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// Option configures Connect
+type Option func(*options)
+
+type options struct {
+	dialOpts      []grpc.DialOption
+	conn          *grpc.ClientConn
+	reconnect     bool
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	maxRetries    int // 0 means retry until the context ends
+	hbInterval    time.Duration
+	hbTimeout     time.Duration
+	room          string
+	rooms         []string
+	filter        *pb.StreamFilter
+	capabilities  []string
+	device        string
+	userAgent     string
+	handlers      []Handler
+	stateHandlers []func(State, error)
+	router        func(addr string) (*grpc.ClientConn, error)
+}
+
+// WithDialOptions sets the options used to dial addr, the default is an
+// insecure connection
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = opts
+	}
+}
+
+// WithConn reuses an existing connection instead of dialing addr.
+// The connection is not closed by Close.
+func WithConn(conn *grpc.ClientConn) Option {
+	return func(o *options) {
+		o.conn = conn
+	}
+}
+
+// WithReconnect turns automatic stream re-establishment on or off, it is on by default
+func WithReconnect(enabled bool) Option {
+	return func(o *options) {
+		o.reconnect = enabled
+	}
+}
+
+// WithBackoff sets the reconnect delay bounds, the delay doubles after
+// every failed attempt
+func WithBackoff(min, max time.Duration) Option {
+	return func(o *options) {
+		o.minBackoff = min
+		o.maxBackoff = max
+	}
+}
+
+// WithMaxRetries caps consecutive failed reconnect attempts, 0 retries forever
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.maxRetries = n
+	}
+}
+
+// Heartbeat defaults, see WithHeartbeat
+const (
+	DefaultHeartbeatInterval = 15 * time.Second
+	DefaultHeartbeatTimeout  = 45 * time.Second
+)
+
+// WithHeartbeat sends a heartbeat every interval and re-establishes the
+// stream when nothing arrived from the server for timeout. It only runs
+// against servers that enable the heartbeat capability, an interval of 0
+// turns it off.
+func WithHeartbeat(interval, timeout time.Duration) Option {
+	return func(o *options) {
+		o.hbInterval = interval
+		o.hbTimeout = timeout
+	}
+}
+
+// WithRoom joins room instead of the server's default room
+func WithRoom(room string) Option {
+	return func(o *options) {
+		o.room = room
+	}
+}
+
+// WithRooms has the stream also receive the public messages of rooms
+// besides the one it chats in, see Subscribe
+func WithRooms(rooms ...string) Option {
+	return func(o *options) {
+		o.rooms = rooms
+	}
+}
+
+// WithFilter has the server hold back what filter excludes from the
+// first message on, see SetFilter
+func WithFilter(filter *pb.StreamFilter) Option {
+	return func(o *options) {
+		o.filter = filter
+	}
+}
+
+// WithCapabilities sets the capabilities advertised when joining, the
+// default is every capability this package knows. Events of the others
+// are not delivered by servers that negotiate.
+func WithCapabilities(caps ...string) Option {
+	return func(o *options) {
+		o.capabilities = caps
+	}
+}
+
+// Metadata keys that describe the client to the server, sent with every
+// stream
+const (
+	DeviceKey    = "x-chat-device"
+	UserAgentKey = "x-chat-user-agent"
+)
+
+// WithDevice names the device the client runs on, such as a host name or
+// "Firefox on Linux". The server shows it in session lists.
+func WithDevice(name string) Option {
+	return func(o *options) {
+		o.device = name
+	}
+}
+
+// WithUserAgent identifies the client software, the server falls back to
+// the gRPC user agent without it
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
+
+// WithRouter sets how the client reaches the server a room moved to
+// when a sharded cluster reports it with TYPE_ROOM_MOVED, the default
+// dials the address with the dial options. Connections from fn are not
+// closed by the client.
+func WithRouter(fn func(addr string) (*grpc.ClientConn, error)) Option {
+	return func(o *options) {
+		o.router = fn
+	}
+}
+
+// WithHandler registers a message handler before the stream starts,
+// so it also sees messages that arrive right after joining
+func WithHandler(h Handler) Option {
+	return func(o *options) {
+		o.handlers = append(o.handlers, h)
+	}
+}
+
+// WithStateHandler registers a callback for connection state changes
+func WithStateHandler(fn func(State, error)) Option {
+	return func(o *options) {
+		o.stateHandlers = append(o.stateHandlers, fn)
+	}
+}
+
+// Client is a joined chat session
+type Client struct {
+	username string
+	opts     options
+	conn     *grpc.ClientConn
+	ownConn  bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the receive loop exits
+
+	mu           sync.Mutex       // guards conn, ownConn, username, room, subs, filter, hello, stream, handlers, closing and err
+	sendMu       sync.Mutex       // serialises Send calls on the stream
+	room         string           // empty until the server confirms a room change
+	subs         []string         // further rooms received, as the server last confirmed
+	filter       *pb.StreamFilter // sent again when rejoining
+	hello        *pb.Hello        // the server's answer, nil until it arrives or for older servers
+	stream       pb.ChatService_RealtimeChatClient
+	streamCancel context.CancelFunc // ends stream, to drop a dead one
+	handlers     []Handler
+	closing      bool
+	err          error
+
+	lastRecv atomic.Int64 // Unix nanoseconds of the last message received
+	latency  atomic.Int64 // round trip of the last heartbeat
+}
+
+// closeTimeout bounds how long Close waits for the server to end the stream
+const closeTimeout = time.Second
+
+// Connect dials addr, joins the chat as username and starts receiving.
+// The client runs until ctx is cancelled or Close is called.
+func Connect(ctx context.Context, addr, username string, opts ...Option) (*Client, error) {
+	if username == "" {
+		return nil, errors.New("chatclient: username cannot be empty")
+	}
+
+	o := options{
+		dialOpts:     []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		reconnect:    true,
+		minBackoff:   500 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		capabilities: pb.Capabilities(),
+		hbInterval:   DefaultHeartbeatInterval,
+		hbTimeout:    DefaultHeartbeatTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Client{
+		username: username,
+		room:     o.room,
+		subs:     o.rooms,
+		filter:   o.filter,
+		opts:     o,
+		conn:     o.conn,
+		done:     make(chan struct{}),
+		handlers: o.handlers,
+	}
+	if c.conn == nil {
+		conn, err := grpc.NewClient(addr, o.dialOpts...)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+		c.ownConn = true
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	stream, err := c.join()
+	if err != nil {
+		c.cancel()
+		if c.ownConn {
+			c.conn.Close()
+		}
+		return nil, err
+	}
+	c.stream = stream
+
+	go c.recvLoop(stream)
+	if o.hbInterval > 0 {
+		go c.heartbeat()
+	}
+	return c, nil
+}
+
+// join opens a new stream and sends the join message
+func (c *Client) join() (pb.ChatService_RealtimeChatClient, error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	var md []string
+	if c.opts.device != "" {
+		md = append(md, DeviceKey, c.opts.device)
+	}
+	if c.opts.userAgent != "" {
+		md = append(md, UserAgentKey, c.opts.userAgent)
+	}
+	stream, err := pb.NewChatServiceClient(c.grpcConn()).RealtimeChat(metadata.AppendToOutgoingContext(ctx, md...))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.lastRecv.Store(time.Now().UnixNano())
+	c.mu.Lock()
+	if c.streamCancel != nil {
+		c.streamCancel() // release the stream this one replaces
+	}
+	c.streamCancel = cancel
+	join := &pb.ChatMessage{
+		User: c.username,
+		Room: c.room,
+		Text: "has joined",
+		Payload: &pb.ChatMessage_Hello{Hello: &pb.Hello{
+			ProtocolVersion: pb.ProtocolVersion,
+			Capabilities:    c.opts.capabilities,
+			Rooms:           c.subs,
+			Filter:          c.filter,
+		}},
+	}
+	c.hello = nil // a reconnect may reach a different server
+	c.mu.Unlock()
+	if err := stream.Send(join); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Username returns the client's current name, it follows /nick changes
+// and is used when rejoining after a reconnect
+func (c *Client) Username() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username
+}
+
+// Nick asks the server to change the client's name, the change takes
+// effect when the server confirms it
+func (c *Client) Nick(newName string) error {
+	return c.Send("/nick " + newName)
+}
+
+// Capabilities returns the capabilities the server enabled for this
+// client and the server's protocol version. ok is false while the answer
+// is pending and for servers that predate negotiation, which send every
+// event.
+func (c *Client) Capabilities() (caps []string, version uint32, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hello == nil {
+		return nil, 0, false
+	}
+	return c.hello.Capabilities, c.hello.ProtocolVersion, true
+}
+
+// Latency returns the round trip of the last heartbeat, 0 before the
+// first answer
+func (c *Client) Latency() time.Duration {
+	return time.Duration(c.latency.Load())
+}
+
+// Room returns the room the client chats in, empty for the server's
+// default room. It follows JoinRoom and is rejoined after a reconnect.
+func (c *Client) Room() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.room
+}
+
+// JoinRoom asks the server to move the client to room, the change takes
+// effect when the server confirms it
+func (c *Client) JoinRoom(room string) error {
+	return c.Send("/join " + room)
+}
+
+// LeaveRoom asks the server to move the client back to the default room
+func (c *Client) LeaveRoom() error {
+	return c.Send("/leave")
+}
+
+// Subscribe asks the server to also deliver the public messages of room
+// on this stream, SendTo then posts to it
+func (c *Client) Subscribe(room string) error {
+	return c.Send("/subscribe " + room)
+}
+
+// Unsubscribe stops the messages of a room subscribed with Subscribe
+func (c *Client) Unsubscribe(room string) error {
+	return c.Send("/unsubscribe " + room)
+}
+
+// Subscriptions returns the rooms received besides Room
+func (c *Client) Subscriptions() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.subs)
+}
+
+// SetFilter has the server hold back the messages filter excludes, such
+// as public messages not mentioning the user or join and leave notices.
+// PMs always arrive. A nil filter lets everything through again, the
+// filter is kept across reconnects.
+func (c *Client) SetFilter(filter *pb.StreamFilter) error {
+	if filter == nil {
+		filter = &pb.StreamFilter{}
+	}
+	if err := c.SendMessage(&pb.ChatMessage{Payload: &pb.ChatMessage_Filter{Filter: filter}}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.filter = filter
+	c.mu.Unlock()
+	return nil
+}
+
+// ListUsers returns the online users, limited to the members of room
+// when it is not empty. Private rooms the client is not a member of are
+// left out.
+func (c *Client) ListUsers(ctx context.Context, room string) ([]*pb.OnlineUser, error) {
+	resp, err := pb.NewRoomServiceClient(c.grpcConn()).ListUsers(ctx, &pb.ListUsersRequest{Room: room, User: c.Username()})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Users, nil
+}
+
+// RoomMembers returns everyone who has been in room with their role,
+// presence and last-seen time, the server's default room when empty
+func (c *Client) RoomMembers(ctx context.Context, room string) ([]*pb.RoomMember, error) {
+	resp, err := pb.NewRoomServiceClient(c.grpcConn()).GetRoomMembers(ctx, &pb.RoomMembersRequest{Room: room})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Members, nil
+}
+
+// ListRooms returns the rooms with online members
+func (c *Client) ListRooms(ctx context.Context) ([]*pb.RoomInfo, error) {
+	resp, err := pb.NewRoomServiceClient(c.grpcConn()).ListRooms(ctx, &pb.ListRoomsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Rooms, nil
+}
+
+// Profile returns user's presence and pinned message
+func (c *Client) Profile(ctx context.Context, user string) (*pb.Profile, error) {
+	return pb.NewProfileServiceClient(c.grpcConn()).GetProfile(ctx, &pb.ProfileRequest{User: user})
+}
+
+// PinToProfile pins the public message with the given ID to the client's
+// profile, replacing any earlier pin; an empty ID removes the pin
+func (c *Client) PinToProfile(ctx context.Context, messageID string) (*pb.Profile, error) {
+	return pb.NewProfileServiceClient(c.grpcConn()).SetProfilePin(ctx, &pb.SetProfilePinRequest{User: c.Username(), MessageId: messageID})
+}
+
+// Contacts returns the client's contacts and whether they are online
+func (c *Client) Contacts(ctx context.Context) ([]*pb.Contact, error) {
+	resp, err := pb.NewContactServiceClient(c.grpcConn()).ListContacts(ctx, &pb.ContactsRequest{User: c.Username()})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Contacts, nil
+}
+
+// AddContact subscribes the client to user's presence wherever they are
+func (c *Client) AddContact(ctx context.Context, user string) error {
+	_, err := pb.NewContactServiceClient(c.grpcConn()).AddContact(ctx, &pb.ContactRequest{User: c.Username(), Contact: user})
+	return err
+}
+
+// RemoveContact drops user from the client's contacts
+func (c *Client) RemoveContact(ctx context.Context, user string) error {
+	_, err := pb.NewContactServiceClient(c.grpcConn()).RemoveContact(ctx, &pb.ContactRequest{User: c.Username(), Contact: user})
+	return err
+}
+
+// Thread returns the thread of the public message threadID with its
+// recent replies
+func (c *Client) Thread(ctx context.Context, threadID string) (*pb.Thread, error) {
+	return pb.NewThreadServiceClient(c.grpcConn()).GetThread(ctx, &pb.ThreadRequest{User: c.Username(), ThreadId: threadID})
+}
+
+// FollowThread delivers the replies to threadID to the client
+func (c *Client) FollowThread(ctx context.Context, threadID string) error {
+	_, err := pb.NewThreadServiceClient(c.grpcConn()).FollowThread(ctx, &pb.ThreadRequest{User: c.Username(), ThreadId: threadID})
+	return err
+}
+
+// UnfollowThread stops delivering the replies to threadID, the client
+// still gets their count
+func (c *Client) UnfollowThread(ctx context.Context, threadID string) error {
+	_, err := pb.NewThreadServiceClient(c.grpcConn()).UnfollowThread(ctx, &pb.ThreadRequest{User: c.Username(), ThreadId: threadID})
+	return err
+}
+
+// SaveDraft keeps the unsent text of conversation, "#room" or "@user",
+// on the server so other devices can pick it up. Empty text deletes it.
+// A newer draft saved from another device wins and is returned.
+func (c *Client) SaveDraft(ctx context.Context, conversation, text string) (*pb.Draft, error) {
+	return pb.NewDraftServiceClient(c.grpcConn()).SaveDraft(ctx, &pb.SaveDraftRequest{
+		User:         c.Username(),
+		Conversation: conversation,
+		Text:         text,
+		UpdatedAt:    time.Now().UnixMilli(),
+	})
+}
+
+// Drafts returns the client's drafts, most recently edited first
+func (c *Client) Drafts(ctx context.Context) ([]*pb.Draft, error) {
+	resp, err := pb.NewDraftServiceClient(c.grpcConn()).GetDrafts(ctx, &pb.DraftsRequest{User: c.Username()})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Drafts, nil
+}
+
+// MessageRequests returns the PMs held for the client from users that
+// are not its contacts
+func (c *Client) MessageRequests(ctx context.Context) ([]*pb.MessageRequest, error) {
+	resp, err := pb.NewMessageRequestServiceClient(c.grpcConn()).ListMessageRequests(ctx, &pb.MessageRequestsRequest{User: c.Username()})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Requests, nil
+}
+
+// AcceptMessageRequest delivers the PMs held from sender and adds them to
+// the client's contacts
+func (c *Client) AcceptMessageRequest(ctx context.Context, sender string) error {
+	_, err := pb.NewMessageRequestServiceClient(c.grpcConn()).AcceptMessageRequest(ctx, &pb.MessageRequestDecision{User: c.Username(), Sender: sender})
+	return err
+}
+
+// DeclineMessageRequest drops the PMs held from sender along with the
+// ones they send later
+func (c *Client) DeclineMessageRequest(ctx context.Context, sender string) error {
+	_, err := pb.NewMessageRequestServiceClient(c.grpcConn()).DeclineMessageRequest(ctx, &pb.MessageRequestDecision{User: c.Username(), Sender: sender})
+	return err
+}
+
+// AddKeyword has the server send a TYPE_KEYWORD_HIT whenever a public
+// message in room contains word
+func (c *Client) AddKeyword(ctx context.Context, room, word string) error {
+	_, err := pb.NewPreferencesServiceClient(c.grpcConn()).AddKeyword(ctx, &pb.KeywordRequest{User: c.Username(), Room: room, Word: word})
+	return err
+}
+
+// RemoveKeyword stops the keyword hits for word in room
+func (c *Client) RemoveKeyword(ctx context.Context, room, word string) error {
+	_, err := pb.NewPreferencesServiceClient(c.grpcConn()).RemoveKeyword(ctx, &pb.KeywordRequest{User: c.Username(), Room: room, Word: word})
+	return err
+}
+
+// HistoryBefore returns up to limit of the newest public messages of room
+// sent before before, oldest first. Servers with a message store read it
+// from there, so paging back reaches messages from before a restart and
+// in cold storage.
+func (c *Client) HistoryBefore(ctx context.Context, room string, before time.Time, limit uint32) ([]*pb.ChatMessage, error) {
+	resp, err := pb.NewHistoryServiceClient(c.grpcConn()).GetHistory(ctx, &pb.HistoryRequest{
+		Room:       room,
+		BeforeTime: before.UnixMilli(),
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Messages, nil
+}
+
+// Catchup summarizes what the given rooms missed after their sequence
+// numbers: counts, mentions of the client, up to max of the newest
+// messages and the membership changes. Rooms come back in map order.
+func (c *Client) Catchup(ctx context.Context, since map[string]uint64, max uint32) ([]*pb.RoomCatchup, error) {
+	req := &pb.CatchupRequest{User: c.Username(), Max: max}
+	for room, seq := range since {
+		req.Rooms = append(req.Rooms, &pb.CatchupRoom{Room: room, SinceSeq: seq})
+	}
+	resp, err := pb.NewHistoryServiceClient(c.grpcConn()).Catchup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Rooms, nil
+}
+
+// OnMessage registers a handler for messages received from now on
+func (c *Client) OnMessage(h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, h)
+}
+
+// Send sends a public message
+func (c *Client) Send(text string) error {
+	return c.SendMessage(&pb.ChatMessage{Text: text})
+}
+
+// SendTo sends a public message to room, which must be the client's room
+// or one it subscribed to
+func (c *Client) SendTo(room, text string) error {
+	return c.SendMessage(&pb.ChatMessage{Text: text, Room: room})
+}
+
+// SendPM sends a private message to recipient
+func (c *Client) SendPM(recipient, text string) error {
+	return c.SendMessage(&pb.ChatMessage{Text: text, RecipientUser: recipient})
+}
+
+// Reply answers the public message threadID in room, the client's room
+// when empty, and follows its thread
+func (c *Client) Reply(room, threadID, text string) error {
+	return c.SendMessage(&pb.ChatMessage{Text: text, Room: room, ThreadId: threadID})
+}
+
+// SendCode sends a public code block, content is delivered verbatim
+func (c *Client) SendCode(language, content string) error {
+	return c.SendMessage(&pb.ChatMessage{Payload: &pb.ChatMessage_Code{Code: &pb.Code{Language: language, Content: content}}})
+}
+
+// SendSignal sends a call signaling message to recipient
+func (c *Client) SendSignal(recipient string, sig *pb.Signal) error {
+	return c.SendMessage(&pb.ChatMessage{RecipientUser: recipient, Payload: &pb.ChatMessage_Signal{Signal: sig}})
+}
+
+// JoinVoice joins the voice channel of room, the client's room when
+// empty, leaving the one it was in. The server only tracks presence,
+// media has to be set up between the peers.
+func (c *Client) JoinVoice(room string) error {
+	return c.voiceSignal(room, pb.SignalType_SIGNAL_VOICE_JOIN)
+}
+
+// LeaveVoice leaves the voice channel the client is in
+func (c *Client) LeaveVoice() error {
+	return c.voiceSignal("", pb.SignalType_SIGNAL_VOICE_LEAVE)
+}
+
+// SetSpeaking tells the voice channel whether the user is speaking, as
+// detected by the client
+func (c *Client) SetSpeaking(speaking bool) error {
+	t := pb.SignalType_SIGNAL_VOICE_SPEAKING_STOP
+	if speaking {
+		t = pb.SignalType_SIGNAL_VOICE_SPEAKING_START
+	}
+	return c.voiceSignal("", t)
+}
+
+func (c *Client) voiceSignal(room string, t pb.SignalType) error {
+	return c.SendMessage(&pb.ChatMessage{Room: room, Payload: &pb.ChatMessage_Signal{Signal: &pb.Signal{Type: t}}})
+}
+
+// SetIdle tells the server whether the user is idle, sending any message
+// also counts as activity
+func (c *Client) SetIdle(idle bool) error {
+	return c.SendMessage(&pb.ChatMessage{Payload: &pb.ChatMessage_Activity{Activity: &pb.Activity{Idle: idle}}})
+}
+
+// SendMessage sends msg, filling in the sender name and a ClientMsgId
+// when it has none. Sending the same msg again after an ambiguous error
+// reuses its ClientMsgId, so the server delivers it at most once and
+// answers the retry with a duplicate Ack.
+func (c *Client) SendMessage(msg *pb.ChatMessage) error {
+	c.mu.Lock()
+	stream, err, username := c.stream, c.err, c.username
+	c.mu.Unlock()
+
+	select {
+	case <-c.done:
+		if err != nil {
+			return err
+		}
+		return ErrClosed
+	default:
+	}
+	if stream == nil {
+		return ErrNotConnected
+	}
+
+	msg.User = username
+	if msg.ClientMsgId == "" && msg.GetSignal() == nil && msg.GetActivity() == nil && msg.GetFilter() == nil {
+		msg.ClientMsgId = newClientMsgID()
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return stream.Send(msg)
+}
+
+// newClientMsgID returns a random idempotency key
+func newClientMsgID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Done is closed once the client has stopped
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the client stopped, nil after a clean close
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close leaves the chat and waits for the receive loop to exit
+func (c *Client) Close() error {
+	c.mu.Lock()
+	stream := c.stream
+	c.closing = true
+	c.mu.Unlock()
+
+	// let the server end the stream cleanly before cancelling it
+	if stream != nil {
+		c.sendMu.Lock()
+		_ = stream.CloseSend()
+		c.sendMu.Unlock()
+		select {
+		case <-c.done:
+		case <-time.After(closeTimeout):
+		}
+	}
+	c.cancel()
+	<-c.done
+	c.mu.Lock()
+	conn, own := c.conn, c.ownConn
+	c.mu.Unlock()
+	if own {
+		return conn.Close()
+	}
+	return nil
+}
+
+// recvLoop dispatches messages and re-establishes the stream when it drops
+func (c *Client) recvLoop(stream pb.ChatService_RealtimeChatClient) {
+	var err error
+	defer func() {
+		c.mu.Lock()
+		c.stream = nil
+		if c.ctx.Err() == nil {
+			c.err = err
+		}
+		c.mu.Unlock()
+		c.cancel()
+		close(c.done)
+		c.notify(Closed, err)
+	}()
+
+	c.notify(Connected, nil)
+	for {
+		var msg *pb.ChatMessage
+		msg, err = stream.Recv()
+		if err == nil {
+			c.lastRecv.Store(time.Now().UnixNano())
+			c.dispatch(msg)
+			continue
+		}
+		if err == io.EOF || c.ctx.Err() != nil || c.isClosing() {
+			err = nil
+			return
+		}
+		if !c.opts.reconnect || !retryable(err) {
+			return
+		}
+
+		log.Printf("chatclient: stream for %s dropped: %v", c.Username(), err)
+		if stream, err = c.reconnect(err); stream == nil {
+			return
+		}
+	}
+}
+
+// reconnect retries join with exponential backoff until it succeeds,
+// the context ends or the retry cap is hit
+func (c *Client) reconnect(cause error) (pb.ChatService_RealtimeChatClient, error) {
+	c.mu.Lock()
+	c.stream = nil
+	c.mu.Unlock()
+
+	delay := c.opts.minBackoff
+	for attempt := 1; ; attempt++ {
+		c.notify(Reconnecting, cause)
+
+		select {
+		case <-time.After(delay):
+		case <-c.ctx.Done():
+			return nil, nil
+		}
+
+		stream, err := c.join()
+		if err == nil {
+			c.mu.Lock()
+			c.stream = stream
+			c.mu.Unlock()
+			c.notify(Connected, nil)
+			return stream, nil
+		}
+		if c.ctx.Err() != nil {
+			return nil, nil
+		}
+		if !retryable(err) || (c.opts.maxRetries > 0 && attempt >= c.opts.maxRetries) {
+			return nil, err
+		}
+
+		cause = err
+		if delay *= 2; delay > c.opts.maxBackoff {
+			delay = c.opts.maxBackoff
+		}
+	}
+}
+
+// heartbeat keeps a negotiated stream alive and cancels it when the
+// server went quiet for longer than the timeout, recvLoop then reconnects.
+// A quiet stream is normal in a quiet chat, so servers without the
+// capability are never judged.
+func (c *Client) heartbeat() {
+	ticker := time.NewTicker(c.opts.hbInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+
+		c.mu.Lock()
+		stream, cancel, hello := c.stream, c.streamCancel, c.hello
+		c.mu.Unlock()
+		if stream == nil || hello == nil || !slices.Contains(hello.Capabilities, pb.CapHeartbeat) {
+			continue
+		}
+		if quiet := time.Since(time.Unix(0, c.lastRecv.Load())); c.opts.hbTimeout > 0 && quiet > c.opts.hbTimeout {
+			log.Printf("chatclient: no word from the server for %s, reconnecting", quiet.Round(time.Second))
+			cancel()
+			continue
+		}
+		c.sendMu.Lock()
+		_ = stream.Send(&pb.ChatMessage{
+			User:    c.Username(),
+			Payload: &pb.ChatMessage_Heartbeat{Heartbeat: &pb.Heartbeat{SentAt: time.Now().UnixMilli()}},
+		})
+		c.sendMu.Unlock()
+	}
+}
+
+func (c *Client) isClosing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closing
+}
+
+func (c *Client) dispatch(msg *pb.ChatMessage) {
+	// batched messages are handled as if they arrived one by one
+	if b := msg.GetBatch(); b != nil {
+		for _, m := range b.Messages {
+			c.dispatch(m)
+		}
+		return
+	}
+	c.mu.Lock()
+	// the answer to our hello is kept rather than delivered
+	if h := msg.GetHello(); h != nil {
+		c.hello = h
+		c.mu.Unlock()
+		return
+	}
+	// heartbeat answers only measure the round trip
+	if hb := msg.GetHeartbeat(); hb != nil {
+		c.mu.Unlock()
+		if hb.SentAt > 0 {
+			c.latency.Store(int64(time.Since(time.UnixMilli(hb.SentAt))))
+		}
+		return
+	}
+	// the server addresses our own rename to the new name
+	if r := msg.GetRename(); r != nil && msg.RecipientUser == r.NewUser && r.OldUser == c.username {
+		c.username = r.NewUser
+	}
+	// room changes are only sent to the connection that moved
+	if rc := msg.GetRoomChange(); rc != nil {
+		c.room = rc.To
+	}
+	// so are subscription changes, they are resubscribed after a reconnect
+	if s := msg.GetSubscriptions(); s != nil {
+		c.subs = s.Rooms
+	}
+	handlers := c.handlers
+	c.mu.Unlock()
+	for _, h := range handlers {
+		h(msg)
+	}
+	// as are moves of the room the connection is in or asked to join
+	if m := msg.GetRoomMoved(); m != nil && m.Node != "" && c.opts.reconnect {
+		c.follow(m)
+	}
+}
+
+// follow rejoins the room m moved to on its new server, recvLoop opens
+// the stream there once the current one is dropped
+func (c *Client) follow(m *pb.RoomMoved) {
+	var conn *grpc.ClientConn
+	var err error
+	if c.opts.router != nil {
+		conn, err = c.opts.router(m.Node)
+	} else {
+		conn, err = grpc.NewClient(m.Node, c.opts.dialOpts...)
+	}
+	if err != nil {
+		log.Printf("chatclient: cannot reach %s for #%s: %v", m.Node, m.Room, err)
+		return
+	}
+	log.Printf("chatclient: #%s moved to %s, rejoining there", m.Room, m.Node)
+	c.mu.Lock()
+	old, own := c.conn, c.ownConn
+	c.conn, c.ownConn = conn, c.opts.router == nil
+	c.room = m.Room
+	cancel := c.streamCancel
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if own && old != conn {
+		old.Close()
+	}
+}
+
+// grpcConn returns the connection to the server the client is on
+func (c *Client) grpcConn() *grpc.ClientConn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+func (c *Client) notify(state State, err error) {
+	for _, fn := range c.opts.stateHandlers {
+		fn(state, err)
+	}
+}
+
+// retryable reports whether a stream error may go away by reconnecting
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied,
+		codes.Unimplemented, codes.FailedPrecondition:
+		return false
+	}
+	return true
+}
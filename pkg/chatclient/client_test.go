@@ -0,0 +1,258 @@
+package chatclient_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"realTimeChat/pkg/chatclient"
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+// proxy forwards TCP connections to a chat server so tests can drop them
+// the way a flaky network would
+type proxy struct {
+	ln     net.Listener
+	target string
+
+	mu    sync.Mutex
+	conns []net.Conn
+	wg    sync.WaitGroup
+}
+
+func newProxy(t *testing.T, target string) *proxy {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	p := &proxy{ln: ln, target: target}
+	p.wg.Add(1)
+	go p.serve()
+	t.Cleanup(p.close)
+	return p
+}
+
+func (p *proxy) addr() string {
+	return p.ln.Addr().String()
+}
+
+func (p *proxy) serve() {
+	defer p.wg.Done()
+	for {
+		in, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		out, err := net.Dial("tcp", p.target)
+		if err != nil {
+			in.Close()
+			continue
+		}
+		p.mu.Lock()
+		p.conns = append(p.conns, in, out)
+		p.mu.Unlock()
+		p.wg.Add(2)
+		go p.pipe(in, out)
+		go p.pipe(out, in)
+	}
+}
+
+func (p *proxy) pipe(dst, src net.Conn) {
+	defer p.wg.Done()
+	_, _ = io.Copy(dst, src)
+	dst.Close()
+	src.Close()
+}
+
+// cut drops the open connections, new ones are still forwarded
+func (p *proxy) cut() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.conns {
+		c.Close()
+	}
+	p.conns = nil
+}
+
+// close stops forwarding and drops the open connections
+func (p *proxy) close() {
+	p.ln.Close()
+	p.cut()
+	p.wg.Wait()
+}
+
+// recorder collects what a client hands to its handlers
+type recorder struct {
+	msgs   chan *pb.ChatMessage
+	states chan chatclient.State
+}
+
+func newRecorder() *recorder {
+	return &recorder{msgs: make(chan *pb.ChatMessage, 256), states: make(chan chatclient.State, 64)}
+}
+
+func (r *recorder) options() []chatclient.Option {
+	return []chatclient.Option{
+		chatclient.WithHandler(func(msg *pb.ChatMessage) {
+			select {
+			case r.msgs <- msg:
+			default:
+			}
+		}),
+		chatclient.WithStateHandler(func(s chatclient.State, _ error) {
+			select {
+			case r.states <- s:
+			default:
+			}
+		}),
+	}
+}
+
+func (r *recorder) expectState(t *testing.T, want chatclient.State) {
+	t.Helper()
+	timer := time.NewTimer(chattest.DefaultTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case s := <-r.states:
+			if s == want {
+				return
+			}
+		case <-timer.C:
+			t.Fatalf("no %v state within %v", want, chattest.DefaultTimeout)
+		}
+	}
+}
+
+func (r *recorder) expectMessage(t *testing.T, match func(*pb.ChatMessage) bool) *pb.ChatMessage {
+	t.Helper()
+	timer := time.NewTimer(chattest.DefaultTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case msg := <-r.msgs:
+			if match(msg) {
+				return msg
+			}
+		case <-timer.C:
+			t.Fatalf("no matching message within %v", chattest.DefaultTimeout)
+			return nil
+		}
+	}
+}
+
+func connect(t *testing.T, addr, username string, opts ...chatclient.Option) *chatclient.Client {
+	t.Helper()
+	c, err := chatclient.Connect(context.Background(), addr, username, opts...)
+	if err != nil {
+		t.Fatalf("connect %s: %v", username, err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestReconnectRejoinsRoom(t *testing.T) {
+	env := chattest.Start(t)
+	p := newProxy(t, env.GRPCAddr)
+	rec := newRecorder()
+	opts := append(rec.options(), chatclient.WithBackoff(10*time.Millisecond, 100*time.Millisecond))
+	alice := connect(t, p.addr(), "alice", opts...)
+	rec.expectState(t, chatclient.Connected)
+
+	if err := alice.JoinRoom("dev"); err != nil {
+		t.Fatal(err)
+	}
+	rec.expectMessage(t, func(m *pb.ChatMessage) bool { return m.GetRoomChange().GetTo() == "dev" })
+
+	p.cut()
+	rec.expectState(t, chatclient.Reconnecting)
+	rec.expectState(t, chatclient.Connected)
+	if alice.Room() != "dev" {
+		t.Errorf("room after reconnect = %q, want dev", alice.Room())
+	}
+
+	bob := env.DialGRPC(t, "bob")
+	bob.Send(t, "/join dev")
+	bob.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.GetRoomChange().GetTo() == "dev" })
+	bob.Send(t, "after the drop")
+	msg := rec.expectMessage(t, func(m *pb.ChatMessage) bool { return m.Text == "after the drop" })
+	if msg.Room != "dev" {
+		t.Errorf("message arrived from room %q, want dev", msg.Room)
+	}
+	select {
+	case <-alice.Done():
+		t.Fatalf("client stopped: %v", alice.Err())
+	default:
+	}
+}
+
+func TestDropWithoutReconnect(t *testing.T) {
+	env := chattest.Start(t)
+	p := newProxy(t, env.GRPCAddr)
+	alice := connect(t, p.addr(), "alice", chatclient.WithReconnect(false))
+
+	p.cut()
+	select {
+	case <-alice.Done():
+	case <-time.After(chattest.DefaultTimeout):
+		t.Fatal("client still running after its stream dropped")
+	}
+	if alice.Err() == nil {
+		t.Error("Err() = nil after a dropped stream, want the stream error")
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	env := chattest.Start(t)
+	p := newProxy(t, env.GRPCAddr)
+	alice := connect(t, p.addr(), "alice",
+		chatclient.WithBackoff(time.Millisecond, 10*time.Millisecond),
+		chatclient.WithMaxRetries(3))
+
+	p.close()
+	select {
+	case <-alice.Done():
+	case <-time.After(chattest.DefaultTimeout):
+		t.Fatal("client still retrying after its retry cap")
+	}
+	if alice.Err() == nil {
+		t.Error("Err() = nil after giving up, want the last join error")
+	}
+}
+
+func TestCloseEndsStream(t *testing.T) {
+	env := chattest.Start(t)
+	rec := newRecorder()
+	alice := connect(t, env.GRPCAddr, "alice", rec.options()...)
+	rec.expectState(t, chatclient.Connected)
+
+	if err := alice.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case <-alice.Done():
+	default:
+		t.Fatal("Done not closed after Close returned")
+	}
+	if err := alice.Err(); err != nil {
+		t.Errorf("Err() = %v after a clean close, want nil", err)
+	}
+	rec.expectState(t, chatclient.Closed)
+	if err := alice.Send("too late"); err == nil {
+		t.Error("Send after Close succeeded")
+	}
+
+	deadline := time.Now().Add(chattest.DefaultTimeout)
+	for slices.Contains(env.Server.OnlineUsers(), "alice") {
+		if time.Now().After(deadline) {
+			t.Fatal("server still lists alice after Close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
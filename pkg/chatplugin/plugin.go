@@ -0,0 +1,53 @@
+// Package chatplugin runs chat server plugins. A plugin is a gRPC server
+// implementing pb.PluginServer; the chat server either starts it as a
+// child process, which Serve supports, or connects to one already
+// listening, see ListenAndServe.
+package chatplugin
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// HandshakePrefix starts the first line a plugin started by the chat
+// server prints on stdout, the address it listens on follows
+const HandshakePrefix = "CHATPLUGIN|1|"
+
+// Serve listens on a free local port, tells the chat server that started
+// this process about it and serves impl until the server goes away,
+// which closes stdin
+func Serve(impl pb.PluginServer) error {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer()
+	pb.RegisterPluginServer(gs, impl)
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		gs.GracefulStop()
+	}()
+	if _, err := fmt.Fprintf(os.Stdout, "%s%s\n", HandshakePrefix, lis.Addr()); err != nil {
+		lis.Close()
+		return err
+	}
+	return gs.Serve(lis)
+}
+
+// ListenAndServe serves impl on addr for chat servers configured to
+// connect to it
+func ListenAndServe(addr string, impl pb.PluginServer) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer()
+	pb.RegisterPluginServer(gs, impl)
+	return gs.Serve(lis)
+}
@@ -0,0 +1,275 @@
+package chatserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// AbuseConfig tunes the heuristics that score every chat message of a
+// sender. The scores of the messages sent within WindowSeconds add up,
+// and senders whose sum reaches a threshold are put in slow mode or
+// shadow banned: their messages look sent to them but reach nobody. A
+// zero threshold disables its action.
+type AbuseConfig struct {
+	WindowSeconds int `json:"windowSeconds"` // DefaultAbuseWindow when 0
+
+	RepeatWeight     float64 `json:"repeatWeight"`    // per earlier message in the window with the same text
+	LinkWeight       float64 `json:"linkWeight"`      // per link sent in the window, on messages with links
+	NewSenderWeight  float64 `json:"newSenderWeight"` // on every message while the sender joined less than NewSenderSeconds ago, fading out
+	NewSenderSeconds int     `json:"newSenderSeconds"`
+
+	SlowModeScore    float64 `json:"slowModeScore"`
+	SlowModeInterval int     `json:"slowModeInterval"` // seconds between messages in slow mode
+	SlowModeSeconds  int     `json:"slowModeSeconds"`  // how long slow mode lasts
+	ShadowBanScore   float64 `json:"shadowBanScore"`
+	ShadowBanSeconds int     `json:"shadowBanSeconds"` // how long a shadow ban lasts
+}
+
+// DefaultAbuseWindow is how long messages count towards a sender's score
+// unless AbuseConfig.WindowSeconds is set
+const DefaultAbuseWindow = time.Minute
+
+// LoadAbuseConfig reads and validates a JSON AbuseConfig
+func LoadAbuseConfig(path string) (AbuseConfig, error) {
+	var cfg AbuseConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid abuse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks for negative values and actions without a duration
+func (c AbuseConfig) Validate() error {
+	var errs []error
+	for name, v := range map[string]float64{
+		"windowSeconds": float64(c.WindowSeconds), "repeatWeight": c.RepeatWeight, "linkWeight": c.LinkWeight,
+		"newSenderWeight": c.NewSenderWeight, "newSenderSeconds": float64(c.NewSenderSeconds),
+		"slowModeScore": c.SlowModeScore, "slowModeInterval": float64(c.SlowModeInterval), "slowModeSeconds": float64(c.SlowModeSeconds),
+		"shadowBanScore": c.ShadowBanScore, "shadowBanSeconds": float64(c.ShadowBanSeconds),
+	} {
+		if v < 0 {
+			errs = append(errs, fmt.Errorf("%s cannot be negative", name))
+		}
+	}
+	if c.SlowModeScore > 0 && (c.SlowModeInterval == 0 || c.SlowModeSeconds == 0) {
+		errs = append(errs, errors.New("slowModeScore needs slowModeInterval and slowModeSeconds"))
+	}
+	if c.ShadowBanScore > 0 && c.ShadowBanSeconds == 0 {
+		errs = append(errs, errors.New("shadowBanScore needs shadowBanSeconds"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c AbuseConfig) enabled() bool {
+	return c.SlowModeScore > 0 || c.ShadowBanScore > 0
+}
+
+func (c AbuseConfig) window() time.Duration {
+	if c.WindowSeconds > 0 {
+		return time.Duration(c.WindowSeconds) * time.Second
+	}
+	return DefaultAbuseWindow
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// abuseVerdict is what happens to a scored message
+type abuseVerdict int
+
+const (
+	abuseAllow       abuseVerdict = iota
+	abuseSlowStarted              // allowed, the sender is in slow mode from now on
+	abuseSlowed                   // refused, sent too soon in slow mode
+	abuseShadowed                 // shown to the sender only
+)
+
+// abuseState is what the heuristics remember of one sender
+type abuseState struct {
+	joined      time.Time
+	recent      []scoredMessage // within the window, oldest first
+	lastSent    time.Time
+	slowUntil   time.Time
+	shadowUntil time.Time
+}
+
+type scoredMessage struct {
+	at    time.Time
+	text  uint64 // hash of the normalized text
+	links int
+	score float64
+}
+
+// abuseDetector scores messages per AbuseConfig
+type abuseDetector struct {
+	cfg   AbuseConfig
+	mu    sync.Mutex
+	users map[string]*abuseState
+	swept time.Time
+}
+
+// joined records when user first joined, the join age of their messages
+// counts from it
+func (d *abuseDetector) joined(user string, now time.Time) {
+	if !d.cfg.enabled() {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stateLocked(user, now)
+}
+
+// stateLocked returns the state of user, creating it with joined. Senders
+// swept since they joined are no longer new, check creates them with the
+// zero time.
+func (d *abuseDetector) stateLocked(user string, joined time.Time) *abuseState {
+	if d.users == nil {
+		d.users = make(map[string]*abuseState)
+	}
+	st, ok := d.users[user]
+	if !ok {
+		st = &abuseState{joined: joined}
+		d.users[user] = st
+	}
+	return st
+}
+
+// rename carries the state of oldName over to newName, so a slowed or
+// shadow banned sender cannot start afresh with /nick. oldName keeps its
+// state for the user's other streams until it is swept.
+func (d *abuseDetector) rename(oldName, newName string) {
+	if !d.cfg.enabled() {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.users, newName)
+	if st, ok := d.users[oldName]; ok {
+		moved := *st
+		moved.recent = slices.Clone(st.recent)
+		d.users[newName] = &moved
+	}
+}
+
+// check scores msg from user and decides what happens to it
+func (d *abuseDetector) check(user string, msg *pb.ChatMessage, now time.Time) abuseVerdict {
+	if !d.cfg.enabled() {
+		return abuseAllow
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cfg, window := d.cfg, d.cfg.window()
+	d.sweepLocked(now, window)
+	st := d.stateLocked(user, time.Time{})
+	for len(st.recent) > 0 && now.Sub(st.recent[0].at) >= window {
+		st.recent = st.recent[1:]
+	}
+	if now.Before(st.shadowUntil) {
+		return abuseShadowed
+	}
+	if now.Before(st.slowUntil) && now.Sub(st.lastSent) < seconds(cfg.SlowModeInterval) {
+		return abuseSlowed
+	}
+
+	m := scoredMessage{at: now, text: normalizedHash(msg), links: len(linkPattern.FindAllString(msg.Text, -1))}
+	var repeats, links int
+	for _, prev := range st.recent {
+		if prev.text == m.text {
+			repeats++
+		}
+		links += prev.links
+	}
+	repeat := cfg.RepeatWeight * float64(repeats)
+	linkScore := 0.0
+	if m.links > 0 {
+		linkScore = cfg.LinkWeight * float64(links+m.links)
+	}
+	newSender := 0.0
+	if age, young := now.Sub(st.joined), seconds(cfg.NewSenderSeconds); age < young {
+		newSender = cfg.NewSenderWeight * (1 - float64(age)/float64(young))
+	}
+	m.score = repeat + linkScore + newSender
+	st.recent = append(st.recent, m)
+	total := 0.0
+	for _, prev := range st.recent {
+		total += prev.score
+	}
+	if m.score > 0 {
+		log.Printf("Abuse score of '%s': %.2f (repeat %.2f, links %.2f, new sender %.2f), %.2f in the last %s", user, m.score, repeat, linkScore, newSender, total, window)
+	}
+
+	switch {
+	case cfg.ShadowBanScore > 0 && total >= cfg.ShadowBanScore:
+		st.shadowUntil = now.Add(seconds(cfg.ShadowBanSeconds))
+		log.Printf("Shadow banned '%s' until %s, score %.2f", user, st.shadowUntil.UTC().Format(time.RFC3339), total)
+		return abuseShadowed
+	case cfg.SlowModeScore > 0 && total >= cfg.SlowModeScore && !now.Before(st.slowUntil):
+		st.slowUntil = now.Add(seconds(cfg.SlowModeSeconds))
+		st.lastSent = now
+		log.Printf("Slow mode for '%s' until %s, score %.2f", user, st.slowUntil.UTC().Format(time.RFC3339), total)
+		return abuseSlowStarted
+	}
+	st.lastSent = now
+	return abuseAllow
+}
+
+// sweepLocked forgets senders with nothing left to remember, at most once
+// per window
+func (d *abuseDetector) sweepLocked(now time.Time, window time.Duration) {
+	if now.Sub(d.swept) < window {
+		return
+	}
+	d.swept = now
+	young := seconds(d.cfg.NewSenderSeconds)
+	for user, st := range d.users {
+		quiet := len(st.recent) == 0 || now.Sub(st.recent[len(st.recent)-1].at) >= window
+		if quiet && now.After(st.slowUntil) && now.After(st.shadowUntil) && now.Sub(st.joined) >= young {
+			delete(d.users, user)
+		}
+	}
+}
+
+// normalizedHash hashes the text and code of msg ignoring case and
+// spacing, so trivially varied repeats still match
+func normalizedHash(msg *pb.ChatMessage) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(strings.Join(strings.Fields(msg.Text), " "))))
+	if code := msg.GetCode(); code != nil {
+		h.Write([]byte{0})
+		h.Write([]byte(strings.Join(strings.Fields(code.Content), " ")))
+	}
+	return h.Sum64()
+}
+
+// shadowDeliver answers a shadow banned sender as if msg was sent: a PM
+// is copied back like any other, and nothing else happens
+func (s *ChatServer) shadowDeliver(stream pb.ChatService_RealtimeChatServer, clientID, room string, msg *pb.ChatMessage) {
+	msg.Id = s.newID()
+	msg.Timestamp = time.Now().UnixMilli()
+	msg.Type = pb.MessageType_TYPE_CHAT
+	msg.Room = ""
+	if msg.RecipientUser == "" {
+		msg.Room = room
+	} else if err := stream.Send(msg); err != nil {
+		log.Printf("Failed to send PM copy back to sender %s: %v", clientID, err)
+	}
+	log.Printf("Dropped message from shadow banned '%s'", msg.User)
+}
@@ -0,0 +1,160 @@
+package chatserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// adminServer implements the AdminService RPCs
+type adminServer struct {
+	pb.UnimplementedAdminServiceServer
+	s *ChatServer
+}
+
+// authorize checks the bearer token in the call's metadata
+func (a *adminServer) authorize(ctx context.Context) error {
+	if a.s.adminToken == "" {
+		return status.Error(codes.PermissionDenied, "admin API is disabled")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.s.adminToken)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid admin token")
+}
+
+// authenticate asks the Authenticator whether the caller may act as
+// user. Status errors pass through, anything else is PermissionDenied.
+func (s *ChatServer) authenticate(ctx context.Context, user string) error {
+	if s.auth == nil {
+		return nil
+	}
+	err := s.auth.Authenticate(ctx, user)
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.PermissionDenied, err.Error())
+}
+
+// authorizeUser checks an RPC that reads or changes the data of user: the
+// caller must be accepted as user the way a stream joining as user is, or
+// present the admin token
+func (s *ChatServer) authorizeUser(ctx context.Context, user string) error {
+	if user == "" {
+		return status.Error(codes.InvalidArgument, "user cannot be empty")
+	}
+	err := s.authenticate(ctx, user)
+	if err != nil && (&adminServer{s: s}).authorize(ctx) == nil {
+		return nil
+	}
+	return err
+}
+
+// ExportRoom streams the public messages of a room in the requested
+// range, one at a time so large rooms are never held in memory twice
+func (a *adminServer) ExportRoom(req *pb.ExportRequest, stream pb.AdminService_ExportRoomServer) error {
+	ctx := stream.Context()
+	if err := a.authorize(ctx); err != nil {
+		return err
+	}
+	room := DefaultRoom
+	if req.Room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(req.Room); !ok {
+			return status.Error(codes.InvalidArgument, "invalid room name")
+		}
+	}
+	var from, to time.Time
+	if req.From > 0 {
+		from = time.UnixMilli(req.From)
+	}
+	if req.To > 0 {
+		to = time.UnixMilli(req.To)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return status.Error(codes.InvalidArgument, "from must be before to")
+	}
+
+	if rr, ok := a.s.store.(RoomReader); ok {
+		return rr.RoomMessages(withReadQuery(ctx, QueryExport), room, from, to, stream.Send)
+	}
+	for _, msg := range a.s.history.latest(room, a.s.history.size) {
+		if !inRange(msg, from, to) {
+			continue
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportMessages saves messages from another platform to the store. They
+// keep their timestamps and get no sequence, so they show up in exports
+// but never in the live history or unread counts.
+func (a *adminServer) ImportMessages(stream pb.AdminService_ImportMessagesServer) error {
+	ctx := stream.Context()
+	if err := a.authorize(ctx); err != nil {
+		return err
+	}
+	if a.s.store == nil {
+		return status.Error(codes.FailedPrecondition, "the server has no message store")
+	}
+	summary := &pb.ImportSummary{}
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			log.Printf("Imported %d messages, skipped %d", summary.Imported, summary.Skipped)
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+		if !a.prepareImport(msg) {
+			summary.Skipped++
+			continue
+		}
+		if err := a.s.store.SaveMessage(ctx, msg); err != nil {
+			return status.Errorf(codes.Internal, "store: %v", err)
+		}
+		summary.Imported++
+	}
+}
+
+// prepareImport validates an imported message and fills in what the
+// server owns
+func (a *adminServer) prepareImport(msg *pb.ChatMessage) bool {
+	room, ok := normalizeRoom(msg.Room)
+	if !ok || msg.User == "" || strings.EqualFold(msg.User, "System") || msg.Text == "" || msg.Timestamp <= 0 {
+		return false
+	}
+	if max := a.s.limits.MaxMessageLength; max > 0 && len(msg.Text) > max {
+		return false
+	}
+	if key, _ := a.s.checkMetadata(msg.Metadata); key != "" {
+		return false
+	}
+	msg.Id = a.s.newID()
+	msg.Room = room
+	msg.Type = pb.MessageType_TYPE_CHAT
+	msg.RecipientUser, msg.Seq, msg.ClientMsgId = "", 0, ""
+	msg.Payload, msg.System, msg.Notify = nil, nil, false
+	return true
+}
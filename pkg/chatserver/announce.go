@@ -0,0 +1,269 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultLeaveGrace delays leave announcements so a quick reconnect
+// shows neither the leave nor the rejoin
+const DefaultLeaveGrace = 5 * time.Second
+
+// Join and leave announcements beyond DefaultAnnounceBurst within
+// DefaultAnnounceWindow are collected into one summary per window
+const (
+	DefaultAnnounceBurst  = 5
+	DefaultAnnounceWindow = 2 * time.Second
+)
+
+// maxAnnounceNames bounds the names listed in a summary
+const maxAnnounceNames = 5
+
+// announcer debounces and batches join/leave announcements so that
+// reconnect storms do not drown the chat
+type announcer struct {
+	mu      sync.Mutex
+	grace   time.Duration
+	burst   int
+	window  time.Duration
+	leaving map[string]*time.Timer // pending leave announcement per user
+
+	windowStart time.Time
+	count       int      // announcements in the current window
+	joined      []string // batched while a burst lasts
+	left        []string
+	flush       *time.Timer // sends the batch, nil when nothing is batched
+}
+
+// announceJoin announces that user joined, unless it is back from a leave
+// that was not announced yet
+func (s *ChatServer) announceJoin(user, excludeID string) {
+	a := &s.announce
+	a.mu.Lock()
+	if t, ok := a.leaving[user]; ok {
+		t.Stop()
+		delete(a.leaving, user)
+		a.mu.Unlock()
+		return
+	}
+	a.mu.Unlock()
+	s.emitAnnouncement(true, user, excludeID)
+}
+
+// announceLeave announces that user left once the grace period passes
+// without it coming back
+func (s *ChatServer) announceLeave(user string) {
+	a := &s.announce
+	if a.grace <= 0 {
+		s.emitAnnouncement(false, user, "")
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if t, ok := a.leaving[user]; ok {
+		t.Stop()
+	}
+	if a.leaving == nil {
+		a.leaving = make(map[string]*time.Timer)
+	}
+	var t *time.Timer
+	t = time.AfterFunc(a.grace, func() {
+		a.mu.Lock()
+		if a.leaving[user] != t {
+			a.mu.Unlock()
+			return
+		}
+		delete(a.leaving, user)
+		a.mu.Unlock()
+		if s.ctx.Err() == nil && !s.isOnline(user) {
+			s.emitAnnouncement(false, user, "")
+		}
+	})
+	a.leaving[user] = t
+}
+
+// emitAnnouncement announces a join or leave to the user's audience, or adds it to the batch
+// while announcements arrive faster than the burst allows
+func (s *ChatServer) emitAnnouncement(joined bool, user, excludeID string) {
+	a := &s.announce
+	a.mu.Lock()
+	now := time.Now()
+	if now.Sub(a.windowStart) >= a.window {
+		a.windowStart = now
+		a.count = 0
+	}
+	a.count++
+	if a.burst <= 0 || (a.flush == nil && a.count <= a.burst) {
+		a.mu.Unlock()
+		s.broadcastPresence([]string{user}, func(users []string) *pb.ChatMessage {
+			return summary(joined, users)
+		}, excludeID)
+		return
+	}
+
+	// a join and leave of the same user within one batch cancel out
+	if joined {
+		if i := slices.Index(a.left, user); i >= 0 {
+			a.left = slices.Delete(a.left, i, i+1)
+		} else {
+			a.joined = append(a.joined, user)
+		}
+	} else {
+		if i := slices.Index(a.joined, user); i >= 0 {
+			a.joined = slices.Delete(a.joined, i, i+1)
+		} else {
+			a.left = append(a.left, user)
+		}
+	}
+	if a.flush == nil {
+		a.flush = time.AfterFunc(a.window, s.flushAnnouncements)
+	}
+	a.mu.Unlock()
+}
+
+// flushAnnouncements announces the batched joins and leaves, each
+// connection hears about the users in its audience
+func (s *ChatServer) flushAnnouncements() {
+	a := &s.announce
+	a.mu.Lock()
+	joined, left := a.joined, a.left
+	a.joined, a.left, a.flush = nil, nil, nil
+	// keep batching if the burst goes on
+	a.windowStart = time.Now()
+	a.count = a.burst
+	a.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return
+	}
+	if len(joined) > 0 {
+		s.broadcastPresence(joined, func(users []string) *pb.ChatMessage { return summary(true, users) }, "")
+	}
+	if len(left) > 0 {
+		s.broadcastPresence(left, func(users []string) *pb.ChatMessage { return summary(false, users) }, "")
+	}
+}
+
+func announcement(joined bool, user string) *pb.ChatMessage {
+	key := i18n.UserLeft
+	if joined {
+		key = i18n.UserJoined
+	}
+	return announced(joined, []string{user}, systemText(key, "user", user))
+}
+
+// summary announces several users at once, e.g. "7 users joined the
+// chat: a, b, c, d, e and 2 more"
+func summary(joined bool, users []string) *pb.ChatMessage {
+	if len(users) == 1 {
+		return announcement(joined, users[0])
+	}
+	key, moreKey := i18n.UsersLeft, i18n.UsersLeftMore
+	if joined {
+		key, moreKey = i18n.UsersJoined, i18n.UsersJoinedMore
+	}
+	count := strconv.Itoa(len(users))
+	names := strings.Join(users[:min(len(users), maxAnnounceNames)], ", ")
+	if n := len(users) - maxAnnounceNames; n > 0 {
+		return announced(joined, users, systemText(moreKey, "count", count, "names", names, "more", strconv.Itoa(n)))
+	}
+	return announced(joined, users, systemText(key, "count", count, "names", names))
+}
+
+// membership marks a system message as a join or leave announcement
+func membership(joined bool, msg *pb.ChatMessage) *pb.ChatMessage {
+	msg.Type = pb.MessageType_TYPE_LEAVE
+	if joined {
+		msg.Type = pb.MessageType_TYPE_JOIN
+	}
+	return msg
+}
+
+// announced marks a system message as the announcement that users came
+// online or went offline, room moves are only a membership
+func announced(joined bool, users []string, msg *pb.ChatMessage) *pb.ChatMessage {
+	msg = membership(joined, msg)
+	msg.Payload = &pb.ChatMessage_Members{Members: &pb.Members{Users: users}}
+	return msg
+}
+
+// sendRoster gives a newly joined connection everyone online it may hear
+// about, including users whose leave is still held back by the grace
+// period so the roster agrees with the announcements
+func (s *ChatServer) sendRoster(clientID string) {
+	users := s.OnlineUsers()
+	s.announce.mu.Lock()
+	for user := range s.announce.leaving {
+		if !slices.Contains(users, user) {
+			users = append(users, user)
+		}
+	}
+	s.announce.mu.Unlock()
+	users = s.presenceFilter(clientID, users)
+	slices.Sort(users)
+	s.sendToConn(clientID, &pb.ChatMessage{
+		User:    "System",
+		Type:    pb.MessageType_TYPE_ROSTER,
+		Payload: &pb.ChatMessage_Members{Members: &pb.Members{Users: users}},
+	})
+}
+
+// Announce sends an announcement from the administrators to the
+// connections in a room, or to every connection of this server
+func (a *adminServer) Announce(ctx context.Context, req *pb.AnnounceRequest) (*pb.AnnounceResult, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s := a.s
+	text := strings.TrimSpace(req.Text)
+	max := s.limits.MaxMessageLength
+	if max <= 0 {
+		max = maxWelcomeLength
+	}
+	switch {
+	case text == "":
+		return nil, status.Error(codes.InvalidArgument, "text is required")
+	case len(text) > max:
+		return nil, status.Errorf(codes.InvalidArgument, "announcements are limited to %d bytes", max)
+	}
+	room := req.Room
+	if room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(room); !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+		if owner := s.roomOwner(room); owner != "" {
+			return nil, status.Errorf(codes.FailedPrecondition, "#%s is served by %s", room, owner)
+		}
+	}
+	msg := systemText(i18n.Announcement, "text", text)
+	msg.Room = room
+
+	s.mu.RLock()
+	var targets []connection
+	for _, conn := range s.connections {
+		if (room == "" || conn.in(room)) && conn.wants(room, msg) {
+			targets = append(targets, conn)
+		}
+	}
+	s.fanout(msg, targets)
+	s.mu.RUnlock()
+	s.watchers.deliver(room, msg)
+	if room == "" {
+		log.Printf("Announced to %d connections: %s", len(targets), text)
+	} else {
+		log.Printf("Announced to %d connections in #%s: %s", len(targets), room, text)
+	}
+	return &pb.AnnounceResult{Connections: int32(len(targets))}, nil
+}
@@ -0,0 +1,179 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/assistant"
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// AssistantName is who the assistant answers as, public messages
+	// starting with "@assistant" are questions for it
+	AssistantName = "assistant"
+	// assistantTimeout bounds one answer
+	assistantTimeout = 2 * time.Minute
+	// assistantContext is how many earlier room messages go along with a
+	// question
+	assistantContext = 20
+	// editInterval spaces the edits of a streamed answer
+	editInterval = 300 * time.Millisecond
+)
+
+// parseAssistant returns the question of a public "@assistant ..." message
+func parseAssistant(msg *pb.ChatMessage) (string, bool) {
+	if msg.RecipientUser != "" || msg.GetCode() != nil {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(msg.Text, "@"+AssistantName)
+	if !ok {
+		return "", false
+	}
+	q := strings.TrimLeft(rest, " ,:，：")
+	if len(q) == len(rest) && rest != "" {
+		return "", false // a longer name such as @assistants
+	}
+	q = strings.TrimSpace(q)
+	return q, q != ""
+}
+
+// reservedName reports names users cannot take
+func (s *ChatServer) reservedName(name string) bool {
+	return strings.EqualFold(name, "System") || (s.assistant != nil && strings.EqualFold(name, AssistantName))
+}
+
+// askAssistant posts a placeholder answer to the room of question and
+// fills it in with edits while the answer streams in
+func (s *ChatServer) askAssistant(question *pb.ChatMessage, text string) {
+	q := assistant.Question{
+		User:    question.User,
+		Room:    question.Room,
+		Text:    text,
+		Context: s.assistantContext(question),
+	}
+	answer := &pb.ChatMessage{
+		User:     AssistantName,
+		Text:     "…",
+		Metadata: map[string]string{"assistant.question": question.Id},
+	}
+	s.assign(answer, question.Room)
+	s.broadcastChat(s.ctx, answer, "")
+	s.pushUnread(answer)
+	go s.streamAnswer(answer, q)
+}
+
+// assistantContext collects the room messages before question
+func (s *ChatServer) assistantContext(question *pb.ChatMessage) []assistant.Turn {
+	var msgs []*pb.ChatMessage
+	for _, msg := range s.history.latest(question.Room, assistantContext+1) {
+		if msg.Seq < question.Seq {
+			msgs = append(msgs, msg)
+		}
+	}
+	turns := s.turns(msgs)
+	if len(turns) > assistantContext {
+		turns = turns[len(turns)-assistantContext:]
+	}
+	return turns
+}
+
+// turns converts messages for the assistant, leaving out those of users
+// who opted out and, for privacy, of users whose preferences cannot be
+// read
+func (s *ChatServer) turns(msgs []*pb.ChatMessage) []assistant.Turn {
+	optedOut := make(map[string]bool)
+	var turns []assistant.Turn
+	for _, msg := range msgs {
+		if msg.Text == "" || msg.GetCode() != nil || msg.EphemeralTo != "" {
+			continue
+		}
+		if msg.User == AssistantName && msg.Metadata["assistant.question"] != "" {
+			turns = append(turns, assistant.Turn{User: msg.User, Text: msg.Text, Assistant: true})
+			continue
+		}
+		out, ok := optedOut[msg.User]
+		if !ok {
+			prefs, err := s.prefs.GetPreferences(s.ctx, msg.User)
+			out = err != nil || prefs.GetAssistantOptOut()
+			optedOut[msg.User] = out
+		}
+		if !out {
+			turns = append(turns, assistant.Turn{User: msg.User, Text: msg.Text})
+		}
+	}
+	return turns
+}
+
+// streamAnswer relays the answer to q as edits of answer, at most one
+// every editInterval, then stores the final text
+func (s *ChatServer) streamAnswer(answer *pb.ChatMessage, q assistant.Question) {
+	ctx, cancel := context.WithTimeout(s.ctx, assistantTimeout)
+	defer cancel()
+
+	var (
+		partial  strings.Builder
+		revision uint32
+		last     time.Time
+	)
+	text, err := s.assistant.Answer(ctx, q, func(delta string) {
+		partial.WriteString(delta)
+		if time.Since(last) < editInterval {
+			return
+		}
+		last = time.Now()
+		revision++
+		s.broadcastRoom(answer.Room, editEvent(answer, s.clipAnswer(partial.String()), revision, false), "")
+	})
+	if err != nil {
+		log.Printf("Assistant failed to answer %s in #%s: %v", q.User, q.Room, err)
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.broadcastRoom(answer.Room, systemText(i18n.AssistantFailed), "")
+	}
+	if text == "" {
+		text = partial.String()
+	}
+	text = s.clipAnswer(strings.TrimSpace(text))
+	if text == "" {
+		text = answer.Text
+	}
+
+	final := proto.Clone(answer).(*pb.ChatMessage)
+	final.Text = text
+	s.history.replace(final)
+	s.save(s.ctx, final)
+	event := editEvent(final, text, revision+1, true)
+	event.Text = text // shown as is by clients without edit support
+	s.broadcastRoom(final.Room, event, "")
+}
+
+// clipAnswer cuts an answer to the message length limit
+func (s *ChatServer) clipAnswer(text string) string {
+	if max := s.limits.MaxMessageLength; max > 0 && len(text) > max {
+		return truncateUTF8(text, max)
+	}
+	return text
+}
+
+// editEvent announces the new text of msg
+func editEvent(msg *pb.ChatMessage, text string, revision uint32, done bool) *pb.ChatMessage {
+	return &pb.ChatMessage{
+		User:      msg.User,
+		Room:      msg.Room,
+		Type:      pb.MessageType_TYPE_EDIT,
+		Timestamp: time.Now().UnixMilli(),
+		Payload: &pb.ChatMessage_Edit{Edit: &pb.MessageEdit{
+			MessageId: msg.Id,
+			Text:      text,
+			Revision:  revision,
+			Done:      done,
+		}},
+	}
+}
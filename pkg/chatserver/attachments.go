@@ -0,0 +1,469 @@
+package chatserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/internal/attachmeta"
+	"realTimeChat/pkg/avscan"
+	"realTimeChat/pkg/ids"
+	"realTimeChat/pkg/objstore"
+	pb "realTimeChat/proto/chat"
+)
+
+// limits for AttachmentService
+const (
+	MaxAttachmentSize = 25 << 20
+	MaxChunkSize      = 1 << 20
+
+	downloadChunkSize = 64 << 10
+	uploadExpiry      = 24 * time.Hour // unfinished uploads are dropped after this
+)
+
+// attachmentIDs names finished uploads. Always random whatever
+// WithIDGenerator says: an ID is all it takes to download a file, so it
+// must not be guessable from another like a Snowflake or ULID.
+var attachmentIDs = ids.NewRandom()
+
+var (
+	uploadIDPattern     = regexp.MustCompile(`^[A-Za-z0-9_-]{16,64}$`)
+	attachmentIDPattern = regexp.MustCompile(`^(?:[0-9a-f]{32}|[0-9A-HJKMNP-TV-Z]{26})$`) // ULIDs from older versions
+	sha256Pattern       = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// attachmentStore keeps unfinished uploads in dir/partial. Finished files
+// go to the object store, in dir unless configured otherwise, files the
+// virus scanner flagged below quarantine/, each with a JSON metadata
+// object.
+type attachmentStore struct {
+	dir     string
+	objects objstore.ObjectStore
+	mu      sync.Mutex
+	active  map[string]bool // upload IDs with an open stream
+}
+
+func newAttachmentStore(dir string, objects objstore.ObjectStore) (*attachmentStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "partial"), 0o750); err != nil {
+		return nil, err
+	}
+	if objects == nil {
+		local, err := objstore.NewLocal(dir)
+		if err != nil {
+			return nil, err
+		}
+		local.Sweep(uploadExpiry) // orphans of a crash
+		objects = local
+	}
+	return &attachmentStore{dir: dir, objects: objects, active: make(map[string]bool)}, nil
+}
+
+// meta reads the metadata of a finished file
+func (s *attachmentStore) meta(ctx context.Context, id string) (attachmeta.Meta, error) {
+	var m attachmeta.Meta
+	r, err := s.objects.Get(ctx, id+".json", 0)
+	if err != nil {
+		return m, err
+	}
+	defer r.Close()
+	err = json.NewDecoder(io.LimitReader(r, 64<<10)).Decode(&m)
+	return m, err
+}
+
+// isQuarantined reports whether the file id was quarantined
+func (s *attachmentStore) isQuarantined(ctx context.Context, id string) bool {
+	_, err := s.objects.Stat(ctx, "quarantine/"+id+".json")
+	return err == nil
+}
+
+func (s *attachmentStore) partPath(uploadID string) string {
+	return filepath.Join(s.dir, "partial", uploadID)
+}
+
+// upload is an unfinished upload held by one stream
+type upload struct {
+	s      *attachmentStore
+	id     string
+	meta   attachmeta.Meta
+	f      *os.File
+	offset int64
+}
+
+// begin opens the upload described by the first chunk, continuing a
+// previous attempt with the same upload ID
+func (s *attachmentStore) begin(first *pb.Chunk) (*upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[first.UploadId] {
+		return nil, status.Error(codes.Aborted, "upload is already in progress on another stream")
+	}
+	s.sweep()
+
+	want := attachmeta.Meta{Kind: "file", Size: first.Size, Name: attachmeta.CleanName(first.Name), SHA256: strings.ToLower(first.Sha256)}
+	path := s.partPath(first.UploadId)
+	var have attachmeta.Meta
+	if data, err := os.ReadFile(path + ".json"); err == nil && json.Unmarshal(data, &have) == nil {
+		if have.Size != want.Size || have.SHA256 != want.SHA256 {
+			return nil, status.Error(codes.FailedPrecondition, "upload_id belongs to a different file")
+		}
+	} else {
+		want.Created = time.Now().UTC()
+		data, _ := json.Marshal(want)
+		if err := os.WriteFile(path+".json", data, 0o640); err != nil {
+			return nil, status.Error(codes.Internal, "failed to store upload")
+		}
+		have = want
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to store upload")
+	}
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		f.Close()
+		return nil, status.Error(codes.Internal, "failed to store upload")
+	}
+	s.active[first.UploadId] = true
+	return &upload{s: s, id: first.UploadId, meta: have, f: f, offset: offset}, nil
+}
+
+// sweep drops unfinished uploads past uploadExpiry, callers hold s.mu
+func (s *attachmentStore) sweep() {
+	parts, _ := filepath.Glob(filepath.Join(s.dir, "partial", "*.json"))
+	for _, p := range parts {
+		id := strings.TrimSuffix(filepath.Base(p), ".json")
+		if info, err := os.Stat(p); err == nil && !s.active[id] && time.Since(info.ModTime()) > uploadExpiry {
+			os.Remove(strings.TrimSuffix(p, ".json"))
+			os.Remove(p)
+		}
+	}
+}
+
+// offset returns how much of an unfinished upload has arrived
+func (s *attachmentStore) offset(uploadID string) int64 {
+	info, err := os.Stat(s.partPath(uploadID))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// write appends a chunk, chunks must arrive in order
+func (u *upload) write(c *pb.Chunk) error {
+	if len(c.Data) > MaxChunkSize {
+		return status.Errorf(codes.InvalidArgument, "chunks are limited to %d bytes", MaxChunkSize)
+	}
+	if c.Offset != u.offset {
+		return status.Errorf(codes.FailedPrecondition, "chunk starts at %d, expected %d", c.Offset, u.offset)
+	}
+	if u.offset+int64(len(c.Data)) > u.meta.Size {
+		return status.Error(codes.InvalidArgument, "upload is larger than its size")
+	}
+	if _, err := u.f.Write(c.Data); err != nil {
+		return status.Error(codes.Internal, "failed to store upload")
+	}
+	u.offset += int64(len(c.Data))
+	return nil
+}
+
+// finish checks the complete file against its checksum and scanner, and
+// moves it to the finished files. Files with a threat are quarantined,
+// their metadata carries the threat.
+func (u *upload) finish(ctx context.Context, scanner avscan.Scanner, thumbnailSizes []int) (attachmeta.Meta, error) {
+	if u.offset != u.meta.Size {
+		return u.meta, status.Errorf(codes.FailedPrecondition, "upload is incomplete, %d of %d bytes received", u.offset, u.meta.Size)
+	}
+	path := u.s.partPath(u.id)
+	f, err := os.Open(path)
+	if err != nil {
+		return u.meta, status.Error(codes.Internal, "failed to read upload")
+	}
+	h := sha256.New()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	h.Write(head[:n])
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return u.meta, status.Error(codes.Internal, "failed to read upload")
+	}
+	if hex.EncodeToString(h.Sum(nil)) != u.meta.SHA256 {
+		u.discard()
+		return u.meta, status.Error(codes.DataLoss, "checksum mismatch, upload discarded")
+	}
+
+	m := u.meta
+	m.ID = attachmentIDs.New()
+	m.MimeType = http.DetectContentType(head[:n])
+	m.Created = time.Now().UTC()
+	if scanner != nil {
+		threat, err := scanFile(ctx, scanner, path)
+		if err != nil {
+			log.Printf("Failed to scan upload %s: %v", u.id, err)
+			return m, status.Error(codes.Unavailable, "virus scan failed, please retry the upload")
+		}
+		if threat != "" {
+			m.Threat = threat
+		}
+	}
+	if m.Threat == "" && strings.HasPrefix(m.MimeType, "image/") {
+		if err := u.s.processImage(ctx, path, &m, thumbnailSizes); err != nil {
+			log.Printf("Failed to process image %s: %v", u.id, err)
+			return m, status.Error(codes.Internal, "failed to store upload")
+		}
+	}
+	u.f.Close()
+	if err := u.s.store(ctx, path, m); err != nil {
+		log.Printf("Failed to store upload %s: %v", u.id, err)
+		u.s.removeThumbnails(ctx, m)
+		return m, status.Error(codes.Internal, "failed to store upload")
+	}
+	os.Remove(path)
+	os.Remove(path + ".json")
+	if m.Threat != "" {
+		return m, status.Errorf(codes.PermissionDenied, "upload quarantined: %s", m.Threat)
+	}
+	return m, nil
+}
+
+// store copies the finished file at path and its metadata to the object
+// store, then commits the file and its thumbnails so they are not
+// cleaned up as orphans
+func (s *attachmentStore) store(ctx context.Context, path string, m attachmeta.Meta) error {
+	key := m.ID
+	if m.Threat != "" {
+		key = "quarantine/" + m.ID
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = s.objects.Put(ctx, key, f, m.Size, m.MimeType)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(m)
+	if err := s.objects.Put(ctx, key+".json", bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		s.objects.Delete(ctx, key)
+		return err
+	}
+	keys := []string{key + ".json", key}
+	for _, t := range m.Thumbnails {
+		keys = append(keys, thumbKey(m.ID, t.Size))
+	}
+	for _, k := range keys {
+		if err := s.objects.Commit(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanFile runs scanner over the file at path
+func scanFile(ctx context.Context, scanner avscan.Scanner, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	ctx, cancel := context.WithTimeout(ctx, avscan.DefaultTimeout)
+	defer cancel()
+	return scanner.Scan(ctx, f)
+}
+
+// discard removes the upload so it starts over
+func (u *upload) discard() {
+	path := u.s.partPath(u.id)
+	os.Remove(path)
+	os.Remove(path + ".json")
+}
+
+// release lets another stream continue the upload
+func (u *upload) release() {
+	u.f.Close()
+	u.s.mu.Lock()
+	delete(u.s.active, u.id)
+	u.s.mu.Unlock()
+}
+
+// attachmentServer implements the AttachmentService RPCs
+type attachmentServer struct {
+	pb.UnimplementedAttachmentServiceServer
+	s *ChatServer
+}
+
+// UploadAttachment stores a file sent in chunks. A stream that breaks
+// keeps what arrived, the client asks GetUploadOffset and sends the rest
+// with the same upload ID.
+func (a *attachmentServer) UploadAttachment(stream pb.AttachmentService_UploadAttachmentServer) error {
+	store := a.s.attachments
+	if store == nil {
+		return status.Error(codes.Unavailable, "uploads are disabled")
+	}
+	// uploads carry no user, only the deployment's flag applies
+	if !a.s.features.enabled(FeatureUploads, "", "") {
+		return status.Error(codes.FailedPrecondition, "uploads are turned off")
+	}
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "empty upload")
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case !uploadIDPattern.MatchString(first.UploadId):
+		return status.Error(codes.InvalidArgument, "upload_id must be 16-64 letters, digits, - or _")
+	case first.Size < 0 || first.Size > MaxAttachmentSize:
+		return status.Errorf(codes.InvalidArgument, "files are limited to %d bytes", MaxAttachmentSize)
+	case !sha256Pattern.MatchString(strings.ToLower(first.Sha256)):
+		return status.Error(codes.InvalidArgument, "sha256 must be 64 hex digits")
+	}
+
+	up, err := store.begin(first)
+	if err != nil {
+		return err
+	}
+	defer up.release()
+	for chunk := first; ; {
+		if err := up.write(chunk); err != nil {
+			return err
+		}
+		chunk, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err // what arrived is kept for a retry
+		}
+	}
+	m, err := up.finish(stream.Context(), a.s.scanner, a.s.thumbnailSizes)
+	if m.Threat != "" {
+		a.s.quarantined(&pb.QuarantineReport{AttachmentId: m.ID, Name: m.Name, Size: m.Size, Threat: m.Threat, Source: newSessionInfo(stream.Context()).String()})
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Stored attachment %s %q (%d bytes)", m.ID, m.Name, m.Size)
+	return stream.SendAndClose(m.Proto())
+}
+
+// DownloadAttachment streams a stored file from the requested offset
+func (a *attachmentServer) DownloadAttachment(req *pb.AttachmentRequest, stream pb.AttachmentService_DownloadAttachmentServer) error {
+	store := a.s.attachments
+	if store == nil || !attachmentIDPattern.MatchString(req.Id) {
+		return status.Error(codes.NotFound, "attachment not found")
+	}
+	ctx := stream.Context()
+	m, key, err := store.resolve(ctx, req)
+	if err != nil {
+		return err
+	}
+	if req.Offset < 0 || req.Offset > m.Size {
+		return status.Errorf(codes.OutOfRange, "offset must be between 0 and %d", m.Size)
+	}
+	f, err := store.objects.Get(ctx, key, req.Offset)
+	if err != nil {
+		return status.Error(codes.NotFound, "attachment not found")
+	}
+	defer f.Close()
+
+	// the first chunk describes the file and is sent even when empty
+	chunk := &pb.Chunk{Offset: req.Offset, Name: m.Name, Size: m.Size, Sha256: m.SHA256}
+	buf := make([]byte, downloadChunkSize)
+	for first := true; ; first = false {
+		n, err := f.Read(buf)
+		if n > 0 || first {
+			chunk.Data = buf[:n]
+			if serr := stream.Send(chunk); serr != nil {
+				return serr
+			}
+			chunk = &pb.Chunk{Offset: chunk.Offset + int64(n)}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, "failed to read attachment")
+		}
+	}
+}
+
+// resolve finds the metadata and object key of the file or thumbnail req
+// asks for, thumbnails get their own size and no checksum
+func (s *attachmentStore) resolve(ctx context.Context, req *pb.AttachmentRequest) (attachmeta.Meta, string, error) {
+	m, err := s.meta(ctx, req.Id)
+	if err != nil {
+		if s.isQuarantined(ctx, req.Id) {
+			return m, "", status.Error(codes.FailedPrecondition, "attachment is quarantined")
+		}
+		return m, "", status.Error(codes.NotFound, "attachment not found")
+	}
+	if req.Thumbnail == 0 {
+		return m, req.Id, nil
+	}
+	t, ok := m.Thumbnail(int(req.Thumbnail))
+	if !ok {
+		return m, "", status.Error(codes.NotFound, "thumbnail not found")
+	}
+	key := thumbKey(req.Id, t.Size)
+	info, err := s.objects.Stat(ctx, key)
+	if err != nil {
+		return m, "", status.Error(codes.NotFound, "thumbnail not found")
+	}
+	m.Size, m.SHA256, m.MimeType = info.Size, "", t.MimeType
+	return m, key, nil
+}
+
+// GetDownloadUrl returns a presigned URL the file or thumbnail can be
+// downloaded from directly, when the object store supports it
+func (a *attachmentServer) GetDownloadUrl(ctx context.Context, req *pb.AttachmentRequest) (*pb.DownloadUrl, error) {
+	store := a.s.attachments
+	if store == nil || !attachmentIDPattern.MatchString(req.Id) {
+		return nil, status.Error(codes.NotFound, "attachment not found")
+	}
+	m, key, err := store.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	d := objstore.Download{ContentType: m.MimeType}
+	if req.Thumbnail == 0 {
+		d.FileName = m.Name
+	}
+	url, err := store.objects.PresignGet(ctx, key, d)
+	if errors.Is(err, objstore.ErrNotSupported) {
+		return nil, status.Error(codes.Unimplemented, "attachments are not in object storage, use DownloadAttachment")
+	}
+	if err != nil {
+		log.Printf("Failed to presign attachment %s: %v", req.Id, err)
+		return nil, status.Error(codes.Internal, "failed to sign download URL")
+	}
+	return &pb.DownloadUrl{Url: url}, nil
+}
+
+// GetUploadOffset reports how far an unfinished upload got
+func (a *attachmentServer) GetUploadOffset(_ context.Context, req *pb.UploadOffsetRequest) (*pb.UploadOffset, error) {
+	if !uploadIDPattern.MatchString(req.UploadId) {
+		return nil, status.Error(codes.InvalidArgument, "upload_id must be 16-64 letters, digits, - or _")
+	}
+	if a.s.attachments == nil {
+		return nil, status.Error(codes.Unavailable, "uploads are disabled")
+	}
+	return &pb.UploadOffset{Offset: a.s.attachments.offset(req.UploadId)}, nil
+}
@@ -0,0 +1,206 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// auditSize is how many entries TailAuditLog can look back on
+	auditSize = 1000
+	// defaultAuditTail is how many entries TailAuditLog starts with
+	defaultAuditTail = 20
+	// auditQueue is how many entries a follower can fall behind before
+	// it misses some
+	auditQueue = 64
+	// maxAuditRequest caps the JSON of a request kept in an entry
+	maxAuditRequest = 4 << 10
+)
+
+// adminMethods is the prefix of the AdminService methods
+var adminMethods = "/" + pb.AdminService_ServiceDesc.ServiceName + "/"
+
+// auditLog keeps the recent admin calls that change state and passes
+// them to followers and the file of WithAuditLog
+type auditLog struct {
+	mu        sync.Mutex
+	entries   []*pb.AuditEntry // oldest first, at most auditSize
+	followers map[chan *pb.AuditEntry]struct{}
+	file      *os.File // JSON lines, nil when not configured
+}
+
+// WithAuditLog appends the audit log to the file at path as JSON lines,
+// it is only kept in memory otherwise
+func WithAuditLog(path string) Option {
+	return func(s *ChatServer) {
+		s.auditPath = path
+	}
+}
+
+// audited reports whether method, a full gRPC method name, is recorded:
+// the AdminService calls except the ones that only read
+func audited(method string) bool {
+	name, ok := strings.CutPrefix(method, adminMethods)
+	if !ok {
+		return false
+	}
+	for _, prefix := range []string{"Get", "List", "Export", "Snapshot", "TailAuditLog"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// auditUnary records the unary admin calls that change state
+func (s *ChatServer) auditUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if audited(info.FullMethod) {
+		m, _ := req.(proto.Message)
+		s.audit.record(auditEntry(ctx, info.FullMethod, m, err))
+	}
+	return resp, err
+}
+
+// auditStream records the streaming admin calls that change state
+func (s *ChatServer) auditStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, ss)
+	if audited(info.FullMethod) {
+		s.audit.record(auditEntry(ss.Context(), info.FullMethod, nil, err))
+	}
+	return err
+}
+
+// auditEntry describes one call, req is nil for streams
+func auditEntry(ctx context.Context, method string, req proto.Message, err error) *pb.AuditEntry {
+	e := &pb.AuditEntry{
+		Time:   time.Now().UnixMilli(),
+		Method: strings.TrimPrefix(method, adminMethods),
+		Code:   status.Code(err).String(),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-admin-actor"); len(v) > 0 {
+			e.Actor = v[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.Peer = p.Addr.String()
+	}
+	if cmd, ok := req.(*pb.SlashCommand); ok && cmd.Secret != "" {
+		cmd = proto.Clone(cmd).(*pb.SlashCommand)
+		cmd.Secret = "[redacted]"
+		req = cmd
+	}
+	if req != nil {
+		if data, err := protojson.Marshal(req); err == nil {
+			if len(data) > maxAuditRequest {
+				data = data[:maxAuditRequest]
+			}
+			e.Request = strings.ToValidUTF8(string(data), "")
+		}
+	}
+	return e
+}
+
+// open starts appending to the file at path, the log stays in memory
+// when it cannot be opened
+func (a *auditLog) open(path string) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		log.Printf("Audit log kept in memory only: %v", err)
+		return
+	}
+	a.file = f
+}
+
+func (a *auditLog) record(e *pb.AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.entries) == auditSize {
+		a.entries = append(a.entries[:0], a.entries[1:]...)
+	}
+	a.entries = append(a.entries, e)
+	for ch := range a.followers {
+		select {
+		case ch <- e:
+		default:
+			// a follower that falls behind misses entries rather than
+			// holding up admin calls
+		}
+	}
+	if a.file != nil {
+		data, _ := protojson.Marshal(e)
+		if _, err := a.file.Write(append(data, '\n')); err != nil {
+			log.Printf("Failed to write the audit log: %v", err)
+		}
+	}
+}
+
+// tail returns the last n entries and, when follow is set, a channel
+// for the next ones that stop must release
+func (a *auditLog) tail(n int, follow bool) (recent []*pb.AuditEntry, next chan *pb.AuditEntry, stop func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	recent = append(recent, a.entries[max(0, len(a.entries)-n):]...)
+	if !follow {
+		return recent, nil, func() {}
+	}
+	next = make(chan *pb.AuditEntry, auditQueue)
+	if a.followers == nil {
+		a.followers = make(map[chan *pb.AuditEntry]struct{})
+	}
+	a.followers[next] = struct{}{}
+	return recent, next, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		delete(a.followers, next)
+	}
+}
+
+// TailAuditLog streams the recent admin calls that changed state, then
+// the new ones as they happen when req.Follow is set
+func (a *adminServer) TailAuditLog(req *pb.AuditLogRequest, stream pb.AdminService_TailAuditLogServer) error {
+	ctx := stream.Context()
+	if err := a.authorize(ctx); err != nil {
+		return err
+	}
+	n := int(req.Limit)
+	if n <= 0 {
+		n = defaultAuditTail
+	}
+	recent, next, stop := a.s.audit.tail(n, req.Follow)
+	defer stop()
+	for _, e := range recent {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	for {
+		select {
+		case e := <-next:
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		case <-a.s.ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// banSweepInterval is how often expired bans are removed from the store.
+// Joins ignore expired bans anyway, the sweep only keeps the store small.
+const banSweepInterval = time.Minute
+
+// maxBanText caps the reason and appeal of a ban
+const maxBanText = 1024
+
+// banError refuses a banned user's stream, or ends it when the ban comes
+// later. The ban travels as a detail of the PERMISSION_DENIED status.
+type banError struct {
+	ban *pb.Ban
+}
+
+func (e banError) Error() string {
+	return e.GRPCStatus().Message()
+}
+
+// GRPCStatus lets gRPC send the error with the ban as a detail, without
+// who created it or the appeal
+func (e banError) GRPCStatus() *status.Status {
+	msg := "You are banned"
+	if e.ban.ExpiresAt != 0 {
+		msg += " until " + time.UnixMilli(e.ban.ExpiresAt).UTC().Format(time.RFC3339)
+	}
+	st := status.New(codes.PermissionDenied, msg+": "+e.ban.Reason)
+	public := &pb.Ban{Id: e.ban.Id, Scope: e.ban.Scope, Reason: e.ban.Reason, CreatedAt: e.ban.CreatedAt, ExpiresAt: e.ban.ExpiresAt}
+	if detailed, err := st.WithDetails(public); err == nil {
+		return detailed
+	}
+	return st
+}
+
+// banActive reports whether ban is still in force at now
+func banActive(ban *pb.Ban, now time.Time) bool {
+	return ban.ExpiresAt == 0 || now.UnixMilli() < ban.ExpiresAt
+}
+
+// normalizeBanTarget checks the target of a new ban, IP targets become
+// an address or a masked CIDR
+func normalizeBanTarget(scope pb.BanScope, target string) (string, error) {
+	target = strings.TrimSpace(target)
+	switch {
+	case target == "":
+		return "", errors.New("target is required")
+	case scope == pb.BanScope_BAN_ACCOUNT:
+		return target, nil
+	case scope != pb.BanScope_BAN_IP:
+		return "", errors.New("unknown scope")
+	}
+	if prefix, err := netip.ParsePrefix(target); err == nil {
+		return prefix.Masked().String(), nil
+	}
+	addr, err := netip.ParseAddr(target)
+	if err != nil {
+		return "", errors.New("target is not an IP address or CIDR")
+	}
+	return addr.Unmap().String(), nil
+}
+
+// banMatches reports whether ban covers user connecting from ips
+func banMatches(ban *pb.Ban, user string, ips []netip.Addr) bool {
+	if ban.Scope == pb.BanScope_BAN_ACCOUNT {
+		return ban.Target == user
+	}
+	prefix, err := netip.ParsePrefix(ban.Target)
+	if err != nil {
+		addr, err := netip.ParseAddr(ban.Target)
+		if err != nil {
+			return false
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	for _, ip := range ips {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionIPs returns the addresses a session is banned by, the peer and
+// the client the gateway forwarded it for
+func sessionIPs(info sessionInfo) []netip.Addr {
+	var out []netip.Addr
+	for _, s := range []string{info.ip, info.forwardedFor} {
+		if addr, err := netip.ParseAddr(s); err == nil {
+			out = append(out, addr.Unmap())
+		}
+	}
+	return out
+}
+
+// banCache keeps the last ban list read from the store, so an outage of
+// the store does not lift the bans
+type banCache struct {
+	mu   sync.Mutex
+	bans []*pb.Ban
+	at   time.Time // zero until a list was read
+}
+
+// currentBans reads the ban list, falling back to the last one read while the
+// store cannot be read. Without one it fails and joins are refused.
+func (s *ChatServer) currentBans(ctx context.Context) ([]*pb.Ban, error) {
+	bans, err := s.bans.Bans(ctx)
+	s.banCache.mu.Lock()
+	defer s.banCache.mu.Unlock()
+	if err == nil {
+		s.banCache.bans, s.banCache.at = bans, time.Now()
+		return bans, nil
+	}
+	if s.banCache.at.IsZero() {
+		log.Printf("ERROR: cannot read bans and none were read before, refusing joins: %v", err)
+		return nil, status.Error(codes.Unavailable, "bans cannot be checked, try again later")
+	}
+	log.Printf("ERROR: cannot read bans, using the list read at %s: %v", s.banCache.at.UTC().Format(time.RFC3339), err)
+	return s.banCache.bans, nil
+}
+
+// checkBans returns a banError when user connecting as info is banned.
+// While the store cannot be read the last list read applies, see
+// currentBans.
+func (s *ChatServer) checkBans(ctx context.Context, user string, info sessionInfo) error {
+	bans, err := s.currentBans(ctx)
+	if err != nil {
+		return err
+	}
+	now, ips := time.Now(), sessionIPs(info)
+	for _, ban := range bans {
+		if banActive(ban, now) && banMatches(ban, user, ips) {
+			return banError{ban: ban}
+		}
+	}
+	return nil
+}
+
+// sweepBans removes expired bans from the store until the server stops,
+// with leader election only while this server leads
+func (s *ChatServer) sweepBans() {
+	t := time.NewTicker(banSweepInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-s.ctx.Done():
+			return
+		}
+		if s.elector == nil || s.elector.IsLeader() {
+			s.expireBans(time.Now())
+		}
+	}
+}
+
+// expireBans removes the bans that ended before now
+func (s *ChatServer) expireBans(now time.Time) {
+	bans, err := s.bans.Bans(s.ctx)
+	if err != nil {
+		log.Printf("Failed to read bans: %v", err)
+		return
+	}
+	for _, ban := range bans {
+		if banActive(ban, now) {
+			continue
+		}
+		if err := s.bans.DeleteBan(s.ctx, ban.Id); err != nil {
+			log.Printf("Failed to remove expired ban %s: %v", ban.Id, err)
+			continue
+		}
+		log.Printf("Ban %s of %s expired", ban.Id, ban.Target)
+	}
+}
+
+// findBan returns the ban with id, expired ones included
+func (s *ChatServer) findBan(ctx context.Context, id string) (*pb.Ban, error) {
+	bans, err := s.bans.Bans(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "reading bans: %v", err)
+	}
+	for _, ban := range bans {
+		if ban.Id == id {
+			return ban, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "no such ban")
+}
+
+// CreateBan bans an account or IP range and ends the sessions it covers
+func (a *adminServer) CreateBan(ctx context.Context, req *pb.CreateBanRequest) (*pb.Ban, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	target, err := normalizeBanTarget(req.Scope, req.Target)
+	switch {
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case strings.TrimSpace(req.Reason) == "":
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	case req.DurationSeconds < 0:
+		return nil, status.Error(codes.InvalidArgument, "duration_seconds cannot be negative")
+	case len(req.Reason) > maxBanText:
+		return nil, status.Errorf(codes.InvalidArgument, "reason is longer than %d bytes", maxBanText)
+	}
+	now := time.Now()
+	ban := &pb.Ban{
+		Id:        a.s.newID(),
+		Scope:     req.Scope,
+		Target:    target,
+		Reason:    req.Reason,
+		CreatedAt: now.UnixMilli(),
+		CreatedBy: req.CreatedBy,
+	}
+	if req.DurationSeconds > 0 {
+		ban.ExpiresAt = now.Add(time.Duration(req.DurationSeconds) * time.Second).UnixMilli()
+	}
+	if err := a.s.bans.PutBan(ctx, ban); err != nil {
+		return nil, status.Errorf(codes.Internal, "saving ban: %v", err)
+	}
+	log.Printf("Banned %s %s: %s", strings.ToLower(strings.TrimPrefix(ban.Scope.String(), "BAN_")), target, ban.Reason)
+	cause := banError{ban: proto.Clone(ban).(*pb.Ban)}
+	a.s.revokeSessions(cause, func(_ string, conn connection) bool {
+		return banMatches(ban, conn.user, sessionIPs(conn.info))
+	})
+	return ban, nil
+}
+
+// RemoveBan lifts a ban
+func (a *adminServer) RemoveBan(ctx context.Context, req *pb.BanRequest) (*pb.Ban, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	ban, err := a.s.findBan(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.s.bans.DeleteBan(ctx, ban.Id); err != nil {
+		return nil, status.Errorf(codes.Internal, "removing ban: %v", err)
+	}
+	log.Printf("Ban %s of %s lifted", ban.Id, ban.Target)
+	return ban, nil
+}
+
+// ListBans returns the bans in force, oldest first
+func (a *adminServer) ListBans(ctx context.Context, req *pb.ListBansRequest) (*pb.BanList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	bans, err := a.s.bans.Bans(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "reading bans: %v", err)
+	}
+	now := time.Now()
+	out := &pb.BanList{}
+	for _, ban := range bans {
+		if banActive(ban, now) && (req.Target == "" || ban.Target == req.Target) {
+			out.Bans = append(out.Bans, ban)
+		}
+	}
+	return out, nil
+}
+
+// SetBanAppeal replaces the appeal note of a ban
+func (a *adminServer) SetBanAppeal(ctx context.Context, req *pb.SetBanAppealRequest) (*pb.Ban, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if len(req.Appeal) > maxBanText {
+		return nil, status.Errorf(codes.InvalidArgument, "appeal is longer than %d bytes", maxBanText)
+	}
+	ban, err := a.s.findBan(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	ban.Appeal = req.Appeal
+	if err := a.s.bans.PutBan(ctx, ban); err != nil {
+		return nil, status.Errorf(codes.Internal, "saving ban: %v", err)
+	}
+	return ban, nil
+}
@@ -0,0 +1,143 @@
+package chatserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// BanStore keeps the bans created through AdminService
+type BanStore interface {
+	// PutBan adds or replaces the ban with ban.Id
+	PutBan(ctx context.Context, ban *pb.Ban) error
+	// DeleteBan removes a ban, it is not an error when there is none
+	DeleteBan(ctx context.Context, id string) error
+	// Bans returns every ban, expired ones included, oldest first
+	Bans(ctx context.Context) ([]*pb.Ban, error)
+}
+
+// MemoryBanStore is an in-process BanStore, bans are lost when the
+// process restarts
+type MemoryBanStore struct {
+	mu   sync.RWMutex
+	bans map[string]*pb.Ban
+}
+
+// NewMemoryBanStore creates an empty MemoryBanStore
+func NewMemoryBanStore() *MemoryBanStore {
+	return &MemoryBanStore{bans: make(map[string]*pb.Ban)}
+}
+
+// PutBan implements BanStore
+func (m *MemoryBanStore) PutBan(_ context.Context, ban *pb.Ban) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bans[ban.Id] = proto.Clone(ban).(*pb.Ban)
+	return nil
+}
+
+// DeleteBan implements BanStore
+func (m *MemoryBanStore) DeleteBan(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.bans, id)
+	return nil
+}
+
+// Bans implements BanStore
+func (m *MemoryBanStore) Bans(context.Context) ([]*pb.Ban, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*pb.Ban, 0, len(m.bans))
+	for _, ban := range m.bans {
+		out = append(out, proto.Clone(ban).(*pb.Ban))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+		return out[i].Id < out[j].Id
+	})
+	return out, nil
+}
+
+// FileBanStore keeps bans in memory and rewrites them to a JSON file on
+// every change, so they survive restarts
+type FileBanStore struct {
+	MemoryBanStore
+	write sync.Mutex // serialises rewrites of the file
+	path  string
+}
+
+// NewFileBanStore loads the bans saved at path, a missing file is an
+// empty store
+func NewFileBanStore(path string) (*FileBanStore, error) {
+	fs := &FileBanStore{MemoryBanStore: *NewMemoryBanStore(), path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &pb.BanList{}
+	if err := protojson.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, ban := range list.Bans {
+		fs.bans[ban.Id] = ban
+	}
+	return fs, nil
+}
+
+// PutBan implements BanStore
+func (fs *FileBanStore) PutBan(ctx context.Context, ban *pb.Ban) error {
+	fs.write.Lock()
+	defer fs.write.Unlock()
+	fs.MemoryBanStore.PutBan(ctx, ban)
+	return fs.save(ctx)
+}
+
+// DeleteBan implements BanStore
+func (fs *FileBanStore) DeleteBan(ctx context.Context, id string) error {
+	fs.write.Lock()
+	defer fs.write.Unlock()
+	fs.MemoryBanStore.DeleteBan(ctx, id)
+	return fs.save(ctx)
+}
+
+// save replaces the file with the current bans
+func (fs *FileBanStore) save(ctx context.Context) error {
+	bans, _ := fs.Bans(ctx)
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(&pb.BanList{Bans: bans})
+	if err != nil {
+		return err
+	}
+	return replaceFile(fs.path, data)
+}
+
+// replaceFile writes data to path through a temporary file, so a crash
+// leaves either the old or the new content
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,143 @@
+package chatserver
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// WriteBatching coalesces consecutive sends to one stream, like Nagle's
+// algorithm: while a write is in flight the following messages wait and
+// go out together once it finished. A burst of broadcasts then costs a
+// few HTTP/2 frames and syscalls per stream instead of one per message.
+type WriteBatching struct {
+	MaxBytes int           // a batch is written once its messages encode to this many bytes
+	Delay    time.Duration // how long the first message of a batch waits for more, 0 only waits for the write in flight
+}
+
+// DefaultWriteBatching keeps batches well below gRPC's default 4MB
+// message limit and adds at most a millisecond to a send
+var DefaultWriteBatching = WriteBatching{
+	MaxBytes: 32 << 10,
+	Delay:    time.Millisecond,
+}
+
+// WithWriteBatching batches the sends to each stream with b, clients
+// that enabled pb.CapBatch receive a batch as one TYPE_BATCH message and
+// the others its messages one after another. Batching is off by default.
+func WithWriteBatching(b WriteBatching) Option {
+	return func(s *ChatServer) {
+		if b.MaxBytes <= 0 {
+			b.MaxBytes = DefaultWriteBatching.MaxBytes
+		}
+		s.batching = &b
+	}
+}
+
+// batchWrites wraps stream in a batchStream when batching is on, batch
+// tells whether the client enabled pb.CapBatch
+func (s *ChatServer) batchWrites(stream pb.ChatService_RealtimeChatServer, batch bool) pb.ChatService_RealtimeChatServer {
+	if s.batching == nil {
+		return stream
+	}
+	return &batchStream{ChatService_RealtimeChatServer: stream, cfg: *s.batching, batch: batch}
+}
+
+// batchStream is the per-connection writer. The first Send of a batch
+// leads it: it waits for the previous batch to be written, then up to
+// cfg.Delay for more messages, and writes them all. Every Send returns
+// once its batch is written, so fanned-out messages stay shared until
+// then and a slow stream holds back its senders as before.
+type batchStream struct {
+	pb.ChatService_RealtimeChatServer
+	cfg   WriteBatching
+	batch bool // the client enabled pb.CapBatch
+
+	mu   sync.Mutex
+	open *writeBatch // collecting messages, nil when none is
+	last *writeBatch // the batch created last, the next one is written after it
+}
+
+type writeBatch struct {
+	msgs []*pb.ChatMessage
+	size int
+	prev *writeBatch   // written before this one, nil once it was
+	full chan struct{} // closed when the batch reached cfg.MaxBytes
+	done chan struct{} // closed when the batch was written
+	err  error         // of the write, read after done
+}
+
+func (bs *batchStream) Send(msg *pb.ChatMessage) error {
+	bs.mu.Lock()
+	b := bs.open
+	leader := b == nil
+	if leader {
+		b = &writeBatch{prev: bs.last, full: make(chan struct{}), done: make(chan struct{})}
+		bs.open, bs.last = b, b
+	}
+	b.msgs = append(b.msgs, msg)
+	if b.size += proto.Size(msg); b.size >= bs.cfg.MaxBytes {
+		// later messages start the next batch
+		bs.open = nil
+		close(b.full)
+	}
+	bs.mu.Unlock()
+
+	if !leader {
+		<-b.done
+		return b.err
+	}
+	bs.lead(b)
+	return b.err
+}
+
+// lead writes b after the batch before it
+func (bs *batchStream) lead(b *writeBatch) {
+	ctx := bs.Context()
+	if b.prev != nil {
+		<-b.prev.done
+		b.prev = nil
+	}
+	if bs.cfg.Delay > 0 {
+		t := time.NewTimer(bs.cfg.Delay)
+		select {
+		case <-b.full:
+		case <-t.C:
+		case <-ctx.Done():
+		}
+		t.Stop()
+	}
+
+	bs.mu.Lock()
+	if bs.open == b {
+		bs.open = nil
+	}
+	msgs := b.msgs
+	bs.mu.Unlock()
+
+	b.err = bs.write(msgs)
+	bs.mu.Lock()
+	if bs.last == b {
+		bs.last = nil // nothing left to wait for
+	}
+	bs.mu.Unlock()
+	close(b.done)
+}
+
+func (bs *batchStream) write(msgs []*pb.ChatMessage) error {
+	if bs.batch && len(msgs) > 1 {
+		return bs.ChatService_RealtimeChatServer.Send(&pb.ChatMessage{
+			Type:    pb.MessageType_TYPE_BATCH,
+			Payload: &pb.ChatMessage_Batch{Batch: &pb.MessageBatch{Messages: msgs}},
+		})
+	}
+	for _, msg := range msgs {
+		if err := bs.ChatService_RealtimeChatServer.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
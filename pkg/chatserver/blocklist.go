@@ -0,0 +1,295 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// maxBlockPattern caps the pattern of a blocklist rule
+const maxBlockPattern = 256
+
+// blockMatcher is a compiled blocklist rule
+type blockMatcher struct {
+	rule *pb.BlockRule
+	re   *regexp.Regexp
+}
+
+// compileBlockRule compiles rule, words match case-insensitively
+func compileBlockRule(rule *pb.BlockRule) (blockMatcher, error) {
+	expr := rule.Pattern
+	if !rule.Regex {
+		expr = "(?i)" + regexp.QuoteMeta(rule.Pattern)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return blockMatcher{}, err
+	}
+	return blockMatcher{rule: rule, re: re}, nil
+}
+
+// key identifies the pattern of a rule, a room rule overrides the global
+// rule with the same key
+func (m blockMatcher) key() string {
+	if m.rule.Regex {
+		return "re:" + m.rule.Pattern
+	}
+	return "word:" + strings.ToLower(m.rule.Pattern)
+}
+
+// find returns the spans of text the rule matches. Words only match
+// whole, unless they start or end in a script written without spaces.
+func (m blockMatcher) find(text string) [][]int {
+	spans := m.re.FindAllStringIndex(text, -1)
+	if m.rule.Regex {
+		return spans
+	}
+	out := spans[:0]
+	for _, sp := range spans {
+		first, _ := utf8.DecodeRuneInString(text[sp[0]:])
+		last, _ := utf8.DecodeLastRuneInString(text[:sp[1]])
+		before, _ := utf8.DecodeLastRuneInString(text[:sp[0]])
+		after, _ := utf8.DecodeRuneInString(text[sp[1]:])
+		if (wordRune(first) && wordRune(before)) || (wordRune(last) && wordRune(after)) {
+			continue
+		}
+		out = append(out, sp)
+	}
+	return out
+}
+
+// wordRune reports whether r is part of a space separated word
+func wordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai)
+}
+
+// blocklist caches the compiled rules of the BlockStore until they
+// change through AdminService
+type blocklist struct {
+	mu       sync.Mutex
+	loaded   bool
+	matchers []blockMatcher
+}
+
+func (b *blocklist) invalidate() {
+	b.mu.Lock()
+	b.loaded = false
+	b.mu.Unlock()
+}
+
+// blockMatchers returns the rules that apply in room, "" for private
+// messages: the room's own rules and the global rules they do not
+// override
+func (s *ChatServer) blockMatchers(ctx context.Context, room string) []blockMatcher {
+	s.blocks.mu.Lock()
+	if !s.blocks.loaded {
+		rules, err := s.blockStore.BlockRules(ctx)
+		if err != nil {
+			s.blocks.mu.Unlock()
+			log.Printf("Failed to read blocklist: %v", err)
+			return nil
+		}
+		var matchers []blockMatcher
+		for _, rule := range rules {
+			m, err := compileBlockRule(rule)
+			if err != nil {
+				log.Printf("Skipping blocklist rule %s: %v", rule.Id, err)
+				continue
+			}
+			matchers = append(matchers, m)
+		}
+		s.blocks.matchers, s.blocks.loaded = matchers, true
+	}
+	all := s.blocks.matchers
+	s.blocks.mu.Unlock()
+
+	overridden := make(map[string]bool)
+	var out []blockMatcher
+	for _, m := range all {
+		if room != "" && m.rule.Room == room {
+			overridden[m.key()] = true
+			out = append(out, m)
+		}
+	}
+	for _, m := range all {
+		if m.rule.Room == "" && !overridden[m.key()] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// checkBlocklist applies the blocklist to msg sent in room. Matches of
+// masking rules are replaced by asterisks, flagging rules tell the room's
+// moderators and queue the message for review, and it returns false when a rejecting rule matched.
+func (s *ChatServer) checkBlocklist(ctx context.Context, msg *pb.ChatMessage, room string) bool {
+	if msg.Text == "" {
+		return true
+	}
+	if msg.RecipientUser != "" {
+		room = ""
+	}
+	var masked [][]int
+	var flagged []string
+	for _, m := range s.blockMatchers(ctx, room) {
+		if m.rule.Action == pb.BlockAction_BLOCK_ALLOW {
+			continue
+		}
+		spans := m.find(msg.Text)
+		if len(spans) == 0 {
+			continue
+		}
+		switch m.rule.Action {
+		case pb.BlockAction_BLOCK_REJECT:
+			log.Printf("Blocklist rule %s rejected a message from %s", m.rule.Id, msg.User)
+			return false
+		case pb.BlockAction_BLOCK_FLAG:
+			flagged = append(flagged, m.rule.Pattern)
+		default:
+			masked = append(masked, spans...)
+		}
+	}
+	if len(flagged) > 0 && room == "" {
+		log.Printf("Blocklist flagged a private message from %s: %s", msg.User, strings.Join(flagged, ", "))
+	} else if len(flagged) > 0 {
+		log.Printf("Blocklist flagged a message from %s in #%s: %s", msg.User, room, strings.Join(flagged, ", "))
+		s.notifyModerators(room, i18n.BlockFlagged, "user", msg.User, "room", room, "text", msg.Text)
+		s.moderation.add(&pb.ModerationItem{Id: s.newID(), Kind: pb.ModerationKind_MODERATION_FLAGGED, Room: room, User: msg.User, Text: msg.Text, Patterns: flagged})
+	}
+	if len(masked) > 0 {
+		msg.Text = maskSpans(msg.Text, masked)
+	}
+	return true
+}
+
+// maskSpans replaces every rune of text inside spans with an asterisk
+func maskSpans(text string, spans [][]int) string {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var b strings.Builder
+	pos := 0
+	for _, sp := range spans {
+		if sp[1] <= pos {
+			continue
+		}
+		start := max(sp[0], pos)
+		b.WriteString(text[pos:start])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[start:sp[1]])))
+		pos = sp[1]
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// notifyModerators sends a system message to every connection of the
+// moderators and owners of room, of any room when room is ""
+func (s *ChatServer) notifyModerators(room, key string, kv ...string) {
+	mods := s.members.moderators(room)
+	if len(mods) == 0 {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, conn := range s.connections {
+		if mods[conn.user] {
+			msg := systemText(key, kv...)
+			msg.EphemeralTo = conn.user
+			go s.sendRoutine(conn.stream, msg, conn.user)
+		}
+	}
+}
+
+// AddBlockRule adds a blocklist rule, a room rule replaces the global
+// rule with the same pattern in that room
+func (a *adminServer) AddBlockRule(ctx context.Context, req *pb.BlockRule) (*pb.BlockRule, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	rule := &pb.BlockRule{
+		Id:        a.s.newID(),
+		Pattern:   strings.TrimSpace(req.Pattern),
+		Regex:     req.Regex,
+		Action:    req.Action,
+		CreatedAt: time.Now().UnixMilli(),
+		CreatedBy: req.CreatedBy,
+	}
+	if req.Room != "" {
+		room, ok := normalizeRoom(req.Room)
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "invalid room name")
+		}
+		rule.Room = room
+	}
+	switch {
+	case rule.Pattern == "":
+		return nil, status.Error(codes.InvalidArgument, "pattern is required")
+	case len(rule.Pattern) > maxBlockPattern:
+		return nil, status.Errorf(codes.InvalidArgument, "pattern is longer than %d bytes", maxBlockPattern)
+	case pb.BlockAction_name[int32(rule.Action)] == "":
+		return nil, status.Error(codes.InvalidArgument, "unknown action")
+	case rule.Action == pb.BlockAction_BLOCK_ALLOW && rule.Room == "":
+		return nil, status.Error(codes.InvalidArgument, "BLOCK_ALLOW needs a room")
+	}
+	if _, err := compileBlockRule(rule); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid pattern: %v", err)
+	}
+	if err := a.s.blockStore.PutBlockRule(ctx, rule); err != nil {
+		return nil, status.Errorf(codes.Internal, "saving rule: %v", err)
+	}
+	a.s.blocks.invalidate()
+	log.Printf("Added blocklist rule %s: %s %q", rule.Id, rule.Action, rule.Pattern)
+	return rule, nil
+}
+
+// RemoveBlockRule removes a blocklist rule
+func (a *adminServer) RemoveBlockRule(ctx context.Context, req *pb.BlockRuleRequest) (*pb.BlockRule, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	rules, err := a.s.blockStore.BlockRules(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "reading blocklist: %v", err)
+	}
+	for _, rule := range rules {
+		if rule.Id != req.Id {
+			continue
+		}
+		if err := a.s.blockStore.DeleteBlockRule(ctx, rule.Id); err != nil {
+			return nil, status.Errorf(codes.Internal, "removing rule: %v", err)
+		}
+		a.s.blocks.invalidate()
+		log.Printf("Removed blocklist rule %s", rule.Id)
+		return rule, nil
+	}
+	return nil, status.Error(codes.NotFound, "no such rule")
+}
+
+// ListBlockRules returns the blocklist rules, oldest first
+func (a *adminServer) ListBlockRules(ctx context.Context, req *pb.ListBlockRulesRequest) (*pb.BlockRuleList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	rules, err := a.s.blockStore.BlockRules(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "reading blocklist: %v", err)
+	}
+	room, _ := normalizeRoom(req.Room)
+	out := &pb.BlockRuleList{}
+	for _, rule := range rules {
+		if room == "" || rule.Room == room {
+			out.Rules = append(out.Rules, rule)
+		}
+	}
+	return out, nil
+}
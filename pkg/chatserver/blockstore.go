@@ -0,0 +1,124 @@
+package chatserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// BlockStore keeps the blocklist rules created through AdminService
+type BlockStore interface {
+	// PutBlockRule adds or replaces the rule with rule.Id
+	PutBlockRule(ctx context.Context, rule *pb.BlockRule) error
+	// DeleteBlockRule removes a rule, it is not an error when there is none
+	DeleteBlockRule(ctx context.Context, id string) error
+	// BlockRules returns every rule, oldest first
+	BlockRules(ctx context.Context) ([]*pb.BlockRule, error)
+}
+
+// MemoryBlockStore is an in-process BlockStore, rules are lost when the
+// process restarts
+type MemoryBlockStore struct {
+	mu    sync.RWMutex
+	rules map[string]*pb.BlockRule
+}
+
+// NewMemoryBlockStore creates an empty MemoryBlockStore
+func NewMemoryBlockStore() *MemoryBlockStore {
+	return &MemoryBlockStore{rules: make(map[string]*pb.BlockRule)}
+}
+
+// PutBlockRule implements BlockStore
+func (m *MemoryBlockStore) PutBlockRule(_ context.Context, rule *pb.BlockRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules[rule.Id] = proto.Clone(rule).(*pb.BlockRule)
+	return nil
+}
+
+// DeleteBlockRule implements BlockStore
+func (m *MemoryBlockStore) DeleteBlockRule(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rules, id)
+	return nil
+}
+
+// BlockRules implements BlockStore
+func (m *MemoryBlockStore) BlockRules(context.Context) ([]*pb.BlockRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*pb.BlockRule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		out = append(out, proto.Clone(rule).(*pb.BlockRule))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+		return out[i].Id < out[j].Id
+	})
+	return out, nil
+}
+
+// FileBlockStore keeps rules in memory and rewrites them to a JSON file
+// on every change, so they survive restarts
+type FileBlockStore struct {
+	MemoryBlockStore
+	write sync.Mutex // serialises rewrites of the file
+	path  string
+}
+
+// NewFileBlockStore loads the rules saved at path, a missing file is an
+// empty store
+func NewFileBlockStore(path string) (*FileBlockStore, error) {
+	fs := &FileBlockStore{MemoryBlockStore: *NewMemoryBlockStore(), path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &pb.BlockRuleList{}
+	if err := protojson.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, rule := range list.Rules {
+		fs.rules[rule.Id] = rule
+	}
+	return fs, nil
+}
+
+// PutBlockRule implements BlockStore
+func (fs *FileBlockStore) PutBlockRule(ctx context.Context, rule *pb.BlockRule) error {
+	fs.write.Lock()
+	defer fs.write.Unlock()
+	fs.MemoryBlockStore.PutBlockRule(ctx, rule)
+	return fs.save(ctx)
+}
+
+// DeleteBlockRule implements BlockStore
+func (fs *FileBlockStore) DeleteBlockRule(ctx context.Context, id string) error {
+	fs.write.Lock()
+	defer fs.write.Unlock()
+	fs.MemoryBlockStore.DeleteBlockRule(ctx, id)
+	return fs.save(ctx)
+}
+
+// save replaces the file with the current rules
+func (fs *FileBlockStore) save(ctx context.Context) error {
+	rules, _ := fs.BlockRules(ctx)
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(&pb.BlockRuleList{Rules: rules})
+	if err != nil {
+		return err
+	}
+	return replaceFile(fs.path, data)
+}
@@ -0,0 +1,320 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultRingTimeout ends calls that are not answered in time
+const DefaultRingTimeout = 45 * time.Second
+
+var callID = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// call is a 1:1 call being set up or in progress. The server only relays
+// signaling, media flows directly between the peers.
+type call struct {
+	id       string
+	caller   string
+	callee   string
+	callerID string // connection that placed the call
+	calleeID string // connection that answered, empty while ringing
+	state    pb.CallState
+	timer    *time.Timer     // ring timeout
+	sharing  map[string]bool // participants sharing their screen
+}
+
+// status is the presence of a participant of c
+func (c *call) status(user string) pb.PresenceStatus {
+	switch {
+	case c.state != pb.CallState_CALL_IN_CALL:
+		return pb.PresenceStatus_PRESENCE_AVAILABLE
+	case c.sharing[user]:
+		return pb.PresenceStatus_PRESENCE_SHARING_SCREEN
+	}
+	return pb.PresenceStatus_PRESENCE_IN_CALL
+}
+
+// peerOf returns the other party of c for user
+func (c *call) peerOf(user string) (name, clientID string) {
+	if user == c.caller {
+		return c.callee, c.calleeID
+	}
+	return c.caller, c.callerID
+}
+
+// callRegistry tracks active calls by ID
+type callRegistry struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// busy reports whether user is in a call, must hold mu
+func (r *callRegistry) busy(user string) bool {
+	for _, c := range r.calls {
+		if c.caller == user || c.callee == user {
+			return true
+		}
+	}
+	return false
+}
+
+// rename updates the calls clientID takes part in, presence follows
+// the rename event on clients
+func (r *callRegistry) rename(clientID, oldName, newName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.calls {
+		switch clientID {
+		case c.callerID:
+			c.caller = newName
+		case c.calleeID:
+			c.callee = newName
+		default:
+			continue
+		}
+		if c.sharing[oldName] {
+			delete(c.sharing, oldName)
+			c.sharing[newName] = true
+		}
+	}
+}
+
+// handleSignal validates a signaling message from userName, updates the
+// call state and relays it to the other party
+func (s *ChatServer) handleSignal(stream pb.ChatService_RealtimeChatServer, clientID, userName string, msg *pb.ChatMessage) {
+	sig := msg.GetSignal()
+	switch sig.Type {
+	case pb.SignalType_SIGNAL_VOICE_JOIN, pb.SignalType_SIGNAL_VOICE_LEAVE,
+		pb.SignalType_SIGNAL_VOICE_SPEAKING_START, pb.SignalType_SIGNAL_VOICE_SPEAKING_STOP:
+		s.handleVoice(stream, clientID, userName, msg)
+		return
+	}
+	peer := msg.RecipientUser
+	if peer == "" || !callID.MatchString(sig.CallId) {
+		s.sendSystem(stream, clientID, i18n.CallInvalidSignal)
+		return
+	}
+	relay := &pb.ChatMessage{User: userName, RecipientUser: peer, Type: pb.MessageType_TYPE_SIGNAL, Payload: &pb.ChatMessage_Signal{Signal: sig}}
+
+	switch sig.Type {
+	case pb.SignalType_SIGNAL_OFFER:
+		if !s.feature(FeatureCalls, userName, "") {
+			s.sendSystem(stream, clientID, i18n.FeatureDisabled, "feature", FeatureCalls)
+			return
+		}
+		s.placeCall(stream, clientID, userName, peer, relay)
+
+	case pb.SignalType_SIGNAL_ANSWER:
+		s.calls.mu.Lock()
+		c := s.calls.calls[sig.CallId]
+		if c == nil || c.callee != userName || c.caller != peer || c.state != pb.CallState_CALL_RINGING {
+			s.calls.mu.Unlock()
+			s.sendSystem(stream, clientID, i18n.CallNotFound)
+			return
+		}
+		c.state = pb.CallState_CALL_IN_CALL
+		c.calleeID = clientID
+		c.timer.Stop()
+		ev := callEvent(c, "")
+		s.calls.mu.Unlock()
+
+		log.Printf("Call %s between '%s' and '%s' answered.", c.id, c.caller, c.callee)
+		s.sendToConn(c.callerID, relay)
+		s.sendCallEvent(c, ev)
+		s.setPresence(c.caller, pb.PresenceStatus_PRESENCE_IN_CALL)
+		s.setPresence(c.callee, pb.PresenceStatus_PRESENCE_IN_CALL)
+
+	case pb.SignalType_SIGNAL_ICE_CANDIDATE:
+		s.calls.mu.Lock()
+		c := s.calls.calls[sig.CallId]
+		if c == nil || (c.caller != userName && c.callee != userName) {
+			s.calls.mu.Unlock()
+			return // candidates may trail a hangup
+		}
+		name, id := c.peerOf(userName)
+		s.calls.mu.Unlock()
+
+		if id != "" {
+			s.sendToConn(id, relay)
+		} else {
+			s.sendToUser(stream.Context(), name, relay)
+		}
+
+	case pb.SignalType_SIGNAL_HANGUP:
+		s.calls.mu.Lock()
+		c := s.calls.calls[sig.CallId]
+		ok := c != nil && (c.caller == userName || c.callee == userName)
+		s.calls.mu.Unlock()
+		if !ok {
+			return
+		}
+		s.endCall(sig.CallId, "hangup")
+
+	case pb.SignalType_SIGNAL_SCREEN_SHARE_START, pb.SignalType_SIGNAL_SCREEN_SHARE_STOP:
+		s.calls.mu.Lock()
+		c := s.calls.calls[sig.CallId]
+		if c == nil || c.state != pb.CallState_CALL_IN_CALL || (c.caller != userName && c.callee != userName) {
+			s.calls.mu.Unlock()
+			s.sendSystem(stream, clientID, i18n.CallNotFound)
+			return
+		}
+		c.sharing[userName] = sig.Type == pb.SignalType_SIGNAL_SCREEN_SHARE_START
+		_, id := c.peerOf(userName)
+		status := c.status(userName)
+		s.calls.mu.Unlock()
+
+		s.sendToConn(id, relay)
+		s.setPresence(userName, status)
+
+	default:
+		s.sendSystem(stream, clientID, i18n.CallInvalidSignal)
+	}
+}
+
+// placeCall starts ringing peer, or tells the caller why it cannot
+func (s *ChatServer) placeCall(stream pb.ChatService_RealtimeChatServer, clientID, userName, peer string, offer *pb.ChatMessage) {
+	id := offer.GetSignal().CallId
+	c := &call{
+		id:       id,
+		caller:   userName,
+		callee:   peer,
+		callerID: clientID,
+		state:    pb.CallState_CALL_RINGING,
+		sharing:  make(map[string]bool),
+	}
+	if peer == userName {
+		s.sendSystem(stream, clientID, i18n.CallSelf)
+		return
+	}
+	if !s.isOnline(peer) {
+		s.sendToConn(clientID, callMessage(callEvent(c, "offline")))
+		return
+	}
+
+	s.calls.mu.Lock()
+	if _, exists := s.calls.calls[id]; exists {
+		s.calls.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.CallIDInUse)
+		return
+	}
+	if s.calls.busy(peer) || s.calls.busy(userName) {
+		s.calls.mu.Unlock()
+		s.sendToConn(clientID, callMessage(callEvent(c, "busy")))
+		return
+	}
+	c.timer = time.AfterFunc(s.ringTimeout, func() {
+		s.endCall(id, "no-answer")
+	})
+	s.calls.calls[id] = c
+	ev := callEvent(c, "")
+	s.calls.mu.Unlock()
+
+	log.Printf("User '%s' is calling '%s' (call %s).", userName, peer, id)
+	s.sendToUser(stream.Context(), peer, offer)
+	s.sendCallEvent(c, ev)
+}
+
+// endCall removes a call and tells both parties it ended
+func (s *ChatServer) endCall(id, reason string) {
+	s.calls.mu.Lock()
+	c := s.calls.calls[id]
+	if c == nil {
+		s.calls.mu.Unlock()
+		return
+	}
+	delete(s.calls.calls, id)
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	answered := c.state == pb.CallState_CALL_IN_CALL
+	c.state = pb.CallState_CALL_ENDED
+	ev := callEvent(c, reason)
+	s.calls.mu.Unlock()
+
+	log.Printf("Call %s between '%s' and '%s' ended: %s.", id, c.caller, c.callee, reason)
+	s.sendCallEvent(c, ev)
+	if answered {
+		s.setPresence(c.caller, pb.PresenceStatus_PRESENCE_AVAILABLE)
+		s.setPresence(c.callee, pb.PresenceStatus_PRESENCE_AVAILABLE)
+	}
+}
+
+// endCallsFor ends the calls of a connection that went away. A call
+// still ringing for user only ends once none of user's connections remain.
+func (s *ChatServer) endCallsFor(clientID, user string) {
+	online := s.isOnline(user)
+
+	s.calls.mu.Lock()
+	var ids []string
+	for id, c := range s.calls.calls {
+		if c.callerID == clientID || c.calleeID == clientID ||
+			(c.calleeID == "" && c.callee == user && !online) {
+			ids = append(ids, id)
+		}
+	}
+	s.calls.mu.Unlock()
+
+	for _, id := range ids {
+		s.endCall(id, "disconnected")
+	}
+}
+
+func callEvent(c *call, reason string) *pb.CallEvent {
+	state := c.state
+	if reason != "" {
+		state = pb.CallState_CALL_ENDED
+	}
+	return &pb.CallEvent{
+		CallId: c.id,
+		State:  state,
+		Caller: c.caller,
+		Callee: c.callee,
+		Reason: reason,
+	}
+}
+
+// callMessage wraps ev in a system message
+func callMessage(ev *pb.CallEvent) *pb.ChatMessage {
+	return &pb.ChatMessage{User: "System", Type: pb.MessageType_TYPE_CALL, Payload: &pb.ChatMessage_CallEvent{CallEvent: ev}}
+}
+
+// sendCallEvent delivers ev to the caller's connection and to the callee,
+// every connection of the callee sees it while the call is ringing
+func (s *ChatServer) sendCallEvent(c *call, ev *pb.CallEvent) {
+	msg := callMessage(ev)
+	s.sendToConn(c.callerID, msg)
+	if c.calleeID != "" {
+		s.sendToConn(c.calleeID, msg)
+	} else {
+		s.sendToUser(context.Background(), c.callee, msg)
+	}
+}
+
+// sendToConn sends msg to a single connection
+func (s *ChatServer) sendToConn(clientID string, msg *pb.ChatMessage) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conn, ok := s.connections[clientID]
+	if ok {
+		go s.sendRoutine(conn.stream, msg, conn.user)
+	}
+	return ok
+}
+
+// isOnline reports whether user has at least one connection
+func (s *ChatServer) isOnline(user string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, conn := range s.connections {
+		if conn.user == user {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package chatserver
+
+import (
+	"log"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// negotiate answers the Hello in a join message and returns the stream
+// to use for the connection. Clients without a Hello keep receiving
+// every event.
+func (s *ChatServer) negotiate(stream pb.ChatService_RealtimeChatServer, clientID string, join *pb.ChatMessage) (pb.ChatService_RealtimeChatServer, error) {
+	hello := join.GetHello()
+	if hello == nil {
+		log.Printf("Client %s sent no hello, using the legacy protocol", clientID)
+		return s.batchWrites(stream, false), nil
+	}
+	enabled := make(map[string]bool)
+	var names []string
+	for _, c := range hello.Capabilities {
+		if slices.Contains(s.capabilities, c) && !enabled[c] {
+			enabled[c] = true
+			names = append(names, c)
+		}
+	}
+	slices.Sort(names)
+	reply := &pb.ChatMessage{
+		User: "System",
+		Type: pb.MessageType_TYPE_HELLO,
+		Payload: &pb.ChatMessage_Hello{Hello: &pb.Hello{
+			ProtocolVersion: pb.ProtocolVersion,
+			Capabilities:    names,
+		}},
+	}
+	if err := stream.Send(reply); err != nil {
+		return nil, err
+	}
+	log.Printf("Client %s negotiated protocol %d with capabilities %v",
+		clientID, min(hello.ProtocolVersion, pb.ProtocolVersion), names)
+	// events are held back before they are batched, a batch is not checked again
+	return capStream{s.batchWrites(stream, enabled[pb.CapBatch]), enabled}, nil
+}
+
+// capStream holds back events the client did not enable, events that
+// also carry system text are sent as plain system messages instead
+type capStream struct {
+	pb.ChatService_RealtimeChatServer
+	caps map[string]bool
+}
+
+func (cs capStream) Send(msg *pb.ChatMessage) error {
+	c := pb.CapabilityOf(pb.TypeOf(msg))
+	if c == "" || cs.caps[c] {
+		return cs.ChatService_RealtimeChatServer.Send(msg)
+	}
+	if msg.System == nil && msg.Text == "" {
+		return nil
+	}
+	// msg is shared with other connections
+	plain := proto.Clone(msg).(*pb.ChatMessage)
+	plain.Payload = nil
+	plain.Type = pb.MessageType_TYPE_SYSTEM
+	return cs.ChatService_RealtimeChatServer.Send(plain)
+}
@@ -0,0 +1,113 @@
+package chatserver
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// Bounds of a Catchup request
+const (
+	defaultCatchupMessages = 20
+	maxCatchupMessages     = 100
+	maxCatchupRooms        = 100
+)
+
+// Catchup summarizes what each requested room missed since the client's
+// last sequence, cheap enough for a mobile client to resync every room
+// it follows in one call after reconnecting. Private rooms are only
+// summarized for their members and the admin token.
+func (h *historyServer) Catchup(ctx context.Context, req *pb.CatchupRequest) (*pb.CatchupResponse, error) {
+	if len(req.Rooms) > maxCatchupRooms {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d rooms per request", maxCatchupRooms)
+	}
+	if err := h.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	limit := int(req.Max)
+	if limit <= 0 {
+		limit = defaultCatchupMessages
+	}
+	limit = min(limit, maxCatchupMessages)
+	resp := &pb.CatchupResponse{Rooms: make([]*pb.RoomCatchup, 0, len(req.Rooms))}
+	for _, r := range req.Rooms {
+		room := DefaultRoom
+		if r.Room != "" {
+			var ok bool
+			if room, ok = normalizeRoom(r.Room); !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", r.Room)
+			}
+		}
+		if !h.s.mayRead(ctx, req.User, room) {
+			return nil, status.Errorf(codes.PermissionDenied, "#%s is private", room)
+		}
+		resp.Rooms = append(resp.Rooms, h.s.catchup(room, r.SinceSeq, req.User, limit))
+	}
+	return resp, nil
+}
+
+// catchup builds the summary of room after since for user, with up to
+// limit of the newest messages
+func (s *ChatServer) catchup(room string, since uint64, user string, limit int) *pb.RoomCatchup {
+	out := &pb.RoomCatchup{Room: room, LastSeq: since}
+	missed := s.history.between(room, since, 0, math.MaxInt)
+	for _, msg := range missed {
+		if user != "" && msg.User != user && mentions(msg.Text, user) {
+			out.MentionCount++
+		}
+	}
+	out.MessageCount = uint32(len(missed))
+	if n := len(missed); n > 0 {
+		out.LastSeq = missed[n-1].Seq
+		out.Truncated = missed[0].Seq > since+1
+		out.Messages = missed[max(0, n-limit):]
+	}
+
+	// membership is compared with the time of the last message the client
+	// has, or of the oldest one kept when that is gone
+	var from time.Time
+	if since > 0 {
+		if prev := s.history.between(room, since-1, since+1, 1); len(prev) > 0 {
+			from = time.UnixMilli(prev[0].Timestamp)
+		} else if len(missed) > 0 {
+			from = time.UnixMilli(missed[0].Timestamp)
+		} else {
+			return out // nothing kept to compare with
+		}
+	}
+	out.Members = s.membershipSince(room, from)
+	return out
+}
+
+// membershipSince collapses the membership changes of room after from to
+// one per user: users in the room now who entered later joined, users
+// gone now who were there before left, those who came and went are left
+// out. A zero from lists everyone in the room as joined.
+func (s *ChatServer) membershipSince(room string, from time.Time) []*pb.MembershipChange {
+	present := make(map[string]bool)
+	s.mu.RLock()
+	for _, conn := range s.connections {
+		if conn.in(room) {
+			present[conn.user] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	var changes []*pb.MembershipChange
+	for user, st := range s.members.snapshot(room) {
+		switch {
+		case present[user] && st.entered.After(from):
+			changes = append(changes, &pb.MembershipChange{User: user, Joined: true})
+		case !present[user] && !from.IsZero() && st.lastSeen.After(from) && !st.entered.After(from):
+			changes = append(changes, &pb.MembershipChange{User: user})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].User < changes[j].User })
+	return changes
+}
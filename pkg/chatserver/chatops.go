@@ -0,0 +1,261 @@
+package chatserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// chatopsTimeout bounds one call to a command's URL, like Slack's
+	chatopsTimeout = 3 * time.Second
+	// maxChatopsResponse caps the body read from a command's URL
+	maxChatopsResponse = 64 << 10
+)
+
+var commandName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// commandRegistry holds the slash commands registered through the admin
+// API, see RegisterCommand
+type commandRegistry struct {
+	mu       sync.RWMutex
+	commands map[string]*pb.SlashCommand
+}
+
+func (r *commandRegistry) get(name string) *pb.SlashCommand {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.commands[name]
+}
+
+// chatopsInvocation is the JSON body POSTed to a command's URL
+type chatopsInvocation struct {
+	Command   string `json:"command"`
+	Text      string `json:"text"`
+	User      string `json:"user"`
+	Room      string `json:"room"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// chatopsResponse is what a command's URL answers with. Ephemeral
+// responses, the default, are shown to the invoker only.
+type chatopsResponse struct {
+	Text         string `json:"text"`
+	ResponseType string `json:"response_type"` // "in_channel" or "ephemeral"
+}
+
+// chatopsCommand forwards a "/command args" message to the URL registered
+// for the command and reports whether one is. The call runs in the
+// background, the response is posted when it arrives.
+func (s *ChatServer) chatopsCommand(stream pb.ChatService_RealtimeChatServer, clientID, user, room string, msg *pb.ChatMessage) bool {
+	if msg.RecipientUser != "" || msg.GetCode() != nil || !strings.HasPrefix(msg.Text, "/") {
+		return false
+	}
+	name, args, _ := strings.Cut(msg.Text[1:], " ")
+	cmd := s.commands.get(name)
+	if cmd == nil {
+		return false
+	}
+	if len(cmd.Rooms) > 0 && !slices.Contains(cmd.Rooms, room) {
+		s.sendSystem(stream, clientID, i18n.ChatOpsNotHere, "command", name, "room", room)
+		return true
+	}
+	inv := chatopsInvocation{Command: name, Text: strings.TrimSpace(args), User: user, Room: room, Timestamp: time.Now().UnixMilli()}
+	go func() {
+		// abandoned when the invoker disconnects
+		res, err := s.invokeCommand(stream.Context(), cmd, inv)
+		if err != nil {
+			log.Printf("ChatOps /%s from %s failed: %v", name, user, err)
+			s.sendEphemeral(clientID, systemText(i18n.PluginFailed, "command", name))
+			return
+		}
+		if strings.TrimSpace(res.Text) == "" {
+			return
+		}
+		if max := s.limits.MaxMessageLength; max > 0 && len(res.Text) > max {
+			res.Text = truncateUTF8(res.Text, max)
+		}
+		if res.ResponseType != "in_channel" {
+			s.sendEphemeral(clientID, systemText(i18n.PluginReply, "text", res.Text))
+			return
+		}
+		s.postCommandReply(cmd, inv, res.Text)
+	}()
+	return true
+}
+
+// invokeCommand POSTs inv to the command's URL. With a secret the request
+// carries X-Chat-Timestamp and X-Chat-Signature, the hex HMAC-SHA256 of
+// "<timestamp>.<body>" prefixed with "v1=".
+func (s *ChatServer) invokeCommand(ctx context.Context, cmd *pb.SlashCommand, inv chatopsInvocation) (*chatopsResponse, error) {
+	body, err := json.Marshal(inv)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, chatopsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cmd.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cmd.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(cmd.Secret))
+		mac.Write([]byte(ts + "."))
+		mac.Write(body)
+		req.Header.Set("X-Chat-Timestamp", ts)
+		req.Header.Set("X-Chat-Signature", "v1="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s answered %s", cmd.Url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChatopsResponse))
+	if err != nil {
+		return nil, err
+	}
+	res := &chatopsResponse{}
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) {
+		res.Text = string(data) // a plain text answer
+		return res, nil
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %w", cmd.Url, err)
+	}
+	return res, nil
+}
+
+// postCommandReply posts an in_channel response to the invoker's room as
+// a message from the command's bot, stored like any other
+func (s *ChatServer) postCommandReply(cmd *pb.SlashCommand, inv chatopsInvocation, text string) {
+	bot := cmd.BotName
+	if bot == "" {
+		bot = cmd.Name
+	}
+	msg := &pb.ChatMessage{
+		User:     bot,
+		Text:     text,
+		Metadata: map[string]string{"chatops.command": cmd.Name, "chatops.user": inv.User},
+	}
+	s.accept(s.ctx, msg, inv.Room)
+	s.broadcastChat(s.ctx, msg, "")
+	s.pushUnread(msg)
+}
+
+// truncateUTF8 cuts s to at most max bytes without splitting a rune
+func truncateUTF8(s string, max int) string {
+	for max > 0 && max < len(s) && s[max]&0xC0 == 0x80 {
+		max--
+	}
+	return s[:max]
+}
+
+// RegisterCommand adds or replaces a slash command
+func (a *adminServer) RegisterCommand(ctx context.Context, req *pb.SlashCommand) (*pb.SlashCommand, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	cmd := proto.Clone(req).(*pb.SlashCommand)
+	cmd.Name = strings.TrimPrefix(cmd.Name, "/")
+	if !commandName.MatchString(cmd.Name) {
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid command name", req.Name)
+	}
+	if slices.Contains(builtinCommands, cmd.Name) {
+		return nil, status.Errorf(codes.InvalidArgument, "/%s is a built-in command", cmd.Name)
+	}
+	for _, p := range a.s.plugins {
+		if p.has(pb.PluginHook_HOOK_COMMAND) && slices.Contains(p.info.Commands, cmd.Name) {
+			return nil, status.Errorf(codes.AlreadyExists, "/%s is handled by plugin %s", cmd.Name, p.Name())
+		}
+	}
+	u, err := url.Parse(cmd.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not an http(s) URL", cmd.Url)
+	}
+	for i, name := range cmd.Rooms {
+		room, ok := normalizeRoom(name)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", name)
+		}
+		cmd.Rooms[i] = room
+	}
+
+	r := &a.s.commands
+	r.mu.Lock()
+	if r.commands == nil {
+		r.commands = make(map[string]*pb.SlashCommand)
+	}
+	r.commands[cmd.Name] = cmd
+	r.mu.Unlock()
+	log.Printf("Registered /%s -> %s", cmd.Name, u.Redacted())
+	return redactCommand(cmd), nil
+}
+
+// UnregisterCommand removes a slash command
+func (a *adminServer) UnregisterCommand(ctx context.Context, req *pb.UnregisterCommandRequest) (*pb.SlashCommand, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	name := strings.TrimPrefix(req.Name, "/")
+	r := &a.s.commands
+	r.mu.Lock()
+	cmd, ok := r.commands[name]
+	delete(r.commands, name)
+	r.mu.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "/%s is not registered", name)
+	}
+	log.Printf("Unregistered /%s", name)
+	return redactCommand(cmd), nil
+}
+
+// ListCommands returns the registered slash commands sorted by name
+func (a *adminServer) ListCommands(ctx context.Context, _ *pb.ListCommandsRequest) (*pb.CommandList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	r := &a.s.commands
+	r.mu.RLock()
+	out := &pb.CommandList{Commands: make([]*pb.SlashCommand, 0, len(r.commands))}
+	for _, cmd := range r.commands {
+		out.Commands = append(out.Commands, redactCommand(cmd))
+	}
+	r.mu.RUnlock()
+	sort.Slice(out.Commands, func(i, j int) bool { return out.Commands[i].Name < out.Commands[j].Name })
+	return out, nil
+}
+
+// redactCommand copies cmd without its secret
+func redactCommand(cmd *pb.SlashCommand) *pb.SlashCommand {
+	out := proto.Clone(cmd).(*pb.SlashCommand)
+	out.Secret = ""
+	return out
+}
@@ -0,0 +1,269 @@
+package chatserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// DefaultClusterTTL is how long a server stays in the registry after
+	// its last heartbeat, it sends one every third of it
+	DefaultClusterTTL = 15 * time.Second
+	// peerTimeout bounds one delivery to another server
+	peerTimeout = 3 * time.Second
+	// clusterQueue is how many registry updates wait to be written
+	clusterQueue = 1024
+)
+
+// Cluster lets the servers sharing a Registry reach each other's users:
+// each records its users there, and a message to a user connected
+// elsewhere is forwarded over ClusterService to the servers that have
+// them.
+type Cluster struct {
+	Node        Node // this server, Node.ID defaults to Node.Addr
+	Registry    Registry
+	Secret      string            // bearer token the servers present to each other, empty trusts every caller
+	TTL         time.Duration     // 0 means DefaultClusterTTL
+	DialOptions []grpc.DialOption // for the other servers, plaintext by default
+}
+
+// WithCluster joins the server to c once it serves
+func WithCluster(c Cluster) Option {
+	return func(s *ChatServer) {
+		if c.Node.ID == "" {
+			c.Node.ID = c.Node.Addr
+		}
+		if c.TTL <= 0 {
+			c.TTL = DefaultClusterTTL
+		}
+		if c.DialOptions == nil {
+			c.DialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+		}
+		s.cluster = &clusterState{cfg: c, updates: make(chan clusterUpdate, clusterQueue), peers: make(map[string]*grpc.ClientConn)}
+	}
+}
+
+// clusterState is the server's side of its Cluster
+type clusterState struct {
+	cfg     Cluster
+	updates chan clusterUpdate
+	stale   atomic.Bool // an update was lost, the registry is rewritten at the next heartbeat
+
+	mu    sync.Mutex
+	peers map[string]*grpc.ClientConn // by address
+}
+
+// clusterUpdate is a user whose first stream opened or last one closed
+type clusterUpdate struct {
+	user   string
+	joined bool
+}
+
+// clusterJoined records in the registry that user has streams here
+func (s *ChatServer) clusterJoined(user string) {
+	s.clusterUpdate(clusterUpdate{user: user, joined: true})
+}
+
+// clusterLeft records in the registry that user has no more streams here
+func (s *ChatServer) clusterLeft(user string) {
+	s.clusterUpdate(clusterUpdate{user: user})
+}
+
+func (s *ChatServer) clusterUpdate(u clusterUpdate) {
+	if s.cluster == nil {
+		return
+	}
+	select {
+	case s.cluster.updates <- u:
+	default:
+		s.cluster.stale.Store(true)
+	}
+}
+
+// runCluster writes the registry updates in order and sends heartbeats
+// until the server stops, then removes the server from the registry
+func (s *ChatServer) runCluster() {
+	c := s.cluster
+	reg, node := c.cfg.Registry, c.cfg.Node
+	c.stale.Store(true) // a restarted server may find its old entries
+	ticker := time.NewTicker(c.cfg.TTL / 3)
+	defer ticker.Stop()
+	s.clusterHeartbeat()
+	for {
+		select {
+		case <-s.ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), peerTimeout)
+			if err := reg.Remove(ctx, node.ID); err != nil {
+				log.Printf("Failed to leave the cluster: %v", err)
+			}
+			cancel()
+			c.closePeers()
+			return
+		case <-ticker.C:
+			s.clusterHeartbeat()
+		case u := <-c.updates:
+			ctx, cancel := context.WithTimeout(s.ctx, c.cfg.TTL/3)
+			var err error
+			if u.joined {
+				err = reg.Join(ctx, node.ID, u.user)
+			} else {
+				err = reg.Leave(ctx, node.ID, u.user)
+			}
+			cancel()
+			if err != nil && s.ctx.Err() == nil {
+				log.Printf("Failed to update the cluster registry for %s: %v", u.user, err)
+				c.stale.Store(true)
+			}
+		}
+	}
+}
+
+// clusterHeartbeat renews the server in the registry and rewrites its
+// users after a lost update
+func (s *ChatServer) clusterHeartbeat() {
+	c := s.cluster
+	ctx, cancel := context.WithTimeout(s.ctx, c.cfg.TTL/3)
+	defer cancel()
+	if err := c.cfg.Registry.Heartbeat(ctx, c.cfg.Node, c.cfg.TTL); err != nil {
+		if s.ctx.Err() == nil {
+			log.Printf("Cluster heartbeat failed: %v", err)
+		}
+		return
+	}
+	if !c.stale.Swap(false) {
+		return
+	}
+	if err := c.cfg.Registry.Remove(ctx, c.cfg.Node.ID); err != nil {
+		c.stale.Store(true)
+		log.Printf("Failed to rewrite the cluster registry: %v", err)
+		return
+	}
+	if err := c.cfg.Registry.Heartbeat(ctx, c.cfg.Node, c.cfg.TTL); err != nil {
+		c.stale.Store(true)
+		log.Printf("Failed to rewrite the cluster registry: %v", err)
+		return
+	}
+	for _, user := range s.localUsers() {
+		if err := c.cfg.Registry.Join(ctx, c.cfg.Node.ID, user); err != nil {
+			c.stale.Store(true)
+			log.Printf("Failed to rewrite the cluster registry: %v", err)
+			return
+		}
+	}
+}
+
+// localUsers returns the users with streams on this server
+func (s *ChatServer) localUsers() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]bool)
+	var users []string
+	for _, conn := range s.connections {
+		if !seen[conn.user] {
+			seen[conn.user] = true
+			users = append(users, conn.user)
+		}
+	}
+	return users
+}
+
+// forwardToUser delivers msg to the other servers with streams of user,
+// reporting whether any had one
+func (s *ChatServer) forwardToUser(ctx context.Context, user string, msg *pb.ChatMessage) bool {
+	c := s.cluster
+	ctx, cancel := context.WithTimeout(ctx, peerTimeout)
+	defer cancel()
+	nodes, err := c.cfg.Registry.Locate(ctx, user)
+	if err != nil {
+		log.Printf("Failed to locate %s in the cluster: %v", user, err)
+		return false
+	}
+	if c.cfg.Secret != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.cfg.Secret)
+	}
+	delivered := false
+	for _, n := range nodes {
+		if n.ID == c.cfg.Node.ID {
+			continue
+		}
+		conn, err := c.peer(n.Addr)
+		if err == nil {
+			var res *pb.PeerDeliveryResult
+			res, err = pb.NewClusterServiceClient(conn).Deliver(ctx, &pb.PeerDelivery{User: user, Message: msg, FromNode: c.cfg.Node.ID})
+			delivered = delivered || err == nil && res.Delivered
+		}
+		if err != nil {
+			log.Printf("Failed to forward a message for %s to %s: %v", user, n.ID, err)
+		}
+	}
+	return delivered
+}
+
+// peer returns the connection to the server at addr, dialled on first use
+func (c *clusterState) peer(addr string) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn, ok := c.peers[addr]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(addr, c.cfg.DialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	c.peers[addr] = conn
+	return conn, nil
+}
+
+func (c *clusterState) closePeers() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for addr, conn := range c.peers {
+		conn.Close()
+		delete(c.peers, addr)
+	}
+}
+
+// clusterServer implements ClusterService for the other servers
+type clusterServer struct {
+	pb.UnimplementedClusterServiceServer
+	s *ChatServer
+}
+
+// Deliver sends a forwarded message to the local streams of its user
+func (cs *clusterServer) Deliver(ctx context.Context, req *pb.PeerDelivery) (*pb.PeerDeliveryResult, error) {
+	if err := cs.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.User == "" || req.Message == nil {
+		return nil, status.Error(codes.InvalidArgument, "user and message are required")
+	}
+	return &pb.PeerDeliveryResult{Delivered: cs.s.sendToLocalUser(ctx, req.User, req.Message)}, nil
+}
+
+// authorize checks the secret the calling server presented
+func (cs *clusterServer) authorize(ctx context.Context) error {
+	secret := cs.s.cluster.cfg.Secret
+	if secret == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if token, found := strings.CutPrefix(v, "Bearer "); found && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid cluster secret")
+}
@@ -0,0 +1,445 @@
+package chatserver
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"realTimeChat/pkg/objstore"
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultCompactInterval is how often a TieredStore moves old messages
+// to its cold tier
+const DefaultCompactInterval = time.Hour
+
+// directSegments is the segment directory of private messages, room
+// names cannot start with an underscore
+const directSegments = "_direct"
+
+// ColdStore keeps archived messages as gzipped JSONL segments, one per
+// room and compaction, in an object store. A manifest object lists the
+// segments so nothing has to be listed.
+type ColdStore struct {
+	objects objstore.ObjectStore
+	prefix  string
+
+	mu       sync.RWMutex
+	manifest coldManifest
+}
+
+type coldManifest struct {
+	Until    int64         `json:"until"` // Unix milliseconds, every message sent before is archived
+	Segments []coldSegment `json:"segments"`
+}
+
+type coldSegment struct {
+	Key   string `json:"key"`
+	Room  string `json:"room"` // empty for private messages
+	From  int64  `json:"from"` // Unix milliseconds of the oldest message
+	To    int64  `json:"to"`   // and of the newest
+	Count int    `json:"count"`
+}
+
+// NewColdStore keeps segments below prefix in objects, loading the
+// manifest of earlier compactions
+func NewColdStore(ctx context.Context, objects objstore.ObjectStore, prefix string) (*ColdStore, error) {
+	c := &ColdStore{objects: objects, prefix: prefix}
+	r, err := objects.Get(ctx, c.manifestKey(), 0)
+	if errors.Is(err, objstore.ErrNotFound) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if err := json.NewDecoder(r).Decode(&c.manifest); err != nil {
+		return nil, fmt.Errorf("cold store manifest: %w", err)
+	}
+	return c, nil
+}
+
+func (c *ColdStore) manifestKey() string {
+	return c.prefix + "history/manifest.json"
+}
+
+// Until returns the time before which every message is archived, zero
+// before the first compaction
+func (c *ColdStore) Until() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.manifest.Until == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(c.manifest.Until)
+}
+
+// Rooms returns the rooms with archived public messages
+func (c *ColdStore) Rooms() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var rooms []string
+	for _, seg := range c.manifest.Segments {
+		if seg.Room != "" && !slices.Contains(rooms, seg.Room) {
+			rooms = append(rooms, seg.Room)
+		}
+	}
+	slices.Sort(rooms)
+	return rooms
+}
+
+// Archive writes msgs, all sent before until, as new segments and then
+// records them in the manifest. Segments written before a failure are
+// never referenced and can be removed.
+func (c *ColdStore) Archive(ctx context.Context, msgs []*pb.ChatMessage, until time.Time) error {
+	rooms := make(map[string][]*pb.ChatMessage)
+	for _, msg := range msgs {
+		room := msg.Room
+		if msg.RecipientUser != "" {
+			room = ""
+		}
+		rooms[room] = append(rooms[room], msg)
+	}
+	var segments []coldSegment
+	for room, msgs := range rooms {
+		seg, err := c.writeSegment(ctx, room, msgs, until)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, seg)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	next := coldManifest{
+		Until:    max(c.manifest.Until, until.UnixMilli()),
+		Segments: append(slices.Clip(c.manifest.Segments), segments...),
+	}
+	data, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	if err := c.put(ctx, c.manifestKey(), data, "application/json"); err != nil {
+		return err
+	}
+	c.manifest = next
+	return nil
+}
+
+// writeSegment stores the messages of room in one segment, oldest first
+func (c *ColdStore) writeSegment(ctx context.Context, room string, msgs []*pb.ChatMessage, until time.Time) (coldSegment, error) {
+	slices.SortStableFunc(msgs, func(a, b *pb.ChatMessage) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, msg := range msgs {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			return coldSegment{}, err
+		}
+		zw.Write(data)
+		zw.Write([]byte{'\n'})
+	}
+	if err := zw.Close(); err != nil {
+		return coldSegment{}, err
+	}
+	dir := room
+	if dir == "" {
+		dir = directSegments
+	}
+	seg := coldSegment{
+		Key:   c.prefix + "history/" + dir + "/" + strconv.FormatInt(until.UnixMilli(), 10) + ".jsonl.gz",
+		Room:  room,
+		From:  msgs[0].Timestamp,
+		To:    msgs[len(msgs)-1].Timestamp,
+		Count: len(msgs),
+	}
+	return seg, c.put(ctx, seg.Key, buf.Bytes(), "application/gzip")
+}
+
+func (c *ColdStore) put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := c.objects.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return err
+	}
+	return c.objects.Commit(ctx, key)
+}
+
+// segments returns the segments of room that may hold messages sent in
+// [from, to), a zero time means no bound
+func (c *ColdStore) segments(room string, from, to time.Time) []coldSegment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var out []coldSegment
+	for _, seg := range c.manifest.Segments {
+		if seg.Room != room || room == "" ||
+			!from.IsZero() && seg.To < from.UnixMilli() || !to.IsZero() && seg.From >= to.UnixMilli() {
+			continue
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+// RoomMessages implements RoomReader, segments are read oldest first
+func (c *ColdStore) RoomMessages(ctx context.Context, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error {
+	segs := c.segments(room, from, to)
+	slices.SortStableFunc(segs, func(a, b coldSegment) int { return cmp.Compare(a.From, b.From) })
+	for _, seg := range segs {
+		err := c.readSegment(ctx, seg, func(msg *pb.ChatMessage) error {
+			if msg.RecipientUser != "" || !inRange(msg, from, to) {
+				return nil
+			}
+			return fn(msg)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RoomHistory implements HistoryReader, reading segments newest first
+// until limit messages are found
+func (c *ColdStore) RoomHistory(ctx context.Context, room string, before time.Time, limit int) ([]*pb.ChatMessage, error) {
+	segs := c.segments(room, time.Time{}, before)
+	slices.SortStableFunc(segs, func(a, b coldSegment) int { return cmp.Compare(b.To, a.To) })
+	var found []*pb.ChatMessage
+	for i, seg := range segs {
+		err := c.readSegment(ctx, seg, func(msg *pb.ChatMessage) error {
+			if msg.RecipientUser == "" && inRange(msg, time.Time{}, before) {
+				found = append(found, msg)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(found) < limit {
+			continue
+		}
+		// older segments only matter if they overlap the newest found
+		slices.SortStableFunc(found, func(a, b *pb.ChatMessage) int { return cmp.Compare(b.Timestamp, a.Timestamp) })
+		found = found[:limit]
+		if i+1 == len(segs) || segs[i+1].To < found[limit-1].Timestamp {
+			break
+		}
+	}
+	return oldestFirst(found, limit), nil
+}
+
+// oldestFirst returns the newest limit of msgs in timestamp order
+func oldestFirst(msgs []*pb.ChatMessage, limit int) []*pb.ChatMessage {
+	slices.SortStableFunc(msgs, func(a, b *pb.ChatMessage) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	return msgs[max(len(msgs)-limit, 0):]
+}
+
+func (c *ColdStore) readSegment(ctx context.Context, seg coldSegment, fn func(*pb.ChatMessage) error) error {
+	r, err := c.objects.Get(ctx, seg.Key, 0)
+	if err != nil {
+		return fmt.Errorf("cold segment %s: %w", seg.Key, err)
+	}
+	defer r.Close()
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("cold segment %s: %w", seg.Key, err)
+	}
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(make([]byte, 64<<10), maxStoredLine)
+	for line := 1; sc.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg := &pb.ChatMessage{}
+		if err := protojson.Unmarshal(sc.Bytes(), msg); err != nil {
+			return fmt.Errorf("cold segment %s:%d: %w", seg.Key, line, err)
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Tiering sets when a TieredStore moves messages to its cold tier
+type Tiering struct {
+	After    time.Duration // messages older than this are moved
+	Interval time.Duration // between compactions, 0 means DefaultCompactInterval
+}
+
+// TieredStore saves messages to a hot store and moves the ones older
+// than Tiering.After to a ColdStore in the background. Reads cover both
+// tiers: the cold one holds everything sent before its Until, the hot
+// one the rest. The hot store must be a RoomReader and a Compactor.
+type TieredStore struct {
+	hot    Store
+	cold   *ColdStore
+	cfg    Tiering
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTieredStore starts compacting hot into cold every interval until
+// Close, the first compaction runs right away
+func NewTieredStore(hot Store, cold *ColdStore, cfg Tiering) (*TieredStore, error) {
+	if _, ok := hot.(RoomReader); !ok {
+		return nil, errors.New("chatserver: the hot store cannot be read")
+	}
+	if _, ok := hot.(Compactor); !ok {
+		return nil, errors.New("chatserver: the hot store cannot be compacted")
+	}
+	if cfg.After <= 0 {
+		return nil, errors.New("chatserver: tiering needs a positive age")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultCompactInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ts := &TieredStore{hot: hot, cold: cold, cfg: cfg, cancel: cancel, done: make(chan struct{})}
+	go ts.run(ctx)
+	return ts, nil
+}
+
+// SaveMessage implements Store, messages are always saved hot
+func (ts *TieredStore) SaveMessage(ctx context.Context, msg *pb.ChatMessage) error {
+	return ts.hot.SaveMessage(ctx, msg)
+}
+
+// RoomMessages implements RoomReader. Hot messages sent before the cold
+// tier's Until, such as imports saved since the last compaction, are
+// skipped until they are moved.
+func (ts *TieredStore) RoomMessages(ctx context.Context, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error {
+	until := ts.cold.Until()
+	if !until.IsZero() && from.Before(until) {
+		coldTo := until
+		if !to.IsZero() && to.Before(until) {
+			coldTo = to
+		}
+		if err := ts.cold.RoomMessages(ctx, room, from, coldTo, fn); err != nil {
+			return err
+		}
+		if coldTo.Equal(to) {
+			return nil
+		}
+		from = until
+	}
+	return ts.hot.(RoomReader).RoomMessages(ctx, room, from, to, fn)
+}
+
+// Rooms implements RoomLister, listing the rooms of both tiers. Without
+// a RoomLister hot tier only the cold rooms are known.
+func (ts *TieredStore) Rooms(ctx context.Context) ([]string, error) {
+	rooms := ts.cold.Rooms()
+	if rl, ok := ts.hot.(RoomLister); ok {
+		hot, err := rl.Rooms(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, hot...)
+	}
+	slices.Sort(rooms)
+	return slices.Compact(rooms), nil
+}
+
+// RoomHistory implements HistoryReader, going to the cold tier only when
+// the hot one has fewer than limit messages before before
+func (ts *TieredStore) RoomHistory(ctx context.Context, room string, before time.Time, limit int) ([]*pb.ChatMessage, error) {
+	until := ts.cold.Until()
+	var hot []*pb.ChatMessage
+	if until.IsZero() || before.After(until) {
+		var err error
+		if hot, err = scanHistory(ctx, ts.hot.(RoomReader), room, until, before, limit); err != nil {
+			return nil, err
+		}
+	}
+	if len(hot) == limit || until.IsZero() {
+		return hot, nil
+	}
+	if before.After(until) {
+		before = until
+	}
+	cold, err := ts.cold.RoomHistory(ctx, room, before, limit-len(hot))
+	if err != nil {
+		return nil, err
+	}
+	return append(cold, hot...), nil
+}
+
+// scanHistory reads the newest limit messages of room sent in [from,
+// before) from a store that can only be scanned forward
+func scanHistory(ctx context.Context, rr RoomReader, room string, from, before time.Time, limit int) ([]*pb.ChatMessage, error) {
+	var msgs []*pb.ChatMessage
+	err := rr.RoomMessages(ctx, room, from, before, func(msg *pb.ChatMessage) error {
+		msgs = append(msgs, msg)
+		if len(msgs) > 2*limit {
+			// trim in batches so appends stay cheap
+			msgs = append(msgs[:0], msgs[len(msgs)-limit:]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msgs[max(len(msgs)-limit, 0):], nil
+}
+
+// Watermark returns the hot store's, so a TieredStore can be the primary
+// of a ReplicatedStore
+func (ts *TieredStore) Watermark(ctx context.Context) (time.Time, error) {
+	if w, ok := ts.hot.(interface {
+		Watermark(context.Context) (time.Time, error)
+	}); ok {
+		return w.Watermark(ctx)
+	}
+	return time.Time{}, nil
+}
+
+// Compact moves the messages older than Tiering.After to the cold tier
+// now
+func (ts *TieredStore) Compact(ctx context.Context) error {
+	cutoff := time.Now().Add(-ts.cfg.After)
+	moved := 0
+	err := ts.hot.(Compactor).Compact(ctx, cutoff, func(msgs []*pb.ChatMessage) error {
+		moved = len(msgs)
+		return ts.cold.Archive(ctx, msgs, cutoff)
+	})
+	if err == nil && moved > 0 {
+		log.Printf("Moved %d messages sent before %s to cold storage", moved, cutoff.Format(time.RFC3339))
+	}
+	return err
+}
+
+// Close stops compacting and waits for a running compaction, closing the
+// stores is up to the caller
+func (ts *TieredStore) Close() {
+	ts.cancel()
+	<-ts.done
+}
+
+func (ts *TieredStore) run(ctx context.Context) {
+	defer close(ts.done)
+	ticker := time.NewTicker(ts.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := ts.Compact(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to move old messages to cold storage: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// ContactStore keeps the contacts each user added. Presence events about
+// a user go to the users that added them, so the store also answers the
+// reverse question.
+type ContactStore interface {
+	Contacts(ctx context.Context, user string) ([]string, error)
+	AddContact(ctx context.Context, user, contact string) error
+	RemoveContact(ctx context.Context, user, contact string) error
+	// AddedBy returns the users that have contact in their contacts
+	AddedBy(ctx context.Context, contact string) ([]string, error)
+}
+
+// MemoryContactStore is an in-process ContactStore
+type MemoryContactStore struct {
+	mu       sync.RWMutex
+	contacts map[string]map[string]bool // user -> contacts
+	addedBy  map[string]map[string]bool // contact -> users
+}
+
+// NewMemoryContactStore creates an empty MemoryContactStore
+func NewMemoryContactStore() *MemoryContactStore {
+	return &MemoryContactStore{
+		contacts: make(map[string]map[string]bool),
+		addedBy:  make(map[string]map[string]bool),
+	}
+}
+
+// Contacts returns the user's contacts sorted by name
+func (m *MemoryContactStore) Contacts(_ context.Context, user string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return sortedKeys(m.contacts[user]), nil
+}
+
+// AddContact adds contact to the user's contacts
+func (m *MemoryContactStore) AddContact(_ context.Context, user, contact string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	addEdge(m.contacts, user, contact)
+	addEdge(m.addedBy, contact, user)
+	return nil
+}
+
+// RemoveContact drops contact from the user's contacts
+func (m *MemoryContactStore) RemoveContact(_ context.Context, user, contact string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removeEdge(m.contacts, user, contact)
+	removeEdge(m.addedBy, contact, user)
+	return nil
+}
+
+// AddedBy returns the users that added contact, sorted by name
+func (m *MemoryContactStore) AddedBy(_ context.Context, contact string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return sortedKeys(m.addedBy[contact]), nil
+}
+
+// renameContacts moves the contacts of oldName to newName, and replaces
+// oldName with newName in the contacts of the users that added them
+func (s *ChatServer) renameContacts(ctx context.Context, oldName, newName string) error {
+	contacts, err := s.contacts.Contacts(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	for _, c := range contacts {
+		if err := s.contacts.RemoveContact(ctx, oldName, c); err != nil {
+			return err
+		}
+		if c == newName {
+			continue
+		}
+		if err := s.contacts.AddContact(ctx, newName, c); err != nil {
+			return err
+		}
+	}
+	addedBy, err := s.contacts.AddedBy(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	for _, u := range addedBy {
+		if err := s.contacts.RemoveContact(ctx, u, oldName); err != nil {
+			return err
+		}
+		if u == newName {
+			continue
+		}
+		if err := s.contacts.AddContact(ctx, u, newName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addEdge(edges map[string]map[string]bool, from, to string) {
+	if edges[from] == nil {
+		edges[from] = make(map[string]bool)
+	}
+	edges[from][to] = true
+}
+
+func removeEdge(edges map[string]map[string]bool, from, to string) {
+	delete(edges[from], to)
+	if len(edges[from]) == 0 {
+		delete(edges, from)
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// presenceAudience says which connections hear about the presence of
+// some users: the users themselves, connections receiving a room the user
+// is a member of and users that added them as a contact. Everybody else is
+// spared the join/leave noise of people they share nothing with.
+type presenceAudience struct {
+	rooms   map[string]map[string]bool // subject -> rooms it is a member of
+	watched map[string]map[string]bool // subject -> users that added it
+}
+
+// audience looks up who may hear about subjects. A failing contact store
+// only narrows the audience to the rooms.
+func (s *ChatServer) audience(ctx context.Context, subjects []string) presenceAudience {
+	a := presenceAudience{
+		rooms:   make(map[string]map[string]bool, len(subjects)),
+		watched: make(map[string]map[string]bool, len(subjects)),
+	}
+	for _, subject := range subjects {
+		a.rooms[subject] = s.members.roomsOf(subject)
+		users, err := s.contacts.AddedBy(ctx, subject)
+		if err != nil {
+			log.Printf("Failed to load the contacts of '%s': %v", subject, err)
+		}
+		a.watched[subject] = make(map[string]bool, len(users))
+		for _, u := range users {
+			a.watched[subject][u] = true
+		}
+	}
+	return a
+}
+
+// visible returns the subjects conn hears about, in their original order
+func (a presenceAudience) visible(conn connection, subjects []string) []string {
+	var out []string
+	for _, subject := range subjects {
+		if subject == conn.user || a.watched[subject][conn.user] || a.shareRoom(subject, conn) {
+			out = append(out, subject)
+		}
+	}
+	return out
+}
+
+// shareRoom reports whether conn receives a room subject is a member of
+func (a presenceAudience) shareRoom(subject string, conn connection) bool {
+	for room := range a.rooms[subject] {
+		if conn.in(room) {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcastPresence sends the presence event build makes for subjects to
+// the connections in their audience. Connections that may hear about only
+// some of the subjects get an event built for those alone.
+func (s *ChatServer) broadcastPresence(subjects []string, build func(users []string) *pb.ChatMessage, excludeID string) {
+	a := s.audience(s.ctx, subjects)
+	all := build(subjects)
+	built := map[string]*pb.ChatMessage{strings.Join(subjects, "\x00"): all}
+	targets := make(map[*pb.ChatMessage][]connection)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for id, conn := range s.connections {
+		if id == excludeID || !conn.wants("", all) {
+			continue
+		}
+		users := a.visible(conn, subjects)
+		if len(users) == 0 {
+			continue
+		}
+		key := strings.Join(users, "\x00")
+		msg, ok := built[key]
+		if !ok {
+			msg = build(users)
+			built[key] = msg
+		}
+		targets[msg] = append(targets[msg], conn)
+	}
+	for msg, conns := range targets {
+		s.fanout(msg, conns)
+	}
+	s.watchers.deliver("", all)
+}
+
+// presenceFilter returns the users out of candidates that the connection
+// clientID may hear about, used for the snapshot a new connection gets
+func (s *ChatServer) presenceFilter(clientID string, candidates []string) []string {
+	s.mu.RLock()
+	conn, ok := s.connections[clientID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return s.audience(s.ctx, candidates).visible(conn, candidates)
+}
+
+// contactList returns user's contacts with their current presence
+func (s *ChatServer) contactList(ctx context.Context, user string) (*pb.Contacts, error) {
+	names, err := s.contacts.Contacts(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	presence := s.Presence()
+	out := &pb.Contacts{User: user, Contacts: make([]*pb.Contact, 0, len(names))}
+	for _, name := range names {
+		c := &pb.Contact{User: name}
+		if c.Online = s.isOnline(name); c.Online {
+			c.Status = presence[name]
+		}
+		out.Contacts = append(out.Contacts, c)
+	}
+	return out, nil
+}
+
+// contactServer implements the ContactService RPCs
+type contactServer struct {
+	pb.UnimplementedContactServiceServer
+	s *ChatServer
+}
+
+// ListContacts returns a user's contacts and whether they are online
+func (c *contactServer) ListContacts(ctx context.Context, req *pb.ContactsRequest) (*pb.Contacts, error) {
+	if err := c.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	list, err := c.s.contactList(ctx, req.User)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "load contacts: %v", err)
+	}
+	return list, nil
+}
+
+// AddContact subscribes a user to the presence of another
+func (c *contactServer) AddContact(ctx context.Context, req *pb.ContactRequest) (*pb.Contacts, error) {
+	if err := validateContact(req); err != nil {
+		return nil, err
+	}
+	if err := c.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	if err := c.s.contacts.AddContact(ctx, req.User, req.Contact); err != nil {
+		return nil, status.Errorf(codes.Internal, "save contact: %v", err)
+	}
+	return c.ListContacts(ctx, &pb.ContactsRequest{User: req.User})
+}
+
+// RemoveContact ends a user's subscription to the presence of another
+func (c *contactServer) RemoveContact(ctx context.Context, req *pb.ContactRequest) (*pb.Contacts, error) {
+	if err := validateContact(req); err != nil {
+		return nil, err
+	}
+	if err := c.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	if err := c.s.contacts.RemoveContact(ctx, req.User, req.Contact); err != nil {
+		return nil, status.Errorf(codes.Internal, "remove contact: %v", err)
+	}
+	return c.ListContacts(ctx, &pb.ContactsRequest{User: req.User})
+}
+
+func validateContact(req *pb.ContactRequest) error {
+	switch {
+	case req.User == "" || strings.TrimSpace(req.Contact) == "":
+		return status.Error(codes.InvalidArgument, "user and contact cannot be empty")
+	case req.User == req.Contact:
+		return status.Error(codes.InvalidArgument, "users cannot add themselves")
+	}
+	return nil
+}
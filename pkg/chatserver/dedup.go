@@ -0,0 +1,90 @@
+package chatserver
+
+import (
+	"sync"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultDedupWindow is how long client message IDs are remembered
+const DefaultDedupWindow = 5 * time.Minute
+
+// limits for remembered client message IDs
+const (
+	maxDedupPerSender = 1024
+	maxClientMsgID    = 128
+)
+
+// dedupEntry is what the first delivery of a client message produced,
+// id is empty while it is still being accepted
+type dedupEntry struct {
+	id      string
+	room    string
+	seq     uint64
+	expires time.Time
+}
+
+// dedupCache remembers recent client_msg_ids per sender so a retry after
+// an ambiguous failure, possibly on a new connection, is not broadcast twice
+type dedupCache struct {
+	mu      sync.Mutex
+	senders map[string]map[string]dedupEntry
+}
+
+func newDedupCache() *dedupCache {
+	return &dedupCache{senders: make(map[string]map[string]dedupEntry)}
+}
+
+// claim reserves key for sender. When it was already claimed within
+// window the earlier entry is returned with dup set.
+func (d *dedupCache) claim(sender, key string, window time.Duration) (dedupEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	seen := d.senders[sender]
+	if e, ok := seen[key]; ok && now.Before(e.expires) {
+		return e, true
+	}
+	if seen == nil {
+		seen = make(map[string]dedupEntry)
+		d.senders[sender] = seen
+	}
+	if len(seen) >= maxDedupPerSender {
+		for k, e := range seen {
+			if now.After(e.expires) {
+				delete(seen, k)
+			}
+		}
+		for k := range seen {
+			if len(seen) < maxDedupPerSender {
+				break
+			}
+			delete(seen, k)
+		}
+	}
+	seen[key] = dedupEntry{expires: now.Add(window)}
+	return dedupEntry{}, false
+}
+
+// record fills in the server ID and sequence of a claimed key
+func (d *dedupCache) record(sender, key string, msg *pb.ChatMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if e, ok := d.senders[sender][key]; ok {
+		e.id, e.room, e.seq = msg.Id, msg.Room, msg.Seq
+		d.senders[sender][key] = e
+	}
+}
+
+// release forgets a claimed key whose message was not accepted
+func (d *dedupCache) release(sender, key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.senders[sender], key)
+}
+
+// ack acknowledges a message to the connection that sent it
+func (s *ChatServer) ack(clientID string, ack *pb.Ack) {
+	s.sendToConn(clientID, &pb.ChatMessage{User: "System", Type: pb.MessageType_TYPE_ACK, Payload: &pb.ChatMessage_Ack{Ack: ack}})
+}
@@ -0,0 +1,180 @@
+package chatserver
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// maxDrafts caps the drafts kept per user, the least recently edited
+	// go first
+	maxDrafts = 100
+	// maxDraftLength applies when Limits.MaxMessageLength is not set
+	maxDraftLength = 16 << 10
+)
+
+// DraftStore keeps the unsent message of each conversation of a user.
+// Deleted drafts are kept with empty text so an older save arriving late
+// cannot bring them back.
+type DraftStore interface {
+	// SaveDraft keeps d unless the stored draft of d.Conversation is
+	// newer, it returns the draft in effect
+	SaveDraft(ctx context.Context, user string, d *pb.Draft) (*pb.Draft, error)
+	// Drafts returns the drafts of user, deleted ones included
+	Drafts(ctx context.Context, user string) ([]*pb.Draft, error)
+}
+
+// MemoryDraftStore is an in-process DraftStore, drafts are lost when the
+// process restarts
+type MemoryDraftStore struct {
+	mu     sync.RWMutex
+	drafts map[string]map[string]*pb.Draft // user -> conversation
+}
+
+// NewMemoryDraftStore creates an empty MemoryDraftStore
+func NewMemoryDraftStore() *MemoryDraftStore {
+	return &MemoryDraftStore{drafts: make(map[string]map[string]*pb.Draft)}
+}
+
+// SaveDraft implements DraftStore, equal timestamps go to the later save
+func (m *MemoryDraftStore) SaveDraft(_ context.Context, user string, d *pb.Draft) (*pb.Draft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	drafts := m.drafts[user]
+	if prev, ok := drafts[d.Conversation]; ok && prev.UpdatedAt > d.UpdatedAt {
+		return proto.Clone(prev).(*pb.Draft), nil
+	}
+	if drafts == nil {
+		drafts = make(map[string]*pb.Draft)
+		m.drafts[user] = drafts
+	}
+	drafts[d.Conversation] = proto.Clone(d).(*pb.Draft)
+	for len(drafts) > maxDrafts {
+		var oldest *pb.Draft
+		for _, o := range drafts {
+			if oldest == nil || o.UpdatedAt < oldest.UpdatedAt {
+				oldest = o
+			}
+		}
+		delete(drafts, oldest.Conversation)
+	}
+	return proto.Clone(d).(*pb.Draft), nil
+}
+
+// Drafts implements DraftStore
+func (m *MemoryDraftStore) Drafts(_ context.Context, user string) ([]*pb.Draft, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*pb.Draft, 0, len(m.drafts[user]))
+	for _, d := range m.drafts[user] {
+		out = append(out, proto.Clone(d).(*pb.Draft))
+	}
+	return out, nil
+}
+
+// renameDrafts moves the drafts of oldName to newName. DraftStore cannot
+// delete, so the drafts of oldName are left deleted, newer than the moved
+// ones.
+func (s *ChatServer) renameDrafts(ctx context.Context, oldName, newName string) error {
+	drafts, err := s.drafts.Drafts(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	for _, d := range drafts {
+		if d.Text == "" {
+			continue
+		}
+		if _, err := s.drafts.SaveDraft(ctx, newName, d); err != nil {
+			return err
+		}
+		deleted := &pb.Draft{Conversation: d.Conversation, UpdatedAt: max(now, d.UpdatedAt+1)}
+		if _, err := s.drafts.SaveDraft(ctx, oldName, deleted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// draftConversation validates and normalizes "#room" or "@user"
+func draftConversation(name string) (string, error) {
+	switch {
+	case strings.HasPrefix(name, "#"):
+		if room, ok := normalizeRoom(name[1:]); ok {
+			return "#" + room, nil
+		}
+	case strings.HasPrefix(name, "@"):
+		if user := name[1:]; user != "" && !strings.ContainsAny(user, " \t\r\n") {
+			return name, nil
+		}
+	}
+	return "", status.Errorf(codes.InvalidArgument, "%q is not a conversation, expected #room or @user", name)
+}
+
+// draftServer implements the DraftService RPCs
+type draftServer struct {
+	pb.UnimplementedDraftServiceServer
+	s *ChatServer
+}
+
+// SaveDraft keeps the draft of a conversation unless a newer one was
+// saved, from this or another device
+func (d *draftServer) SaveDraft(ctx context.Context, req *pb.SaveDraftRequest) (*pb.Draft, error) {
+	if err := d.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	conv, err := draftConversation(req.Conversation)
+	if err != nil {
+		return nil, err
+	}
+	max := d.s.limits.MaxMessageLength
+	if max <= 0 {
+		max = maxDraftLength
+	}
+	if len(req.Text) > max {
+		return nil, status.Errorf(codes.InvalidArgument, "drafts are limited to %d bytes", max)
+	}
+	now := time.Now().UnixMilli()
+	at := req.UpdatedAt
+	if at <= 0 || at > now {
+		at = now // a device whose clock runs ahead would otherwise always win
+	}
+	saved, err := d.s.drafts.SaveDraft(ctx, req.User, &pb.Draft{Conversation: conv, Text: req.Text, UpdatedAt: at})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "save draft: %v", err)
+	}
+	return saved, nil
+}
+
+// GetDrafts lists the drafts of a user, most recently edited first
+func (d *draftServer) GetDrafts(ctx context.Context, req *pb.DraftsRequest) (*pb.Drafts, error) {
+	if err := d.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	all, err := d.s.drafts.Drafts(ctx, req.User)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "load drafts: %v", err)
+	}
+	out := &pb.Drafts{User: req.User}
+	for _, draft := range all {
+		if draft.Text != "" {
+			out.Drafts = append(out.Drafts, draft)
+		}
+	}
+	sort.Slice(out.Drafts, func(i, j int) bool {
+		if out.Drafts[i].UpdatedAt != out.Drafts[j].UpdatedAt {
+			return out.Drafts[i].UpdatedAt > out.Drafts[j].UpdatedAt
+		}
+		return out.Drafts[i].Conversation < out.Drafts[j].Conversation
+	})
+	return out, nil
+}
@@ -0,0 +1,50 @@
+package chatserver
+
+import (
+	"time"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// sendEphemeral sends msg to the connection clientID only, addressed to
+// its user. Nothing is kept, the reply is lost when the connection is
+// gone.
+func (s *ChatServer) sendEphemeral(clientID string, msg *pb.ChatMessage) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conn, ok := s.connections[clientID]
+	if ok {
+		msg.EphemeralTo = conn.user
+		go s.sendRoutine(conn.stream, msg, conn.user)
+	}
+	return ok
+}
+
+// deliverEphemeral sends a client's ephemeral message to the connections
+// of its target in room without storing it or copying it to the sender.
+// The sender is told when it cannot be delivered and false is returned.
+func (s *ChatServer) deliverEphemeral(stream pb.ChatService_RealtimeChatServer, clientID, room string, msg *pb.ChatMessage) bool {
+	if msg.RecipientUser != "" {
+		s.sendSystem(stream, clientID, i18n.EphemeralPM)
+		return false
+	}
+	msg.Id = s.newID()
+	msg.Timestamp = time.Now().UnixMilli()
+	msg.Type = pb.MessageType_TYPE_CHAT
+	msg.Room = room
+
+	s.mu.RLock()
+	found := false
+	for _, conn := range s.connections {
+		if conn.user == msg.EphemeralTo && conn.in(room) {
+			go s.sendRoutine(conn.stream, msg, conn.user)
+			found = true
+		}
+	}
+	s.mu.RUnlock()
+	if !found {
+		s.sendSystem(stream, clientID, i18n.EphemeralAbsent, "user", msg.EphemeralTo, "room", room)
+	}
+	return found
+}
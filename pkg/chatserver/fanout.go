@@ -0,0 +1,170 @@
+package chatserver
+
+import (
+	"log"
+	"sync"
+
+	"google.golang.org/grpc/encoding"
+	protocodec "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/mem"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// sharedFrames holds the wire encoding of the messages being fanned out,
+// so a message sent to many streams is marshaled once. The encoding is
+// a plain slice rather than a pooled buffer: the transport may still be
+// writing it after Send returns, so only the garbage collector knows
+// when the last stream is done with it.
+type sharedFrames struct {
+	mu     sync.Mutex
+	frames map[*pb.ChatMessage]*sharedFrame
+}
+
+type sharedFrame struct {
+	data []byte
+	refs int // sends that have not marshaled yet
+}
+
+// share marshals msg for n sends, each must call release once it is
+// sent. It reports false when msg cannot be marshaled, the sends then
+// fail on their own.
+func (f *sharedFrames) share(msg *pb.ChatMessage, n int) bool {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to marshal message %s for fan-out: %v", msg.Id, err)
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.frames == nil {
+		f.frames = make(map[*pb.ChatMessage]*sharedFrame)
+	}
+	if frame, ok := f.frames[msg]; ok {
+		frame.refs += n // already being fanned out
+		return true
+	}
+	f.frames[msg] = &sharedFrame{data: data, refs: n}
+	return true
+}
+
+// release drops one send of msg
+func (f *sharedFrames) release(msg *pb.ChatMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if frame, ok := f.frames[msg]; ok {
+		if frame.refs--; frame.refs <= 0 {
+			delete(f.frames, msg)
+		}
+	}
+}
+
+// pending returns the sends that have not marshaled yet
+func (f *sharedFrames) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, frame := range f.frames {
+		n += frame.refs
+	}
+	return n
+}
+
+// lookup returns the shared encoding of v
+func (f *sharedFrames) lookup(v any) ([]byte, bool) {
+	msg, ok := v.(*pb.ChatMessage)
+	if !ok {
+		return nil, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	frame, ok := f.frames[msg]
+	if !ok {
+		return nil, false
+	}
+	return frame.data, true
+}
+
+// frameCodec is the server's proto codec, messages being fanned out are
+// sent from their shared encoding and everything else is marshaled as
+// usual
+type frameCodec struct {
+	encoding.CodecV2
+	frames *sharedFrames
+}
+
+func newFrameCodec(frames *sharedFrames) frameCodec {
+	return frameCodec{encoding.GetCodecV2(protocodec.Name), frames}
+}
+
+func (c frameCodec) Marshal(v any) (mem.BufferSlice, error) {
+	if data, ok := c.frames.lookup(v); ok {
+		// never written to, so every stream can hold the same bytes
+		return mem.BufferSlice{mem.SliceBuffer(data)}, nil
+	}
+	if msg, ok := v.(*pb.ChatMessage); ok && msg.GetBatch() != nil {
+		if data, ok := c.frames.batch(msg); ok {
+			return mem.BufferSlice{mem.SliceBuffer(data)}, nil
+		}
+	}
+	return c.CodecV2.Marshal(v)
+}
+
+// batchField is the number of ChatMessage.batch
+var batchField = (&pb.ChatMessage{}).ProtoReflect().Descriptor().Fields().ByName("batch").Number()
+
+// batch encodes a TYPE_BATCH message of batchStream, copying the shared
+// encodings of its messages rather than marshaling them again. It
+// reports false when none of them is being fanned out.
+func (f *sharedFrames) batch(msg *pb.ChatMessage) ([]byte, bool) {
+	msgs := msg.GetBatch().Messages
+	frames := make([][]byte, len(msgs))
+	shared := false
+	f.mu.Lock()
+	for i, m := range msgs {
+		if frame, ok := f.frames[m]; ok {
+			frames[i], shared = frame.data, true
+		}
+	}
+	f.mu.Unlock()
+	if !shared {
+		return nil, false
+	}
+
+	var body []byte
+	for i, m := range msgs {
+		data := frames[i]
+		if data == nil {
+			var err error
+			if data, err = proto.Marshal(m); err != nil {
+				return nil, false
+			}
+		}
+		body = protowire.AppendTag(body, 1, protowire.BytesType) // MessageBatch.messages
+		body = protowire.AppendBytes(body, data)
+	}
+	// batchStream only sets the type besides the batch
+	data, err := proto.Marshal(&pb.ChatMessage{Type: msg.Type})
+	if err != nil {
+		return nil, false
+	}
+	data = protowire.AppendTag(data, batchField, protowire.BytesType)
+	return protowire.AppendBytes(data, body), true
+}
+
+// fanout sends msg to each connection on its own goroutine, marshaling
+// it once for all of them. Streams that rewrite msg, such as clients
+// without a capability, marshal their copy themselves.
+func (s *ChatServer) fanout(msg *pb.ChatMessage, targets []connection) {
+	shared := len(targets) > 1 && s.frames.share(msg, len(targets))
+	for _, conn := range targets {
+		go func() {
+			if shared {
+				defer s.frames.release(msg)
+			}
+			s.sendRoutine(conn.stream, msg, conn.user)
+		}()
+	}
+}
@@ -0,0 +1,109 @@
+package chatserver
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+func fanoutMessage() *pb.ChatMessage {
+	return &pb.ChatMessage{
+		Id:        "01J0000000000000000000000",
+		User:      "alice",
+		Room:      DefaultRoom,
+		Seq:       42,
+		Text:      "the quick brown fox jumps over the lazy dog",
+		Timestamp: 1760000000000,
+	}
+}
+
+func TestFrameCodecSharesEncoding(t *testing.T) {
+	frames := &sharedFrames{}
+	codec := newFrameCodec(frames)
+	msg := fanoutMessage()
+	want, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !frames.share(msg, 2) {
+		t.Fatal("share failed")
+	}
+	for range 2 {
+		got, err := codec.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got.Materialize()) != string(want) {
+			t.Error("shared encoding differs from proto.Marshal")
+		}
+		frames.release(msg)
+	}
+	if n := frames.pending(); n != 0 {
+		t.Errorf("pending = %d after every send released, want 0", n)
+	}
+	if _, ok := frames.lookup(msg); ok {
+		t.Error("frame still shared after every send released")
+	}
+}
+
+func TestFrameCodecBatch(t *testing.T) {
+	frames := &sharedFrames{}
+	codec := newFrameCodec(frames)
+	shared, other := fanoutMessage(), fanoutMessage()
+	other.Id, other.Text = "01J0000000000000000000001", "not shared"
+	batch := &pb.ChatMessage{
+		Type:    pb.MessageType_TYPE_BATCH,
+		Payload: &pb.ChatMessage_Batch{Batch: &pb.MessageBatch{Messages: []*pb.ChatMessage{shared, other}}},
+	}
+	frames.share(shared, 1)
+	defer frames.release(shared)
+
+	data, err := codec.Marshal(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(pb.ChatMessage)
+	if err := proto.Unmarshal(data.Materialize(), got); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got, batch) {
+		t.Errorf("batch decodes to %v, want %v", got, batch)
+	}
+}
+
+// BenchmarkFanoutEncode compares marshaling a message for each stream
+// with marshaling it once and sharing the encoding
+func BenchmarkFanoutEncode(b *testing.B) {
+	msg := fanoutMessage()
+	for _, n := range []int{10, 100} {
+		b.Run(fmt.Sprintf("per-stream/%d", n), func(b *testing.B) {
+			codec := newFrameCodec(&sharedFrames{})
+			b.ReportAllocs()
+			for b.Loop() {
+				for range n {
+					if _, err := codec.Marshal(msg); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("shared/%d", n), func(b *testing.B) {
+			frames := &sharedFrames{}
+			codec := newFrameCodec(frames)
+			b.ReportAllocs()
+			for b.Loop() {
+				frames.share(msg, n)
+				for range n {
+					if _, err := codec.Marshal(msg); err != nil {
+						b.Fatal(err)
+					}
+					frames.release(msg)
+				}
+			}
+		})
+	}
+}
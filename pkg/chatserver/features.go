@@ -0,0 +1,213 @@
+package chatserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// The features the server enforces, they are on unless a flag turns them
+// off. Flags of other names are only passed on to clients.
+const (
+	FeatureUploads      = "uploads"       // voice and file attachments in messages, AttachmentService uploads
+	FeatureCalls        = "calls"         // placing calls
+	FeatureLinkPreviews = "link_previews" // previews of the links in messages
+	FeatureTranslations = "translations"  // /translate and automatic translation
+)
+
+var builtinFeatures = []string{FeatureUploads, FeatureCalls, FeatureLinkPreviews, FeatureTranslations}
+
+// featurePollInterval is how often the feature flag file is checked for
+// changes
+const featurePollInterval = 2 * time.Second
+
+var featureName = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// FeatureFlag turns a feature on or off. A room listed in Rooms follows
+// its entry there, otherwise a tenant listed in Tenants, otherwise
+// Enabled.
+type FeatureFlag struct {
+	Enabled bool            `json:"enabled"`
+	Tenants map[string]bool `json:"tenants,omitempty"`
+	Rooms   map[string]bool `json:"rooms,omitempty"`
+}
+
+// FeatureFlags are the flags of WithFeatureFlags by feature name
+type FeatureFlags map[string]FeatureFlag
+
+// LoadFeatureFlags reads and validates a JSON file of feature flags,
+// room names are normalized
+func LoadFeatureFlags(path string) (FeatureFlags, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var flags FeatureFlags
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := flags.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid feature flags %s: %w", path, err)
+	}
+	for name, f := range flags {
+		rooms := make(map[string]bool, len(f.Rooms))
+		for room, on := range f.Rooms {
+			room, _ = normalizeRoom(room)
+			rooms[room] = on
+		}
+		f.Rooms = rooms
+		flags[name] = f
+	}
+	return flags, nil
+}
+
+// Validate checks the feature and room names
+func (f FeatureFlags) Validate() error {
+	var errs []error
+	for name, flag := range f {
+		if !featureName.MatchString(name) {
+			errs = append(errs, fmt.Errorf("%q is not a valid feature name", name))
+		}
+		for room := range flag.Rooms {
+			if _, ok := normalizeRoom(room); !ok {
+				errs = append(errs, fmt.Errorf("%s: %q is not a valid room name", name, room))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// featureSet holds the flags in effect, the zero value turns the
+// builtin features on and nothing else
+type featureSet struct {
+	mu    sync.RWMutex
+	flags FeatureFlags
+	stamp string // size and mtime of the file the flags were loaded from
+}
+
+// WithFeatureFlags reads feature flags from the JSON file at path and
+// reloads it when it changes, see FeatureFlags
+func WithFeatureFlags(path string) Option {
+	return func(s *ChatServer) {
+		s.featurePath = path
+	}
+}
+
+// enabled reports whether feature is on for tenant in room, both may be
+// empty
+func (fs *featureSet) enabled(feature, tenant, room string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	flag, ok := fs.flags[feature]
+	if !ok {
+		return slices.Contains(builtinFeatures, feature)
+	}
+	if on, ok := flag.Rooms[room]; ok && room != "" {
+		return on
+	}
+	if on, ok := flag.Tenants[tenant]; ok && tenant != "" {
+		return on
+	}
+	return flag.Enabled
+}
+
+// all resolves every known feature for tenant in room
+func (fs *featureSet) all(tenant, room string) map[string]bool {
+	fs.mu.RLock()
+	names := slices.AppendSeq(slices.Clone(builtinFeatures), maps.Keys(fs.flags))
+	fs.mu.RUnlock()
+	out := make(map[string]bool, len(names))
+	for _, name := range names {
+		out[name] = fs.enabled(name, tenant, room)
+	}
+	return out
+}
+
+// reload replaces the flags when the file at path changed, keeping the
+// loaded ones when it cannot be read or is invalid
+func (fs *featureSet) reload(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stamp := fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+	fs.mu.RLock()
+	unchanged := stamp == fs.stamp
+	fs.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	flags, err := LoadFeatureFlags(path)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.stamp = stamp // report a broken file once, not every poll
+	if err != nil {
+		return err
+	}
+	fs.flags = flags
+	log.Printf("Loaded %d feature flags from %s", len(flags), path)
+	return nil
+}
+
+// startFeatureFlags loads the feature flag file and reloads it whenever
+// it changes until the server stops
+func (s *ChatServer) startFeatureFlags(path string) {
+	if err := s.features.reload(path); err != nil {
+		log.Printf("Feature flags: %v", err)
+	}
+	go func() {
+		t := time.NewTicker(featurePollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-s.ctx.Done():
+				return
+			}
+			if err := s.features.reload(path); err != nil {
+				log.Printf("Feature flags: %v, keeping the loaded ones", err)
+			}
+		}
+	}()
+}
+
+// feature reports whether feature is on for user in room, room is empty
+// for private messages
+func (s *ChatServer) feature(feature, user, room string) bool {
+	return s.features.enabled(feature, s.tenant(user), room)
+}
+
+// featureServer implements pb.FeatureServiceServer
+type featureServer struct {
+	pb.UnimplementedFeatureServiceServer
+	s *ChatServer
+}
+
+// GetFeatures resolves every feature flag for the user's tenant and a room
+func (f *featureServer) GetFeatures(_ context.Context, req *pb.FeaturesRequest) (*pb.Features, error) {
+	room := DefaultRoom
+	if req.Room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(req.Room); !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+	}
+	tenant := DefaultTenant
+	if req.User != "" {
+		tenant = f.s.tenant(req.User)
+	}
+	return &pb.Features{Room: room, Features: f.s.features.all(tenant, room)}, nil
+}
@@ -0,0 +1,321 @@
+package chatserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// maxStoredLine bounds one message in a FileStore, well above what the
+// message, code and metadata limits allow
+const maxStoredLine = 1 << 20
+
+// FileStore appends messages to a file, one protojson object per line.
+// It is meant for small deployments and imports, reads scan the whole
+// file.
+type FileStore struct {
+	mu   sync.Mutex // serialises appends, each message is one write
+	f    *os.File
+	path string
+}
+
+// NewFileStore opens or creates the store at path
+func NewFileStore(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{f: f, path: path}, nil
+}
+
+// SaveMessage appends msg to the file
+func (fs *FileStore) SaveMessage(_ context.Context, msg *pb.ChatMessage) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, err = fs.f.Write(append(data, '\n'))
+	return err
+}
+
+// RoomMessages implements RoomReader. Messages appended while it runs
+// are not seen.
+func (fs *FileStore) RoomMessages(ctx context.Context, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error {
+	size, err := fs.size()
+	if err != nil {
+		return err
+	}
+	return scanStored(ctx, fs.path, size, room, from, to, fn)
+}
+
+// Rooms implements RoomLister, scanning the whole file
+func (fs *FileStore) Rooms(ctx context.Context) ([]string, error) {
+	size, err := fs.size()
+	if err != nil {
+		return nil, err
+	}
+	rooms := make(map[string]bool)
+	err = scanLines(ctx, fs.path, size, func(msg *pb.ChatMessage) error {
+		if msg.RecipientUser == "" && msg.Room != "" {
+			rooms[msg.Room] = true
+		}
+		return nil
+	})
+	return slices.Sorted(maps.Keys(rooms)), err
+}
+
+// Watermark returns the timestamp of the message written last, zero when
+// the file is empty
+func (fs *FileStore) Watermark(context.Context) (time.Time, error) {
+	size, err := fs.size()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lastStored(fs.path, size)
+}
+
+// size returns how much of the file is written completely now
+func (fs *FileStore) size() (int64, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	info, err := fs.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Compact implements Compactor. The file is rewritten without the old
+// messages while appends go on, only the messages appended meanwhile are
+// copied with appends held. Messages appended during the rewrite stay
+// in the file whatever their timestamp.
+func (fs *FileStore) Compact(ctx context.Context, cutoff time.Time, fn func([]*pb.ChatMessage) error) error {
+	size, err := fs.size()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(fs.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), ".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // after the rename it is gone already
+	defer tmp.Close()
+
+	var old []*pb.ChatMessage
+	w := bufio.NewWriter(tmp)
+	sc := bufio.NewScanner(io.LimitReader(f, size))
+	sc.Buffer(make([]byte, 64<<10), maxStoredLine)
+	for line := 1; sc.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg := &pb.ChatMessage{}
+		if err := protojson.Unmarshal(sc.Bytes(), msg); err != nil {
+			return fmt.Errorf("%s:%d: %w", fs.path, line, err)
+		}
+		if time.UnixMilli(msg.Timestamp).Before(cutoff) {
+			old = append(old, msg)
+			continue
+		}
+		w.Write(sc.Bytes())
+		w.WriteByte('\n')
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(old) == 0 {
+		return fn(nil)
+	}
+	if err := fn(old); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, err := io.Copy(w, io.NewSectionReader(f, size, math.MaxInt64-size)); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), fs.path); err != nil {
+		return err
+	}
+	// appends go to the new file from now on
+	nf, err := os.OpenFile(fs.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	fs.f.Close()
+	fs.f = nf
+	return nil
+}
+
+// FileReplica reads a copy of a FileStore's file that another process
+// keeps up to date, such as a replicated volume or a periodic rsync. It
+// never writes to the file.
+type FileReplica struct {
+	path string
+}
+
+// NewFileReplica reads the store file at path, which need not exist yet
+func NewFileReplica(path string) *FileReplica {
+	return &FileReplica{path: path}
+}
+
+// RoomMessages implements RoomReader, a line still being copied at the
+// end of the file is skipped
+func (fr *FileReplica) RoomMessages(ctx context.Context, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error {
+	size, err := completeSize(fr.path)
+	if err != nil {
+		return err
+	}
+	return scanStored(ctx, fr.path, size, room, from, to, fn)
+}
+
+// Watermark implements Replica
+func (fr *FileReplica) Watermark(context.Context) (time.Time, error) {
+	size, err := completeSize(fr.path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lastStored(fr.path, size)
+}
+
+// completeSize returns the size of path up to its last newline, 0 when
+// it does not exist
+func completeSize(path string) (int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end, err := lastNewline(f, info.Size(), maxStoredLine)
+	if err != nil {
+		return 0, err
+	}
+	return end + 1, nil
+}
+
+// lastNewline returns the offset of the last newline before size,
+// looking back at most limit bytes, -1 when there is none
+func lastNewline(f *os.File, size, limit int64) (int64, error) {
+	buf := make([]byte, 4<<10)
+	for end := size; end > 0 && size-end < limit; {
+		start := max(end-int64(len(buf)), 0)
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return start + int64(i), nil
+		}
+		end = start
+	}
+	return -1, nil
+}
+
+// lastStored returns the timestamp of the last message in the first size
+// bytes of the store file at path
+func lastStored(path string, size int64) (time.Time, error) {
+	if size == 0 {
+		return time.Time{}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	// size ends with the newline of the last message, find the one before
+	start, err := lastNewline(f, size-1, maxStoredLine)
+	if err != nil {
+		return time.Time{}, err
+	}
+	line := make([]byte, size-1-(start+1))
+	if _, err := f.ReadAt(line, start+1); err != nil {
+		return time.Time{}, err
+	}
+	msg := &pb.ChatMessage{}
+	if err := protojson.Unmarshal(line, msg); err != nil {
+		return time.Time{}, fmt.Errorf("%s: last message: %w", path, err)
+	}
+	return time.UnixMilli(msg.Timestamp), nil
+}
+
+// scanStored calls fn for the public messages of room sent in [from, to)
+// among the first size bytes of the store file at path
+func scanStored(ctx context.Context, path string, size int64, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error {
+	return scanLines(ctx, path, size, func(msg *pb.ChatMessage) error {
+		if msg.Room != room || msg.RecipientUser != "" || !inRange(msg, from, to) {
+			return nil
+		}
+		return fn(msg)
+	})
+}
+
+// scanLines calls fn for every message among the first size bytes of the
+// store file at path
+func scanLines(ctx context.Context, path string, size int64, fn func(*pb.ChatMessage) error) error {
+	if size == 0 {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(io.LimitReader(f, size))
+	sc.Buffer(make([]byte, 64<<10), maxStoredLine)
+	for line := 1; sc.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg := &pb.ChatMessage{}
+		if err := protojson.Unmarshal(sc.Bytes(), msg); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Close closes the file
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.f.Close()
+}
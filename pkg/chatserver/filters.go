@@ -0,0 +1,82 @@
+package chatserver
+
+import (
+	"log"
+	"slices"
+	"strconv"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// maxFilterRooms bounds the rooms a StreamFilter names, a stream never
+// receives more than its current room and its subscriptions
+const maxFilterRooms = maxSubscriptions + 1
+
+// checkFilter normalizes the rooms of f, a filter that lets everything
+// through is returned as nil. key is the i18n key of the problem when f
+// cannot be applied.
+func checkFilter(f *pb.StreamFilter) (filter *pb.StreamFilter, key string, args []string) {
+	if len(f.GetRooms()) > maxFilterRooms {
+		return nil, i18n.FilterTooMany, []string{"max", strconv.Itoa(maxFilterRooms)}
+	}
+	out := &pb.StreamFilter{MentionsOnly: f.GetMentionsOnly(), HideMembership: f.GetHideMembership()}
+	for _, r := range f.GetRooms() {
+		name, ok := normalizeRoom(r)
+		if !ok {
+			return nil, i18n.RoomInvalid, []string{"room", r}
+		}
+		if !slices.Contains(out.Rooms, name) {
+			out.Rooms = append(out.Rooms, name)
+		}
+	}
+	if !out.MentionsOnly && !out.HideMembership && len(out.Rooms) == 0 {
+		return nil, "", nil
+	}
+	return out, "", nil
+}
+
+// setFilter replaces the filter of clientID. A filter that cannot be
+// applied is told to the stream and the previous one stays in place.
+func (s *ChatServer) setFilter(stream pb.ChatService_RealtimeChatServer, clientID string, f *pb.StreamFilter) {
+	filter, key, args := checkFilter(f)
+	if key != "" {
+		s.sendSystem(stream, clientID, key, args...)
+		return
+	}
+	s.mu.Lock()
+	conn, ok := s.connections[clientID]
+	if ok {
+		conn.filter = filter
+		s.connections[clientID] = conn
+	}
+	s.mu.Unlock()
+	if ok {
+		log.Printf("Client %s set filter %v", clientID, filter)
+	}
+}
+
+// wants reports whether the connection's filter lets msg through before
+// it is fanned out. room is the room msg is delivered for, empty for
+// messages to everyone. PMs and messages addressed to one user always
+// pass.
+func (c connection) wants(room string, msg *pb.ChatMessage) bool {
+	f := c.filter
+	if f == nil || msg.RecipientUser != "" || msg.EphemeralTo != "" {
+		return true
+	}
+	if room != "" && len(f.Rooms) > 0 && !slices.Contains(f.Rooms, room) {
+		return false
+	}
+	t := msg.Type
+	if t == pb.MessageType_TYPE_UNSPECIFIED {
+		t = pb.TypeOf(msg)
+	}
+	switch t {
+	case pb.MessageType_TYPE_JOIN, pb.MessageType_TYPE_LEAVE, pb.MessageType_TYPE_MEMBER:
+		return !f.HideMembership
+	case pb.MessageType_TYPE_CHAT:
+		return !f.MentionsOnly || msg.User == c.user || mentions(msg.Text, c.user)
+	}
+	return true
+}
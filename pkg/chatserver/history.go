@@ -0,0 +1,214 @@
+package chatserver
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultHistorySize is how many recent messages each room keeps
+const DefaultHistorySize = 1000
+
+// maxHistoryPage bounds one GetHistory response
+const maxHistoryPage = 500
+
+// roomHistory keeps the latest public messages of each room in
+// sequence order, enough for clients to fill gaps after a hiccup
+type roomHistory struct {
+	mu    sync.RWMutex
+	size  int
+	rooms map[string][]*pb.ChatMessage
+}
+
+func newRoomHistory(size int) *roomHistory {
+	return &roomHistory{size: size, rooms: make(map[string][]*pb.ChatMessage)}
+}
+
+// add records msg, concurrent senders may finish out of order so it is
+// inserted by sequence
+func (h *roomHistory) add(msg *pb.ChatMessage) {
+	if h.size <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msgs := h.rooms[msg.Room]
+	i := sort.Search(len(msgs), func(i int) bool { return msgs[i].Seq > msg.Seq })
+	msgs = append(msgs, nil)
+	copy(msgs[i+1:], msgs[i:])
+	msgs[i] = msg
+	if len(msgs) > 2*h.size {
+		// trim in batches so appends stay cheap
+		msgs = append([]*pb.ChatMessage(nil), msgs[len(msgs)-h.size:]...)
+	}
+	h.rooms[msg.Room] = msgs
+}
+
+// replace swaps the kept message with the sequence of msg for msg, so
+// readers holding the old one never see it change
+func (h *roomHistory) replace(msg *pb.ChatMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msgs := h.rooms[msg.Room]
+	i := sort.Search(len(msgs), func(i int) bool { return msgs[i].Seq >= msg.Seq })
+	if i < len(msgs) && msgs[i].Id == msg.Id {
+		msgs[i] = msg
+	}
+}
+
+// between returns up to limit messages with after < seq < before,
+// before 0 means no upper bound
+func (h *roomHistory) between(room string, after, before uint64, limit int) []*pb.ChatMessage {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	msgs := h.rooms[room]
+	if len(msgs) > h.size {
+		msgs = msgs[len(msgs)-h.size:]
+	}
+	i := sort.Search(len(msgs), func(i int) bool { return msgs[i].Seq > after })
+	var out []*pb.ChatMessage
+	for ; i < len(msgs) && len(out) < limit; i++ {
+		if before != 0 && msgs[i].Seq >= before {
+			break
+		}
+		out = append(out, msgs[i])
+	}
+	return out
+}
+
+// latest returns up to n of the newest messages of room, oldest first
+func (h *roomHistory) latest(room string, n int) []*pb.ChatMessage {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	msgs := h.rooms[room]
+	if n > h.size {
+		n = h.size
+	}
+	if len(msgs) > n {
+		msgs = msgs[len(msgs)-n:]
+	}
+	return append([]*pb.ChatMessage(nil), msgs...)
+}
+
+// restore adds msgs handed over from another server, keeping the
+// messages of the same sequence already kept
+func (h *roomHistory) restore(room string, msgs []*pb.ChatMessage) {
+	if h.size <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	kept := h.rooms[room]
+	for _, msg := range msgs {
+		i := sort.Search(len(kept), func(i int) bool { return kept[i].Seq >= msg.Seq })
+		if i < len(kept) && kept[i].Seq == msg.Seq {
+			continue
+		}
+		kept = append(kept, nil)
+		copy(kept[i+1:], kept[i:])
+		kept[i] = msg
+	}
+	if len(kept) > h.size {
+		kept = append([]*pb.ChatMessage(nil), kept[len(kept)-h.size:]...)
+	}
+	h.rooms[room] = kept
+}
+
+// drop forgets the messages of room
+func (h *roomHistory) drop(room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.rooms, room)
+}
+
+// roomNames returns the rooms with kept messages
+func (h *roomHistory) roomNames() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return slices.Collect(maps.Keys(h.rooms))
+}
+
+// find returns the kept message with the given ID, newest first
+func (h *roomHistory) find(id string) *pb.ChatMessage {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, msgs := range h.rooms {
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].Id == id {
+				return msgs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// historyBefore returns up to limit of the newest public messages of
+// room sent before before, from the store when it can be read
+func (s *ChatServer) historyBefore(ctx context.Context, room string, before time.Time, limit int) ([]*pb.ChatMessage, error) {
+	switch st := s.store.(type) {
+	case HistoryReader:
+		return st.RoomHistory(ctx, room, before, limit)
+	case RoomReader:
+		return scanHistory(ctx, st, room, time.Time{}, before, limit)
+	}
+	var msgs []*pb.ChatMessage
+	for _, msg := range s.history.latest(room, s.history.size) {
+		if inRange(msg, time.Time{}, before) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs[max(len(msgs)-limit, 0):], nil
+}
+
+// historyServer implements the HistoryService RPCs
+type historyServer struct {
+	pb.UnimplementedHistoryServiceServer
+	s *ChatServer
+}
+
+// GetHistory returns the oldest messages in the requested range, or the
+// newest before before_time. The request names no user, so private rooms
+// are only served to the admin token.
+func (h *historyServer) GetHistory(ctx context.Context, req *pb.HistoryRequest) (*pb.HistoryResponse, error) {
+	if req.Room == "" {
+		req.Room = DefaultRoom
+	} else {
+		room, ok := normalizeRoom(req.Room)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+		req.Room = room
+	}
+	if h.s.access.isPrivate(req.Room) && (&adminServer{s: h.s}).authorize(ctx) != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "#%s is private", req.Room)
+	}
+	if req.BeforeTime > 0 {
+		limit := int(req.Limit)
+		if limit <= 0 || limit > maxHistoryPage {
+			limit = maxHistoryPage
+		}
+		msgs, err := h.s.historyBefore(withReadQuery(ctx, QueryHistory), req.Room, time.UnixMilli(req.BeforeTime), limit)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "store: %v", err)
+		}
+		return &pb.HistoryResponse{Messages: msgs}, nil
+	}
+	if req.BeforeSeq != 0 && req.BeforeSeq <= req.AfterSeq {
+		return nil, status.Error(codes.InvalidArgument, "before_seq must be greater than after_seq")
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxHistoryPage {
+		limit = maxHistoryPage
+	}
+	return &pb.HistoryResponse{
+		Messages: h.s.history.between(req.Room, req.AfterSeq, req.BeforeSeq, limit),
+	}, nil
+}
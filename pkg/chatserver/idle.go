@@ -0,0 +1,206 @@
+package chatserver
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultIdleTimeout is how long a connection may go without activity
+// before it counts as idle
+const DefaultIdleTimeout = 10 * time.Minute
+
+// idleTracker follows the activity of each connection. A user is away
+// while all of their connections are idle, either because the client
+// said so or because nothing happened for the timeout.
+type idleTracker struct {
+	mu      sync.Mutex
+	timeout time.Duration // 0 only honours the clients' hints
+	conns   map[string]*idleConn
+	away    map[string]bool // users currently shown as away
+}
+
+// idleConn is the activity of one connection
+type idleConn struct {
+	user  string
+	last  time.Time
+	idle  bool
+	timer *time.Timer
+}
+
+// awayChange is a user whose away state changed, to be broadcast once
+// the tracker is unlocked
+type awayChange struct {
+	user string
+	away bool
+}
+
+// trackActivity starts following a new connection, joining counts as
+// activity
+func (s *ChatServer) trackActivity(clientID, user string) {
+	t := &s.idle
+	t.mu.Lock()
+	if t.conns == nil {
+		t.conns = make(map[string]*idleConn)
+		t.away = make(map[string]bool)
+	}
+	c := &idleConn{user: user, last: time.Now()}
+	if t.timeout > 0 {
+		c.timer = time.AfterFunc(t.timeout, func() { s.idleTimeout(clientID) })
+	}
+	t.conns[clientID] = c
+	change := t.updateLocked(user)
+	t.mu.Unlock()
+	s.announceAway(change)
+}
+
+// markActive records activity on a connection, bringing its user back
+// if they were away
+func (s *ChatServer) markActive(clientID string) {
+	t := &s.idle
+	t.mu.Lock()
+	c := t.conns[clientID]
+	if c == nil {
+		t.mu.Unlock()
+		return
+	}
+	c.last, c.idle = time.Now(), false
+	if c.timer != nil {
+		c.timer.Reset(t.timeout)
+	}
+	change := t.updateLocked(c.user)
+	t.mu.Unlock()
+	s.announceAway(change)
+}
+
+// markIdle records that a client reported its user gone
+func (s *ChatServer) markIdle(clientID string) {
+	t := &s.idle
+	t.mu.Lock()
+	c := t.conns[clientID]
+	if c == nil {
+		t.mu.Unlock()
+		return
+	}
+	c.idle = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	change := t.updateLocked(c.user)
+	t.mu.Unlock()
+	s.announceAway(change)
+}
+
+// idleTimeout runs when a connection's timer fires
+func (s *ChatServer) idleTimeout(clientID string) {
+	t := &s.idle
+	t.mu.Lock()
+	c := t.conns[clientID]
+	// activity may have raced the timer
+	if c == nil || time.Since(c.last) < t.timeout {
+		t.mu.Unlock()
+		return
+	}
+	c.idle = true
+	change := t.updateLocked(c.user)
+	t.mu.Unlock()
+	s.announceAway(change)
+}
+
+// renameActivity moves a connection to its new name after the rename was
+// broadcast, renaming counts as activity. Clients carry an away status
+// over to the new name, so it is cleared explicitly, and the old name is
+// re-evaluated for the user's other tabs.
+func (s *ChatServer) renameActivity(clientID, oldName, newName string) {
+	t := &s.idle
+	t.mu.Lock()
+	c := t.conns[clientID]
+	if c == nil {
+		t.mu.Unlock()
+		return
+	}
+	wasAway := t.away[oldName]
+	c.user, c.last, c.idle = newName, time.Now(), false
+	if c.timer != nil {
+		c.timer.Reset(t.timeout)
+	}
+	delete(t.away, newName)
+	old := t.updateLocked(oldName)
+	t.mu.Unlock()
+	s.announceAway(old)
+	if wasAway {
+		s.announceAway(&awayChange{user: newName})
+	}
+}
+
+// untrackActivity forgets a closed connection
+func (s *ChatServer) untrackActivity(clientID string) {
+	t := &s.idle
+	t.mu.Lock()
+	c := t.conns[clientID]
+	if c == nil {
+		t.mu.Unlock()
+		return
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	delete(t.conns, clientID)
+	change := t.updateLocked(c.user)
+	t.mu.Unlock()
+	s.announceAway(change)
+}
+
+// updateLocked recomputes whether user is away. A user without
+// connections is forgotten, leaving is announced elsewhere.
+func (t *idleTracker) updateLocked(user string) *awayChange {
+	conns, idle := 0, 0
+	for _, c := range t.conns {
+		if c.user == user {
+			conns++
+			if c.idle {
+				idle++
+			}
+		}
+	}
+	if conns == 0 {
+		delete(t.away, user)
+		return nil
+	}
+	away := idle == conns
+	if away == t.away[user] {
+		return nil
+	}
+	if away {
+		t.away[user] = true
+	} else {
+		delete(t.away, user)
+	}
+	return &awayChange{user: user, away: away}
+}
+
+// isAway reports whether all of user's connections are idle
+func (s *ChatServer) isAway(user string) bool {
+	s.idle.mu.Lock()
+	defer s.idle.mu.Unlock()
+	return s.idle.away[user]
+}
+
+// announceAway broadcasts a change, a call's status takes precedence and
+// is left alone
+func (s *ChatServer) announceAway(change *awayChange) {
+	if change == nil {
+		return
+	}
+	if s.callStatus(change.user) != pb.PresenceStatus_PRESENCE_AVAILABLE {
+		return
+	}
+	if change.away {
+		log.Printf("User '%s' is away.", change.user)
+	} else {
+		log.Printf("User '%s' is back.", change.user)
+	}
+	s.setPresence(change.user, pb.PresenceStatus_PRESENCE_AVAILABLE)
+}
@@ -0,0 +1,59 @@
+package chatserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"realTimeChat/internal/attachmeta"
+	"realTimeChat/pkg/imaging"
+)
+
+// thumbKey is the object key of the thumbnail of attachment id at size
+func thumbKey(id string, size int) string {
+	return fmt.Sprintf("%s_%d", id, size)
+}
+
+// processImage strips the metadata of the image at path in place and
+// puts its thumbnails in the object store, pending until the file is
+// stored; m gets the new size, checksum and dimensions. Content that is
+// not a supported image is left alone.
+func (s *attachmentStore) processImage(ctx context.Context, path string, m *attachmeta.Meta, sizes []int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	res, err := imaging.Process(data, sizes)
+	if errors.Is(err, imaging.ErrNotImage) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, t := range res.Thumbnails {
+		if err := s.objects.Put(ctx, thumbKey(m.ID, t.Size), bytes.NewReader(t.Data), int64(len(t.Data)), t.MimeType); err != nil {
+			s.removeThumbnails(ctx, *m)
+			return err
+		}
+		m.Thumbnails = append(m.Thumbnails, attachmeta.Thumb{Size: t.Size, Width: int32(t.Width), Height: int32(t.Height), MimeType: t.MimeType})
+	}
+	if err := os.WriteFile(path, res.Data, 0o640); err != nil {
+		s.removeThumbnails(ctx, *m)
+		return err
+	}
+	sum := sha256.Sum256(res.Data)
+	m.Size, m.SHA256 = int64(len(res.Data)), hex.EncodeToString(sum[:])
+	m.MimeType, m.Width, m.Height = res.MimeType, int32(res.Width), int32(res.Height)
+	return nil
+}
+
+// removeThumbnails deletes the thumbnails of m
+func (s *attachmentStore) removeThumbnails(ctx context.Context, m attachmeta.Meta) {
+	for _, t := range m.Thumbnails {
+		s.objects.Delete(ctx, thumbKey(m.ID, t.Size))
+	}
+}
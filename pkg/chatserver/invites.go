@@ -0,0 +1,279 @@
+package chatserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultInviteTTL is how long invites created without a TTL last
+const DefaultInviteTTL = 7 * 24 * time.Hour
+
+// roomAccess holds the private rooms and the invites into them
+type roomAccess struct {
+	mu      sync.Mutex
+	private map[string]bool
+	invites map[string]*pb.Invite // by token
+}
+
+func (a *roomAccess) isPrivate(room string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.private[room]
+}
+
+func (a *roomAccess) setPrivate(room string, private bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !private {
+		delete(a.private, room)
+		return
+	}
+	if a.private == nil {
+		a.private = make(map[string]bool)
+	}
+	a.private[room] = true
+}
+
+// privateRooms returns the rooms made private
+func (a *roomAccess) privateRooms() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Collect(maps.Keys(a.private))
+}
+
+// roomInvites returns copies of the usable invites of room
+func (a *roomAccess) roomInvites(room string, now time.Time) []*pb.Invite {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var out []*pb.Invite
+	for _, inv := range a.invites {
+		if inv.Room == room && usable(inv, now) {
+			out = append(out, proto.Clone(inv).(*pb.Invite))
+		}
+	}
+	return out
+}
+
+// addInvites keeps invites handed over from another server
+func (a *roomAccess) addInvites(invites []*pb.Invite) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.invites == nil {
+		a.invites = make(map[string]*pb.Invite)
+	}
+	for _, inv := range invites {
+		if inv.Token != "" {
+			a.invites[inv.Token] = inv
+		}
+	}
+}
+
+// dropRoom forgets the privacy and the invites of room
+func (a *roomAccess) dropRoom(room string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.private, room)
+	for token, inv := range a.invites {
+		if inv.Room == room {
+			delete(a.invites, token)
+		}
+	}
+}
+
+// usable reports whether inv can still be redeemed at now
+func usable(inv *pb.Invite, now time.Time) bool {
+	return now.UnixMilli() < inv.ExpiresAt && (inv.MaxUses == 0 || inv.Uses < inv.MaxUses)
+}
+
+// lookup returns a copy of the invite behind token while it is usable,
+// expired and used up invites are forgotten
+func (a *roomAccess) lookup(token string, now time.Time) (*pb.Invite, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	inv, ok := a.invites[token]
+	if !ok {
+		return nil, false
+	}
+	if !usable(inv, now) {
+		delete(a.invites, token)
+		return nil, false
+	}
+	return proto.Clone(inv).(*pb.Invite), true
+}
+
+// redeem uses up one use of the invite behind token for room
+func (a *roomAccess) redeem(token, room string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	inv, ok := a.invites[token]
+	if !ok || inv.Room != room || !usable(inv, now) {
+		return false
+	}
+	inv.Uses++
+	return true
+}
+
+// newInviteToken returns 128 random bits, URL safe
+func newInviteToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("chatserver: reading random bits: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// mayEnter checks that user may enter room. A private room the user has
+// not been in needs a usable invite, redeem reports that one of its uses
+// must be taken with roomAccess.redeem once the join is accepted, so a
+// join refused later costs nothing.
+func (s *ChatServer) mayEnter(user, room, invite string) (ok, redeem bool) {
+	if !s.access.isPrivate(room) {
+		return true, false
+	}
+	if _, member := s.members.snapshot(room)[user]; member {
+		return true, false
+	}
+	if invite == "" {
+		return false, false
+	}
+	inv, usable := s.access.lookup(invite, time.Now())
+	if !usable || inv.Room != room {
+		return false, false
+	}
+	return true, true
+}
+
+// mayRead reports whether user may read the messages of room: any public
+// room, a private one they have been in, and every room with the admin
+// token
+func (s *ChatServer) mayRead(ctx context.Context, user, room string) bool {
+	if !s.access.isPrivate(room) {
+		return true
+	}
+	if _, member := s.members.snapshot(room)[user]; member {
+		return true
+	}
+	return (&adminServer{s: s}).authorize(ctx) == nil
+}
+
+// GetInvite returns the room and limits of an invite without its creator
+func (r *roomServer) GetInvite(_ context.Context, req *pb.InviteRequest) (*pb.Invite, error) {
+	inv, ok := r.s.access.lookup(req.Token, time.Now())
+	if !ok {
+		return nil, status.Error(codes.NotFound, "invite is invalid, expired or used up")
+	}
+	inv.CreatedBy = ""
+	return inv, nil
+}
+
+// SetRoomPrivate makes a room private or public again
+func (a *adminServer) SetRoomPrivate(ctx context.Context, req *pb.SetRoomPrivateRequest) (*pb.RoomInfo, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	room, ok := normalizeRoom(req.Room)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+	}
+	if room == DefaultRoom && req.Private {
+		return nil, status.Errorf(codes.InvalidArgument, "#%s cannot be private", DefaultRoom)
+	}
+	a.s.access.setPrivate(room, req.Private)
+	log.Printf("#%s is now private: %v", room, req.Private)
+	return &pb.RoomInfo{Name: room, Private: req.Private}, nil
+}
+
+// CreateInvite creates an invite into a room
+func (a *adminServer) CreateInvite(ctx context.Context, req *pb.CreateInviteRequest) (*pb.Invite, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	room, ok := normalizeRoom(req.Room)
+	switch {
+	case !ok:
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+	case req.MaxUses < 0 || req.TtlSeconds < 0:
+		return nil, status.Error(codes.InvalidArgument, "max_uses and ttl_seconds cannot be negative")
+	}
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	if ttl == 0 {
+		ttl = DefaultInviteTTL
+	}
+	now := time.Now()
+	inv := &pb.Invite{
+		Token:     newInviteToken(),
+		Room:      room,
+		MaxUses:   req.MaxUses,
+		CreatedAt: now.UnixMilli(),
+		ExpiresAt: now.Add(ttl).UnixMilli(),
+		CreatedBy: req.CreatedBy,
+	}
+	acc := &a.s.access
+	acc.mu.Lock()
+	if acc.invites == nil {
+		acc.invites = make(map[string]*pb.Invite)
+	}
+	acc.invites[inv.Token] = inv
+	acc.mu.Unlock()
+	log.Printf("Invite into #%s created, %d uses, expires %s", room, inv.MaxUses, now.Add(ttl).UTC().Format(time.RFC3339))
+	return proto.Clone(inv).(*pb.Invite), nil
+}
+
+// RevokeInvite deletes an invite
+func (a *adminServer) RevokeInvite(ctx context.Context, req *pb.InviteRequest) (*pb.Invite, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	acc := &a.s.access
+	acc.mu.Lock()
+	inv, ok := acc.invites[req.Token]
+	delete(acc.invites, req.Token)
+	acc.mu.Unlock()
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such invite")
+	}
+	log.Printf("Invite into #%s revoked", inv.Room)
+	return inv, nil
+}
+
+// ListInvites returns the usable invites, newest first
+func (a *adminServer) ListInvites(ctx context.Context, req *pb.ListInvitesRequest) (*pb.InviteList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	room := ""
+	if req.Room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(req.Room); !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+	}
+	now := time.Now()
+	out := &pb.InviteList{}
+	acc := &a.s.access
+	acc.mu.Lock()
+	for token, inv := range acc.invites {
+		switch {
+		case !usable(inv, now):
+			delete(acc.invites, token)
+		case room == "" || inv.Room == room:
+			out.Invites = append(out.Invites, proto.Clone(inv).(*pb.Invite))
+		}
+	}
+	acc.mu.Unlock()
+	sort.Slice(out.Invites, func(i, j int) bool { return out.Invites[i].CreatedAt > out.Invites[j].CreatedAt })
+	return out, nil
+}
@@ -0,0 +1,139 @@
+package chatserver
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// Bounds of the keywords one user watches in a room
+const (
+	maxRoomKeywords = 20
+	maxKeywordLen   = 64
+)
+
+// keywordIn returns the first of words found in text, "" for none. Case
+// is ignored, words made of letters, digits and underscores only match
+// whole words so "pager" does not fire on "pagers".
+func keywordIn(text string, words []string) string {
+	lower := strings.ToLower(text)
+	for _, w := range words {
+		needle := strings.ToLower(strings.TrimSpace(w))
+		if needle == "" {
+			continue
+		}
+		whole := asciiWord(needle)
+		for rest, at := lower, 0; ; {
+			i := strings.Index(rest, needle)
+			if i < 0 {
+				break
+			}
+			start, end := at+i, at+i+len(needle)
+			if !whole || ((start == 0 || !isNameByte(lower[start-1])) && (end == len(lower) || !isNameByte(lower[end]))) {
+				return w
+			}
+			rest, at = lower[end:], end
+		}
+	}
+	return ""
+}
+
+func asciiWord(w string) bool {
+	for i := 0; i < len(w); i++ {
+		if !isNameByte(w[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// notifyKeywords tells the online members of msg's room whose keywords
+// for it occur in msg, on all their connections whichever room those are
+// in. Keywords are asked for explicitly, so mutes and quiet hours do not
+// hold them back.
+func (s *ChatServer) notifyKeywords(ctx context.Context, msg *pb.ChatMessage) {
+	if msg.Text == "" {
+		return
+	}
+	members := s.members.snapshot(msg.Room)
+	online := make(map[string]bool)
+	s.mu.RLock()
+	for _, conn := range s.connections {
+		if _, ok := members[conn.user]; ok && conn.user != msg.User {
+			online[conn.user] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	for user := range online {
+		prefs, err := s.prefs.GetPreferences(ctx, user)
+		if err != nil {
+			continue
+		}
+		word := keywordIn(msg.Text, prefs.GetKeywords()[msg.Room].GetWords())
+		if word == "" {
+			continue
+		}
+		s.sendToUser(ctx, user, &pb.ChatMessage{
+			User: "System",
+			Type: pb.MessageType_TYPE_KEYWORD_HIT,
+			Payload: &pb.ChatMessage_KeywordHit{KeywordHit: &pb.KeywordHit{
+				Keyword:   word,
+				Room:      msg.Room,
+				MessageId: msg.Id,
+				Sender:    msg.User,
+				Text:      msg.Text,
+			}},
+		})
+	}
+}
+
+// AddKeyword registers a keyword for a user in a room
+func (p *preferencesServer) AddKeyword(ctx context.Context, req *pb.KeywordRequest) (*pb.Preferences, error) {
+	return p.editKeywords(ctx, req, func(words []string, word string) []string {
+		if slices.ContainsFunc(words, func(w string) bool { return strings.EqualFold(w, word) }) {
+			return words
+		}
+		return append(words, word)
+	})
+}
+
+// RemoveKeyword drops a keyword of a user in a room
+func (p *preferencesServer) RemoveKeyword(ctx context.Context, req *pb.KeywordRequest) (*pb.Preferences, error) {
+	return p.editKeywords(ctx, req, func(words []string, word string) []string {
+		return slices.DeleteFunc(words, func(w string) bool { return strings.EqualFold(w, word) })
+	})
+}
+
+// editKeywords applies edit to the keywords of the request's room and
+// stores the preferences. Rooms are normalized like /join, messages are
+// matched by the normalized name.
+func (p *preferencesServer) editKeywords(ctx context.Context, req *pb.KeywordRequest, edit func(words []string, word string) []string) (*pb.Preferences, error) {
+	word := strings.TrimSpace(req.Word)
+	if req.User == "" || strings.TrimSpace(req.Room) == "" || word == "" {
+		return nil, status.Error(codes.InvalidArgument, "user, room and word cannot be empty")
+	}
+	room, ok := normalizeRoom(req.Room)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+	}
+	prefs, err := p.GetPreferences(ctx, &pb.PreferencesRequest{User: req.User})
+	if err != nil {
+		return nil, err
+	}
+	if prefs.Keywords == nil {
+		prefs.Keywords = make(map[string]*pb.Keywords)
+	}
+	words := edit(prefs.Keywords[room].GetWords(), word)
+	if len(words) == 0 {
+		delete(prefs.Keywords, room)
+	} else {
+		prefs.Keywords[room] = &pb.Keywords{Words: words}
+	}
+	return p.SetPreferences(ctx, prefs)
+}
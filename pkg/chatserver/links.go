@@ -0,0 +1,98 @@
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"realTimeChat/pkg/unfurl"
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	maxPreviewsPerMessage = 3
+	previewTimeout        = 10 * time.Second
+)
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// extractLinks returns the distinct http(s) links in text, in order
+func extractLinks(text string) []string {
+	var links []string
+	for _, l := range linkPattern.FindAllString(text, -1) {
+		l = strings.TrimRight(l, ".,;:!?)]}'")
+		if !contains(links, l) {
+			links = append(links, l)
+		}
+		if len(links) == maxPreviewsPerMessage {
+			break
+		}
+	}
+	return links
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// unfurlLinks fetches previews for the links in msg in the background
+// and sends each as a link_preview event to the message's audience
+func (s *ChatServer) unfurlLinks(msg *pb.ChatMessage) {
+	if s.unfurler == nil || !s.feature(FeatureLinkPreviews, msg.User, msg.Room) || s.settings.get(msg.Room).Nsfw {
+		return
+	}
+	links := extractLinks(msg.Text)
+	if len(links) == 0 {
+		return
+	}
+
+	go func() {
+		for _, link := range links {
+			ctx, cancel := context.WithTimeout(s.ctx, previewTimeout)
+			p, err := s.unfurler.Unfurl(ctx, link)
+			cancel()
+			if s.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if !errors.Is(err, unfurl.ErrNoPreview) {
+					log.Printf("Link preview for %s failed: %v", link, err)
+				}
+				continue
+			}
+			s.sendPreview(msg, p)
+		}
+	}()
+}
+
+// sendPreview delivers a preview to everyone who received msg
+func (s *ChatServer) sendPreview(msg *pb.ChatMessage, p *unfurl.Preview) {
+	event := &pb.ChatMessage{
+		User:          "System",
+		RecipientUser: msg.RecipientUser,
+		Type:          pb.MessageType_TYPE_LINK_PREVIEW,
+		Payload: &pb.ChatMessage_LinkPreview{LinkPreview: &pb.LinkPreview{
+			MessageId:   msg.Id,
+			Url:         p.URL,
+			Title:       p.Title,
+			Description: p.Description,
+			ImageUrl:    p.ImageURL,
+			SiteName:    p.SiteName,
+		}},
+	}
+	if msg.RecipientUser == "" {
+		event.Room = msg.Room
+		s.broadcastRoom(msg.Room, event, "")
+		return
+	}
+	s.sendToUser(s.ctx, msg.RecipientUser, event)
+	s.sendToUser(s.ctx, msg.User, event)
+}
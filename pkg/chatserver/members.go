@@ -0,0 +1,349 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// roomMembers remembers who has been in each room, their role and when
+// their last connection left it
+type roomMembers struct {
+	mu    sync.Mutex
+	rooms map[string]map[string]*memberState // room -> user
+}
+
+type memberState struct {
+	role     pb.RoomRole
+	lastSeen time.Time // zero until the user first leaves the room
+	entered  time.Time // when the user's first connection last entered the room
+}
+
+// enter records user in room. The first user of a room other than
+// DefaultRoom becomes its owner.
+func (m *roomMembers) enter(user, room string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rooms == nil {
+		m.rooms = make(map[string]map[string]*memberState)
+	}
+	members := m.rooms[room]
+	if members == nil {
+		members = make(map[string]*memberState)
+		m.rooms[room] = members
+	}
+	if _, ok := members[user]; ok {
+		return
+	}
+	st := &memberState{}
+	if len(members) == 0 && room != DefaultRoom {
+		st.role = pb.RoomRole_ROLE_OWNER
+	}
+	members[user] = st
+}
+
+// arrive records that user's first connection entered room
+func (m *roomMembers) arrive(user, room string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st, ok := m.rooms[room][user]; ok {
+		st.entered = at
+	}
+}
+
+// leave records that user's last connection left room
+func (m *roomMembers) leave(user, room string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st, ok := m.rooms[room][user]; ok {
+		st.lastSeen = at
+	}
+}
+
+// roomsOf returns the rooms user is a member of
+func (m *roomMembers) roomsOf(user string) map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]bool)
+	for room, members := range m.rooms {
+		if _, ok := members[user]; ok {
+			out[room] = true
+		}
+	}
+	return out
+}
+
+// setRole changes user's role in room, it reports false when the user
+// was never there
+func (m *roomMembers) setRole(user, room string, role pb.RoomRole) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.rooms[room][user]
+	if ok {
+		st.role = role
+	}
+	return ok
+}
+
+// rename moves the memberships to the new name. In rooms the new name
+// was already in the two are merged, keeping the higher role and the
+// later times.
+func (m *roomMembers) rename(oldName, newName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, members := range m.rooms {
+		st, ok := members[oldName]
+		if !ok {
+			continue
+		}
+		delete(members, oldName)
+		if prev, taken := members[newName]; taken {
+			prev.role = max(prev.role, st.role)
+			if st.lastSeen.After(prev.lastSeen) {
+				prev.lastSeen = st.lastSeen
+			}
+			if st.entered.After(prev.entered) {
+				prev.entered = st.entered
+			}
+			continue
+		}
+		members[newName] = st
+	}
+}
+
+// snapshot copies the members of room, nil for rooms nobody entered
+func (m *roomMembers) snapshot(room string) map[string]memberState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	members, ok := m.rooms[room]
+	if !ok {
+		return nil
+	}
+	out := make(map[string]memberState, len(members))
+	for user, st := range members {
+		out[user] = *st
+	}
+	return out
+}
+
+// restore takes over the members of room handed over from another
+// server, their roles win over the ones given here in the meantime
+func (m *roomMembers) restore(room string, members []*pb.RoomMember) {
+	if len(members) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rooms == nil {
+		m.rooms = make(map[string]map[string]*memberState)
+	}
+	kept := m.rooms[room]
+	if kept == nil {
+		kept = make(map[string]*memberState)
+		m.rooms[room] = kept
+	}
+	handed := make(map[string]bool, len(members))
+	for _, mem := range members {
+		handed[mem.User] = true
+		st, ok := kept[mem.User]
+		if !ok {
+			st = &memberState{}
+			kept[mem.User] = st
+			if mem.LastSeen > 0 {
+				st.lastSeen = time.UnixMilli(mem.LastSeen)
+			}
+		}
+		st.role = mem.Role
+	}
+	for user, st := range kept {
+		if !handed[user] && st.role == pb.RoomRole_ROLE_OWNER {
+			st.role = pb.RoomRole_ROLE_MEMBER // made owner by entering first here
+		}
+	}
+}
+
+// drop forgets the members of room
+func (m *roomMembers) drop(room string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rooms, room)
+}
+
+// roomNames returns the rooms anybody entered
+func (m *roomMembers) roomNames() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Collect(maps.Keys(m.rooms))
+}
+
+// moderators returns the moderators and owners of room, of every room
+// when room is ""
+func (m *roomMembers) moderators(room string) map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]bool)
+	for r, members := range m.rooms {
+		if room != "" && r != room {
+			continue
+		}
+		for user, st := range members {
+			if st.role >= pb.RoomRole_ROLE_MODERATOR {
+				out[user] = true
+			}
+		}
+	}
+	return out
+}
+
+// roomStreams counts the connections of user in room
+func (s *ChatServer) roomStreams(user, room string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, conn := range s.connections {
+		if conn.user == user && conn.in(room) {
+			n++
+		}
+	}
+	return n
+}
+
+// memberEntered records a connection of user entering room and tells the
+// room when it is the user's first one there
+func (s *ChatServer) memberEntered(user, room string) {
+	s.members.enter(user, room)
+	if s.roomStreams(user, room) == 1 {
+		s.members.arrive(user, room, time.Now())
+		s.emitMember(user, room)
+	}
+}
+
+// memberLeft records a connection of user leaving room and tells the room
+// when it was the user's last one there
+func (s *ChatServer) memberLeft(user, room string) {
+	if s.roomStreams(user, room) == 0 {
+		s.members.leave(user, room, time.Now())
+		s.emitMember(user, room)
+	}
+}
+
+// emitMember sends the current state of user's membership to room
+func (s *ChatServer) emitMember(user, room string) {
+	member, ok := s.roomMember(user, room)
+	if !ok {
+		return
+	}
+	s.broadcastRoom(room, &pb.ChatMessage{
+		User:      "System",
+		Room:      room,
+		Type:      pb.MessageType_TYPE_MEMBER,
+		Timestamp: time.Now().UnixMilli(),
+		Payload:   &pb.ChatMessage_Member{Member: member},
+	}, "")
+}
+
+// roomMember describes user's membership of room
+func (s *ChatServer) roomMember(user, room string) (*pb.RoomMember, bool) {
+	st, ok := s.members.snapshot(room)[user]
+	if !ok {
+		return nil, false
+	}
+	return s.describeMembers(room, map[string]memberState{user: st}, s.Presence())[0], true
+}
+
+// list returns the members of room without their presence, for handing
+// the room to another server or saving it
+func (m *roomMembers) list(room string) []*pb.RoomMember {
+	var out []*pb.RoomMember
+	for user, st := range m.snapshot(room) {
+		mem := &pb.RoomMember{User: user, Role: st.role}
+		if !st.lastSeen.IsZero() {
+			mem.LastSeen = st.lastSeen.UnixMilli()
+		}
+		out = append(out, mem)
+	}
+	return out
+}
+
+// describeMembers builds the RoomMember of each user in members
+func (s *ChatServer) describeMembers(room string, members map[string]memberState, presence map[string]pb.PresenceStatus) []*pb.RoomMember {
+	online := make(map[string]bool)
+	s.mu.RLock()
+	for _, conn := range s.connections {
+		if conn.in(room) {
+			online[conn.user] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	out := make([]*pb.RoomMember, 0, len(members))
+	for user, st := range members {
+		m := &pb.RoomMember{User: user, Role: st.role, Online: online[user]}
+		if m.Online {
+			m.Status = presence[user]
+		} else if !st.lastSeen.IsZero() {
+			m.LastSeen = st.lastSeen.UnixMilli()
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// GetRoomMembers lists everyone who has been in a room, online members
+// first and then by name
+func (r *roomServer) GetRoomMembers(ctx context.Context, req *pb.RoomMembersRequest) (*pb.RoomMembers, error) {
+	room := DefaultRoom
+	if req.Room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(req.Room); !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+	}
+	// the admin token sees private rooms, e.g. for the gateway's dashboard
+	if r.s.access.isPrivate(room) && (&adminServer{s: r.s}).authorize(ctx) != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "#%s is private", room)
+	}
+	members := r.s.members.snapshot(room)
+	if members == nil && room != DefaultRoom {
+		return nil, status.Errorf(codes.NotFound, "#%s has no members", room)
+	}
+	out := &pb.RoomMembers{Room: room, Members: r.s.describeMembers(room, members, r.s.Presence())}
+	sort.Slice(out.Members, func(i, j int) bool {
+		a, b := out.Members[i], out.Members[j]
+		if a.Online != b.Online {
+			return a.Online
+		}
+		return a.User < b.User
+	})
+	return out, nil
+}
+
+// SetRoomRole changes the role of a member of a room
+func (a *adminServer) SetRoomRole(ctx context.Context, req *pb.SetRoomRoleRequest) (*pb.RoomMember, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	room, ok := normalizeRoom(req.Room)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+	}
+	if _, known := pb.RoomRole_name[int32(req.Role)]; !known {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role %d", req.Role)
+	}
+	if !a.s.members.setRole(req.User, room, req.Role) {
+		return nil, status.Errorf(codes.NotFound, "%s has not been in #%s", req.User, room)
+	}
+	log.Printf("Role of '%s' in #%s set to %s", req.User, room, req.Role)
+	a.s.emitMember(req.User, room)
+	member, _ := a.s.roomMember(req.User, room)
+	return member, nil
+}
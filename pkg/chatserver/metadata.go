@@ -0,0 +1,36 @@
+package chatserver
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+
+	"realTimeChat/pkg/i18n"
+)
+
+var metadataKey = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_./-]{0,63}$`)
+
+// checkMetadata validates message metadata like checkCode, the size
+// counts keys and values
+func (s *ChatServer) checkMetadata(md map[string]string) (string, []string) {
+	max := s.limits.MaxMetadataSize
+	if max <= 0 {
+		max = DefaultMaxMetadataSize
+	}
+	keys := make([]string, 0, len(md))
+	size := 0
+	for k, v := range md {
+		keys = append(keys, k)
+		size += len(k) + len(v)
+	}
+	slices.Sort(keys) // report the same key every time
+	for _, k := range keys {
+		if !metadataKey.MatchString(k) {
+			return i18n.MetadataBadKey, []string{"key", k}
+		}
+	}
+	if size > max {
+		return i18n.MetadataTooLarge, []string{"max", strconv.Itoa(max)}
+	}
+	return "", nil
+}
@@ -0,0 +1,80 @@
+package chatserver
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// moderationSize caps the moderation queue, the oldest items are dropped
+// once it is full
+const moderationSize = 500
+
+// moderationQueue holds the flagged messages and quarantined attachments
+// until a moderator resolves them
+type moderationQueue struct {
+	mu    sync.Mutex
+	items []*pb.ModerationItem // oldest first
+}
+
+func (q *moderationQueue) add(item *pb.ModerationItem) {
+	item.CreatedAt = time.Now().UnixMilli()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == moderationSize {
+		q.items = append(q.items[:0], q.items[1:]...)
+	}
+	q.items = append(q.items, item)
+}
+
+// list returns the items of room, every item when room is ""
+func (q *moderationQueue) list(room string) []*pb.ModerationItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []*pb.ModerationItem
+	for _, item := range q.items {
+		if room == "" || item.Room == room {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// resolve removes the item with id, nil when there is none
+func (q *moderationQueue) resolve(id string) *pb.ModerationItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.IndexFunc(q.items, func(item *pb.ModerationItem) bool { return item.Id == id })
+	if i < 0 {
+		return nil
+	}
+	item := q.items[i]
+	q.items = slices.Delete(q.items, i, i+1)
+	return item
+}
+
+// ListModerationQueue lists the items awaiting a moderator, oldest first
+func (a *adminServer) ListModerationQueue(ctx context.Context, req *pb.ModerationQueueRequest) (*pb.ModerationQueue, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.ModerationQueue{Items: a.s.moderation.list(req.Room)}, nil
+}
+
+// ResolveModeration takes an item off the moderation queue
+func (a *adminServer) ResolveModeration(ctx context.Context, req *pb.ResolveModerationRequest) (*pb.ModerationItem, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	item := a.s.moderation.resolve(req.Id)
+	if item == nil {
+		return nil, status.Error(codes.NotFound, "no such moderation item")
+	}
+	return item, nil
+}
@@ -0,0 +1,157 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultRenameGrace is how long an old name keeps routing PMs after /nick
+const DefaultRenameGrace = 5 * time.Minute
+
+// alias points a previous username at the name it was changed to
+type alias struct {
+	user    string
+	expires time.Time
+}
+
+// parseNick reports whether msg is a public "/nick <newname>" command
+func parseNick(msg *pb.ChatMessage) (string, bool) {
+	if msg.RecipientUser != "" || msg.GetCode() != nil {
+		return "", false
+	}
+	if msg.Text == "/nick" {
+		return "", true
+	}
+	name, ok := strings.CutPrefix(msg.Text, "/nick ")
+	return strings.TrimSpace(name), ok
+}
+
+// rename moves clientID from oldName to newName and announces it. The
+// caller is told of failures with a System message and false is returned.
+func (s *ChatServer) rename(stream pb.ChatService_RealtimeChatServer, clientID, oldName, newName string) bool {
+	// 1. validate the new name
+	switch {
+	case newName == "":
+		s.sendSystem(stream, clientID, i18n.NickUsage)
+		return false
+	case newName == oldName:
+		s.sendSystem(stream, clientID, i18n.NickSame, "name", newName)
+		return false
+	case strings.ContainsAny(newName, " \t\r\n") || s.reservedName(newName):
+		s.sendSystem(stream, clientID, i18n.NickInvalid, "name", newName)
+		return false
+	}
+	if max := s.limits.MaxUsernameLength; max > 0 && len(newName) > max {
+		s.sendSystem(stream, clientID, i18n.NickTooLong, "max", strconv.Itoa(max))
+		return false
+	}
+	if s.auth != nil {
+		if err := s.auth.Authenticate(stream.Context(), newName); err != nil {
+			log.Printf("Rename of '%s' to '%s' denied: %v", oldName, newName, err)
+			s.sendSystem(stream, clientID, i18n.NickDenied, "name", newName)
+			return false
+		}
+	}
+	if err := s.checkBans(stream.Context(), newName, sessionInfo{}); err != nil {
+		log.Printf("Rename of '%s' to '%s' denied: %v", oldName, newName, err)
+		s.sendSystem(stream, clientID, i18n.NickDenied, "name", newName)
+		return false
+	}
+	if s.knownUser(newName) {
+		s.sendSystem(stream, clientID, i18n.NickTaken, "name", newName)
+		return false
+	}
+
+	// 2. update the registry, names must also be unique among online users.
+	// All the state of oldName moves, so its other streams would be left
+	// with a name that owns nothing.
+	s.mu.Lock()
+	for id, conn := range s.connections {
+		if id != clientID && conn.user == newName {
+			s.mu.Unlock()
+			s.sendSystem(stream, clientID, i18n.NickTaken, "name", newName)
+			return false
+		}
+	}
+	if s.userStreamsLocked(oldName) > 1 {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.NickOtherStreams)
+		return false
+	}
+	conn := s.connections[clientID]
+	conn.user = newName
+	s.connections[clientID] = conn
+	s.abuse.rename(oldName, newName)
+
+	now := time.Now()
+	delete(s.aliases, newName)
+	for old, a := range s.aliases {
+		switch {
+		case now.After(a.expires):
+			delete(s.aliases, old)
+		case a.user == oldName:
+			a.user = newName // keep chains of renames routing to the latest name
+			s.aliases[old] = a
+		}
+	}
+	if s.renameGrace > 0 {
+		s.aliases[oldName] = alias{user: newName, expires: now.Add(s.renameGrace)}
+	}
+	s.mu.Unlock()
+	s.clusterJoined(newName)
+	s.clusterLeft(oldName)
+	s.calls.rename(clientID, oldName, newName)
+	s.voice.rename(clientID, newName)
+	s.reads.rename(oldName, newName)
+	s.members.rename(oldName, newName)
+	s.settings.rename(oldName, newName)
+	s.threads.rename(oldName, newName)
+	s.requests.rename(oldName, newName)
+	ctx := stream.Context()
+	for what, move := range map[string]func(context.Context, string, string) error{
+		"preferences": s.renamePreferences,
+		"contacts":    s.renameContacts,
+		"drafts":      s.renameDrafts,
+		"profile":     s.renameProfile,
+	} {
+		if err := move(ctx, oldName, newName); err != nil {
+			log.Printf("Failed to move the %s of '%s' to '%s': %v", what, oldName, newName, err)
+		}
+	}
+	for _, room := range conn.rooms() {
+		s.memberLeft(oldName, room)
+		s.memberEntered(newName, room)
+	}
+
+	log.Printf("User '%s' (ID: %s) is now '%s'.", oldName, clientID, newName)
+	if s.hooks.OnRename != nil {
+		s.hooks.OnRename(oldName, newName)
+	}
+
+	// 3. broadcast the rename, the renamer's copy is addressed to its new name
+	event := systemText(i18n.UserRenamed, "old", oldName, "new", newName)
+	event.Type = pb.MessageType_TYPE_RENAME
+	event.Payload = &pb.ChatMessage_Rename{Rename: &pb.Rename{OldUser: oldName, NewUser: newName}}
+	s.broadcast(event, clientID)
+	own := proto.Clone(event).(*pb.ChatMessage)
+	own.RecipientUser = newName
+	if err := stream.Send(own); err != nil {
+		log.Printf("Failed to send rename to %s: %v", clientID, err)
+	}
+	s.renameActivity(clientID, oldName, newName)
+	return true
+}
+
+// knownUser reports whether name belongs to a user the server remembers
+// even while they are offline: a room member, a publisher or a reader
+func (s *ChatServer) knownUser(name string) bool {
+	return len(s.members.roomsOf(name)) > 0 || s.settings.publishes(name) || s.reads.known(name)
+}
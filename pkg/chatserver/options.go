@@ -0,0 +1,405 @@
+package chatserver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	"realTimeChat/pkg/assistant"
+	"realTimeChat/pkg/avscan"
+	"realTimeChat/pkg/ids"
+	"realTimeChat/pkg/leader"
+	"realTimeChat/pkg/objstore"
+	"realTimeChat/pkg/translate"
+	"realTimeChat/pkg/unfurl"
+	pb "realTimeChat/proto/chat"
+)
+
+// Option configures a ChatServer
+type Option func(*ChatServer)
+
+// Authenticator decides whether a stream may join as username.
+// Returning a gRPC status error passes its code through to the client,
+// any other error is reported as PermissionDenied.
+type Authenticator interface {
+	Authenticate(ctx context.Context, username string) error
+}
+
+// AuthFunc adapts a plain function to Authenticator
+type AuthFunc func(ctx context.Context, username string) error
+
+// Authenticate calls f
+func (f AuthFunc) Authenticate(ctx context.Context, username string) error {
+	return f(ctx, username)
+}
+
+// Limits bounds what clients may send and how many streams they may
+// open, zero values mean unlimited. Streams over a limit are rejected
+// with ResourceExhausted, see ChatServer.StreamStats.
+type Limits struct {
+	MaxUsernameLength int // bytes
+	MaxMessageLength  int // bytes of message text
+	MaxCodeLength     int // bytes of code block content, 0 means DefaultMaxCodeLength
+	MaxMetadataSize   int // bytes of metadata keys and values, 0 means DefaultMaxMetadataSize
+
+	MaxStreams        int // open streams in total
+	MaxStreamsPerUser int // joined streams sharing one username
+	MaxStreamsPerIP   int // open streams from one source address, a gateway counts as one
+}
+
+// DefaultMaxCodeLength caps code blocks when Limits.MaxCodeLength is unset
+const DefaultMaxCodeLength = 16 << 10
+
+// DefaultMaxMetadataSize caps message metadata when
+// Limits.MaxMetadataSize is unset
+const DefaultMaxMetadataSize = 4 << 10
+
+// Keepalive tunes how connections are kept alive and checked for
+// health, zero durations keep gRPC's defaults. Load balancers often drop
+// idle connections without telling either side, pinging detects that.
+type Keepalive struct {
+	Time    time.Duration // ping a client after this long without activity
+	Timeout time.Duration // close the connection when a ping is not answered in time
+
+	MinClientInterval   time.Duration // clients pinging more often are disconnected
+	PermitWithoutStream bool          // allow client pings while no stream is open
+
+	MaxConnectionIdle     time.Duration // close connections without streams for this long
+	MaxConnectionAge      time.Duration // ask clients to reconnect after this long
+	MaxConnectionAgeGrace time.Duration // then give open streams this long to finish
+}
+
+// DefaultKeepalive pings quiet clients every minute and lets clients
+// ping every 10s, enough for the gateway's default
+var DefaultKeepalive = Keepalive{
+	Time:                time.Minute,
+	Timeout:             20 * time.Second,
+	MinClientInterval:   10 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// serverOptions converts k to gRPC server options
+func (k Keepalive) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  k.Time,
+			Timeout:               k.Timeout,
+			MaxConnectionIdle:     k.MaxConnectionIdle,
+			MaxConnectionAge:      k.MaxConnectionAge,
+			MaxConnectionAgeGrace: k.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             k.MinClientInterval,
+			PermitWithoutStream: k.PermitWithoutStream,
+		}),
+	}
+}
+
+// Hooks are callbacks fired on session and message events.
+// They run on the stream goroutine and should return quickly.
+type Hooks struct {
+	OnJoin    func(username string)
+	OnLeave   func(username string)
+	OnMessage func(msg *pb.ChatMessage) // accepted message, before fan-out
+	OnRename  func(oldName, newName string)
+}
+
+// WithPlugins installs plugins, their filters run in the given order.
+// The caller closes them after the server stopped.
+func WithPlugins(plugins ...*Plugin) Option {
+	return func(s *ChatServer) {
+		s.plugins = append(s.plugins, plugins...)
+	}
+}
+
+// WithScripts runs the Lua scripts in dir on every message before it is
+// broadcast and reloads them when the directory changes
+func WithScripts(dir string) Option {
+	return func(s *ChatServer) {
+		s.scriptDir = dir
+	}
+}
+
+// WithStore persists accepted messages to st
+func WithStore(st Store) Option {
+	return func(s *ChatServer) {
+		s.store = st
+	}
+}
+
+// WithPreferenceStore keeps notification preferences in st instead of memory
+func WithPreferenceStore(st PreferenceStore) Option {
+	return func(s *ChatServer) {
+		s.prefs = st
+	}
+}
+
+// WithProfileStore keeps the messages users pin to their profiles in st
+// instead of memory
+func WithProfileStore(st ProfileStore) Option {
+	return func(s *ChatServer) {
+		s.profiles = st
+	}
+}
+
+// WithContactStore keeps the contacts users add in st instead of memory
+func WithContactStore(st ContactStore) Option {
+	return func(s *ChatServer) {
+		s.contacts = st
+	}
+}
+
+// WithDraftStore keeps the drafts users save in st instead of memory
+func WithDraftStore(st DraftStore) Option {
+	return func(s *ChatServer) {
+		s.drafts = st
+	}
+}
+
+// WithQuotaStore keeps quota counters and the quotas set through
+// AdminService in st instead of memory
+func WithQuotaStore(st QuotaStore) Option {
+	return func(s *ChatServer) {
+		s.quotaStore = st
+	}
+}
+
+// WithBanStore keeps the bans created through AdminService in st instead
+// of memory
+func WithBanStore(st BanStore) Option {
+	return func(s *ChatServer) {
+		s.bans = st
+	}
+}
+
+// WithBlockStore keeps the blocklist rules created through AdminService
+// in st instead of memory
+func WithBlockStore(st BlockStore) Option {
+	return func(s *ChatServer) {
+		s.blockStore = st
+	}
+}
+
+// WithAbuse scores messages with cfg, putting senders in slow mode or
+// shadow banning them over its thresholds
+func WithAbuse(cfg AbuseConfig) Option {
+	return func(s *ChatServer) {
+		s.abuse.cfg = cfg
+	}
+}
+
+// WithQuotas sets the default quotas of tenants and rooms
+func WithQuotas(q Quotas) Option {
+	return func(s *ChatServer) {
+		s.quotas = q
+	}
+}
+
+// WithTenants assigns users to tenants, fn returning "" means
+// DefaultTenant. It is called often and should be cheap.
+func WithTenants(fn func(user string) string) Option {
+	return func(s *ChatServer) {
+		s.tenantOf = fn
+	}
+}
+
+// WithAuthenticator checks every joining user, /nick target and RPC acting
+// for a user with a. With nil, the default, anybody may act as anybody.
+func WithAuthenticator(a Authenticator) Option {
+	return func(s *ChatServer) {
+		s.auth = a
+	}
+}
+
+// WithLimits sets message and username limits
+func WithLimits(l Limits) Option {
+	return func(s *ChatServer) {
+		s.limits = l
+	}
+}
+
+// WithHooks installs event callbacks
+func WithHooks(h Hooks) Option {
+	return func(s *ChatServer) {
+		s.hooks = h
+	}
+}
+
+// WithRenameGrace sets how long PMs to a user's old name are still
+// delivered after /nick, 0 disables the alias
+func WithRenameGrace(d time.Duration) Option {
+	return func(s *ChatServer) {
+		s.renameGrace = d
+	}
+}
+
+// WithLeaveGrace sets how long a leave announcement waits for the user to
+// come back, a rejoin within it is not announced either. 0 announces at once.
+func WithLeaveGrace(d time.Duration) Option {
+	return func(s *ChatServer) {
+		s.announce.grace = d
+	}
+}
+
+// WithAnnounceBurst lets n join/leave announcements through per window,
+// later ones are summarised once the window ends. n 0 disables batching.
+func WithAnnounceBurst(n int, window time.Duration) Option {
+	return func(s *ChatServer) {
+		s.announce.burst = n
+		s.announce.window = window
+	}
+}
+
+// WithHistorySize sets how many recent messages each room keeps for
+// HistoryService, 0 disables it
+func WithHistorySize(n int) Option {
+	return func(s *ChatServer) {
+		s.history = newRoomHistory(n)
+	}
+}
+
+// WithDedupWindow sets how long a client_msg_id is remembered, messages
+// repeating one within the window are acknowledged but not delivered again
+func WithDedupWindow(d time.Duration) Option {
+	return func(s *ChatServer) {
+		s.dedupWindow = d
+	}
+}
+
+// WithIdleTimeout sets how long a connection may be inactive before its
+// user shows as away, 0 only goes by the clients' activity hints
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *ChatServer) {
+		s.idle.timeout = d
+	}
+}
+
+// WithRingTimeout sets how long a call rings before it ends unanswered
+func WithRingTimeout(d time.Duration) Option {
+	return func(s *ChatServer) {
+		s.ringTimeout = d
+	}
+}
+
+// WithLinkPreviews unfurls links in messages with u and sends the
+// previews as follow-up link_preview events
+func WithLinkPreviews(u *unfurl.Unfurler) Option {
+	return func(s *ChatServer) {
+		s.unfurler = u
+	}
+}
+
+// WithKeepalive replaces DefaultKeepalive
+func WithKeepalive(k Keepalive) Option {
+	return func(s *ChatServer) {
+		s.keepalive = k
+	}
+}
+
+// WithIDGenerator sets how message and session IDs are made, the default
+// is ids.NewULID. Servers sharing a store should use ULIDs or Snowflakes
+// with a node ID each.
+func WithIDGenerator(g ids.Generator) Option {
+	return func(s *ChatServer) {
+		s.ids = g
+	}
+}
+
+// WithTranslator enables /translate and the auto_translate preference,
+// translations are sent as translation events to the reader only
+func WithTranslator(t translate.Translator) Option {
+	return func(s *ChatServer) {
+		s.translator = t
+	}
+}
+
+// WithAssistant answers public "@assistant <question>" messages with a,
+// streaming the answer into the room as edits of one message. The name
+// assistant is then reserved.
+func WithAssistant(a assistant.Assistant) Option {
+	return func(s *ChatServer) {
+		s.assistant = a
+	}
+}
+
+// WithAttachmentDir stores files uploaded through AttachmentService in
+// dir, the default is a directory below os.TempDir
+func WithAttachmentDir(dir string) Option {
+	return func(s *ChatServer) {
+		s.attachmentDir = dir
+	}
+}
+
+// WithVirusScanner scans files uploaded through AttachmentService before
+// they can be downloaded, infected files are quarantined and the
+// moderators told
+func WithVirusScanner(sc avscan.Scanner) Option {
+	return func(s *ChatServer) {
+		s.scanner = sc
+	}
+}
+
+// WithObjectStore keeps finished attachments in store, such as an S3
+// bucket shared by several servers, instead of the attachment dir, which
+// still holds unfinished uploads
+func WithObjectStore(store objstore.ObjectStore) Option {
+	return func(s *ChatServer) {
+		s.objects = store
+	}
+}
+
+// WithThumbnailSizes renders thumbnails of image attachments fitting
+// each size, imaging.DefaultThumbnailSizes by default. Without sizes
+// images are still stripped of their metadata.
+func WithThumbnailSizes(sizes ...int) Option {
+	return func(s *ChatServer) {
+		s.thumbnailSizes = sizes
+	}
+}
+
+// WithCapabilities limits the capabilities enabled for clients that
+// negotiate, the default is pb.Capabilities. Clients without a Hello
+// still receive every event.
+func WithCapabilities(caps ...string) Option {
+	return func(s *ChatServer) {
+		s.capabilities = caps
+	}
+}
+
+// WithAdminToken enables AdminService, callers must send
+// "authorization: Bearer <token>" in the call metadata
+func WithAdminToken(token string) Option {
+	return func(s *ChatServer) {
+		s.adminToken = token
+	}
+}
+
+// WithLeaderElection runs the jobs that must run once across the servers
+// sharing the ban store, removing expired bans, only while e elects this
+// server. GetStats reports e's leadership.
+func WithLeaderElection(e *leader.Elector) Option {
+	return func(s *ChatServer) {
+		s.elector = e
+	}
+}
+
+// WithWelcome sets the messages sent to connections entering room, or the
+// message of the day sent when they join the chat when room is empty. It
+// can be changed at runtime through AdminService.SetWelcome.
+func WithWelcome(room string, messages ...string) Option {
+	return func(s *ChatServer) {
+		if name, ok := normalizeRoom(room); ok {
+			room = name
+		}
+		s.welcomes.set(room, messages)
+	}
+}
+
+// WithGRPCServerOptions passes extra options to the grpc.Server created by Serve
+func WithGRPCServerOptions(opts ...grpc.ServerOption) Option {
+	return func(s *ChatServer) {
+		s.grpcOpts = append(s.grpcOpts, opts...)
+	}
+}
@@ -0,0 +1,261 @@
+package chatserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/chatplugin"
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// pluginTimeout bounds every call to a plugin, one that does not answer
+// in time is skipped
+const pluginTimeout = 2 * time.Second
+
+// builtinCommands cannot be taken over by plugins
+var builtinCommands = []string{"nick", "join", "leave", "translate", "summarize", "sessions", "logout-others"}
+
+// Plugin is a connected plugin, see the Plugin service in proto/chat
+type Plugin struct {
+	info   *pb.PluginInfo
+	client pb.PluginClient
+	conn   *grpc.ClientConn
+	cmd    *exec.Cmd      // nil when connected to an address
+	stdin  io.WriteCloser // closing it tells a child plugin to exit
+}
+
+// ConnectPlugin connects to a plugin listening on addr and asks what it
+// handles, the default is an insecure connection
+func ConnectPlugin(ctx context.Context, addr string, opts ...grpc.DialOption) (*Plugin, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p := &Plugin{client: pb.NewPluginClient(conn), conn: conn}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	p.info, err = p.client.Describe(ctx, &pb.PluginInfoRequest{ProtocolVersion: pb.ProtocolVersion}, grpc.WaitForReady(true))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("plugin at %s: %w", addr, err)
+	}
+	if p.info.Name == "" {
+		p.info.Name = addr
+	}
+	return p, nil
+}
+
+// StartPlugin runs path as a child process and connects to it once it
+// printed its address, see chatplugin.Serve. The plugin's stderr goes to
+// the server's.
+func StartPlugin(ctx context.Context, path string, args ...string) (*Plugin, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	stop := func() {
+		stdin.Close()
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+
+	addr := make(chan string, 1)
+	go func() {
+		sc := bufio.NewScanner(stdout)
+		if sc.Scan() {
+			addr <- sc.Text()
+		}
+		close(addr)
+		// keep draining so the plugin never blocks on a full pipe
+		for sc.Scan() {
+			log.Printf("plugin %s: %s", path, sc.Text())
+		}
+	}()
+	var line string
+	select {
+	case line = <-addr:
+	case <-ctx.Done():
+		stop()
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Second):
+		stop()
+		return nil, fmt.Errorf("plugin %s did not start in time", path)
+	}
+	target, ok := strings.CutPrefix(line, chatplugin.HandshakePrefix)
+	if !ok {
+		stop()
+		return nil, fmt.Errorf("plugin %s: unexpected handshake %q", path, line)
+	}
+	p, err := ConnectPlugin(ctx, target)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	p.cmd, p.stdin = cmd, stdin
+	return p, nil
+}
+
+// Name returns the name the plugin reported
+func (p *Plugin) Name() string {
+	return p.info.Name
+}
+
+// Close disconnects from the plugin and stops it when it was started by
+// StartPlugin
+func (p *Plugin) Close() error {
+	err := p.conn.Close()
+	if p.cmd != nil {
+		p.stdin.Close()
+		done := make(chan struct{})
+		go func() {
+			_ = p.cmd.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			_ = p.cmd.Process.Kill()
+			<-done
+		}
+	}
+	return err
+}
+
+// has reports whether the plugin asked for hook
+func (p *Plugin) has(hook pb.PluginHook) bool {
+	return slices.Contains(p.info.Hooks, hook)
+}
+
+// pluginsAdmit asks the join hooks whether user may open a stream, in
+// order, the first denial wins
+func (s *ChatServer) pluginsAdmit(ctx context.Context, user, room string) error {
+	for _, p := range s.plugins {
+		if !p.has(pb.PluginHook_HOOK_JOIN) {
+			continue
+		}
+		cctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+		d, err := p.client.UserJoining(cctx, &pb.JoinEvent{User: user, Room: room})
+		cancel()
+		if err != nil {
+			log.Printf("Plugin %s failed on join of '%s': %v", p.Name(), user, err)
+			continue
+		}
+		if d.Deny {
+			reason := d.Reason
+			if reason == "" {
+				reason = "denied by " + p.Name()
+			}
+			return status.Error(codes.PermissionDenied, reason)
+		}
+	}
+	return nil
+}
+
+// filterMessage runs msg through the filter hooks in order, each sees the
+// previous one's rewrite. It returns the reason of a rejection.
+func (s *ChatServer) filterMessage(ctx context.Context, msg *pb.ChatMessage) (string, bool) {
+	for _, p := range s.plugins {
+		if !p.has(pb.PluginHook_HOOK_FILTER) {
+			continue
+		}
+		cctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+		res, err := p.client.FilterMessage(cctx, msg)
+		cancel()
+		if err != nil {
+			log.Printf("Plugin %s failed to filter a message from %s: %v", p.Name(), msg.User, err)
+			continue
+		}
+		if res.Reject {
+			if res.Reason == "" {
+				res.Reason = "rejected by " + p.Name()
+			}
+			log.Printf("Plugin %s rejected a message from %s: %s", p.Name(), msg.User, res.Reason)
+			return res.Reason, false
+		}
+		if m := res.Message; m != nil {
+			msg.Text, msg.Metadata = m.Text, m.Metadata
+		}
+	}
+	return "", true
+}
+
+// pluginsDelivered tells the delivery hooks about msg without waiting
+func (s *ChatServer) pluginsDelivered(msg *pb.ChatMessage) {
+	var copied *pb.ChatMessage
+	for _, p := range s.plugins {
+		if !p.has(pb.PluginHook_HOOK_DELIVERED) {
+			continue
+		}
+		if copied == nil {
+			copied = proto.Clone(msg).(*pb.ChatMessage)
+		}
+		go func(p *Plugin) {
+			ctx, cancel := context.WithTimeout(s.ctx, pluginTimeout)
+			defer cancel()
+			if _, err := p.client.MessageDelivered(ctx, copied); err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+				log.Printf("Plugin %s failed on delivery of %s: %v", p.Name(), copied.Id, err)
+			}
+		}(p)
+	}
+}
+
+// pluginCommand hands a "/command args" message to the plugin that
+// registered the command and reports whether one did
+func (s *ChatServer) pluginCommand(stream pb.ChatService_RealtimeChatServer, clientID, user, room string, msg *pb.ChatMessage) bool {
+	if len(s.plugins) == 0 || msg.RecipientUser != "" || msg.GetCode() != nil || !strings.HasPrefix(msg.Text, "/") {
+		return false
+	}
+	name, args, _ := strings.Cut(msg.Text[1:], " ")
+	if slices.Contains(builtinCommands, name) {
+		return false
+	}
+	for _, p := range s.plugins {
+		if !p.has(pb.PluginHook_HOOK_COMMAND) || !slices.Contains(p.info.Commands, name) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(stream.Context(), pluginTimeout)
+		reply, err := p.client.HandleCommand(ctx, &pb.PluginCommand{User: user, Room: room, Command: name, Args: strings.TrimSpace(args)})
+		cancel()
+		if err != nil {
+			log.Printf("Plugin %s failed on /%s from %s: %v", p.Name(), name, user, err)
+			s.sendSystem(stream, clientID, i18n.PluginFailed, "command", name)
+			return true
+		}
+		if reply.Reply != "" {
+			s.sendSystem(stream, clientID, i18n.PluginReply, "text", reply.Reply)
+		}
+		if reply.Broadcast != "" {
+			s.broadcastRoom(room, systemText(i18n.PluginReply, "text", reply.Broadcast), "")
+		}
+		return true
+	}
+	return false
+}
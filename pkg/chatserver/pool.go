@@ -0,0 +1,18 @@
+package chatserver
+
+import (
+	"sync"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// messagePool recycles the messages streams are read into. Only the
+// ones handled without being kept go back: heartbeats, activity hints
+// and filters, which are most of what idle clients send.
+var messagePool = sync.Pool{New: func() any { return new(pb.ChatMessage) }}
+
+// recycle returns msg to messagePool, nothing may use it afterwards
+func recycle(msg *pb.ChatMessage) {
+	msg.Reset()
+	messagePool.Put(msg)
+}
@@ -0,0 +1,160 @@
+package chatserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// startServer serves s on an ephemeral port until the test ends. The
+// chattest harness cannot be used here, it imports this package.
+func startServer(t *testing.T, s *ChatServer) *grpc.ClientConn {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.Serve(lis)
+	}()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+		<-done
+	})
+	return conn
+}
+
+// TestPooledReceive has clients interleave heartbeats and filters, which
+// the server reads into pooled messages and recycles, with chat messages
+// it keeps. Run with -race: a message recycled while still in use shows
+// up as a data race or as a reply that does not match what was sent.
+func TestPooledReceive(t *testing.T) {
+	const clients, rounds = 6, 50
+	s := NewChatServer()
+	conn := startServer(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	streams := make([]pb.ChatService_RealtimeChatClient, clients)
+	for i := range streams {
+		stream, err := pb.NewChatServiceClient(conn).RealtimeChat(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.Send(&pb.ChatMessage{User: fmt.Sprintf("user%d", i), Text: "has joined"}); err != nil {
+			t.Fatal(err)
+		}
+		streams[i] = stream
+	}
+	waitOnline(t, s, clients)
+
+	var wg sync.WaitGroup
+	for i, stream := range streams {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for n := range rounds {
+				msgs := []*pb.ChatMessage{
+					{Payload: &pb.ChatMessage_Heartbeat{Heartbeat: &pb.Heartbeat{SentAt: int64(n + 1)}}},
+					{Payload: &pb.ChatMessage_Filter{Filter: &pb.StreamFilter{HideMembership: n%2 == 0}}},
+				}
+				if n%10 == 0 {
+					msgs = append(msgs, &pb.ChatMessage{Text: fmt.Sprintf("from user%d #%d", i, n)})
+				}
+				for _, msg := range msgs {
+					if err := stream.Send(msg); err != nil {
+						t.Errorf("user%d send: %v", i, err)
+						return
+					}
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			beats := make(map[int64]bool)
+			chats := 0
+			for len(beats) < rounds || chats < (clients-1)*rounds/10 {
+				msg, err := stream.Recv()
+				if err != nil {
+					t.Errorf("user%d receive: %v", i, err)
+					return
+				}
+				if hb := msg.GetHeartbeat(); hb != nil {
+					if hb.SentAt < 1 || hb.SentAt > rounds || beats[hb.SentAt] {
+						t.Errorf("user%d got heartbeat %d", i, hb.SentAt)
+					}
+					beats[hb.SentAt] = true
+					continue
+				}
+				if msg.Type != pb.MessageType_TYPE_CHAT || msg.User == "System" {
+					continue
+				}
+				chats++
+				if !strings.HasPrefix(msg.Text, "from "+msg.User+" #") {
+					t.Errorf("user%d got %q from %s", i, msg.Text, msg.User)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// waitOnline blocks until n users are connected to s
+func waitOnline(t *testing.T, s *ChatServer, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.OnlineUsers()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d users did not come online", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// BenchmarkReceive compares reading heartbeats into new messages with
+// reading them into pooled ones
+func BenchmarkReceive(b *testing.B) {
+	data, err := proto.Marshal(&pb.ChatMessage{
+		User:    "alice",
+		Payload: &pb.ChatMessage_Heartbeat{Heartbeat: &pb.Heartbeat{SentAt: 1760000000000}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			msg := new(pb.ChatMessage)
+			if err := proto.Unmarshal(data, msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			msg := messagePool.Get().(*pb.ChatMessage)
+			if err := proto.Unmarshal(data, msg); err != nil {
+				b.Fatal(err)
+			}
+			recycle(msg)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/translate"
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultRoom is the room streams join unless they ask for another, room
+// preferences for it apply to the main chat
+const DefaultRoom = "general"
+
+// PreferenceStore keeps per-user notification preferences. Get returns
+// nil without an error for users that have none.
+type PreferenceStore interface {
+	GetPreferences(ctx context.Context, user string) (*pb.Preferences, error)
+	SetPreferences(ctx context.Context, prefs *pb.Preferences) error
+	DeletePreferences(ctx context.Context, user string) error
+}
+
+// MemoryPreferenceStore is an in-process PreferenceStore
+type MemoryPreferenceStore struct {
+	mu    sync.RWMutex
+	prefs map[string]*pb.Preferences
+}
+
+// NewMemoryPreferenceStore creates an empty MemoryPreferenceStore
+func NewMemoryPreferenceStore() *MemoryPreferenceStore {
+	return &MemoryPreferenceStore{prefs: make(map[string]*pb.Preferences)}
+}
+
+// GetPreferences returns a copy of the user's preferences
+func (m *MemoryPreferenceStore) GetPreferences(_ context.Context, user string) (*pb.Preferences, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.prefs[user]
+	if !ok {
+		return nil, nil
+	}
+	return proto.Clone(p).(*pb.Preferences), nil
+}
+
+// SetPreferences replaces the user's preferences
+func (m *MemoryPreferenceStore) SetPreferences(_ context.Context, prefs *pb.Preferences) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prefs[prefs.User] = proto.Clone(prefs).(*pb.Preferences)
+	return nil
+}
+
+// DeletePreferences removes the user's preferences
+func (m *MemoryPreferenceStore) DeletePreferences(_ context.Context, user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.prefs, user)
+	return nil
+}
+
+// renamePreferences moves the preferences of oldName, keywords included,
+// to newName. They replace any left behind by an earlier holder of newName.
+func (s *ChatServer) renamePreferences(ctx context.Context, oldName, newName string) error {
+	prefs, err := s.prefs.GetPreferences(ctx, oldName)
+	if err != nil || prefs == nil {
+		return err
+	}
+	prefs.User = newName
+	if err := s.prefs.SetPreferences(ctx, prefs); err != nil {
+		return err
+	}
+	return s.prefs.DeletePreferences(ctx, oldName)
+}
+
+var clockTime = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// validatePreferences checks prefs before they are stored
+func validatePreferences(prefs *pb.Preferences) error {
+	var errs []error
+	if prefs.User == "" {
+		errs = append(errs, errors.New("user cannot be empty"))
+	}
+	for room, level := range prefs.Rooms {
+		if strings.TrimSpace(room) == "" {
+			errs = append(errs, errors.New("room names cannot be empty"))
+		}
+		if _, ok := pb.NotifyLevel_name[int32(level)]; !ok {
+			errs = append(errs, fmt.Errorf("room %q: unknown notify level %d", room, level))
+		}
+	}
+	if q := prefs.QuietHours; q != nil {
+		if !clockTime.MatchString(q.Start) || !clockTime.MatchString(q.End) {
+			errs = append(errs, errors.New("quiet hours must be given as HH:MM"))
+		}
+		if _, err := time.LoadLocation(q.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("quiet hours timezone: %w", err))
+		}
+	}
+	if prefs.AutoTranslate != "" && !translate.ValidLang(prefs.AutoTranslate) {
+		errs = append(errs, fmt.Errorf("auto_translate: %q is not a language tag", prefs.AutoTranslate))
+	}
+	if prefs.Locale != "" && !translate.ValidLang(prefs.Locale) {
+		errs = append(errs, fmt.Errorf("locale: %q is not a language tag", prefs.Locale))
+	}
+	for room, kw := range prefs.Keywords {
+		if strings.TrimSpace(room) == "" {
+			errs = append(errs, errors.New("keyword room names cannot be empty"))
+		}
+		if len(kw.GetWords()) > maxRoomKeywords {
+			errs = append(errs, fmt.Errorf("room %q: at most %d keywords", room, maxRoomKeywords))
+		}
+		for _, w := range kw.GetWords() {
+			if w = strings.TrimSpace(w); w == "" || len(w) > maxKeywordLen {
+				errs = append(errs, fmt.Errorf("room %q: keywords must be 1 to %d bytes", room, maxKeywordLen))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// inQuietHours reports whether t falls inside q, ranges may wrap midnight
+func inQuietHours(q *pb.QuietHours, t time.Time) bool {
+	if q == nil || q.Start == q.End {
+		return false
+	}
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return false
+	}
+	now := t.In(loc).Format("15:04")
+	if q.Start < q.End {
+		return now >= q.Start && now < q.End
+	}
+	return now >= q.Start || now < q.End
+}
+
+// mentions reports whether text contains @user as a whole word
+func mentions(text, user string) bool {
+	for rest := text; ; {
+		i := strings.Index(rest, "@"+user)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+1+len(user):]
+		if rest == "" || !isNameByte(rest[0]) {
+			return true
+		}
+	}
+}
+
+func isNameByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// shouldNotify decides whether msg should alert user, consulting their
+// preferences. PMs ignore room levels but respect quiet hours.
+func (s *ChatServer) shouldNotify(ctx context.Context, user string, msg *pb.ChatMessage) bool {
+	if pb.TypeOf(msg).IsEvent() {
+		return false
+	}
+	prefs, err := s.prefs.GetPreferences(ctx, user)
+	if err != nil {
+		prefs = nil // fall back to the defaults
+	}
+	if inQuietHours(prefs.GetQuietHours(), time.Now()) {
+		return false
+	}
+	if msg.RecipientUser != "" {
+		return true
+	}
+	switch prefs.GetRooms()[msg.Room] {
+	case pb.NotifyLevel_NOTIFY_ALL:
+		return true
+	case pb.NotifyLevel_NOTIFY_MUTED:
+		return false
+	}
+	return mentions(msg.Text, user)
+}
+
+// withNotify returns msg flagged for notification when user should be
+// alerted, msg itself is shared between recipients and never modified
+func (s *ChatServer) withNotify(ctx context.Context, user string, msg *pb.ChatMessage) *pb.ChatMessage {
+	if !s.shouldNotify(ctx, user, msg) {
+		return msg
+	}
+	out := proto.Clone(msg).(*pb.ChatMessage)
+	out.Notify = true
+	return out
+}
+
+// preferencesServer implements the PreferencesService RPCs
+type preferencesServer struct {
+	pb.UnimplementedPreferencesServiceServer
+	s *ChatServer
+}
+
+// GetPreferences returns the stored preferences or the defaults
+func (p *preferencesServer) GetPreferences(ctx context.Context, req *pb.PreferencesRequest) (*pb.Preferences, error) {
+	if err := p.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	prefs, err := p.s.prefs.GetPreferences(ctx, req.User)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "load preferences: %v", err)
+	}
+	if prefs == nil {
+		prefs = &pb.Preferences{User: req.User}
+	}
+	return prefs, nil
+}
+
+// SetPreferences validates and replaces a user's preferences
+func (p *preferencesServer) SetPreferences(ctx context.Context, prefs *pb.Preferences) (*pb.Preferences, error) {
+	if err := validatePreferences(prefs); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := p.s.authorizeUser(ctx, prefs.User); err != nil {
+		return nil, err
+	}
+	if err := p.s.prefs.SetPreferences(ctx, prefs); err != nil {
+		return nil, status.Errorf(codes.Internal, "save preferences: %v", err)
+	}
+	return prefs, nil
+}
+
+// DeletePreferences resets a user to the default preferences
+func (p *preferencesServer) DeletePreferences(ctx context.Context, req *pb.PreferencesRequest) (*pb.Preferences, error) {
+	if err := p.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	if err := p.s.prefs.DeletePreferences(ctx, req.User); err != nil {
+		return nil, status.Errorf(codes.Internal, "delete preferences: %v", err)
+	}
+	return &pb.Preferences{User: req.User}, nil
+}
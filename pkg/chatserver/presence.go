@@ -0,0 +1,74 @@
+package chatserver
+
+import (
+	pb "realTimeChat/proto/chat"
+)
+
+// setPresence tells the user's audience that its status changed,
+// available becomes away while all of the user's connections are idle
+func (s *ChatServer) setPresence(user string, status pb.PresenceStatus) {
+	if status == pb.PresenceStatus_PRESENCE_AVAILABLE && s.isAway(user) {
+		status = pb.PresenceStatus_PRESENCE_AWAY
+	}
+	s.broadcastPresence([]string{user}, func([]string) *pb.ChatMessage {
+		return presenceMessage(user, status)
+	}, "")
+}
+
+// Presence returns the status of every user that is not available,
+// users in a call are busy until it ends or their connection drops and
+// are never shown away
+func (s *ChatServer) Presence() map[string]pb.PresenceStatus {
+	out := make(map[string]pb.PresenceStatus)
+	s.idle.mu.Lock()
+	for user := range s.idle.away {
+		out[user] = pb.PresenceStatus_PRESENCE_AWAY
+	}
+	s.idle.mu.Unlock()
+
+	s.calls.mu.Lock()
+	defer s.calls.mu.Unlock()
+	for _, c := range s.calls.calls {
+		for _, user := range []string{c.caller, c.callee} {
+			if st := c.status(user); st != pb.PresenceStatus_PRESENCE_AVAILABLE {
+				out[user] = st
+			}
+		}
+	}
+	return out
+}
+
+// callStatus returns user's status from the calls alone
+func (s *ChatServer) callStatus(user string) pb.PresenceStatus {
+	s.calls.mu.Lock()
+	defer s.calls.mu.Unlock()
+	for _, c := range s.calls.calls {
+		if c.caller == user || c.callee == user {
+			if st := c.status(user); st != pb.PresenceStatus_PRESENCE_AVAILABLE {
+				return st
+			}
+		}
+	}
+	return pb.PresenceStatus_PRESENCE_AVAILABLE
+}
+
+// sendPresence gives a newly joined connection the current statuses of
+// the users it may hear about
+func (s *ChatServer) sendPresence(clientID string) {
+	presence := s.Presence()
+	users := make([]string, 0, len(presence))
+	for user := range presence {
+		users = append(users, user)
+	}
+	for _, user := range s.presenceFilter(clientID, users) {
+		s.sendToConn(clientID, presenceMessage(user, presence[user]))
+	}
+}
+
+func presenceMessage(user string, status pb.PresenceStatus) *pb.ChatMessage {
+	return &pb.ChatMessage{
+		User:    "System",
+		Type:    pb.MessageType_TYPE_PRESENCE,
+		Payload: &pb.ChatMessage_Presence{Presence: &pb.Presence{User: user, Status: status}},
+	}
+}
@@ -0,0 +1,139 @@
+package chatserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// ProfileStore keeps the pinned message of each user. Get returns nil
+// without an error for users that have pinned nothing. Only the user
+// and pin fields of a Profile are stored, presence is filled in live.
+type ProfileStore interface {
+	GetProfile(ctx context.Context, user string) (*pb.Profile, error)
+	SetProfile(ctx context.Context, profile *pb.Profile) error
+}
+
+// MemoryProfileStore is an in-process ProfileStore
+type MemoryProfileStore struct {
+	mu       sync.RWMutex
+	profiles map[string]*pb.Profile
+}
+
+// NewMemoryProfileStore creates an empty MemoryProfileStore
+func NewMemoryProfileStore() *MemoryProfileStore {
+	return &MemoryProfileStore{profiles: make(map[string]*pb.Profile)}
+}
+
+// GetProfile returns a copy of the user's stored profile
+func (m *MemoryProfileStore) GetProfile(_ context.Context, user string) (*pb.Profile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.profiles[user]
+	if !ok {
+		return nil, nil
+	}
+	return proto.Clone(p).(*pb.Profile), nil
+}
+
+// SetProfile replaces the user's profile, one without a pin is removed
+func (m *MemoryProfileStore) SetProfile(_ context.Context, profile *pb.Profile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if profile.Pinned == nil {
+		delete(m.profiles, profile.User)
+		return nil
+	}
+	m.profiles[profile.User] = &pb.Profile{User: profile.User, Pinned: proto.Clone(profile.Pinned).(*pb.ChatMessage), PinnedAt: profile.PinnedAt}
+	return nil
+}
+
+// profile returns the stored profile of user with their current presence
+func (s *ChatServer) profile(ctx context.Context, user string) (*pb.Profile, error) {
+	p, err := s.profiles.GetProfile(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = &pb.Profile{User: user}
+	}
+	if p.Online = s.isOnline(user); p.Online {
+		p.Status = s.Presence()[user]
+	}
+	return p, nil
+}
+
+// renameProfile moves the pin of oldName to newName, replacing any left
+// behind by an earlier holder of newName
+func (s *ChatServer) renameProfile(ctx context.Context, oldName, newName string) error {
+	p, err := s.profiles.GetProfile(ctx, oldName)
+	if err != nil || p == nil || p.Pinned == nil {
+		return err
+	}
+	if err := s.profiles.SetProfile(ctx, &pb.Profile{User: newName, Pinned: p.Pinned, PinnedAt: p.PinnedAt}); err != nil {
+		return err
+	}
+	return s.profiles.SetProfile(ctx, &pb.Profile{User: oldName})
+}
+
+// pinnable returns a snapshot of the message id for a profile, only
+// public messages of public rooms still in the history qualify
+func (s *ChatServer) pinnable(id string) (*pb.ChatMessage, error) {
+	msg := s.history.find(id)
+	if msg == nil {
+		return nil, status.Error(codes.NotFound, "message not found in recent history")
+	}
+	if msg.RecipientUser != "" || pb.TypeOf(msg).IsEvent() {
+		return nil, status.Error(codes.InvalidArgument, "only chat messages can be pinned")
+	}
+	if s.access.isPrivate(msg.Room) {
+		return nil, status.Error(codes.PermissionDenied, "messages of private rooms cannot be pinned")
+	}
+	pinned := proto.Clone(msg).(*pb.ChatMessage)
+	pinned.Notify = false
+	return pinned, nil
+}
+
+// profileServer implements the ProfileService RPCs
+type profileServer struct {
+	pb.UnimplementedProfileServiceServer
+	s *ChatServer
+}
+
+// GetProfile returns a user's presence and pinned message
+func (p *profileServer) GetProfile(ctx context.Context, req *pb.ProfileRequest) (*pb.Profile, error) {
+	if req.User == "" {
+		return nil, status.Error(codes.InvalidArgument, "user cannot be empty")
+	}
+	profile, err := p.s.profile(ctx, req.User)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "load profile: %v", err)
+	}
+	return profile, nil
+}
+
+// SetProfilePin pins a message to a user's profile, replacing any
+// earlier pin, or removes the pin. Only the user themselves may.
+func (p *profileServer) SetProfilePin(ctx context.Context, req *pb.SetProfilePinRequest) (*pb.Profile, error) {
+	if err := p.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	stored := &pb.Profile{User: req.User}
+	if req.MessageId != "" {
+		msg, err := p.s.pinnable(req.MessageId)
+		if err != nil {
+			return nil, err
+		}
+		stored.Pinned, stored.PinnedAt = msg, time.Now().UnixMilli()
+	}
+	if err := p.s.profiles.SetProfile(ctx, stored); err != nil {
+		return nil, status.Errorf(codes.Internal, "save profile: %v", err)
+	}
+	return p.GetProfile(ctx, &pb.ProfileRequest{User: req.User})
+}
@@ -0,0 +1,39 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// quarantined logs an attachment the virus scanner flagged, tells the
+// moderators and owners of every room who are online and queues it for
+// review
+func (s *ChatServer) quarantined(r *pb.QuarantineReport) {
+	log.Printf("Quarantined attachment %s %q (%d bytes) from %s: %s", r.AttachmentId, r.Name, r.Size, r.Source, r.Threat)
+	name := r.Name
+	if name == "" {
+		name = r.AttachmentId
+	}
+	s.notifyModerators("", i18n.Quarantined, "name", name, "size", strconv.FormatInt(r.Size, 10), "threat", r.Threat)
+	s.moderation.add(&pb.ModerationItem{Id: s.newID(), Kind: pb.ModerationKind_MODERATION_QUARANTINE, Quarantine: r})
+}
+
+// ReportQuarantine notifies moderators of an attachment another process,
+// such as the gateway, quarantined
+func (a *adminServer) ReportQuarantine(ctx context.Context, req *pb.QuarantineReport) (*pb.QuarantineReport, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.AttachmentId == "" || req.Threat == "" {
+		return nil, status.Error(codes.InvalidArgument, "attachment_id and threat are required")
+	}
+	a.s.quarantined(req)
+	return req, nil
+}
@@ -0,0 +1,390 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultTenant is the tenant of every user unless WithTenants splits
+// them up
+const DefaultTenant = "default"
+
+// Quotas are the limits every tenant and room gets unless AdminService
+// sets its own, nil or zero fields are unlimited
+type Quotas struct {
+	Tenant *pb.Quota
+	Room   *pb.Quota
+}
+
+// QuotaStore keeps the usage counters quotas are checked against and the
+// quotas set through AdminService
+type QuotaStore interface {
+	// AddUsage adds delta to the counter key unless that takes it over
+	// limit, 0 being unlimited, and reports whether it did. A counter
+	// that passed expires starts over, a zero expires never does.
+	AddUsage(ctx context.Context, key string, delta, limit int64, expires time.Time) (bool, error)
+	// Usage returns a counter, 0 when it is unset or expired
+	Usage(ctx context.Context, key string) (int64, error)
+	// GetQuota returns the quota set for name, nil when there is none
+	GetQuota(ctx context.Context, scope pb.QuotaScope, name string) (*pb.Quota, error)
+	// SetQuota replaces the quota of name, nil removes it
+	SetQuota(ctx context.Context, scope pb.QuotaScope, name string, q *pb.Quota) error
+}
+
+// quotaCounter is a MemoryQuotaStore counter
+type quotaCounter struct {
+	n       int64
+	expires time.Time
+}
+
+// quotaName identifies a quota set for a tenant or room
+type quotaName struct {
+	scope pb.QuotaScope
+	name  string
+}
+
+// MemoryQuotaStore is an in-process QuotaStore, usage starts over when
+// the process restarts
+type MemoryQuotaStore struct {
+	mu       sync.Mutex
+	counters map[string]quotaCounter
+	quotas   map[quotaName]*pb.Quota
+	swept    time.Time
+}
+
+// NewMemoryQuotaStore creates an empty MemoryQuotaStore
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{
+		counters: make(map[string]quotaCounter),
+		quotas:   make(map[quotaName]*pb.Quota),
+	}
+}
+
+// AddUsage implements QuotaStore
+func (m *MemoryQuotaStore) AddUsage(_ context.Context, key string, delta, limit int64, expires time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if now.Sub(m.swept) > time.Hour {
+		for k, c := range m.counters {
+			if c.expired(now) {
+				delete(m.counters, k)
+			}
+		}
+		m.swept = now
+	}
+	c := m.counters[key]
+	if c.expired(now) {
+		c = quotaCounter{}
+	}
+	if limit > 0 && delta > 0 && c.n+delta > limit {
+		return false, nil
+	}
+	c.n += delta
+	c.expires = expires
+	m.counters[key] = c
+	return true, nil
+}
+
+// Usage implements QuotaStore
+func (m *MemoryQuotaStore) Usage(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.counters[key]
+	if c.expired(time.Now()) {
+		return 0, nil
+	}
+	return c.n, nil
+}
+
+// GetQuota implements QuotaStore
+func (m *MemoryQuotaStore) GetQuota(_ context.Context, scope pb.QuotaScope, name string) (*pb.Quota, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.quotas[quotaName{scope, name}]
+	if !ok {
+		return nil, nil
+	}
+	return proto.Clone(q).(*pb.Quota), nil
+}
+
+// SetQuota implements QuotaStore
+func (m *MemoryQuotaStore) SetQuota(_ context.Context, scope pb.QuotaScope, name string, q *pb.Quota) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if q == nil {
+		delete(m.quotas, quotaName{scope, name})
+		return nil
+	}
+	m.quotas[quotaName{scope, name}] = proto.Clone(q).(*pb.Quota)
+	return nil
+}
+
+func (c quotaCounter) expired(now time.Time) bool {
+	return !c.expires.IsZero() && !now.Before(c.expires)
+}
+
+// quotaKey names a usage counter, daily counters carry the UTC date
+func quotaKey(scope pb.QuotaScope, name, counter string, day time.Time) string {
+	key := "tenant/"
+	if scope == pb.QuotaScope_QUOTA_ROOM {
+		key = "room/"
+	}
+	key += name + "/" + counter
+	if !day.IsZero() {
+		key += "/" + day.UTC().Format(time.DateOnly)
+	}
+	return key
+}
+
+// tenant returns the tenant user belongs to
+func (s *ChatServer) tenant(user string) string {
+	if s.tenantOf != nil {
+		if t := s.tenantOf(user); t != "" {
+			return t
+		}
+	}
+	return DefaultTenant
+}
+
+// quota returns the quota in effect for name, custom when it was set
+// through AdminService. A store error falls back to the default.
+func (s *ChatServer) quota(ctx context.Context, scope pb.QuotaScope, name string) (q *pb.Quota, custom bool) {
+	q, err := s.quotaStore.GetQuota(ctx, scope, name)
+	if err != nil {
+		log.Printf("Failed to read quota of %s: %v", name, err)
+	}
+	if q != nil {
+		return q, true
+	}
+	def := s.quotas.Tenant
+	if scope == pb.QuotaScope_QUOTA_ROOM {
+		def = s.quotas.Room
+	}
+	if def == nil {
+		def = &pb.Quota{}
+	}
+	return def, false
+}
+
+// quotaCharge is one counter a message adds to
+type quotaCharge struct {
+	key     string
+	delta   int64
+	limit   int64
+	expires time.Time
+	reject  string   // i18n key when the limit is hit
+	args    []string // its arguments
+}
+
+// chargeMessage counts msg against the quotas of its sender's tenant and
+// its room. When one is exhausted nothing is counted and the i18n key and
+// arguments of a message for the sender are returned. Store errors let
+// the message through.
+func (s *ChatServer) chargeMessage(ctx context.Context, msg *pb.ChatMessage, room string) (string, []string) {
+	now := time.Now().UTC()
+	endOfDay := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	size := s.storedSize(msg)
+
+	tenant := s.tenant(msg.User)
+	tq, _ := s.quota(ctx, pb.QuotaScope_QUOTA_TENANT, tenant)
+	charges := []quotaCharge{
+		{quotaKey(pb.QuotaScope_QUOTA_TENANT, tenant, "messages", now), 1, tq.MessagesPerDay, endOfDay,
+			i18n.QuotaTenantMessages, []string{"tenant", tenant, "max", strconv.FormatInt(tq.MessagesPerDay, 10)}},
+		{quotaKey(pb.QuotaScope_QUOTA_TENANT, tenant, "storage", time.Time{}), size, tq.StorageBytes, time.Time{},
+			i18n.QuotaTenantStorage, []string{"tenant", tenant, "max", strconv.FormatInt(tq.StorageBytes, 10)}},
+	}
+	if msg.RecipientUser == "" {
+		rq, _ := s.quota(ctx, pb.QuotaScope_QUOTA_ROOM, room)
+		charges = append(charges,
+			quotaCharge{quotaKey(pb.QuotaScope_QUOTA_ROOM, room, "messages", now), 1, rq.MessagesPerDay, endOfDay,
+				i18n.QuotaRoomMessages, []string{"room", room, "max", strconv.FormatInt(rq.MessagesPerDay, 10)}},
+			quotaCharge{quotaKey(pb.QuotaScope_QUOTA_ROOM, room, "storage", time.Time{}), size, rq.StorageBytes, time.Time{},
+				i18n.QuotaRoomStorage, []string{"room", room, "max", strconv.FormatInt(rq.StorageBytes, 10)}},
+		)
+	}
+
+	var added []quotaCharge
+	for _, c := range charges {
+		ok, err := s.quotaStore.AddUsage(ctx, c.key, c.delta, c.limit, c.expires)
+		if err != nil {
+			log.Printf("Failed to count quota %s: %v", c.key, err)
+			continue
+		}
+		if !ok {
+			for _, a := range added {
+				if _, err := s.quotaStore.AddUsage(ctx, a.key, -a.delta, 0, a.expires); err != nil {
+					log.Printf("Failed to refund quota %s: %v", a.key, err)
+				}
+			}
+			log.Printf("Rejected message from %s: quota %s exhausted", msg.User, c.key)
+			return c.reject, c.args
+		}
+		added = append(added, c)
+	}
+	return "", nil
+}
+
+// storedSize is what a message adds to storage: its text, code and the
+// attachment it refers to
+func (s *ChatServer) storedSize(msg *pb.ChatMessage) int64 {
+	size := int64(len(msg.Text))
+	if code := msg.GetCode(); code != nil {
+		size += int64(len(code.Content))
+	}
+	if a := msg.GetAttachment(); a != nil {
+		if s.attachments != nil {
+			if meta, err := s.attachments.meta(s.ctx, a.Id); err == nil {
+				return size + meta.Size
+			}
+		}
+		size += max(a.Size, 0)
+	}
+	return size
+}
+
+// roomQuotas are the quotas checked when a user enters a room
+type roomQuotas struct {
+	tenant string
+	tq, rq *pb.Quota
+}
+
+// roomQuotasFor reads the quotas for user entering room, before s.mu is
+// taken for admitLocked
+func (s *ChatServer) roomQuotasFor(ctx context.Context, user, room string) roomQuotas {
+	tenant := s.tenant(user)
+	tq, _ := s.quota(ctx, pb.QuotaScope_QUOTA_TENANT, tenant)
+	rq, _ := s.quota(ctx, pb.QuotaScope_QUOTA_ROOM, room)
+	return roomQuotas{tenant: tenant, tq: tq, rq: rq}
+}
+
+// admitLocked checks whether user may enter room, returning the i18n key
+// and arguments of the refusal. The default room is never full and does
+// not count towards a tenant's rooms. Callers hold s.mu.
+func (s *ChatServer) admitLocked(user, room string, q roomQuotas) (string, []string) {
+	if room == DefaultRoom {
+		return "", nil
+	}
+	members := make(map[string]bool)
+	rooms := make(map[string]bool)
+	for _, conn := range s.connections {
+		if conn.in(room) {
+			members[conn.user] = true
+		}
+		if s.tenant(conn.user) == q.tenant {
+			for _, r := range conn.rooms() {
+				if r != DefaultRoom {
+					rooms[r] = true
+				}
+			}
+		}
+	}
+	if max := q.rq.MaxMembers; max > 0 && !members[user] && len(members) >= int(max) {
+		return i18n.QuotaRoomFull, []string{"room", room, "max", strconv.Itoa(int(max))}
+	}
+	if max := q.tq.MaxRooms; max > 0 && !rooms[room] && len(rooms) >= int(max) {
+		return i18n.QuotaTooManyRooms, []string{"tenant", q.tenant, "max", strconv.Itoa(int(max))}
+	}
+	return "", nil
+}
+
+// GetQuota reports the quota of a tenant or room and its current usage
+func (a *adminServer) GetQuota(ctx context.Context, req *pb.QuotaRequest) (*pb.QuotaUsage, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	name, err := quotaTarget(req.Scope, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return a.s.quotaUsage(ctx, req.Scope, name)
+}
+
+// SetQuota replaces or clears the quota of a tenant or room, usage so
+// far is kept
+func (a *adminServer) SetQuota(ctx context.Context, req *pb.SetQuotaRequest) (*pb.QuotaUsage, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	name, err := quotaTarget(req.Scope, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	q := req.Quota
+	switch {
+	case req.Clear:
+		q = nil
+	case q == nil:
+		return nil, status.Error(codes.InvalidArgument, "quota is required unless clear is set")
+	case q.MessagesPerDay < 0 || q.StorageBytes < 0 || q.MaxMembers < 0 || q.MaxRooms < 0:
+		return nil, status.Error(codes.InvalidArgument, "quotas cannot be negative")
+	}
+	if err := a.s.quotaStore.SetQuota(ctx, req.Scope, name, q); err != nil {
+		return nil, status.Errorf(codes.Internal, "quota store: %v", err)
+	}
+	log.Printf("Quota of %s %s set to %v", req.Scope, name, q)
+	return a.s.quotaUsage(ctx, req.Scope, name)
+}
+
+// quotaTarget validates the name of a quota request
+func quotaTarget(scope pb.QuotaScope, name string) (string, error) {
+	switch scope {
+	case pb.QuotaScope_QUOTA_TENANT:
+		if name == "" {
+			return "", status.Error(codes.InvalidArgument, "tenant name cannot be empty")
+		}
+		return name, nil
+	case pb.QuotaScope_QUOTA_ROOM:
+		room, ok := normalizeRoom(name)
+		if !ok {
+			return "", status.Errorf(codes.InvalidArgument, "%q is not a valid room name", name)
+		}
+		return room, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "unknown quota scope %d", scope)
+}
+
+// quotaUsage collects the quota of name with its counters and live counts
+func (s *ChatServer) quotaUsage(ctx context.Context, scope pb.QuotaScope, name string) (*pb.QuotaUsage, error) {
+	q, custom := s.quota(ctx, scope, name)
+	out := &pb.QuotaUsage{Scope: scope, Name: name, Quota: q, Custom: custom}
+	var err error
+	if out.MessagesToday, err = s.quotaStore.Usage(ctx, quotaKey(scope, name, "messages", time.Now())); err != nil {
+		return nil, status.Errorf(codes.Internal, "quota store: %v", err)
+	}
+	if out.StorageBytes, err = s.quotaStore.Usage(ctx, quotaKey(scope, name, "storage", time.Time{})); err != nil {
+		return nil, status.Errorf(codes.Internal, "quota store: %v", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]bool)
+	for _, conn := range s.connections {
+		switch {
+		case scope == pb.QuotaScope_QUOTA_ROOM && conn.in(name):
+			seen[conn.user] = true
+		case scope == pb.QuotaScope_QUOTA_TENANT && s.tenant(conn.user) == name:
+			for _, r := range conn.rooms() {
+				if r != DefaultRoom {
+					seen[r] = true
+				}
+			}
+		}
+	}
+	if scope == pb.QuotaScope_QUOTA_ROOM {
+		out.Members = int32(len(seen))
+	} else {
+		out.Rooms = int32(len(seen))
+	}
+	return out, nil
+}
@@ -0,0 +1,203 @@
+package chatserver
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"realTimeChat/pkg/redis"
+)
+
+// Node is one chat server of a cluster
+type Node struct {
+	ID   string // unique in the cluster
+	Addr string // where the other servers reach its gRPC port
+}
+
+// Registry records which servers of a cluster have streams of which
+// users. A server that stops sending heartbeats is skipped once its last
+// one expired, with the users it had.
+type Registry interface {
+	// Heartbeat records that node is alive for ttl
+	Heartbeat(ctx context.Context, node Node, ttl time.Duration) error
+	// Join records that the server nodeID has streams of user
+	Join(ctx context.Context, nodeID, user string) error
+	// Leave records that the server nodeID has no more streams of user
+	Leave(ctx context.Context, nodeID, user string) error
+	// Locate returns the live servers with streams of user
+	Locate(ctx context.Context, user string) ([]Node, error)
+	// Remove forgets the server nodeID and its users
+	Remove(ctx context.Context, nodeID string) error
+}
+
+// MemoryRegistry is a Registry for servers sharing one process
+type MemoryRegistry struct {
+	mu    sync.Mutex
+	nodes map[string]memoryNode
+	users map[string]map[string]bool // nodes by user
+}
+
+type memoryNode struct {
+	Node
+	expires time.Time
+}
+
+// NewMemoryRegistry creates an empty MemoryRegistry
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{nodes: make(map[string]memoryNode), users: make(map[string]map[string]bool)}
+}
+
+// Heartbeat implements Registry
+func (m *MemoryRegistry) Heartbeat(_ context.Context, node Node, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes[node.ID] = memoryNode{Node: node, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Join implements Registry
+func (m *MemoryRegistry) Join(_ context.Context, nodeID, user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.users[user] == nil {
+		m.users[user] = make(map[string]bool)
+	}
+	m.users[user][nodeID] = true
+	return nil
+}
+
+// Leave implements Registry
+func (m *MemoryRegistry) Leave(_ context.Context, nodeID, user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.users[user], nodeID)
+	if len(m.users[user]) == 0 {
+		delete(m.users, user)
+	}
+	return nil
+}
+
+// Locate implements Registry
+func (m *MemoryRegistry) Locate(_ context.Context, user string) ([]Node, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Node
+	now := time.Now()
+	for id := range m.users[user] {
+		if n, ok := m.nodes[id]; ok && now.Before(n.expires) {
+			out = append(out, n.Node)
+		}
+	}
+	return out, nil
+}
+
+// Remove implements Registry
+func (m *MemoryRegistry) Remove(_ context.Context, nodeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.nodes, nodeID)
+	for user, nodes := range m.users {
+		if delete(nodes, nodeID); len(nodes) == 0 {
+			delete(m.users, user)
+		}
+	}
+	return nil
+}
+
+// The scripts keep the two sets of a membership in step
+const (
+	registryHeartbeat = `redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+redis.call("PEXPIRE", KEYS[2], ARGV[2])
+return 1`
+	registryJoin = `redis.call("SADD", KEYS[1], ARGV[1])
+redis.call("SADD", KEYS[2], ARGV[2])
+return 1`
+	registryLeave = `redis.call("SREM", KEYS[1], ARGV[1])
+redis.call("SREM", KEYS[2], ARGV[2])
+return 1`
+	registryRemove = `for _, user in ipairs(redis.call("SMEMBERS", KEYS[2])) do
+  redis.call("SREM", ARGV[2] .. user, ARGV[1])
+end
+redis.call("DEL", KEYS[1], KEYS[2])
+return 1`
+)
+
+// RedisRegistry keeps the registry in Redis below a key prefix: the
+// address of each live server in <prefix>:node:<id>, expiring with its
+// heartbeat, the servers of each user in the set <prefix>:user:<user>
+// and the users of each server in the set <prefix>:users:<id>, which
+// expires along. Users of servers that went away are dropped from the
+// user sets as they are looked up.
+type RedisRegistry struct {
+	c      *redis.Client
+	prefix string
+}
+
+// NewRedisRegistry creates a Registry keeping its keys below prefix in c
+func NewRedisRegistry(c *redis.Client, prefix string) *RedisRegistry {
+	return &RedisRegistry{c: c, prefix: prefix}
+}
+
+func (r *RedisRegistry) nodeKey(id string) string   { return r.prefix + ":node:" + id }
+func (r *RedisRegistry) usersKey(id string) string  { return r.prefix + ":users:" + id }
+func (r *RedisRegistry) userKey(user string) string { return r.prefix + ":user:" + user }
+
+// Heartbeat implements Registry
+func (r *RedisRegistry) Heartbeat(ctx context.Context, node Node, ttl time.Duration) error {
+	_, err := r.c.Do(ctx, "EVAL", registryHeartbeat, "2", r.nodeKey(node.ID), r.usersKey(node.ID),
+		node.Addr, millis(ttl))
+	return err
+}
+
+// Join implements Registry
+func (r *RedisRegistry) Join(ctx context.Context, nodeID, user string) error {
+	_, err := r.c.Do(ctx, "EVAL", registryJoin, "2", r.userKey(user), r.usersKey(nodeID), nodeID, user)
+	return err
+}
+
+// Leave implements Registry
+func (r *RedisRegistry) Leave(ctx context.Context, nodeID, user string) error {
+	_, err := r.c.Do(ctx, "EVAL", registryLeave, "2", r.userKey(user), r.usersKey(nodeID), nodeID, user)
+	return err
+}
+
+// Locate implements Registry
+func (r *RedisRegistry) Locate(ctx context.Context, user string) ([]Node, error) {
+	ids, err := r.c.Strings(ctx, "SMEMBERS", r.userKey(user))
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	args := []string{"MGET"}
+	for _, id := range ids {
+		args = append(args, r.nodeKey(id))
+	}
+	addrs, err := r.c.Strings(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var out []Node
+	gone := []string{"SREM", r.userKey(user)}
+	for i, id := range ids {
+		if i < len(addrs) && addrs[i] != "" {
+			out = append(out, Node{ID: id, Addr: addrs[i]})
+		} else {
+			gone = append(gone, id)
+		}
+	}
+	if len(gone) > 2 {
+		r.c.Do(ctx, gone...) // they are skipped either way
+	}
+	return out, nil
+}
+
+// Remove implements Registry
+func (r *RedisRegistry) Remove(ctx context.Context, nodeID string) error {
+	_, err := r.c.Do(ctx, "EVAL", registryRemove, "2", r.nodeKey(nodeID), r.usersKey(nodeID),
+		nodeID, r.prefix+":user:")
+	return err
+}
+
+func millis(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
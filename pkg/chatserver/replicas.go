@@ -0,0 +1,264 @@
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultReplicaCheckInterval is how often a ReplicatedStore measures
+// how far its replicas are behind
+const DefaultReplicaCheckInterval = 5 * time.Second
+
+// ReadQuery names a kind of store read, so each can be routed on its own
+type ReadQuery string
+
+// Reads the server makes from a RoomReader store
+const (
+	QueryExport    ReadQuery = "export"    // AdminService.ExportRoom
+	QuerySummarize ReadQuery = "summarize" // the scrollback of /summarize
+	QueryHistory   ReadQuery = "history"   // GetHistory paging by before_time
+)
+
+type readQueryKey struct{}
+
+// withReadQuery tags the reads made with ctx as q
+func withReadQuery(ctx context.Context, q ReadQuery) context.Context {
+	return context.WithValue(ctx, readQueryKey{}, q)
+}
+
+// readQueryOf returns the query the reads made with ctx belong to
+func readQueryOf(ctx context.Context) ReadQuery {
+	q, _ := ctx.Value(readQueryKey{}).(ReadQuery)
+	return q
+}
+
+// Replica is a read-only copy of the primary store
+type Replica interface {
+	RoomReader
+	// Watermark returns the timestamp of the newest message the replica
+	// has, zero when it has none
+	Watermark(ctx context.Context) (time.Time, error)
+}
+
+// ReadRoute decides where the reads of a query go
+type ReadRoute struct {
+	Primary      bool          // always read the primary
+	MaxStaleness time.Duration // skip replicas further behind the primary, 0 accepts any lag
+}
+
+// ReplicaConfig sets up a ReplicatedStore. A replica is behind by how
+// much older its watermark is than the newest message saved to the
+// primary. Reads of a time range that ends before a replica's watermark
+// may use it however far behind it is.
+type ReplicaConfig struct {
+	Replicas      []Replica
+	Default       ReadRoute               // of queries without a route
+	Routes        map[ReadQuery]ReadRoute // per query
+	CheckInterval time.Duration           // 0 means DefaultReplicaCheckInterval
+}
+
+// ReplicatedStore saves messages to a primary store and serves room reads
+// from replicas that are fresh enough, so long exports and scans stay
+// off the write path. Reads fall back to the primary when no replica
+// qualifies or one fails before returning anything, the primary must
+// then be a RoomReader.
+type ReplicatedStore struct {
+	primary  Store
+	cfg      ReplicaConfig
+	replicas []*replicaState
+	next     atomic.Uint32 // round robin among the qualifying replicas
+	written  atomic.Int64  // Unix milliseconds of the newest message saved
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+type replicaState struct {
+	Replica
+	index     int
+	watermark atomic.Int64 // Unix milliseconds, as last measured
+	healthy   atomic.Bool
+}
+
+// ReplicaStatus is what a ReplicatedStore last measured of a replica
+type ReplicaStatus struct {
+	Healthy   bool          // the last watermark check and read succeeded
+	Watermark time.Time     // newest message it had
+	Lag       time.Duration // behind the newest message saved to the primary
+}
+
+// NewReplicatedStore saves to primary and reads through cfg. It checks
+// the replicas once before returning and then every CheckInterval until
+// Close. A primary with a Watermark method starts the lag from its
+// newest message, otherwise from the first message saved.
+func NewReplicatedStore(primary Store, cfg ReplicaConfig) *ReplicatedStore {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = DefaultReplicaCheckInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rs := &ReplicatedStore{primary: primary, cfg: cfg, cancel: cancel, done: make(chan struct{})}
+	for i, r := range cfg.Replicas {
+		rs.replicas = append(rs.replicas, &replicaState{Replica: r, index: i + 1})
+	}
+	if p, ok := primary.(interface {
+		Watermark(context.Context) (time.Time, error)
+	}); ok {
+		if wm, err := p.Watermark(ctx); err == nil && !wm.IsZero() {
+			rs.written.Store(wm.UnixMilli())
+		} else if err != nil {
+			log.Printf("Failed to read the primary store's watermark: %v", err)
+		}
+	}
+	rs.check(ctx)
+	go rs.run(ctx)
+	return rs
+}
+
+// SaveMessage implements Store, it only writes to the primary
+func (rs *ReplicatedStore) SaveMessage(ctx context.Context, msg *pb.ChatMessage) error {
+	if err := rs.primary.SaveMessage(ctx, msg); err != nil {
+		return err
+	}
+	for {
+		written := rs.written.Load()
+		if msg.Timestamp <= written || rs.written.CompareAndSwap(written, msg.Timestamp) {
+			return nil
+		}
+	}
+}
+
+// RoomMessages implements RoomReader, routing the read by the query of
+// ctx
+func (rs *ReplicatedStore) RoomMessages(ctx context.Context, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error {
+	route := rs.route(readQueryOf(ctx))
+	if !route.Primary {
+		if r := rs.pick(route, to); r != nil {
+			delivered := false
+			err := r.RoomMessages(ctx, room, from, to, func(msg *pb.ChatMessage) error {
+				delivered = true
+				return fn(msg)
+			})
+			if err == nil || delivered || ctx.Err() != nil {
+				return err
+			}
+			log.Printf("Reading replica %d failed, using the primary: %v", r.index, err)
+			r.healthy.Store(false)
+		}
+	}
+	rr, ok := rs.primary.(RoomReader)
+	if !ok {
+		return errors.New("chatserver: no replica is fresh enough and the primary store cannot be read")
+	}
+	return rr.RoomMessages(ctx, room, from, to, fn)
+}
+
+// Rooms implements RoomLister when the primary does
+func (rs *ReplicatedStore) Rooms(ctx context.Context) ([]string, error) {
+	if rl, ok := rs.primary.(RoomLister); ok {
+		return rl.Rooms(ctx)
+	}
+	return nil, nil
+}
+
+// Compact implements Compactor with the primary's, so a TieredStore can
+// move old messages out of a replicated store
+func (rs *ReplicatedStore) Compact(ctx context.Context, cutoff time.Time, fn func([]*pb.ChatMessage) error) error {
+	c, ok := rs.primary.(Compactor)
+	if !ok {
+		return errors.New("chatserver: the primary store cannot be compacted")
+	}
+	return c.Compact(ctx, cutoff, fn)
+}
+
+// Replicas returns the state of each replica, in the order configured
+func (rs *ReplicatedStore) Replicas() []ReplicaStatus {
+	out := make([]ReplicaStatus, len(rs.replicas))
+	for i, r := range rs.replicas {
+		out[i] = ReplicaStatus{Healthy: r.healthy.Load(), Lag: rs.lag(r)}
+		if wm := r.watermark.Load(); wm > 0 {
+			out[i].Watermark = time.UnixMilli(wm)
+		}
+	}
+	return out
+}
+
+// Close stops checking the replicas, closing the stores is up to the
+// caller
+func (rs *ReplicatedStore) Close() {
+	rs.cancel()
+	<-rs.done
+}
+
+func (rs *ReplicatedStore) route(q ReadQuery) ReadRoute {
+	if r, ok := rs.cfg.Routes[q]; ok {
+		return r
+	}
+	return rs.cfg.Default
+}
+
+// pick returns the next healthy replica fresh enough for route or
+// holding everything before to, nil when there is none
+func (rs *ReplicatedStore) pick(route ReadRoute, to time.Time) *replicaState {
+	n := len(rs.replicas)
+	start := int(rs.next.Add(1))
+	for i := range n {
+		r := rs.replicas[(start+i)%n]
+		if !r.healthy.Load() || r.watermark.Load() == 0 && rs.written.Load() > 0 {
+			continue // an empty copy of a store with messages is never right
+		}
+		if route.MaxStaleness == 0 || rs.lag(r) <= route.MaxStaleness ||
+			!to.IsZero() && to.UnixMilli() <= r.watermark.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// lag returns how far r was behind the primary at its last check, the
+// messages saved since count against it
+func (rs *ReplicatedStore) lag(r *replicaState) time.Duration {
+	return time.Duration(max(rs.written.Load()-r.watermark.Load(), 0)) * time.Millisecond
+}
+
+func (rs *ReplicatedStore) run(ctx context.Context) {
+	defer close(rs.done)
+	ticker := time.NewTicker(rs.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rs.check(ctx)
+		}
+	}
+}
+
+// check measures the watermark of every replica
+func (rs *ReplicatedStore) check(ctx context.Context) {
+	for _, r := range rs.replicas {
+		cctx, cancel := context.WithTimeout(ctx, rs.cfg.CheckInterval)
+		wm, err := r.Watermark(cctx)
+		cancel()
+		if err != nil {
+			if r.healthy.Swap(false) {
+				log.Printf("Replica %d is unavailable: %v", r.index, err)
+			}
+			continue
+		}
+		if !wm.IsZero() {
+			r.watermark.Store(wm.UnixMilli())
+		}
+		if !r.healthy.Swap(true) {
+			if wm.IsZero() {
+				log.Printf("Replica %d is available, it has no messages yet", r.index)
+			} else {
+				log.Printf("Replica %d is available, %v behind the primary", r.index, rs.lag(r))
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package chatserver
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// Bounds of the message requests one recipient holds, older messages of
+// a sender and senders beyond the limit are dropped
+const (
+	maxRequestSenders  = 100
+	maxRequestMessages = 20
+)
+
+// messageRequests holds the PMs of senders that recipients with
+// message requests on have not added as contacts, until the recipient
+// accepts or declines them
+type messageRequests struct {
+	mu       sync.Mutex
+	pending  map[string]map[string]*pb.MessageRequest // recipient -> sender
+	declined map[string]map[string]bool               // recipient -> sender
+}
+
+// hold queues msg for its recipient, it reports whether the sender's
+// request is new. Messages of declined senders are dropped.
+func (r *messageRequests) hold(msg *pb.ChatMessage) (first bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recipient, sender := msg.RecipientUser, msg.User
+	if r.declined[recipient][sender] {
+		return false
+	}
+	if r.pending == nil {
+		r.pending = make(map[string]map[string]*pb.MessageRequest)
+	}
+	requests := r.pending[recipient]
+	if requests == nil {
+		requests = make(map[string]*pb.MessageRequest)
+		r.pending[recipient] = requests
+	}
+	req, ok := requests[sender]
+	if !ok {
+		if len(requests) >= maxRequestSenders {
+			return false
+		}
+		req = &pb.MessageRequest{Sender: sender, ReceivedAt: time.Now().UnixMilli()}
+		requests[sender] = req
+	}
+	req.Messages = append(req.Messages, proto.Clone(msg).(*pb.ChatMessage))
+	if n := len(req.Messages) - maxRequestMessages; n > 0 {
+		req.Messages = slices.Delete(req.Messages, 0, n)
+	}
+	return !ok
+}
+
+// list returns the pending requests of recipient, oldest first
+func (r *messageRequests) list(recipient string) *pb.MessageRequests {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := &pb.MessageRequests{User: recipient}
+	for _, req := range r.pending[recipient] {
+		out.Requests = append(out.Requests, proto.Clone(req).(*pb.MessageRequest))
+	}
+	slices.SortFunc(out.Requests, func(a, b *pb.MessageRequest) int {
+		return cmp.Compare(a.ReceivedAt, b.ReceivedAt)
+	})
+	return out
+}
+
+// take removes the request of sender and returns its messages. Declining
+// remembers the sender so later PMs are dropped, accepting forgets an
+// earlier decline.
+func (r *messageRequests) take(recipient, sender string, decline bool) []*pb.ChatMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	req := r.pending[recipient][sender]
+	delete(r.pending[recipient], sender)
+	if len(r.pending[recipient]) == 0 {
+		delete(r.pending, recipient)
+	}
+	if decline {
+		if r.declined == nil {
+			r.declined = make(map[string]map[string]bool)
+		}
+		addEdge(r.declined, recipient, sender)
+	} else {
+		removeEdge(r.declined, recipient, sender)
+	}
+	return req.GetMessages()
+}
+
+// rename moves the requests held for oldName and the senders they
+// declined to newName, and the requests and declines of oldName as a
+// sender, so a declined sender cannot come back with /nick
+func (r *messageRequests) rename(oldName, newName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if requests, ok := r.pending[oldName]; ok {
+		delete(r.pending, oldName)
+		r.pending[newName] = requests
+	}
+	if declined, ok := r.declined[oldName]; ok {
+		delete(r.declined, oldName)
+		r.declined[newName] = declined
+	}
+	for _, requests := range r.pending {
+		if req, ok := requests[oldName]; ok {
+			delete(requests, oldName)
+			req.Sender = newName
+			for _, msg := range req.Messages {
+				msg.User = newName
+			}
+			requests[newName] = req
+		}
+	}
+	for _, declined := range r.declined {
+		if declined[oldName] {
+			delete(declined, oldName)
+			declined[newName] = true
+		}
+	}
+}
+
+// holdMessageRequest keeps a PM from being delivered when its recipient
+// takes messages from contacts only and the sender is not one. The
+// recipient learns of a new request right away.
+func (s *ChatServer) holdMessageRequest(ctx context.Context, msg *pb.ChatMessage) bool {
+	recipient := msg.RecipientUser
+	prefs, err := s.prefs.GetPreferences(ctx, recipient)
+	if err != nil || !prefs.GetMessageRequests() {
+		return false
+	}
+	contacts, err := s.contacts.Contacts(ctx, recipient)
+	if err != nil {
+		log.Printf("Failed to load the contacts of '%s', holding a PM from %s: %v", recipient, msg.User, err)
+	} else if slices.Contains(contacts, msg.User) {
+		return false
+	}
+	if s.requests.hold(msg) {
+		note := systemText(i18n.PMRequestNew, "user", msg.User)
+		note.EphemeralTo = recipient
+		s.sendToUser(ctx, recipient, note)
+	}
+	return true
+}
+
+// messageRequestServer implements the MessageRequestService RPCs
+type messageRequestServer struct {
+	pb.UnimplementedMessageRequestServiceServer
+	s *ChatServer
+}
+
+// ListMessageRequests returns the requests waiting for a user
+func (m *messageRequestServer) ListMessageRequests(ctx context.Context, req *pb.MessageRequestsRequest) (*pb.MessageRequests, error) {
+	if err := m.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	return m.s.requests.list(req.User), nil
+}
+
+// AcceptMessageRequest delivers the held messages and adds the sender to
+// the user's contacts so later PMs arrive directly
+func (m *messageRequestServer) AcceptMessageRequest(ctx context.Context, req *pb.MessageRequestDecision) (*pb.MessageRequests, error) {
+	if req.User == "" || req.Sender == "" {
+		return nil, status.Error(codes.InvalidArgument, "user and sender cannot be empty")
+	}
+	if err := m.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	if err := m.s.contacts.AddContact(ctx, req.User, req.Sender); err != nil {
+		return nil, status.Errorf(codes.Internal, "save contact: %v", err)
+	}
+	for _, msg := range m.s.requests.take(req.User, req.Sender, false) {
+		m.s.sendToUser(ctx, req.User, msg)
+	}
+	return m.s.requests.list(req.User), nil
+}
+
+// DeclineMessageRequest drops the held messages, the sender's later PMs
+// are dropped too until the user accepts or adds them
+func (m *messageRequestServer) DeclineMessageRequest(ctx context.Context, req *pb.MessageRequestDecision) (*pb.MessageRequests, error) {
+	if req.User == "" || req.Sender == "" {
+		return nil, status.Error(codes.InvalidArgument, "user and sender cannot be empty")
+	}
+	if err := m.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	m.s.requests.take(req.User, req.Sender, true)
+	return m.s.requests.list(req.User), nil
+}
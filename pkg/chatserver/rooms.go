@@ -0,0 +1,247 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+var roomName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// normalizeRoom lowercases a room name and strips a leading '#', ok is
+// false for names that are not valid
+func normalizeRoom(room string) (string, bool) {
+	room = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(room), "#"))
+	return room, roomName.MatchString(room)
+}
+
+// parseRoomCommand reports whether msg is a public "/join <room> [invite]"
+// or "/leave" command, leave returns to DefaultRoom
+func parseRoomCommand(msg *pb.ChatMessage) (room, invite string, ok bool) {
+	if msg.RecipientUser != "" || msg.GetCode() != nil {
+		return "", "", false
+	}
+	if msg.Text == "/join" {
+		return "", "", true
+	}
+	if msg.Text == "/leave" {
+		return DefaultRoom, "", true
+	}
+	args, ok := strings.CutPrefix(msg.Text, "/join ")
+	room, invite, _ = strings.Cut(strings.TrimSpace(args), " ")
+	return room, strings.TrimSpace(invite), ok
+}
+
+// joinRoom moves clientID to room and returns the normalized name, telling
+// the members of both rooms. The caller is told of failures with a System
+// message and false is returned. Private rooms need invite unless the user
+// has been in them before.
+func (s *ChatServer) joinRoom(stream pb.ChatService_RealtimeChatServer, clientID, user, room, invite string) (string, bool) {
+	if room == "" {
+		s.sendSystem(stream, clientID, i18n.RoomUsage)
+		return "", false
+	}
+	name, ok := normalizeRoom(room)
+	if !ok {
+		s.sendSystem(stream, clientID, i18n.RoomInvalid, "room", room)
+		return "", false
+	}
+	s.mu.RLock()
+	current := s.connections[clientID].room
+	s.mu.RUnlock()
+	if current == name {
+		s.sendSystem(stream, clientID, i18n.RoomAlready, "room", name)
+		return "", false
+	}
+	if owner := s.roomOwner(name); owner != "" {
+		s.roomMoved(stream, clientID, user, name, owner, i18n.RoomElsewhere)
+		return "", false
+	}
+	enter, redeem := s.mayEnter(user, name, invite)
+	if !enter {
+		key := i18n.RoomPrivate
+		if invite != "" {
+			key = i18n.InviteInvalid
+		}
+		s.sendSystem(stream, clientID, key, "room", name)
+		return "", false
+	}
+
+	quotas := s.roomQuotasFor(stream.Context(), user, name)
+	s.mu.Lock()
+	conn := s.connections[clientID]
+	from := conn.room
+	if from == name {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.RoomAlready, "room", name)
+		return "", false
+	}
+	if key, args := s.admitLocked(user, name, quotas); key != "" {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, key, args...)
+		return "", false
+	}
+	if redeem && !s.access.redeem(invite, name, time.Now()) {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.InviteInvalid, "room", name)
+		return "", false
+	}
+	conn.room = name
+	// a subscribed room becomes the current one, its members saw the user
+	// come in already
+	subscribed := conn.subs[name]
+	if subscribed {
+		conn.subs = maps.Clone(conn.subs)
+		delete(conn.subs, name)
+	}
+	s.connections[clientID] = conn
+	s.mu.Unlock()
+	s.reads.enter(user, name)
+	s.memberLeft(user, from)
+	s.leaveVoice(clientID, from)
+	if !subscribed {
+		s.memberEntered(user, name)
+	}
+
+	if redeem {
+		log.Printf("'%s' entered #%s with an invite", user, name)
+	}
+	log.Printf("User '%s' (ID: %s) moved from #%s to #%s.", user, clientID, from, name)
+	s.broadcastRoom(from, membership(false, systemText(i18n.RoomUserLeft, "user", user, "room", from)), clientID)
+	if !subscribed {
+		s.broadcastRoom(name, membership(true, systemText(i18n.RoomUserJoined, "user", user, "room", name)), clientID)
+	}
+
+	own := systemText(i18n.RoomEntered, "room", name)
+	own.Room = name
+	own.Type = pb.MessageType_TYPE_ROOM_CHANGE
+	own.Payload = &pb.ChatMessage_RoomChange{RoomChange: &pb.RoomChange{User: user, From: from, To: name}}
+	if err := stream.Send(own); err != nil {
+		log.Printf("Failed to send room change to %s: %v", clientID, err)
+	}
+	s.sendWelcome(stream, clientID, name, false)
+	if subscribed || len(conn.subs) > 0 {
+		s.sendSubscriptions(clientID)
+	}
+	return name, true
+}
+
+// broadcastRoom sends msg to every connection in room except excludeID
+func (s *ChatServer) broadcastRoom(room string, msg *pb.ChatMessage, excludeID string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var targets []connection
+	for id, conn := range s.connections {
+		if id != excludeID && conn.in(room) && conn.wants(room, msg) {
+			targets = append(targets, conn)
+		}
+	}
+	s.fanout(msg, targets)
+	s.watchers.deliver(room, msg)
+}
+
+// roomServer implements the RoomService RPCs
+type roomServer struct {
+	pb.UnimplementedRoomServiceServer
+	s *ChatServer
+}
+
+// ListUsers returns the online users sorted by name, limited to the
+// members of req.Room when given. Private rooms are only shown to their
+// members and the admin token.
+func (r *roomServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.UserList, error) {
+	if req.User != "" {
+		if err := r.s.authorizeUser(ctx, req.User); err != nil {
+			return nil, err
+		}
+	}
+	readable := make(map[string]bool)
+	visible := func(room string) bool {
+		ok, seen := readable[room]
+		if !seen {
+			ok = r.s.mayRead(ctx, req.User, room)
+			readable[room] = ok
+		}
+		return ok
+	}
+	room := req.Room
+	if room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(room); !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+		if !visible(room) {
+			return nil, status.Errorf(codes.PermissionDenied, "#%s is private", room)
+		}
+	}
+	presence := r.s.Presence()
+
+	r.s.mu.RLock()
+	byName := make(map[string]*pb.OnlineUser)
+	for _, conn := range r.s.connections {
+		u, ok := byName[conn.user]
+		if !ok {
+			u = &pb.OnlineUser{Name: conn.user, Status: presence[conn.user]}
+			byName[conn.user] = u
+		}
+		if !contains(u.Rooms, conn.room) {
+			u.Rooms = append(u.Rooms, conn.room)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	out := &pb.UserList{}
+	for _, u := range byName {
+		if room != "" && !contains(u.Rooms, room) {
+			continue
+		}
+		u.Rooms = slices.DeleteFunc(u.Rooms, func(name string) bool { return !visible(name) })
+		sort.Strings(u.Rooms)
+		out.Users = append(out.Users, u)
+	}
+	sort.Slice(out.Users, func(i, j int) bool { return out.Users[i].Name < out.Users[j].Name })
+	return out, nil
+}
+
+// ListRooms returns the public rooms with online members, DefaultRoom
+// always comes first
+func (r *roomServer) ListRooms(context.Context, *pb.ListRoomsRequest) (*pb.RoomList, error) {
+	r.s.mu.RLock()
+	members := map[string]map[string]bool{DefaultRoom: {}}
+	for _, conn := range r.s.connections {
+		if members[conn.room] == nil {
+			members[conn.room] = make(map[string]bool)
+		}
+		members[conn.room][conn.user] = true
+	}
+	r.s.mu.RUnlock()
+
+	out := &pb.RoomList{}
+	for name, users := range members {
+		if r.s.access.isPrivate(name) {
+			continue
+		}
+		st := r.s.settings.get(name)
+		out.Rooms = append(out.Rooms, &pb.RoomInfo{Name: name, Members: uint32(len(users)), Language: st.Language, Nsfw: st.Nsfw})
+	}
+	sort.Slice(out.Rooms, func(i, j int) bool {
+		a, b := out.Rooms[i].Name, out.Rooms[j].Name
+		if a == DefaultRoom || b == DefaultRoom {
+			return a == DefaultRoom
+		}
+		return a < b
+	})
+	return out, nil
+}
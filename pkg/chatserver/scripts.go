@@ -0,0 +1,259 @@
+package chatserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// scriptTimeout bounds one script run, a script over it lets the
+	// message through
+	scriptTimeout = 100 * time.Millisecond
+	// scriptPollInterval is how often the script directory is checked
+	// for changes
+	scriptPollInterval = 2 * time.Second
+)
+
+// scriptEngine runs the Lua scripts of a directory on every message
+// before it is broadcast. Each script defines on_message(msg); it may
+// change msg.text and msg.metadata, and returning false, reason drops the
+// message.
+type scriptEngine struct {
+	dir     string
+	mu      sync.Mutex // serialises runs, a Lua state is single threaded
+	scripts []*script
+	stamp   string // what the scripts were loaded from, see dirStamp
+}
+
+// script is one loaded file
+type script struct {
+	name string
+	L    *lua.LState
+}
+
+// startScripts loads the scripts in dir and reloads them whenever the
+// directory changes until the server stops
+func (s *ChatServer) startScripts(dir string) {
+	e := &scriptEngine{dir: dir}
+	if err := e.reload(); err != nil {
+		log.Printf("Scripts: %v", err)
+	}
+	s.scripts = e
+	go func() {
+		t := time.NewTicker(scriptPollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+			case <-s.ctx.Done():
+				e.close()
+				return
+			}
+			if err := e.reload(); err != nil {
+				log.Printf("Scripts: %v, keeping the loaded ones", err)
+			}
+		}
+	}()
+}
+
+// dirStamp describes the .lua files of dir by name, size and mtime
+func dirStamp(dir string) ([]string, string, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.lua"))
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return names, b.String(), nil
+}
+
+// reload replaces the loaded scripts when the directory changed. Nothing
+// is replaced unless every script loads.
+func (e *scriptEngine) reload() error {
+	names, stamp, err := dirStamp(e.dir)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	unchanged := stamp == e.stamp
+	e.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	var scripts []*script
+	for _, name := range names {
+		sc, err := loadScript(name)
+		if err != nil {
+			for _, loaded := range scripts {
+				loaded.L.Close()
+			}
+			e.mu.Lock()
+			e.stamp = stamp // report a broken file once, not every poll
+			e.mu.Unlock()
+			return err
+		}
+		scripts = append(scripts, sc)
+	}
+
+	e.mu.Lock()
+	old := e.scripts
+	e.scripts, e.stamp = scripts, stamp
+	e.mu.Unlock()
+	for _, sc := range old {
+		sc.L.Close()
+	}
+	log.Printf("Loaded %d message scripts from %s", len(scripts), e.dir)
+	return nil
+}
+
+func (e *scriptEngine) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, sc := range e.scripts {
+		sc.L.Close()
+	}
+	e.scripts = nil
+}
+
+// loadScript runs a file in a fresh sandbox: only the base, string,
+// table and math libraries without file or module access
+func loadScript(path string) (*script, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true, CallStackSize: 64, RegistryMaxSize: 1 << 16})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.StringLibName, lua.OpenString},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	name := filepath.Base(path)
+	logFn := L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, L.GetTop())
+		for i := range parts {
+			parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+		log.Printf("script %s: %s", name, strings.Join(parts, " "))
+		return 0
+	})
+	L.SetGlobal("print", logFn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	L.SetContext(ctx)
+	if err := L.DoFile(path); err != nil {
+		L.Close()
+		return nil, err
+	}
+	L.RemoveContext()
+	if L.GetGlobal("on_message").Type() != lua.LTFunction {
+		L.Close()
+		return nil, fmt.Errorf("%s does not define on_message(msg)", name)
+	}
+	return &script{name: name, L: L}, nil
+}
+
+// run passes msg through every script in file name order, each sees the
+// previous one's changes. It returns the reason of a drop. Scripts that
+// fail or run too long are skipped.
+func (e *scriptEngine) run(msg *pb.ChatMessage, room string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, sc := range e.scripts {
+		reason, keep, err := sc.call(msg, room)
+		if err != nil {
+			log.Printf("Script %s failed on a message from %s: %v", sc.name, msg.User, err)
+			continue
+		}
+		if !keep {
+			if reason == "" {
+				reason = "dropped by " + sc.name
+			}
+			log.Printf("Script %s dropped a message from %s: %s", sc.name, msg.User, reason)
+			return reason, false
+		}
+	}
+	return "", true
+}
+
+// call runs on_message with a table view of msg, sent in room, and copies
+// the text and metadata back unless it failed
+func (sc *script) call(msg *pb.ChatMessage, room string) (string, bool, error) {
+	L := sc.L
+	t := L.NewTable()
+	t.RawSetString("user", lua.LString(msg.User))
+	if msg.RecipientUser == "" {
+		t.RawSetString("room", lua.LString(room))
+	} else {
+		t.RawSetString("recipient", lua.LString(msg.RecipientUser))
+	}
+	t.RawSetString("text", lua.LString(msg.Text))
+	if code := msg.GetCode(); code != nil {
+		t.RawSetString("code", lua.LString(code.Content))
+	}
+	md := L.NewTable()
+	for k, v := range msg.Metadata {
+		md.RawSetString(k, lua.LString(v))
+	}
+	t.RawSetString("metadata", md)
+
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+	err := L.CallByParam(lua.P{Fn: L.GetGlobal("on_message"), NRet: 2, Protect: true}, t)
+	if err != nil {
+		return "", true, err
+	}
+	ret, reason := L.Get(-2), L.Get(-1)
+	L.Pop(2)
+
+	text, ok := t.RawGetString("text").(lua.LString)
+	if !ok {
+		return "", true, fmt.Errorf("msg.text must be a string")
+	}
+	metadata := make(map[string]string)
+	if md, ok := t.RawGetString("metadata").(*lua.LTable); ok {
+		md.ForEach(func(k, v lua.LValue) {
+			if v != lua.LNil {
+				metadata[k.String()] = L.ToStringMeta(v).String()
+			}
+		})
+	}
+	msg.Text = string(text)
+	msg.Metadata = metadata
+	if len(msg.Metadata) == 0 {
+		msg.Metadata = nil
+	}
+	if ret == lua.LFalse {
+		return lua.LVAsString(reason), false, nil
+	}
+	return "", true, nil
+}
@@ -0,0 +1,925 @@
+// Package chatserver implements the gRPC chat backend. It can be embedded
+// in other applications or run standalone through server/main.go.
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/assistant"
+	"realTimeChat/pkg/avscan"
+	"realTimeChat/pkg/i18n"
+	"realTimeChat/pkg/ids"
+	"realTimeChat/pkg/imaging"
+	"realTimeChat/pkg/leader"
+	"realTimeChat/pkg/objstore"
+	"realTimeChat/pkg/translate"
+	"realTimeChat/pkg/unfurl"
+	pb "realTimeChat/proto/chat"
+)
+
+// connection store stream and user info
+type connection struct {
+	stream pb.ChatService_RealtimeChatServer
+	user   string
+	room   string           // public messages go to the connections in the same room
+	subs   map[string]bool  // further rooms the stream receives, never room; replaced, not modified
+	filter *pb.StreamFilter // what the client wants held back, nil for nothing; replaced, not modified
+	info   sessionInfo
+	revoke context.CancelCauseFunc // ends the stream, see revokeSessions
+}
+
+// in reports whether the connection receives the public messages of room
+func (c connection) in(room string) bool {
+	return c.room == room || c.subs[room]
+}
+
+// rooms returns the current room followed by the subscribed ones
+func (c connection) rooms() []string {
+	out := []string{c.room}
+	for room := range c.subs {
+		out = append(out, room)
+	}
+	slices.Sort(out[1:])
+	return out
+}
+
+// ChatServer struct
+type ChatServer struct {
+	pb.UnimplementedChatServiceServer
+	mu          sync.RWMutex          // read write mutex to protect connections map
+	connections map[string]connection // store active connection
+	aliases     map[string]alias      // old name -> current name after /nick
+	renameGrace time.Duration
+	calls       callRegistry
+	voice       voiceChannels // who is in the voice channel of each room
+	threads     threadSet     // replies to public messages, see ThreadService
+	reads       *readState
+	history     *roomHistory
+	seqMu       sync.Mutex // orders sequence assignment with history
+	dedup       *dedupCache
+	dedupWindow time.Duration
+	ringTimeout time.Duration
+	streams     streamCounter
+	announce    announcer
+	watchers    watcherSet
+	requests    messageRequests
+	usage       usageStats
+	idle        idleTracker
+
+	store        Store
+	prefs        PreferenceStore
+	profiles     ProfileStore
+	contacts     ContactStore
+	drafts       DraftStore
+	quotaStore   QuotaStore
+	bans         BanStore
+	banCache     banCache // last list read from bans
+	blockStore   BlockStore
+	quotas       Quotas
+	tenantOf     func(user string) string // nil puts everyone in DefaultTenant
+	auth         Authenticator
+	limits       Limits
+	hooks        Hooks
+	plugins      []*Plugin
+	commands     commandRegistry // slash commands of external tools
+	welcomes     welcomes        // sent to connections as they join, see sendWelcome
+	settings     roomSettings    // see SetRoomSettings
+	members      roomMembers     // who has been in each room, see GetRoomMembers
+	access       roomAccess      // private rooms and their invites
+	abuse        abuseDetector   // scores messages, see AbuseConfig
+	blocks       blocklist       // compiled rules of blockStore
+	scriptDir    string
+	scripts      *scriptEngine // nil without scriptDir
+	features     featureSet
+	featurePath  string // feature flag file, "" keeps the builtin features on
+	keepalive    Keepalive
+	grpcOpts     []grpc.ServerOption
+	frames       sharedFrames    // encodings of the messages being fanned out
+	batching     *WriteBatching  // nil writes every send on its own
+	elector      *leader.Elector // nil runs the singleton jobs on every server
+	cluster      *clusterState   // nil without WithCluster
+	shards       *shardState     // nil serves every room
+	unfurler     *unfurl.Unfurler
+	translator   translate.Translator
+	assistant    assistant.Assistant
+	capabilities []string        // offered to clients that send a Hello
+	adminToken   string          // AdminService is disabled when empty
+	audit        auditLog        // admin calls that changed state
+	auditPath    string          // where audit is appended, "" keeps it in memory
+	moderation   moderationQueue // flagged messages and quarantines awaiting a moderator
+
+	attachmentDir string
+	attachments   *attachmentStore // nil when attachmentDir is unusable
+	scanner       avscan.Scanner   // nil leaves uploads unscanned
+
+	thumbnailSizes []int                // of image attachments
+	objects        objstore.ObjectStore // finished attachments, attachmentDir when nil
+
+	ids    ids.Generator   // message and session IDs
+	ctx    context.Context // cancelled on Stop, bounds background work
+	cancel context.CancelFunc
+
+	grpcMu     sync.Mutex
+	grpcServer *grpc.Server // created by Serve
+	stopped    bool         // Stop ran, possibly before Serve
+	health     *health.Server
+}
+
+// NewChatServer creates a new ChatServer
+func NewChatServer(opts ...Option) *ChatServer {
+	s := &ChatServer{
+		connections: make(map[string]connection),
+		aliases:     make(map[string]alias),
+		renameGrace: DefaultRenameGrace,
+		calls:       callRegistry{calls: make(map[string]*call)},
+		reads:       newReadState(),
+		history:     newRoomHistory(DefaultHistorySize),
+		dedup:       newDedupCache(),
+		dedupWindow: DefaultDedupWindow,
+		ringTimeout: DefaultRingTimeout,
+		idle:        idleTracker{timeout: DefaultIdleTimeout},
+		keepalive:   DefaultKeepalive,
+		announce: announcer{
+			grace:  DefaultLeaveGrace,
+			burst:  DefaultAnnounceBurst,
+			window: DefaultAnnounceWindow,
+		},
+		prefs:         NewMemoryPreferenceStore(),
+		profiles:      NewMemoryProfileStore(),
+		contacts:      NewMemoryContactStore(),
+		drafts:        NewMemoryDraftStore(),
+		quotaStore:    NewMemoryQuotaStore(),
+		bans:          NewMemoryBanStore(),
+		blockStore:    NewMemoryBlockStore(),
+		capabilities:  pb.Capabilities(),
+		health:        health.NewServer(),
+		ids:           ids.NewULID(),
+		attachmentDir: filepath.Join(os.TempDir(), "realtimechat-attachments"),
+
+		thumbnailSizes: imaging.DefaultThumbnailSizes,
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(s)
+	}
+	if store, err := newAttachmentStore(s.attachmentDir, s.objects); err == nil {
+		s.attachments = store
+	} else {
+		log.Printf("Attachments disabled: %v", err)
+	}
+	if s.shards != nil && s.cluster == nil {
+		log.Printf("Sharding disabled: it needs a cluster")
+		s.shards = nil
+	}
+	if s.auditPath != "" {
+		s.audit.open(s.auditPath)
+	}
+	if s.scriptDir != "" {
+		s.startScripts(s.scriptDir)
+	}
+	if s.featurePath != "" {
+		s.startFeatureFlags(s.featurePath)
+	}
+	return s
+}
+
+// Serve registers the chat and health services on a new gRPC server and
+// serves lis until Stop or GracefulStop is called
+func (s *ChatServer) Serve(lis net.Listener) error {
+	s.grpcMu.Lock()
+	if s.stopped {
+		s.grpcMu.Unlock()
+		lis.Close()
+		return grpc.ErrServerStopped
+	}
+	if s.grpcServer != nil {
+		s.grpcMu.Unlock()
+		return errors.New("chatserver: Serve already called")
+	}
+	// options from WithGRPCServerOptions come last so they take precedence
+	opts := append(s.keepalive.serverOptions(),
+		grpc.ForceServerCodecV2(newFrameCodec(&s.frames)),
+		grpc.ChainUnaryInterceptor(s.auditUnary),
+		grpc.ChainStreamInterceptor(s.auditStream))
+	gs := grpc.NewServer(append(opts, s.grpcOpts...)...)
+	pb.RegisterChatServiceServer(gs, s)
+	pb.RegisterPreferencesServiceServer(gs, &preferencesServer{s: s})
+	pb.RegisterProfileServiceServer(gs, &profileServer{s: s})
+	pb.RegisterContactServiceServer(gs, &contactServer{s: s})
+	pb.RegisterThreadServiceServer(gs, &threadServer{s: s})
+	pb.RegisterDraftServiceServer(gs, &draftServer{s: s})
+	pb.RegisterMessageRequestServiceServer(gs, &messageRequestServer{s: s})
+	pb.RegisterUnreadServiceServer(gs, &unreadServer{s: s})
+	pb.RegisterHistoryServiceServer(gs, &historyServer{s: s})
+	pb.RegisterRoomServiceServer(gs, &roomServer{s: s})
+	pb.RegisterAttachmentServiceServer(gs, &attachmentServer{s: s})
+	pb.RegisterFeatureServiceServer(gs, &featureServer{s: s})
+	pb.RegisterAdminServiceServer(gs, &adminServer{s: s})
+	if s.cluster != nil {
+		pb.RegisterClusterServiceServer(gs, &clusterServer{s: s})
+	}
+	healthpb.RegisterHealthServer(gs, s.health)
+	s.health.SetServingStatus(pb.ChatService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	s.grpcServer = gs
+	s.grpcMu.Unlock()
+	go s.sweepBans()
+	if s.cluster != nil {
+		go s.runCluster()
+	}
+
+	return gs.Serve(lis)
+}
+
+// Stop closes all connections immediately
+func (s *ChatServer) Stop() {
+	s.health.Shutdown()
+	if gs := s.server(); gs != nil {
+		gs.Stop()
+	}
+}
+
+// GracefulStop reports NOT_SERVING, stops accepting streams and waits
+// for open ones to finish
+func (s *ChatServer) GracefulStop() {
+	s.health.Shutdown()
+	if gs := s.server(); gs != nil {
+		gs.GracefulStop()
+	}
+}
+
+// server marks the server stopped and returns the running grpc.Server, if any
+func (s *ChatServer) server() *grpc.Server {
+	s.grpcMu.Lock()
+	defer s.grpcMu.Unlock()
+	s.stopped = true
+	s.cancel()
+	return s.grpcServer
+}
+
+// sendRoutine sends a message to a specific stream
+func (s *ChatServer) sendRoutine(stream pb.ChatService_RealtimeChatServer, msg *pb.ChatMessage, username string) {
+	if stream.Context().Err() != nil {
+		return // the connection is gone
+	}
+	if err := stream.Send(msg); err != nil {
+		log.Printf("Failed to send PM to %s: %v", username, err)
+	}
+}
+
+// sendToUser sends msg to every connection of username, falling back to
+// the user's new name if username was recently changed with /nick. In a
+// cluster the user's connections on the other servers get it too.
+func (s *ChatServer) sendToUser(ctx context.Context, username string, msg *pb.ChatMessage) bool {
+	found := s.sendToLocalUser(ctx, username, msg)
+	if s.cluster != nil && s.forwardToUser(ctx, username, msg) {
+		found = true
+	}
+	return found
+}
+
+// sendToLocalUser is sendToUser for the connections on this server
+func (s *ChatServer) sendToLocalUser(ctx context.Context, username string, msg *pb.ChatMessage) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.sendToUserLocked(ctx, username, msg) {
+		return true
+	}
+	if a, ok := s.aliases[username]; ok && time.Now().Before(a.expires) {
+		return s.sendToUserLocked(ctx, a.user, msg)
+	}
+	return false
+}
+
+func (s *ChatServer) sendToUserLocked(ctx context.Context, username string, msg *pb.ChatMessage) bool {
+	found := false
+	for _, conn := range s.connections {
+		if conn.user == username {
+			go s.sendRoutine(conn.stream, s.withNotify(ctx, username, msg), username)
+			found = true
+		}
+	}
+	return found
+}
+
+// systemText builds a System message from an i18n key and name/value
+// argument pairs, Text carries the English rendering for older clients
+func systemText(key string, kv ...string) *pb.ChatMessage {
+	args := i18n.Args(kv...)
+	return &pb.ChatMessage{
+		User:      "System",
+		Text:      i18n.Render(i18n.DefaultLocale, key, args),
+		System:    &pb.SystemText{Key: key, Args: args},
+		Type:      pb.MessageType_TYPE_SYSTEM,
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
+
+// sendSystem sends a System message to a single stream, it is ephemeral
+// to the user of the connection
+func (s *ChatServer) sendSystem(stream pb.ChatService_RealtimeChatServer, clientID, key string, kv ...string) {
+	systemMsg := systemText(key, kv...)
+	s.mu.RLock()
+	systemMsg.EphemeralTo = s.connections[clientID].user
+	s.mu.RUnlock()
+	if err := stream.Send(systemMsg); err != nil {
+		log.Printf("Failed to send system message to %s: %v", clientID, err)
+	}
+}
+
+// RealtimeChat define in proto file
+func (s *ChatServer) RealtimeChat(stream pb.ChatService_RealtimeChatServer) error {
+	log.Println("New client connected...")
+	release, err := s.acquireStream(peerIP(stream.Context()))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// 1. accept the first message which should contain user info
+	firstMsg, err := stream.Recv()
+	if err != nil {
+		log.Printf("Failed to receive first message: %v", err)
+		return status.Error(codes.InvalidArgument, "First message must contain user info")
+	}
+	userName := firstMsg.User
+	if userName == "" {
+		return status.Error(codes.InvalidArgument, "Username cannot be empty")
+	}
+	if s.assistant != nil && strings.EqualFold(userName, AssistantName) {
+		return status.Errorf(codes.InvalidArgument, "'%s' is reserved for the assistant", userName)
+	}
+	if max := s.limits.MaxUsernameLength; max > 0 && len(userName) > max {
+		return status.Errorf(codes.InvalidArgument, "Username cannot be longer than %d bytes", max)
+	}
+	room := DefaultRoom
+	if firstMsg.Room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(firstMsg.Room); !ok {
+			return status.Errorf(codes.InvalidArgument, "'%s' is not a valid room name", firstMsg.Room)
+		}
+	}
+	if err := s.authenticate(stream.Context(), userName); err != nil {
+		log.Printf("Authentication failed for '%s': %v", userName, err)
+		return err
+	}
+	info := newSessionInfo(stream.Context())
+	if err := s.checkBans(stream.Context(), userName, info); err != nil {
+		log.Printf("Refused '%s' from %s: %v", userName, info, err)
+		return err
+	}
+	if ok, _ := s.mayEnter(userName, room, ""); !ok {
+		return status.Errorf(codes.PermissionDenied, "#%s is private, join it with an invite", room)
+	}
+	if err := s.pluginsAdmit(stream.Context(), userName, room); err != nil {
+		log.Printf("Plugin refused '%s': %v", userName, err)
+		return err
+	}
+	// a filter that cannot be applied is reported once the stream is up
+	filter, filterKey, filterArgs := checkFilter(firstMsg.GetHello().GetFilter())
+
+	// 2. create a unique client ID
+	clientID := s.newID()
+	stream, err = s.negotiate(stream, clientID, firstMsg)
+	if err != nil {
+		log.Printf("Failed to answer hello from %s: %v", clientID, err)
+		return err
+	}
+	if err := s.checkShard(stream, clientID, userName, room); err != nil {
+		return err
+	}
+
+	// 3. store connection to map
+	ctx, revoke := context.WithCancelCause(stream.Context())
+	defer revoke(nil)
+	quotas := s.roomQuotasFor(stream.Context(), userName, room)
+	s.mu.Lock()
+	if max := s.limits.MaxStreamsPerUser; max > 0 && s.userStreamsLocked(userName) >= max {
+		s.mu.Unlock()
+		s.streams.rejectedPerUser.Add(1)
+		log.Printf("Rejected stream for '%s': %d streams open", userName, max)
+		return status.Errorf(codes.ResourceExhausted, "Too many connections for '%s' (max %d)", userName, max)
+	}
+	if key, args := s.admitLocked(userName, room, quotas); key != "" {
+		s.mu.Unlock()
+		log.Printf("Rejected stream for '%s': #%s is over its quota", userName, room)
+		return status.Error(codes.ResourceExhausted, i18n.Render(i18n.DefaultLocale, key, i18n.Args(args...)))
+	}
+	first := s.userStreamsLocked(userName) == 0
+	s.connections[clientID] = connection{
+		stream: stream,
+		user:   userName,
+		room:   room,
+		filter: filter,
+		info:   info,
+		revoke: revoke,
+	}
+	s.mu.Unlock()
+	s.usage.streams(1, time.Now())
+	s.trackActivity(clientID, userName)
+	s.reads.join(userName)
+	s.reads.enter(userName, room)
+	s.memberEntered(userName, room)
+	s.abuse.joined(userName, time.Now())
+
+	log.Printf("User '%s' (ID: %s) joined #%s from %s.", userName, clientID, room, info)
+	if s.hooks.OnJoin != nil {
+		s.hooks.OnJoin(userName)
+	}
+
+	// 4. announce the join, other tabs of the same user already did
+	if first {
+		s.announceJoin(userName, clientID)
+		s.clusterJoined(userName)
+	}
+	s.sendRoster(clientID)
+	s.sendPresence(clientID)
+	s.sendWelcome(stream, clientID, room, true)
+	if filterKey != "" {
+		s.sendSystem(stream, clientID, filterKey, filterArgs...)
+	}
+	for _, r := range firstMsg.GetHello().GetRooms() {
+		s.subscribeRoom(stream, clientID, userName, r, "")
+	}
+
+	// 5. hear from client
+	incoming := receive(stream)
+	var result error
+	for {
+		var msg *pb.ChatMessage
+		select {
+		case r := <-incoming:
+			msg, err = r.msg, r.err
+		case <-ctx.Done():
+			err = context.Cause(ctx)
+		}
+		if err == io.EOF {
+			// stream close
+			break
+		}
+		if errors.Is(err, errRevoked) || isRoomMoved(err) || errors.As(err, new(banError)) {
+			result = err
+			break
+		}
+		if err != nil {
+			log.Printf("Error receiving from %s: %v", clientID, err)
+			break
+		}
+
+		// messages are always attributed to the joined user
+		msg.User = userName
+		if hb := msg.GetHeartbeat(); hb != nil {
+			// echoed to the sender only, a heartbeat is not activity
+			s.sendToConn(clientID, &pb.ChatMessage{User: "System", Type: pb.MessageType_TYPE_HEARTBEAT, Payload: &pb.ChatMessage_Heartbeat{Heartbeat: &pb.Heartbeat{SentAt: hb.SentAt}}})
+			recycle(msg)
+			continue
+		}
+		if f := msg.GetFilter(); f != nil {
+			s.setFilter(stream, clientID, f)
+			recycle(msg)
+			continue
+		}
+		if newName, ok := parseNick(msg); ok {
+			if s.rename(stream, clientID, userName, newName) {
+				userName = newName
+			}
+			continue
+		}
+		if name, invite, ok := parseRoomCommand(msg); ok {
+			if joined, ok := s.joinRoom(stream, clientID, userName, name, invite); ok {
+				room = joined
+			}
+			continue
+		}
+		if name, invite, subscribe, ok := parseSubscribeCommand(msg); ok {
+			if subscribe {
+				s.subscribeRoom(stream, clientID, userName, name, invite)
+			} else {
+				s.unsubscribeRoom(stream, clientID, userName, name)
+			}
+			continue
+		}
+		if args, ok := parseTranslate(msg); ok {
+			s.handleTranslate(stream, clientID, userName, args)
+			continue
+		}
+		if args, ok := parseSummarize(msg); ok {
+			s.handleSummarize(stream, clientID, userName, room, args)
+			continue
+		}
+		if args, ok := parseSettings(msg); ok {
+			s.handleSettings(stream, clientID, userName, room, args)
+			continue
+		}
+		if parseSessions(msg) {
+			s.listSessions(stream, clientID, userName)
+			continue
+		}
+		if parseLogoutOthers(msg) {
+			s.logoutOthers(stream, clientID, userName)
+			continue
+		}
+		if s.pluginCommand(stream, clientID, userName, room, msg) || s.chatopsCommand(stream, clientID, userName, room, msg) {
+			s.markActive(clientID)
+			continue
+		}
+		if a := msg.GetActivity(); a != nil {
+			if a.Idle {
+				s.markIdle(clientID)
+			} else {
+				s.markActive(clientID)
+			}
+			recycle(msg)
+			continue
+		}
+		s.markActive(clientID)
+		if msg.GetSignal() != nil {
+			// signaling is relayed, never stored or shown as a message
+			s.handleSignal(stream, clientID, userName, msg)
+			continue
+		}
+		switch msg.Payload.(type) {
+		case nil, *pb.ChatMessage_Code, *pb.ChatMessage_Attachment:
+		default:
+			msg.Payload = nil // events are the server's to send
+		}
+		target, ok := s.targetRoom(clientID, msg)
+		if !ok {
+			s.sendSystem(stream, clientID, i18n.NotSubscribed, "room", msg.Room)
+			continue
+		}
+		if owner := s.roomOwner(target); owner != "" && msg.RecipientUser == "" {
+			s.sendSystem(stream, clientID, i18n.RoomMovedAway, "room", target, "node", owner)
+			continue
+		}
+		if max := s.limits.MaxMessageLength; max > 0 && len(msg.Text) > max {
+			s.sendSystem(stream, clientID, i18n.MessageTooLong, "max", strconv.Itoa(max))
+			continue
+		}
+		if code := msg.GetCode(); code != nil {
+			if key, args := s.checkCode(code); key != "" {
+				s.sendSystem(stream, clientID, key, args...)
+				continue
+			}
+		}
+		if len(msg.Metadata) > 0 {
+			if key, args := s.checkMetadata(msg.Metadata); key != "" {
+				s.sendSystem(stream, clientID, key, args...)
+				continue
+			}
+		}
+		if a := msg.GetAttachment(); a != nil && (a.Id == "" || !attachmentKinds[a.Kind]) {
+			s.sendSystem(stream, clientID, i18n.AttachmentInvalid)
+			continue
+		}
+		if a := msg.GetAttachment(); a != nil && a.Kind != "gif" {
+			room := target
+			if msg.RecipientUser != "" {
+				room = "" // a private message follows its tenant, not the sender's room
+			}
+			if !s.feature(FeatureUploads, userName, room) {
+				s.sendSystem(stream, clientID, i18n.FeatureDisabled, "feature", FeatureUploads)
+				continue
+			}
+		}
+		if msg.RecipientUser == "" {
+			if key, args := s.checkRoomSettings(userName, target, msg); key != "" {
+				s.sendSystem(stream, clientID, key, args...)
+				continue
+			}
+		}
+		if msg.ThreadId != "" {
+			if msg.RecipientUser != "" || msg.EphemeralTo != "" {
+				s.sendSystem(stream, clientID, i18n.ThreadInvalid)
+				continue
+			}
+			if root := s.threadRoot(msg.ThreadId); root == nil || root.Room != target {
+				s.sendSystem(stream, clientID, i18n.ThreadNotFound, "id", msg.ThreadId)
+				continue
+			}
+		}
+		key := msg.ClientMsgId
+		if len(key) > maxClientMsgID {
+			s.sendSystem(stream, clientID, i18n.ClientMsgIDLong)
+			continue
+		}
+		if key != "" {
+			if prev, dup := s.dedup.claim(userName, key, s.dedupWindow); dup {
+				log.Printf("Dropping duplicate message %s from %s", key, userName)
+				s.ack(clientID, &pb.Ack{ClientMsgId: key, Id: prev.id, Room: prev.room, Seq: prev.seq, Duplicate: true})
+				continue
+			}
+		}
+		if reason, ok := s.filterMessage(stream.Context(), msg); !ok {
+			if key != "" {
+				s.dedup.release(userName, key)
+			}
+			s.sendSystem(stream, clientID, i18n.PluginRejected, "reason", reason)
+			continue
+		}
+		if !s.checkBlocklist(stream.Context(), msg, target) {
+			if key != "" {
+				s.dedup.release(userName, key)
+			}
+			s.sendSystem(stream, clientID, i18n.BlockRejected)
+			continue
+		}
+		if s.scripts != nil {
+			if reason, ok := s.scripts.run(msg, target); !ok {
+				if key != "" {
+					s.dedup.release(userName, key)
+				}
+				s.sendSystem(stream, clientID, i18n.ScriptDropped, "reason", reason)
+				continue
+			}
+		}
+		switch s.abuse.check(userName, msg, time.Now()) {
+		case abuseSlowed:
+			if key != "" {
+				s.dedup.release(userName, key)
+			}
+			s.sendSystem(stream, clientID, i18n.SlowMode, "seconds", strconv.Itoa(s.abuse.cfg.SlowModeInterval))
+			continue
+		case abuseShadowed:
+			s.shadowDeliver(stream, clientID, target, msg)
+			if key != "" {
+				s.dedup.record(userName, key, msg)
+				s.ack(clientID, &pb.Ack{ClientMsgId: key, Id: msg.Id, Room: msg.Room})
+			}
+			continue
+		case abuseSlowStarted:
+			s.sendSystem(stream, clientID, i18n.SlowMode, "seconds", strconv.Itoa(s.abuse.cfg.SlowModeInterval))
+		}
+		if msg.EphemeralTo != "" {
+			// delivered once, never stored or charged
+			if !s.deliverEphemeral(stream, clientID, target, msg) {
+				if key != "" {
+					s.dedup.release(userName, key)
+				}
+				continue
+			}
+			if key != "" {
+				s.dedup.record(userName, key, msg)
+				s.ack(clientID, &pb.Ack{ClientMsgId: key, Id: msg.Id, Room: msg.Room})
+			}
+			continue
+		}
+		if reject, args := s.chargeMessage(stream.Context(), msg, target); reject != "" {
+			if key != "" {
+				s.dedup.release(userName, key) // a retry may fit tomorrow's quota
+			}
+			s.sendSystem(stream, clientID, reject, args...)
+			continue
+		}
+		if msg.ThreadId != "" {
+			// replies go to the followers, the room only sees the count
+			if !s.postReply(stream.Context(), msg, target, clientID) {
+				if key != "" {
+					s.dedup.release(userName, key)
+				}
+				s.sendSystem(stream, clientID, i18n.ThreadNotFound, "id", msg.ThreadId)
+				continue
+			}
+			if key != "" {
+				s.dedup.record(userName, key, msg)
+				s.ack(clientID, &pb.Ack{ClientMsgId: key, Id: msg.Id, Room: msg.Room})
+			}
+			s.pluginsDelivered(msg)
+			continue
+		}
+		s.accept(stream.Context(), msg, target)
+		if key != "" {
+			s.dedup.record(userName, key, msg)
+		}
+		s.unfurlLinks(msg)
+
+		if msg.RecipientUser == "" {
+			// broadcast message
+			log.Printf("Broadcasting message from %s: %s", msg.User, msg.Text)
+			s.broadcastChat(stream.Context(), msg, clientID)
+			s.pushUnread(msg)
+			s.notifyKeywords(stream.Context(), msg)
+			s.autoTranslate(msg)
+			if q, ok := parseAssistant(msg); ok && s.assistant != nil {
+				s.askAssistant(msg, q)
+			}
+		} else {
+			// pm message
+			log.Printf("Private message from %s to %s", msg.User, msg.RecipientUser)
+
+			// 1. send to recipient, unless it becomes a message request
+			held := s.holdMessageRequest(stream.Context(), msg)
+			found := held || s.sendToUser(stream.Context(), msg.RecipientUser, msg)
+
+			// 2. send copy back to sender
+			if err := stream.Send(msg); err != nil {
+				log.Printf("Failed to send PM copy back to sender %s: %v", clientID, err)
+			}
+
+			// 3. notify sender if recipient not found or the message is held
+			if held {
+				s.sendSystem(stream, clientID, i18n.PMRequestHeld, "user", msg.RecipientUser)
+			} else if !found {
+				s.sendSystem(stream, clientID, i18n.RecipientOffline, "user", msg.RecipientUser)
+			}
+		}
+		if key != "" {
+			s.ack(clientID, &pb.Ack{ClientMsgId: key, Id: msg.Id, Room: msg.Room, Seq: msg.Seq})
+		}
+		s.pluginsDelivered(msg)
+	}
+
+	// 7. close connection
+	s.mu.Lock()
+	rooms := s.connections[clientID].rooms()
+	delete(s.connections, clientID)
+	last := s.userStreamsLocked(userName) == 0
+	s.mu.Unlock()
+	s.usage.streams(-1, time.Now())
+	s.untrackActivity(clientID)
+	s.endCallsFor(clientID, userName)
+	s.leaveVoice(clientID, "")
+	for _, r := range rooms {
+		s.memberLeft(userName, r)
+	}
+
+	log.Printf("User '%s' (ID: %s) disconnected.", userName, clientID)
+	if s.hooks.OnLeave != nil {
+		s.hooks.OnLeave(userName)
+	}
+
+	// 8. announce the leave once the user's last stream is gone
+	if last {
+		s.announceLeave(userName)
+		s.clusterLeft(userName)
+	}
+
+	return result
+}
+
+// received is one result of stream.Recv
+type received struct {
+	msg *pb.ChatMessage
+	err error
+}
+
+// receive reads stream in the background so the handler can stop waiting
+// for the client when the session is revoked. It stops with the stream.
+func receive(stream pb.ChatService_RealtimeChatServer) <-chan received {
+	out := make(chan received)
+	go func() {
+		for {
+			msg := messagePool.Get().(*pb.ChatMessage)
+			err := stream.RecvMsg(msg)
+			if err != nil {
+				recycle(msg)
+				msg = nil
+			}
+			select {
+			case out <- received{msg, err}:
+			case <-stream.Context().Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// attachmentKinds lists the attachment kinds clients know how to show
+var attachmentKinds = map[string]bool{"voice": true, "gif": true, "file": true}
+
+var codeLanguage = regexp.MustCompile(`^[A-Za-z0-9+#._-]{0,32}$`)
+
+// checkCode validates a code block, returning the i18n key and arguments
+// of a message for the sender when it is rejected
+func (s *ChatServer) checkCode(code *pb.Code) (string, []string) {
+	max := s.limits.MaxCodeLength
+	if max <= 0 {
+		max = DefaultMaxCodeLength
+	}
+	switch {
+	case strings.TrimSpace(code.Content) == "":
+		return i18n.CodeEmpty, nil
+	case len(code.Content) > max:
+		return i18n.CodeTooLong, []string{"max", strconv.Itoa(max)}
+	case !codeLanguage.MatchString(code.Language):
+		return i18n.CodeBadLanguage, []string{"language", code.Language}
+	}
+	return "", nil
+}
+
+// accept assigns the message ID, timestamp and the sender's room, runs the
+// message hook and persists the message
+func (s *ChatServer) accept(ctx context.Context, msg *pb.ChatMessage, room string) {
+	s.assign(msg, room)
+	s.save(ctx, msg)
+}
+
+// assign gives msg its ID, timestamp and room and, for public messages,
+// its sequence and place in the history
+func (s *ChatServer) assign(msg *pb.ChatMessage, room string) {
+	msg.Id = s.newID()
+	msg.Timestamp = time.Now().UnixMilli() // never trust the client's clock
+	msg.Type = pb.MessageType_TYPE_CHAT
+	msg.Room = ""
+	if msg.RecipientUser == "" {
+		msg.Room = room
+		// history must never miss a sequence that was already handed out
+		s.seqMu.Lock()
+		msg.Seq = s.reads.next(msg.Room, msg.User)
+		s.history.add(msg)
+		s.seqMu.Unlock()
+	}
+	s.usage.message(msg.User, msg.Room, time.UnixMilli(msg.Timestamp))
+}
+
+// save runs the message hook and persists msg
+func (s *ChatServer) save(ctx context.Context, msg *pb.ChatMessage) {
+	if s.hooks.OnMessage != nil {
+		s.hooks.OnMessage(msg)
+	}
+	if s.store != nil {
+		if err := s.store.SaveMessage(ctx, msg); err != nil {
+			log.Printf("Failed to store message from %s: %v", msg.User, err)
+		}
+	}
+}
+
+// newID returns a message ID unique across restarts
+func (s *ChatServer) newID() string {
+	return s.ids.New()
+}
+
+// broadcast message to all clients except the sender
+func (s *ChatServer) broadcast(msg *pb.ChatMessage, excludeID string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var targets []connection
+	for id, conn := range s.connections {
+		if id == excludeID || !conn.wants(msg.Room, msg) {
+			continue // skip sender and filtered connections
+		}
+		targets = append(targets, conn)
+	}
+	s.fanout(msg, targets)
+	s.watchers.deliver("", msg)
+}
+
+// broadcastChat sends a user message to the members of its room, flagging
+// the copies of recipients who should be notified of it
+func (s *ChatServer) broadcastChat(ctx context.Context, msg *pb.ChatMessage, excludeID string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var targets []connection
+	for id, conn := range s.connections {
+		if id == excludeID || !conn.in(msg.Room) || !conn.wants(msg.Room, msg) {
+			continue // skip sender, other rooms and filtered connections
+		}
+		if out := s.withNotify(ctx, conn.user, msg); out != msg {
+			go s.sendRoutine(conn.stream, out, conn.user)
+			continue
+		}
+		targets = append(targets, conn)
+	}
+	s.fanout(msg, targets)
+	s.watchers.deliver(msg.Room, msg)
+}
+
+// OnlineUsers returns the names of all connected users
+func (s *ChatServer) OnlineUsers() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool, len(s.connections))
+	users := make([]string, 0, len(s.connections))
+	for _, conn := range s.connections {
+		if !seen[conn.user] {
+			seen[conn.user] = true
+			users = append(users, conn.user)
+		}
+	}
+	return users
+}
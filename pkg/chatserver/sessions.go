@@ -0,0 +1,209 @@
+package chatserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// errRevoked ends the stream of a session that was logged out
+var errRevoked = status.Error(codes.Unauthenticated, "This session was logged out")
+
+// maxUserAgent and maxDevice cap what a session keeps of the client's
+// description
+const (
+	maxUserAgent = 256
+	maxDevice    = 64
+)
+
+// sessionInfo describes where a connection comes from, read from the
+// stream when it joins
+type sessionInfo struct {
+	since        time.Time
+	ip           string
+	forwardedFor string
+	userAgent    string
+	device       string
+}
+
+// newSessionInfo reads the peer address and the x-chat-device,
+// x-chat-user-agent, user-agent and x-forwarded-for metadata of a new
+// stream
+func newSessionInfo(ctx context.Context) sessionInfo {
+	info := sessionInfo{since: time.Now(), ip: peerIP(ctx)}
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return strings.TrimSpace(v[0])
+		}
+		return ""
+	}
+	info.userAgent = first("x-chat-user-agent")
+	if info.userAgent == "" {
+		info.userAgent = first("user-agent")
+	}
+	if len(info.userAgent) > maxUserAgent {
+		info.userAgent = truncateUTF8(info.userAgent, maxUserAgent)
+	}
+	if info.device = first("x-chat-device"); len(info.device) > maxDevice {
+		info.device = truncateUTF8(info.device, maxDevice)
+	}
+	info.forwardedFor, _, _ = strings.Cut(first("x-forwarded-for"), ",")
+	info.forwardedFor = strings.TrimSpace(info.forwardedFor)
+	return info
+}
+
+// String describes the client for logs, such as "Firefox on Linux at
+// 203.0.113.7"
+func (i sessionInfo) String() string {
+	from := i.ip
+	if i.forwardedFor != "" {
+		from = i.forwardedFor + " via " + i.ip
+	}
+	client := i.device
+	if client == "" {
+		client = i.userAgent
+	}
+	switch {
+	case client == "" && from == "":
+		return "an unknown client"
+	case client == "":
+		return from
+	case from == "":
+		return client
+	}
+	return client + " at " + from
+}
+
+// session describes the connection clientID
+func (c connection) session(clientID string) *pb.Session {
+	return &pb.Session{
+		Id:           clientID,
+		User:         c.user,
+		Room:         c.room,
+		UserAgent:    c.info.userAgent,
+		Ip:           c.info.ip,
+		ForwardedFor: c.info.forwardedFor,
+		ConnectedAt:  c.info.since.UnixMilli(),
+		Device:       c.info.device,
+	}
+}
+
+// Sessions returns the connections of user, or of everyone when user is
+// empty, oldest first
+func (s *ChatServer) Sessions(user string) []*pb.Session {
+	s.mu.RLock()
+	var out []*pb.Session
+	for id, conn := range s.connections {
+		if user == "" || conn.user == user {
+			out = append(out, conn.session(id))
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ConnectedAt != out[j].ConnectedAt {
+			return out[i].ConnectedAt < out[j].ConnectedAt
+		}
+		return out[i].Id < out[j].Id
+	})
+	return out
+}
+
+// revokeSessions ends the streams of the connections match selects with
+// cause and returns them
+func (s *ChatServer) revokeSessions(cause error, match func(id string, conn connection) bool) []*pb.Session {
+	s.mu.RLock()
+	var out []*pb.Session
+	for id, conn := range s.connections {
+		if match(id, conn) {
+			conn.revoke(cause)
+			out = append(out, conn.session(id))
+		}
+	}
+	s.mu.RUnlock()
+	for _, sess := range out {
+		log.Printf("Revoked session %s of '%s'", sess.Id, sess.User)
+	}
+	return out
+}
+
+// parseSessions reports whether msg is a public "/sessions" command
+func parseSessions(msg *pb.ChatMessage) bool {
+	return msg.RecipientUser == "" && msg.GetCode() == nil && msg.Text == "/sessions"
+}
+
+// parseLogoutOthers reports whether msg is a public "/logout-others"
+// command
+func parseLogoutOthers(msg *pb.ChatMessage) bool {
+	return msg.RecipientUser == "" && msg.GetCode() == nil && msg.Text == "/logout-others"
+}
+
+// listSessions tells the connection clientID about every session of user
+func (s *ChatServer) listSessions(stream pb.ChatService_RealtimeChatServer, clientID, user string) {
+	sessions := s.Sessions(user)
+	lines := make([]string, len(sessions))
+	for i, sess := range sessions {
+		mark := " "
+		if sess.Id == clientID {
+			mark = "*"
+		}
+		from := sess.Ip
+		if sess.ForwardedFor != "" {
+			from = sess.ForwardedFor
+		}
+		since := time.UnixMilli(sess.ConnectedAt).UTC().Format(time.RFC3339)
+		client := sess.UserAgent
+		if sess.Device != "" {
+			client = sess.Device
+		}
+		lines[i] = fmt.Sprintf("%s %s  %s  %s  %s", mark, sess.Id, since, from, client)
+	}
+	s.sendSystem(stream, clientID, i18n.SessionsList, "count", strconv.Itoa(len(sessions)), "list", strings.Join(lines, "\n"))
+}
+
+// logoutOthers revokes every session of user except clientID
+func (s *ChatServer) logoutOthers(stream pb.ChatService_RealtimeChatServer, clientID, user string) {
+	revoked := s.revokeSessions(errRevoked, func(id string, conn connection) bool {
+		return conn.user == user && id != clientID
+	})
+	s.sendSystem(stream, clientID, i18n.LoggedOutOthers, "count", strconv.Itoa(len(revoked)))
+}
+
+// ListSessions returns the sessions of a user, or all of them
+func (a *adminServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.SessionList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.SessionList{Sessions: a.s.Sessions(req.User)}, nil
+}
+
+// RevokeSession logs out one session by ID, or every session of a user
+func (a *adminServer) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.SessionList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.Id == "" && req.User == "" {
+		return nil, status.Error(codes.InvalidArgument, "id or user is required")
+	}
+	revoked := a.s.revokeSessions(errRevoked, func(id string, conn connection) bool {
+		if req.Id != "" {
+			return id == req.Id
+		}
+		return conn.user == req.User
+	})
+	if len(revoked) == 0 {
+		return nil, status.Error(codes.NotFound, "no such session")
+	}
+	return &pb.SessionList{Sessions: revoked}, nil
+}
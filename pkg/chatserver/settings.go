@@ -0,0 +1,337 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/i18n"
+	"realTimeChat/pkg/translate"
+	pb "realTimeChat/proto/chat"
+)
+
+// maxPublishers caps the publishers of a broadcast room
+const maxPublishers = 100
+
+// roomSettings holds the settings of the rooms that changed them, see
+// SetRoomSettings
+type roomSettings struct {
+	mu    sync.RWMutex
+	rooms map[string]*pb.RoomSettings
+}
+
+// get returns a copy of the settings of room, the defaults when it has
+// none
+func (r *roomSettings) get(room string) *pb.RoomSettings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getLocked(room)
+}
+
+// getLocked is get for callers holding mu
+func (r *roomSettings) getLocked(room string) *pb.RoomSettings {
+	if st, ok := r.rooms[room]; ok {
+		return proto.Clone(st).(*pb.RoomSettings)
+	}
+	return &pb.RoomSettings{Room: room}
+}
+
+// roomNames returns the rooms with settings
+func (r *roomSettings) roomNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Collect(maps.Keys(r.rooms))
+}
+
+// set replaces the settings of room, the defaults remove them
+func (r *roomSettings) set(room string, st *pb.RoomSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setLocked(room, st)
+}
+
+// setLocked is set for callers holding mu
+func (r *roomSettings) setLocked(room string, st *pb.RoomSettings) {
+	st = proto.Clone(st).(*pb.RoomSettings)
+	st.Room = room
+	if proto.Equal(st, &pb.RoomSettings{Room: room}) {
+		delete(r.rooms, room)
+		return
+	}
+	if r.rooms == nil {
+		r.rooms = make(map[string]*pb.RoomSettings)
+	}
+	r.rooms[room] = st
+}
+
+// update changes the settings of room with fn and returns the result,
+// concurrent updates of other settings are not lost
+func (r *roomSettings) update(room string, fn func(*pb.RoomSettings)) *pb.RoomSettings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.getLocked(room)
+	fn(st)
+	r.setLocked(room, st)
+	return st
+}
+
+// rename moves the publisher rights of oldName to newName, the lists stay
+// sorted and newName is listed once when it already published
+func (r *roomSettings) rename(oldName, newName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for room, st := range r.rooms {
+		if !slices.Contains(st.Publishers, oldName) {
+			continue
+		}
+		st = proto.Clone(st).(*pb.RoomSettings)
+		publishers := slices.DeleteFunc(st.Publishers, func(name string) bool { return name == oldName })
+		st.Publishers = slices.Compact(slices.Sorted(slices.Values(append(publishers, newName))))
+		r.rooms[room] = st
+	}
+}
+
+// publishes reports whether user is a publisher of any room
+func (r *roomSettings) publishes(user string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, st := range r.rooms {
+		if slices.Contains(st.Publishers, user) {
+			return true
+		}
+	}
+	return false
+}
+
+// role returns the role of user in room, ROLE_MEMBER for users who have
+// not been in it
+func (m *roomMembers) role(user, room string) pb.RoomRole {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st, ok := m.rooms[room][user]; ok {
+		return st.role
+	}
+	return pb.RoomRole_ROLE_MEMBER
+}
+
+// checkRoomSettings returns the system message key and arguments telling
+// user why msg may not be posted to room, "" when it may
+func (s *ChatServer) checkRoomSettings(user, room string, msg *pb.ChatMessage) (string, []string) {
+	st := s.settings.get(room)
+	if st.Broadcast && !slices.Contains(st.Publishers, user) && s.members.role(user, room) < pb.RoomRole_ROLE_MODERATOR {
+		return i18n.SettingsBroadcast, []string{"room", room}
+	}
+	if st.PostRole > pb.RoomRole_ROLE_MEMBER && s.members.role(user, room) < st.PostRole {
+		return i18n.SettingsPostRole, []string{"room", room, "role", roleName(st.PostRole)}
+	}
+	if a := msg.GetAttachment(); a != nil {
+		switch {
+		case st.Attachments == pb.AttachmentPolicy_ATTACHMENTS_NONE,
+			st.Attachments == pb.AttachmentPolicy_ATTACHMENTS_MEDIA && a.Kind == "file":
+			return i18n.SettingsAttachment, []string{"room", room, "kind", a.Kind}
+		}
+	}
+	return "", nil
+}
+
+// parseSettings reports whether msg is a public "/settings [<key> <value>]"
+// command, args holds whatever followed the command
+func parseSettings(msg *pb.ChatMessage) ([]string, bool) {
+	if msg.RecipientUser != "" || msg.GetCode() != nil {
+		return nil, false
+	}
+	if msg.Text == "/settings" {
+		return nil, true
+	}
+	rest, ok := strings.CutPrefix(msg.Text, "/settings ")
+	return strings.Fields(rest), ok
+}
+
+// handleSettings shows the settings of room, or changes one of them when
+// user moderates it and tells the room
+func (s *ChatServer) handleSettings(stream pb.ChatService_RealtimeChatServer, clientID, user, room string, args []string) {
+	if len(args) == 0 {
+		s.sendSystem(stream, clientID, i18n.SettingsShow, describeSettings(s.settings.get(room))...)
+		return
+	}
+	if len(args) != 2 {
+		s.sendSystem(stream, clientID, i18n.SettingsUsage)
+		return
+	}
+	if s.members.role(user, room) < pb.RoomRole_ROLE_MODERATOR {
+		s.sendSystem(stream, clientID, i18n.SettingsDenied, "room", room)
+		return
+	}
+	key, value := strings.ToLower(args[0]), strings.ToLower(args[1])
+	var change func(*pb.RoomSettings)
+	switch key {
+	case "language":
+		if value == "off" {
+			value = ""
+		} else if !translate.ValidLang(value) {
+			break
+		}
+		change = func(st *pb.RoomSettings) { st.Language = value }
+	case "nsfw":
+		if on, ok := parseSwitch(value); ok {
+			change = func(st *pb.RoomSettings) { st.Nsfw = on }
+		}
+	case "post":
+		if role, ok := pb.RoomRole_value["ROLE_"+strings.ToUpper(value)]; ok {
+			change = func(st *pb.RoomSettings) { st.PostRole = pb.RoomRole(role) }
+		}
+	case "broadcast":
+		if on, ok := parseSwitch(value); ok {
+			change = func(st *pb.RoomSettings) { st.Broadcast = on }
+		}
+	case "publishers":
+		var names []string
+		if value != "none" {
+			names = slices.Compact(slices.Sorted(slices.Values(strings.Split(args[1], ","))))
+		}
+		if validPublishers(names) == nil {
+			change = func(st *pb.RoomSettings) { st.Publishers = names }
+		}
+	case "attachments":
+		if policy, ok := pb.AttachmentPolicy_value["ATTACHMENTS_"+strings.ToUpper(value)]; ok {
+			change = func(st *pb.RoomSettings) { st.Attachments = pb.AttachmentPolicy(policy) }
+		}
+	default:
+		s.sendSystem(stream, clientID, i18n.SettingsUsage)
+		return
+	}
+	if change == nil {
+		s.sendSystem(stream, clientID, i18n.SettingsBadValue, "key", key, "value", args[1])
+		return
+	}
+	st := s.settings.update(room, change)
+	log.Printf("Settings of #%s changed by '%s': %s %s", room, user, key, value)
+	s.noticeRoom(room, systemText(i18n.SettingsChanged, append([]string{"user", user}, describeSettings(st)...)...))
+}
+
+// parseSwitch reads the on/off value of a setting
+func parseSwitch(value string) (bool, bool) {
+	switch value {
+	case "on", "yes", "true":
+		return true, true
+	case "off", "no", "false":
+		return false, true
+	}
+	return false, false
+}
+
+// describeSettings returns the system message arguments for st
+func describeSettings(st *pb.RoomSettings) []string {
+	language, publishers := st.Language, strings.Join(st.Publishers, ", ")
+	if language == "" {
+		language = "-"
+	}
+	if publishers == "" {
+		publishers = "-"
+	}
+	return []string{
+		"room", st.Room,
+		"language", language,
+		"nsfw", switchName(st.Nsfw),
+		"post", roleName(st.PostRole),
+		"attachments", strings.ToLower(strings.TrimPrefix(st.Attachments.String(), "ATTACHMENTS_")),
+		"broadcast", switchName(st.Broadcast),
+		"publishers", publishers,
+	}
+}
+
+func switchName(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// validPublishers checks the publishers of a broadcast room
+func validPublishers(names []string) error {
+	if len(names) > maxPublishers {
+		return status.Errorf(codes.InvalidArgument, "at most %d publishers", maxPublishers)
+	}
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, " \t\r\n") {
+			return status.Errorf(codes.InvalidArgument, "%q is not a valid publisher", name)
+		}
+	}
+	return nil
+}
+
+// roleName is the name of role in commands and system messages
+func roleName(role pb.RoomRole) string {
+	return strings.ToLower(strings.TrimPrefix(role.String(), "ROLE_"))
+}
+
+// noticeRoom sends a system message to the connections in room and its
+// watchers without keeping it
+func (s *ChatServer) noticeRoom(room string, msg *pb.ChatMessage) {
+	msg.Room = room
+	s.broadcastRoom(room, msg, "")
+}
+
+// settingsRoom validates the room of a settings request, empty stands for
+// DefaultRoom
+func settingsRoom(name string) (string, error) {
+	if name == "" {
+		return DefaultRoom, nil
+	}
+	room, ok := normalizeRoom(name)
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "%q is not a valid room name", name)
+	}
+	return room, nil
+}
+
+// GetRoomSettings returns the settings of a room, the admin token is
+// needed for private rooms
+func (r *roomServer) GetRoomSettings(ctx context.Context, req *pb.RoomSettingsRequest) (*pb.RoomSettings, error) {
+	room, err := settingsRoom(req.Room)
+	if err != nil {
+		return nil, err
+	}
+	if r.s.access.isPrivate(room) && (&adminServer{s: r.s}).authorize(ctx) != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "#%s is private", room)
+	}
+	return r.s.settings.get(room), nil
+}
+
+// SetRoomSettings replaces the settings of a room
+func (a *adminServer) SetRoomSettings(ctx context.Context, req *pb.RoomSettings) (*pb.RoomSettings, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	room, err := settingsRoom(req.Room)
+	if err != nil {
+		return nil, err
+	}
+	st := proto.Clone(req).(*pb.RoomSettings)
+	st.Room = room
+	st.Language = strings.ToLower(st.Language)
+	if st.Language != "" && !translate.ValidLang(st.Language) {
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid language", req.Language)
+	}
+	if _, known := pb.RoomRole_name[int32(st.PostRole)]; !known {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role %d", st.PostRole)
+	}
+	if _, known := pb.AttachmentPolicy_name[int32(st.Attachments)]; !known {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown attachment policy %d", st.Attachments)
+	}
+	if err := validPublishers(st.Publishers); err != nil {
+		return nil, err
+	}
+	st.Publishers = slices.Compact(slices.Sorted(slices.Values(st.Publishers)))
+	a.s.settings.set(room, st)
+	log.Printf("Settings of #%s set: %v", room, describeSettings(st))
+	a.s.noticeRoom(room, systemText(i18n.SettingsChanged, append([]string{"user", "admin"}, describeSettings(st)...)...))
+	return a.s.settings.get(room), nil
+}
@@ -0,0 +1,255 @@
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	"realTimeChat/pkg/shard"
+	pb "realTimeChat/proto/chat"
+)
+
+// DefaultShardGrace is how long the streams in a room that moved to
+// another server stay open for their clients to follow it
+const DefaultShardGrace = 10 * time.Second
+
+// errRoomMoved ends the streams left in a room after it moved
+var errRoomMoved = status.Error(codes.Unavailable, "The room moved to another server")
+
+// Sharding assigns each room to one server of a Cluster by consistent
+// hashing, instead of every server serving every room. The nodes of the
+// ring are the Cluster Node.Addr of each server, so clients and gateways
+// can connect to a room's owner directly.
+type Sharding struct {
+	Ring  *shard.Ring
+	Grace time.Duration // 0 means DefaultShardGrace
+}
+
+// WithSharding serves only the rooms sh.Ring assigns to this server. It
+// needs WithCluster, which also carries the private messages between
+// the servers.
+func WithSharding(sh Sharding) Option {
+	return func(s *ChatServer) {
+		if sh.Grace <= 0 {
+			sh.Grace = DefaultShardGrace
+		}
+		s.shards = &shardState{grace: sh.Grace}
+		s.shards.ring.Store(sh.Ring)
+	}
+}
+
+// shardState is the server's side of its Sharding
+type shardState struct {
+	ring  atomic.Pointer[shard.Ring]
+	grace time.Duration
+	mu    sync.Mutex // one topology change at a time
+}
+
+// roomOwner returns the address of the server serving room, "" when it
+// is this one
+func (s *ChatServer) roomOwner(room string) string {
+	if s.shards == nil {
+		return ""
+	}
+	owner := s.shards.ring.Load().Owner(room)
+	if owner == s.cluster.cfg.Node.Addr {
+		return ""
+	}
+	return owner
+}
+
+// roomMoved tells clientID that room is served by owner, clients that
+// enabled pb.CapRoomMoved can follow it there
+func (s *ChatServer) roomMoved(stream pb.ChatService_RealtimeChatServer, clientID, user, room, owner, key string) {
+	msg := systemText(key, "room", room, "node", owner)
+	msg.Room = room
+	msg.Type = pb.MessageType_TYPE_ROOM_MOVED
+	msg.Payload = &pb.ChatMessage_RoomMoved{RoomMoved: &pb.RoomMoved{Room: room, Node: owner}}
+	msg.EphemeralTo = user
+	if err := stream.Send(msg); err != nil {
+		log.Printf("Failed to tell %s that #%s moved: %v", clientID, room, err)
+	}
+}
+
+// SetShards switches the server to ring. The rooms it no longer serves
+// are handed to their new servers with their recent history, sequence,
+// members, privacy and invites; their streams are told where the room
+// went and closed after the grace period.
+func (s *ChatServer) SetShards(ring *shard.Ring) {
+	if s.shards == nil || s.cluster == nil {
+		return
+	}
+	sh := s.shards
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if old := sh.ring.Swap(ring); old.Equal(ring) {
+		return
+	}
+	log.Printf("Serving the rooms of %d servers: %v", len(ring.Nodes()), ring.Nodes())
+
+	rooms := make(map[string]bool)
+	for _, room := range s.history.roomNames() {
+		rooms[room] = true
+	}
+	for _, room := range s.members.roomNames() {
+		rooms[room] = true
+	}
+	s.mu.RLock()
+	for _, conn := range s.connections {
+		for _, room := range conn.rooms() {
+			rooms[room] = true
+		}
+	}
+	s.mu.RUnlock()
+	for room := range rooms {
+		if owner := s.roomOwner(room); owner != "" {
+			s.handOff(room, owner)
+		}
+	}
+}
+
+// handOff moves room to owner
+func (s *ChatServer) handOff(room, owner string) {
+	state := s.roomState(room)
+	if err := s.transferRoom(state, owner); err != nil {
+		// the room still moves, the new server starts it afresh
+		log.Printf("Failed to hand #%s over to %s: %v", room, owner, err)
+	} else {
+		log.Printf("Handed #%s over to %s with %d messages and %d members", room, owner, len(state.History), len(state.Members))
+	}
+	s.history.drop(room)
+	s.members.drop(room)
+	s.access.dropRoom(room)
+
+	var moved []string
+	s.mu.Lock()
+	for id, conn := range s.connections {
+		switch {
+		case conn.room == room:
+			moved = append(moved, id)
+		case conn.subs[room]:
+			conn.subs = maps.Clone(conn.subs)
+			delete(conn.subs, room)
+			s.connections[id] = conn
+			go s.sendSubscriptions(id)
+		}
+	}
+	s.mu.Unlock()
+	for _, id := range moved {
+		s.mu.RLock()
+		conn := s.connections[id]
+		s.mu.RUnlock()
+		s.roomMoved(conn.stream, id, conn.user, room, owner, i18n.RoomMovedAway)
+	}
+	if len(moved) > 0 {
+		time.AfterFunc(s.shards.grace, func() { s.closeMoved(room) })
+	}
+}
+
+// closeMoved ends the streams still in room once it moved away
+func (s *ChatServer) closeMoved(room string) {
+	if s.roomOwner(room) == "" {
+		return // it came back
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, conn := range s.connections {
+		if conn.room == room {
+			conn.revoke(errRoomMoved)
+		}
+	}
+}
+
+// roomState collects what the server keeps of room
+func (s *ChatServer) roomState(room string) *pb.RoomState {
+	s.seqMu.Lock() // the sequence and the history match
+	state := &pb.RoomState{
+		Room:     room,
+		Seq:      s.reads.latestSeq(room),
+		History:  s.history.latest(room, s.history.size),
+		Private:  s.access.isPrivate(room),
+		Members:  s.members.list(room),
+		Invites:  s.access.roomInvites(room, time.Now()),
+		FromNode: s.cluster.cfg.Node.ID,
+		Settings: s.settings.get(room),
+	}
+	s.seqMu.Unlock()
+	return state
+}
+
+func (s *ChatServer) transferRoom(state *pb.RoomState, owner string) error {
+	conn, err := s.cluster.peer(owner)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, peerTimeout)
+	defer cancel()
+	if secret := s.cluster.cfg.Secret; secret != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+secret)
+	}
+	_, err = pb.NewClusterServiceClient(conn).TransferRoom(ctx, state)
+	return err
+}
+
+// TransferRoom takes over a room another server no longer serves
+func (cs *clusterServer) TransferRoom(ctx context.Context, state *pb.RoomState) (*pb.RoomStateAck, error) {
+	if err := cs.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s := cs.s
+	room, ok := normalizeRoom(state.Room)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "'%s' is not a valid room name", state.Room)
+	}
+	if s.shards == nil {
+		return nil, status.Error(codes.FailedPrecondition, "this server is not sharded")
+	}
+	// the sender may have read the new topology first, the room is kept
+	// for when this server does
+	var history []*pb.ChatMessage
+	for _, msg := range state.History {
+		if msg.Room == room && msg.Seq > 0 {
+			history = append(history, msg)
+		}
+	}
+	s.seqMu.Lock()
+	s.reads.raiseSeq(room, state.Seq)
+	s.history.restore(room, history)
+	s.seqMu.Unlock()
+	s.members.restore(room, state.Members)
+	if state.Private {
+		s.access.setPrivate(room, true)
+	}
+	s.access.addInvites(state.Invites)
+	if state.Settings != nil {
+		s.settings.set(room, state.Settings)
+	}
+	log.Printf("Took #%s over from %s with %d messages and %d members", room, state.FromNode, len(history), len(state.Members))
+	return &pb.RoomStateAck{}, nil
+}
+
+// checkShard refuses a stream opening in a room another server serves,
+// after telling the client where to go. Clients that cannot follow
+// retry, the servers may not agree on the topology yet.
+func (s *ChatServer) checkShard(stream pb.ChatService_RealtimeChatServer, clientID, user, room string) error {
+	owner := s.roomOwner(room)
+	if owner == "" {
+		return nil
+	}
+	s.roomMoved(stream, clientID, user, room, owner, i18n.RoomElsewhere)
+	return status.Errorf(codes.Unavailable, "#%s is served by %s", room, owner)
+}
+
+// isRoomMoved reports whether err ended a stream because its room moved
+func isRoomMoved(err error) bool {
+	return errors.Is(err, errRoomMoved)
+}
@@ -0,0 +1,480 @@
+package chatserver
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// maxSnapshotMeta caps the metadata of one attachment in a snapshot
+const maxSnapshotMeta = 64 << 10
+
+// Snapshot streams everything the server keeps: users, rooms, their
+// messages, the metadata of the attachments those reference, bans,
+// blocklist rules, the message of the day and tenant quotas. Records
+// come in that order so Restore can take them back one at a time.
+func (a *adminServer) Snapshot(_ *pb.SnapshotRequest, stream pb.AdminService_SnapshotServer) error {
+	ctx := stream.Context()
+	if err := a.authorize(ctx); err != nil {
+		return err
+	}
+	s := a.s
+	bans, err := s.bans.Bans(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "bans: %v", err)
+	}
+	rules, err := s.blockStore.BlockRules(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "block rules: %v", err)
+	}
+	rooms, err := s.snapshotRooms(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "store: %v", err)
+	}
+
+	users := make(map[string]bool)
+	for _, room := range rooms {
+		for user := range s.members.snapshot(room) {
+			users[user] = true
+		}
+	}
+	for _, ban := range bans {
+		if ban.Scope == pb.BanScope_BAN_ACCOUNT {
+			users[ban.Target] = true
+		}
+	}
+	for _, user := range s.localUsers() {
+		users[user] = true
+	}
+	tenants := map[string]bool{DefaultTenant: true}
+	for _, user := range slices.Sorted(maps.Keys(users)) {
+		tenants[s.tenant(user)] = true
+		rec, err := s.snapshotUser(ctx, user)
+		if err != nil {
+			return status.Errorf(codes.Internal, "user %s: %v", user, err)
+		}
+		if rec == nil {
+			continue
+		}
+		if err := stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_User{User: rec}}); err != nil {
+			return err
+		}
+	}
+
+	for _, room := range rooms {
+		rec, err := s.snapshotRoom(ctx, room)
+		if err != nil {
+			return status.Errorf(codes.Internal, "room %s: %v", room, err)
+		}
+		if err := stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_Room{Room: rec}}); err != nil {
+			return err
+		}
+	}
+
+	var attachments []string
+	seen := make(map[string]bool)
+	send := func(msg *pb.ChatMessage) error {
+		if att := msg.GetAttachment(); att != nil && att.Id != "" && !seen[att.Id] {
+			seen[att.Id] = true
+			attachments = append(attachments, att.Id)
+		}
+		return stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_Message{Message: msg}})
+	}
+	rr, _ := s.store.(RoomReader)
+	for _, room := range rooms {
+		if rr != nil {
+			err = rr.RoomMessages(withReadQuery(ctx, QueryExport), room, time.Time{}, time.Time{}, send)
+		} else {
+			for _, msg := range s.history.latest(room, s.history.size) {
+				if err = send(msg); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, id := range attachments {
+		rec, err := s.snapshotAttachment(ctx, id)
+		if err != nil {
+			log.Printf("Snapshot skips attachment %s: %v", id, err)
+			continue
+		}
+		if err := stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_Attachment{Attachment: rec}}); err != nil {
+			return err
+		}
+	}
+	for _, ban := range bans {
+		if err := stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_Ban{Ban: ban}}); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules {
+		if err := stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_BlockRule{BlockRule: rule}}); err != nil {
+			return err
+		}
+	}
+	if motd := s.welcomes.get(""); len(motd) > 0 {
+		if err := stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_Motd{Motd: &pb.Welcome{Messages: motd}}}); err != nil {
+			return err
+		}
+	}
+	for _, tenant := range slices.Sorted(maps.Keys(tenants)) {
+		q, err := s.quotaStore.GetQuota(ctx, pb.QuotaScope_QUOTA_TENANT, tenant)
+		if err != nil {
+			return status.Errorf(codes.Internal, "quota store: %v", err)
+		}
+		if q == nil {
+			continue
+		}
+		req := &pb.SetQuotaRequest{Scope: pb.QuotaScope_QUOTA_TENANT, Name: tenant, Quota: q}
+		if err := stream.Send(&pb.SnapshotRecord{Record: &pb.SnapshotRecord_TenantQuota{TenantQuota: req}}); err != nil {
+			return err
+		}
+	}
+	log.Printf("Sent a snapshot of %d users, %d rooms and %d attachments", len(users), len(rooms), len(attachments))
+	return nil
+}
+
+// snapshotRooms returns every room the server or its store knows, sorted
+func (s *ChatServer) snapshotRooms(ctx context.Context) ([]string, error) {
+	rooms := make(map[string]bool)
+	for _, names := range [][]string{s.members.roomNames(), s.history.roomNames(), s.access.privateRooms(), s.welcomes.roomNames(), s.settings.roomNames()} {
+		for _, room := range names {
+			rooms[room] = true
+		}
+	}
+	if rl, ok := s.store.(RoomLister); ok {
+		names, err := rl.Rooms(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, room := range names {
+			rooms[room] = true
+		}
+	}
+	delete(rooms, "") // the message of the day has its own record
+	return slices.Sorted(maps.Keys(rooms)), nil
+}
+
+// snapshotUser collects what the server stores of user, nil when nothing
+func (s *ChatServer) snapshotUser(ctx context.Context, user string) (*pb.SnapshotUser, error) {
+	rec := &pb.SnapshotUser{User: user}
+	profile, err := s.profiles.GetProfile(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if profile.GetPinned() != nil {
+		rec.Profile = &pb.Profile{User: user, Pinned: profile.Pinned, PinnedAt: profile.PinnedAt}
+	}
+	if rec.Preferences, err = s.prefs.GetPreferences(ctx, user); err != nil {
+		return nil, err
+	}
+	if rec.Contacts, err = s.contacts.Contacts(ctx, user); err != nil {
+		return nil, err
+	}
+	if rec.Drafts, err = s.drafts.Drafts(ctx, user); err != nil {
+		return nil, err
+	}
+	if rec.Profile == nil && rec.Preferences == nil && len(rec.Contacts) == 0 && len(rec.Drafts) == 0 {
+		return nil, nil
+	}
+	return rec, nil
+}
+
+// snapshotRoom collects the state of room, its messages are sent apart
+func (s *ChatServer) snapshotRoom(ctx context.Context, room string) (*pb.SnapshotRoom, error) {
+	quota, err := s.quotaStore.GetQuota(ctx, pb.QuotaScope_QUOTA_ROOM, room)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SnapshotRoom{
+		Room:     room,
+		Seq:      s.reads.latestSeq(room),
+		Members:  s.members.list(room),
+		Private:  s.access.isPrivate(room),
+		Invites:  s.access.roomInvites(room, time.Now()),
+		Welcome:  s.welcomes.get(room),
+		Quota:    quota,
+		Settings: s.settings.get(room),
+	}, nil
+}
+
+// snapshotAttachment reads the metadata of a finished or quarantined file
+func (s *ChatServer) snapshotAttachment(ctx context.Context, id string) (*pb.SnapshotAttachment, error) {
+	if s.attachments == nil {
+		return nil, errors.New("no attachment store")
+	}
+	rec := &pb.SnapshotAttachment{Id: id}
+	r, err := s.attachments.objects.Get(ctx, id+".json", 0)
+	if err != nil {
+		rec.Quarantined = true
+		if r, err = s.attachments.objects.Get(ctx, "quarantine/"+id+".json", 0); err != nil {
+			return nil, err
+		}
+	}
+	defer r.Close()
+	rec.Meta, err = io.ReadAll(io.LimitReader(r, maxSnapshotMeta))
+	return rec, err
+}
+
+// Restore takes back the records of a Snapshot. What is already there
+// is kept: messages with a known ID and attachments with metadata are
+// skipped, everything else is added or replaces its current value.
+func (a *adminServer) Restore(stream pb.AdminService_RestoreServer) error {
+	ctx := stream.Context()
+	if err := a.authorize(ctx); err != nil {
+		return err
+	}
+	r := &restore{s: a.s, summary: &pb.RestoreSummary{}}
+	for {
+		rec, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		ok, err := r.apply(ctx, rec)
+		if err != nil {
+			return status.Errorf(codes.Internal, "restore: %v", err)
+		}
+		if !ok {
+			r.summary.Skipped++
+		}
+	}
+	r.flush()
+	sum := r.summary
+	log.Printf("Restored %d users, %d rooms, %d messages, %d attachments, %d bans and %d block rules, skipped %d",
+		sum.Users, sum.Rooms, sum.Messages, sum.Attachments, sum.Bans, sum.BlockRules, sum.Skipped)
+	return stream.SendAndClose(sum)
+}
+
+// restore is one Restore call
+type restore struct {
+	s       *ChatServer
+	summary *pb.RestoreSummary
+
+	room    string          // whose messages are arriving
+	known   map[string]bool // IDs the store has of room
+	history []*pb.ChatMessage
+}
+
+// apply writes one record back, reporting false for one it skipped
+func (r *restore) apply(ctx context.Context, rec *pb.SnapshotRecord) (bool, error) {
+	s := r.s
+	switch rec := rec.Record.(type) {
+	case *pb.SnapshotRecord_User:
+		return r.user(ctx, rec.User)
+	case *pb.SnapshotRecord_Room:
+		return r.restoreRoom(ctx, rec.Room)
+	case *pb.SnapshotRecord_Message:
+		return r.message(ctx, rec.Message)
+	case *pb.SnapshotRecord_Attachment:
+		return r.attachment(ctx, rec.Attachment)
+	case *pb.SnapshotRecord_Ban:
+		ban := rec.Ban
+		target, err := normalizeBanTarget(ban.Scope, ban.Target)
+		if ban.Id == "" || err != nil {
+			return false, nil
+		}
+		ban.Target = target
+		if err := s.bans.PutBan(ctx, ban); err != nil {
+			return false, err
+		}
+		r.summary.Bans++
+	case *pb.SnapshotRecord_BlockRule:
+		rule := rec.BlockRule
+		if _, err := compileBlockRule(rule); rule.Id == "" || err != nil {
+			return false, nil
+		}
+		if err := s.blockStore.PutBlockRule(ctx, rule); err != nil {
+			return false, err
+		}
+		s.blocks.invalidate()
+		r.summary.BlockRules++
+	case *pb.SnapshotRecord_Motd:
+		s.welcomes.set("", rec.Motd.Messages)
+	case *pb.SnapshotRecord_TenantQuota:
+		q := rec.TenantQuota
+		if q.Scope != pb.QuotaScope_QUOTA_TENANT || q.Name == "" || q.Quota == nil {
+			return false, nil
+		}
+		if err := s.quotaStore.SetQuota(ctx, q.Scope, q.Name, q.Quota); err != nil {
+			return false, err
+		}
+	default:
+		return false, nil // written by a newer server
+	}
+	return true, nil
+}
+
+func (r *restore) user(ctx context.Context, rec *pb.SnapshotUser) (bool, error) {
+	s := r.s
+	if rec.User == "" {
+		return false, nil
+	}
+	if p := rec.Preferences; p != nil {
+		p.User = rec.User
+		if validatePreferences(p) != nil {
+			return false, nil
+		}
+	}
+	if p := rec.Profile; p.GetPinned() != nil {
+		p.User = rec.User
+		if err := s.profiles.SetProfile(ctx, p); err != nil {
+			return false, err
+		}
+	}
+	if p := rec.Preferences; p != nil {
+		if err := s.prefs.SetPreferences(ctx, p); err != nil {
+			return false, err
+		}
+	}
+	for _, contact := range rec.Contacts {
+		if validateContact(&pb.ContactRequest{User: rec.User, Contact: contact}) != nil {
+			continue
+		}
+		if err := s.contacts.AddContact(ctx, rec.User, contact); err != nil {
+			return false, err
+		}
+	}
+	for _, d := range rec.Drafts {
+		conv, err := draftConversation(d.Conversation)
+		if err != nil || d.UpdatedAt <= 0 {
+			continue
+		}
+		if _, err := s.drafts.SaveDraft(ctx, rec.User, &pb.Draft{Conversation: conv, Text: d.Text, UpdatedAt: d.UpdatedAt}); err != nil {
+			return false, err
+		}
+	}
+	r.summary.Users++
+	return true, nil
+}
+
+func (r *restore) restoreRoom(ctx context.Context, rec *pb.SnapshotRoom) (bool, error) {
+	s := r.s
+	room, ok := normalizeRoom(rec.Room)
+	if !ok || room != rec.Room {
+		return false, nil
+	}
+	if rec.Quota != nil {
+		if err := s.quotaStore.SetQuota(ctx, pb.QuotaScope_QUOTA_ROOM, room, rec.Quota); err != nil {
+			return false, err
+		}
+	}
+	s.seqMu.Lock()
+	s.reads.raiseSeq(room, rec.Seq)
+	s.seqMu.Unlock()
+	s.members.restore(room, rec.Members)
+	if rec.Private {
+		s.access.setPrivate(room, true)
+	}
+	var invites []*pb.Invite
+	for _, inv := range rec.Invites {
+		if inv.Room == room && inv.Token != "" {
+			invites = append(invites, inv)
+		}
+	}
+	s.access.addInvites(invites)
+	if len(rec.Welcome) > 0 {
+		s.welcomes.set(room, rec.Welcome)
+	}
+	if rec.Settings != nil {
+		s.settings.set(room, rec.Settings)
+	}
+	r.summary.Rooms++
+	return true, nil
+}
+
+// message saves a message unless the store has it and keeps it in the
+// history when it had a sequence. The messages of a room arrive
+// together, the IDs the store has are read once for each room.
+func (r *restore) message(ctx context.Context, msg *pb.ChatMessage) (bool, error) {
+	s := r.s
+	if msg.Id == "" || msg.RecipientUser != "" {
+		return false, nil
+	}
+	room, ok := normalizeRoom(msg.Room)
+	if !ok {
+		return false, nil
+	}
+	if room != r.room {
+		r.flush()
+		r.room, r.known = room, make(map[string]bool)
+		if rr, ok := s.store.(RoomReader); ok {
+			err := rr.RoomMessages(ctx, room, time.Time{}, time.Time{}, func(m *pb.ChatMessage) error {
+				r.known[m.Id] = true
+				return nil
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	if r.known[msg.Id] {
+		return false, nil
+	}
+	r.known[msg.Id] = true
+	if s.store != nil {
+		if err := s.store.SaveMessage(ctx, msg); err != nil {
+			return false, err
+		}
+	}
+	if msg.Seq > 0 {
+		r.history = append(r.history, msg)
+	}
+	r.summary.Messages++
+	return true, nil
+}
+
+// flush keeps the messages of the last room in the history
+func (r *restore) flush() {
+	if len(r.history) == 0 {
+		return
+	}
+	s := r.s
+	slices.SortFunc(r.history, func(a, b *pb.ChatMessage) int { return cmp.Compare(a.Seq, b.Seq) })
+	s.seqMu.Lock()
+	s.reads.raiseSeq(r.room, r.history[len(r.history)-1].Seq)
+	s.history.restore(r.room, r.history)
+	s.seqMu.Unlock()
+	r.history = nil
+}
+
+// attachment puts back the metadata of a file, the file itself is
+// restored from the attachment storage's own backups
+func (r *restore) attachment(ctx context.Context, rec *pb.SnapshotAttachment) (bool, error) {
+	st := r.s.attachments
+	if st == nil || !attachmentIDPattern.MatchString(rec.Id) || len(rec.Meta) == 0 || len(rec.Meta) > maxSnapshotMeta {
+		return false, nil
+	}
+	key := rec.Id + ".json"
+	if rec.Quarantined {
+		key = "quarantine/" + key
+	}
+	if _, err := st.objects.Stat(ctx, key); err == nil {
+		return false, nil
+	}
+	if err := st.objects.Put(ctx, key, bytes.NewReader(rec.Meta), int64(len(rec.Meta)), "application/json"); err != nil {
+		return false, err
+	}
+	if err := st.objects.Commit(ctx, key); err != nil {
+		return false, err
+	}
+	r.summary.Attachments++
+	return true, nil
+}
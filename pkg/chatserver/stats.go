@@ -0,0 +1,361 @@
+package chatserver
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// statsRetention is how long hourly usage statistics are kept
+	statsRetention = 90 * 24 * time.Hour
+	// defaultTopRooms is the number of rooms GetStats ranks by default
+	defaultTopRooms = 10
+	// rateWindow is the longest span, in seconds, the message rate of
+	// WatchStats averages over
+	rateWindow = 60
+	// defaultWatchInterval is how often WatchStats sends a snapshot
+	defaultWatchInterval = 5 * time.Second
+)
+
+// usageStats aggregates the live message and connection events into UTC
+// hours. Hours without events are not stored, their connection count is
+// the one the previous stored hour ended with.
+type usageStats struct {
+	mu     sync.Mutex
+	hours  map[int64]*hourStats    // keyed by the hour's start in Unix seconds
+	conns  int                     // open chat streams now
+	total  int64                   // messages since the server started
+	recent [rateWindow]secondCount // indexed by the second modulo rateWindow
+}
+
+// secondCount is the messages of one second
+type secondCount struct {
+	sec int64 // Unix second
+	n   int64
+}
+
+// hourStats is one hour of usage
+type hourStats struct {
+	messages int64
+	rooms    map[string]int64    // public messages per room
+	users    map[string]struct{} // users who sent a message
+	peak     int
+	peakAt   time.Time
+	last     int // open streams when the hour's last event happened
+}
+
+// hour returns the bucket for t, creating it and dropping expired ones.
+// Callers hold u.mu.
+func (u *usageStats) hour(t time.Time) *hourStats {
+	key := t.Truncate(time.Hour).Unix()
+	if h, ok := u.hours[key]; ok {
+		return h
+	}
+	if u.hours == nil {
+		u.hours = make(map[int64]*hourStats)
+	}
+	expired := t.Add(-statsRetention).Unix()
+	for k := range u.hours {
+		if k < expired {
+			delete(u.hours, k)
+		}
+	}
+	h := &hourStats{
+		rooms:  make(map[string]int64),
+		users:  make(map[string]struct{}),
+		peak:   u.conns,
+		peakAt: t,
+		last:   u.conns,
+	}
+	u.hours[key] = h
+	return h
+}
+
+// message counts an accepted message, room is empty for private ones
+func (u *usageStats) message(user, room string, t time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	h := u.hour(t)
+	h.messages++
+	h.users[user] = struct{}{}
+	u.total++
+	sec := t.Unix()
+	if r := &u.recent[sec%rateWindow]; r.sec == sec {
+		r.n++
+	} else {
+		r.sec, r.n = sec, 1
+	}
+	if room != "" {
+		h.rooms[room]++
+	}
+}
+
+// streams records a change in the number of open chat streams
+func (u *usageStats) streams(delta int, t time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.conns += delta
+	h := u.hour(t)
+	h.last = u.conns
+	if u.conns > h.peak {
+		h.peak, h.peakAt = u.conns, t
+	}
+}
+
+// live returns the open streams, the messages since the server started
+// and their rate over the last window seconds, at most rateWindow
+func (u *usageStats) live(now time.Time, window int64) (conns int, total int64, rate float64) {
+	window = min(max(window, 1), rateWindow)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var n int64
+	for _, r := range u.recent {
+		if r.sec > now.Unix()-window && r.sec <= now.Unix() {
+			n += r.n
+		}
+	}
+	return u.conns, u.total, float64(n) / float64(window)
+}
+
+// query summarises [from, to) in hourly or daily buckets, the range is
+// widened to whole buckets
+func (u *usageStats) query(from, to time.Time, daily bool, top int) *pb.Stats {
+	step := time.Hour
+	if daily {
+		step = 24 * time.Hour
+	}
+	from, to = from.UTC().Truncate(step), to.UTC()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// the connection count carried into the range
+	carry, carryKey := 0, int64(0)
+	for k, h := range u.hours {
+		if k < from.Unix() && k >= carryKey {
+			carry, carryKey = h.last, k
+		}
+	}
+
+	out := &pb.Stats{}
+	users := make(map[string]struct{})
+	rooms := make(map[string]int64)
+	for start := from; start.Before(to); start = start.Add(step) {
+		b := &pb.StatsBucket{Start: start.UnixMilli()}
+		bucketUsers := make(map[string]struct{})
+		for hr := start; hr.Before(start.Add(step)) && hr.Before(to); hr = hr.Add(time.Hour) {
+			h, ok := u.hours[hr.Unix()]
+			if !ok {
+				// no stream opened or closed, the count held all hour
+				b.PeakConcurrency = max(b.PeakConcurrency, int32(carry))
+				if int32(carry) > out.PeakConcurrency {
+					out.PeakConcurrency, out.PeakAt = int32(carry), hr.UnixMilli()
+				}
+				continue
+			}
+			b.Messages += h.messages
+			for user := range h.users {
+				bucketUsers[user] = struct{}{}
+				users[user] = struct{}{}
+			}
+			for room, n := range h.rooms {
+				rooms[room] += n
+			}
+			b.PeakConcurrency = max(b.PeakConcurrency, int32(h.peak))
+			if int32(h.peak) > out.PeakConcurrency {
+				out.PeakConcurrency, out.PeakAt = int32(h.peak), h.peakAt.UnixMilli()
+			}
+			carry = h.last
+		}
+		b.ActiveUsers = int32(len(bucketUsers))
+		out.Messages += b.Messages
+		out.Buckets = append(out.Buckets, b)
+	}
+	out.ActiveUsers = int32(len(users))
+
+	for room, n := range rooms {
+		out.TopRooms = append(out.TopRooms, &pb.RoomCount{Room: room, Messages: n})
+	}
+	sort.Slice(out.TopRooms, func(i, j int) bool {
+		a, b := out.TopRooms[i], out.TopRooms[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+		return a.Room < b.Room
+	})
+	if len(out.TopRooms) > top {
+		out.TopRooms = out.TopRooms[:top]
+	}
+	return out
+}
+
+// GetStats reports usage since the server started, at most statsRetention
+// back
+func (a *adminServer) GetStats(ctx context.Context, req *pb.StatsRequest) (*pb.Stats, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	to := now
+	if req.To > 0 && time.UnixMilli(req.To).Before(now) {
+		to = time.UnixMilli(req.To)
+	}
+	from := to.Add(-24 * time.Hour)
+	if req.Daily {
+		from = to.Add(-30 * 24 * time.Hour)
+	}
+	if req.From > 0 {
+		from = time.UnixMilli(req.From)
+	}
+	if !from.Before(to) {
+		return nil, status.Error(codes.InvalidArgument, "from must be before to")
+	}
+	if oldest := now.Add(-statsRetention); from.Before(oldest) {
+		from = oldest
+	}
+	top := int(req.TopRooms)
+	if top <= 0 {
+		top = defaultTopRooms
+	}
+	stats := a.s.usage.query(from, to, req.Daily, top)
+	if e := a.s.elector; e != nil {
+		l := e.Stats()
+		stats.Leadership = &pb.Leadership{
+			Name:    l.Name,
+			Id:      l.ID,
+			Leader:  l.Leader,
+			Holder:  l.Holder,
+			Since:   l.Since.UnixMilli(),
+			Elected: l.Elected,
+			Lost:    l.Lost,
+			Errors:  l.Errors,
+		}
+	}
+	return stats, nil
+}
+
+// snapshot describes the server now, the message rate averaged over
+// window
+func (s *ChatServer) snapshot(window time.Duration) *pb.StatsSnapshot {
+	now := time.Now()
+	conns, total, rate := s.usage.live(now, int64(window/time.Second))
+	out := &pb.StatsSnapshot{
+		Time:              now.UnixMilli(),
+		Connections:       int32(conns),
+		Messages:          total,
+		MessagesPerSecond: rate,
+		PendingSends:      int32(s.frames.pending()),
+		Goroutines:        int32(runtime.NumGoroutine()),
+	}
+	users, rooms := make(map[string]bool), make(map[string]bool)
+	s.mu.RLock()
+	for _, conn := range s.connections {
+		users[conn.user] = true
+		for _, room := range conn.rooms() {
+			rooms[room] = true
+		}
+	}
+	s.mu.RUnlock()
+	out.Users, out.Rooms = int32(len(users)), int32(len(rooms))
+	watchers, backlog := s.watchers.backlog()
+	out.Watchers, out.WatcherBacklog = int32(watchers), int32(backlog)
+	if s.cluster != nil {
+		out.ClusterQueue = int32(len(s.cluster.updates))
+	}
+	return out
+}
+
+// WatchStats sends a snapshot of the server now and then one every
+// req.IntervalSeconds until the caller goes away
+func (a *adminServer) WatchStats(req *pb.WatchStatsRequest, stream pb.AdminService_WatchStatsServer) error {
+	ctx := stream.Context()
+	if err := a.authorize(ctx); err != nil {
+		return err
+	}
+	interval := defaultWatchInterval
+	if req.IntervalSeconds < 0 || req.IntervalSeconds > 3600 {
+		return status.Error(codes.InvalidArgument, "interval_seconds must be between 0 and 3600")
+	} else if req.IntervalSeconds > 0 {
+		interval = time.Duration(req.IntervalSeconds) * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := stream.Send(a.s.snapshot(interval)); err != nil {
+			return err
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return nil
+		case <-a.s.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// ListRoomStats reports the rooms this server knows with their online
+// users, members, latest sequence and messages of the last 24 hours
+func (a *adminServer) ListRoomStats(ctx context.Context, req *pb.RoomStatsRequest) (*pb.RoomStatsList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s := a.s
+	online := make(map[string]map[string]bool)
+	s.mu.RLock()
+	for _, conn := range s.connections {
+		for _, room := range conn.rooms() {
+			if online[room] == nil {
+				online[room] = make(map[string]bool)
+			}
+			online[room][conn.user] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	rooms := make(map[string]bool)
+	if req.Room != "" {
+		room, ok := normalizeRoom(req.Room)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+		rooms[room] = true
+	} else {
+		for _, names := range [][]string{s.members.roomNames(), s.history.roomNames(), s.access.privateRooms()} {
+			for _, room := range names {
+				rooms[room] = true
+			}
+		}
+		for room := range online {
+			rooms[room] = true
+		}
+	}
+	now := time.Now()
+	counts := make(map[string]int64)
+	for _, c := range s.usage.query(now.Add(-24*time.Hour), now, false, math.MaxInt).TopRooms {
+		counts[c.Room] = c.Messages
+	}
+
+	out := &pb.RoomStatsList{}
+	for room := range rooms {
+		out.Rooms = append(out.Rooms, &pb.RoomStats{
+			Room:         room,
+			Online:       int32(len(online[room])),
+			Members:      int32(len(s.members.snapshot(room))),
+			Seq:          s.reads.latestSeq(room),
+			Private:      s.access.isPrivate(room),
+			Messages_24H: counts[room],
+		})
+	}
+	sort.Slice(out.Rooms, func(i, j int) bool { return out.Rooms[i].Room < out.Rooms[j].Room })
+	return out, nil
+}
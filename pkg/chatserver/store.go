@@ -0,0 +1,111 @@
+package chatserver
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// Store persists chat messages
+type Store interface {
+	SaveMessage(ctx context.Context, msg *pb.ChatMessage) error
+}
+
+// RoomReader is implemented by stores that can read back the public
+// messages of a room, ExportRoom prefers it over the in-memory history
+type RoomReader interface {
+	// RoomMessages calls fn for the messages of room sent in [from, to)
+	// in the order they were saved, a zero time means no bound
+	RoomMessages(ctx context.Context, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error
+}
+
+// HistoryReader is implemented by stores that page back through a room
+// faster than scanning it from the start, GetHistory prefers it for
+// before_time requests
+type HistoryReader interface {
+	// RoomHistory returns up to limit of the newest public messages of
+	// room sent before before, oldest first
+	RoomHistory(ctx context.Context, room string, before time.Time, limit int) ([]*pb.ChatMessage, error)
+}
+
+// RoomLister is implemented by stores that can name the rooms they hold
+// public messages of, snapshots include those rooms
+type RoomLister interface {
+	Rooms(ctx context.Context) ([]string, error)
+}
+
+// Compactor is implemented by stores that can hand their old messages
+// over to a cold tier, see TieredStore
+type Compactor interface {
+	// Compact calls fn with the messages sent before cutoff and removes
+	// them once fn succeeded
+	Compact(ctx context.Context, cutoff time.Time, fn func([]*pb.ChatMessage) error) error
+}
+
+// MemoryStore keeps messages in process memory
+type MemoryStore struct {
+	mu       sync.RWMutex
+	messages []*pb.ChatMessage
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// SaveMessage stores a copy of msg
+func (m *MemoryStore) SaveMessage(_ context.Context, msg *pb.ChatMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, proto.Clone(msg).(*pb.ChatMessage))
+	return nil
+}
+
+// Messages returns all stored messages in arrival order
+func (m *MemoryStore) Messages() []*pb.ChatMessage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]*pb.ChatMessage, len(m.messages))
+	copy(out, m.messages)
+	return out
+}
+
+// RoomMessages implements RoomReader
+func (m *MemoryStore) RoomMessages(ctx context.Context, room string, from, to time.Time, fn func(*pb.ChatMessage) error) error {
+	for _, msg := range m.Messages() {
+		if msg.Room != room || msg.RecipientUser != "" || !inRange(msg, from, to) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rooms implements RoomLister
+func (m *MemoryStore) Rooms(context.Context) ([]string, error) {
+	rooms := make(map[string]bool)
+	for _, msg := range m.Messages() {
+		if msg.RecipientUser == "" && msg.Room != "" {
+			rooms[msg.Room] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(rooms)), nil
+}
+
+// inRange reports whether msg was sent in [from, to)
+func inRange(msg *pb.ChatMessage, from, to time.Time) bool {
+	t := time.UnixMilli(msg.Timestamp)
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
@@ -0,0 +1,106 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// StreamStats reports open streams and how many were turned away by Limits
+type StreamStats struct {
+	Open            int
+	RejectedTotal   uint64 // Limits.MaxStreams was reached
+	RejectedPerUser uint64 // Limits.MaxStreamsPerUser was reached
+	RejectedPerIP   uint64 // Limits.MaxStreamsPerIP was reached
+}
+
+// streamCounter counts open streams overall and by source address, the
+// per-user count comes from the connections map
+type streamCounter struct {
+	mu   sync.Mutex
+	open int
+	byIP map[string]int
+
+	rejectedTotal   atomic.Uint64
+	rejectedPerUser atomic.Uint64
+	rejectedPerIP   atomic.Uint64
+}
+
+// acquireStream admits a new stream from ip, the returned release must be
+// called when the stream ends
+func (s *ChatServer) acquireStream(ip string) (release func(), err error) {
+	c := &s.streams
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if max := s.limits.MaxStreams; max > 0 && c.open >= max {
+		c.rejectedTotal.Add(1)
+		log.Printf("Rejected stream from %s: %d streams open", ip, c.open)
+		return nil, status.Error(codes.ResourceExhausted, "Chat server is full, please try again later")
+	}
+	if max := s.limits.MaxStreamsPerIP; max > 0 && ip != "" && c.byIP[ip] >= max {
+		c.rejectedPerIP.Add(1)
+		log.Printf("Rejected stream from %s: %d streams open from this address", ip, c.byIP[ip])
+		return nil, status.Errorf(codes.ResourceExhausted, "Too many connections from your address (max %d)", max)
+	}
+	if c.byIP == nil {
+		c.byIP = make(map[string]int)
+	}
+	c.open++
+	c.byIP[ip]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.open--
+			if c.byIP[ip]--; c.byIP[ip] <= 0 {
+				delete(c.byIP, ip)
+			}
+		})
+	}, nil
+}
+
+// userStreamsLocked counts the joined streams of user, s.mu must be held
+func (s *ChatServer) userStreamsLocked(user string) int {
+	n := 0
+	for _, conn := range s.connections {
+		if conn.user == user {
+			n++
+		}
+	}
+	return n
+}
+
+// StreamStats returns a snapshot of the stream counters
+func (s *ChatServer) StreamStats() StreamStats {
+	c := &s.streams
+	c.mu.Lock()
+	open := c.open
+	c.mu.Unlock()
+	return StreamStats{
+		Open:            open,
+		RejectedTotal:   c.rejectedTotal.Load(),
+		RejectedPerUser: c.rejectedPerUser.Load(),
+		RejectedPerIP:   c.rejectedPerIP.Load(),
+	}
+}
+
+// peerIP returns the source address of the stream without its port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
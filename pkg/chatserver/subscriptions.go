@@ -0,0 +1,171 @@
+package chatserver
+
+import (
+	"log"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// maxSubscriptions bounds the rooms one stream receives besides its
+// current one
+const maxSubscriptions = 50
+
+// parseSubscribeCommand reports whether msg is a public
+// "/subscribe <room> [invite]" or "/unsubscribe <room>" command
+func parseSubscribeCommand(msg *pb.ChatMessage) (room, invite string, subscribe, ok bool) {
+	if msg.RecipientUser != "" || msg.GetCode() != nil {
+		return "", "", false, false
+	}
+	cmd, args, _ := strings.Cut(msg.Text, " ")
+	switch cmd {
+	case "/subscribe":
+		subscribe = true
+	case "/unsubscribe":
+	default:
+		return "", "", false, false
+	}
+	room, invite, _ = strings.Cut(strings.TrimSpace(args), " ")
+	return room, strings.TrimSpace(invite), subscribe, true
+}
+
+// subscribeRoom has clientID also receive the public messages of room,
+// so one stream can follow every room its user is in. Members of the
+// room see the user come in as with /join. Failures are told to the
+// caller with a System message.
+func (s *ChatServer) subscribeRoom(stream pb.ChatService_RealtimeChatServer, clientID, user, room, invite string) bool {
+	if room == "" {
+		s.sendSystem(stream, clientID, i18n.SubscribeUsage)
+		return false
+	}
+	name, ok := normalizeRoom(room)
+	if !ok {
+		s.sendSystem(stream, clientID, i18n.RoomInvalid, "room", room)
+		return false
+	}
+	if owner := s.roomOwner(name); owner != "" {
+		s.sendSystem(stream, clientID, i18n.RoomElsewhere, "room", name, "node", owner)
+		return false
+	}
+	enter, redeem := s.mayEnter(user, name, invite)
+	if !enter {
+		key := i18n.RoomPrivate
+		if invite != "" {
+			key = i18n.InviteInvalid
+		}
+		s.sendSystem(stream, clientID, key, "room", name)
+		return false
+	}
+
+	quotas := s.roomQuotasFor(stream.Context(), user, name)
+	s.mu.Lock()
+	conn := s.connections[clientID]
+	if conn.in(name) {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.RoomAlready, "room", name)
+		return false
+	}
+	if len(conn.subs) >= maxSubscriptions {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.SubscribeTooMany, "max", strconv.Itoa(maxSubscriptions))
+		return false
+	}
+	if key, args := s.admitLocked(user, name, quotas); key != "" {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, key, args...)
+		return false
+	}
+	if redeem && !s.access.redeem(invite, name, time.Now()) {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.InviteInvalid, "room", name)
+		return false
+	}
+	subs := maps.Clone(conn.subs)
+	if subs == nil {
+		subs = make(map[string]bool)
+	}
+	subs[name] = true
+	conn.subs = subs
+	s.connections[clientID] = conn
+	s.mu.Unlock()
+	s.reads.enter(user, name)
+	s.memberEntered(user, name)
+
+	if redeem {
+		log.Printf("'%s' entered #%s with an invite", user, name)
+	}
+	log.Printf("User '%s' (ID: %s) subscribed to #%s.", user, clientID, name)
+	s.broadcastRoom(name, membership(true, systemText(i18n.RoomUserJoined, "user", user, "room", name)), clientID)
+	s.sendSystem(stream, clientID, i18n.Subscribed, "room", name)
+	s.sendSubscriptions(clientID)
+	return true
+}
+
+// unsubscribeRoom stops clientID receiving room, its current room can
+// only be left by joining another
+func (s *ChatServer) unsubscribeRoom(stream pb.ChatService_RealtimeChatServer, clientID, user, room string) bool {
+	name, ok := normalizeRoom(room)
+	if !ok {
+		s.sendSystem(stream, clientID, i18n.SubscribeUsage)
+		return false
+	}
+	s.mu.Lock()
+	conn := s.connections[clientID]
+	if conn.room == name {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.SubscribeCurrent, "room", name)
+		return false
+	}
+	if !conn.subs[name] {
+		s.mu.Unlock()
+		s.sendSystem(stream, clientID, i18n.NotSubscribed, "room", name)
+		return false
+	}
+	conn.subs = maps.Clone(conn.subs)
+	delete(conn.subs, name)
+	s.connections[clientID] = conn
+	s.mu.Unlock()
+	s.memberLeft(user, name)
+	s.leaveVoice(clientID, name)
+
+	log.Printf("User '%s' (ID: %s) unsubscribed from #%s.", user, clientID, name)
+	s.broadcastRoom(name, membership(false, systemText(i18n.RoomUserLeft, "user", user, "room", name)), clientID)
+	s.sendSystem(stream, clientID, i18n.Unsubscribed, "room", name)
+	s.sendSubscriptions(clientID)
+	return true
+}
+
+// sendSubscriptions tells clientID which rooms it receives
+func (s *ChatServer) sendSubscriptions(clientID string) {
+	s.mu.RLock()
+	conn, ok := s.connections[clientID]
+	s.mu.RUnlock()
+	if !ok {
+		return
+	}
+	rooms := conn.rooms()
+	s.sendToConn(clientID, &pb.ChatMessage{
+		User:    "System",
+		Room:    conn.room,
+		Type:    pb.MessageType_TYPE_SUBSCRIPTIONS,
+		Payload: &pb.ChatMessage_Subscriptions{Subscriptions: &pb.Subscriptions{Room: rooms[0], Rooms: rooms[1:]}},
+	})
+}
+
+// targetRoom returns the room a public message from clientID goes to: the
+// subscribed room it names, or the stream's current room. ok is false
+// for rooms the stream does not receive.
+func (s *ChatServer) targetRoom(clientID string, msg *pb.ChatMessage) (string, bool) {
+	s.mu.RLock()
+	conn := s.connections[clientID]
+	s.mu.RUnlock()
+	if msg.Room == "" || msg.RecipientUser != "" {
+		return conn.room, true
+	}
+	name, ok := normalizeRoom(msg.Room)
+	return name, ok && conn.in(name)
+}
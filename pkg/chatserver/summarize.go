@@ -0,0 +1,160 @@
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"realTimeChat/pkg/assistant"
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// DefaultSummarizeLast is how many messages /summarize covers without
+	// arguments
+	DefaultSummarizeLast = 50
+	// maxSummarize caps the messages of one summary
+	maxSummarize = 500
+)
+
+// summarizePrompt is the question asked about the selected messages
+const summarizePrompt = "Summarize the conversation above in a few short bullet points for someone " +
+	"who missed it: decisions, open questions and who is waiting on whom. " +
+	"Write in the language most of the messages use."
+
+// summarizeRange is what /summarize covers: the last n messages, or those
+// sent since a time
+type summarizeRange struct {
+	last  int
+	since time.Time
+}
+
+// parseSummarize reports whether msg is a public "/summarize" command,
+// args holds whatever followed the command
+func parseSummarize(msg *pb.ChatMessage) ([]string, bool) {
+	if msg.RecipientUser != "" || msg.GetCode() != nil {
+		return nil, false
+	}
+	if msg.Text == "/summarize" {
+		return nil, true
+	}
+	rest, ok := strings.CutPrefix(msg.Text, "/summarize ")
+	return strings.Fields(rest), ok
+}
+
+// summarizeRangeOf reads "[last N | since <time>]". A time is a duration
+// back from now such as 2h, a clock time today such as 14:30 or an
+// RFC 3339 timestamp.
+func summarizeRangeOf(args []string, now time.Time) (summarizeRange, bool) {
+	switch {
+	case len(args) == 0:
+		return summarizeRange{last: DefaultSummarizeLast}, true
+	case len(args) == 2 && args[0] == "last":
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return summarizeRange{}, false
+		}
+		return summarizeRange{last: min(n, maxSummarize)}, true
+	case len(args) == 2 && args[0] == "since":
+		since, ok := parseSince(args[1], now)
+		if !ok || !since.Before(now) {
+			return summarizeRange{}, false
+		}
+		return summarizeRange{since: since}, true
+	}
+	return summarizeRange{}, false
+}
+
+// parseSince reads the time of "/summarize since"
+func parseSince(s string, now time.Time) (time.Time, bool) {
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), true
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		y, m, d := now.Date()
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, now.Location()), true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// summaryMessages collects the messages of room in r, from the store when
+// it can be read back and otherwise from the in-memory history
+func (s *ChatServer) summaryMessages(ctx context.Context, room string, r summarizeRange) ([]*pb.ChatMessage, error) {
+	limit := r.last
+	if limit == 0 {
+		limit = maxSummarize
+	}
+	var msgs []*pb.ChatMessage
+	keep := func(msg *pb.ChatMessage) error {
+		msgs = append(msgs, msg)
+		if len(msgs) > limit {
+			msgs = msgs[1:] // the newest are kept
+		}
+		return nil
+	}
+	if rr, ok := s.store.(RoomReader); ok {
+		err := rr.RoomMessages(withReadQuery(ctx, QuerySummarize), room, r.since, time.Time{}, keep)
+		return msgs, err
+	}
+	for _, msg := range s.history.latest(room, s.history.size) {
+		if inRange(msg, r.since, time.Time{}) {
+			_ = keep(msg)
+		}
+	}
+	return msgs, nil
+}
+
+// handleSummarize summarizes the scrollback of room with the assistant
+// and sends the summary to the requesting connection only
+func (s *ChatServer) handleSummarize(stream pb.ChatService_RealtimeChatServer, clientID, user, room string, args []string) {
+	r, ok := summarizeRangeOf(args, time.Now())
+	switch {
+	case !ok:
+		s.sendSystem(stream, clientID, i18n.SummarizeUsage)
+		return
+	case s.assistant == nil:
+		s.sendSystem(stream, clientID, i18n.SummarizeOff)
+		return
+	}
+	s.sendSystem(stream, clientID, i18n.Summarizing)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(stream.Context(), assistantTimeout)
+		defer cancel()
+		msgs, err := s.summaryMessages(ctx, room, r)
+		if err != nil {
+			log.Printf("Reading #%s to summarize for %s failed: %v", room, user, err)
+			s.sendEphemeral(clientID, systemText(i18n.SummarizeFailed))
+			return
+		}
+		turns := s.turns(msgs)
+		if len(turns) == 0 {
+			s.sendEphemeral(clientID, systemText(i18n.SummarizeEmpty))
+			return
+		}
+		summary, err := s.assistant.Answer(ctx, assistant.Question{User: user, Room: room, Text: summarizePrompt, Context: turns}, func(string) {})
+		if err == nil && strings.TrimSpace(summary) == "" {
+			err = errors.New("empty summary")
+		}
+		if err != nil {
+			log.Printf("Summarizing #%s for %s failed: %v", room, user, err)
+			s.sendEphemeral(clientID, systemText(i18n.SummarizeFailed))
+			return
+		}
+		s.sendEphemeral(clientID, &pb.ChatMessage{
+			User:      AssistantName,
+			Text:      s.clipAnswer(strings.TrimSpace(summary)),
+			Room:      room,
+			Type:      pb.MessageType_TYPE_CHAT,
+			Timestamp: time.Now().UnixMilli(),
+			Metadata:  map[string]string{"assistant.summary": strconv.Itoa(len(turns))},
+		})
+	}()
+}
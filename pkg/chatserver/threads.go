@@ -0,0 +1,251 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// maxThreads caps the threads kept, the least recently active go first
+	maxThreads = 1000
+	// maxThreadReplies is how many replies of a thread GetThread returns
+	maxThreadReplies = 200
+)
+
+// thread is the replies to a public message and who follows them
+type thread struct {
+	root      *pb.ChatMessage
+	replies   []*pb.ChatMessage // oldest first, at most maxThreadReplies
+	count     uint32
+	active    time.Time
+	followers map[string]bool
+}
+
+// threadSet holds the threads by the ID of their root message
+type threadSet struct {
+	mu      sync.Mutex
+	threads map[string]*thread
+}
+
+// open returns the thread of root, which starts out followed by the
+// author of root, must hold mu
+func (ts *threadSet) open(root *pb.ChatMessage) *thread {
+	if t, ok := ts.threads[root.Id]; ok {
+		return t
+	}
+	if ts.threads == nil {
+		ts.threads = make(map[string]*thread)
+	}
+	if len(ts.threads) >= maxThreads {
+		var oldest string
+		for id, t := range ts.threads {
+			if oldest == "" || t.active.Before(ts.threads[oldest].active) {
+				oldest = id
+			}
+		}
+		delete(ts.threads, oldest)
+	}
+	t := &thread{root: root, active: time.Now(), followers: map[string]bool{root.User: true}}
+	ts.threads[root.Id] = t
+	return t
+}
+
+// threadRoot returns the message thread id replies to, nil when there is no
+// such public message
+func (s *ChatServer) threadRoot(id string) *pb.ChatMessage {
+	s.threads.mu.Lock()
+	t, ok := s.threads.threads[id]
+	s.threads.mu.Unlock()
+	if ok {
+		return t.root
+	}
+	root := s.history.find(id)
+	if root == nil || root.Room == "" || root.RecipientUser != "" || root.ThreadId != "" {
+		return nil
+	}
+	return root
+}
+
+// reply adds msg to its thread and makes its sender a follower, it
+// returns the followers and the update for the room
+func (ts *threadSet) reply(root, msg *pb.ChatMessage) (map[string]bool, *pb.ThreadUpdate) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	t := ts.open(root)
+	if len(t.replies) == maxThreadReplies {
+		t.replies = append(t.replies[:0], t.replies[1:]...)
+	}
+	t.replies = append(t.replies, msg)
+	t.count++
+	t.active = time.UnixMilli(msg.Timestamp)
+	t.followers[msg.User] = true
+	update := &pb.ThreadUpdate{
+		ThreadId:    root.Id,
+		Room:        root.Room,
+		ReplyCount:  t.count,
+		LastUser:    msg.User,
+		LastReplyAt: msg.Timestamp,
+	}
+	return maps.Clone(t.followers), update
+}
+
+// follow makes user follow the thread of root or stop following it
+func (ts *threadSet) follow(root *pb.ChatMessage, user string, on bool) *pb.Thread {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	t := ts.open(root)
+	if on {
+		t.followers[user] = true
+	} else {
+		delete(t.followers, user)
+	}
+	return t.describe(user)
+}
+
+// get describes the thread of root for user, it has no replies yet when
+// nobody answered
+func (ts *threadSet) get(root *pb.ChatMessage, user string) *pb.Thread {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if t, ok := ts.threads[root.Id]; ok {
+		return t.describe(user)
+	}
+	return &pb.Thread{Root: root, Followers: 1, Following: user == root.User}
+}
+
+// describe copies t for user, must hold the lock of its set
+func (t *thread) describe(user string) *pb.Thread {
+	return &pb.Thread{
+		Root:       t.root,
+		Replies:    slices.Clone(t.replies),
+		ReplyCount: t.count,
+		Followers:  uint32(len(t.followers)),
+		Following:  t.followers[user],
+	}
+}
+
+// rename moves the follows of oldName to newName
+func (ts *threadSet) rename(oldName, newName string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, t := range ts.threads {
+		if t.followers[oldName] {
+			delete(t.followers, oldName)
+			t.followers[newName] = true
+		}
+	}
+}
+
+// acceptReply is accept for thread replies. Only the followers get a
+// reply, so it has no room sequence and stays out of the room's history
+// and the store, from where backfills, catch-up and unread counts would
+// show it to everyone in the room.
+func (s *ChatServer) acceptReply(msg *pb.ChatMessage, room string) {
+	msg.Id = s.newID()
+	msg.Timestamp = time.Now().UnixMilli()
+	msg.Type = pb.MessageType_TYPE_CHAT
+	msg.Room = room
+	msg.Seq = 0
+	s.usage.message(msg.User, msg.Room, time.UnixMilli(msg.Timestamp))
+	if s.hooks.OnMessage != nil {
+		s.hooks.OnMessage(msg)
+	}
+}
+
+// postReply accepts a thread reply and sends it to the followers of the
+// thread, the room gets the new reply count. It reports false when the
+// root is gone.
+func (s *ChatServer) postReply(ctx context.Context, msg *pb.ChatMessage, room, excludeID string) bool {
+	root := s.threadRoot(msg.ThreadId)
+	if root == nil || root.Room != room {
+		return false
+	}
+	s.acceptReply(msg, room)
+	followers, update := s.threads.reply(root, msg)
+	log.Printf("Reply from %s in thread %s of #%s for %d followers", msg.User, root.Id, room, len(followers))
+
+	s.mu.RLock()
+	var targets []connection
+	for id, conn := range s.connections {
+		if id == excludeID || !followers[conn.user] || !conn.wants(room, msg) {
+			continue
+		}
+		if out := s.withNotify(ctx, conn.user, msg); out != msg {
+			go s.sendRoutine(conn.stream, out, conn.user)
+			continue
+		}
+		targets = append(targets, conn)
+	}
+	s.fanout(msg, targets)
+	s.mu.RUnlock()
+	s.broadcastRoom(room, &pb.ChatMessage{
+		User:      "System",
+		Room:      room,
+		Type:      pb.MessageType_TYPE_THREAD,
+		Timestamp: msg.Timestamp,
+		Payload:   &pb.ChatMessage_Thread{Thread: update},
+	}, "")
+	return true
+}
+
+// threadServer implements the ThreadService RPCs
+type threadServer struct {
+	pb.UnimplementedThreadServiceServer
+	s *ChatServer
+}
+
+// lookup finds the root of a thread req.User may see
+func (t *threadServer) lookup(ctx context.Context, req *pb.ThreadRequest) (*pb.ChatMessage, error) {
+	if req.ThreadId == "" {
+		return nil, status.Error(codes.InvalidArgument, "thread_id is required")
+	}
+	if err := t.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	root := t.s.threadRoot(req.ThreadId)
+	if root == nil {
+		return nil, status.Errorf(codes.NotFound, "no thread %s", req.ThreadId)
+	}
+	if t.s.access.isPrivate(root.Room) {
+		if _, member := t.s.members.snapshot(root.Room)[req.User]; !member && (&adminServer{s: t.s}).authorize(ctx) != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "#%s is private", root.Room)
+		}
+	}
+	return root, nil
+}
+
+// GetThread returns a thread with its recent replies
+func (t *threadServer) GetThread(ctx context.Context, req *pb.ThreadRequest) (*pb.Thread, error) {
+	root, err := t.lookup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return t.s.threads.get(root, req.User), nil
+}
+
+// FollowThread sends the replies of a thread to the user from now on
+func (t *threadServer) FollowThread(ctx context.Context, req *pb.ThreadRequest) (*pb.Thread, error) {
+	root, err := t.lookup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return t.s.threads.follow(root, req.User, true), nil
+}
+
+// UnfollowThread stops sending the replies of a thread to the user
+func (t *threadServer) UnfollowThread(ctx context.Context, req *pb.ThreadRequest) (*pb.Thread, error) {
+	root, err := t.lookup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return t.s.threads.follow(root, req.User, false), nil
+}
@@ -0,0 +1,137 @@
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"realTimeChat/pkg/i18n"
+	"realTimeChat/pkg/translate"
+	pb "realTimeChat/proto/chat"
+)
+
+// translateTimeout bounds one translation
+const translateTimeout = 10 * time.Second
+
+// parseTranslate reports whether msg is a public "/translate <message_id> <lang>"
+// command, args holds whatever followed the command
+func parseTranslate(msg *pb.ChatMessage) ([]string, bool) {
+	if msg.RecipientUser != "" || msg.GetCode() != nil {
+		return nil, false
+	}
+	if msg.Text == "/translate" {
+		return nil, true
+	}
+	rest, ok := strings.CutPrefix(msg.Text, "/translate ")
+	return strings.Fields(rest), ok
+}
+
+// handleTranslate translates a kept public message for the sender only
+func (s *ChatServer) handleTranslate(stream pb.ChatService_RealtimeChatServer, clientID, user string, args []string) {
+	switch {
+	case len(args) != 2:
+		s.sendSystem(stream, clientID, i18n.TranslateUsage)
+		return
+	case s.translator == nil:
+		s.sendSystem(stream, clientID, i18n.TranslateOff)
+		return
+	case !translate.ValidLang(args[1]):
+		s.sendSystem(stream, clientID, i18n.TranslateBadLang, "lang", args[1])
+		return
+	}
+	msg := s.history.find(args[0])
+	if msg == nil || msg.Text == "" || !s.mayRead(stream.Context(), user, msg.Room) {
+		s.sendSystem(stream, clientID, i18n.TranslateNotFound, "id", args[0])
+		return
+	}
+	if !s.feature(FeatureTranslations, user, msg.Room) {
+		s.sendSystem(stream, clientID, i18n.FeatureDisabled, "feature", FeatureTranslations)
+		return
+	}
+
+	go func() {
+		event, err := s.translateMessage(stream.Context(), msg, args[1])
+		if err != nil {
+			log.Printf("Translation of %s to %s for %s failed: %v", msg.Id, args[1], user, err)
+			reply := systemText(i18n.TranslateFailed)
+			if errors.Is(err, translate.ErrUnsupportedLanguage) {
+				reply = systemText(i18n.TranslateNoLang, "lang", args[1])
+			}
+			s.sendEphemeral(clientID, reply)
+			return
+		}
+		s.sendToConn(clientID, event)
+	}()
+}
+
+// autoTranslate sends translations of a public message in the background
+// to recipients whose preferences ask for one, translating once per language
+func (s *ChatServer) autoTranslate(msg *pb.ChatMessage) {
+	if s.translator == nil || msg.Text == "" || msg.RecipientUser != "" || !s.feature(FeatureTranslations, msg.User, msg.Room) {
+		return
+	}
+	s.mu.RLock()
+	conns := make(map[string]string, len(s.connections)) // clientID -> user
+	for id, conn := range s.connections {
+		if conn.user != msg.User && conn.in(msg.Room) {
+			conns[id] = conn.user
+		}
+	}
+	s.mu.RUnlock()
+
+	go func() {
+		byLang := make(map[string][]string) // lang -> clientIDs
+		for id, user := range conns {
+			prefs, err := s.prefs.GetPreferences(s.ctx, user)
+			if err != nil || prefs.GetAutoTranslate() == "" {
+				continue
+			}
+			byLang[prefs.AutoTranslate] = append(byLang[prefs.AutoTranslate], id)
+		}
+		for lang, ids := range byLang {
+			event, err := s.translateMessage(s.ctx, msg, lang)
+			if s.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("Auto-translation of %s to %s failed: %v", msg.Id, lang, err)
+				continue
+			}
+			if sameLang(event.GetTranslation().SourceLang, lang) {
+				continue // already in the reader's language
+			}
+			for _, id := range ids {
+				s.sendToConn(id, event)
+			}
+		}
+	}()
+}
+
+// translateMessage returns a translation event for msg
+func (s *ChatServer) translateMessage(ctx context.Context, msg *pb.ChatMessage, lang string) (*pb.ChatMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, translateTimeout)
+	defer cancel()
+	res, err := s.translator.Translate(ctx, msg.Text, lang)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ChatMessage{
+		User: "System",
+		Type: pb.MessageType_TYPE_TRANSLATION,
+		Payload: &pb.ChatMessage_Translation{Translation: &pb.Translation{
+			MessageId:  msg.Id,
+			Lang:       lang,
+			Text:       res.Text,
+			SourceLang: res.SourceLang,
+		}},
+	}, nil
+}
+
+// sameLang compares the primary subtags of two language tags
+func sameLang(a, b string) bool {
+	a, _, _ = strings.Cut(a, "-")
+	b, _, _ = strings.Cut(b, "-")
+	return a != "" && strings.EqualFold(a, b)
+}
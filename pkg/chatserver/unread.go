@@ -0,0 +1,196 @@
+package chatserver
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// readState tracks the latest sequence of each room and how far each
+// user has read
+type readState struct {
+	mu       sync.Mutex
+	seq      map[string]uint64            // room -> latest sequence
+	lastRead map[string]map[string]uint64 // user -> room -> last read sequence
+}
+
+func newReadState() *readState {
+	return &readState{
+		seq:      map[string]uint64{DefaultRoom: 0},
+		lastRead: make(map[string]map[string]uint64),
+	}
+}
+
+// next assigns the next sequence in room, the sender has read it
+func (r *readState) next(room, sender string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq[room]++
+	seq := r.seq[room]
+	r.userLocked(sender)[room] = seq
+	return seq
+}
+
+// userLocked returns user's read positions. Users seen for the first time
+// start at the latest message of DefaultRoom, history from before they
+// joined is not unread.
+func (r *readState) userLocked(user string) map[string]uint64 {
+	read, ok := r.lastRead[user]
+	if !ok {
+		read = map[string]uint64{DefaultRoom: r.seq[DefaultRoom]}
+		r.lastRead[user] = read
+	}
+	return read
+}
+
+// enter starts tracking room for user at its latest message, rooms the
+// user was in before keep their position
+func (r *readState) enter(user, room string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	read := r.userLocked(user)
+	if _, ok := read[room]; !ok {
+		read[room] = r.seq[room]
+	}
+}
+
+// join makes sure user has read positions
+func (r *readState) join(user string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.userLocked(user)
+}
+
+// markRead moves user's position in room forward to seq, it reports false
+// for unknown rooms
+func (r *readState) markRead(user, room string, seq uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	latest, ok := r.seq[room]
+	if !ok {
+		return false
+	}
+	read := r.userLocked(user)
+	if seq = min(seq, latest); seq > read[room] {
+		read[room] = seq
+	}
+	return true
+}
+
+// counts returns user's unread counts for the rooms the user has been in,
+// limited to rooms when given
+func (r *readState) counts(user string, rooms ...string) *pb.UnreadCounts {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	read := r.userLocked(user)
+	if len(rooms) == 0 {
+		for room := range read {
+			rooms = append(rooms, room)
+		}
+	}
+	out := &pb.UnreadCounts{User: user, Rooms: make(map[string]uint32, len(rooms))}
+	for _, room := range rooms {
+		if pos, ok := read[room]; ok {
+			out.Rooms[room] = uint32(min(r.seq[room]-pos, math.MaxUint32))
+		}
+	}
+	return out
+}
+
+// latestSeq returns the newest sequence handed out in room
+func (r *readState) latestSeq(room string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seq[room]
+}
+
+// raiseSeq continues the sequence of room after seq unless it is past it
+func (r *readState) raiseSeq(room string, seq uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if seq > r.seq[room] {
+		r.seq[room] = seq
+	}
+}
+
+// rename moves the read positions to the new name
+func (r *readState) rename(oldName, newName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if read, ok := r.lastRead[oldName]; ok {
+		delete(r.lastRead, oldName)
+		if _, taken := r.lastRead[newName]; !taken {
+			r.lastRead[newName] = read
+		}
+	}
+}
+
+// known reports whether user has read positions
+func (r *readState) known(user string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.lastRead[user]
+	return ok
+}
+
+// pushUnread sends every online user except the sender their new count
+// for the message's room, if they have been in it
+func (s *ChatServer) pushUnread(msg *pb.ChatMessage) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, conn := range s.connections {
+		if conn.user == msg.User {
+			continue
+		}
+		counts := s.reads.counts(conn.user, msg.Room)
+		if len(counts.Rooms) == 0 {
+			continue
+		}
+		go s.sendRoutine(conn.stream, &pb.ChatMessage{User: "System", Type: pb.MessageType_TYPE_UNREAD, Payload: &pb.ChatMessage_Unread{Unread: counts}}, conn.user)
+	}
+}
+
+// unreadServer implements the UnreadService RPCs
+type unreadServer struct {
+	pb.UnimplementedUnreadServiceServer
+	s *ChatServer
+}
+
+// GetUnreadCounts returns the user's unread count for every room, only
+// to the user themselves
+func (u *unreadServer) GetUnreadCounts(ctx context.Context, req *pb.UnreadRequest) (*pb.UnreadCounts, error) {
+	if err := u.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	return u.s.reads.counts(req.User), nil
+}
+
+// MarkRead records a read position of the user themselves and syncs
+// their other connections
+func (u *unreadServer) MarkRead(ctx context.Context, req *pb.MarkReadRequest) (*pb.UnreadCounts, error) {
+	if err := u.s.authorizeUser(ctx, req.User); err != nil {
+		return nil, err
+	}
+	if req.Room == "" {
+		req.Room = DefaultRoom
+	}
+	if !u.s.reads.markRead(req.User, req.Room, req.Seq) {
+		return nil, status.Errorf(codes.NotFound, "room %q not found", req.Room)
+	}
+	counts := u.s.reads.counts(req.User)
+
+	u.s.mu.RLock()
+	for _, conn := range u.s.connections {
+		if conn.user == req.User {
+			go u.s.sendRoutine(conn.stream, &pb.ChatMessage{User: "System", Type: pb.MessageType_TYPE_UNREAD, Payload: &pb.ChatMessage_Unread{Unread: counts}}, conn.user)
+		}
+	}
+	u.s.mu.RUnlock()
+	return counts, nil
+}
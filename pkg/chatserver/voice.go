@@ -0,0 +1,204 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// voiceMember is a connection in the voice channel of a room. The server
+// only tracks who is there and the speaking hints of their clients, media
+// flows directly between the peers.
+type voiceMember struct {
+	user     string
+	joined   time.Time
+	speaking bool
+}
+
+func (m *voiceMember) describe() *pb.VoiceMember {
+	return &pb.VoiceMember{User: m.user, Speaking: m.speaking, JoinedAt: m.joined.UnixMilli()}
+}
+
+// voiceChannels tracks the voice channel of each room by connection, a
+// connection is in at most one
+type voiceChannels struct {
+	mu    sync.Mutex
+	rooms map[string]map[string]*voiceMember // room -> client ID
+}
+
+// roomOf returns the room whose voice channel clientID is in, must hold mu
+func (v *voiceChannels) roomOf(clientID string) (string, *voiceMember) {
+	for room, members := range v.rooms {
+		if m, ok := members[clientID]; ok {
+			return room, m
+		}
+	}
+	return "", nil
+}
+
+// remove takes clientID out of the voice channel of room, must hold mu
+func (v *voiceChannels) remove(room, clientID string) {
+	delete(v.rooms[room], clientID)
+	if len(v.rooms[room]) == 0 {
+		delete(v.rooms, room)
+	}
+}
+
+// members lists the voice channel of room by the time they joined
+func (v *voiceChannels) members(room string) []*pb.VoiceMember {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	out := make([]*pb.VoiceMember, 0, len(v.rooms[room]))
+	for _, m := range v.rooms[room] {
+		out = append(out, m.describe())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].JoinedAt != out[j].JoinedAt {
+			return out[i].JoinedAt < out[j].JoinedAt
+		}
+		return out[i].User < out[j].User
+	})
+	return out
+}
+
+// rename updates the voice channel membership of clientID
+func (v *voiceChannels) rename(clientID, newName string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, m := v.roomOf(clientID); m != nil {
+		m.user = newName
+	}
+}
+
+// handleVoice joins or leaves a voice channel or passes on a speaking
+// hint, the signal is never relayed as is
+func (s *ChatServer) handleVoice(stream pb.ChatService_RealtimeChatServer, clientID, userName string, msg *pb.ChatMessage) {
+	switch msg.GetSignal().Type {
+	case pb.SignalType_SIGNAL_VOICE_JOIN:
+		room, ok := s.targetRoom(clientID, msg)
+		if !ok {
+			s.sendSystem(stream, clientID, i18n.NotSubscribed, "room", msg.Room)
+			return
+		}
+		if owner := s.roomOwner(room); owner != "" {
+			s.sendSystem(stream, clientID, i18n.RoomMovedAway, "room", room, "node", owner)
+			return
+		}
+		if !s.feature(FeatureCalls, userName, room) {
+			s.sendSystem(stream, clientID, i18n.FeatureDisabled, "feature", FeatureCalls)
+			return
+		}
+		s.joinVoice(clientID, userName, room)
+
+	case pb.SignalType_SIGNAL_VOICE_LEAVE:
+		s.leaveVoice(clientID, "")
+
+	case pb.SignalType_SIGNAL_VOICE_SPEAKING_START, pb.SignalType_SIGNAL_VOICE_SPEAKING_STOP:
+		speaking := msg.GetSignal().Type == pb.SignalType_SIGNAL_VOICE_SPEAKING_START
+		s.voice.mu.Lock()
+		room, m := s.voice.roomOf(clientID)
+		if m == nil {
+			s.voice.mu.Unlock()
+			s.sendSystem(stream, clientID, i18n.VoiceNotJoined)
+			return
+		}
+		changed := m.speaking != speaking
+		m.speaking = speaking
+		member := m.describe()
+		s.voice.mu.Unlock()
+		if !changed {
+			return
+		}
+		action := pb.VoiceAction_VOICE_SILENT
+		if speaking {
+			action = pb.VoiceAction_VOICE_SPEAKING
+		}
+		s.emitVoice(room, action, member)
+	}
+}
+
+// joinVoice moves clientID into the voice channel of room, out of the one
+// it was in
+func (s *ChatServer) joinVoice(clientID, user, room string) {
+	s.voice.mu.Lock()
+	from, m := s.voice.roomOf(clientID)
+	if from == room {
+		s.voice.mu.Unlock()
+		return
+	}
+	var left *pb.VoiceMember
+	if m != nil {
+		s.voice.remove(from, clientID)
+		m.speaking = false
+		left = m.describe()
+	}
+	if s.voice.rooms == nil {
+		s.voice.rooms = make(map[string]map[string]*voiceMember)
+	}
+	if s.voice.rooms[room] == nil {
+		s.voice.rooms[room] = make(map[string]*voiceMember)
+	}
+	m = &voiceMember{user: user, joined: time.Now()}
+	s.voice.rooms[room][clientID] = m
+	joined := m.describe()
+	s.voice.mu.Unlock()
+
+	if left != nil {
+		s.emitVoice(from, pb.VoiceAction_VOICE_LEFT, left)
+	}
+	log.Printf("User '%s' (ID: %s) joined the voice channel of #%s.", user, clientID, room)
+	s.emitVoice(room, pb.VoiceAction_VOICE_JOINED, joined)
+}
+
+// leaveVoice takes clientID out of its voice channel, only when it is the
+// one of room unless room is ""
+func (s *ChatServer) leaveVoice(clientID, room string) {
+	s.voice.mu.Lock()
+	from, m := s.voice.roomOf(clientID)
+	if m == nil || (room != "" && from != room) {
+		s.voice.mu.Unlock()
+		return
+	}
+	s.voice.remove(from, clientID)
+	m.speaking = false
+	left := m.describe()
+	s.voice.mu.Unlock()
+
+	log.Printf("User '%s' (ID: %s) left the voice channel of #%s.", left.User, clientID, from)
+	s.emitVoice(from, pb.VoiceAction_VOICE_LEFT, left)
+}
+
+// emitVoice tells the connections in room of a change of its voice channel
+func (s *ChatServer) emitVoice(room string, action pb.VoiceAction, member *pb.VoiceMember) {
+	s.broadcastRoom(room, &pb.ChatMessage{
+		User:      "System",
+		Room:      room,
+		Type:      pb.MessageType_TYPE_VOICE,
+		Timestamp: time.Now().UnixMilli(),
+		Payload:   &pb.ChatMessage_Voice{Voice: &pb.VoiceEvent{Room: room, Action: action, Member: member}},
+	}, "")
+}
+
+// GetVoiceMembers lists the voice channel of a room, the admin token is
+// needed for private rooms
+func (r *roomServer) GetVoiceMembers(ctx context.Context, req *pb.VoiceMembersRequest) (*pb.VoiceMembers, error) {
+	room := DefaultRoom
+	if req.Room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(req.Room); !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid room name", req.Room)
+		}
+	}
+	if r.s.access.isPrivate(room) && (&adminServer{s: r.s}).authorize(ctx) != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "#%s is private", room)
+	}
+	return &pb.VoiceMembers{Room: room, Members: r.s.voice.members(room)}, nil
+}
@@ -0,0 +1,127 @@
+package chatserver
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// watchBuffer is how far a WatchRoom stream may fall behind before it is
+// ended, a slow reader must not hold up the chat
+const watchBuffer = 256
+
+// watcher is one WatchRoom stream
+type watcher struct {
+	room   string
+	ch     chan *pb.ChatMessage
+	lagged chan struct{} // closed when ch overflowed
+	once   sync.Once
+}
+
+// watcherSet holds the open WatchRoom streams
+type watcherSet struct {
+	mu   sync.RWMutex
+	next int
+	set  map[int]*watcher
+}
+
+func (ws *watcherSet) add(room string) (int, *watcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.set == nil {
+		ws.set = make(map[int]*watcher)
+	}
+	ws.next++
+	w := &watcher{room: room, ch: make(chan *pb.ChatMessage, watchBuffer), lagged: make(chan struct{})}
+	ws.set[ws.next] = w
+	return ws.next, w
+}
+
+func (ws *watcherSet) remove(id int) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	delete(ws.set, id)
+}
+
+// backlog returns the number of watchers and of the messages queued for
+// them
+func (ws *watcherSet) backlog() (watchers, queued int) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	for _, w := range ws.set {
+		queued += len(w.ch)
+	}
+	return len(ws.set), queued
+}
+
+// deliver queues msg for the watchers of room, every watcher when room
+// is empty
+func (ws *watcherSet) deliver(room string, msg *pb.ChatMessage) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	for _, w := range ws.set {
+		if room != "" && w.room != room {
+			continue
+		}
+		select {
+		case w.ch <- msg:
+		default:
+			w.once.Do(func() { close(w.lagged) })
+		}
+	}
+}
+
+// WatchRoom streams what members of a room see, without joining it. Up
+// to req.History recent public messages are sent first.
+func (r *roomServer) WatchRoom(req *pb.RoomRequest, stream pb.RoomService_WatchRoomServer) error {
+	room := DefaultRoom
+	if req.Room != "" {
+		var ok bool
+		if room, ok = normalizeRoom(req.Room); !ok {
+			return status.Error(codes.InvalidArgument, "invalid room name")
+		}
+	}
+	if r.s.access.isPrivate(room) {
+		return status.Errorf(codes.PermissionDenied, "#%s is private", room)
+	}
+	release, err := r.s.acquireStream(peerIP(stream.Context()))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// register before replaying so nothing falls in between, live
+	// messages already replayed are skipped by sequence
+	id, w := r.s.watchers.add(room)
+	defer r.s.watchers.remove(id)
+	var last uint64
+	if req.History > 0 {
+		for _, msg := range r.s.history.latest(room, int(req.History)) {
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+			last = msg.Seq
+		}
+	}
+
+	for {
+		select {
+		case msg := <-w.ch:
+			if msg.Seq != 0 && msg.Seq <= last {
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		case <-w.lagged:
+			return status.Error(codes.ResourceExhausted, "watcher fell too far behind")
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-r.s.ctx.Done():
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
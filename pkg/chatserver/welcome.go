@@ -0,0 +1,161 @@
+package chatserver
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	// maxWelcomeMessages caps the messages of one welcome
+	maxWelcomeMessages = 10
+	// maxWelcomeLength caps one welcome message when Limits has no
+	// MaxMessageLength
+	maxWelcomeLength = 4096
+)
+
+// welcomes holds the message of the day, under "", and the welcome of each
+// room, see SetWelcome
+type welcomes struct {
+	mu    sync.RWMutex
+	rooms map[string][]string
+}
+
+func (w *welcomes) get(room string) []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.rooms[room]
+}
+
+// roomNames returns the rooms with a welcome, "" for the message of the day
+func (w *welcomes) roomNames() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return slices.Collect(maps.Keys(w.rooms))
+}
+
+// set replaces the welcome of room, no messages remove it
+func (w *welcomes) set(room string, messages []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(messages) == 0 {
+		delete(w.rooms, room)
+		return
+	}
+	if w.rooms == nil {
+		w.rooms = make(map[string][]string)
+	}
+	w.rooms[room] = messages
+}
+
+// sendWelcome sends the welcome of room to a connection that just entered
+// it, preceded by the message of the day when it just joined the chat
+func (s *ChatServer) sendWelcome(stream pb.ChatService_RealtimeChatServer, clientID, room string, joined bool) {
+	var msgs []*pb.ChatMessage
+	add := func(kind string, texts []string) {
+		for _, text := range texts {
+			msgs = append(msgs, &pb.ChatMessage{
+				User:      "System",
+				Text:      text,
+				Room:      room,
+				Type:      pb.MessageType_TYPE_SYSTEM,
+				Timestamp: time.Now().UnixMilli(),
+				Metadata:  map[string]string{"welcome": kind},
+			})
+		}
+	}
+	if joined {
+		add("motd", s.welcomes.get(""))
+	}
+	add("room", s.welcomes.get(room))
+	if s.settings.get(room).Nsfw {
+		msg := systemText(i18n.SettingsNSFW, "room", room)
+		msg.Room = room
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) == 0 {
+		return
+	}
+
+	s.mu.RLock()
+	user := s.connections[clientID].user
+	s.mu.RUnlock()
+	for _, msg := range msgs {
+		msg.EphemeralTo = user
+		if err := stream.Send(msg); err != nil {
+			log.Printf("Failed to send welcome to %s: %v", clientID, err)
+			return
+		}
+	}
+}
+
+// GetWelcome returns the welcome of a room, or the message of the day
+func (a *adminServer) GetWelcome(ctx context.Context, req *pb.WelcomeRequest) (*pb.Welcome, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	room, err := welcomeRoom(req.Room)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Welcome{Room: room, Messages: a.s.welcomes.get(room)}, nil
+}
+
+// SetWelcome replaces the welcome of a room, or the message of the day
+func (a *adminServer) SetWelcome(ctx context.Context, req *pb.Welcome) (*pb.Welcome, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	room, err := welcomeRoom(req.Room)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Messages) > maxWelcomeMessages {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d welcome messages", maxWelcomeMessages)
+	}
+	max := a.s.limits.MaxMessageLength
+	if max <= 0 {
+		max = maxWelcomeLength
+	}
+	var messages []string
+	for _, text := range req.Messages {
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "":
+			return nil, status.Error(codes.InvalidArgument, "welcome messages cannot be empty")
+		case len(text) > max:
+			return nil, status.Errorf(codes.InvalidArgument, "welcome messages are limited to %d bytes", max)
+		}
+		messages = append(messages, text)
+	}
+	a.s.welcomes.set(room, messages)
+	if room == "" {
+		log.Printf("Message of the day set to %d messages", len(messages))
+	} else {
+		log.Printf("Welcome of #%s set to %d messages", room, len(messages))
+	}
+	return &pb.Welcome{Room: room, Messages: slices.Clone(messages)}, nil
+}
+
+// welcomeRoom validates the room of a welcome request, empty stands for
+// the message of the day
+func welcomeRoom(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	room, ok := normalizeRoom(name)
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "%q is not a valid room name", name)
+	}
+	return room, nil
+}
@@ -0,0 +1,65 @@
+package chattest_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+// benchClients are the receiver counts of the delivery benchmarks
+var benchClients = []int{10, 100}
+
+// BenchmarkGRPCBroadcast measures how long a message takes to reach
+// every gRPC receiver in the room
+func BenchmarkGRPCBroadcast(b *testing.B) {
+	for _, n := range benchClients {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			env := chattest.Start(b)
+			sender := env.DialGRPC(b, "sender")
+			receivers := make([]*chattest.GRPCClient, n)
+			for i := range receivers {
+				receivers[i] = env.DialGRPC(b, fmt.Sprintf("r%d", i))
+			}
+
+			b.ReportAllocs()
+			i := 0
+			for b.Loop() {
+				text := strconv.Itoa(i)
+				i++
+				sender.Send(b, text)
+				for _, r := range receivers {
+					r.ExpectMessage(b, func(m *pb.ChatMessage) bool { return m.Text == text })
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWSFanout measures how long a message sent over gRPC takes to
+// reach every WebSocket receiver through the gateway
+func BenchmarkWSFanout(b *testing.B) {
+	for _, n := range benchClients {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			env := chattest.Start(b)
+			sender := env.DialGRPC(b, "sender")
+			receivers := make([]*chattest.WSClient, n)
+			for i := range receivers {
+				receivers[i] = env.DialWS(b, fmt.Sprintf("r%d", i))
+			}
+
+			b.ReportAllocs()
+			i := 0
+			for b.Loop() {
+				text := strconv.Itoa(i)
+				i++
+				sender.Send(b, text)
+				for _, r := range receivers {
+					r.ExpectMessage(b, func(f chattest.Frame) bool { return f.Type == "chat" && f.Text == text })
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package chattest runs the chat server and the web gateway in-process on
+// ephemeral ports so feature tests can exercise them end to end.
+package chattest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"realTimeChat/pkg/chatserver"
+	"realTimeChat/pkg/gateway"
+)
+
+// DefaultTimeout bounds how long helpers wait for an expected event
+const DefaultTimeout = 5 * time.Second
+
+// Env is a running chat server and gateway pair
+type Env struct {
+	GRPCAddr string // chat server address
+	HTTPAddr string // gateway address
+	Server   *chatserver.ChatServer
+
+	Gateway *gateway.Gateway
+
+	httpServer *http.Server
+	wg         sync.WaitGroup // serve loops
+
+	mu        sync.Mutex
+	clients   []interface{ Close() } // clients to tear down first
+	senders   map[string]*GRPCClient // clients created by SendAs
+	closeOnce sync.Once
+}
+
+// Start starts a new environment and registers its teardown with t,
+// opts configure the chat server
+func Start(t testing.TB, opts ...chatserver.Option) *Env {
+	t.Helper()
+
+	env, err := NewEnv(opts...)
+	if err != nil {
+		t.Fatalf("chattest: start environment: %v", err)
+	}
+	t.Cleanup(env.Close)
+	return env
+}
+
+// NewEnv starts the chat server and gateway on ephemeral ports
+func NewEnv(opts ...chatserver.Option) (*Env, error) {
+	gin.SetMode(gin.TestMode)
+
+	// 1. chat server
+	grpcLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen grpc: %w", err)
+	}
+	httpLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		grpcLis.Close()
+		return nil, fmt.Errorf("listen http: %w", err)
+	}
+
+	env := &Env{
+		GRPCAddr: grpcLis.Addr().String(),
+		HTTPAddr: httpLis.Addr().String(),
+		Server:   chatserver.NewChatServer(opts...),
+		senders:  make(map[string]*GRPCClient),
+	}
+
+	// 2. gateway in front of it
+	env.Gateway = gateway.New(gateway.WithUpstream(env.GRPCAddr))
+	env.httpServer = &http.Server{Handler: env.Gateway.Handler()}
+
+	env.wg.Add(2)
+	go func() {
+		defer env.wg.Done()
+		_ = env.Server.Serve(grpcLis)
+	}()
+	go func() {
+		defer env.wg.Done()
+		_ = env.httpServer.Serve(httpLis)
+	}()
+	return env, nil
+}
+
+// Close tears everything down: clients, then gateway, then chat server.
+// It returns once every serve loop has exited.
+func (e *Env) Close() {
+	e.closeOnce.Do(func() {
+		e.mu.Lock()
+		clients := e.clients
+		e.clients = nil
+		e.mu.Unlock()
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+
+		_ = e.httpServer.Close()
+		e.Gateway.Close()
+		e.Server.Stop()
+		e.wg.Wait()
+	})
+}
+
+// WSURL returns the gateway WebSocket endpoint
+func (e *Env) WSURL() string {
+	return "ws://" + e.HTTPAddr + "/ws"
+}
+
+// SendAs sends a public message as username, dialing a gRPC client for
+// that user on first use
+func (e *Env) SendAs(t testing.TB, username, text string) *GRPCClient {
+	t.Helper()
+
+	e.mu.Lock()
+	c, ok := e.senders[username]
+	e.mu.Unlock()
+	if !ok {
+		c = e.DialGRPC(t, username)
+		e.mu.Lock()
+		e.senders[username] = c
+		e.mu.Unlock()
+	}
+	c.Send(t, text)
+	return c
+}
+
+// track remembers a client so Close can shut it down
+func (e *Env) track(c interface{ Close() }) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clients = append(e.clients, c)
+}
+
+// waitOnline blocks until the chat server lists username as connected
+func (e *Env) waitOnline(t testing.TB, username string) {
+	t.Helper()
+
+	deadline := time.Now().Add(DefaultTimeout)
+	for !slices.Contains(e.Server.OnlineUsers(), username) {
+		if time.Now().After(deadline) {
+			t.Fatalf("chattest: %s did not come online within %v", username, DefaultTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,53 @@
+package chattest_test
+
+import (
+	"testing"
+
+	"realTimeChat/pkg/chatserver"
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+func TestGRPCToWebSocket(t *testing.T) {
+	env := chattest.Start(t)
+	alice := env.DialGRPC(t, "alice")
+	bob := env.DialWS(t, "bob")
+
+	alice.Send(t, "hello from grpc")
+	f := bob.ExpectMessage(t, func(f chattest.Frame) bool { return f.Text == "hello from grpc" })
+	if f.User != "alice" || f.Room != chatserver.DefaultRoom || f.ID == "" {
+		t.Errorf("got frame %+v, want a default room message from alice with an ID", f.WSMessage)
+	}
+}
+
+func TestWebSocketToGRPC(t *testing.T) {
+	env := chattest.Start(t)
+	alice := env.DialGRPC(t, "alice")
+	bob := env.DialWS(t, "bob")
+
+	bob.Send(t, "hello from ws")
+	msg := alice.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.Text == "hello from ws" })
+	if msg.User != "bob" || msg.Seq == 0 {
+		t.Errorf("got %v, want a sequenced message from bob", msg)
+	}
+
+	bob.SendPM(t, "alice", "psst")
+	msg = alice.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.Text == "psst" })
+	if msg.RecipientUser != "alice" || msg.Room != "" {
+		t.Errorf("got %v, want a private message to alice", msg)
+	}
+}
+
+func TestSendAs(t *testing.T) {
+	env := chattest.Start(t)
+	alice := env.DialGRPC(t, "alice")
+
+	first := env.SendAs(t, "carol", "one")
+	second := env.SendAs(t, "carol", "two")
+	if first != second {
+		t.Error("SendAs dialed carol twice")
+	}
+	for _, text := range []string{"one", "two"} {
+		alice.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.User == "carol" && m.Text == text })
+	}
+}
@@ -0,0 +1,231 @@
+package chattest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"realTimeChat/pkg/chatclient"
+	"realTimeChat/pkg/gateway"
+	pb "realTimeChat/proto/chat"
+)
+
+// GRPCClient is a chat session speaking gRPC directly to the server
+type GRPCClient struct {
+	User    string
+	Timeout time.Duration // ExpectMessage deadline, DefaultTimeout by default
+	Chat    *chatclient.Client
+
+	msgs      chan *pb.ChatMessage
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// DialGRPC opens a chat session as username and waits until the server
+// has registered it. The session does not reconnect so dropped streams
+// surface as test failures.
+func (e *Env) DialGRPC(t testing.TB, username string) *GRPCClient {
+	t.Helper()
+
+	c := &GRPCClient{
+		User:    username,
+		Timeout: DefaultTimeout,
+		msgs:    make(chan *pb.ChatMessage, 256),
+		stop:    make(chan struct{}),
+	}
+	chat, err := chatclient.Connect(context.Background(), e.GRPCAddr, username,
+		chatclient.WithReconnect(false),
+		chatclient.WithHandler(func(msg *pb.ChatMessage) {
+			select {
+			case c.msgs <- msg:
+			case <-c.stop:
+			}
+		}))
+	if err != nil {
+		t.Fatalf("chattest: connect %s: %v", username, err)
+	}
+	c.Chat = chat
+	e.track(c)
+
+	e.waitOnline(t, username)
+	return c
+}
+
+// Send sends a public message
+func (c *GRPCClient) Send(t testing.TB, text string) {
+	t.Helper()
+	if err := c.Chat.Send(text); err != nil {
+		t.Fatalf("chattest: %s send: %v", c.User, err)
+	}
+}
+
+// SendPM sends a private message to recipient
+func (c *GRPCClient) SendPM(t testing.TB, recipient, text string) {
+	t.Helper()
+	if err := c.Chat.SendPM(recipient, text); err != nil {
+		t.Fatalf("chattest: %s send: %v", c.User, err)
+	}
+}
+
+// ExpectMessage waits for a message accepted by match, skipping others,
+// and fails the test if none arrives within c.Timeout
+func (c *GRPCClient) ExpectMessage(t testing.TB, match func(*pb.ChatMessage) bool) *pb.ChatMessage {
+	t.Helper()
+
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case msg := <-c.msgs:
+			if match == nil || match(msg) {
+				return msg
+			}
+		case <-c.Chat.Done():
+			t.Fatalf("chattest: %s stream closed while waiting for message", c.User)
+			return nil
+		case <-timer.C:
+			t.Fatalf("chattest: %s timed out after %v waiting for message", c.User, c.Timeout)
+			return nil
+		}
+	}
+}
+
+// Close leaves the chat and waits for the session to end
+func (c *GRPCClient) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		_ = c.Chat.Close()
+	})
+}
+
+// Frame is one JSON event received from the gateway
+type Frame struct {
+	gateway.WSMessage
+	Users []string `json:"users,omitempty"`
+}
+
+// WSClient is a chat session speaking to the gateway over WebSocket
+type WSClient struct {
+	User    string
+	Timeout time.Duration // ExpectMessage deadline, DefaultTimeout by default
+
+	conn      *websocket.Conn
+	frames    chan Frame
+	stop      chan struct{}
+	done      chan struct{} // closed when the read loop exits
+	closeOnce sync.Once
+}
+
+// DialWS connects to the gateway, joins as username and waits for the
+// initial user list
+func (e *Env) DialWS(t testing.TB, username string) *WSClient {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(e.WSURL(), nil)
+	if err != nil {
+		t.Fatalf("chattest: dial ws: %v", err)
+	}
+
+	c := &WSClient{
+		User:    username,
+		Timeout: DefaultTimeout,
+		conn:    conn,
+		frames:  make(chan Frame, 256),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	e.track(c)
+
+	c.write(t, gateway.WSMessage{Type: "join", User: username, Text: "has joined"})
+	f := c.ExpectMessage(t, func(f Frame) bool {
+		return f.Type == "userList" || f.Type == "error"
+	})
+	if f.Type == "error" {
+		t.Fatalf("chattest: %s join failed: %s", username, f.Text)
+	}
+	e.waitOnline(t, username)
+	return c
+}
+
+// readLoop decodes frames, the gateway may batch several per message
+// separated by newlines
+func (c *WSClient) readLoop() {
+	defer close(c.done)
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		for _, line := range bytes.Split(data, []byte{'\n'}) {
+			var f Frame
+			if err := json.Unmarshal(line, &f); err != nil {
+				continue
+			}
+			select {
+			case c.frames <- f:
+			case <-c.stop:
+				return
+			}
+		}
+	}
+}
+
+// Send sends a public message
+func (c *WSClient) Send(t testing.TB, text string) {
+	t.Helper()
+	c.write(t, gateway.WSMessage{Type: "chat", User: c.User, Text: text})
+}
+
+// SendPM sends a private message to recipient
+func (c *WSClient) SendPM(t testing.TB, recipient, text string) {
+	t.Helper()
+	c.write(t, gateway.WSMessage{Type: "chat", User: c.User, Text: text, RecipientUser: recipient})
+}
+
+func (c *WSClient) write(t testing.TB, msg gateway.WSMessage) {
+	t.Helper()
+	msg.Timestamp = time.Now().Format(time.RFC3339)
+	if err := c.conn.WriteJSON(msg); err != nil {
+		t.Fatalf("chattest: %s write: %v", c.User, err)
+	}
+}
+
+// ExpectMessage waits for a frame accepted by match, skipping others,
+// and fails the test if none arrives within c.Timeout
+func (c *WSClient) ExpectMessage(t testing.TB, match func(Frame) bool) Frame {
+	t.Helper()
+
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case f := <-c.frames:
+			if match == nil || match(f) {
+				return f
+			}
+		case <-c.done:
+			t.Fatalf("chattest: %s connection closed while waiting for frame", c.User)
+			return Frame{}
+		case <-timer.C:
+			t.Fatalf("chattest: %s timed out after %v waiting for frame", c.User, c.Timeout)
+			return Frame{}
+		}
+	}
+}
+
+// Close closes the connection and waits for the read loop to exit
+func (c *WSClient) Close() {
+	c.closeOnce.Do(func() {
+		_ = c.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		close(c.stop)
+		c.conn.Close()
+		<-c.done
+	})
+}
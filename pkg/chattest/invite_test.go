@@ -0,0 +1,135 @@
+package chattest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/chatserver"
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+const adminToken = "test-admin-token"
+
+// dialAdmin returns admin and room clients for env and a context carrying
+// the admin token
+func dialAdmin(t *testing.T, env *chattest.Env) (pb.AdminServiceClient, pb.RoomServiceClient, context.Context) {
+	t.Helper()
+	conn, err := grpc.NewClient(env.GRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+adminToken)
+	return pb.NewAdminServiceClient(conn), pb.NewRoomServiceClient(conn), ctx
+}
+
+func isRoomChange(room string) func(*pb.ChatMessage) bool {
+	return func(m *pb.ChatMessage) bool {
+		return m.Type == pb.MessageType_TYPE_ROOM_CHANGE && m.GetRoomChange().GetTo() == room
+	}
+}
+
+func TestRefusedJoinKeepsInvite(t *testing.T) {
+	env := chattest.Start(t, chatserver.WithAdminToken(adminToken))
+	admin, rooms, ctx := dialAdmin(t, env)
+	if _, err := admin.SetRoomPrivate(ctx, &pb.SetRoomPrivateRequest{Room: "vip", Private: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.SetQuota(ctx, &pb.SetQuotaRequest{
+		Scope: pb.QuotaScope_QUOTA_ROOM, Name: "vip", Quota: &pb.Quota{MaxMembers: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := admin.CreateInvite(ctx, &pb.CreateInviteRequest{Room: "vip", MaxUses: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := admin.CreateInvite(ctx, &pb.CreateInviteRequest{Room: "vip", MaxUses: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carol := env.DialGRPC(t, "carol")
+	carol.Send(t, "/join vip "+first.Token)
+	carol.ExpectMessage(t, isRoomChange("vip"))
+
+	bob := env.DialGRPC(t, "bob")
+	bob.Send(t, "/join vip "+second.Token)
+	bob.ExpectMessage(t, func(m *pb.ChatMessage) bool {
+		return m.Type == pb.MessageType_TYPE_SYSTEM && strings.Contains(m.Text, "full")
+	})
+	inv, err := rooms.GetInvite(context.Background(), &pb.InviteRequest{Token: second.Token})
+	if err != nil {
+		t.Fatalf("GetInvite after a refused join: %v", err)
+	}
+	if inv.Uses != 0 {
+		t.Errorf("refused join used the invite, uses = %d", inv.Uses)
+	}
+
+	carol.Send(t, "/join "+chatserver.DefaultRoom)
+	carol.ExpectMessage(t, isRoomChange(chatserver.DefaultRoom))
+	bob.Send(t, "/join vip "+second.Token)
+	bob.ExpectMessage(t, isRoomChange("vip"))
+}
+
+func TestListUsersHidesPrivateRooms(t *testing.T) {
+	env := chattest.Start(t, chatserver.WithAdminToken(adminToken))
+	admin, rooms, ctx := dialAdmin(t, env)
+	if _, err := admin.SetRoomPrivate(ctx, &pb.SetRoomPrivateRequest{Room: "vip", Private: true}); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := admin.CreateInvite(ctx, &pb.CreateInviteRequest{Room: "vip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	carol := env.DialGRPC(t, "carol")
+	carol.Send(t, "/join #VIP "+inv.Token)
+	carol.ExpectMessage(t, isRoomChange("vip"))
+	bob := env.DialGRPC(t, "bob")
+
+	if _, err := bob.Chat.ListUsers(context.Background(), "vip"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ListUsers of a private room by a stranger = %v, want PermissionDenied", err)
+	}
+	if _, err := rooms.ListUsers(context.Background(), &pb.ListUsersRequest{Room: "vip"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("anonymous ListUsers of a private room = %v, want PermissionDenied", err)
+	}
+	roomsOf := func(users []*pb.OnlineUser, name string) []string {
+		for _, u := range users {
+			if u.Name == name {
+				return u.Rooms
+			}
+		}
+		t.Fatalf("%s is not listed", name)
+		return nil
+	}
+	users, err := bob.Chat.ListUsers(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := roomsOf(users, "carol"); len(got) != 0 {
+		t.Errorf("stranger sees carol in %v", got)
+	}
+
+	users, err = carol.Chat.ListUsers(context.Background(), "vip")
+	if err != nil {
+		t.Fatalf("member ListUsers of a private room: %v", err)
+	}
+	if got := roomsOf(users, "carol"); len(got) != 1 || got[0] != "vip" {
+		t.Errorf("member sees carol in %v, want [vip]", got)
+	}
+	resp, err := rooms.ListUsers(ctx, &pb.ListUsersRequest{Room: "vip"})
+	if err != nil {
+		t.Fatalf("admin ListUsers of a private room: %v", err)
+	}
+	if got := roomsOf(resp.Users, "carol"); len(got) != 1 || got[0] != "vip" {
+		t.Errorf("admin sees carol in %v, want [vip]", got)
+	}
+}
@@ -0,0 +1,45 @@
+package chattest_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+func TestKeywordMixedCaseRoom(t *testing.T) {
+	env := chattest.Start(t)
+	carol := env.DialGRPC(t, "carol")
+	bob := env.DialGRPC(t, "bob")
+	for _, c := range []*chattest.GRPCClient{carol, bob} {
+		c.Send(t, "/join ops")
+		c.ExpectMessage(t, isRoomChange("ops"))
+	}
+
+	if err := carol.Chat.AddKeyword(context.Background(), "#Ops", "deploy"); err != nil {
+		t.Fatal(err)
+	}
+	bob.Send(t, "deploy is done")
+	hit := carol.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.Type == pb.MessageType_TYPE_KEYWORD_HIT })
+	if h := hit.GetKeywordHit(); h.Room != "ops" || h.Keyword != "deploy" {
+		t.Errorf("got keyword hit %v, want deploy in ops", h)
+	}
+
+	conn, err := grpc.NewClient(env.GRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	prefs, err := pb.NewPreferencesServiceClient(conn).RemoveKeyword(context.Background(),
+		&pb.KeywordRequest{User: "carol", Room: "OPS", Word: "deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefs.Keywords) != 0 {
+		t.Errorf("keywords after removing the only one for OPS: %v", prefs.Keywords)
+	}
+}
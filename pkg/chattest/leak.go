@@ -0,0 +1,34 @@
+package chattest
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// leakTimeout bounds how long CheckGoroutines waits for goroutines to
+// wind down
+const leakTimeout = 5 * time.Second
+
+// CheckGoroutines records the number of running goroutines and, when t
+// ends, fails it if more are still running once the environments and
+// clients cleaned up after it have closed. Call it before Start so its
+// check runs last.
+func CheckGoroutines(t testing.TB) {
+	t.Helper()
+
+	baseline := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(leakTimeout)
+		n := runtime.NumGoroutine()
+		for n > baseline && time.Now().Before(deadline) {
+			time.Sleep(20 * time.Millisecond)
+			n = runtime.NumGoroutine()
+		}
+		if n > baseline {
+			buf := make([]byte, 1<<20)
+			buf = buf[:runtime.Stack(buf, true)]
+			t.Errorf("chattest: %d goroutines leaked, %d running, %d before:\n%s", n-baseline, n, baseline, buf)
+		}
+	})
+}
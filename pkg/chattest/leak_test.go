@@ -0,0 +1,33 @@
+package chattest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+// churn is how many clients of each kind TestConnectionChurn connects
+// and disconnects
+const churn = 20
+
+func TestConnectionChurn(t *testing.T) {
+	chattest.CheckGoroutines(t)
+	env := chattest.Start(t)
+	watcher := env.DialGRPC(t, "watcher")
+
+	for i := range churn {
+		name := fmt.Sprintf("grpc%d", i)
+		c := env.DialGRPC(t, name)
+		c.Send(t, "hi")
+		watcher.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.User == name && m.Text == "hi" })
+		c.Close()
+
+		name = fmt.Sprintf("ws%d", i)
+		ws := env.DialWS(t, name)
+		ws.Send(t, "hi")
+		watcher.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.User == name && m.Text == "hi" })
+		ws.Close()
+	}
+}
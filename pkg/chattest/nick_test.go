@@ -0,0 +1,51 @@
+package chattest_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+// waitStreams waits until the server has n open streams
+func waitStreams(t *testing.T, env *chattest.Env, n int) {
+	t.Helper()
+	deadline := time.Now().Add(chattest.DefaultTimeout)
+	for env.Server.StreamStats().Open != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d streams open, want %d", env.Server.StreamStats().Open, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNickRefusedWithOtherStreams(t *testing.T) {
+	env := chattest.Start(t)
+	first := env.DialGRPC(t, "alice")
+	second := env.DialGRPC(t, "alice")
+	// DialGRPC returns once alice is online, which the first stream already
+	// made her; a command is only answered once its stream is registered
+	second.Send(t, "/join lobby")
+	second.ExpectMessage(t, isRoomChange("lobby"))
+
+	first.Send(t, "/nick alicia")
+	first.ExpectMessage(t, func(m *pb.ChatMessage) bool {
+		if m.Type == pb.MessageType_TYPE_RENAME {
+			t.Fatal("renamed one of two streams")
+		}
+		return m.Type == pb.MessageType_TYPE_SYSTEM && strings.Contains(m.Text, "other sessions")
+	})
+	if got := first.Chat.Username(); got != "alice" {
+		t.Errorf("refused rename left the client as %q", got)
+	}
+
+	second.Close()
+	waitStreams(t, env, 1)
+	first.Send(t, "/nick alicia")
+	msg := first.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.Type == pb.MessageType_TYPE_RENAME })
+	if r := msg.GetRename(); r.OldUser != "alice" || r.NewUser != "alicia" {
+		t.Errorf("got rename %v, want alice to alicia", r)
+	}
+}
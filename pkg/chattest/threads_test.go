@@ -0,0 +1,51 @@
+package chattest_test
+
+import (
+	"context"
+	"testing"
+
+	"realTimeChat/pkg/chatserver"
+	"realTimeChat/pkg/chattest"
+	pb "realTimeChat/proto/chat"
+)
+
+func TestReplyOnlyReachesFollowers(t *testing.T) {
+	env := chattest.Start(t)
+	alice := env.DialGRPC(t, "alice")
+	carol := env.DialGRPC(t, "carol")
+	bob := env.DialWS(t, "bob")
+
+	alice.Send(t, "root")
+	root := carol.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.Text == "root" })
+	bob.ExpectMessage(t, func(f chattest.Frame) bool { return f.Text == "root" })
+
+	if err := carol.Chat.Reply(chatserver.DefaultRoom, root.Id, "secret reply"); err != nil {
+		t.Fatal(err)
+	}
+	reply := alice.ExpectMessage(t, func(m *pb.ChatMessage) bool { return m.Text == "secret reply" })
+	if reply.Seq != 0 {
+		t.Errorf("reply has room sequence %d", reply.Seq)
+	}
+
+	// a reply taking a room sequence would leave bob a gap here, which the
+	// gateway fills from the room's history
+	alice.Send(t, "after")
+	bob.ExpectMessage(t, func(f chattest.Frame) bool {
+		if f.Text == "secret reply" {
+			t.Errorf("non-follower got the reply: %+v", f.WSMessage)
+		}
+		return f.Text == "after"
+	})
+
+	rooms, err := carol.Chat.Catchup(context.Background(), map[string]uint64{chatserver.DefaultRoom: 0}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rooms {
+		for _, m := range r.Messages {
+			if m.ThreadId != "" {
+				t.Errorf("catch-up of #%s includes reply %q", r.Room, m.Text)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package gateway
+
+import "time"
+
+// activityInterval bounds how often a browser's activity hints reach the
+// chat server while its idle state stays the same
+const activityInterval = 30 * time.Second
+
+// handleActivity forwards the browser's idle hint, sent on visibility
+// changes and user input
+func (c *WSClient) handleActivity(msg WSMessage) {
+	if c.chat == nil {
+		return
+	}
+	wasIdle := c.idle.Swap(msg.Idle)
+	if !msg.Idle && !wasIdle && time.Since(c.lastActive) < activityInterval {
+		return
+	}
+	if msg.Idle && wasIdle {
+		return
+	}
+	if !msg.Idle {
+		c.lastActive = time.Now()
+	}
+	if err := c.chat.SetIdle(msg.Idle); err != nil {
+		c.gw.log.Debugf("Failed to forward activity of %s: %v", c.chat.Username(), err)
+	}
+}
@@ -0,0 +1,477 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/internal/attachmeta"
+	"realTimeChat/pkg/ids"
+	pb "realTimeChat/proto/chat"
+)
+
+// limits for voice uploads
+const (
+	MaxVoiceSize     = 2 << 20
+	MaxVoiceDuration = 5 * time.Minute
+)
+
+// limits for file uploads
+const (
+	MaxFileSize = 25 << 20
+)
+
+// Attachment is the attachment metadata sent to WebSocket clients
+type Attachment struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"` // voice, gif or file
+	MimeType   string `json:"mimeType"`
+	Size       int64  `json:"size"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+	URL        string `json:"url"`
+	Width      int32  `json:"width,omitempty"`
+	Height     int32  `json:"height,omitempty"`
+	PreviewURL string `json:"previewUrl,omitempty"`
+	Name       string `json:"name,omitempty"` // original file name of files
+
+	Thumbnails []Thumbnail `json:"thumbnails,omitempty"` // of images, smallest first
+}
+
+// Thumbnail is a scaled down image attachment
+type Thumbnail struct {
+	Size   int32  `json:"size"`
+	URL    string `json:"url"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+}
+
+// publicAttachment converts the attachment of a chat message for clients
+func publicAttachment(a *pb.Attachment) *Attachment {
+	out := &Attachment{
+		ID:         a.Id,
+		Kind:       a.Kind,
+		MimeType:   a.MimeType,
+		Size:       a.Size,
+		DurationMs: a.DurationMs,
+		URL:        a.Url,
+		Width:      a.Width,
+		Height:     a.Height,
+		PreviewURL: a.PreviewUrl,
+		Name:       a.Name,
+	}
+	for _, t := range a.Thumbnails {
+		out.Thumbnails = append(out.Thumbnails, Thumbnail{Size: t.Size, URL: t.Url, Width: t.Width, Height: t.Height})
+	}
+	return out
+}
+
+// attachmentID matches the IDs of both attachment stores, ULIDs from
+// the versions that used them
+var attachmentID = regexp.MustCompile(`^(?:[0-9a-f]{32}|[0-9A-HJKMNP-TV-Z]{26})$`)
+
+// attachmentIDs names uploads with 128 random bits, the ID is the only
+// key to a file
+var attachmentIDs = ids.NewRandom()
+
+// attachmentStore keeps uploads as files in dir, each with a JSON
+// metadata file, so they survive gateway restarts. Uploads the virus
+// scanner flagged are kept in dir/quarantine and never served, unfinished
+// resumable uploads in dir/resumable.
+type attachmentStore struct {
+	dir         string
+	mu          sync.RWMutex
+	meta        map[string]attachmeta.Meta
+	quarantined map[string]attachmeta.Meta
+
+	partMu  sync.Mutex
+	sending map[string]bool // resumable uploads with a PATCH in progress
+}
+
+func newAttachmentStore(dir string) (*attachmentStore, error) {
+	for _, sub := range []string{"quarantine", "resumable"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return nil, err
+		}
+	}
+	s := &attachmentStore{dir: dir, meta: make(map[string]attachmeta.Meta), quarantined: make(map[string]attachmeta.Meta), sending: make(map[string]bool)}
+	for sub, into := range map[string]map[string]attachmeta.Meta{"": s.meta, "quarantine": s.quarantined} {
+		files, err := filepath.Glob(filepath.Join(dir, sub, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				continue
+			}
+			var m attachmeta.Meta
+			if json.Unmarshal(data, &m) == nil && attachmentID.MatchString(m.ID) {
+				into[m.ID] = m
+			}
+		}
+	}
+	return s, nil
+}
+
+// save writes data and its metadata, the metadata goes last so a crash
+// never leaves metadata without a file
+func (s *attachmentStore) save(m attachmeta.Meta, data []byte) (attachmeta.Meta, error) {
+	m.ID = attachmentIDs.New()
+	return s.put(m, data)
+}
+
+// put stores data under m.ID, in quarantine when m has a threat
+func (s *attachmentStore) put(m attachmeta.Meta, data []byte) (attachmeta.Meta, error) {
+	m.Size = int64(len(data))
+	m.Created = time.Now().UTC()
+	dir, into := s.dir, s.meta
+	if m.Threat != "" {
+		dir, into = filepath.Join(s.dir, "quarantine"), s.quarantined
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, m.ID), data, 0o640); err != nil {
+		return m, err
+	}
+	meta, _ := json.Marshal(m)
+	if err := os.WriteFile(filepath.Join(dir, m.ID+".json"), meta, 0o640); err != nil {
+		os.Remove(filepath.Join(dir, m.ID))
+		return m, err
+	}
+
+	s.mu.Lock()
+	into[m.ID] = m
+	s.mu.Unlock()
+	return m, nil
+}
+
+func (s *attachmentStore) get(id string) (attachmeta.Meta, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.meta[id]
+	return m, ok
+}
+
+// isQuarantined reports whether the upload id was quarantined
+func (s *attachmentStore) isQuarantined(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.quarantined[id]
+	return ok
+}
+
+// markQuarantined remembers that the chat server quarantined id
+func (s *attachmentStore) markQuarantined(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quarantined[id] = attachmeta.Meta{ID: id}
+}
+
+// attachment routers, uploads and range-capable, limited downloads
+func (g *Gateway) setupAttachmentRoutes(r gin.IRouter) {
+	r.POST("/api/uploads/voice", g.handleVoiceUpload)
+	r.POST("/api/uploads/file", g.handleFileUpload)
+	r.Match([]string{http.MethodGet, http.MethodHead}, "/api/attachments/:id", g.limitDownloads, g.handleAttachmentDownload)
+	r.Match([]string{http.MethodGet, http.MethodHead}, "/api/attachments/:id/thumbnails/:size", g.limitDownloads, g.handleThumbnailDownload)
+	g.setupResumableRoutes(r)
+}
+
+// handleVoiceUpload accepts a multipart "file" field with a short audio
+// clip. The container is sniffed from its content and the duration read
+// from it, falling back to the client's "durationMs" field for formats
+// that do not record it.
+func (g *Gateway) handleVoiceUpload(c *gin.Context) {
+	if g.attachments == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "uploads are disabled"})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxVoiceSize+64<<10)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "voice messages are limited to 2MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing file field"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, MaxVoiceSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(data) > MaxVoiceSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "voice messages are limited to 2MB"})
+		return
+	}
+
+	mimeType, duration, err := sniffAudio(data)
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	}
+	if duration == 0 {
+		if ms, err := strconv.ParseInt(c.PostForm("durationMs"), 10, 64); err == nil && ms > 0 {
+			duration = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if duration > MaxVoiceDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "voice messages are limited to 5 minutes"})
+		return
+	}
+
+	if !g.scanUpload(c, "", data) {
+		return
+	}
+	m, err := g.attachments.save(attachmeta.Meta{
+		Kind:       "voice",
+		MimeType:   mimeType,
+		DurationMs: duration.Milliseconds(),
+	}, data)
+	if err != nil {
+		g.log.Errorf("Failed to store upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store upload"})
+		return
+	}
+	g.log.Infof("Stored %s voice upload %s (%d bytes, %v)", mimeType, m.ID, m.Size, duration)
+	c.JSON(http.StatusCreated, publicAttachment(m.Proto()))
+}
+
+// handleFileUpload accepts a multipart "file" field with any file up to
+// MaxFileSize, its name is kept for downloads
+func (g *Gateway) handleFileUpload(c *gin.Context) {
+	if g.attachments == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "uploads are disabled"})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxFileSize+64<<10)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "files are limited to 25MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing file field"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, MaxFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(data) > MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "files are limited to 25MB"})
+		return
+	}
+
+	g.storeFile(c, attachmeta.CleanName(fh.Filename), data)
+}
+
+// storeFile scans, processes and stores a complete file named name and
+// answers the request with the attachment
+func (g *Gateway) storeFile(c *gin.Context, name string, data []byte) {
+	if !g.scanUpload(c, name, data) {
+		return
+	}
+	m := attachmeta.Meta{
+		ID:       attachmentIDs.New(),
+		Kind:     "file",
+		MimeType: http.DetectContentType(data),
+		Name:     name,
+	}
+	var err error
+	if strings.HasPrefix(m.MimeType, "image/") {
+		if data, err = g.attachments.processImage(&m, data, g.thumbnailSizes); err != nil {
+			g.log.Errorf("Failed to process image upload: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store upload"})
+			return
+		}
+	}
+	m, err = g.attachments.put(m, data)
+	if err != nil {
+		g.attachments.removeThumbnails(m)
+		g.log.Errorf("Failed to store upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store upload"})
+		return
+	}
+	g.log.Infof("Stored file upload %s %q (%d bytes)", m.ID, m.Name, m.Size)
+	c.JSON(http.StatusCreated, publicAttachment(m.Proto()))
+}
+
+// handleAttachmentDownload serves a stored file, http.ServeContent
+// answers Range requests so players can seek and If-None-Match so
+// browsers revalidate without a download
+func (g *Gateway) handleAttachmentDownload(c *gin.Context) {
+	id := c.Param("id")
+	if g.attachments == nil || !attachmentID.MatchString(id) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	m, ok := g.attachments.get(id)
+	if !ok && g.redirectPresigned(c, id, 0) {
+		return
+	}
+	if !ok {
+		m, ok = g.fetchAttachment(c.Request.Context(), id)
+	}
+	if !ok && g.attachments.isQuarantined(id) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "attachment is quarantined"})
+		return
+	}
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	f, err := os.Open(filepath.Join(g.attachments.dir, id))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", m.MimeType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	if m.Kind == "file" {
+		// never render uploaded files in the page's origin
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": m.Name}))
+	} else {
+		h.Set("Content-Disposition", "inline")
+	}
+	h.Set("Cache-Control", "private, max-age=86400, immutable")
+	// files never change under their ID, which makes a strong ETag
+	h.Set("ETag", `"`+id+`"`)
+	http.ServeContent(c.Writer, c.Request, "", m.Created, f)
+}
+
+// redirectPresigned sends the browser to a presigned URL of the file or
+// thumbnail when the chat server keeps attachments in object storage,
+// false means it does not and the caller serves the request itself
+func (g *Gateway) redirectPresigned(c *gin.Context, id string, thumbnail int) bool {
+	conn, err := g.upstreamConn()
+	if err != nil {
+		return false
+	}
+	resp, err := pb.NewAttachmentServiceClient(conn).GetDownloadUrl(c.Request.Context(), &pb.AttachmentRequest{Id: id, Thumbnail: int32(thumbnail)})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.FailedPrecondition:
+		g.attachments.markQuarantined(id)
+		return false
+	case codes.Unimplemented, codes.NotFound:
+		return false
+	default:
+		g.log.Errorf("Failed to get download URL of attachment %s: %v", id, err)
+		return false
+	}
+	// the URL expires, the redirect must not outlive it
+	c.Header("Cache-Control", "private, no-store")
+	c.Redirect(http.StatusFound, resp.Url)
+	return true
+}
+
+// fetchAttachment copies a file uploaded through the chat server's
+// AttachmentService into the local store, so later requests are served
+// with Range support like local uploads
+func (g *Gateway) fetchAttachment(ctx context.Context, id string) (attachmeta.Meta, bool) {
+	conn, err := g.upstreamConn()
+	if err != nil {
+		return attachmeta.Meta{}, false
+	}
+	stream, err := pb.NewAttachmentServiceClient(conn).DownloadAttachment(ctx, &pb.AttachmentRequest{Id: id})
+	if err != nil {
+		return attachmeta.Meta{}, false
+	}
+	var first *pb.Chunk
+	var data bytes.Buffer
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			switch status.Code(err) {
+			case codes.NotFound:
+			case codes.FailedPrecondition:
+				g.attachments.markQuarantined(id)
+			default:
+				g.log.Errorf("Failed to fetch attachment %s: %v", id, err)
+			}
+			return attachmeta.Meta{}, false
+		}
+		if first == nil {
+			first = chunk
+		}
+		if data.Len()+len(chunk.Data) > MaxFileSize {
+			return attachmeta.Meta{}, false
+		}
+		data.Write(chunk.Data)
+	}
+	if first == nil {
+		return attachmeta.Meta{}, false
+	}
+	if sum := sha256.Sum256(data.Bytes()); first.Sha256 != "" && hex.EncodeToString(sum[:]) != first.Sha256 {
+		g.log.Errorf("Attachment %s failed its checksum", id)
+		return attachmeta.Meta{}, false
+	}
+	m, err := g.attachments.put(attachmeta.Meta{
+		ID:       id,
+		Kind:     "file",
+		MimeType: http.DetectContentType(data.Bytes()),
+		Name:     first.Name,
+	}, data.Bytes())
+	if err != nil {
+		g.log.Errorf("Failed to store attachment %s: %v", id, err)
+		return attachmeta.Meta{}, false
+	}
+	return m, true
+}
+
+// attachmentFor resolves an attachment referenced by a browser message,
+// only the stored metadata or the GIF provider is trusted
+func (g *Gateway) attachmentFor(a *Attachment) (*pb.Attachment, bool) {
+	if a == nil {
+		return nil, false
+	}
+	if a.Kind == "gif" {
+		return g.gifAttachment(a.ID)
+	}
+	if g.attachments == nil {
+		return nil, false
+	}
+	m, ok := g.attachments.get(strings.ToLower(a.ID))
+	if !ok {
+		return nil, false
+	}
+	return m.Proto(), true
+}
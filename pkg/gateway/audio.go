@@ -0,0 +1,252 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// errNotAudio is returned for uploads that are not a supported container
+var errNotAudio = errors.New("not a supported audio file (ogg, webm, wav, mp3 or m4a)")
+
+// sniffAudio identifies the audio container from its magic bytes and
+// reads the duration from the container headers where it is recorded.
+// A zero duration means the container did not say.
+func sniffAudio(data []byte) (mimeType string, duration time.Duration, err error) {
+	switch {
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return "audio/wav", wavDuration(data), nil
+	case len(data) >= 4 && string(data[:4]) == "OggS":
+		return "audio/ogg", oggDuration(data), nil
+	case len(data) >= 4 && bytes.Equal(data[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "audio/webm", webmDuration(data), nil
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		return "audio/mp4", mp4Duration(data), nil
+	case len(data) >= 3 && string(data[:3]) == "ID3",
+		len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return "audio/mpeg", 0, nil
+	}
+	return "", 0, errNotAudio
+}
+
+// wavDuration divides the data chunk size by the byte rate
+func wavDuration(data []byte) time.Duration {
+	var byteRate uint32
+	for off := 12; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := int(binary.LittleEndian.Uint32(data[off+4 : off+8]))
+		body := off + 8
+		switch {
+		case id == "fmt " && body+12 <= len(data):
+			byteRate = binary.LittleEndian.Uint32(data[body+8 : body+12])
+		case id == "data" && byteRate > 0:
+			return time.Duration(float64(size) / float64(byteRate) * float64(time.Second))
+		}
+		off = body + size + size%2 // chunks are word aligned
+	}
+	return 0
+}
+
+// oggDuration reads the final granule position, which counts samples
+// at 48kHz for Opus or the stream rate for Vorbis
+func oggDuration(data []byte) time.Duration {
+	// the first packet identifies the codec, it starts after the segment table
+	if len(data) < 27 {
+		return 0
+	}
+	packet := 27 + int(data[26])
+	if packet >= len(data) {
+		return 0
+	}
+	var rate, preSkip uint64
+	switch head := data[packet:]; {
+	case len(head) >= 12 && string(head[:8]) == "OpusHead":
+		rate, preSkip = 48000, uint64(binary.LittleEndian.Uint16(head[10:12]))
+	case len(head) >= 16 && string(head[:7]) == "\x01vorbis":
+		rate = uint64(binary.LittleEndian.Uint32(head[12:16]))
+	default:
+		return 0
+	}
+
+	last := bytes.LastIndex(data, []byte("OggS"))
+	if rate == 0 || last < 0 || last+14 > len(data) {
+		return 0
+	}
+	granule := binary.LittleEndian.Uint64(data[last+6 : last+14])
+	if granule == math.MaxUint64 || granule < preSkip {
+		return 0
+	}
+	return time.Duration(float64(granule-preSkip) / float64(rate) * float64(time.Second))
+}
+
+// EBML element IDs needed to find the WebM duration
+const (
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+)
+
+// webmDuration reads Segment > Info > Duration. Browser recordings often
+// leave it out, in which case 0 is returned.
+func webmDuration(data []byte) time.Duration {
+	// skip the EBML header
+	_, size, n := ebmlElement(data)
+	if n == 0 || size < 0 || n+size > len(data) {
+		return 0
+	}
+	data = data[n+size:]
+
+	id, size, n := ebmlElement(data)
+	if id != ebmlSegment || n == 0 {
+		return 0
+	}
+	data = data[n:]
+	if size >= 0 && size < len(data) {
+		data = data[:size]
+	}
+	for len(data) > 0 {
+		id, size, n := ebmlElement(data)
+		if n == 0 || size < 0 || n+size > len(data) {
+			return 0
+		}
+		if id == ebmlInfo {
+			return ebmlInfoDuration(data[n : n+size])
+		}
+		data = data[n+size:]
+	}
+	return 0
+}
+
+func ebmlInfoDuration(info []byte) time.Duration {
+	scale := 1e6 // default TimecodeScale, in nanoseconds
+	var units float64
+	for len(info) > 0 {
+		id, size, n := ebmlElement(info)
+		if n == 0 || size < 0 || n+size > len(info) {
+			break
+		}
+		body := info[n : n+size]
+		switch id {
+		case ebmlTimecodeScale:
+			var v uint64
+			for _, b := range body {
+				v = v<<8 | uint64(b)
+			}
+			scale = float64(v)
+		case ebmlDuration:
+			switch size {
+			case 4:
+				units = float64(math.Float32frombits(binary.BigEndian.Uint32(body)))
+			case 8:
+				units = math.Float64frombits(binary.BigEndian.Uint64(body))
+			}
+		}
+		info = info[n+size:]
+	}
+	return time.Duration(units * scale)
+}
+
+// ebmlElement decodes an element header, size is -1 when unknown and n
+// is 0 when the header is malformed
+func ebmlElement(data []byte) (id uint32, size int, n int) {
+	idLen := vintLen(data)
+	if idLen == 0 || idLen > 4 || idLen >= len(data) {
+		return 0, 0, 0
+	}
+	for _, b := range data[:idLen] {
+		id = id<<8 | uint32(b)
+	}
+	rest := data[idLen:]
+	sizeLen := vintLen(rest)
+	if sizeLen == 0 || sizeLen > len(rest) {
+		return 0, 0, 0
+	}
+	v := uint64(rest[0] & (0xFF >> sizeLen))
+	allOnes := v == uint64(0xFF>>sizeLen)
+	for _, b := range rest[1:sizeLen] {
+		v = v<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	if allOnes {
+		return id, -1, idLen + sizeLen
+	}
+	if v > math.MaxInt32 {
+		return 0, 0, 0
+	}
+	return id, int(v), idLen + sizeLen
+}
+
+// vintLen is the length of an EBML variable-size integer from its first byte
+func vintLen(data []byte) int {
+	if len(data) == 0 || data[0] == 0 {
+		return 0
+	}
+	n := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	return n
+}
+
+// mp4Duration reads moov > mvhd
+func mp4Duration(data []byte) time.Duration {
+	moov := mp4Box(data, "moov")
+	if moov == nil {
+		return 0
+	}
+	mvhd := mp4Box(moov, "mvhd")
+	if len(mvhd) < 4 {
+		return 0
+	}
+	var timescale, units uint64
+	switch mvhd[0] { // version
+	case 0:
+		if len(mvhd) < 20 {
+			return 0
+		}
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		units = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case 1:
+		if len(mvhd) < 32 {
+			return 0
+		}
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		units = binary.BigEndian.Uint64(mvhd[24:32])
+	}
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(float64(units) / float64(timescale) * float64(time.Second))
+}
+
+// mp4Box returns the body of the first box of the given type in data
+func mp4Box(data []byte, boxType string) []byte {
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[:4]))
+		header := 8
+		switch size {
+		case 0:
+			size = len(data) // extends to the end
+		case 1:
+			if len(data) < 16 {
+				return nil
+			}
+			large := binary.BigEndian.Uint64(data[8:16])
+			if large > uint64(len(data)) {
+				return nil
+			}
+			size, header = int(large), 16
+		}
+		if size < header || size > len(data) {
+			return nil
+		}
+		if string(data[4:8]) == boxType {
+			return data[header:size]
+		}
+		data = data[size:]
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"realTimeChat/pkg/i18n"
+)
+
+// DefaultAuthTimeout is how long a new WebSocket gets to send a valid
+// join before it is closed
+const DefaultAuthTimeout = 10 * time.Second
+
+// Subprotocol is the WebSocket subprotocol of the chat frames. A browser
+// may offer a token next to it as "bearer.<token>", the gateway answers
+// with Subprotocol only so the token is never echoed.
+const Subprotocol = "chat"
+
+const tokenProtocolPrefix = "bearer."
+
+// ErrInvalidToken is returned by authenticators for unknown tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+// Authenticator checks the token a browser presents and returns the
+// username it may join as. username is the requested name, empty when
+// the token came with the handshake; returning "" keeps it.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token, username string) (string, error)
+}
+
+// AuthFunc adapts a plain function to Authenticator
+type AuthFunc func(ctx context.Context, token, username string) (string, error)
+
+// Authenticate calls f
+func (f AuthFunc) Authenticate(ctx context.Context, token, username string) (string, error) {
+	return f(ctx, token, username)
+}
+
+// SharedToken accepts a single shared secret and lets its holders pick
+// any username
+func SharedToken(secret string) Authenticator {
+	return AuthFunc(func(_ context.Context, token, username string) (string, error) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return "", ErrInvalidToken
+		}
+		return username, nil
+	})
+}
+
+// WithAuthenticator requires every WebSocket to present a token, in the
+// handshake (see Subprotocol) or in its join frame
+func WithAuthenticator(a Authenticator) Option {
+	return func(g *Gateway) {
+		g.auth = a
+	}
+}
+
+// WithAuthTimeout sets how long a new WebSocket may take to join, 0
+// disables the deadline
+func WithAuthTimeout(d time.Duration) Option {
+	return func(g *Gateway) {
+		g.authTimeout = d
+	}
+}
+
+// requireUser guards routes that read or change the data of the user
+// named by the :user path parameter, or the user query parameter. With an
+// Authenticator the request must carry "Authorization: Bearer <token>"
+// for a token it accepts as that user; without one anybody may, as
+// anybody may join as anybody.
+func (g *Gateway) requireUser(c *gin.Context) {
+	if g.auth == nil {
+		c.Next()
+		return
+	}
+	user := c.Param("user")
+	if user == "" {
+		user = c.Query("user")
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	name, err := g.auth.Authenticate(ctx, token, user)
+	cancel()
+	if err != nil {
+		g.log.Warnf("Refused %s %s for '%s': %v", c.Request.Method, c.FullPath(), user, err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	if user == "" || (name != "" && name != user) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "the token does not belong to this user"})
+		return
+	}
+	c.Next()
+}
+
+// handshakeAuth picks the subprotocol to answer with and checks a token
+// offered next to it. It returns the authenticated username, "" without
+// a token, and false when the upgrade must be refused.
+func (g *Gateway) handshakeAuth(w http.ResponseWriter, r *http.Request) (http.Header, string, bool) {
+	var header http.Header
+	token := ""
+	for _, p := range websocket.Subprotocols(r) {
+		switch {
+		case p == Subprotocol:
+			header = http.Header{"Sec-Websocket-Protocol": {Subprotocol}}
+		case strings.HasPrefix(p, tokenProtocolPrefix):
+			token = strings.TrimPrefix(p, tokenProtocolPrefix)
+		}
+	}
+	if token == "" || g.auth == nil {
+		return header, "", true
+	}
+	user, err := g.auth.Authenticate(r.Context(), token, "")
+	if err != nil || user == "" {
+		g.log.Warnf("Refused WebSocket from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return nil, "", false
+	}
+	return header, user, true
+}
+
+// authenticate resolves the username of a join frame. It reports false
+// after telling the browser why, closing the socket when the token was
+// wrong.
+func (c *WSClient) authenticate(msg WSMessage) (string, bool) {
+	user := strings.TrimSpace(msg.User)
+	switch {
+	case c.gw.auth == nil:
+	case c.authUser != "":
+		// authenticated in the handshake, the frame may only repeat the name
+		if user != "" && user != c.authUser {
+			c.sendError(i18n.AuthUserMismatch)
+			c.closeWith(websocket.ClosePolicyViolation, "username does not match token")
+			return "", false
+		}
+		user = c.authUser
+	case msg.Token == "":
+		c.sendError(i18n.AuthRequired)
+		c.closeWith(websocket.ClosePolicyViolation, "authentication required")
+		return "", false
+	default:
+		ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+		name, err := c.gw.auth.Authenticate(ctx, msg.Token, user)
+		cancel()
+		if err != nil {
+			c.gw.log.Warnf("Refused join of '%s': %v", user, err)
+			c.sendError(i18n.AuthFailed)
+			c.closeWith(websocket.ClosePolicyViolation, "authentication failed")
+			return "", false
+		}
+		if name != "" {
+			user = name
+		}
+	}
+	if user == "" {
+		c.sendError(i18n.UsernameRequired)
+		return "", false
+	}
+	return user, true
+}
+
+// expectJoin closes the socket unless it has joined when d is up
+func (c *WSClient) expectJoin(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.joinTimer = time.AfterFunc(d, func() {
+		if !c.authed.Load() {
+			c.gw.log.Debugf("Closing WebSocket that did not join within %s", d)
+			c.closeWith(websocket.ClosePolicyViolation, "join timeout")
+		}
+	})
+}
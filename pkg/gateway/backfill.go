@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// backfillTimeout bounds the history calls made to fill one gap
+const backfillTimeout = 5 * time.Second
+
+// seqTracker remembers the last sequence delivered to a browser per
+// room. Only public messages are sequenced.
+type seqTracker struct {
+	mu   sync.Mutex
+	last map[string]uint64
+}
+
+// inSequence reports whether msg should be delivered now. A message past
+// a gap first has the missing range backfilled from the chat server's
+// history, messages already delivered by a backfill are dropped.
+func (c *WSClient) inSequence(msg *pb.ChatMessage) bool {
+	c.seqs.mu.Lock()
+	defer c.seqs.mu.Unlock()
+	if c.seqs.last == nil {
+		c.seqs.last = make(map[string]uint64)
+	}
+	last, known := c.seqs.last[msg.Room]
+	switch {
+	case !known:
+		// first message since joining, nothing earlier was expected
+	case msg.Seq <= last:
+		return false
+	case msg.Seq > last+1:
+		c.backfill(msg.Room, last, msg.Seq)
+	}
+	c.seqs.last[msg.Room] = msg.Seq
+	return true
+}
+
+// reset forgets every room, the next message of each starts afresh
+func (t *seqTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = nil
+}
+
+// ackSeq advances the room past the client's own message, the server
+// does not echo public messages to their sender
+func (c *WSClient) ackSeq(room string, seq uint64) {
+	c.seqs.mu.Lock()
+	defer c.seqs.mu.Unlock()
+	if last, ok := c.seqs.last[room]; ok && seq == last+1 {
+		c.seqs.last[room] = seq
+	}
+}
+
+// backfill delivers the messages with after < seq < before, it must be
+// called with seqs.mu held
+func (c *WSClient) backfill(room string, after, before uint64) {
+	_, conn, err := c.gw.roomUpstream(room)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, backfillTimeout)
+	defer cancel()
+	if c.gw.adminToken != "" {
+		// the browser is in the room, but the chat server serves the
+		// history of private rooms only to the admin token
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.gw.adminToken)
+	}
+
+	self := c.chat.Username()
+	next := after + 1
+	complete := true
+	for next < before {
+		resp, err := pb.NewHistoryServiceClient(conn).GetHistory(ctx, &pb.HistoryRequest{
+			Room:      room,
+			AfterSeq:  next - 1,
+			BeforeSeq: before,
+		})
+		if err != nil {
+			c.gw.log.Warnf("Backfill of %s (%d, %d) for %s failed: %v", room, after, before, self, err)
+			complete = false
+			break
+		}
+		if len(resp.Messages) == 0 {
+			break
+		}
+		for _, m := range resp.Messages {
+			if m.Seq != next {
+				complete = false // evicted from the server's history
+			}
+			next = m.Seq + 1
+			if m.User != self {
+				c.relayChat(m)
+			}
+		}
+	}
+	if next < before {
+		complete = false
+	}
+	c.gw.log.Debugf("Backfilled %s (%d, %d) for %s", room, after, before, self)
+	if !complete {
+		c.sendSystem(i18n.BackfillIncomplete)
+	}
+}
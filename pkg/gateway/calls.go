@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"time"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// Signal is WebRTC signaling between two browsers, the payload (an SDP
+// or ICE candidate) is opaque to the gateway and the chat server
+type Signal struct {
+	CallID  string `json:"callId"`
+	Type    string `json:"type"` // offer, answer, ice, hangup, screen-start, screen-stop or one of the voice types
+	Payload string `json:"payload,omitempty"`
+}
+
+var signalTypes = map[string]pb.SignalType{
+	"offer":  pb.SignalType_SIGNAL_OFFER,
+	"answer": pb.SignalType_SIGNAL_ANSWER,
+	"ice":    pb.SignalType_SIGNAL_ICE_CANDIDATE,
+	"hangup": pb.SignalType_SIGNAL_HANGUP,
+
+	"screen-start": pb.SignalType_SIGNAL_SCREEN_SHARE_START,
+	"screen-stop":  pb.SignalType_SIGNAL_SCREEN_SHARE_STOP,
+
+	// the voice channel of the message's room, without recipient or call
+	"voice-join":     pb.SignalType_SIGNAL_VOICE_JOIN,
+	"voice-leave":    pb.SignalType_SIGNAL_VOICE_LEAVE,
+	"speaking-start": pb.SignalType_SIGNAL_VOICE_SPEAKING_START,
+	"speaking-stop":  pb.SignalType_SIGNAL_VOICE_SPEAKING_STOP,
+}
+
+var signalNames = map[pb.SignalType]string{
+	pb.SignalType_SIGNAL_OFFER:         "offer",
+	pb.SignalType_SIGNAL_ANSWER:        "answer",
+	pb.SignalType_SIGNAL_ICE_CANDIDATE: "ice",
+	pb.SignalType_SIGNAL_HANGUP:        "hangup",
+
+	pb.SignalType_SIGNAL_SCREEN_SHARE_START: "screen-start",
+	pb.SignalType_SIGNAL_SCREEN_SHARE_STOP:  "screen-stop",
+}
+
+var callStates = map[pb.CallState]string{
+	pb.CallState_CALL_RINGING: "ringing",
+	pb.CallState_CALL_IN_CALL: "in-call",
+	pb.CallState_CALL_ENDED:   "ended",
+}
+
+var voiceActions = map[pb.VoiceAction]string{
+	pb.VoiceAction_VOICE_JOINED:   "joined",
+	pb.VoiceAction_VOICE_LEFT:     "left",
+	pb.VoiceAction_VOICE_SPEAKING: "speaking",
+	pb.VoiceAction_VOICE_SILENT:   "silent",
+}
+
+var presenceStatuses = map[pb.PresenceStatus]string{
+	pb.PresenceStatus_PRESENCE_AVAILABLE:      "available",
+	pb.PresenceStatus_PRESENCE_IN_CALL:        "in-call",
+	pb.PresenceStatus_PRESENCE_SHARING_SCREEN: "sharing-screen",
+	pb.PresenceStatus_PRESENCE_AWAY:           "away",
+}
+
+// handleSignal relays a signaling message upstream. Signals skip the
+// rate limit, filters and transforms since a call setup sends a burst
+// of ICE candidates and none of it is shown as text.
+func (c *WSClient) handleSignal(msg WSMessage) {
+	if c.chat == nil {
+		c.sendError(i18n.NotConnected)
+		return
+	}
+	sig := msg.Signal
+	if sig == nil {
+		c.sendError(i18n.SignalNoRecipient)
+		return
+	}
+	t, ok := signalTypes[sig.Type]
+	if !ok {
+		c.sendError(i18n.SignalUnknown)
+		return
+	}
+	var err error
+	switch t {
+	case pb.SignalType_SIGNAL_VOICE_JOIN:
+		err = c.chat.JoinVoice(msg.Room)
+	case pb.SignalType_SIGNAL_VOICE_LEAVE:
+		err = c.chat.LeaveVoice()
+	case pb.SignalType_SIGNAL_VOICE_SPEAKING_START, pb.SignalType_SIGNAL_VOICE_SPEAKING_STOP:
+		err = c.chat.SetSpeaking(t == pb.SignalType_SIGNAL_VOICE_SPEAKING_START)
+	default:
+		if msg.RecipientUser == "" {
+			c.sendError(i18n.SignalNoRecipient)
+			return
+		}
+		err = c.chat.SendSignal(msg.RecipientUser, &pb.Signal{
+			CallId:  sig.CallID,
+			Type:    t,
+			Payload: sig.Payload,
+		})
+	}
+	if err != nil {
+		c.gw.log.Errorf("Failed to send signal to gRPC: %v", err)
+		c.sendError(i18n.SignalFailed)
+	}
+}
+
+// relaySignal forwards a signal from the other party of a call
+func (c *WSClient) relaySignal(msg *pb.ChatMessage, sig *pb.Signal) {
+	c.queueFrame(SignalFrame{
+		Type:          "signal",
+		User:          msg.User,
+		RecipientUser: msg.RecipientUser,
+		Signal: Signal{
+			CallID:  sig.CallId,
+			Type:    signalNames[sig.Type],
+			Payload: sig.Payload,
+		},
+	}, prioDirect)
+}
+
+// relayCall forwards a call state change
+func (c *WSClient) relayCall(ev *pb.CallEvent) {
+	c.queueFrame(CallFrame{
+		Type: "call",
+		Call: CallInfo{
+			CallID: ev.CallId,
+			State:  callStates[ev.State],
+			Caller: ev.Caller,
+			Callee: ev.Callee,
+			Reason: ev.Reason,
+		},
+	}, prioDirect)
+}
+
+// relayVoice forwards a change of the voice channel of a room
+func (c *WSClient) relayVoice(ev *pb.VoiceEvent) {
+	c.queueFrame(VoiceFrame{Type: "voice", Room: ev.Room, Action: voiceActions[ev.Action], Member: voiceMember(ev.Member)}, prioPresence)
+}
+
+// voiceMember converts a member of a voice channel
+func voiceMember(m *pb.VoiceMember) VoiceMember {
+	return VoiceMember{User: m.GetUser(), Speaking: m.GetSpeaking(), JoinedAt: time.UnixMilli(m.GetJoinedAt()).UTC().Format(time.RFC3339)}
+}
+
+// relayPresence forwards a user's call, screen-share or away status
+func (c *WSClient) relayPresence(p *pb.Presence) {
+	c.queueFrame(PresenceFrame{Type: "presence", User: p.User, Status: presenceStatuses[p.Status]}, prioPresence)
+}
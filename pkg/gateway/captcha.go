@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// captchaTimeout bounds one verification with the CAPTCHA service
+const captchaTimeout = 5 * time.Second
+
+// CaptchaProvider verifies the responses of a CAPTCHA widget. The web
+// client renders the widget of Name with SiteKey, implementations hold
+// the secret.
+type CaptchaProvider interface {
+	Name() string // "hcaptcha", "turnstile" or "recaptcha"
+	SiteKey() string
+	// Verify returns nil when response is a valid solution, remoteIP is
+	// the browser's address
+	Verify(ctx context.Context, response, remoteIP string) error
+}
+
+// ErrCaptchaRejected is returned by CaptchaProvider.Verify for responses
+// the service did not accept
+var ErrCaptchaRejected = errors.New("captcha rejected")
+
+// WithCaptcha challenges joins over Config.Challenge's rate with p
+// instead of a proof of work
+func WithCaptcha(p CaptchaProvider) Option {
+	return func(g *Gateway) {
+		g.captcha = p
+	}
+}
+
+// SiteVerifyProvider verifies responses with a siteverify endpoint, the
+// API hCaptcha, Cloudflare Turnstile and reCAPTCHA share
+type SiteVerifyProvider struct {
+	name      string
+	siteKey   string
+	secret    string
+	verifyURL string
+	client    *http.Client
+}
+
+func newSiteVerify(name, verifyURL, siteKey, secret string) *SiteVerifyProvider {
+	return &SiteVerifyProvider{
+		name:      name,
+		siteKey:   siteKey,
+		secret:    secret,
+		verifyURL: verifyURL,
+		client:    &http.Client{Timeout: captchaTimeout},
+	}
+}
+
+// NewHCaptchaProvider creates an hCaptcha provider
+func NewHCaptchaProvider(siteKey, secret string) *SiteVerifyProvider {
+	return newSiteVerify("hcaptcha", "https://api.hcaptcha.com/siteverify", siteKey, secret)
+}
+
+// NewTurnstileProvider creates a Cloudflare Turnstile provider
+func NewTurnstileProvider(siteKey, secret string) *SiteVerifyProvider {
+	return newSiteVerify("turnstile", "https://challenges.cloudflare.com/turnstile/v0/siteverify", siteKey, secret)
+}
+
+// NewReCAPTCHAProvider creates a reCAPTCHA v2 provider
+func NewReCAPTCHAProvider(siteKey, secret string) *SiteVerifyProvider {
+	return newSiteVerify("recaptcha", "https://www.google.com/recaptcha/api/siteverify", siteKey, secret)
+}
+
+// Name implements CaptchaProvider
+func (p *SiteVerifyProvider) Name() string { return p.name }
+
+// SiteKey implements CaptchaProvider
+func (p *SiteVerifyProvider) SiteKey() string { return p.siteKey }
+
+// Verify implements CaptchaProvider
+func (p *SiteVerifyProvider) Verify(ctx context.Context, response, remoteIP string) error {
+	form := url.Values{"secret": {p.secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", p.name, resp.Status)
+	}
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrCaptchaRejected, strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
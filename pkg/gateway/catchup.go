@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// catchup router proxies HistoryService.Catchup for clients resyncing
+// several rooms after a reconnect, the body is a CatchupRequest in JSON.
+// Only the user themselves may catch up, given by ?user=, see requireUser.
+func (g *Gateway) setupCatchupRoutes(r gin.IRouter) {
+	r = r.Group("", g.requireUser)
+	r.POST("/api/catchup", func(c *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var req pb.CatchupRequest
+		if err := protojson.Unmarshal(body, &req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if user := c.Query("user"); user != "" {
+			if req.User != "" && req.User != user {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "user in the body does not match ?user="})
+				return
+			}
+			req.User = user
+		}
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewHistoryServiceClient(conn).Catchup(c.Request.Context(), &req)
+		})
+	})
+}
@@ -0,0 +1,151 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/bits"
+	"sync"
+	"time"
+
+	"realTimeChat/pkg/i18n"
+)
+
+// Proof of work difficulties in leading zero bits. A browser needs about
+// 2^difficulty hashes, a few seconds at the default.
+const (
+	DefaultPoWDifficulty = 16
+	MaxPoWDifficulty     = 32
+)
+
+// challengeTimeout replaces the join deadline of a socket that was sent a
+// challenge, solving a CAPTCHA takes longer than a join
+const challengeTimeout = 2 * time.Minute
+
+// maxSolution bounds the proof of work solutions the gateway hashes
+const maxSolution = 64
+
+// ChallengeAnswer is sent in a join that answers a ChallengeFrame
+type ChallengeAnswer struct {
+	Nonce    string `json:"nonce,omitempty"`    // of the proof of work
+	Solution string `json:"solution,omitempty"` // proof of work
+	Response string `json:"response,omitempty"` // CAPTCHA widget response
+}
+
+// joinCounter counts the joins of each address per minute
+type joinCounter struct {
+	mu      sync.Mutex
+	windows map[string]joinWindow
+	swept   time.Time
+}
+
+type joinWindow struct {
+	start time.Time
+	n     int
+}
+
+// hit counts a join from ip and returns the joins in the current minute
+func (j *joinCounter) hit(ip string, now time.Time) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.windows == nil {
+		j.windows = make(map[string]joinWindow)
+	}
+	if now.Sub(j.swept) > time.Minute {
+		for k, w := range j.windows {
+			if now.Sub(w.start) >= time.Minute {
+				delete(j.windows, k)
+			}
+		}
+		j.swept = now
+	}
+	w := j.windows[ip]
+	if now.Sub(w.start) >= time.Minute {
+		w = joinWindow{start: now}
+	}
+	w.n++
+	j.windows[ip] = w
+	return w.n
+}
+
+// passChallenge reports whether a join may go on. Joins over the
+// configured rate are answered with a challenge, and the join answering
+// it is checked before it reaches the chat server.
+func (c *WSClient) passChallenge(msg WSMessage) bool {
+	cfg := c.gw.config.Load().cfg.Challenge
+	if c.challenge == nil {
+		if cfg.JoinsPerMinute == 0 || c.gw.joins.hit(c.remoteIP, time.Now()) <= cfg.JoinsPerMinute {
+			return true
+		}
+		c.gw.log.Infof("Challenging join from %s", c.remoteIP)
+		c.sendChallenge(cfg)
+		return false
+	}
+	if c.solved(msg.Challenge) {
+		c.challenge = nil
+		return true
+	}
+	c.gw.log.Infof("Failed challenge from %s", c.remoteIP)
+	c.sendError(i18n.ChallengeFailed)
+	c.sendChallenge(cfg)
+	return false
+}
+
+// sendChallenge sends a new challenge and gives the browser
+// challengeTimeout to answer it
+func (c *WSClient) sendChallenge(cfg Challenge) {
+	ch := &ChallengeFrame{Type: "challenge", Kind: "pow"}
+	if p := c.gw.captcha; p != nil {
+		ch.Kind, ch.Provider, ch.SiteKey = "captcha", p.Name(), p.SiteKey()
+	} else {
+		var b [16]byte
+		_, _ = rand.Read(b[:])
+		ch.Nonce = base64.RawURLEncoding.EncodeToString(b[:])
+		ch.Difficulty = cfg.Difficulty
+		if ch.Difficulty == 0 {
+			ch.Difficulty = DefaultPoWDifficulty
+		}
+	}
+	c.challenge = ch
+	if c.joinTimer != nil {
+		c.joinTimer.Reset(challengeTimeout)
+	}
+	c.queueFrame(ch, prioSystem)
+}
+
+// solved checks an answer to the pending challenge
+func (c *WSClient) solved(a *ChallengeAnswer) bool {
+	if a == nil {
+		return false
+	}
+	ch := c.challenge
+	if ch.Kind == "pow" {
+		return a.Nonce == ch.Nonce && len(a.Solution) <= maxSolution && powValid(ch.Nonce, a.Solution, ch.Difficulty)
+	}
+	if a.Response == "" || c.gw.captcha == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, captchaTimeout)
+	defer cancel()
+	if err := c.gw.captcha.Verify(ctx, a.Response, c.remoteIP); err != nil {
+		c.gw.log.Infof("CAPTCHA from %s not accepted: %v", c.remoteIP, err)
+		return false
+	}
+	return true
+}
+
+// powValid reports whether SHA-256 of nonce, ":" and solution starts
+// with difficulty zero bits
+func powValid(nonce, solution string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(nonce + ":" + solution))
+	zeros := 0
+	for _, b := range sum {
+		if b != 0 {
+			zeros += bits.LeadingZeros8(b)
+			break
+		}
+		zeros += 8
+	}
+	return zeros >= difficulty
+}
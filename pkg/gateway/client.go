@@ -0,0 +1,632 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/metadata"
+
+	"realTimeChat/pkg/chatclient"
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// rosterTimeout bounds asking an older server who is online
+const rosterTimeout = 5 * time.Second
+
+// WSClient WebSocket client connection
+type WSClient struct {
+	conn       *websocket.Conn    // the socket of the pumps transport
+	sock       io.Closer          // the socket of the epoll transport, conn is nil then
+	ctx        context.Context    // bounds all work for the connection
+	cancel     context.CancelFunc // called when either pump exits
+	username   string             // written under hub.mu
+	chat       *chatclient.Client // upstream session, set once joined
+	send       *outbox
+	closeOnce  sync.Once
+	closeMsg   []byte // close frame written once send is closed
+	hub        *WSHub
+	gw         *Gateway
+	limiter    *rate.Limiter // built from the config's RateLimit
+	limit      RateLimit     // settings limiter was built with
+	seqs       seqTracker    // per-room delivery position, see inSequence
+	rejoining  atomic.Bool   // upstream stream dropped, see upstreamState
+	accepted   atomic.Bool   // the current upstream stream has delivered, see revoked
+	idle       atomic.Bool   // the browser reported its user idle
+	lastActive time.Time     // last activity hint forwarded, read pump only
+	authUser   string        // authenticated in the handshake
+	userAgent  string        // of the browser, reported to the server
+	remoteIP   string
+	authed     atomic.Bool // sent a valid join, see expectJoin
+	joinTimer  *time.Timer
+	challenge  *ChallengeFrame // awaiting an answer, read pump only
+	pingSent   atomic.Int64    // Unix nanoseconds of the unanswered ping, see pinged
+	poor       atomic.Bool     // a pong was late, see ConnectionQualityFrame
+	pace       pacer           // adapts the ping interval, see Heartbeat.MinPingInterval
+	closing    bool            // closing for an oversized message, reader only
+}
+
+// WSMessage WebSocket message structure
+type WSMessage struct {
+	Type          string      `json:"type"`
+	ID            string      `json:"id,omitempty"`          // server message ID
+	Room          string      `json:"room,omitempty"`        // room of a public message
+	Seq           uint64      `json:"seq,omitempty"`         // sequence within Room
+	ClientMsgID   string      `json:"clientMsgId,omitempty"` // idempotency key, echoed in the "ack" frame
+	User          string      `json:"user"`
+	Text          string      `json:"text"`
+	HTML          string      `json:"html,omitempty"` // sanitized rendering of Text, see Config.Markdown
+	RecipientUser string      `json:"recipientUser,omitempty"`
+	Timestamp     string      `json:"timestamp"`
+	Notify        bool        `json:"notify,omitempty"`      // alert the user per their preferences
+	EphemeralTo   string      `json:"ephemeralTo,omitempty"` // delivered to this user only and never kept
+	ThreadID      string      `json:"threadId,omitempty"`    // ID of the public message this replies to
+	Code          *Code       `json:"code,omitempty"`        // set on "code" messages
+	Attachment    *Attachment `json:"attachment,omitempty"`  // uploaded file, see /api/uploads
+	Signal        *Signal     `json:"signal,omitempty"`      // set on "signal" messages
+	Idle          bool        `json:"idle,omitempty"`        // set on "activity" messages
+	Token         string      `json:"token,omitempty"`       // credentials of a "join", see Authenticator
+
+	Metadata map[string]string `json:"metadata,omitempty"` // extension data, kept as sent
+
+	Challenge *ChallengeAnswer `json:"challenge,omitempty"` // answer of a "join" to a ChallengeFrame
+
+	Key  string            `json:"key,omitempty"`  // i18n key of a System message, Text is its English rendering
+	Args map[string]string `json:"args,omitempty"` // arguments for Key
+}
+
+// Code is a code block, relayed verbatim without filtering or Markdown
+type Code struct {
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// queue puts a message of class p on the send queue without blocking,
+// it reports false when the queue is full of frames of class p and above
+// or already closed. A system frame that does not fit closes the socket,
+// the client catches up after reconnecting.
+func (c *WSClient) queue(message []byte, p priority) bool {
+	return c.queueBuffer(outFrame{data: message, prio: p})
+}
+
+// queueFrame encodes v into a pooled buffer and queues it like queue
+func (c *WSClient) queueFrame(v any, p priority) bool {
+	buf := encodePooled(v)
+	return c.queueBuffer(outFrame{data: buf.frame(), buf: buf, prio: p})
+}
+
+func (c *WSClient) queueBuffer(f outFrame) bool {
+	ok, shed := c.send.push(f)
+	if shed {
+		c.hub.shed.Add(1)
+	}
+	if !ok {
+		f.release()
+		if f.prio == prioSystem {
+			c.closeWith(websocket.CloseTryAgainLater, reasonSlow)
+		}
+	}
+	return ok
+}
+
+// closeWith closes the send queue once, the write pump then sends a
+// close frame with code and reason after any queued messages
+func (c *WSClient) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeMsg = websocket.FormatCloseMessage(code, reason)
+		c.send.close()
+	})
+}
+
+func (c *WSClient) readPump() {
+	defer c.finish()
+
+	c.conn.SetReadLimit(int64(c.gw.config.Load().cfg.Limits.frameSize()))
+	hb := c.gw.heartbeat
+	_ = c.conn.SetReadDeadline(time.Now().Add(hb.PongWait))
+	// heartbeat handler
+	c.conn.SetPongHandler(func(string) error {
+		_ = c.conn.SetReadDeadline(time.Now().Add(hb.PongWait))
+		c.ponged()
+		return nil
+	})
+
+	for {
+		// read from WebSocket
+		_, message, err := c.conn.ReadMessage()
+		if errors.Is(err, websocket.ErrReadLimit) {
+			// the connection already answered with 1009
+			c.gw.log.Infof("Closed WebSocket of %s: frame over the size limit", c.username)
+			break
+		}
+		if err != nil {
+			c.gw.log.Debugf("WebSocket read error: %v", err)
+			break
+		}
+		c.receive(message)
+	}
+}
+
+// receive handles a message read from the socket, by one reader at a time
+func (c *WSClient) receive(message []byte) {
+	if limits := c.gw.config.Load().cfg.Limits; len(message) > limits.payloadSize() {
+		max := strconv.Itoa(limits.payloadSize())
+		c.sendError(i18n.MessageTooLarge, "max", max)
+		if limits.Oversize == "disconnect" {
+			// read on until the error is sent and the socket closed
+			c.closeWith(websocket.CloseMessageTooBig, "message over "+max+" bytes")
+			c.closing = true
+		}
+		return
+	}
+	if c.closing {
+		return
+	}
+
+	// parse message
+	wsMsg := getWSMessage()
+	defer putWSMessage(wsMsg)
+	if err := json.Unmarshal(message, wsMsg); err != nil {
+		c.gw.log.Warnf("JSON unmarshal error: %v", err)
+		return
+	}
+
+	// handle message based on type
+	switch wsMsg.Type {
+	case "join":
+		c.handleJoin(*wsMsg)
+	case "chat", "code":
+		c.handleChat(*wsMsg)
+	case "signal":
+		c.handleSignal(*wsMsg)
+	case "read":
+		c.handleRead(*wsMsg)
+	case "activity":
+		c.handleActivity(*wsMsg)
+	}
+}
+
+// finish unregisters the client once its socket can no longer be read
+// and releases the socket and the upstream session
+func (c *WSClient) finish() {
+	select {
+	case c.hub.unregister <- c:
+	case <-c.hub.done:
+	}
+	c.closeConn()
+	if c.joinTimer != nil {
+		c.joinTimer.Stop()
+	}
+	if c.chat != nil {
+		_ = c.chat.Close() // ends the stream cleanly before the context goes
+	}
+	c.cancel()
+}
+
+// closeConn closes the socket of either transport
+func (c *WSClient) closeConn() {
+	if c.sock != nil {
+		_ = c.sock.Close()
+		return
+	}
+	c.conn.Close()
+}
+
+// writePump pumps messages from the hub to the WebSocket connection
+func (c *WSClient) writePump() {
+	hb := c.gw.heartbeat
+	pingTimer := time.NewTimer(c.pace.next(hb))
+	defer func() {
+		pingTimer.Stop()
+		c.cancel()
+		c.conn.Close()
+	}()
+
+	var frames []outFrame
+	for {
+		select {
+		case <-c.ctx.Done():
+			// the read pump is gone, nobody will close send any more
+			return
+
+		case <-c.send.ready:
+			// send queued messages from grpc results to websocket
+			var closed bool
+			frames, closed = c.send.take(frames)
+			_ = c.conn.SetWriteDeadline(time.Now().Add(hb.WriteWait))
+			if len(frames) > 0 {
+				w, err := c.conn.NextWriter(websocket.TextMessage)
+				if err != nil {
+					return
+				}
+				for i, frame := range frames {
+					if i > 0 {
+						_, _ = w.Write([]byte{'\n'})
+					}
+					_, _ = w.Write(frame.data)
+					frame.release()
+				}
+				if err := w.Close(); err != nil {
+					return
+				}
+			}
+			if closed {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, c.closeMsg)
+				return
+			}
+
+		case <-pingTimer.C: // send heartbeat
+			_ = c.conn.SetWriteDeadline(time.Now().Add(hb.WriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			c.pinged()
+			pingTimer.Reset(c.pace.next(hb))
+		}
+	}
+}
+
+func (c *WSClient) handleJoin(msg WSMessage) {
+	if c.chat != nil {
+		c.sendError(i18n.AlreadyJoined)
+		return
+	}
+	if m := c.gw.Maintenance(); m.Enabled {
+		c.queue(maintenanceFrame(m), prioSystem)
+		c.closeWith(websocket.CloseTryAgainLater, reasonMaintenance)
+		return
+	}
+	if !c.passChallenge(msg) {
+		return
+	}
+	user, ok := c.authenticate(msg)
+	if !ok {
+		return
+	}
+	c.authed.Store(true)
+	msg.User = user
+	c.hub.mu.Lock() // the hub reads username for the user list
+	c.username = msg.User
+	c.hub.mu.Unlock()
+
+	// wait for the chat server if it is still starting
+	if !c.gw.Ready() {
+		c.sendSystem(i18n.WaitingForServer)
+		if !c.gw.waitReady(c.ctx, c.gw.joinWait) {
+			c.sendError(i18n.ServerUnavailable)
+			return
+		}
+	}
+
+	// connect to the gRPC server serving the room
+	addr, conn, err := c.gw.roomUpstream("")
+	if err != nil {
+		c.gw.log.Errorf("Failed to connect to gRPC server: %v", err)
+		c.sendError(i18n.ConnectFailed)
+		return
+	}
+
+	// start gRPC stream and join, telling the server who is behind it
+	ctx := metadata.AppendToOutgoingContext(c.ctx, "x-forwarded-for", c.remoteIP)
+	chat, err := chatclient.Connect(ctx, addr, msg.User,
+		chatclient.WithConn(conn),
+		chatclient.WithRouter(c.gw.shardConn),
+		chatclient.WithDevice(deviceName(c.userAgent)),
+		chatclient.WithUserAgent(c.userAgent),
+		chatclient.WithBackoff(reconnectMinBackoff, reconnectMaxBackoff),
+		chatclient.WithMaxRetries(c.gw.retries),
+		chatclient.WithStateHandler(c.upstreamState),
+		chatclient.WithHandler(c.relay))
+	if err != nil {
+		c.gw.log.Errorf("Failed to join chat: %v", err)
+		if !c.upstreamRefused(err) {
+			c.sendError(i18n.JoinFailed)
+		}
+		return
+	}
+	c.chat = chat
+
+	// the server sends the user list and announces the join, older
+	// servers only announce it
+	if caps, _, ok := chat.Capabilities(); !ok || !slices.Contains(caps, pb.CapRoster) {
+		c.sendUserList()
+	}
+}
+
+// allow applies the configured per-client message rate limit
+func (c *WSClient) allow() bool {
+	rl := c.gw.config.Load().cfg.RateLimit
+	if rl.MessagesPerSecond == 0 {
+		return true
+	}
+	if c.limiter == nil {
+		c.limiter = rate.NewLimiter(rate.Limit(rl.MessagesPerSecond), rl.Burst)
+	} else if rl != c.limit {
+		// config reloaded, keep the tokens already spent
+		c.limiter.SetLimit(rate.Limit(rl.MessagesPerSecond))
+		c.limiter.SetBurst(rl.Burst)
+	}
+	c.limit = rl
+	return c.limiter.Allow()
+}
+
+// handleChat processes chat messages from WebSocket and sends them to gRPC
+func (c *WSClient) handleChat(msg WSMessage) {
+	if c.chat == nil {
+		c.sendError(i18n.NotConnected)
+		return
+	}
+
+	if !c.allow() {
+		c.sendError(i18n.RateLimited)
+		return
+	}
+	if !c.gw.transform(ToUpstream, &msg) {
+		return
+	}
+	msg.Text = c.gw.config.Load().filter.apply(msg.Text)
+	c.gw.log.Debugf("Relaying message from %s", c.chat.Username())
+
+	grpcMsg := &pb.ChatMessage{
+		Text:          msg.Text,
+		RecipientUser: msg.RecipientUser,
+		Room:          msg.Room, // a subscribed room, the current one when empty
+		EphemeralTo:   msg.EphemeralTo,
+		ThreadId:      msg.ThreadID,
+		ClientMsgId:   msg.ClientMsgID,
+		Metadata:      msg.Metadata,
+	}
+	if msg.Type == "code" {
+		if msg.Code == nil {
+			c.sendError(i18n.CodeMissing)
+			return
+		}
+		grpcMsg.Payload = &pb.ChatMessage_Code{Code: &pb.Code{Language: msg.Code.Language, Content: msg.Code.Content}}
+	} else if msg.Attachment != nil {
+		a, ok := c.gw.attachmentFor(msg.Attachment)
+		if !ok {
+			c.sendError(i18n.AttachmentUnknown)
+			return
+		}
+		grpcMsg.Payload = &pb.ChatMessage_Attachment{Attachment: a}
+	}
+
+	if err := c.chat.SendMessage(grpcMsg); err != nil {
+		c.gw.log.Errorf("Failed to send message to gRPC: %v", err)
+		c.sendError(i18n.SendFailed)
+	}
+}
+
+// relay forwards a message received from gRPC to the WebSocket
+func (c *WSClient) relay(msg *pb.ChatMessage) {
+	c.accepted.Store(true)
+	switch p := msg.Payload.(type) {
+	case *pb.ChatMessage_Rename:
+		c.relayRename(msg, p.Rename)
+		return
+	case *pb.ChatMessage_LinkPreview:
+		c.relayPreview(p.LinkPreview)
+		return
+	case *pb.ChatMessage_Signal:
+		c.relaySignal(msg, p.Signal)
+		return
+	case *pb.ChatMessage_CallEvent:
+		c.relayCall(p.CallEvent)
+		return
+	case *pb.ChatMessage_Presence:
+		c.relayPresence(p.Presence)
+		return
+	case *pb.ChatMessage_Voice:
+		c.relayVoice(p.Voice)
+		return
+	case *pb.ChatMessage_Thread:
+		c.relayThread(p.Thread)
+		return
+	case *pb.ChatMessage_Unread:
+		c.relayUnread(p.Unread)
+		return
+	case *pb.ChatMessage_KeywordHit:
+		c.relayKeywordHit(p.KeywordHit)
+		return
+	case *pb.ChatMessage_Subscriptions:
+		c.queueFrame(SubscriptionsFrame{Type: "subscriptions", Room: p.Subscriptions.Room, Rooms: p.Subscriptions.Rooms}, prioSystem)
+		return
+	case *pb.ChatMessage_Ack:
+		c.relayAck(p.Ack)
+		return
+	case *pb.ChatMessage_Translation:
+		c.relayTranslation(p.Translation)
+		return
+	case *pb.ChatMessage_Edit:
+		c.relayEdit(p.Edit)
+		return
+	case *pb.ChatMessage_Member:
+		c.relayMember(msg.Room, p.Member)
+		return
+	case *pb.ChatMessage_Members:
+		c.relayMembers(msg.Type, p.Members)
+		if msg.Type == pb.MessageType_TYPE_ROSTER {
+			return
+		}
+		// joins and leaves are also shown as text
+	}
+	if msg.Seq != 0 && !c.inSequence(msg) {
+		return
+	}
+	c.relayChat(msg)
+}
+
+// sentAt returns the server timestamp of msg in UTC, servers that predate
+// timestamps leave it unset and the arrival time is used instead
+func sentAt(msg *pb.ChatMessage) time.Time {
+	if msg.Timestamp == 0 {
+		return time.Now().UTC()
+	}
+	return time.UnixMilli(msg.Timestamp).UTC()
+}
+
+// relayChat forwards a chat message to the WebSocket
+func (c *WSClient) relayChat(msg *pb.ChatMessage) {
+	// transform to WSMessage
+	wsMsg := getWSMessage()
+	defer putWSMessage(wsMsg)
+	*wsMsg = WSMessage{
+		Type:          "chat",
+		ID:            msg.Id,
+		Room:          msg.Room,
+		Seq:           msg.Seq,
+		User:          msg.User,
+		Text:          msg.Text,
+		RecipientUser: msg.RecipientUser,
+		Timestamp:     sentAt(msg).Format(time.RFC3339Nano),
+		Notify:        msg.Notify,
+		EphemeralTo:   msg.EphemeralTo,
+		ThreadID:      msg.ThreadId,
+		Metadata:      msg.Metadata,
+	}
+	if st := msg.GetSystem(); st != nil {
+		wsMsg.Key, wsMsg.Args = st.Key, st.Args
+	}
+	if a := msg.GetAttachment(); a != nil {
+		wsMsg.Attachment = publicAttachment(a)
+	}
+	if code := msg.GetCode(); code != nil {
+		wsMsg.Type = "code"
+		wsMsg.Code = &Code{Language: code.Language, Content: code.Content}
+	}
+	if !c.gw.transform(ToClient, wsMsg) {
+		return
+	}
+	if wsMsg.Code == nil && c.gw.config.Load().cfg.Markdown {
+		if html, ok := renderMarkdown(wsMsg.Text); ok {
+			wsMsg.HTML = html
+		}
+	}
+
+	c.queueFrame(wsMsg, chatPriority(msg, c.chat.Username()))
+}
+
+// relayRename updates presence when this client was renamed and tells
+// the browser about the new name
+func (c *WSClient) relayRename(msg *pb.ChatMessage, r *pb.Rename) {
+	self := msg.RecipientUser == r.NewUser
+	if self {
+		c.hub.mu.Lock()
+		if c.username == r.OldUser {
+			c.username = r.NewUser
+		}
+		c.hub.mu.Unlock()
+	}
+
+	c.queueFrame(RenameFrame{
+		Type:    "userRename",
+		User:    r.NewUser,
+		OldUser: r.OldUser,
+		Text:    msg.Text,
+		Self:    self,
+	}, prioSystem)
+}
+
+// relayAck confirms a message to the browser that sent it
+func (c *WSClient) relayAck(a *pb.Ack) {
+	if a.Seq != 0 {
+		c.ackSeq(a.Room, a.Seq)
+	}
+	c.queueFrame(AckFrame{
+		Type:        "ack",
+		ClientMsgID: a.ClientMsgId,
+		ID:          a.Id,
+		Room:        a.Room,
+		Seq:         a.Seq,
+		Duplicate:   a.Duplicate,
+	}, prioDirect)
+}
+
+// relayPreview forwards a link preview for an earlier message
+func (c *WSClient) relayPreview(p *pb.LinkPreview) {
+	c.queueFrame(LinkPreviewFrame{
+		Type:        "link_preview",
+		MessageID:   p.MessageId,
+		URL:         p.Url,
+		Title:       p.Title,
+		Description: p.Description,
+		ImageURL:    p.ImageUrl,
+		SiteName:    p.SiteName,
+	}, prioChat)
+}
+
+// relayTranslation forwards a translation of an earlier message, the
+// translated text is filtered like chat text
+func (c *WSClient) relayTranslation(tr *pb.Translation) {
+	c.queueFrame(TranslationFrame{
+		Type:       "translation",
+		MessageID:  tr.MessageId,
+		Lang:       tr.Lang,
+		Text:       c.gw.config.Load().filter.apply(tr.Text),
+		SourceLang: tr.SourceLang,
+	}, prioDirect)
+}
+
+// relayEdit forwards the new text of an earlier message, filtered like
+// chat text
+func (c *WSClient) relayEdit(e *pb.MessageEdit) {
+	c.queueFrame(EditFrame{
+		Type:      "edit",
+		MessageID: e.MessageId,
+		Text:      c.gw.config.Load().filter.apply(e.Text),
+		Revision:  e.Revision,
+		Done:      e.Done,
+	}, prioChat)
+}
+
+// relayMembers keeps the browser's user list in step with the server
+func (c *WSClient) relayMembers(t pb.MessageType, m *pb.Members) {
+	switch t {
+	case pb.MessageType_TYPE_ROSTER:
+		c.queueFrame(UserListFrame{Type: "userList", Users: m.Users}, prioSystem)
+	case pb.MessageType_TYPE_JOIN:
+		for _, user := range m.Users {
+			c.queueFrame(UserJoinFrame{Type: "userJoin", User: user}, prioSystem)
+		}
+	case pb.MessageType_TYPE_LEAVE:
+		for _, user := range m.Users {
+			c.queueFrame(UserLeaveFrame{Type: "userLeave", User: user}, prioSystem)
+		}
+	}
+}
+
+// sendUserList asks the server who is online, for servers that do not
+// send the roster themselves
+func (c *WSClient) sendUserList() {
+	ctx, cancel := context.WithTimeout(c.ctx, rosterTimeout)
+	defer cancel()
+	users := []string{}
+	online, err := c.chat.ListUsers(ctx, "")
+	if err != nil {
+		c.gw.log.Warnf("Listing users for %s failed: %v", c.chat.Username(), err)
+	}
+	for _, u := range online {
+		users = append(users, u.Name)
+	}
+	c.queueFrame(UserListFrame{Type: "userList", Users: users}, prioSystem)
+}
+
+// sendSystem sends an informational message, see pkg/i18n for the keys
+func (c *WSClient) sendSystem(key string, kv ...string) {
+	c.queue(systemFrame(key, kv...), prioSystem)
+}
+
+// sendError reports a failure to the browser, see pkg/i18n for the keys
+// and errorKinds for how they are classified
+func (c *WSClient) sendError(key string, kv ...string) {
+	c.queue(errorFrame(key, kv...), prioSystem)
+}
@@ -0,0 +1,255 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Config holds the gateway settings that can be reloaded at runtime
+// without dropping connections
+type Config struct {
+	AllowedOrigins []string  `json:"allowedOrigins"` // empty allows any origin
+	RateLimit      RateLimit `json:"rateLimit"`
+	FilterWords    []string  `json:"filterWords"` // masked in outgoing chat text
+	LogLevel       string    `json:"logLevel"`    // debug, info, warn or error
+	Markdown       bool      `json:"markdown"`    // render chat text to HTML for the web client
+	Limits         Limits    `json:"limits"`
+	Challenge      Challenge `json:"challenge"`
+	Downloads      Downloads `json:"downloads"`
+}
+
+// Challenge makes browsers prove they are not bots before their join
+// reaches the chat server, once their address joins more than
+// JoinsPerMinute times in a minute. They solve a CAPTCHA when the gateway
+// has a CaptchaProvider, a proof of work otherwise.
+type Challenge struct {
+	JoinsPerMinute int `json:"joinsPerMinute"` // 0 disables challenges
+	Difficulty     int `json:"difficulty"`     // leading zero bits of a proof of work, DefaultPoWDifficulty when 0
+}
+
+// Limits bounds the frames a browser may send. Frames over MaxFrameSize
+// close the socket with 1009, frames over MaxPayloadSize are handled per
+// Oversize. Zero values use the defaults. A reload applies MaxFrameSize
+// to new connections only.
+type Limits struct {
+	MaxFrameSize   int    `json:"maxFrameSize"`   // bytes, DefaultMaxFrameSize when 0
+	MaxPayloadSize int    `json:"maxPayloadSize"` // bytes, DefaultMaxPayloadSize when 0
+	Oversize       string `json:"oversize"`       // "reject" (default) answers with an error, "disconnect" closes with 1009
+}
+
+// Default frame limits, the payload limit fits a code block at the chat
+// server's default size
+const (
+	DefaultMaxFrameSize   = 256 << 10
+	DefaultMaxPayloadSize = 40 << 10
+)
+
+func (l Limits) frameSize() int {
+	if l.MaxFrameSize > 0 {
+		return l.MaxFrameSize
+	}
+	return DefaultMaxFrameSize
+}
+
+func (l Limits) payloadSize() int {
+	if l.MaxPayloadSize > 0 {
+		return l.MaxPayloadSize
+	}
+	return DefaultMaxPayloadSize
+}
+
+// RateLimit bounds how fast one WebSocket client may send chat messages,
+// a zero rate disables the limit
+type RateLimit struct {
+	MessagesPerSecond float64 `json:"messagesPerSecond"`
+	Burst             int     `json:"burst"`
+}
+
+// LoadConfig reads and validates a JSON config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks the config for values the gateway cannot apply
+func (c *Config) Validate() error {
+	var errs []error
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Errorf("allowedOrigins: %q is not a scheme://host origin", origin))
+		}
+	}
+	if c.RateLimit.MessagesPerSecond < 0 {
+		errs = append(errs, errors.New("rateLimit.messagesPerSecond cannot be negative"))
+	}
+	if c.RateLimit.MessagesPerSecond > 0 && c.RateLimit.Burst < 1 {
+		errs = append(errs, errors.New("rateLimit.burst must be at least 1 when a rate is set"))
+	}
+	for _, w := range c.FilterWords {
+		if strings.TrimSpace(w) == "" {
+			errs = append(errs, errors.New("filterWords cannot contain empty entries"))
+			break
+		}
+	}
+	if c.Limits.MaxFrameSize < 0 || c.Limits.MaxPayloadSize < 0 {
+		errs = append(errs, errors.New("limits cannot be negative"))
+	} else if c.Limits.payloadSize() > c.Limits.frameSize() {
+		errs = append(errs, errors.New("limits.maxPayloadSize cannot exceed limits.maxFrameSize"))
+	}
+	if c.Challenge.JoinsPerMinute < 0 {
+		errs = append(errs, errors.New("challenge.joinsPerMinute cannot be negative"))
+	}
+	if c.Challenge.Difficulty < 0 || c.Challenge.Difficulty > MaxPoWDifficulty {
+		errs = append(errs, fmt.Errorf("challenge.difficulty must be between 0 and %d", MaxPoWDifficulty))
+	}
+	if c.Downloads.BytesPerSecond < 0 || c.Downloads.MaxPerClient < 0 || c.Downloads.MaxConcurrent < 0 || c.Downloads.RetryAfterSeconds < 0 {
+		errs = append(errs, errors.New("downloads cannot be negative"))
+	}
+	switch c.Limits.Oversize {
+	case "", "reject", "disconnect":
+	default:
+		errs = append(errs, fmt.Errorf("limits.oversize: want reject or disconnect, got %q", c.Limits.Oversize))
+	}
+	if _, err := parseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("logLevel: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// allowsOrigin reports whether a browser origin may open a WebSocket,
+// ok is false when the config has no allowlist
+func (c *Config) allowsOrigin(origin string) (allowed, ok bool) {
+	if len(c.AllowedOrigins) == 0 {
+		return false, false
+	}
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// wordFilter masks configured words case-insensitively. ASCII words only
+// match as whole words, other scripts (e.g. Chinese) match anywhere.
+type wordFilter struct {
+	re *regexp.Regexp
+}
+
+var asciiWord = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func newWordFilter(words []string) *wordFilter {
+	if len(words) == 0 {
+		return &wordFilter{}
+	}
+	patterns := make([]string, len(words))
+	for i, w := range words {
+		w = strings.TrimSpace(w)
+		patterns[i] = regexp.QuoteMeta(w)
+		if asciiWord.MatchString(w) {
+			patterns[i] = `\b` + patterns[i] + `\b`
+		}
+	}
+	return &wordFilter{re: regexp.MustCompile(`(?i)(` + strings.Join(patterns, "|") + `)`)}
+}
+
+func (f *wordFilter) apply(text string) string {
+	if f.re == nil {
+		return text
+	}
+	return f.re.ReplaceAllStringFunc(text, func(w string) string {
+		return strings.Repeat("*", len([]rune(w)))
+	})
+}
+
+// configSnapshot is an immutable view of a Config with derived state,
+// swapped atomically on reload
+type configSnapshot struct {
+	cfg    Config
+	filter *wordFilter
+}
+
+func newConfigSnapshot(cfg Config) *configSnapshot {
+	cfg.AllowedOrigins = slices.Clone(cfg.AllowedOrigins)
+	cfg.FilterWords = slices.Clone(cfg.FilterWords)
+	return &configSnapshot{cfg: cfg, filter: newWordFilter(cfg.FilterWords)}
+}
+
+// Config returns a copy of the active runtime config
+func (g *Gateway) Config() Config {
+	return g.config.Load().cfg
+}
+
+// SetConfig validates cfg and atomically replaces the active config.
+// Existing connections pick it up on their next message.
+func (g *Gateway) SetConfig(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	level, _ := parseLevel(cfg.LogLevel)
+	g.config.Store(newConfigSnapshot(*cfg))
+	g.log.level.Store(level)
+	return nil
+}
+
+// Reload re-reads the file given by WithConfigFile and applies it,
+// keeping the old config if the new one is invalid
+func (g *Gateway) Reload() error {
+	if g.configFile == "" {
+		return errors.New("no config file configured")
+	}
+	cfg, err := LoadConfig(g.configFile)
+	if err != nil {
+		return err
+	}
+	if err := g.SetConfig(cfg); err != nil {
+		return err
+	}
+	g.log.Infof("Config reloaded from %s", g.configFile)
+	return nil
+}
+
+// checkOrigin applies the configured origin allowlist, deferring to next
+// when no allowlist is set. Requests without an Origin header are not
+// from browsers and are always allowed.
+func (g *Gateway) checkOrigin(next func(*http.Request) bool) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if allowed, ok := g.config.Load().cfg.allowsOrigin(origin); ok {
+			if !allowed {
+				g.log.Warnf("Rejected WebSocket from origin %s", origin)
+			}
+			return allowed
+		}
+		if next == nil {
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		}
+		return next(r)
+	}
+}
@@ -0,0 +1,39 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// contact routers proxy the chat server's ContactService, only for the
+// user themselves, see requireUser
+func (g *Gateway) setupContactRoutes(r gin.IRouter) {
+	r = r.Group("", g.requireUser)
+	r.GET("/api/contacts/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewContactServiceClient(conn).ListContacts(c.Request.Context(), &pb.ContactsRequest{User: c.Param("user")})
+		})
+	})
+	r.POST("/api/contacts/:user", func(c *gin.Context) {
+		var req struct {
+			Contact string `json:"contact"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Contact == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with contact"})
+			return
+		}
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewContactServiceClient(conn).AddContact(c.Request.Context(), &pb.ContactRequest{User: c.Param("user"), Contact: req.Contact})
+		})
+	})
+	r.DELETE("/api/contacts/:user/:contact", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewContactServiceClient(conn).RemoveContact(c.Request.Context(), &pb.ContactRequest{User: c.Param("user"), Contact: c.Param("contact")})
+		})
+	})
+}
@@ -0,0 +1,331 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// dashboardActor is the actor the chat server's audit log records for
+// changes made in the dashboard, followed by the browser's address
+const dashboardActor = "dashboard@"
+
+// overviewResponse is the body of GET /admin/api/overview
+type overviewResponse struct {
+	Hub         HubStats     `json:"hub"`
+	Maintenance Maintenance  `json:"maintenance"`
+	Upstream    upstreamInfo `json:"upstream"`
+	Last24h     *usage24h    `json:"last24h,omitempty"` // nil when the chat server did not answer
+}
+
+type upstreamInfo struct {
+	Address string `json:"address"`
+	Ready   bool   `json:"ready"`
+	Error   string `json:"error,omitempty"`
+}
+
+type usage24h struct {
+	Messages        int64       `json:"messages"`
+	ActiveUsers     int32       `json:"activeUsers"`
+	PeakConcurrency int32       `json:"peakConcurrency"`
+	TopRooms        []roomCount `json:"topRooms"`
+	Leadership      *leadership `json:"leadership,omitempty"`
+}
+
+// configResponse is the body of GET /admin/api/config, the admin token
+// and provider credentials are never part of it
+type configResponse struct {
+	Config     Config `json:"config"`
+	ConfigFile string `json:"configFile,omitempty"`
+	Upstream   string `json:"upstream"`
+	Transport  string `json:"transport"`
+	Uploads    bool   `json:"uploads"`
+	Media      bool   `json:"media"`   // GIF search is configured
+	Captcha    bool   `json:"captcha"` // challenges use a CAPTCHA, proofs of work otherwise
+	Scanner    bool   `json:"scanner"` // uploads are scanned for viruses
+	Auth       bool   `json:"auth"`    // joins are authenticated
+}
+
+// setupDashboardRoutes serves the admin dashboard at /admin and the JSON
+// API it uses under /admin/api, both only with an admin token. The page
+// itself is public, it asks for the token and sends it with every call.
+func (g *Gateway) setupDashboardRoutes(r gin.IRouter) {
+	if g.adminToken == "" {
+		return
+	}
+	r.Match([]string{http.MethodGet, http.MethodHead}, "/admin", g.serveAsset("admin.html"))
+
+	api := r.Group("/admin/api", g.requireAdmin)
+	api.GET("/overview", g.handleOverview)
+	api.GET("/config", func(c *gin.Context) {
+		transport := g.transport
+		if transport == "" {
+			transport = TransportPumps
+		}
+		c.JSON(http.StatusOK, configResponse{
+			Config:     g.Config(),
+			ConfigFile: g.configFile,
+			Upstream:   g.upstream,
+			Transport:  string(transport),
+			Uploads:    g.attachments != nil,
+			Media:      g.media != nil,
+			Captcha:    g.captcha != nil,
+			Scanner:    g.scanner != nil,
+			Auth:       g.auth != nil,
+		})
+	})
+
+	// rooms
+	api.GET("/rooms", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).ListRoomStats(ctx, &pb.RoomStatsRequest{})
+		})
+	})
+	api.GET("/rooms/:room/members", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewRoomServiceClient(conn).GetRoomMembers(ctx, &pb.RoomMembersRequest{Room: c.Param("room")})
+		})
+	})
+	api.PUT("/rooms/:room/members/:user/role", func(c *gin.Context) {
+		var req struct {
+			Role string `json:"role"`
+		}
+		role, ok := pb.RoomRole(0), false
+		if c.ShouldBindJSON(&req) == nil {
+			for r, name := range roomRoles {
+				if name == req.Role {
+					role, ok = r, true
+				}
+			}
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with role member, moderator or owner"})
+			return
+		}
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).SetRoomRole(ctx, &pb.SetRoomRoleRequest{Room: c.Param("room"), User: c.Param("user"), Role: role})
+		})
+	})
+	api.PUT("/rooms/:room/private", func(c *gin.Context) {
+		var req struct {
+			Private *bool `json:"private"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Private == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with private"})
+			return
+		}
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).SetRoomPrivate(ctx, &pb.SetRoomPrivateRequest{Room: c.Param("room"), Private: *req.Private})
+		})
+	})
+	api.GET("/rooms/:room/settings", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewRoomServiceClient(conn).GetRoomSettings(ctx, &pb.RoomSettingsRequest{Room: c.Param("room")})
+		})
+	})
+	api.PUT("/rooms/:room/settings", func(c *gin.Context) {
+		var req pb.RoomSettings
+		if !readProto(c, &req) {
+			return
+		}
+		req.Room = c.Param("room")
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).SetRoomSettings(ctx, &req)
+		})
+	})
+	api.POST("/announcements", func(c *gin.Context) {
+		var req struct {
+			Room string `json:"room"`
+			Text string `json:"text"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Text == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with text and an optional room"})
+			return
+		}
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).Announce(ctx, &pb.AnnounceRequest{Room: req.Room, Text: req.Text})
+		})
+	})
+
+	// users
+	api.GET("/sessions", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).ListSessions(ctx, &pb.ListSessionsRequest{User: c.Query("user")})
+		})
+	})
+	api.DELETE("/sessions/:id", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).RevokeSession(ctx, &pb.RevokeSessionRequest{Id: c.Param("id")})
+		})
+	})
+	api.DELETE("/users/:user/sessions", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).RevokeSession(ctx, &pb.RevokeSessionRequest{User: c.Param("user")})
+		})
+	})
+	api.GET("/bans", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).ListBans(ctx, &pb.ListBansRequest{Target: c.Query("target")})
+		})
+	})
+	api.POST("/bans", func(c *gin.Context) {
+		var req pb.CreateBanRequest
+		if !readProto(c, &req) {
+			return
+		}
+		if req.CreatedBy == "" {
+			req.CreatedBy = dashboardActor + remoteIP(c.Request)
+		}
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).CreateBan(ctx, &req)
+		})
+	})
+	api.DELETE("/bans/:id", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).RemoveBan(ctx, &pb.BanRequest{Id: c.Param("id")})
+		})
+	})
+	api.PUT("/bans/:id/appeal", func(c *gin.Context) {
+		var req struct {
+			Appeal string `json:"appeal"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with appeal"})
+			return
+		}
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).SetBanAppeal(ctx, &pb.SetBanAppealRequest{Id: c.Param("id"), Appeal: req.Appeal})
+		})
+	})
+
+	// moderation
+	api.GET("/moderation", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).ListModerationQueue(ctx, &pb.ModerationQueueRequest{Room: c.Query("room")})
+		})
+	})
+	api.DELETE("/moderation/:id", func(c *gin.Context) {
+		g.adminCall(c, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewAdminServiceClient(conn).ResolveModeration(ctx, &pb.ResolveModerationRequest{Id: c.Param("id")})
+		})
+	})
+	api.GET("/audit", g.handleAudit)
+}
+
+// adminContext carries the admin token to the chat server, and the
+// dashboard with the browser's address as the actor for its audit log
+func (g *Gateway) adminContext(c *gin.Context) context.Context {
+	return metadata.AppendToOutgoingContext(c.Request.Context(),
+		"authorization", "Bearer "+g.adminToken,
+		"x-admin-actor", dashboardActor+remoteIP(c.Request))
+}
+
+// adminCall is upstreamCall with the context of adminContext
+func (g *Gateway) adminCall(c *gin.Context, call func(context.Context, *grpc.ClientConn) (proto.Message, error)) {
+	ctx := g.adminContext(c)
+	g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+		return call(ctx, conn)
+	})
+}
+
+// readProto decodes a JSON request body into m, answering 400 when it
+// cannot
+func readProto(c *gin.Context, m proto.Message) bool {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+	if err == nil {
+		err = protojson.Unmarshal(body, m)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// handleOverview serves GET /admin/api/overview, the gateway's own state
+// and the chat server's usage of the last 24 hours. It answers without
+// the usage when the chat server is down, that is when it is needed most.
+func (g *Gateway) handleOverview(c *gin.Context) {
+	resp := overviewResponse{
+		Hub:         g.HubStats(),
+		Maintenance: g.Maintenance(),
+		Upstream:    upstreamInfo{Address: g.upstream},
+	}
+	ready, _, err := g.readiness.get()
+	resp.Upstream.Ready = ready
+	if err != nil {
+		resp.Upstream.Error = err.Error()
+	}
+
+	conn, err := g.upstreamConn()
+	if err == nil {
+		var stats *pb.Stats
+		now := time.Now()
+		stats, err = pb.NewAdminServiceClient(conn).GetStats(g.adminContext(c), &pb.StatsRequest{
+			From:     now.Add(-24 * time.Hour).UnixMilli(),
+			To:       now.UnixMilli(),
+			TopRooms: 5,
+		})
+		if err == nil {
+			usage := &usage24h{Messages: stats.Messages, ActiveUsers: stats.ActiveUsers, PeakConcurrency: stats.PeakConcurrency, TopRooms: []roomCount{}}
+			for _, r := range stats.TopRooms {
+				usage.TopRooms = append(usage.TopRooms, roomCount{Room: r.Room, Messages: r.Messages})
+			}
+			if l := stats.Leadership; l != nil {
+				usage.Leadership = &leadership{Name: l.Name, ID: l.Id, Leader: l.Leader, Holder: l.Holder, Since: statsTime(l.Since), Elected: l.Elected, Lost: l.Lost, Errors: l.Errors}
+			}
+			resp.Last24h = usage
+		}
+	}
+	if err != nil && resp.Upstream.Error == "" {
+		resp.Upstream.Error = status.Convert(err).Message()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleAudit serves GET /admin/api/audit, the chat server's recent admin
+// calls that changed state, newest last. limit defaults to 50.
+func (g *Gateway) handleAudit(c *gin.Context) {
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		limit = n
+	}
+	conn, err := g.upstreamConn()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chat server unavailable"})
+		return
+	}
+	stream, err := pb.NewAdminServiceClient(conn).TailAuditLog(g.adminContext(c), &pb.AuditLogRequest{Limit: int32(limit)})
+	entries := []json.RawMessage{}
+	for err == nil {
+		var e *pb.AuditEntry
+		if e, err = stream.Recv(); err == nil {
+			data, _ := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(e)
+			entries = append(entries, data)
+		}
+	}
+	if !errors.Is(err, io.EOF) {
+		g.log.Warnf("Reading the audit log failed: %v", err)
+		c.JSON(exportStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
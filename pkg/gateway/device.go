@@ -0,0 +1,50 @@
+package gateway
+
+import "strings"
+
+// browserMarkers and osMarkers map User-Agent tokens to names, the first match wins. Edge
+// and Opera also claim to be Chrome, and everything claims Safari, so the
+// specific names come first.
+var (
+	browserMarkers = [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"CriOS/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	osMarkers = [][2]string{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// deviceName describes a browser by its User-Agent, such as "Firefox on
+// Linux", for the server's session list. It returns "Web browser" when
+// neither is recognised.
+func deviceName(ua string) string {
+	match := func(markers [][2]string) string {
+		for _, m := range markers {
+			if strings.Contains(ua, m[0]) {
+				return m[1]
+			}
+		}
+		return ""
+	}
+	browser, system := match(browserMarkers), match(osMarkers)
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return "Web browser on " + system
+	}
+	return "Web browser"
+}
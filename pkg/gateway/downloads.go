@@ -0,0 +1,161 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// Downloads bounds what attachment downloads may take from the gateway,
+// so media cannot starve chat traffic. Limits apply per authenticated
+// user, or per client address for anonymous downloads, zero values
+// disable them.
+type Downloads struct {
+	BytesPerSecond    int64 `json:"bytesPerSecond"`    // per client, shared by its downloads
+	MaxPerClient      int   `json:"maxPerClient"`      // concurrent downloads of one client
+	MaxConcurrent     int   `json:"maxConcurrent"`     // concurrent downloads of all clients
+	RetryAfterSeconds int   `json:"retryAfterSeconds"` // sent with 429 answers, 1 when 0
+}
+
+// minDownloadBurst lets a throttled writer send a reasonable chunk at a
+// time even at low rates
+const minDownloadBurst = 32 << 10
+
+// downloadLimiter tracks the downloads and bandwidth of each client, see
+// downloadClientKey
+type downloadLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*downloadClient
+	total   int
+	swept   time.Time
+}
+
+type downloadClient struct {
+	active  int
+	limiter *rate.Limiter // nil without a rate
+	last    time.Time
+}
+
+// acquire reserves a download slot for key, false when a limit is
+// reached. The returned limiter throttles the download and may be nil.
+func (d *downloadLimiter) acquire(key string, cfg Downloads, now time.Time) (*rate.Limiter, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.clients == nil {
+		d.clients = make(map[string]*downloadClient)
+	}
+	if now.Sub(d.swept) > time.Minute {
+		for k, c := range d.clients {
+			if c.active == 0 && now.Sub(c.last) >= time.Minute {
+				delete(d.clients, k)
+			}
+		}
+		d.swept = now
+	}
+	c := d.clients[key]
+	if c == nil {
+		c = &downloadClient{}
+		d.clients[key] = c
+	}
+	c.last = now
+	if (cfg.MaxConcurrent > 0 && d.total >= cfg.MaxConcurrent) || (cfg.MaxPerClient > 0 && c.active >= cfg.MaxPerClient) {
+		return nil, false
+	}
+	c.active++
+	d.total++
+	switch {
+	case cfg.BytesPerSecond <= 0:
+		c.limiter = nil
+	case c.limiter == nil:
+		c.limiter = rate.NewLimiter(rate.Limit(cfg.BytesPerSecond), int(max(cfg.BytesPerSecond, minDownloadBurst)))
+	case c.limiter.Limit() != rate.Limit(cfg.BytesPerSecond):
+		// a reload changed the rate
+		c.limiter.SetLimit(rate.Limit(cfg.BytesPerSecond))
+		c.limiter.SetBurst(int(max(cfg.BytesPerSecond, minDownloadBurst)))
+	}
+	return c.limiter, true
+}
+
+// release frees the slot taken by acquire
+func (d *downloadLimiter) release(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if c := d.clients[key]; c != nil && c.active > 0 {
+		c.active--
+		c.last = time.Now()
+	}
+	d.total--
+}
+
+// limitDownloads applies Config.Downloads to the handlers after it,
+// clients over a concurrency limit get 429
+func (g *Gateway) limitDownloads(c *gin.Context) {
+	cfg := g.config.Load().cfg.Downloads
+	if cfg == (Downloads{}) {
+		return
+	}
+	key := g.downloadClientKey(c)
+	limiter, ok := g.downloads.acquire(key, cfg, time.Now())
+	if !ok {
+		retry := max(cfg.RetryAfterSeconds, 1)
+		c.Header("Retry-After", strconv.Itoa(retry))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many downloads, try again later"})
+		return
+	}
+	defer g.downloads.release(key)
+	if limiter != nil {
+		c.Writer = &throttledWriter{ResponseWriter: c.Writer, c: c, limiter: limiter}
+	}
+	c.Next()
+}
+
+// downloadClientKey names whose limits a download counts against: the
+// user of a valid bearer token, the connecting address otherwise.
+// Forwarded headers are ignored, any client could set them.
+func (g *Gateway) downloadClientKey(c *gin.Context) string {
+	if g.auth != nil {
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && token != "" {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+			user, err := g.auth.Authenticate(ctx, token, "")
+			cancel()
+			if err == nil && user != "" {
+				return "user:" + user
+			}
+		}
+	}
+	return "ip:" + remoteIP(c.Request)
+}
+
+// throttledWriter paces a response body through a shared rate limiter
+type throttledWriter struct {
+	gin.ResponseWriter
+	c       *gin.Context
+	limiter *rate.Limiter
+}
+
+func (w *throttledWriter) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		n := min(len(b), w.limiter.Burst())
+		if err := w.limiter.WaitN(w.c.Request.Context(), n); err != nil {
+			return written, err
+		}
+		n, err := w.ResponseWriter.Write(b[:n])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+func (w *throttledWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// draft routers proxy the chat server's DraftService for the user
+// themselves, conversations such as "#general" travel in the body rather
+// than the path
+func (g *Gateway) setupDraftRoutes(r gin.IRouter) {
+	r = r.Group("", g.requireUser)
+	r.GET("/api/drafts/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewDraftServiceClient(conn).GetDrafts(c.Request.Context(), &pb.DraftsRequest{User: c.Param("user")})
+		})
+	})
+	r.PUT("/api/drafts/:user", func(c *gin.Context) {
+		var req struct {
+			Conversation string `json:"conversation"`
+			Text         string `json:"text"`
+			UpdatedAt    int64  `json:"updatedAt"` // milliseconds, 0 for the time the server gets it
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Conversation == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with conversation, text and updatedAt"})
+			return
+		}
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewDraftServiceClient(conn).SaveDraft(c.Request.Context(), &pb.SaveDraftRequest{
+				User:         c.Param("user"),
+				Conversation: req.Conversation,
+				Text:         req.Text,
+				UpdatedAt:    req.UpdatedAt,
+			})
+		})
+	})
+}
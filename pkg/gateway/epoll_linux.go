@@ -0,0 +1,479 @@
+//go:build linux
+
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"golang.org/x/sys/unix"
+)
+
+// pollEvents arms a socket for one read, it is re-armed once the frames
+// that arrived are handled
+const pollEvents = unix.EPOLLIN | unix.EPOLLRDHUP | unix.EPOLLONESHOT
+
+// poller serves the sockets of TransportEpoll. One goroutine waits for
+// all of them and starts a reader for a socket with frames to read, a
+// writer is started when frames are queued. Both exit once done, so an
+// idle socket has no goroutine.
+type poller struct {
+	gw      *Gateway
+	epfd    int
+	wakefd  int // eventfd that interrupts the wait, see close
+	mu      sync.Mutex
+	sockets map[int]*socket // by file descriptor
+	closing bool
+	stopped bool          // the wait returned and closed epfd and wakefd
+	done    chan struct{} // closed by close, stops the heartbeat
+}
+
+func newPoller(g *Gateway) (*poller, error) {
+	epfd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("epoll: %w", err)
+	}
+	wakefd, err := unix.Eventfd(0, unix.EFD_NONBLOCK|unix.EFD_CLOEXEC)
+	if err != nil {
+		unix.Close(epfd)
+		return nil, fmt.Errorf("eventfd: %w", err)
+	}
+	ev := unix.EpollEvent{Events: unix.EPOLLIN, Fd: int32(wakefd)}
+	if err := unix.EpollCtl(epfd, unix.EPOLL_CTL_ADD, wakefd, &ev); err != nil {
+		unix.Close(epfd)
+		unix.Close(wakefd)
+		return nil, fmt.Errorf("epoll: %w", err)
+	}
+	p := &poller{
+		gw:      g,
+		epfd:    epfd,
+		wakefd:  wakefd,
+		sockets: make(map[int]*socket),
+		done:    make(chan struct{}),
+	}
+	go p.wait()
+	go p.heartbeat()
+	return p, nil
+}
+
+// wait starts a reader for every socket with frames to read until the
+// poller is closed and its last socket is gone
+func (p *poller) wait() {
+	events := make([]unix.EpollEvent, 256)
+	for {
+		n, err := unix.EpollWait(p.epfd, events, -1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			p.gw.log.Errorf("WebSocket poller stopped: %v", err)
+		}
+		p.mu.Lock()
+		if err != nil || p.closing && len(p.sockets) == 0 {
+			p.stopped = true
+			unix.Close(p.epfd)
+			unix.Close(p.wakefd)
+			p.mu.Unlock()
+			return
+		}
+		for _, ev := range events[:n] {
+			if int(ev.Fd) == p.wakefd {
+				var buf [8]byte
+				_, _ = unix.Read(p.wakefd, buf[:])
+				continue
+			}
+			if s, ok := p.sockets[int(ev.Fd)]; ok {
+				s.startRead()
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// wakeup interrupts the wait, callers hold mu
+func (p *poller) wakeup() {
+	if p.stopped {
+		return
+	}
+	var one [8]byte
+	binary.NativeEndian.PutUint64(one[:], 1)
+	_, _ = unix.Write(p.wakefd, one[:])
+}
+
+// close stops the heartbeat. The poller waits on until the sockets the
+// hub is closing are gone.
+func (p *poller) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closing {
+		p.closing = true
+		close(p.done)
+	}
+	p.wakeup()
+}
+
+// serve upgrades r to a socket of the poller
+func (p *poller) serve(c *WSClient, w http.ResponseWriter, r *http.Request) {
+	if !p.gw.upgrader.CheckOrigin(r) {
+		p.gw.log.Warnf("WebSocket upgrade failed: origin %q not allowed", r.Header.Get("Origin"))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	u := ws.HTTPUpgrader{
+		Timeout:  p.gw.heartbeat.WriteWait,
+		Protocol: func(proto string) bool { return proto == Subprotocol },
+	}
+	conn, rw, _, err := u.Upgrade(r, w)
+	if err != nil {
+		p.gw.log.Warnf("WebSocket upgrade failed: %v", err)
+		if conn != nil {
+			conn.Close()
+		}
+		return
+	}
+	s, err := newSocket(p, c, conn)
+	if err != nil {
+		p.gw.log.Warnf("WebSocket upgrade failed: %v", err)
+		conn.Close()
+		return
+	}
+	if n := rw.Reader.Buffered(); n > 0 {
+		// frames sent right behind the upgrade request
+		b, _ := rw.Reader.Peek(n)
+		s.pending = bytes.Clone(b)
+	}
+	c.sock = s
+	c.send.wake = s.wake
+	if !p.gw.register(c) {
+		return
+	}
+	if err := p.add(s); err != nil {
+		p.gw.log.Warnf("WebSocket not polled: %v", err)
+		c.finish()
+		return
+	}
+	if len(s.pending) > 0 {
+		s.startRead()
+	}
+}
+
+// add arms s for its first read
+func (p *poller) add(s *socket) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closing {
+		return errors.New("gateway closing")
+	}
+	ev := unix.EpollEvent{Events: pollEvents, Fd: int32(s.fd)}
+	if err := unix.EpollCtl(p.epfd, unix.EPOLL_CTL_ADD, s.fd, &ev); err != nil {
+		return err
+	}
+	p.sockets[s.fd] = s
+	return nil
+}
+
+// rearm arms s for its next read
+func (p *poller) rearm(s *socket) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sockets[s.fd] != s {
+		return net.ErrClosed
+	}
+	ev := unix.EpollEvent{Events: pollEvents, Fd: int32(s.fd)}
+	return unix.EpollCtl(p.epfd, unix.EPOLL_CTL_MOD, s.fd, &ev)
+}
+
+// remove stops polling s, before its descriptor is closed and reused
+func (p *poller) remove(s *socket) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sockets[s.fd] != s {
+		return
+	}
+	_ = unix.EpollCtl(p.epfd, unix.EPOLL_CTL_DEL, s.fd, nil)
+	delete(p.sockets, s.fd)
+	if p.closing && len(p.sockets) == 0 {
+		p.wakeup()
+	}
+}
+
+// heartbeat pings the sockets that are due a ping and hangs up the ones
+// nothing arrived from for PongWait, like the read deadline of the pumps
+func (p *poller) heartbeat() {
+	hb := p.gw.heartbeat
+	ticker := time.NewTicker(hb.tick())
+	defer ticker.Stop()
+	var sockets []*socket
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			for _, s := range p.sockets {
+				sockets = append(sockets, s)
+			}
+			p.mu.Unlock()
+			for _, s := range sockets {
+				if now.Sub(time.Unix(0, s.lastRead.Load())) > hb.PongWait {
+					p.gw.log.Debugf("WebSocket of %s timed out", s.c.username)
+					s.hangup()
+					continue
+				}
+				if !s.c.pace.due(hb, now) {
+					continue
+				}
+				s.pingDue.Store(true)
+				s.wake()
+			}
+			clear(sockets)
+			sockets = sockets[:0]
+		}
+	}
+}
+
+// socket is a WebSocket served by the poller. Its reader and writer are
+// started on demand, at most one of each runs at a time.
+type socket struct {
+	p        *poller
+	c        *WSClient
+	conn     net.Conn
+	raw      syscall.RawConn
+	fd       int
+	rd       wsutil.Reader
+	pending  []byte       // arrived with the upgrade request, read first
+	lastRead atomic.Int64 // Unix nanoseconds of the last frame read
+	reading  atomic.Bool  // a reader runs, see startRead
+	writing  atomic.Bool  // a writer runs, see wake
+	pingDue  atomic.Bool  // the writer sends a ping next
+	closed   atomic.Bool  // hung up, see hangup
+	wmu      sync.Mutex   // serializes writes to conn
+	frames   []outFrame   // of the last take, writer only
+}
+
+func newSocket(p *poller, c *WSClient, conn net.Conn) (*socket, error) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("cannot poll a %T", conn)
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	s := &socket{p: p, c: c, conn: conn, raw: raw}
+	if err := raw.Control(func(fd uintptr) { s.fd = int(fd) }); err != nil {
+		return nil, err
+	}
+	s.rd = wsutil.Reader{
+		Source:         s,
+		State:          ws.StateServerSide,
+		OnIntermediate: s.control,
+	}
+	s.lastRead.Store(time.Now().UnixNano())
+	return s, nil
+}
+
+// startRead starts a reader unless one is running
+func (s *socket) startRead() {
+	if s.reading.CompareAndSwap(false, true) {
+		go s.read()
+	}
+}
+
+// read handles the message that arrived, the socket is re-armed for the
+// next one. When the socket is gone the client is finished.
+func (s *socket) read() {
+	c := s.c
+	for {
+		message, err := s.next()
+		switch {
+		case errors.Is(err, wsutil.ErrFrameTooLarge):
+			_, _ = s.Write(ws.MustCompileFrame(ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusMessageTooBig, ""))))
+			c.gw.log.Infof("Closed WebSocket of %s: frame over the size limit", c.username)
+			c.finish()
+			return
+		case err != nil:
+			c.gw.log.Debugf("WebSocket read error: %v", err)
+			c.finish()
+			return
+		case message != nil:
+			c.receive(message)
+		}
+		if len(s.pending) == 0 {
+			break
+		}
+	}
+	s.reading.Store(false)
+	if err := s.p.rearm(s); err != nil {
+		c.gw.log.Debugf("WebSocket not re-armed: %v", err)
+		c.finish()
+	}
+}
+
+// next reads a message, it is nil after answering a control frame
+func (s *socket) next() ([]byte, error) {
+	c := s.c
+	limit := int64(c.gw.config.Load().cfg.Limits.frameSize())
+	s.rd.MaxFrameSize = limit
+	// a partly sent frame is waited for like an idle socket
+	_ = s.conn.SetReadDeadline(time.Now().Add(c.gw.heartbeat.PongWait))
+	hdr, err := s.rd.NextFrame()
+	if err != nil {
+		return nil, err
+	}
+	s.lastRead.Store(time.Now().UnixNano())
+	if hdr.OpCode.IsControl() {
+		return nil, s.control(hdr, &s.rd)
+	}
+	message, err := io.ReadAll(io.LimitReader(&s.rd, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(message)) > limit {
+		return nil, wsutil.ErrFrameTooLarge
+	}
+	return message, nil
+}
+
+// control answers a control frame, a pong also ends the outstanding ping
+func (s *socket) control(hdr ws.Header, r io.Reader) error {
+	if hdr.OpCode == ws.OpPong {
+		s.c.ponged()
+	}
+	return wsutil.ControlHandler{
+		Src:                 r,
+		Dst:                 s,
+		State:               ws.StateServerSide,
+		DisableSrcCiphering: true, // unmasked by rd
+	}.Handle(hdr)
+}
+
+// Read reads what arrived with the upgrade request before the socket
+func (s *socket) Read(b []byte) (int, error) {
+	if len(s.pending) > 0 {
+		n := copy(b, s.pending)
+		s.pending = s.pending[n:]
+		return n, nil
+	}
+	return s.conn.Read(b)
+}
+
+// Write writes a whole frame next to the writer, for the answers to
+// control frames
+func (s *socket) Write(b []byte) (int, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.c.gw.heartbeat.WriteWait))
+	return s.conn.Write(b)
+}
+
+// wake starts a writer unless one is running, it is the outbox's signal
+func (s *socket) wake() {
+	if s.writing.CompareAndSwap(false, true) {
+		go s.flush()
+	}
+}
+
+// flush writes until nothing is left, frames queued while the writer
+// exits are picked up by it or by the writer they wake
+func (s *socket) flush() {
+	for {
+		s.write()
+		s.writing.Store(false)
+		if s.closed.Load() || !s.pingDue.Load() && !s.c.send.pending() {
+			return
+		}
+		if !s.writing.CompareAndSwap(false, true) {
+			return
+		}
+	}
+}
+
+// write sends the queued frames as one message like the write pump, then
+// a due ping. Once the outbox is closed the close frame follows and the
+// socket is hung up.
+func (s *socket) write() {
+	c := s.c
+	frames, closed := c.send.take(s.frames)
+	s.frames = frames
+	defer func() {
+		for _, frame := range frames {
+			frame.release()
+		}
+	}()
+	if s.closed.Load() {
+		return
+	}
+
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(c.gw.heartbeat.WriteWait))
+	var err error
+	if len(frames) > 0 {
+		err = s.writeText(frames)
+	}
+	if err == nil && s.pingDue.Swap(false) {
+		if _, err = s.conn.Write(ws.CompiledPing); err == nil {
+			c.pinged()
+		}
+	}
+	if err == nil && closed {
+		err = ws.WriteFrame(s.conn, ws.NewCloseFrame(c.closeMsg))
+	}
+	if err != nil || closed {
+		s.hangup()
+	}
+}
+
+// writeText writes frames as one text message separated by newlines,
+// with a single writev
+func (s *socket) writeText(frames []outFrame) error {
+	n := len(frames) - 1
+	for _, frame := range frames {
+		n += len(frame.data)
+	}
+	var hdr bytes.Buffer
+	hdr.Grow(ws.MaxHeaderSize)
+	_ = ws.WriteHeader(&hdr, ws.Header{Fin: true, OpCode: ws.OpText, Length: int64(n)})
+	bufs := make(net.Buffers, 0, 2*len(frames))
+	bufs = append(bufs, hdr.Bytes())
+	for i, frame := range frames {
+		if i > 0 {
+			bufs = append(bufs, newline)
+		}
+		bufs = append(bufs, frame.data)
+	}
+	_, err := bufs.WriteTo(s.conn)
+	return err
+}
+
+var newline = []byte{'\n'}
+
+// hangup shuts the socket down, the reader then sees it end and
+// finishes the client
+func (s *socket) hangup() {
+	if !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+	_ = s.raw.Control(func(fd uintptr) {
+		_ = unix.Shutdown(int(fd), unix.SHUT_RDWR)
+	})
+}
+
+// Close stops polling the socket and closes it, called by finish
+func (s *socket) Close() error {
+	s.closed.Store(true)
+	s.p.remove(s)
+	return s.conn.Close()
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package gateway
+
+import (
+	"errors"
+	"net/http"
+)
+
+// poller is only implemented on Linux
+type poller struct{}
+
+func newPoller(*Gateway) (*poller, error) {
+	return nil, errors.New("the epoll transport needs Linux")
+}
+
+func (p *poller) serve(*WSClient, http.ResponseWriter, *http.Request) {}
+
+func (p *poller) close() {}
@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// Error codes of "error" frames, stable for clients to branch on while
+// the text and key may change
+const (
+	ErrNotJoined   = "not_joined"      // the request needs a joined session
+	ErrBadRequest  = "bad_request"     // the frame was malformed or incomplete
+	ErrRateLimited = "rate_limited"    // slow down and send again
+	ErrUnavailable = "unavailable"     // the chat server cannot be reached right now
+	ErrAuth        = "unauthenticated" // the token is missing or invalid
+	ErrForbidden   = "forbidden"       // the chat server refused this user
+	ErrBanned      = "banned"          // the user or their address is banned
+	ErrChallenge   = "challenge"       // the answer to a "challenge" frame was wrong
+	ErrTooMany     = "too_many"        // the user or server has too many connections
+	ErrTooLarge    = "too_large"       // the frame is over the configured size
+	ErrInternal    = "internal"
+)
+
+// wsError describes an error frame, Retryable tells clients that trying
+// the same thing again later may succeed
+type wsError struct {
+	Code      string
+	Retryable bool
+}
+
+// errorKinds classifies the i18n keys sent as errors, keys missing here
+// are internal errors
+var errorKinds = map[string]wsError{
+	i18n.NotConnected:      {ErrNotJoined, false},
+	i18n.AlreadyJoined:     {ErrBadRequest, false},
+	i18n.SignalNoRecipient: {ErrBadRequest, false},
+	i18n.SignalUnknown:     {ErrBadRequest, false},
+	i18n.CodeMissing:       {ErrBadRequest, false},
+	i18n.AttachmentUnknown: {ErrBadRequest, false},
+	i18n.UsernameRequired:  {ErrBadRequest, false},
+	i18n.RateLimited:       {ErrRateLimited, true},
+	i18n.ServerUnavailable: {ErrUnavailable, true},
+	i18n.ConnectFailed:     {ErrUnavailable, true},
+	i18n.JoinFailed:        {ErrUnavailable, true},
+	i18n.SendFailed:        {ErrUnavailable, true},
+	i18n.SignalFailed:      {ErrUnavailable, true},
+	i18n.AuthRequired:      {ErrAuth, false},
+	i18n.AuthFailed:        {ErrAuth, false},
+	i18n.AuthUserMismatch:  {ErrAuth, false},
+	i18n.JoinDenied:        {ErrForbidden, false},
+	i18n.TooManyStreams:    {ErrTooMany, true},
+	i18n.MessageTooLarge:   {ErrTooLarge, false},
+	i18n.SessionRevoked:    {ErrAuth, false},
+	i18n.Banned:            {ErrBanned, false},
+	i18n.BannedUntil:       {ErrBanned, false},
+	i18n.ChallengeFailed:   {ErrChallenge, true},
+}
+
+// errorFrame builds an "error" frame for key
+func errorFrame(key string, kv ...string) []byte {
+	kind, ok := errorKinds[key]
+	if !ok {
+		kind = wsError{Code: ErrInternal}
+	}
+	args := i18n.Args(kv...)
+	return encodeFrame(ErrorFrame{
+		Type:      "error",
+		Code:      kind.Code,
+		Text:      i18n.Render(i18n.DefaultLocale, key, args),
+		Key:       key,
+		Args:      args,
+		Retryable: kind.Retryable,
+	})
+}
+
+// Close codes and reasons the gateway ends WebSockets with. Clients
+// reconnect after GoingAway, ServiceRestart, TryAgainLater and
+// InternalServerErr and MessageTooBig, and give up after PolicyViolation.
+const (
+	reasonShutdown    = "server shutting down"
+	reasonSlow        = "client too slow"
+	reasonMaintenance = "maintenance"
+	reasonUpstream    = "chat server unavailable"
+)
+
+// revoked reports and closes the socket when the chat server logged the
+// session out. Joins are refused with the same code, but only before the
+// stream delivers anything.
+func (c *WSClient) revoked(err error) bool {
+	if status.Code(err) != codes.Unauthenticated || !c.accepted.Load() {
+		return false
+	}
+	c.sendError(i18n.SessionRevoked)
+	c.closeWith(websocket.ClosePolicyViolation, "logged out")
+	return true
+}
+
+// upstreamRefused reports and closes the socket when the chat server
+// turned the user away: they are banned, its authenticator refused them
+// or they have too many streams. It returns false for other errors.
+func (c *WSClient) upstreamRefused(err error) bool {
+	if ban := banOf(err); ban != nil {
+		if ban.ExpiresAt == 0 {
+			c.sendError(i18n.Banned, "reason", ban.Reason)
+		} else {
+			until := time.UnixMilli(ban.ExpiresAt).UTC().Format(time.RFC3339)
+			c.sendError(i18n.BannedUntil, "until", until, "reason", ban.Reason)
+		}
+		c.closeWith(websocket.ClosePolicyViolation, "banned")
+		return true
+	}
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated:
+		c.sendError(i18n.JoinDenied)
+		c.closeWith(websocket.ClosePolicyViolation, "join denied")
+	case codes.ResourceExhausted:
+		c.sendError(i18n.TooManyStreams)
+		c.closeWith(websocket.CloseTryAgainLater, "too many connections")
+	default:
+		return false
+	}
+	return true
+}
+
+// banOf returns the ban the chat server refused a stream for, nil when
+// err is not a ban
+func banOf(err error) *pb.Ban {
+	if status.Code(err) != codes.PermissionDenied {
+		return nil
+	}
+	for _, d := range status.Convert(err).Details() {
+		if ban, ok := d.(*pb.Ban); ok {
+			return ban
+		}
+	}
+	return nil
+}
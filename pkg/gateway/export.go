@@ -0,0 +1,303 @@
+package gateway
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// transcript writes an exported room in one format, messages are written
+// as they arrive from the chat server
+type transcript interface {
+	begin(room string) error
+	write(msg *pb.ChatMessage) error
+	end() error
+}
+
+var exportFormats = map[string]struct {
+	contentType string
+	create      func(w io.Writer) transcript
+}{
+	"json": {"application/json", func(w io.Writer) transcript { return &jsonTranscript{w: w} }},
+	"csv":  {"text/csv; charset=utf-8", func(w io.Writer) transcript { return &csvTranscript{w: csv.NewWriter(w)} }},
+	"html": {"text/html; charset=utf-8", func(w io.Writer) transcript { return &htmlTranscript{w: w} }},
+}
+
+// handleExport serves GET /api/admin/rooms/:room/export. format is json
+// (the default), csv or html, from and to are RFC 3339 times or dates,
+// a date as to includes that whole day.
+func (g *Gateway) handleExport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	f, ok := exportFormats[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or html"})
+		return
+	}
+	from, err := parseExportTime(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error()})
+		return
+	}
+	to, err := parseExportTime(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error()})
+		return
+	}
+	conn, err := g.upstreamConn()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chat server unavailable"})
+		return
+	}
+
+	room := c.Param("room")
+	req := &pb.ExportRequest{Room: room}
+	if !from.IsZero() {
+		req.From = from.UnixMilli()
+	}
+	if !to.IsZero() {
+		req.To = to.UnixMilli()
+	}
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "authorization", "Bearer "+g.adminToken)
+	stream, err := pb.NewAdminServiceClient(conn).ExportRoom(ctx, req)
+	var first *pb.ChatMessage
+	if err == nil {
+		// errors of a server stream arrive with the first receive, get
+		// them while a status can still be sent
+		first, err = stream.Recv()
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		g.log.Warnf("Export of %s failed: %v", room, err)
+		c.JSON(exportStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s.%s", room, time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", f.contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Status(http.StatusOK)
+	t := f.create(c.Writer)
+	err = t.begin(room)
+	for msg := first; err == nil && msg != nil; {
+		if err = t.write(msg); err == nil {
+			msg, err = stream.Recv()
+		}
+	}
+	if errors.Is(err, io.EOF) || err == nil {
+		err = t.end()
+	}
+	if err != nil {
+		// the status is sent already, the transcript ends up truncated
+		g.log.Errorf("Export of %s broke off: %v", room, err)
+	}
+}
+
+// exportStatus maps a chat server error to the HTTP status of the export
+func exportStatus(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	// the chat server refused the gateway, e.g. a different admin token
+	return http.StatusBadGateway
+}
+
+// parseExportTime accepts RFC 3339 or a date, end moves a date to the
+// start of the next day. Empty means no bound.
+func parseExportTime(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, errors.New("want RFC 3339 or YYYY-MM-DD")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// exportKind names what a message carries: chat, code, attachment or system
+func exportKind(msg *pb.ChatMessage) string {
+	switch {
+	case msg.GetCode() != nil:
+		return "code"
+	case msg.GetAttachment() != nil:
+		return "attachment"
+	case pb.TypeOf(msg) != pb.MessageType_TYPE_CHAT:
+		return "system"
+	}
+	return "chat"
+}
+
+// exportBody is the readable content of msg, code blocks verbatim and
+// attachments as name and URL
+func exportBody(msg *pb.ChatMessage) string {
+	if code := msg.GetCode(); code != nil {
+		return code.Content
+	}
+	if a := msg.GetAttachment(); a != nil {
+		return withCaption(msg.Text, attachmentLabel(a)+" "+a.Url)
+	}
+	return msg.Text
+}
+
+// attachmentLabel is the file name of a, or its kind when it has none
+func attachmentLabel(a *pb.Attachment) string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Kind
+}
+
+func withCaption(caption, s string) string {
+	if caption == "" {
+		return s
+	}
+	return caption + "\n" + s
+}
+
+func exportTime(msg *pb.ChatMessage) string {
+	return time.UnixMilli(msg.Timestamp).UTC().Format(time.RFC3339)
+}
+
+// jsonTranscript writes a JSON array of messages in protojson form
+type jsonTranscript struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonTranscript) begin(string) error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonTranscript) write(msg *pb.ChatMessage) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "\n"
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonTranscript) end() error {
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// csvTranscript writes one row per message
+type csvTranscript struct {
+	w *csv.Writer
+}
+
+func (t *csvTranscript) begin(string) error {
+	return t.w.Write([]string{"time", "id", "seq", "user", "kind", "text", "metadata"})
+}
+
+func (t *csvTranscript) write(msg *pb.ChatMessage) error {
+	md := ""
+	if len(msg.Metadata) > 0 {
+		data, _ := json.Marshal(msg.Metadata)
+		md = string(data)
+	}
+	return t.w.Write([]string{exportTime(msg), msg.Id, fmt.Sprint(msg.Seq), msg.User, exportKind(msg), exportBody(msg), md})
+}
+
+func (t *csvTranscript) end() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// htmlTranscript writes a standalone page, styles are inlined so the file
+// can be archived on its own
+type htmlTranscript struct {
+	w io.Writer
+}
+
+var transcriptTemplates = template.Must(template.New("begin").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>#{{.Room}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+.msg { padding: .3em 0; border-bottom: 1px solid #eee; }
+.time { color: #888; font-size: .85em; margin-right: .5em; }
+.user { font-weight: bold; margin-right: .5em; }
+.system { color: #888; font-style: italic; }
+.body { white-space: pre-wrap; }
+pre { background: #f5f5f5; padding: .5em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>#{{.Room}}</h1>
+<p>Exported {{.Exported}}</p>
+`))
+
+func init() {
+	template.Must(transcriptTemplates.New("message").Parse(`<div class="msg{{if eq .Kind "system"}} system{{end}}" id="{{.ID}}">` +
+		`<span class="time">{{.Time}}</span><span class="user">{{.User}}</span>` +
+		`{{if eq .Kind "code"}}<pre><code>{{.Body}}</code></pre>` +
+		`{{else if .URL}}<span class="body">{{.Body}}</span> <a href="{{.URL}}">{{.URL}}</a>` +
+		`{{else}}<span class="body">{{.Body}}</span>{{end}}</div>
+`))
+	template.Must(transcriptTemplates.New("end").Parse("</body>\n</html>\n"))
+}
+
+func (t *htmlTranscript) begin(room string) error {
+	return transcriptTemplates.ExecuteTemplate(t.w, "begin", map[string]string{
+		"Room":     room,
+		"Exported": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (t *htmlTranscript) write(msg *pb.ChatMessage) error {
+	body, url := exportBody(msg), ""
+	if a := msg.GetAttachment(); a != nil {
+		body, url = withCaption(msg.Text, attachmentLabel(a)), a.Url
+	}
+	return transcriptTemplates.ExecuteTemplate(t.w, "message", map[string]string{
+		"ID":   msg.Id,
+		"Time": exportTime(msg),
+		"User": msg.User,
+		"Kind": exportKind(msg),
+		"Body": body,
+		"URL":  url,
+	})
+}
+
+func (t *htmlTranscript) end() error {
+	return transcriptTemplates.ExecuteTemplate(t.w, "end", nil)
+}
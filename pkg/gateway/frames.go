@@ -0,0 +1,232 @@
+package gateway
+
+import "encoding/json"
+
+// Frames sent to the browser besides WSMessage. Those carrying an event
+// mirror the ChatMessage payload of the same name in proto/chat, with
+// enums spelled as the lowercase names the web client uses.
+
+// RenameFrame is sent as "userRename", Self is set on the renamer's copy
+type RenameFrame struct {
+	Type    string `json:"type"`
+	User    string `json:"user"`
+	OldUser string `json:"oldUser"`
+	Text    string `json:"text"`
+	Self    bool   `json:"self"`
+}
+
+// AckFrame is sent as "ack" to the browser that sent ClientMsgID
+type AckFrame struct {
+	Type        string `json:"type"`
+	ClientMsgID string `json:"clientMsgId"`
+	ID          string `json:"id"`
+	Room        string `json:"room"`
+	Seq         uint64 `json:"seq"`
+	Duplicate   bool   `json:"duplicate"`
+}
+
+// LinkPreviewFrame is sent as "link_preview" for an earlier message
+type LinkPreviewFrame struct {
+	Type        string `json:"type"`
+	MessageID   string `json:"messageId"`
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"imageUrl"`
+	SiteName    string `json:"siteName"`
+}
+
+// TranslationFrame is sent as "translation" for an earlier message
+type TranslationFrame struct {
+	Type       string `json:"type"`
+	MessageID  string `json:"messageId"`
+	Lang       string `json:"lang"`
+	Text       string `json:"text"`
+	SourceLang string `json:"sourceLang"`
+}
+
+// EditFrame is sent as "edit" when an earlier message changes, only the
+// highest revision counts
+type EditFrame struct {
+	Type      string `json:"type"`
+	MessageID string `json:"messageId"`
+	Text      string `json:"text"`
+	Revision  uint32 `json:"revision"`
+	Done      bool   `json:"done"`
+}
+
+// SignalFrame is sent as "signal" from the other party of a call
+type SignalFrame struct {
+	Type          string `json:"type"`
+	User          string `json:"user"`
+	RecipientUser string `json:"recipientUser"`
+	Signal        Signal `json:"signal"`
+}
+
+// CallFrame is sent as "call" when a call changes state
+type CallFrame struct {
+	Type string   `json:"type"`
+	Call CallInfo `json:"call"`
+}
+
+// CallInfo describes a call, State is ringing, in-call or ended
+type CallInfo struct {
+	CallID string `json:"callId"`
+	State  string `json:"state"`
+	Caller string `json:"caller"`
+	Callee string `json:"callee"`
+	Reason string `json:"reason"`
+}
+
+// VoiceFrame is sent as "voice" when a user joins or leaves the voice
+// channel of a room or starts or stops speaking in it
+type VoiceFrame struct {
+	Type   string      `json:"type"`
+	Room   string      `json:"room"`
+	Action string      `json:"action"` // joined, left, speaking or silent
+	Member VoiceMember `json:"member"`
+}
+
+// ThreadFrame is sent as "thread" to a room when a message in it got a
+// reply, the reply itself only goes to the followers of the thread
+type ThreadFrame struct {
+	Type        string `json:"type"`
+	Room        string `json:"room"`
+	ThreadID    string `json:"threadId"`
+	ReplyCount  uint32 `json:"replyCount"`
+	LastUser    string `json:"lastUser"`
+	LastReplyAt string `json:"lastReplyAt"` // RFC 3339
+}
+
+// VoiceMember is a user in a voice channel
+type VoiceMember struct {
+	User     string `json:"user"`
+	Speaking bool   `json:"speaking"`
+	JoinedAt string `json:"joinedAt"` // RFC 3339
+}
+
+// PresenceFrame is sent as "presence" when a user's status changes
+type PresenceFrame struct {
+	Type   string `json:"type"`
+	User   string `json:"user"`
+	Status string `json:"status"`
+}
+
+// MemberFrame is sent as "member" when a user enters or leaves a room or
+// their role in it changes, and is the shape of /api/users members
+type MemberFrame struct {
+	Type     string `json:"type,omitempty"`
+	Room     string `json:"room,omitempty"`
+	User     string `json:"user"`
+	Role     string `json:"role"`
+	Online   bool   `json:"online"`
+	Status   string `json:"status,omitempty"`   // while online
+	LastSeen string `json:"lastSeen,omitempty"` // RFC 3339, while offline
+}
+
+// UnreadFrame is sent as "unread_update" with the counts per room
+type UnreadFrame struct {
+	Type  string            `json:"type"`
+	Rooms map[string]uint32 `json:"rooms"`
+}
+
+// KeywordHitFrame is sent as "keywordHit" when a public message contains
+// one of the user's keywords for its room
+type KeywordHitFrame struct {
+	Type      string `json:"type"`
+	Keyword   string `json:"keyword"`
+	Room      string `json:"room"`
+	MessageID string `json:"messageId"`
+	Sender    string `json:"sender"`
+	Text      string `json:"text"`
+}
+
+// SubscriptionsFrame is sent as "subscriptions" when the rooms the
+// socket receives change, chat messages name the room they belong to
+type SubscriptionsFrame struct {
+	Type  string   `json:"type"`
+	Room  string   `json:"room"`            // current room
+	Rooms []string `json:"rooms,omitempty"` // subscribed besides it
+}
+
+// UserListFrame is sent as "userList" with everyone online after joining
+type UserListFrame struct {
+	Type  string   `json:"type"`
+	Users []string `json:"users"`
+}
+
+// UserJoinFrame is sent as "userJoin" when the server announces a join,
+// the text of the announcement follows as a chat message
+type UserJoinFrame struct {
+	Type string `json:"type"`
+	User string `json:"user"`
+}
+
+// UserLeaveFrame is sent as "userLeave" when the server announces a leave
+type UserLeaveFrame struct {
+	Type string `json:"type"`
+	User string `json:"user"`
+}
+
+// SystemFrame is sent as "system", Text is the English rendering of Key
+// for clients without the catalog
+type SystemFrame struct {
+	Type string            `json:"type"`
+	Text string            `json:"text"`
+	Key  string            `json:"key"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// ErrorFrame is sent as "error". Code is one of the Err constants,
+// Retryable tells whether the same request may succeed later.
+type ErrorFrame struct {
+	Type      string            `json:"type"`
+	Code      string            `json:"code"`
+	Text      string            `json:"text"`
+	Key       string            `json:"key"`
+	Args      map[string]string `json:"args,omitempty"`
+	Retryable bool              `json:"retryable"`
+}
+
+// MaintenanceFrame is sent as "maintenance", DrainAt is RFC 3339
+type MaintenanceFrame struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+	Text    string `json:"text"`
+	DrainAt string `json:"drainAt,omitempty"`
+}
+
+// UpstreamFrame is sent as "upstream" with the state of the gRPC stream
+type UpstreamFrame struct {
+	Type  string `json:"type"`
+	State string `json:"state"`
+}
+
+// ConnectionQualityFrame is sent as "connection_quality" when a pong is
+// late ("poor") and when one arrives again ("good"), RTT is the round
+// trip of that pong in milliseconds
+type ConnectionQualityFrame struct {
+	Type    string `json:"type"`
+	Quality string `json:"quality"`
+	RTT     int64  `json:"rttMs,omitempty"`
+}
+
+// ChallengeFrame is sent as "challenge" instead of joining when the
+// browser's address joins too often. The browser answers by sending its
+// join again with a ChallengeAnswer: for Kind "pow" a Solution such that
+// SHA-256 of Nonce, ":" and Solution starts with Difficulty zero bits, for
+// Kind "captcha" the Response of Provider's widget rendered with SiteKey.
+type ChallengeFrame struct {
+	Type       string `json:"type"`
+	Kind       string `json:"kind"`
+	Nonce      string `json:"nonce,omitempty"`
+	Difficulty int    `json:"difficulty,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	SiteKey    string `json:"siteKey,omitempty"`
+}
+
+// encodeFrame marshals a frame, the frame types cannot fail to encode
+func encodeFrame(v any) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,347 @@
+package gateway
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+
+	"realTimeChat/pkg/avscan"
+	"realTimeChat/pkg/imaging"
+	"realTimeChat/web"
+)
+
+// DefaultUpstream is the chat server address used when none is configured
+const DefaultUpstream = "localhost:50051"
+
+// DefaultJoinWait is how long a join waits for an unreachable chat server
+const DefaultJoinWait = 15 * time.Second
+
+// DefaultReconnectRetries caps attempts to re-establish a dropped upstream
+// stream before the browser's WebSocket is closed
+const DefaultReconnectRetries = 10
+
+// Keepalive sets how the gateway pings the chat server to detect
+// connections dropped silently, e.g. by a load balancer. The chat server
+// must allow pings this frequent, see chatserver.Keepalive.
+type Keepalive struct {
+	Time                time.Duration // ping after this long without activity, 0 disables pings
+	Timeout             time.Duration // reconnect when a ping is not answered in time
+	PermitWithoutStream bool          // keep pinging while no client is joined
+}
+
+// DefaultKeepalive pings every 30s, well inside common load balancer
+// idle timeouts
+var DefaultKeepalive = Keepalive{
+	Time:                30 * time.Second,
+	Timeout:             10 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// Direction tells a Transformer which way a message is travelling
+type Direction int
+
+const (
+	// ToUpstream messages come from a browser and go to the chat server
+	ToUpstream Direction = iota
+	// ToClient messages come from the chat server and go to a browser
+	ToClient
+)
+
+// Transformer may rewrite a chat message passing through the gateway,
+// returning false drops it. msg is reused once the transformer returns
+// and must not be kept.
+type Transformer func(dir Direction, msg *WSMessage) bool
+
+// Option configures a Gateway
+type Option func(*Gateway)
+
+// Gateway serves the web client and relays its WebSocket traffic to the
+// gRPC chat server
+type Gateway struct {
+	hub          *WSHub
+	upstream     string
+	dialOpts     []grpc.DialOption
+	keepalive    Keepalive
+	heartbeat    Heartbeat // WebSocket pings
+	upgrader     websocket.Upgrader
+	transport    Transport
+	poller       *poller // serves the sockets of TransportEpoll, nil for the pumps
+	transformers []Transformer
+	middleware   []gin.HandlerFunc
+	assets       fs.FS // web client files, rooted at index.html
+	static       fs.FS // assets/static
+	router       *gin.Engine
+	joinWait     time.Duration
+	retries      int // see WithReconnectRetries
+	uploadDir    string
+	attachments  *attachmentStore // nil when uploadDir is unusable
+	media        MediaProvider    // GIF search, nil when not configured
+	captcha      CaptchaProvider  // join challenges, proof of work when nil
+	scanner      avscan.Scanner   // checks uploads, nil leaves them unscanned
+	joins        joinCounter      // joins per address, see Config.Challenge
+	downloads    downloadLimiter  // attachment downloads per address, see Config.Downloads
+
+	thumbnailSizes []int // of image uploads
+
+	log        *logger
+	config     atomic.Pointer[configSnapshot]
+	initConfig *Config
+	configFile string
+	adminToken string
+	maint      maintenanceState
+
+	auth        Authenticator // nil accepts any username
+	authTimeout time.Duration
+
+	readiness   *readiness
+	stopWatcher context.CancelFunc
+	watcherDone chan struct{}
+
+	connOnce sync.Once
+	conn     *grpc.ClientConn // shared by all upstream sessions
+	connErr  error
+	shards   shardRouter // chat servers other than upstream, see WithShards
+}
+
+// WithUpstream sets the chat server address
+func WithUpstream(target string) Option {
+	return func(g *Gateway) {
+		g.upstream = target
+	}
+}
+
+// WithDialOptions replaces the gRPC dial options, the default is an
+// insecure connection
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(g *Gateway) {
+		g.dialOpts = opts
+	}
+}
+
+// WithKeepalive replaces DefaultKeepalive
+func WithKeepalive(k Keepalive) Option {
+	return func(g *Gateway) {
+		g.keepalive = k
+	}
+}
+
+// WithUpgrader replaces the WebSocket upgrader settings
+func WithUpgrader(u websocket.Upgrader) Option {
+	return func(g *Gateway) {
+		g.upgrader = u
+	}
+}
+
+// WithTransformer appends a message transformer, transformers run in the
+// order they were added
+func WithTransformer(t Transformer) Option {
+	return func(g *Gateway) {
+		g.transformers = append(g.transformers, t)
+	}
+}
+
+// WithMiddleware adds gin middleware in front of every route
+func WithMiddleware(mw ...gin.HandlerFunc) Option {
+	return func(g *Gateway) {
+		g.middleware = append(g.middleware, mw...)
+	}
+}
+
+// WithAssets serves the web client from fsys instead of the embedded
+// copy, fsys must contain index.html and a static directory, and
+// admin.html for the admin dashboard. Use
+// os.DirFS to pick up edits without rebuilding.
+func WithAssets(fsys fs.FS) Option {
+	return func(g *Gateway) {
+		g.assets = fsys
+	}
+}
+
+// WithJoinWait sets how long a join waits for the chat server to become
+// reachable before failing
+func WithJoinWait(d time.Duration) Option {
+	return func(g *Gateway) {
+		g.joinWait = d
+	}
+}
+
+// WithReconnectRetries sets how many consecutive attempts a client's
+// upstream stream gets to recover after the chat server drops it, 0
+// retries until the WebSocket closes
+func WithReconnectRetries(n int) Option {
+	return func(g *Gateway) {
+		g.retries = n
+	}
+}
+
+// WithUploadDir stores uploaded attachments in dir, the default is a
+// directory under os.TempDir
+func WithUploadDir(dir string) Option {
+	return func(g *Gateway) {
+		g.uploadDir = dir
+	}
+}
+
+// WithConfig sets the initial runtime config
+func WithConfig(cfg *Config) Option {
+	return func(g *Gateway) {
+		g.initConfig = cfg
+	}
+}
+
+// WithConfigFile names the JSON file re-read by Reload
+func WithConfigFile(path string) Option {
+	return func(g *Gateway) {
+		g.configFile = path
+	}
+}
+
+// WithAdminToken enables the /api/admin endpoints, callers must send
+// it as a bearer token
+func WithAdminToken(token string) Option {
+	return func(g *Gateway) {
+		g.adminToken = token
+	}
+}
+
+// New creates a Gateway, starts its hub and begins probing the chat server
+func New(opts ...Option) *Gateway {
+	g := &Gateway{
+		hub:         newWSHub(),
+		upstream:    DefaultUpstream,
+		assets:      web.Assets,
+		joinWait:    DefaultJoinWait,
+		authTimeout: DefaultAuthTimeout,
+		retries:     DefaultReconnectRetries,
+		uploadDir:   filepath.Join(os.TempDir(), "realtimechat-uploads"),
+		log:         newLogger(),
+		readiness:   newReadiness(),
+		dialOpts:    []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		keepalive:   DefaultKeepalive,
+		heartbeat:   DefaultHeartbeat,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true // 允许跨域
+			},
+		},
+		thumbnailSizes: imaging.DefaultThumbnailSizes,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	g.hub.log = g.log
+	if err := g.heartbeat.validate(); err != nil {
+		g.log.Errorf("Ignoring invalid heartbeat: %v", err)
+		g.heartbeat = DefaultHeartbeat
+	}
+	g.config.Store(newConfigSnapshot(Config{}))
+	if g.initConfig != nil {
+		if err := g.SetConfig(g.initConfig); err != nil {
+			g.log.Errorf("Ignoring invalid gateway config: %v", err)
+		}
+	}
+	g.upgrader.CheckOrigin = g.checkOrigin(g.upgrader.CheckOrigin)
+	if store, err := newAttachmentStore(g.uploadDir); err == nil {
+		g.attachments = store
+	} else {
+		g.log.Errorf("Uploads disabled: %v", err)
+	}
+	if static, err := fs.Sub(g.assets, "static"); err == nil {
+		g.static = static
+	} else {
+		g.static = g.assets
+	}
+	g.router = g.setupRouter()
+
+	go g.hub.run()
+	g.startTransport()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.stopWatcher = cancel
+	g.watcherDone = make(chan struct{})
+	go func() {
+		defer close(g.watcherDone)
+		g.watchUpstream(ctx)
+	}()
+	return g
+}
+
+// Handler returns the gateway HTTP handler, it can be mounted on any mux
+func (g *Gateway) Handler() http.Handler {
+	return g.router
+}
+
+// HubStats returns a snapshot of the WebSocket hub, a growing Dropped
+// count means broadcasts are produced faster than the hub delivers them
+func (g *Gateway) HubStats() HubStats {
+	return g.hub.stats()
+}
+
+// HubConnections returns the heartbeat round trip, ping interval and
+// missed pongs of every WebSocket client, the slowest first
+func (g *Gateway) HubConnections() []ConnectionStats {
+	return g.hub.connections(g.heartbeat)
+}
+
+// Close stops the hub, disconnects every WebSocket client and closes
+// the upstream connection
+func (g *Gateway) Close() {
+	g.stopWatcher()
+	<-g.watcherDone
+	g.maint.mu.Lock()
+	if g.maint.timer != nil {
+		g.maint.timer.Stop()
+	}
+	g.maint.gen++
+	g.maint.mu.Unlock()
+	g.hub.Close()
+	if g.poller != nil {
+		g.poller.close()
+	}
+	if conn, _ := g.upstreamConn(); conn != nil {
+		conn.Close()
+	}
+	g.shards.close()
+}
+
+// upstreamConn returns the shared chat server connection, creating it on first use
+func (g *Gateway) upstreamConn() (*grpc.ClientConn, error) {
+	g.connOnce.Do(func() {
+		g.conn, g.connErr = grpc.NewClient(g.upstream, g.upstreamDialOptions()...)
+	})
+	return g.conn, g.connErr
+}
+
+// upstreamDialOptions are the dial options with the keepalive
+func (g *Gateway) upstreamDialOptions() []grpc.DialOption {
+	opts := g.dialOpts
+	if g.keepalive.Time > 0 {
+		opts = append([]grpc.DialOption{grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                g.keepalive.Time,
+			Timeout:             g.keepalive.Timeout,
+			PermitWithoutStream: g.keepalive.PermitWithoutStream,
+		})}, opts...)
+	}
+	return opts
+}
+
+// transform runs the transformers on msg and reports whether to keep it
+func (g *Gateway) transform(dir Direction, msg *WSMessage) bool {
+	for _, t := range g.transformers {
+		if !t(dir, msg) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// GiphyProvider searches GIPHY
+type GiphyProvider struct {
+	apiKey  string
+	baseURL string
+	rating  string
+	client  *http.Client
+}
+
+// NewGiphyProvider creates a GIPHY provider with the given API key
+func NewGiphyProvider(apiKey string) *GiphyProvider {
+	return &GiphyProvider{
+		apiKey:  apiKey,
+		baseURL: "https://api.giphy.com/v1/gifs",
+		rating:  "pg-13",
+		client:  &http.Client{Timeout: mediaTimeout},
+	}
+}
+
+type giphyRendition struct {
+	URL    string `json:"url"`
+	Width  string `json:"width"`
+	Height string `json:"height"`
+}
+
+type giphyGIF struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Images struct {
+		Original    giphyRendition `json:"original"`
+		FixedHeight giphyRendition `json:"fixed_height_small"`
+	} `json:"images"`
+}
+
+func (g giphyGIF) media() Media {
+	width, _ := strconv.Atoi(g.Images.Original.Width)
+	height, _ := strconv.Atoi(g.Images.Original.Height)
+	return Media{
+		ID:         g.ID,
+		Title:      g.Title,
+		URL:        g.Images.Original.URL,
+		PreviewURL: g.Images.FixedHeight.URL,
+		MimeType:   "image/gif",
+		Width:      width,
+		Height:     height,
+	}
+}
+
+// Name implements MediaProvider
+func (p *GiphyProvider) Name() string { return "giphy" }
+
+// Search implements MediaProvider
+func (p *GiphyProvider) Search(ctx context.Context, query string, limit int) ([]Media, error) {
+	q := url.Values{
+		"api_key": {p.apiKey},
+		"q":       {query},
+		"limit":   {strconv.Itoa(limit)},
+		"rating":  {p.rating},
+	}
+	var resp struct {
+		Data []giphyGIF `json:"data"`
+	}
+	if err := getJSON(ctx, p.client, p.baseURL+"/search?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	out := make([]Media, 0, len(resp.Data))
+	for _, g := range resp.Data {
+		out = append(out, g.media())
+	}
+	return out, nil
+}
+
+// Get implements MediaProvider
+func (p *GiphyProvider) Get(ctx context.Context, id string) (*Media, error) {
+	q := url.Values{"api_key": {p.apiKey}}
+	var resp struct {
+		Data giphyGIF `json:"data"`
+	}
+	if err := getJSON(ctx, p.client, p.baseURL+"/"+url.PathEscape(id)+"?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.ID == "" {
+		return nil, ErrMediaNotFound
+	}
+	m := resp.Data.media()
+	return &m, nil
+}
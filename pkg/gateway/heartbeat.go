@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Heartbeat sets how the gateway pings browsers to find WebSockets that
+// died without a close frame
+type Heartbeat struct {
+	PingInterval time.Duration // time between pings, must be below PongWait
+	PongWait     time.Duration // close the socket when nothing arrives for this long
+	WriteWait    time.Duration // deadline of every write, a stuck browser is dropped
+	LateAfter    time.Duration // report the connection as poor when a pong takes longer, 0 never does
+
+	// MinPingInterval makes pinging adaptive: new connections are pinged
+	// this often, steady round trips stretch the interval up to
+	// PingInterval and late, jittery or missed pongs shorten it again. 0
+	// pings every PingInterval.
+	MinPingInterval time.Duration
+}
+
+// DefaultHeartbeat pings stable connections every 54s, inside the 60s
+// browsers and proxies are given to answer, and flaky ones every 15s
+var DefaultHeartbeat = Heartbeat{
+	PingInterval:    54 * time.Second,
+	PongWait:        60 * time.Second,
+	WriteWait:       10 * time.Second,
+	LateAfter:       5 * time.Second,
+	MinPingInterval: 15 * time.Second,
+}
+
+func (h Heartbeat) validate() error {
+	switch {
+	case h.PingInterval <= 0 || h.PongWait <= 0 || h.WriteWait <= 0:
+		return errors.New("heartbeat durations must be positive")
+	case h.PingInterval >= h.PongWait:
+		return errors.New("heartbeat ping interval must be below the pong wait")
+	case h.MinPingInterval < 0 || h.MinPingInterval > h.PingInterval:
+		return errors.New("heartbeat minimum ping interval must be between 0 and the ping interval")
+	}
+	return nil
+}
+
+// tick is how often the poller checks whether sockets are due a ping
+func (h Heartbeat) tick() time.Duration {
+	if h.MinPingInterval > 0 {
+		return max(h.MinPingInterval/4, 100*time.Millisecond)
+	}
+	return h.PingInterval
+}
+
+// pacer measures the round trips of a connection's pings and picks the
+// interval to the next one, like TCP's retransmission timer it keeps a
+// smoothed round trip and its variation
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	srtt     time.Duration // 0 until the first pong
+	rttvar   time.Duration
+	missed   uint64    // pings that were not answered before the next one
+	last     time.Time // of the last ping
+}
+
+// next returns the interval to the next ping under hb
+func (p *pacer) next(hb Heartbeat) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.intervalLocked(hb)
+}
+
+func (p *pacer) intervalLocked(hb Heartbeat) time.Duration {
+	if hb.MinPingInterval <= 0 {
+		return hb.PingInterval
+	}
+	if p.interval == 0 {
+		p.interval = hb.MinPingInterval
+	}
+	return p.interval
+}
+
+// due reports whether a ping is due at now, for the poller checking
+// every hb.tick. Without adaptive pinging every tick is due.
+func (p *pacer) due(hb Heartbeat, now time.Time) bool {
+	if hb.MinPingInterval <= 0 {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last.IsZero() {
+		p.last = now // first seen, counts from here
+		return false
+	}
+	return now.Sub(p.last) >= p.intervalLocked(hb)
+}
+
+// pinged records a ping sent at now, unanswered tells whether the one
+// before it is still waiting for its pong
+func (p *pacer) pinged(hb Heartbeat, now time.Time, unanswered bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = now
+	if unanswered {
+		p.missed++
+		p.interval = hb.MinPingInterval
+	}
+}
+
+// ponged records the round trip of a ping, the interval grows by half
+// while pongs arrive on time and steadily and halves otherwise
+func (p *pacer) ponged(hb Heartbeat, rtt time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	steady := true
+	if p.srtt == 0 {
+		p.srtt, p.rttvar = rtt, rtt/2
+	} else {
+		delta := (p.srtt - rtt).Abs()
+		steady = rtt <= p.srtt+4*p.rttvar
+		p.rttvar = (3*p.rttvar + delta) / 4
+		p.srtt = (7*p.srtt + rtt) / 8
+	}
+	if hb.LateAfter > 0 && rtt > hb.LateAfter {
+		steady = false
+	}
+	if hb.MinPingInterval <= 0 {
+		return
+	}
+	if steady {
+		p.interval = min(p.interval*3/2, hb.PingInterval)
+	} else {
+		p.interval = max(p.interval/2, hb.MinPingInterval)
+	}
+}
+
+// stats returns the measurements of the connection, RTT is 0 before the
+// first pong
+func (p *pacer) stats() (rtt, rttvar, interval time.Duration, missed uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.srtt, p.rttvar, p.interval, p.missed
+}
+
+// WithHeartbeat replaces DefaultHeartbeat, New keeps the default when h
+// is invalid
+func WithHeartbeat(h Heartbeat) Option {
+	return func(g *Gateway) {
+		g.heartbeat = h
+	}
+}
+
+// Connection qualities of "connection_quality" frames
+const (
+	qualityGood = "good"
+	qualityPoor = "poor"
+)
+
+// pinged records a ping just written and reports the connection as poor
+// unless its pong arrives within LateAfter, or at once when the previous
+// ping is still unanswered
+func (c *WSClient) pinged() {
+	now := time.Now()
+	sent := now.UnixNano()
+	missed := c.pingSent.Swap(sent) != 0
+	c.pace.pinged(c.gw.heartbeat, now, missed)
+	late := c.gw.heartbeat.LateAfter
+	if late <= 0 {
+		return
+	}
+	if missed {
+		c.reportPoor()
+		return
+	}
+	time.AfterFunc(late, func() {
+		if c.pingSent.Load() == sent {
+			c.reportPoor()
+		}
+	})
+}
+
+func (c *WSClient) reportPoor() {
+	if c.poor.CompareAndSwap(false, true) {
+		c.gw.log.Debugf("Pong of %s is late", c.username)
+		c.queueFrame(ConnectionQualityFrame{Type: "connection_quality", Quality: qualityPoor}, prioPresence)
+	}
+}
+
+// ponged measures the round trip of the outstanding ping and reports the
+// connection as good again once a late pong arrived
+func (c *WSClient) ponged() {
+	sent := c.pingSent.Swap(0)
+	if sent == 0 {
+		return // unsolicited
+	}
+	rtt := time.Since(time.Unix(0, sent))
+	c.pace.ponged(c.gw.heartbeat, rtt)
+	if c.poor.CompareAndSwap(true, false) {
+		c.queueFrame(ConnectionQualityFrame{Type: "connection_quality", Quality: qualityGood, RTT: rtt.Milliseconds()}, prioPresence)
+	}
+}
@@ -0,0 +1,208 @@
+// Package gateway bridges browser WebSocket clients to the gRPC chat server.
+package gateway
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// broadcastBuffer is how many broadcasts may wait for the hub's run loop
+const broadcastBuffer = 256
+
+var (
+	// errHubBusy is returned when the broadcast queue is full
+	errHubBusy = errors.New("gateway: broadcast queue full")
+	// errHubClosed is returned once the hub has stopped
+	errHubClosed = errors.New("gateway: hub closed")
+)
+
+// HubStats reports the WebSocket clients and the broadcast queue of the hub
+type HubStats struct {
+	Clients     int      `json:"clients"`
+	Queued      int      `json:"queued"` // broadcasts waiting for the run loop
+	Capacity    int      `json:"capacity"`
+	Dropped     uint64   `json:"dropped"`     // broadcasts refused because the queue was full
+	SlowClients uint64   `json:"slowClients"` // clients disconnected for not keeping up
+	Shed        uint64   `json:"shed"`        // frames of lower classes dropped from full client queues
+	RTT         RTTStats `json:"rtt"`         // heartbeat round trips of the clients
+}
+
+// RTTStats summarizes the smoothed heartbeat round trips of the clients
+// that answered a ping, in milliseconds
+type RTTStats struct {
+	Measured    int    `json:"measured"` // clients with a round trip
+	MedianMs    int64  `json:"medianMs"`
+	P90Ms       int64  `json:"p90Ms"`
+	MaxMs       int64  `json:"maxMs"`
+	MissedPongs uint64 `json:"missedPongs"` // pings of current clients left unanswered
+}
+
+// ConnectionStats is the heartbeat state of one WebSocket client
+type ConnectionStats struct {
+	User           string `json:"user,omitempty"` // empty before the join
+	RemoteIP       string `json:"remoteIp"`
+	RTTMs          int64  `json:"rttMs"`    // smoothed round trip, 0 before the first pong
+	RTTVarMs       int64  `json:"rttVarMs"` // its variation
+	PingIntervalMs int64  `json:"pingIntervalMs"`
+	MissedPongs    uint64 `json:"missedPongs"`
+	Poor           bool   `json:"poor,omitempty"` // a pong is late
+}
+
+// WSHub WebSocket hub to manage clients
+type WSHub struct {
+	clients    map[*WSClient]bool
+	broadcast  chan []byte
+	register   chan *WSClient // register chan for new clients
+	unregister chan *WSClient
+	done       chan struct{} // closed when the hub stops
+	exited     chan struct{} // closed when run returns
+	closeOnce  sync.Once
+	log        *logger
+	mu         sync.RWMutex
+
+	dropped     atomic.Uint64
+	slowClients atomic.Uint64
+	shed        atomic.Uint64
+}
+
+// newWSHub creates a new WSHub
+func newWSHub() *WSHub {
+	return &WSHub{
+		clients:    make(map[*WSClient]bool),
+		broadcast:  make(chan []byte, broadcastBuffer),
+		register:   make(chan *WSClient),
+		unregister: make(chan *WSClient),
+		done:       make(chan struct{}),
+		exited:     make(chan struct{}),
+	}
+}
+
+// run processes hub events until Close is called
+func (h *WSHub) run() {
+	defer close(h.exited)
+	for {
+		select {
+		case client := <-h.register: // new client registration
+			h.mu.Lock()
+			h.clients[client] = true
+			h.mu.Unlock()
+			h.log.Debugf("WebSocket client registered")
+
+		case client := <-h.unregister: // client unregistration
+			h.mu.Lock()
+			if _, ok := h.clients[client]; ok {
+				delete(h.clients, client)
+				client.closeWith(websocket.CloseNormalClosure, "") // the socket is gone already
+			}
+			username := client.username
+			h.mu.Unlock()
+			h.log.Infof("WebSocket client unregistered: %s", username)
+
+		case message := <-h.broadcast: // broadcast message to all clients
+			h.mu.Lock()
+			for client := range h.clients {
+				if !client.queue(message, prioSystem) {
+					client.closeWith(websocket.CloseTryAgainLater, reasonSlow)
+					delete(h.clients, client) // remove client
+					h.slowClients.Add(1)
+				}
+			}
+			h.mu.Unlock()
+
+		case <-h.done: // hub stopped, drop every client
+			h.mu.Lock()
+			for client := range h.clients {
+				delete(h.clients, client)
+				client.closeWith(websocket.CloseGoingAway, reasonShutdown)
+			}
+			h.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Close stops the hub, disconnects all clients and waits for run to return
+func (h *WSHub) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+	<-h.exited
+}
+
+// broadcastWait queues message for every client, waiting for room in the
+// queue until ctx is done
+func (h *WSHub) broadcastWait(ctx context.Context, message []byte) error {
+	select {
+	case h.broadcast <- message:
+		return nil
+	case <-h.done:
+		return errHubClosed
+	case <-ctx.Done():
+		h.dropped.Add(1)
+		return errHubBusy
+	}
+}
+
+// stats returns a snapshot of the hub counters
+func (h *WSHub) stats() HubStats {
+	var rtt RTTStats
+	var rtts []int64
+	h.mu.RLock()
+	clients := len(h.clients)
+	for client := range h.clients {
+		srtt, _, _, missed := client.pace.stats()
+		rtt.MissedPongs += missed
+		if srtt > 0 {
+			rtts = append(rtts, srtt.Milliseconds())
+		}
+	}
+	h.mu.RUnlock()
+	if rtt.Measured = len(rtts); rtt.Measured > 0 {
+		slices.Sort(rtts)
+		rtt.MedianMs = rtts[len(rtts)/2]
+		rtt.P90Ms = rtts[len(rtts)*9/10]
+		rtt.MaxMs = rtts[len(rtts)-1]
+	}
+	return HubStats{
+		Clients:     clients,
+		Queued:      len(h.broadcast),
+		Capacity:    cap(h.broadcast),
+		Dropped:     h.dropped.Load(),
+		SlowClients: h.slowClients.Load(),
+		Shed:        h.shed.Load(),
+		RTT:         rtt,
+	}
+}
+
+// connections returns the heartbeat state of every client, the slowest
+// round trips first
+func (h *WSHub) connections(hb Heartbeat) []ConnectionStats {
+	h.mu.RLock()
+	conns := make([]ConnectionStats, 0, len(h.clients))
+	for client := range h.clients {
+		srtt, rttvar, interval, missed := client.pace.stats()
+		if interval == 0 {
+			interval = client.pace.next(hb)
+		}
+		conns = append(conns, ConnectionStats{
+			User:           client.username,
+			RemoteIP:       client.remoteIP,
+			RTTMs:          srtt.Milliseconds(),
+			RTTVarMs:       rttvar.Milliseconds(),
+			PingIntervalMs: interval.Milliseconds(),
+			MissedPongs:    missed,
+			Poor:           client.poor.Load(),
+		})
+	}
+	h.mu.RUnlock()
+	slices.SortFunc(conns, func(a, b ConnectionStats) int {
+		return cmp.Compare(b.RTTMs, a.RTTMs)
+	})
+	return conns
+}
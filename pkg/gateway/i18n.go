@@ -0,0 +1,35 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"realTimeChat/pkg/i18n"
+)
+
+// i18n routers serve the system message catalog, clients render message
+// keys with it in the user's locale
+func (g *Gateway) setupI18nRoutes(r gin.IRouter) {
+	r.GET("/api/i18n/:locale", func(c *gin.Context) {
+		locale := i18n.Match(c.Param("locale"))
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.JSON(http.StatusOK, gin.H{
+			"locale":   locale,
+			"locales":  i18n.Locales(),
+			"messages": i18n.Catalog(locale),
+		})
+	})
+}
+
+// systemFrame builds a "system" frame, text is the English rendering of
+// key for clients without the catalog
+func systemFrame(key string, kv ...string) []byte {
+	args := i18n.Args(kv...)
+	return encodeFrame(SystemFrame{
+		Type: "system",
+		Text: i18n.Render(i18n.DefaultLocale, key, args),
+		Key:  key,
+		Args: args,
+	})
+}
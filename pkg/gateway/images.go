@@ -0,0 +1,142 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/internal/attachmeta"
+	"realTimeChat/pkg/imaging"
+	pb "realTimeChat/proto/chat"
+)
+
+// WithThumbnailSizes sets the bounding boxes, in pixels, thumbnails of
+// image uploads are rendered at, none disables thumbnails
+func WithThumbnailSizes(sizes ...int) Option {
+	return func(g *Gateway) {
+		g.thumbnailSizes = sizes
+	}
+}
+
+// thumbPath is where the thumbnail of attachment id at size is kept
+func (s *attachmentStore) thumbPath(id string, size int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%d", id, size))
+}
+
+// processImage strips the metadata of an image upload and writes its
+// thumbnails, m must already have its ID. It returns the data to store,
+// content that is not a supported image is returned unchanged.
+func (s *attachmentStore) processImage(m *attachmeta.Meta, data []byte, sizes []int) ([]byte, error) {
+	res, err := imaging.Process(data, sizes)
+	if errors.Is(err, imaging.ErrNotImage) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range res.Thumbnails {
+		if err := os.WriteFile(s.thumbPath(m.ID, t.Size), t.Data, 0o640); err != nil {
+			s.removeThumbnails(*m)
+			return nil, err
+		}
+		m.Thumbnails = append(m.Thumbnails, attachmeta.Thumb{Size: t.Size, Width: int32(t.Width), Height: int32(t.Height), MimeType: t.MimeType})
+	}
+	m.MimeType, m.Width, m.Height = res.MimeType, int32(res.Width), int32(res.Height)
+	return res.Data, nil
+}
+
+// removeThumbnails deletes the thumbnails of m
+func (s *attachmentStore) removeThumbnails(m attachmeta.Meta) {
+	for _, t := range m.Thumbnails {
+		os.Remove(s.thumbPath(m.ID, t.Size))
+	}
+}
+
+// handleThumbnailDownload serves a thumbnail of an image attachment,
+// those of files uploaded to the chat server are fetched from it or
+// redirected to its object storage
+func (g *Gateway) handleThumbnailDownload(c *gin.Context) {
+	id := c.Param("id")
+	size, err := strconv.Atoi(c.Param("size"))
+	if g.attachments == nil || !attachmentID.MatchString(id) || err != nil || size <= 0 {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if g.attachments.isQuarantined(id) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "attachment is quarantined"})
+		return
+	}
+	var data []byte
+	mimeType := ""
+	if m, ok := g.attachments.get(id); ok {
+		if t, ok := m.Thumbnail(size); ok {
+			data, err = os.ReadFile(g.attachments.thumbPath(id, size))
+			mimeType = t.MimeType
+		}
+	}
+	if data == nil && g.redirectPresigned(c, id, size) {
+		return
+	}
+	if data == nil {
+		data, mimeType, err = g.fetchThumbnail(c, id, size)
+	}
+	if err != nil || data == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	h := c.Writer.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Disposition", "inline")
+	h.Set("Content-Type", mimeType)
+	h.Set("Cache-Control", "private, max-age=86400, immutable")
+	h.Set("ETag", fmt.Sprintf(`"%s_%d"`, id, size))
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, bytes.NewReader(data))
+}
+
+// fetchThumbnail downloads a thumbnail from the chat server's
+// AttachmentService, nil data means there is none
+func (g *Gateway) fetchThumbnail(c *gin.Context, id string, size int) ([]byte, string, error) {
+	conn, err := g.upstreamConn()
+	if err != nil {
+		return nil, "", err
+	}
+	stream, err := pb.NewAttachmentServiceClient(conn).DownloadAttachment(c.Request.Context(), &pb.AttachmentRequest{Id: id, Thumbnail: int32(size)})
+	if err != nil {
+		return nil, "", err
+	}
+	var data []byte
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			switch status.Code(err) {
+			case codes.NotFound:
+			case codes.FailedPrecondition:
+				g.attachments.markQuarantined(id)
+			default:
+				g.log.Errorf("Failed to fetch thumbnail %s/%d: %v", id, size, err)
+			}
+			return nil, "", nil
+		}
+		if len(data)+len(chunk.Data) > MaxFileSize {
+			return nil, "", nil
+		}
+		data = append(data, chunk.Data...)
+	}
+	if data == nil {
+		return nil, "", nil
+	}
+	return data, http.DetectContentType(data), nil
+}
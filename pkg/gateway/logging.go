@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// log levels, ordered by severity
+const (
+	levelDebug int32 = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = map[string]int32{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+// parseLevel maps a level name to its value, empty means info
+func parseLevel(name string) (int32, error) {
+	if name == "" {
+		return levelInfo, nil
+	}
+	lvl, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return lvl, nil
+}
+
+// logger is a leveled front for the standard logger whose threshold can
+// be changed while running
+type logger struct {
+	level atomic.Int32
+}
+
+func newLogger() *logger {
+	l := &logger{}
+	l.level.Store(levelInfo)
+	return l
+}
+
+func (l *logger) logf(level int32, format string, args ...interface{}) {
+	if level >= l.level.Load() {
+		log.Printf(format, args...)
+	}
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) { l.logf(levelDebug, format, args...) }
+func (l *logger) Infof(format string, args ...interface{})  { l.logf(levelInfo, format, args...) }
+func (l *logger) Warnf(format string, args ...interface{})  { l.logf(levelWarn, format, args...) }
+func (l *logger) Errorf(format string, args ...interface{}) { l.logf(levelError, format, args...) }
@@ -0,0 +1,115 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultMaintenanceMessage is sent to clients when no message is given
+const DefaultMaintenanceMessage = "The chat is under maintenance, new joins are paused"
+
+// maintenanceWait bounds how long the maintenance notice waits for room
+// in a backed up broadcast queue
+const maintenanceWait = 5 * time.Second
+
+// Maintenance describes the gateway maintenance mode. While enabled new
+// joins are refused, and if DrainAt is set every WebSocket is closed at
+// that time.
+type Maintenance struct {
+	Enabled bool      `json:"enabled"`
+	Message string    `json:"message,omitempty"`
+	DrainAt time.Time `json:"drainAt,omitzero"` // zero means no forced drain
+}
+
+// maintenanceState holds the active mode and its pending drain
+type maintenanceState struct {
+	mu    sync.Mutex
+	cur   Maintenance
+	timer *time.Timer
+	gen   uint64 // bumped on every change so stale timers do nothing
+}
+
+// Maintenance returns the current maintenance mode
+func (g *Gateway) Maintenance() Maintenance {
+	g.maint.mu.Lock()
+	defer g.maint.mu.Unlock()
+	return g.maint.cur
+}
+
+// SetMaintenance switches maintenance mode, notifies connected clients
+// and schedules the forced drain. Disabling cancels a pending drain.
+func (g *Gateway) SetMaintenance(m Maintenance) error {
+	if !m.Enabled && !m.DrainAt.IsZero() {
+		return errors.New("drainAt requires maintenance to be enabled")
+	}
+	if m.Enabled && m.Message == "" {
+		m.Message = DefaultMaintenanceMessage
+	}
+
+	g.maint.mu.Lock()
+	was := g.maint.cur
+	g.maint.cur = m
+	g.maint.gen++
+	gen := g.maint.gen
+	if g.maint.timer != nil {
+		g.maint.timer.Stop()
+		g.maint.timer = nil
+	}
+	if m.Enabled && !m.DrainAt.IsZero() {
+		g.maint.timer = time.AfterFunc(time.Until(m.DrainAt), func() {
+			g.drain(gen)
+		})
+	}
+	g.maint.mu.Unlock()
+
+	switch {
+	case m.Enabled:
+		g.log.Infof("Maintenance mode enabled (drain at %v)", m.DrainAt)
+		g.broadcastMaintenance(m)
+	case was.Enabled:
+		g.log.Infof("Maintenance mode disabled")
+		g.broadcastMaintenance(m)
+	}
+	return nil
+}
+
+// drain closes every WebSocket so clients reconnect elsewhere, gen
+// guards against a drain that was cancelled while its timer fired
+func (g *Gateway) drain(gen uint64) {
+	g.maint.mu.Lock()
+	current := g.maint.gen == gen
+	g.maint.mu.Unlock()
+	if !current {
+		return
+	}
+
+	g.hub.mu.Lock()
+	defer g.hub.mu.Unlock()
+	g.log.Infof("Draining %d WebSocket clients for maintenance", len(g.hub.clients))
+	for client := range g.hub.clients {
+		client.closeWith(websocket.CloseServiceRestart, reasonMaintenance)
+	}
+}
+
+// maintenanceFrame encodes the maintenance notice sent to clients
+func maintenanceFrame(m Maintenance) []byte {
+	frame := MaintenanceFrame{Type: "maintenance", Enabled: m.Enabled, Text: m.Message}
+	if !m.DrainAt.IsZero() {
+		frame.DrainAt = m.DrainAt.Format(time.RFC3339)
+	}
+	return encodeFrame(frame)
+}
+
+// broadcastMaintenance tells every client about m, giving up after
+// maintenanceWait when the hub is backed up
+func (g *Gateway) broadcastMaintenance(m Maintenance) {
+	ctx, cancel := context.WithTimeout(context.Background(), maintenanceWait)
+	defer cancel()
+	if err := g.hub.broadcastWait(ctx, maintenanceFrame(m)); errors.Is(err, errHubBusy) {
+		g.log.Warnf("Maintenance notice not sent: %v", err)
+	}
+}
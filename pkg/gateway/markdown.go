@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Markdown subset rendered for the web client: fenced code blocks,
+// `code`, **bold**, *italic* or _italic_ and [text](url) links. Input is
+// HTML-escaped before any tag is added, so only these tags reach clients.
+var (
+	inlineCode = regexp.MustCompile("`([^`\n]+)`")
+	boldText   = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	italicStar = regexp.MustCompile(`\*([^*\n]+)\*`)
+	italicBar  = regexp.MustCompile(`(^|[^\w])_([^_\n]+)_([^\w]|$)`)
+	mdLink     = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+)
+
+// renderMarkdown returns the sanitized HTML for text and whether it
+// contains any formatting at all
+func renderMarkdown(text string) (string, bool) {
+	var b strings.Builder
+	// odd parts are inside ``` fences
+	for i, part := range strings.Split(text, "```") {
+		if i%2 == 1 && i < strings.Count(text, "```") {
+			b.WriteString("<pre><code>")
+			b.WriteString(html.EscapeString(strings.TrimPrefix(fenceBody(part), "\n")))
+			b.WriteString("</code></pre>")
+			continue
+		}
+		if i%2 == 1 {
+			part = "```" + part // unclosed fence, keep it literal
+		}
+		b.WriteString(renderInline(part))
+	}
+
+	out := b.String()
+	plain := strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+	return out, out != plain
+}
+
+// fenceBody drops the language name after an opening fence
+func fenceBody(block string) string {
+	first, rest, ok := strings.Cut(block, "\n")
+	if ok && !strings.ContainsAny(strings.TrimSpace(first), " \t") {
+		return rest
+	}
+	return block
+}
+
+// renderInline renders one line-oriented run of text outside code blocks
+func renderInline(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range inlineCode.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(renderSpans(text[last:m[0]]))
+		b.WriteString("<code>" + html.EscapeString(text[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	b.WriteString(renderSpans(text[last:]))
+	return strings.ReplaceAll(b.String(), "\n", "<br>")
+}
+
+// renderSpans renders links, keeping their URLs out of emphasis
+func renderSpans(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range mdLink.FindAllStringSubmatchIndex(text, -1) {
+		href, ok := safeHref(text[m[4]:m[5]])
+		if !ok {
+			continue // left as literal text
+		}
+		b.WriteString(emphasis(text[last:m[0]]))
+		b.WriteString(`<a href="` + html.EscapeString(href) + `" target="_blank" rel="noopener noreferrer nofollow">`)
+		b.WriteString(emphasis(text[m[2]:m[3]]) + "</a>")
+		last = m[1]
+	}
+	b.WriteString(emphasis(text[last:]))
+	return b.String()
+}
+
+// emphasis escapes text and applies bold and italic
+func emphasis(text string) string {
+	s := html.EscapeString(text)
+	s = boldText.ReplaceAllString(s, "<strong>$1</strong>")
+	s = italicStar.ReplaceAllString(s, "<em>$1</em>")
+	return italicBar.ReplaceAllString(s, "$1<em>$2</em>$3")
+}
+
+// safeHref only lets absolute http, https and mailto links through
+func safeHref(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "mailto":
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const linkAttrs = `" target="_blank" rel="noopener noreferrer nofollow">`
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // "" only checks that the output is well formed
+	}{
+		{"javascript link", "[x](javascript:alert(1))", "[x](javascript:alert(1))"},
+		{"mixed case javascript", "[x](JaVaScRiPt:alert(1))", "[x](JaVaScRiPt:alert(1))"},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", "[x](data:text/html;base64,PHNjcmlwdD4=)"},
+		{"upper case data", "[x](DATA:text/html,hi)", "[x](DATA:text/html,hi)"},
+		{"scheme relative", "[x](//evil.com)", "[x](//evil.com)"},
+		{"relative", "[x](/settings)", "[x](/settings)"},
+		{"upper case https", "[x](HTTPS://example.com/a?b=1&c=2)", `<a href="https://example.com/a?b=1&amp;c=2` + linkAttrs + "x</a>"},
+		{"mailto", "[x](mailto:a@b.c)", `<a href="mailto:a@b.c` + linkAttrs + "x</a>"},
+		{"quote in url", `[x](https://e.com/"onmouseover="alert(1))`, `<a href="https://e.com/%22onmouseover=%22alert%281` + linkAttrs + "x</a>)"},
+		{"quote in link text", `[a" onclick="x](https://e.com)`, `<a href="https://e.com` + linkAttrs + "a&#34; onclick=&#34;x</a>"},
+		{"emphasis in link", "[**x**](https://e.com)", `<a href="https://e.com` + linkAttrs + "<strong>x</strong></a>"},
+
+		{"bold in italic", "*it **bold** it*", "<em>it <strong>bold</strong> it</em>"},
+		{"italic in bold", "**bold *it* bold**", ""},
+		{"link in bold", "**[x](https://e.com)**", ""},
+		{"unclosed bold", "**unclosed", "**unclosed"},
+		{"unclosed italic", "*unclosed", "*unclosed"},
+		{"nested underscores", "_a _b_ c_", "_a <em>b</em> c_"},
+		{"snake case", "snake_case_name", "snake_case_name"},
+
+		{"script tag", "<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"attribute injection", "<img src=x onerror=alert(1)>", "&lt;img src=x onerror=alert(1)&gt;"},
+		{"script in bold", "**<script>x</script>**", "<strong>&lt;script&gt;x&lt;/script&gt;</strong>"},
+
+		{"entities in code span", "`<b>&amp;</b>`", "<code>&lt;b&gt;&amp;amp;&lt;/b&gt;</code>"},
+		{"markup in code span", "`**x** [a](https://e.com)`", "<code>**x** [a](https://e.com)</code>"},
+		{"entities in code block", "```\n<script>&\n```", "<pre><code>&lt;script&gt;&amp;\n</code></pre>"},
+		{"unclosed code block", "```js\n<b>\n", "```js<br>&lt;b&gt;<br>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := renderMarkdown(tt.in)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if err := checkMarkup(got); err != "" {
+				t.Errorf("renderMarkdown(%q) = %q: %s", tt.in, got, err)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownPlain(t *testing.T) {
+	for _, in := range []string{"plain & <text>", "a\nb", "**", "[x](javascript:alert(1))"} {
+		if got, formatted := renderMarkdown(in); formatted {
+			t.Errorf("renderMarkdown(%q) = %q, reported as formatted", in, got)
+		}
+	}
+}
+
+var markupTag = regexp.MustCompile(`<(/?)([a-z]+)( [^<>]*)?>`)
+
+// checkMarkup returns what is wrong with rendered HTML: tags other than
+// the ones renderMarkdown adds, attributes outside links, unbalanced tags
+// or a stray angle bracket
+func checkMarkup(s string) string {
+	var open []string
+	for _, m := range markupTag.FindAllStringSubmatch(s, -1) {
+		closing, tag, attrs := m[1] == "/", m[2], m[3]
+		switch tag {
+		case "br":
+			if closing || attrs != "" {
+				return "malformed " + m[0]
+			}
+			continue
+		case "a":
+			if !closing && !strings.HasPrefix(attrs, ` href="`) {
+				return "link without href: " + m[0]
+			}
+		case "strong", "em", "code", "pre":
+			if attrs != "" {
+				return "attributes on " + m[0]
+			}
+		default:
+			return "unexpected tag " + m[0]
+		}
+		if !closing {
+			open = append(open, tag)
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != tag {
+			return "unbalanced " + m[0]
+		}
+		open = open[:len(open)-1]
+	}
+	if len(open) > 0 {
+		return "unclosed <" + open[len(open)-1] + ">"
+	}
+	if strings.ContainsAny(markupTag.ReplaceAllString(s, ""), "<>") {
+		return "stray angle bracket"
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// Media is an animated GIF or sticker offered by a MediaProvider
+type Media struct {
+	ID         string `json:"id"`
+	Title      string `json:"title,omitempty"`
+	URL        string `json:"url"`        // full size animation
+	PreviewURL string `json:"previewUrl"` // small rendition for pickers
+	MimeType   string `json:"mimeType"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+}
+
+// MediaProvider searches a GIF service. Implementations hold the API key,
+// browsers only ever talk to the gateway.
+type MediaProvider interface {
+	Name() string
+	Search(ctx context.Context, query string, limit int) ([]Media, error)
+	Get(ctx context.Context, id string) (*Media, error)
+}
+
+// ErrMediaNotFound is returned by MediaProvider.Get for unknown IDs
+var ErrMediaNotFound = errors.New("media not found")
+
+// limits for GIF searches
+const (
+	DefaultGIFResults = 20
+	MaxGIFResults     = 50
+	mediaTimeout      = 5 * time.Second
+)
+
+var mediaID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// WithMediaProvider enables GIF search and gif attachments through p
+func WithMediaProvider(p MediaProvider) Option {
+	return func(g *Gateway) {
+		g.media = p
+	}
+}
+
+// media routers
+func (g *Gateway) setupMediaRoutes(r gin.IRouter) {
+	r.GET("/api/gifs/search", g.handleGIFSearch)
+}
+
+// handleGIFSearch proxies ?q= to the provider, ?limit= caps the results
+func (g *Gateway) handleGIFSearch(c *gin.Context) {
+	if g.media == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "GIF search is not configured"})
+		return
+	}
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" || len(q) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must be 1 to 100 bytes"})
+		return
+	}
+	limit := DefaultGIFResults
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = min(n, MaxGIFResults)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), mediaTimeout)
+	defer cancel()
+	results, err := g.media.Search(ctx, q, limit)
+	if err != nil {
+		g.log.Errorf("GIF search via %s failed: %v", g.media.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "GIF search failed"})
+		return
+	}
+	if results == nil {
+		results = []Media{}
+	}
+	c.JSON(http.StatusOK, gin.H{"provider": g.media.Name(), "results": results})
+}
+
+// gifAttachment resolves a gif picked in the browser, its URLs come from
+// the provider and never from the client
+func (g *Gateway) gifAttachment(id string) (*pb.Attachment, bool) {
+	if g.media == nil || !mediaID.MatchString(id) {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mediaTimeout)
+	defer cancel()
+	m, err := g.media.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, ErrMediaNotFound) {
+			g.log.Errorf("GIF lookup via %s failed: %v", g.media.Name(), err)
+		}
+		return nil, false
+	}
+	if !httpsURL(m.URL) || (m.PreviewURL != "" && !httpsURL(m.PreviewURL)) {
+		return nil, false
+	}
+	return &pb.Attachment{
+		Id:         m.ID,
+		Kind:       "gif",
+		MimeType:   m.MimeType,
+		Url:        m.URL,
+		Width:      int32(m.Width),
+		Height:     int32(m.Height),
+		PreviewUrl: m.PreviewURL,
+	}, true
+}
+
+func httpsURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+// getJSON fetches endpoint and decodes the JSON body into v
+func getJSON(ctx context.Context, client *http.Client, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return redactKey(err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrMediaNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("provider returned %s", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(v)
+}
+
+// redactKey drops the request URL from transport errors, it carries the
+// API key as a query parameter
+func redactKey(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("%s: %w", uerr.Op, uerr.Err)
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package gateway
+
+import (
+	"time"
+
+	pb "realTimeChat/proto/chat"
+)
+
+var roomRoles = map[pb.RoomRole]string{
+	pb.RoomRole_ROLE_MEMBER:    "member",
+	pb.RoomRole_ROLE_MODERATOR: "moderator",
+	pb.RoomRole_ROLE_OWNER:     "owner",
+}
+
+// memberFrame converts a room member, without Type and Room
+func memberFrame(m *pb.RoomMember) MemberFrame {
+	f := MemberFrame{User: m.User, Role: roomRoles[m.Role], Online: m.Online}
+	if m.Online {
+		f.Status = presenceStatuses[m.Status]
+	} else if m.LastSeen > 0 {
+		f.LastSeen = time.UnixMilli(m.LastSeen).UTC().Format(time.RFC3339)
+	}
+	return f
+}
+
+// relayMember forwards a change of room membership to the WebSocket
+func (c *WSClient) relayMember(room string, m *pb.RoomMember) {
+	f := memberFrame(m)
+	f.Type, f.Room = "member", room
+	c.queueFrame(f, prioChat)
+}
@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// sendBuffer is how many frames may wait for a socket's write pump
+const sendBuffer = 256
+
+// priority is the delivery class of a frame. Frames are written in the
+// order they were queued, the class only decides what a full queue
+// gives up: the oldest frame of the lowest class below the new one.
+type priority int
+
+const (
+	prioPresence priority = iota // typing, presence and connection quality, dropped first
+	prioChat                     // room chat and its previews, edits and counts
+	prioDirect                   // PMs, mentions, acks and call signaling
+	prioSystem                   // system notices and announcements, never dropped
+)
+
+// outFrame is a queued frame
+type outFrame struct {
+	data []byte
+	buf  *frameBuffer // data's pooled buffer, nil for frames shared between sockets
+	prio priority
+}
+
+// release returns the frame's buffer to the pool once it is written or
+// dropped
+func (f outFrame) release() {
+	if f.buf != nil {
+		putFrameBuffer(f.buf)
+	}
+}
+
+// outbox is the outbound queue of a socket
+type outbox struct {
+	mu     sync.Mutex
+	frames []outFrame
+	closed bool
+	ready  chan struct{} // signalled when frames are added or the outbox closes
+	wake   func()        // called instead of signalling ready when set, see poller
+}
+
+func newOutbox() *outbox {
+	return &outbox{ready: make(chan struct{}, 1)}
+}
+
+// push queues frame. When the queue is full a frame of a lower class is
+// dropped to make room, shed is true then. ok is false when nothing
+// could be dropped or the outbox is closed.
+func (o *outbox) push(frame outFrame) (ok, shed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return false, false
+	}
+	if len(o.frames) >= sendBuffer {
+		i := o.victim(frame.prio)
+		if i < 0 {
+			return false, false
+		}
+		o.frames[i].release()
+		o.frames = slices.Delete(o.frames, i, i+1)
+		shed = true
+	}
+	o.frames = append(o.frames, frame)
+	o.signal()
+	return true, shed
+}
+
+// victim returns the index of the oldest frame of the lowest class
+// below p, -1 when every queued frame is of class p or above
+func (o *outbox) victim(p priority) int {
+	at, lowest := -1, p
+	for i, f := range o.frames {
+		if f.prio < lowest {
+			at, lowest = i, f.prio
+		}
+	}
+	return at
+}
+
+// take returns the queued frames and whether the outbox is closed. spare
+// is the slice of the previous take, reused for the next frames once
+// they are written.
+func (o *outbox) take(spare []outFrame) ([]outFrame, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	frames := o.frames
+	clear(spare)
+	o.frames = spare[:0]
+	return frames, o.closed
+}
+
+// close stops accepting frames, the ones queued are still taken
+func (o *outbox) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closed = true
+	o.signal()
+}
+
+// pending reports whether frames or the close are waiting to be taken
+func (o *outbox) pending() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.frames) > 0 || o.closed
+}
+
+func (o *outbox) signal() {
+	if o.wake != nil {
+		o.wake()
+		return
+	}
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+}
+
+// chatPriority classifies a chat frame relayed to user
+func chatPriority(msg *pb.ChatMessage, user string) priority {
+	switch {
+	case msg.User == "System" || msg.System != nil:
+		return prioSystem
+	case msg.RecipientUser != "" || msg.EphemeralTo != "" || msg.Notify:
+		return prioDirect
+	case user != "" && strings.Contains(msg.Text, "@"+user):
+		// a rough mention check, the server flags the ones that notify
+		return prioDirect
+	}
+	return prioChat
+}
@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledFrame bounds the buffers kept for reuse, a rare large frame
+// should not pin its memory
+const maxPooledFrame = 64 << 10
+
+// frameBuffer is a reusable buffer frames are encoded into
+type frameBuffer struct {
+	bytes.Buffer
+	enc *json.Encoder
+}
+
+var framePool = sync.Pool{New: func() any {
+	b := new(frameBuffer)
+	b.enc = json.NewEncoder(&b.Buffer)
+	return b
+}}
+
+// encodePooled encodes v into a buffer from the pool, it goes back with
+// putFrameBuffer once the frame is written
+func encodePooled(v any) *frameBuffer {
+	b := framePool.Get().(*frameBuffer)
+	_ = b.enc.Encode(v) // the frame types cannot fail to encode
+	return b
+}
+
+// frame returns the encoded frame without the newline Encode adds
+func (b *frameBuffer) frame() []byte {
+	return bytes.TrimSuffix(b.Bytes(), []byte{'\n'})
+}
+
+func putFrameBuffer(b *frameBuffer) {
+	if b.Cap() > maxPooledFrame {
+		return
+	}
+	b.Reset()
+	framePool.Put(b)
+}
+
+var wsMessagePool = sync.Pool{New: func() any { return new(WSMessage) }}
+
+// getWSMessage returns a zeroed WSMessage, transformers must not keep it
+// after they return
+func getWSMessage() *WSMessage {
+	return wsMessagePool.Get().(*WSMessage)
+}
+
+func putWSMessage(m *WSMessage) {
+	*m = WSMessage{}
+	wsMessagePool.Put(m)
+}
@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func poolFrame() *WSMessage {
+	return &WSMessage{
+		Type:      "chat",
+		ID:        "01J0000000000000000000000",
+		Room:      "general",
+		Seq:       42,
+		User:      "alice",
+		Text:      "the quick brown fox jumps over the lazy dog",
+		Timestamp: "2025-10-09T08:53:20Z",
+	}
+}
+
+func TestEncodePooled(t *testing.T) {
+	want, err := json.Marshal(poolFrame())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		b := encodePooled(poolFrame())
+		if got := string(b.frame()); got != string(want) {
+			t.Errorf("pooled frame = %s, want %s", got, want)
+		}
+		putFrameBuffer(b)
+	}
+
+	big := encodePooled(WSMessage{Text: strings.Repeat("x", maxPooledFrame)})
+	putFrameBuffer(big)
+	if b := encodePooled(poolFrame()); b == big {
+		t.Error("a buffer larger than maxPooledFrame went back to the pool")
+	}
+}
+
+func TestPutWSMessage(t *testing.T) {
+	m := getWSMessage()
+	*m = *poolFrame()
+	putWSMessage(m)
+	if !reflect.DeepEqual(*m, WSMessage{}) {
+		t.Errorf("recycled message still holds %+v", *m)
+	}
+}
+
+// BenchmarkEncodeFrame compares marshaling each frame into a new slice
+// with encoding it into a pooled buffer
+func BenchmarkEncodeFrame(b *testing.B) {
+	msg := poolFrame()
+	b.Run("marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := json.Marshal(msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			putFrameBuffer(encodePooled(msg))
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// preference routers proxy the chat server's PreferencesService, only
+// for the user themselves, see requireUser
+func (g *Gateway) setupPreferenceRoutes(r gin.IRouter) {
+	r = r.Group("", g.requireUser)
+	r.GET("/api/preferences/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewPreferencesServiceClient(conn).GetPreferences(c.Request.Context(), &pb.PreferencesRequest{User: c.Param("user")})
+		})
+	})
+	r.PUT("/api/preferences/:user", func(c *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var prefs pb.Preferences
+		if err := protojson.Unmarshal(body, &prefs); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		prefs.User = c.Param("user")
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewPreferencesServiceClient(conn).SetPreferences(c.Request.Context(), &prefs)
+		})
+	})
+	r.DELETE("/api/preferences/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewPreferencesServiceClient(conn).DeletePreferences(c.Request.Context(), &pb.PreferencesRequest{User: c.Param("user")})
+		})
+	})
+	r.POST("/api/preferences/:user/keywords", func(c *gin.Context) {
+		var req struct {
+			Room string `json:"room"`
+			Word string `json:"word"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Room == "" || req.Word == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with room and word"})
+			return
+		}
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewPreferencesServiceClient(conn).AddKeyword(c.Request.Context(), &pb.KeywordRequest{User: c.Param("user"), Room: req.Room, Word: req.Word})
+		})
+	})
+	r.DELETE("/api/preferences/:user/keywords/:room/:word", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewPreferencesServiceClient(conn).RemoveKeyword(c.Request.Context(), &pb.KeywordRequest{User: c.Param("user"), Room: c.Param("room"), Word: c.Param("word")})
+		})
+	})
+}
+
+// upstreamCall runs call against the chat server and writes the
+// result as JSON, mapping gRPC errors to HTTP statuses
+func (g *Gateway) upstreamCall(c *gin.Context, call func(*grpc.ClientConn) (proto.Message, error)) {
+	conn, err := g.upstreamConn()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := call(conn)
+	if err != nil {
+		code := http.StatusBadGateway
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			code = http.StatusBadRequest
+		case codes.NotFound:
+			code = http.StatusNotFound
+		case codes.PermissionDenied:
+			code = http.StatusForbidden
+		case codes.Unimplemented, codes.Unavailable:
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	writeProto(c, resp)
+}
+
+// writeProto writes m as JSON using the proto field names clients see
+// in the gRPC API
+func writeProto(c *gin.Context, m proto.Message) {
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// profile routers proxy the chat server's ProfileService, GET serves the
+// web client's hover cards. Only the user themselves may change their
+// pin, see requireUser.
+func (g *Gateway) setupProfileRoutes(r gin.IRouter) {
+	r.GET("/api/profiles/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewProfileServiceClient(conn).GetProfile(c.Request.Context(), &pb.ProfileRequest{User: c.Param("user")})
+		})
+	})
+	r = r.Group("", g.requireUser)
+	r.PUT("/api/profiles/:user/pin", func(c *gin.Context) {
+		var req struct {
+			MessageID string `json:"messageId"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.MessageID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with messageId"})
+			return
+		}
+		g.setProfilePin(c, req.MessageID)
+	})
+	r.DELETE("/api/profiles/:user/pin", func(c *gin.Context) {
+		g.setProfilePin(c, "")
+	})
+}
+
+// setProfilePin pins messageID to the profile in the path, "" unpins
+func (g *Gateway) setProfilePin(c *gin.Context, messageID string) {
+	g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+		return pb.NewProfileServiceClient(conn).SetProfilePin(c.Request.Context(), &pb.SetProfilePinRequest{User: c.Param("user"), MessageId: messageID})
+	})
+}
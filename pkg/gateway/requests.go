@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// message request routers proxy the chat server's MessageRequestService,
+// only for the recipient themselves, see requireUser
+func (g *Gateway) setupMessageRequestRoutes(r gin.IRouter) {
+	r = r.Group("", g.requireUser)
+	r.GET("/api/message-requests/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewMessageRequestServiceClient(conn).ListMessageRequests(c.Request.Context(), &pb.MessageRequestsRequest{User: c.Param("user")})
+		})
+	})
+	r.POST("/api/message-requests/:user/:sender/accept", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewMessageRequestServiceClient(conn).AcceptMessageRequest(c.Request.Context(), decision(c))
+		})
+	})
+	r.POST("/api/message-requests/:user/:sender/decline", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewMessageRequestServiceClient(conn).DeclineMessageRequest(c.Request.Context(), decision(c))
+		})
+	})
+}
+
+func decision(c *gin.Context) *pb.MessageRequestDecision {
+	return &pb.MessageRequestDecision{User: c.Param("user"), Sender: c.Param("sender")}
+}
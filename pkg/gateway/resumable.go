@@ -0,0 +1,249 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"realTimeChat/internal/attachmeta"
+)
+
+// Resumable uploads let clients on flaky connections send a file in as
+// many requests as it takes, in the manner of tus:
+//
+//	POST  /api/uploads/resumable      {"name", "size", "sha256"}, answers 201 with the upload ID
+//	HEAD  /api/uploads/resumable/:id  Upload-Offset says how much arrived
+//	PATCH /api/uploads/resumable/:id  appends the body at the Upload-Offset header
+//
+// A PATCH ending short of the size answers 204 with the new Upload-Offset,
+// one at the wrong offset 409 with the offset to continue from. The PATCH
+// that completes the file has it checked against its SHA-256 and answers
+// like POST /api/uploads/file. Unfinished uploads expire after a day.
+
+// resumableExpiry is how long an unfinished upload is kept after its
+// last PATCH
+const resumableExpiry = 24 * time.Hour
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// partMeta describes an unfinished resumable upload, stored next to it
+type partMeta struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	Created time.Time `json:"created"`
+}
+
+func (s *attachmentStore) partPath(id string) string {
+	return filepath.Join(s.dir, "resumable", id)
+}
+
+// partMeta reads the description of the unfinished upload id
+func (s *attachmentStore) partMeta(id string) (partMeta, bool) {
+	var m partMeta
+	data, err := os.ReadFile(s.partPath(id) + ".json")
+	return m, err == nil && json.Unmarshal(data, &m) == nil
+}
+
+// startPart creates an empty upload for m and returns its ID
+func (s *attachmentStore) startPart(m partMeta) (string, error) {
+	s.sweepParts()
+	id := attachmentIDs.New()
+	path := s.partPath(id)
+	if err := os.WriteFile(path, nil, 0o640); err != nil {
+		return "", err
+	}
+	data, _ := json.Marshal(m)
+	if err := os.WriteFile(path+".json", data, 0o640); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return id, nil
+}
+
+// removePart drops an unfinished upload
+func (s *attachmentStore) removePart(id string) {
+	os.Remove(s.partPath(id))
+	os.Remove(s.partPath(id) + ".json")
+}
+
+// sweepParts drops uploads without a PATCH for resumableExpiry
+func (s *attachmentStore) sweepParts() {
+	parts, _ := filepath.Glob(filepath.Join(s.dir, "resumable", "*.json"))
+	s.partMu.Lock()
+	defer s.partMu.Unlock()
+	for _, p := range parts {
+		path := strings.TrimSuffix(p, ".json")
+		info, err := os.Stat(path)
+		if err != nil || (!s.sending[filepath.Base(path)] && time.Since(info.ModTime()) > resumableExpiry) {
+			os.Remove(path)
+			os.Remove(p)
+		}
+	}
+}
+
+// claimPart reserves id for one PATCH, false when another is running
+func (s *attachmentStore) claimPart(id string) bool {
+	s.partMu.Lock()
+	defer s.partMu.Unlock()
+	if s.sending[id] {
+		return false
+	}
+	s.sending[id] = true
+	return true
+}
+
+func (s *attachmentStore) releasePart(id string) {
+	s.partMu.Lock()
+	defer s.partMu.Unlock()
+	delete(s.sending, id)
+}
+
+func (g *Gateway) setupResumableRoutes(r gin.IRouter) {
+	r.POST("/api/uploads/resumable", g.handleResumableStart)
+	r.Match([]string{http.MethodGet, http.MethodHead}, "/api/uploads/resumable/:id", g.handleResumableOffset)
+	r.PATCH("/api/uploads/resumable/:id", g.handleResumablePatch)
+}
+
+// handleResumableStart creates an upload for the file described in the
+// JSON body
+func (g *Gateway) handleResumableStart(c *gin.Context) {
+	if g.attachments == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "uploads are disabled"})
+		return
+	}
+	var req struct {
+		Name   string `json:"name"`
+		Size   int64  `json:"size"`
+		SHA256 string `json:"sha256"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected a JSON body with name, size and sha256"})
+		return
+	}
+	if req.Size <= 0 || req.Size > MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "files are limited to 25MB"})
+		return
+	}
+	req.SHA256 = strings.ToLower(req.SHA256)
+	if !sha256Hex.MatchString(req.SHA256) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sha256 must be 64 hex digits"})
+		return
+	}
+	id, err := g.attachments.startPart(partMeta{Name: attachmeta.CleanName(req.Name), Size: req.Size, SHA256: req.SHA256, Created: time.Now().UTC()})
+	if err != nil {
+		g.log.Errorf("Failed to start resumable upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store upload"})
+		return
+	}
+	c.Header("Location", "/api/uploads/resumable/"+id)
+	c.Header("Upload-Offset", "0")
+	c.JSON(http.StatusCreated, gin.H{"id": id, "offset": 0})
+}
+
+// handleResumableOffset reports how much of an upload arrived
+func (g *Gateway) handleResumableOffset(c *gin.Context) {
+	id := c.Param("id")
+	if g.attachments == nil || !attachmentID.MatchString(id) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	m, ok := g.attachments.partMeta(id)
+	info, err := os.Stat(g.attachments.partPath(id))
+	if !ok || err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(info.Size(), 10))
+	c.Header("Upload-Length", strconv.FormatInt(m.Size, 10))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"offset": info.Size(), "size": m.Size})
+}
+
+// handleResumablePatch appends the body to an upload and finishes it
+// once it is complete. What arrived before a dropped connection is kept.
+func (g *Gateway) handleResumablePatch(c *gin.Context) {
+	id := c.Param("id")
+	if g.attachments == nil || !attachmentID.MatchString(id) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing Upload-Offset header"})
+		return
+	}
+	if !g.attachments.claimPart(id) {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload is already in progress on another request"})
+		return
+	}
+	defer g.attachments.releasePart(id)
+	m, ok := g.attachments.partMeta(id)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	path := g.attachments.partPath(id)
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	have, err := f.Seek(0, io.SeekEnd)
+	if err != nil || have != offset {
+		f.Close()
+		c.Header("Upload-Offset", strconv.FormatInt(have, 10))
+		c.JSON(http.StatusConflict, gin.H{"error": "upload continues at " + strconv.FormatInt(have, 10), "offset": have})
+		return
+	}
+
+	// one byte more than fits tells a body that is too large apart
+	n, err := io.Copy(f, io.LimitReader(c.Request.Body, m.Size-offset+1))
+	if offset+n > m.Size {
+		f.Truncate(offset)
+		f.Close()
+		c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload is larger than its size"})
+		return
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	offset += n
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	if err != nil {
+		g.log.Debugf("Resumable upload %s interrupted at %d: %v", id, offset, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "upload interrupted", "offset": offset})
+		return
+	}
+	if offset < m.Size {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read upload"})
+		return
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != m.SHA256 {
+		g.attachments.removePart(id)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "checksum mismatch, upload discarded"})
+		return
+	}
+	g.storeFile(c, m.Name, data)
+	// a scanner or disk failure can be retried with an empty PATCH
+	if c.Writer.Status() < http.StatusInternalServerError {
+		g.attachments.removePart(id)
+	}
+}
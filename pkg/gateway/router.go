@@ -0,0 +1,320 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+func (g *Gateway) setupRouter() *gin.Engine {
+	r := gin.Default()
+	r.Use(g.middleware...)
+
+	// static file router
+	r.StaticFS("/static", http.FS(g.static))
+	r.Match([]string{http.MethodGet, http.MethodHead}, "/", g.serveAsset("index.html"))
+	r.Match([]string{http.MethodGet, http.MethodHead}, "/favicon.ico", g.serveAsset("static/images/favicon.ico"))
+
+	// API router
+	r.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "pong!",
+		})
+	})
+
+	// health routers, liveness and readiness for orchestrators
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.GET("/readyz", func(c *gin.Context) {
+		if g.Maintenance().Enabled {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "maintenance"})
+			return
+		}
+		ready, _, err := g.readiness.get()
+		if ready {
+			c.JSON(http.StatusOK, gin.H{"status": "ready"})
+			return
+		}
+		resp := gin.H{"status": "degraded", "upstream": g.upstream}
+		if err != nil {
+			resp["error"] = err.Error()
+		}
+		c.JSON(http.StatusServiceUnavailable, resp)
+	})
+
+	// admin router, only enabled with an admin token
+	if g.adminToken != "" {
+		admin := r.Group("/api/admin", g.requireAdmin)
+		admin.POST("/reload", func(c *gin.Context) {
+			if err := g.Reload(); err != nil {
+				g.log.Errorf("Config reload failed: %v", err)
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"status": "reloaded"})
+		})
+		admin.GET("/maintenance", func(c *gin.Context) {
+			c.JSON(http.StatusOK, g.Maintenance())
+		})
+		admin.PUT("/maintenance", g.handleSetMaintenance)
+		admin.GET("/hub", func(c *gin.Context) {
+			if c.Query("connections") == "" {
+				c.JSON(http.StatusOK, g.HubStats())
+				return
+			}
+			c.JSON(http.StatusOK, struct {
+				HubStats
+				Connections []ConnectionStats `json:"connections"`
+			}{g.HubStats(), g.HubConnections()})
+		})
+		admin.GET("/rooms/:room/export", g.handleExport)
+		r.GET("/api/stats", g.requireAdmin, g.handleStats)
+		r.GET("/api/stats/stream", g.requireAdmin, g.handleStatsStream)
+	}
+
+	// admin dashboard routers
+	g.setupDashboardRoutes(r)
+
+	// notification preference routers
+	g.setupPreferenceRoutes(r)
+
+	// profile routers, pinned messages and hover cards
+	g.setupProfileRoutes(r)
+	g.setupContactRoutes(r)
+	g.setupThreadRoutes(r)
+	g.setupDraftRoutes(r)
+	g.setupMessageRequestRoutes(r)
+	g.setupCatchupRoutes(r)
+
+	// unread counter routers
+	g.setupUnreadRoutes(r)
+	g.setupI18nRoutes(r)
+
+	// attachment routers
+	g.setupAttachmentRoutes(r)
+
+	// GIF search routers
+	g.setupMediaRoutes(r)
+
+	// WebSocket router
+	r.GET("/ws", func(c *gin.Context) {
+		g.handleWebSocket(c.Writer, c.Request)
+	})
+
+	// users count router
+	r.GET("/api/users", g.handleUsers)
+
+	// feature flags for a user and room
+	r.GET("/api/features", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewFeatureServiceClient(conn).GetFeatures(c.Request.Context(), &pb.FeaturesRequest{User: c.Query("user"), Room: c.Query("room")})
+		})
+	})
+
+	// settings of a public room, changed through the dashboard
+	r.GET("/api/rooms/:room/settings", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewRoomServiceClient(conn).GetRoomSettings(c.Request.Context(), &pb.RoomSettingsRequest{Room: c.Param("room")})
+		})
+	})
+
+	// the voice channel of a public room
+	r.GET("/api/rooms/:room/voice", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewRoomServiceClient(conn).GetVoiceMembers(c.Request.Context(), &pb.VoiceMembersRequest{Room: c.Param("room")})
+		})
+	})
+
+	// invite links into private rooms
+	r.GET("/join/:token", g.handleInvite)
+
+	return r
+}
+
+// handleUsers serves GET /api/users with the members of a room on the
+// chat server, not only the users of this gateway, the default room
+// without ?room=. users and count only cover the online members.
+func (g *Gateway) handleUsers(c *gin.Context) {
+	conn, err := g.upstreamConn()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chat server unavailable"})
+		return
+	}
+	list, err := pb.NewRoomServiceClient(conn).GetRoomMembers(c.Request.Context(), &pb.RoomMembersRequest{Room: c.Query("room")})
+	if err != nil {
+		g.log.Warnf("Listing members failed: %v", err)
+		c.JSON(exportStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	users := make([]string, 0, len(list.Members))
+	members := make([]MemberFrame, 0, len(list.Members))
+	for _, m := range list.Members {
+		if m.Online {
+			users = append(users, m.User)
+		}
+		members = append(members, memberFrame(m))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"room":    list.Room,
+		"users":   users,
+		"count":   len(users),
+		"members": members,
+	})
+}
+
+// handleInvite serves GET /join/:token, redirecting valid invites to the
+// web client with the room and token to join it
+func (g *Gateway) handleInvite(c *gin.Context) {
+	conn, err := g.upstreamConn()
+	if err != nil {
+		c.String(http.StatusServiceUnavailable, "chat server unavailable")
+		return
+	}
+	token := c.Param("token")
+	inv, err := pb.NewRoomServiceClient(conn).GetInvite(c.Request.Context(), &pb.InviteRequest{Token: token})
+	if err != nil {
+		if status.Code(err) != codes.NotFound {
+			g.log.Warnf("Looking up invite failed: %v", err)
+		}
+		c.String(exportStatus(err), "This invite is invalid, expired or used up.")
+		return
+	}
+	c.Redirect(http.StatusFound, "/?"+url.Values{"room": {inv.Room}, "invite": {token}}.Encode())
+}
+
+// requireAdmin rejects requests without the admin bearer token
+func (g *Gateway) requireAdmin(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(g.adminToken)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	c.Next()
+}
+
+// maintenanceRequest is the body of PUT /api/admin/maintenance, drainIn
+// is a duration such as "10m" and may be used instead of drainAt
+type maintenanceRequest struct {
+	Maintenance
+	DrainIn string `json:"drainIn"`
+}
+
+func (g *Gateway) handleSetMaintenance(c *gin.Context) {
+	var req maintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DrainIn != "" {
+		d, err := time.ParseDuration(req.DrainIn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "drainIn: " + err.Error()})
+			return
+		}
+		req.DrainAt = time.Now().Add(d)
+	}
+	if err := g.SetMaintenance(req.Maintenance); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, g.Maintenance())
+}
+
+// serveAsset serves a single file from the web assets
+func (g *Gateway) serveAsset(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, err := g.assets.Open(name)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+
+		var modTime time.Time
+		if info, err := f.Stat(); err == nil {
+			modTime = info.ModTime()
+		}
+		rs, ok := f.(io.ReadSeeker)
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(c.Writer, c.Request, path.Base(name), modTime, rs)
+	}
+}
+
+// remoteIP returns the address of the browser, or of the proxy in front
+// of the gateway
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func (g *Gateway) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	header, authUser, ok := g.handshakeAuth(w, r)
+	if !ok {
+		return
+	}
+	client := &WSClient{
+		send:      newOutbox(),
+		hub:       g.hub,
+		gw:        g,
+		authUser:  authUser,
+		userAgent: r.UserAgent(),
+		remoteIP:  remoteIP(r),
+	}
+	if g.poller != nil && r.TLS == nil {
+		g.poller.serve(client, w, r)
+		return
+	}
+
+	conn, err := g.upgrader.Upgrade(w, r, header)
+	if err != nil {
+		g.log.Warnf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	client.conn = conn
+	if !g.register(client) {
+		return
+	}
+
+	// handle read and write pumps
+	go client.writePump()
+	go client.readPump()
+}
+
+// register hands a client with an upgraded socket to the hub, it reports
+// false and closes the socket when the hub has stopped
+func (g *Gateway) register(client *WSClient) bool {
+	client.ctx, client.cancel = context.WithCancel(context.Background())
+
+	// register client
+	select {
+	case client.hub.register <- client:
+	case <-client.hub.done:
+		client.cancel()
+		client.closeConn()
+		return false
+	}
+
+	// sockets that never join are not kept around
+	client.expectJoin(g.authTimeout)
+	return true
+}
@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+
+	"realTimeChat/internal/attachmeta"
+	"realTimeChat/pkg/avscan"
+	pb "realTimeChat/proto/chat"
+)
+
+// reportTimeout bounds reporting a quarantined upload to the chat server
+const reportTimeout = 5 * time.Second
+
+// WithVirusScanner scans uploads before they are stored. Infected files
+// are quarantined and, with an admin token, reported to the chat server,
+// which tells its moderators.
+func WithVirusScanner(sc avscan.Scanner) Option {
+	return func(g *Gateway) {
+		g.scanner = sc
+	}
+}
+
+// scanUpload runs the virus scanner over an upload named name. It answers
+// the request and returns false when the upload was quarantined or could
+// not be scanned.
+func (g *Gateway) scanUpload(c *gin.Context, name string, data []byte) bool {
+	if g.scanner == nil {
+		return true
+	}
+	// the logs and moderators must not see an address the client made up
+	// in X-Forwarded-For, as c.ClientIP() would give
+	source := remoteIP(c.Request)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), avscan.DefaultTimeout)
+	threat, err := g.scanner.Scan(ctx, bytes.NewReader(data))
+	cancel()
+	if err != nil {
+		g.log.Errorf("Failed to scan upload from %s: %v", source, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "virus scan failed, please try again"})
+		return false
+	}
+	if threat == "" {
+		return true
+	}
+	m, err := g.attachments.save(attachmeta.Meta{Kind: "file", MimeType: http.DetectContentType(data), Name: name, Threat: threat}, data)
+	if err != nil {
+		g.log.Errorf("Failed to quarantine upload: %v", err)
+	}
+	g.log.Warnf("Quarantined upload %s %q (%d bytes) from %s: %s", m.ID, name, len(data), source, threat)
+	go g.reportQuarantine(&pb.QuarantineReport{AttachmentId: m.ID, Name: name, Size: m.Size, Threat: threat, Source: "gateway upload from " + source})
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "file rejected by virus scan: " + threat})
+	return false
+}
+
+// reportQuarantine tells the chat server about a quarantined upload so it
+// notifies the moderators, it needs the admin token
+func (g *Gateway) reportQuarantine(r *pb.QuarantineReport) {
+	if g.adminToken == "" {
+		return
+	}
+	conn, err := g.upstreamConn()
+	if err != nil {
+		g.log.Warnf("Could not report quarantined upload %s: %v", r.AttachmentId, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+g.adminToken)
+	if _, err := pb.NewAdminServiceClient(conn).ReportQuarantine(ctx, r); err != nil {
+		g.log.Warnf("Could not report quarantined upload %s: %v", r.AttachmentId, err)
+	}
+}
@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+
+	"realTimeChat/pkg/shard"
+)
+
+// shardDefaultRoom is the room streams join first, chatserver.DefaultRoom
+const shardDefaultRoom = "general"
+
+// shardRouter keeps one connection per chat server of a sharded cluster
+type shardRouter struct {
+	ring  atomic.Pointer[shard.Ring] // nil sends every stream upstream
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn // by address
+}
+
+// WithShards opens each browser's stream on the chat server ring assigns
+// its room to, for chat servers sharded with the same list. Streams
+// follow their room when it moves, everything else still goes to the
+// upstream address.
+func WithShards(ring *shard.Ring) Option {
+	return func(g *Gateway) {
+		g.shards.ring.Store(ring)
+	}
+}
+
+// SetShards replaces the ring new streams are routed by, the chat
+// servers move the open ones
+func (g *Gateway) SetShards(ring *shard.Ring) {
+	g.shards.ring.Store(ring)
+	g.log.Infof("Routing rooms to %v", ring.Nodes())
+}
+
+// roomUpstream returns the address and connection of the chat server
+// serving room
+func (g *Gateway) roomUpstream(room string) (string, *grpc.ClientConn, error) {
+	if room == "" {
+		room = shardDefaultRoom
+	}
+	addr := g.shards.ring.Load().Owner(room)
+	if addr == "" {
+		conn, err := g.upstreamConn()
+		return g.upstream, conn, err
+	}
+	conn, err := g.shardConn(addr)
+	return addr, conn, err
+}
+
+// shardConn returns the connection to the chat server at addr, the
+// upstream one when they match
+func (g *Gateway) shardConn(addr string) (*grpc.ClientConn, error) {
+	if addr == g.upstream {
+		return g.upstreamConn()
+	}
+	r := &g.shards
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if conn, ok := r.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(addr, g.upstreamDialOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if r.conns == nil {
+		r.conns = make(map[string]*grpc.ClientConn)
+	}
+	r.conns[addr] = conn
+	return conn, nil
+}
+
+func (r *shardRouter) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for addr, conn := range r.conns {
+		conn.Close()
+		delete(r.conns, addr)
+	}
+}
@@ -0,0 +1,229 @@
+package gateway
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// statsResponse is the body of GET /api/stats, times are RFC 3339 in UTC
+type statsResponse struct {
+	From            string        `json:"from"`
+	To              string        `json:"to"`
+	Granularity     string        `json:"granularity"`
+	Messages        int64         `json:"messages"`
+	ActiveUsers     int32         `json:"activeUsers"`
+	PeakConcurrency int32         `json:"peakConcurrency"`
+	PeakAt          string        `json:"peakAt,omitempty"`
+	TopRooms        []roomCount   `json:"topRooms"`
+	Buckets         []statsBucket `json:"buckets"`
+	Leadership      *leadership   `json:"leadership,omitempty"`
+}
+
+type roomCount struct {
+	Room     string `json:"room"`
+	Messages int64  `json:"messages"`
+}
+
+type statsBucket struct {
+	Start           string `json:"start"`
+	Messages        int64  `json:"messages"`
+	ActiveUsers     int32  `json:"activeUsers"`
+	PeakConcurrency int32  `json:"peakConcurrency"`
+}
+
+// leadership is the leader election state of the chat server answering
+type leadership struct {
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Leader  bool   `json:"leader"`
+	Holder  string `json:"holder,omitempty"`
+	Since   string `json:"since"`
+	Elected uint64 `json:"elected"`
+	Lost    uint64 `json:"lost"`
+	Errors  uint64 `json:"errors"`
+}
+
+// handleStats serves GET /api/stats. granularity is hour (the default) or
+// day, from and to are parsed like the export's, top limits the ranked
+// rooms.
+func (g *Gateway) handleStats(c *gin.Context) {
+	granularity := c.DefaultQuery("granularity", "hour")
+	if granularity != "hour" && granularity != "day" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be hour or day"})
+		return
+	}
+	from, err := parseExportTime(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error()})
+		return
+	}
+	to, err := parseExportTime(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error()})
+		return
+	}
+	req := &pb.StatsRequest{Daily: granularity == "day"}
+	if !from.IsZero() {
+		req.From = from.UnixMilli()
+	}
+	if !to.IsZero() {
+		req.To = to.UnixMilli()
+	}
+	if s := c.Query("top"); s != "" {
+		top, err := strconv.Atoi(s)
+		if err != nil || top < 1 || top > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "top must be between 1 and 100"})
+			return
+		}
+		req.TopRooms = int32(top)
+	}
+	conn, err := g.upstreamConn()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chat server unavailable"})
+		return
+	}
+
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "authorization", "Bearer "+g.adminToken)
+	stats, err := pb.NewAdminServiceClient(conn).GetStats(ctx, req)
+	if err != nil {
+		g.log.Warnf("Stats failed: %v", err)
+		c.JSON(exportStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	resp := statsResponse{
+		Granularity:     granularity,
+		Messages:        stats.Messages,
+		ActiveUsers:     stats.ActiveUsers,
+		PeakConcurrency: stats.PeakConcurrency,
+		TopRooms:        []roomCount{},
+		Buckets:         make([]statsBucket, 0, len(stats.Buckets)),
+	}
+	if stats.PeakAt > 0 {
+		resp.PeakAt = statsTime(stats.PeakAt)
+	}
+	for _, r := range stats.TopRooms {
+		resp.TopRooms = append(resp.TopRooms, roomCount{Room: r.Room, Messages: r.Messages})
+	}
+	for _, b := range stats.Buckets {
+		resp.Buckets = append(resp.Buckets, statsBucket{
+			Start:           statsTime(b.Start),
+			Messages:        b.Messages,
+			ActiveUsers:     b.ActiveUsers,
+			PeakConcurrency: b.PeakConcurrency,
+		})
+	}
+	if l := stats.Leadership; l != nil {
+		resp.Leadership = &leadership{
+			Name:    l.Name,
+			ID:      l.Id,
+			Leader:  l.Leader,
+			Holder:  l.Holder,
+			Since:   statsTime(l.Since),
+			Elected: l.Elected,
+			Lost:    l.Lost,
+			Errors:  l.Errors,
+		}
+	}
+	if n := len(stats.Buckets); n > 0 {
+		step := time.Hour
+		if req.Daily {
+			step = 24 * time.Hour
+		}
+		resp.From = statsTime(stats.Buckets[0].Start)
+		resp.To = time.UnixMilli(stats.Buckets[n-1].Start).Add(step).UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// liveStats is one event of GET /api/stats/stream, the chat server's
+// snapshot and this gateway's hub at the same moment
+type liveStats struct {
+	Time              string   `json:"time"`
+	Connections       int32    `json:"connections"` // chat streams of the chat server
+	Users             int32    `json:"users"`
+	Rooms             int32    `json:"rooms"`
+	Messages          int64    `json:"messages"` // since the chat server started
+	MessagesPerSecond float64  `json:"messagesPerSecond"`
+	PendingSends      int32    `json:"pendingSends"`
+	WatcherBacklog    int32    `json:"watcherBacklog"`
+	Watchers          int32    `json:"watchers"`
+	ClusterQueue      int32    `json:"clusterQueue"`
+	Goroutines        int32    `json:"goroutines"`
+	Gateway           HubStats `json:"gateway"`
+}
+
+// handleStatsStream serves GET /api/stats/stream as server-sent events,
+// a "stats" event right away and then every interval seconds (5 by
+// default) until the client goes away. It ends with an "error" event
+// when the chat server fails.
+func (g *Gateway) handleStatsStream(c *gin.Context) {
+	req := &pb.WatchStatsRequest{}
+	if s := c.Query("interval"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 3600 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be between 1 and 3600 seconds"})
+			return
+		}
+		req.IntervalSeconds = int32(n)
+	}
+	conn, err := g.upstreamConn()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chat server unavailable"})
+		return
+	}
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "authorization", "Bearer "+g.adminToken)
+	stream, err := pb.NewAdminServiceClient(conn).WatchStats(ctx, req)
+	var snap *pb.StatsSnapshot
+	if err == nil {
+		// the first snapshot tells whether the chat server accepted the
+		// call while a status can still be sent
+		snap, err = stream.Recv()
+	}
+	if err != nil {
+		g.log.Warnf("Watching stats failed: %v", err)
+		c.JSON(exportStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // keep proxies such as nginx from holding events back
+	for {
+		c.SSEvent("stats", liveStats{
+			Time:              statsTime(snap.Time),
+			Connections:       snap.Connections,
+			Users:             snap.Users,
+			Rooms:             snap.Rooms,
+			Messages:          snap.Messages,
+			MessagesPerSecond: snap.MessagesPerSecond,
+			PendingSends:      snap.PendingSends,
+			WatcherBacklog:    snap.WatcherBacklog,
+			Watchers:          snap.Watchers,
+			ClusterQueue:      snap.ClusterQueue,
+			Goroutines:        snap.Goroutines,
+			Gateway:           g.HubStats(),
+		})
+		c.Writer.Flush()
+		if snap, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if c.Request.Context().Err() == nil && !errors.Is(err, io.EOF) {
+		g.log.Warnf("Stats stream broke off: %v", err)
+		c.SSEvent("error", gin.H{"error": status.Convert(err).Message()})
+		c.Writer.Flush()
+	}
+}
+
+func statsTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
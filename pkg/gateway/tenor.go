@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// TenorProvider searches Tenor through its v2 API
+type TenorProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewTenorProvider creates a Tenor provider with the given API key
+func NewTenorProvider(apiKey string) *TenorProvider {
+	return &TenorProvider{
+		apiKey:  apiKey,
+		baseURL: "https://tenor.googleapis.com/v2",
+		client:  &http.Client{Timeout: mediaTimeout},
+	}
+}
+
+type tenorFormat struct {
+	URL  string `json:"url"`
+	Dims []int  `json:"dims"`
+}
+
+type tenorResult struct {
+	ID           string                 `json:"id"`
+	Description  string                 `json:"content_description"`
+	MediaFormats map[string]tenorFormat `json:"media_formats"`
+}
+
+func (r tenorResult) media() Media {
+	gif := r.MediaFormats["gif"]
+	m := Media{
+		ID:         r.ID,
+		Title:      r.Description,
+		URL:        gif.URL,
+		PreviewURL: r.MediaFormats["tinygif"].URL,
+		MimeType:   "image/gif",
+	}
+	if len(gif.Dims) == 2 {
+		m.Width, m.Height = gif.Dims[0], gif.Dims[1]
+	}
+	return m
+}
+
+// Name implements MediaProvider
+func (p *TenorProvider) Name() string { return "tenor" }
+
+func (p *TenorProvider) query() url.Values {
+	return url.Values{
+		"key":           {p.apiKey},
+		"client_key":    {"realTimeChat"},
+		"media_filter":  {"gif,tinygif"},
+		"contentfilter": {"medium"},
+	}
+}
+
+// Search implements MediaProvider
+func (p *TenorProvider) Search(ctx context.Context, query string, limit int) ([]Media, error) {
+	q := p.query()
+	q.Set("q", query)
+	q.Set("limit", strconv.Itoa(limit))
+	var resp struct {
+		Results []tenorResult `json:"results"`
+	}
+	if err := getJSON(ctx, p.client, p.baseURL+"/search?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	out := make([]Media, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		out = append(out, r.media())
+	}
+	return out, nil
+}
+
+// Get implements MediaProvider
+func (p *TenorProvider) Get(ctx context.Context, id string) (*Media, error) {
+	q := p.query()
+	q.Set("ids", id)
+	var resp struct {
+		Results []tenorResult `json:"results"`
+	}
+	if err := getJSON(ctx, p.client, p.baseURL+"/posts?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, ErrMediaNotFound
+	}
+	m := resp.Results[0].media()
+	return &m, nil
+}
@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "realTimeChat/proto/chat"
+)
+
+// thread routers proxy the chat server's ThreadService for the user
+// themselves, given by ?user= when reading a thread
+func (g *Gateway) setupThreadRoutes(r gin.IRouter) {
+	r = r.Group("", g.requireUser)
+	r.GET("/api/threads/:id", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewThreadServiceClient(conn).GetThread(c.Request.Context(), &pb.ThreadRequest{User: c.Query("user"), ThreadId: c.Param("id")})
+		})
+	})
+	r.PUT("/api/threads/:id/followers/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewThreadServiceClient(conn).FollowThread(c.Request.Context(), &pb.ThreadRequest{User: c.Param("user"), ThreadId: c.Param("id")})
+		})
+	})
+	r.DELETE("/api/threads/:id/followers/:user", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewThreadServiceClient(conn).UnfollowThread(c.Request.Context(), &pb.ThreadRequest{User: c.Param("user"), ThreadId: c.Param("id")})
+		})
+	})
+}
+
+// relayThread forwards the new reply count of a thread
+func (c *WSClient) relayThread(u *pb.ThreadUpdate) {
+	c.queueFrame(ThreadFrame{
+		Type:        "thread",
+		Room:        u.Room,
+		ThreadID:    u.ThreadId,
+		ReplyCount:  u.ReplyCount,
+		LastUser:    u.LastUser,
+		LastReplyAt: time.UnixMilli(u.LastReplyAt).UTC().Format(time.RFC3339),
+	}, prioPresence)
+}
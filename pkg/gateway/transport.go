@@ -0,0 +1,39 @@
+package gateway
+
+// Transport selects how WebSockets are read and written
+type Transport string
+
+const (
+	// TransportPumps gives every socket a read and a write goroutine, the
+	// default
+	TransportPumps Transport = "pumps"
+	// TransportEpoll waits for all sockets from one goroutine and only
+	// runs one for a socket while it has frames to read or write, so
+	// idle sockets cost no goroutines. Linux only, sockets served over
+	// TLS by the gateway itself still use the pumps.
+	TransportEpoll Transport = "epoll"
+)
+
+// WithTransport selects the WebSocket transport, New falls back to
+// TransportPumps when t is unknown or unavailable
+func WithTransport(t Transport) Option {
+	return func(g *Gateway) {
+		g.transport = t
+	}
+}
+
+// startTransport sets up the poller of TransportEpoll
+func (g *Gateway) startTransport() {
+	switch g.transport {
+	case "", TransportPumps:
+	case TransportEpoll:
+		p, err := newPoller(g)
+		if err != nil {
+			g.log.Errorf("Using the pumps transport: %v", err)
+			return
+		}
+		g.poller = p
+	default:
+		g.log.Errorf("Ignoring unknown WebSocket transport %q", g.transport)
+	}
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+// unread routers proxy the chat server's UnreadService for the user
+// themselves, see requireUser
+func (g *Gateway) setupUnreadRoutes(r gin.IRouter) {
+	r = r.Group("", g.requireUser)
+	r.GET("/api/unread-counts", func(c *gin.Context) {
+		g.upstreamCall(c, func(conn *grpc.ClientConn) (proto.Message, error) {
+			return pb.NewUnreadServiceClient(conn).GetUnreadCounts(c.Request.Context(), &pb.UnreadRequest{User: c.Query("user")})
+		})
+	})
+}
+
+// handleRead marks the room read up to msg.Seq for this client's user,
+// the server then pushes the new counts to all of the user's sessions
+func (c *WSClient) handleRead(msg WSMessage) {
+	if c.chat == nil {
+		c.sendError(i18n.NotConnected)
+		return
+	}
+	_, conn, err := c.gw.roomUpstream(msg.Room)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	defer cancel()
+	_, err = pb.NewUnreadServiceClient(conn).MarkRead(ctx, &pb.MarkReadRequest{
+		User: c.chat.Username(),
+		Room: msg.Room,
+		Seq:  msg.Seq,
+	})
+	if err != nil {
+		c.gw.log.Warnf("Failed to mark %s read for %s: %v", msg.Room, c.chat.Username(), err)
+	}
+}
+
+// relayUnread forwards new unread counts
+func (c *WSClient) relayUnread(u *pb.UnreadCounts) {
+	c.queueFrame(UnreadFrame{Type: "unread_update", Rooms: u.Rooms}, prioChat)
+}
+
+// relayKeywordHit forwards a keyword hit, the text is filtered like the
+// message itself
+func (c *WSClient) relayKeywordHit(k *pb.KeywordHit) {
+	c.queueFrame(KeywordHitFrame{
+		Type:      "keywordHit",
+		Keyword:   k.Keyword,
+		Room:      k.Room,
+		MessageID: k.MessageId,
+		Sender:    k.Sender,
+		Text:      c.gw.config.Load().filter.apply(k.Text),
+	}, prioDirect)
+}
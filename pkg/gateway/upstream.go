@@ -0,0 +1,186 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"realTimeChat/pkg/chatclient"
+	"realTimeChat/pkg/i18n"
+	pb "realTimeChat/proto/chat"
+)
+
+const (
+	probeTimeout    = 2 * time.Second
+	probeMinBackoff = 250 * time.Millisecond
+	probeMaxBackoff = 5 * time.Second
+	probeInterval   = 5 * time.Second // recheck period while ready
+
+	reconnectMinBackoff = 500 * time.Millisecond
+	reconnectMaxBackoff = 10 * time.Second
+)
+
+// readiness tracks whether the chat server is reachable
+type readiness struct {
+	mu      sync.Mutex
+	ready   bool
+	err     error         // last probe failure
+	changed chan struct{} // closed and replaced on every transition
+}
+
+func newReadiness() *readiness {
+	return &readiness{changed: make(chan struct{})}
+}
+
+func (r *readiness) set(ready bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+	if r.ready == ready {
+		return
+	}
+	r.ready = ready
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *readiness) get() (ready bool, changed <-chan struct{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready, r.changed, r.err
+}
+
+// Ready reports whether the chat server answered the last health probe
+func (g *Gateway) Ready() bool {
+	ready, _, _ := g.readiness.get()
+	return ready
+}
+
+// waitReady blocks until the chat server is reachable, the timeout
+// passes or the gateway closes
+func (g *Gateway) waitReady(ctx context.Context, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		ready, changed, _ := g.readiness.get()
+		if ready {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		case <-g.hub.done:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// watchUpstream probes the chat server health service, backing off while
+// it is unreachable and rechecking periodically once it is up
+func (g *Gateway) watchUpstream(ctx context.Context) {
+	delay := probeMinBackoff
+	for {
+		err := g.probe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		wasReady := g.Ready()
+		g.readiness.set(err == nil, err)
+		switch {
+		case err == nil && !wasReady:
+			g.log.Infof("Chat server %s is reachable", g.upstream)
+		case err != nil && wasReady:
+			g.log.Warnf("Chat server %s is unreachable: %v", g.upstream, err)
+		}
+
+		wait := probeInterval
+		if err == nil {
+			delay = probeMinBackoff
+		} else {
+			wait = delay
+			if delay *= 2; delay > probeMaxBackoff {
+				delay = probeMaxBackoff
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probe runs one health check, servers without the health service count
+// as reachable
+func (g *Gateway) probe(ctx context.Context) error {
+	conn, err := g.upstreamConn()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: pb.ChatService_ServiceDesc.ServiceName,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return status.Errorf(codes.Unavailable, "chat server is %s", resp.GetStatus())
+	}
+	return nil
+}
+
+// upstreamState follows a client's upstream stream. The chat client
+// rejoins on its own after a drop, the browser is told while that is in
+// progress and its WebSocket is closed once the client gives up.
+func (c *WSClient) upstreamState(state chatclient.State, err error) {
+	switch state {
+	case chatclient.Reconnecting:
+		c.accepted.Store(false)
+		if c.rejoining.CompareAndSwap(false, true) {
+			c.gw.log.Warnf("Upstream stream for %s dropped, reconnecting: %v", c.username, err)
+			c.sendUpstream("reconnecting")
+			c.sendSystem(i18n.Reconnecting)
+		}
+	case chatclient.Connected:
+		if c.rejoining.CompareAndSwap(true, false) {
+			// a restarted server numbers rooms from scratch
+			c.seqs.reset()
+			c.gw.log.Infof("Upstream stream for %s re-established", c.username)
+			c.sendUpstream("connected")
+			c.sendSystem(i18n.Reconnected)
+			if c.idle.Load() {
+				// the new stream starts out active
+				go func() { _ = c.chat.SetIdle(true) }()
+			}
+		}
+	case chatclient.Closed:
+		// also reached after readPump closes the session, closing again is a no-op
+		if err != nil {
+			c.gw.log.Warnf("Upstream stream for %s closed: %v", c.username, err)
+		}
+		if !c.revoked(err) && !c.upstreamRefused(err) {
+			c.closeWith(websocket.CloseInternalServerErr, reasonUpstream)
+		}
+	}
+}
+
+// sendUpstream tells the browser about the state of its upstream stream
+func (c *WSClient) sendUpstream(state string) {
+	c.queueFrame(UpstreamFrame{Type: "upstream", State: state}, prioSystem)
+}
@@ -0,0 +1,460 @@
+// Package i18n is the catalog of system message texts. The chat server
+// and gateway send a message key with named arguments, clients render it
+// in the reader's locale and fall back to the English text that comes
+// along with it.
+package i18n
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is used for locales without a catalog
+const DefaultLocale = "en"
+
+// Chat server message keys
+const (
+	UserJoined        = "user.joined"       // user
+	UserLeft          = "user.left"         // user
+	UsersJoined       = "users.joined"      // count, names
+	UsersJoinedMore   = "users.joined_more" // count, names, more
+	UsersLeft         = "users.left"        // count, names
+	UsersLeftMore     = "users.left_more"   // count, names, more
+	UserRenamed       = "user.renamed"      // old, new
+	NickUsage         = "nick.usage"
+	NickSame          = "nick.same"     // name
+	NickInvalid       = "nick.invalid"  // name
+	NickTooLong       = "nick.too_long" // max
+	NickDenied        = "nick.denied"   // name
+	NickTaken         = "nick.taken"    // name
+	NickOtherStreams  = "nick.other_streams"
+	MessageTooLong    = "message.too_long" // max
+	CodeEmpty         = "code.empty"
+	CodeTooLong       = "code.too_long"     // max
+	CodeBadLanguage   = "code.bad_language" // language
+	AttachmentInvalid = "attachment.unsupported"
+	ClientMsgIDLong   = "client_msg_id.too_long"
+	MetadataBadKey    = "metadata.bad_key"     // key
+	MetadataTooLarge  = "metadata.too_large"   // max
+	RecipientOffline  = "pm.recipient_offline" // user
+	PMRequestHeld     = "pm.request_held"      // user
+	PMRequestNew      = "pm.request_new"       // user
+	CallInvalidSignal = "call.invalid_signal"
+	CallNotFound      = "call.not_found"
+	VoiceNotJoined    = "voice.not_joined"
+	ThreadNotFound    = "thread.not_found"
+	ThreadInvalid     = "thread.invalid"
+	CallSelf          = "call.self"
+	CallIDInUse       = "call.id_in_use"
+	TranslateUsage    = "translate.usage"
+	TranslateOff      = "translate.unavailable"
+	TranslateBadLang  = "translate.bad_language" // lang
+	TranslateNotFound = "translate.not_found"    // id
+	TranslateFailed   = "translate.failed"
+	TranslateNoLang   = "translate.unsupported" // lang
+	RoomUsage         = "room.usage"
+	RoomInvalid       = "room.invalid"     // room
+	RoomAlready       = "room.already_in"  // room
+	RoomEntered       = "room.entered"     // room
+	RoomUserJoined    = "room.user_joined" // user, room
+	RoomUserLeft      = "room.user_left"   // user, room
+	RoomPrivate       = "room.private"     // room
+	InviteInvalid     = "room.invite_invalid"
+	SubscribeUsage    = "room.subscribe_usage"
+	Subscribed        = "room.subscribed"             // room
+	Unsubscribed      = "room.unsubscribed"           // room
+	NotSubscribed     = "room.not_subscribed"         // room
+	SubscribeCurrent  = "room.is_current"             // room
+	SubscribeTooMany  = "room.too_many_subscriptions" // max
+	FilterTooMany     = "room.filter_too_many"        // max
+	RoomElsewhere     = "room.elsewhere"              // room, node
+	RoomMovedAway     = "room.moved_away"             // room, node
+
+	QuotaTenantMessages = "quota.tenant_messages" // tenant, max
+	QuotaTenantStorage  = "quota.tenant_storage"  // tenant, max
+	QuotaTooManyRooms   = "quota.too_many_rooms"  // tenant, max
+	QuotaRoomMessages   = "quota.room_messages"   // room, max
+	QuotaRoomStorage    = "quota.room_storage"    // room, max
+	QuotaRoomFull       = "quota.room_full"       // room, max
+
+	PluginRejected  = "plugin.rejected"  // reason
+	PluginReply     = "plugin.reply"     // text
+	PluginFailed    = "plugin.failed"    // command
+	ChatOpsNotHere  = "chatops.not_here" // command, room
+	AssistantFailed = "assistant.failed"
+	SummarizeUsage  = "summarize.usage"
+	SummarizeOff    = "summarize.unavailable"
+	Summarizing     = "summarize.started"
+	SummarizeEmpty  = "summarize.empty"
+	SummarizeFailed = "summarize.failed"
+	EphemeralPM     = "ephemeral.private"
+	EphemeralAbsent = "ephemeral.absent"    // user, room
+	ScriptDropped   = "script.dropped"      // reason
+	SlowMode        = "abuse.slow_mode"     // seconds
+	BlockFlagged    = "blocklist.flagged"   // user, room, text
+	SessionsList    = "sessions.list"       // count, list
+	LoggedOutOthers = "sessions.logged_out" // count
+	BlockRejected   = "blocklist.rejected"
+	Quarantined     = "attachment.quarantined" // name, size, threat
+	Announcement    = "admin.announcement"     // text
+	FeatureDisabled = "feature.disabled"       // feature
+
+	SettingsShow       = "settings.show"    // room, language, nsfw, post, attachments, broadcast, publishers
+	SettingsChanged    = "settings.changed" // user, room, language, nsfw, post, attachments, broadcast, publishers
+	SettingsUsage      = "settings.usage"
+	SettingsDenied     = "settings.denied"     // room
+	SettingsBadValue   = "settings.bad_value"  // key, value
+	SettingsPostRole   = "settings.post_role"  // room, role
+	SettingsAttachment = "settings.attachment" // room, kind
+	SettingsNSFW       = "settings.nsfw"       // room
+	SettingsBroadcast  = "settings.broadcast"  // room
+)
+
+// Gateway message keys
+const (
+	BackfillIncomplete = "gateway.backfill_incomplete"
+	NotConnected       = "gateway.not_connected"
+	SignalNoRecipient  = "gateway.signal_no_recipient"
+	SignalUnknown      = "gateway.signal_unknown"
+	SignalFailed       = "gateway.signal_failed"
+	AlreadyJoined      = "gateway.already_joined"
+	WaitingForServer   = "gateway.waiting"
+	ServerUnavailable  = "gateway.unavailable"
+	ConnectFailed      = "gateway.connect_failed"
+	JoinFailed         = "gateway.join_failed"
+	RateLimited        = "gateway.rate_limited"
+	CodeMissing        = "gateway.code_missing"
+	AttachmentUnknown  = "gateway.attachment_unknown"
+	SendFailed         = "gateway.send_failed"
+	Reconnecting       = "gateway.reconnecting"
+	Reconnected        = "gateway.reconnected"
+	AuthRequired       = "gateway.auth_required"
+	AuthFailed         = "gateway.auth_failed"
+	AuthUserMismatch   = "gateway.auth_user_mismatch"
+	UsernameRequired   = "gateway.username_required"
+	JoinDenied         = "gateway.join_denied"
+	TooManyStreams     = "gateway.too_many_streams"
+	MessageTooLarge    = "gateway.message_too_large" // max
+	SessionRevoked     = "gateway.session_revoked"
+	Banned             = "gateway.banned"       // reason
+	BannedUntil        = "gateway.banned_until" // until, reason
+	ChallengeFailed    = "gateway.challenge_failed"
+)
+
+var catalogs = map[string]map[string]string{
+	"en": {
+		UserJoined:        "{user} has joined the chat",
+		UserLeft:          "{user} has left the chat",
+		UsersJoined:       "{count} users joined the chat: {names}",
+		UsersJoinedMore:   "{count} users joined the chat: {names} and {more} more",
+		UsersLeft:         "{count} users left the chat: {names}",
+		UsersLeftMore:     "{count} users left the chat: {names} and {more} more",
+		UserRenamed:       "{old} is now known as {new}",
+		NickUsage:         "Usage: /nick <newname>",
+		NickSame:          "You are already known as '{name}'.",
+		NickInvalid:       "'{name}' is not a valid username.",
+		NickTooLong:       "Username cannot be longer than {max} bytes.",
+		NickDenied:        "You may not use the name '{name}'.",
+		NickTaken:         "Username '{name}' is already taken.",
+		NickOtherStreams:  "Close your other sessions before changing your name.",
+		MessageTooLong:    "Message is too long (max {max} bytes).",
+		CodeEmpty:         "Code block cannot be empty.",
+		CodeTooLong:       "Code block is too long (max {max} bytes).",
+		CodeBadLanguage:   "'{language}' is not a valid code language.",
+		AttachmentInvalid: "Unsupported attachment.",
+		ClientMsgIDLong:   "Client message ID is too long.",
+		MetadataBadKey:    "'{key}' is not a valid metadata key.",
+		MetadataTooLarge:  "Metadata is too large (max {max} bytes).",
+		RecipientOffline:  "User '{user}' not found or is offline.",
+		PMRequestHeld:     "'{user}' only takes messages from contacts, yours was sent as a message request.",
+		PMRequestNew:      "{user} sent you a message request.",
+		CallInvalidSignal: "Invalid call signal.",
+		VoiceNotJoined:    "You are not in a voice channel.",
+		ThreadNotFound:    "No public message {id} in this room to reply to.",
+		ThreadInvalid:     "Only public messages can be thread replies.",
+		CallNotFound:      "No such call.",
+		CallSelf:          "You cannot call yourself.",
+		CallIDInUse:       "Call ID already in use.",
+		TranslateUsage:    "Usage: /translate <message_id> <lang>",
+		TranslateOff:      "Translation is not available.",
+		TranslateBadLang:  "'{lang}' is not a valid language.",
+		TranslateNotFound: "Message '{id}' not found.",
+		TranslateFailed:   "Could not translate the message, please try again later.",
+		TranslateNoLang:   "Translation to '{lang}' is not supported.",
+		RoomUsage:         "Usage: /join <room> [invite]",
+		RoomInvalid:       "'{room}' is not a valid room name.",
+		RoomAlready:       "You are already in #{room}.",
+		RoomEntered:       "You are now in #{room}.",
+		RoomUserJoined:    "{user} joined #{room}",
+		RoomUserLeft:      "{user} left #{room}",
+		RoomPrivate:       "#{room} is private, you need an invite to join it.",
+		InviteInvalid:     "This invite is invalid, expired or used up.",
+		SubscribeUsage:    "Usage: /subscribe <room> [invite] or /unsubscribe <room>",
+		Subscribed:        "You now also receive #{room}.",
+		Unsubscribed:      "You no longer receive #{room}.",
+		NotSubscribed:     "You are not subscribed to #{room}.",
+		SubscribeCurrent:  "#{room} is your current room, /join another one to leave it.",
+		SubscribeTooMany:  "You cannot subscribe to more than {max} rooms on one connection.",
+		FilterTooMany:     "A filter can name at most {max} rooms, it was not applied.",
+		RoomElsewhere:     "#{room} is served by another server ({node}), connect there to join it.",
+		RoomMovedAway:     "#{room} moved to another server ({node}), this connection will be closed soon. Reconnect to keep chatting.",
+
+		QuotaTenantMessages: "{tenant} has used its {max} messages for today.",
+		QuotaTenantStorage:  "{tenant} has used its {max} bytes of storage.",
+		QuotaTooManyRooms:   "{tenant} may be in at most {max} rooms at once.",
+		QuotaRoomMessages:   "#{room} has reached its {max} messages for today.",
+		QuotaRoomStorage:    "#{room} has used its {max} bytes of storage.",
+		QuotaRoomFull:       "#{room} is full, it holds at most {max} members.",
+
+		PluginRejected:  "Your message was not sent: {reason}",
+		PluginReply:     "{text}",
+		PluginFailed:    "/{command} failed, please try again later.",
+		ChatOpsNotHere:  "/{command} cannot be used in #{room}.",
+		AssistantFailed: "The assistant could not answer, please try again later.",
+		SummarizeUsage:  "Usage: /summarize [last <count> | since <2h, 14:30 or RFC 3339 time>]",
+		SummarizeOff:    "Summaries are not available on this server.",
+		Summarizing:     "Summarizing, only you will see the result…",
+		SummarizeEmpty:  "There is nothing to summarize.",
+		SummarizeFailed: "Could not summarize the conversation, please try again later.",
+		EphemeralPM:     "An ephemeral message cannot also be a private message.",
+		EphemeralAbsent: "'{user}' is not in #{room}, the message was not delivered.",
+		ScriptDropped:   "Your message was not sent: {reason}",
+		SlowMode:        "Slow mode: you can send one message every {seconds} seconds.",
+		BlockRejected:   "Your message was not sent: it contains blocked words.",
+		BlockFlagged:    "Flagged message from {user} in #{room}: {text}",
+		Quarantined:     "Uploaded file {name} ({size} bytes) was quarantined: {threat}",
+		Announcement:    "Announcement: {text}",
+		FeatureDisabled: "The {feature} feature is turned off here.",
+
+		SettingsShow:       "Settings of #{room}: language {language}, nsfw {nsfw}, post {post}, attachments {attachments}, broadcast {broadcast}, publishers {publishers}",
+		SettingsChanged:    "{user} changed the settings of #{room}: language {language}, nsfw {nsfw}, post {post}, attachments {attachments}, broadcast {broadcast}, publishers {publishers}",
+		SettingsUsage:      "Usage: /settings [language <lang>|off, nsfw on|off, post member|moderator|owner, attachments all|media|none, broadcast on|off, publishers <user,...>|none]",
+		SettingsDenied:     "Only moderators of #{room} can change its settings.",
+		SettingsBadValue:   "'{value}' is not a valid value for {key}.",
+		SettingsPostRole:   "Only a {role} or above can post in #{room}.",
+		SettingsAttachment: "{kind} attachments are not allowed in #{room}.",
+		SettingsNSFW:       "#{room} is marked NSFW.",
+		SettingsBroadcast:  "#{room} is a broadcast room, only its publishers can post.",
+		SessionsList:       "You have {count} sessions, * is this one:\n{list}",
+		LoggedOutOthers:    "Logged out {count} other sessions.",
+
+		BackfillIncomplete: "Some earlier messages could not be recovered",
+		NotConnected:       "Not connected to chat server",
+		SignalNoRecipient:  "Signal without recipient",
+		SignalUnknown:      "Unknown signal type",
+		SignalFailed:       "Failed to send signal",
+		AlreadyJoined:      "Already joined",
+		WaitingForServer:   "Waiting for chat server...",
+		ServerUnavailable:  "Chat server is unavailable, please try again later",
+		ConnectFailed:      "Failed to connect to chat server",
+		JoinFailed:         "Failed to join chat",
+		RateLimited:        "You are sending messages too fast",
+		CodeMissing:        "Code message without code",
+		AttachmentUnknown:  "Unknown attachment",
+		SendFailed:         "Failed to send message",
+		Reconnecting:       "Connection to chat server lost, reconnecting...",
+		Reconnected:        "Reconnected to chat server",
+		AuthRequired:       "A token is required to join",
+		AuthFailed:         "Invalid token",
+		AuthUserMismatch:   "The username does not match your token",
+		UsernameRequired:   "Please enter a username",
+		JoinDenied:         "The chat server did not let you join",
+		TooManyStreams:     "Too many connections, please try again later",
+		MessageTooLarge:    "Message is too long (max {max} bytes)",
+		SessionRevoked:     "You were logged out of this session",
+		Banned:             "You are banned: {reason}",
+		BannedUntil:        "You are banned until {until}: {reason}",
+		ChallengeFailed:    "The challenge was not solved, please try again",
+	},
+	"zh": {
+		UserJoined:        "{user} 加入了聊天室",
+		UserLeft:          "{user} 离开了聊天室",
+		UsersJoined:       "{count} 位用户加入了聊天室：{names}",
+		UsersJoinedMore:   "{count} 位用户加入了聊天室：{names} 等 {more} 人",
+		UsersLeft:         "{count} 位用户离开了聊天室：{names}",
+		UsersLeftMore:     "{count} 位用户离开了聊天室：{names} 等 {more} 人",
+		UserRenamed:       "{old} 改名为 {new}",
+		NickUsage:         "用法：/nick <新名字>",
+		NickSame:          "你的名字已经是 '{name}'。",
+		NickInvalid:       "'{name}' 不是有效的用户名。",
+		NickTooLong:       "用户名不能超过 {max} 字节。",
+		NickDenied:        "你不能使用名字 '{name}'。",
+		NickTaken:         "用户名 '{name}' 已被占用。",
+		NickOtherStreams:  "请先关闭你的其他会话再改名。",
+		MessageTooLong:    "消息过长（最多 {max} 字节）。",
+		CodeEmpty:         "代码块不能为空。",
+		CodeTooLong:       "代码块过长（最多 {max} 字节）。",
+		CodeBadLanguage:   "'{language}' 不是有效的代码语言。",
+		AttachmentInvalid: "不支持的附件。",
+		ClientMsgIDLong:   "客户端消息 ID 过长。",
+		MetadataBadKey:    "'{key}' 不是有效的元数据键。",
+		MetadataTooLarge:  "元数据过大（最多 {max} 字节）。",
+		RecipientOffline:  "用户 '{user}' 不存在或不在线。",
+		PMRequestHeld:     "'{user}' 只接收联系人的私信，你的消息已作为消息请求发送。",
+		PMRequestNew:      "{user} 向你发送了消息请求。",
+		CallInvalidSignal: "无效的通话信令。",
+		VoiceNotJoined:    "你不在语音频道中。",
+		ThreadNotFound:    "本房间中没有可回复的公开消息 {id}。",
+		ThreadInvalid:     "只有公开消息可以作为话题回复。",
+		CallNotFound:      "通话不存在。",
+		CallSelf:          "不能呼叫自己。",
+		CallIDInUse:       "通话 ID 已被使用。",
+		TranslateUsage:    "用法：/translate <消息ID> <语言>",
+		TranslateOff:      "翻译功能未启用。",
+		TranslateBadLang:  "'{lang}' 不是有效的语言。",
+		TranslateNotFound: "找不到消息 '{id}'。",
+		TranslateFailed:   "翻译失败，请稍后再试。",
+		TranslateNoLang:   "不支持翻译成 '{lang}'。",
+		RoomUsage:         "用法：/join <房间> [邀请码]",
+		RoomInvalid:       "'{room}' 不是有效的房间名。",
+		RoomAlready:       "你已经在 #{room} 中。",
+		RoomEntered:       "你现在在 #{room} 中。",
+		RoomUserJoined:    "{user} 加入了 #{room}",
+		RoomUserLeft:      "{user} 离开了 #{room}",
+		RoomPrivate:       "#{room} 是私有房间，需要邀请才能加入。",
+		InviteInvalid:     "邀请无效、已过期或已用完。",
+		SubscribeUsage:    "用法：/subscribe <房间> [邀请码] 或 /unsubscribe <房间>",
+		Subscribed:        "你现在也会收到 #{room} 的消息。",
+		Unsubscribed:      "你不再收到 #{room} 的消息。",
+		NotSubscribed:     "你没有订阅 #{room}。",
+		SubscribeCurrent:  "#{room} 是你的当前房间，用 /join 进入其他房间即可离开。",
+		SubscribeTooMany:  "一个连接最多订阅 {max} 个房间。",
+		FilterTooMany:     "过滤最多指定 {max} 个房间，未生效。",
+		RoomElsewhere:     "#{room} 由另一台服务器（{node}）负责，请连接到该服务器后加入。",
+		RoomMovedAway:     "#{room} 已迁移到另一台服务器（{node}），本连接稍后将被关闭，请重新连接后继续聊天。",
+
+		QuotaTenantMessages: "{tenant} 今天的 {max} 条消息额度已用完。",
+		QuotaTenantStorage:  "{tenant} 的 {max} 字节存储额度已用完。",
+		QuotaTooManyRooms:   "{tenant} 最多同时在 {max} 个房间中。",
+		QuotaRoomMessages:   "#{room} 今天的消息已达上限 {max} 条。",
+		QuotaRoomStorage:    "#{room} 的 {max} 字节存储额度已用完。",
+		QuotaRoomFull:       "#{room} 已满（{max} 人）。",
+
+		PluginRejected:  "消息未发送：{reason}",
+		PluginReply:     "{text}",
+		PluginFailed:    "/{command} 执行失败，请稍后重试。",
+		ChatOpsNotHere:  "/{command} 不能在 #{room} 中使用。",
+		AssistantFailed: "AI 助手暂时无法回答，请稍后重试。",
+		SummarizeUsage:  "用法：/summarize [last <条数> | since <2h、14:30 或 RFC 3339 时间>]",
+		SummarizeOff:    "此服务器未启用摘要功能。",
+		Summarizing:     "正在生成摘要，结果只有你能看到……",
+		SummarizeEmpty:  "没有可以摘要的消息。",
+		SummarizeFailed: "生成摘要失败，请稍后重试。",
+		EphemeralPM:     "临时消息不能同时是私信。",
+		EphemeralAbsent: "'{user}' 不在 #{room}，消息未送达。",
+		ScriptDropped:   "消息未发送：{reason}",
+		SlowMode:        "慢速模式：每 {seconds} 秒只能发送一条消息。",
+		BlockRejected:   "消息未发送：包含屏蔽词。",
+		BlockFlagged:    "{user} 在 #{room} 发送的消息命中屏蔽词：{text}",
+		Quarantined:     "上传的文件 {name}（{size} 字节）已被隔离：{threat}",
+		Announcement:    "公告：{text}",
+		FeatureDisabled: "此处已关闭 {feature} 功能。",
+
+		SettingsShow:       "#{room} 的设置：语言 {language}，NSFW {nsfw}，发言 {post}，附件 {attachments}，广播 {broadcast}，发布者 {publishers}",
+		SettingsChanged:    "{user} 修改了 #{room} 的设置：语言 {language}，NSFW {nsfw}，发言 {post}，附件 {attachments}，广播 {broadcast}，发布者 {publishers}",
+		SettingsUsage:      "用法：/settings [language <语言>|off、nsfw on|off、post member|moderator|owner、attachments all|media|none、broadcast on|off、publishers <用户,...>|none]",
+		SettingsDenied:     "只有 #{room} 的管理员可以修改房间设置。",
+		SettingsBadValue:   "'{value}' 不是 {key} 的有效值。",
+		SettingsPostRole:   "只有 {role} 及以上角色可以在 #{room} 发言。",
+		SettingsAttachment: "#{room} 不允许 {kind} 类型的附件。",
+		SettingsNSFW:       "#{room} 已标记为 NSFW（含成人内容）。",
+		SettingsBroadcast:  "#{room} 是广播房间，只有发布者可以发言。",
+		SessionsList:       "你有 {count} 个会话，* 为当前会话：\n{list}",
+		LoggedOutOthers:    "已退出其他 {count} 个会话。",
+
+		BackfillIncomplete: "部分较早的消息无法恢复",
+		NotConnected:       "未连接到聊天服务器",
+		SignalNoRecipient:  "信令缺少接收者",
+		SignalUnknown:      "未知的信令类型",
+		SignalFailed:       "信令发送失败",
+		AlreadyJoined:      "已经加入聊天",
+		WaitingForServer:   "正在等待聊天服务器...",
+		ServerUnavailable:  "聊天服务器不可用，请稍后再试",
+		ConnectFailed:      "连接聊天服务器失败",
+		JoinFailed:         "加入聊天失败",
+		RateLimited:        "发送消息过于频繁",
+		CodeMissing:        "代码消息缺少代码",
+		AttachmentUnknown:  "未知的附件",
+		SendFailed:         "消息发送失败",
+		Reconnecting:       "与聊天服务器的连接已断开，正在重连...",
+		Reconnected:        "已重新连接到聊天服务器",
+		AuthRequired:       "加入聊天需要令牌",
+		AuthFailed:         "令牌无效",
+		AuthUserMismatch:   "用户名与令牌不符",
+		UsernameRequired:   "请输入用户名",
+		JoinDenied:         "聊天服务器拒绝了你的加入",
+		TooManyStreams:     "连接过多，请稍后再试",
+		MessageTooLarge:    "消息过长（最多 {max} 字节）",
+		SessionRevoked:     "此会话已被退出登录",
+		Banned:             "你已被封禁：{reason}",
+		BannedUntil:        "你已被封禁至 {until}：{reason}",
+		ChallengeFailed:    "验证未通过，请重试",
+	},
+}
+
+// Locales lists the locales with a catalog
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Match returns the catalog locale for a tag such as "zh-CN" or the POSIX
+// form "zh_CN.UTF-8", falling back to DefaultLocale
+func Match(locale string) string {
+	locale, _, _ = strings.Cut(strings.ToLower(locale), ".")
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if _, ok := catalogs[locale]; ok {
+		return locale
+	}
+	primary, _, _ := strings.Cut(locale, "-")
+	if _, ok := catalogs[primary]; ok {
+		return primary
+	}
+	return DefaultLocale
+}
+
+// Catalog returns a copy of the texts for locale, see Match
+func Catalog(locale string) map[string]string {
+	src := catalogs[Match(locale)]
+	out := make(map[string]string, len(src))
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
+
+// Render formats key in locale, replacing {name} placeholders with args.
+// Keys missing from the locale use the English text, unknown keys render
+// as the key itself.
+func Render(locale, key string, args map[string]string) string {
+	text, ok := catalogs[Match(locale)][key]
+	if !ok {
+		if text, ok = catalogs[DefaultLocale][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(args))
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Args builds an argument map from name/value pairs
+func Args(kv ...string) map[string]string {
+	if len(kv) == 0 {
+		return nil
+	}
+	args := make(map[string]string, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		args[kv[i]] = kv[i+1]
+	}
+	return args
+}
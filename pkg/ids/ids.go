@@ -0,0 +1,158 @@
+// Package ids generates the identifiers of messages, sessions and
+// attachments. IDs sort by creation time as plain strings and stay unique
+// across server instances: ULIDs through 80 random bits, Snowflakes
+// through a node ID given to each instance. Random IDs do neither, they
+// are for names that must not be guessed.
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Generator creates unique IDs, safe for concurrent use
+type Generator interface {
+	New() string
+}
+
+// crockford is the base32 alphabet of ULIDs, without I, L, O and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID generates 26 character ULIDs: a millisecond timestamp followed by
+// random bits, incremented instead of redrawn within one millisecond so
+// IDs from one generator are strictly increasing
+type ULID struct {
+	mu     sync.Mutex
+	last   uint64 // timestamp of the previous ID
+	hi, lo uint64 // its random bits, 16 high and 64 low
+}
+
+// NewULID creates a ULID generator
+func NewULID() *ULID {
+	return &ULID{}
+}
+
+// New implements Generator
+func (g *ULID) New() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= g.last {
+		// same millisecond, or the clock went back
+		ms = g.last
+		g.lo++
+		if g.lo == 0 {
+			g.hi = (g.hi + 1) & 0xffff
+		}
+	} else {
+		var b [10]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			panic("ids: reading random bits: " + err.Error())
+		}
+		g.hi = uint64(binary.BigEndian.Uint16(b[:2]))
+		g.lo = binary.BigEndian.Uint64(b[2:])
+		g.last = ms
+	}
+	return encodeULID(ms, g.hi, g.lo)
+}
+
+// encodeULID writes the 48 bit timestamp and 80 random bits as 26
+// base32 digits, the first carrying only 3 bits
+func encodeULID(ms, hi, lo uint64) string {
+	var out [26]byte
+	// 128 bits: ms (48) | hi (16) | lo (64), read 5 bits at a time from
+	// the least significant end
+	top := ms<<16 | hi // the high 64 bits
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | top<<59
+		top >>= 5
+	}
+	return string(out[:])
+}
+
+// Snowflake layout: 41 bits of milliseconds since SnowflakeEpoch, 10 bits
+// of node and 12 bits of sequence
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	// MaxNode is the largest node ID of a Snowflake generator
+	MaxNode = 1<<nodeBits - 1
+)
+
+// SnowflakeEpoch is the zero time of Snowflake timestamps
+var SnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake generates 13 character Snowflake IDs. Every instance needs
+// its own node ID, at most 4096 IDs are made per millisecond and more
+// wait for the next one.
+type Snowflake struct {
+	mu   sync.Mutex
+	node uint64
+	last int64 // milliseconds since SnowflakeEpoch of the previous ID
+	seq  uint64
+}
+
+// NewSnowflake creates a Snowflake generator for node, 0 to MaxNode
+func NewSnowflake(node int) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, fmt.Errorf("ids: node %d is not between 0 and %d", node, MaxNode)
+	}
+	return &Snowflake{node: uint64(node)}, nil
+}
+
+// New implements Generator
+func (g *Snowflake) New() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := time.Since(SnowflakeEpoch).Milliseconds()
+	if ms < g.last {
+		ms = g.last // the clock went back, keep counting from where we were
+	}
+	if ms == g.last {
+		g.seq = (g.seq + 1) & (1<<sequenceBits - 1)
+		if g.seq == 0 {
+			// sequence used up, wait for the next millisecond
+			for ms <= g.last {
+				time.Sleep(100 * time.Microsecond)
+				ms = time.Since(SnowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		g.seq = 0
+	}
+	g.last = ms
+
+	id := uint64(ms)<<(nodeBits+sequenceBits) | g.node<<sequenceBits | g.seq
+	var out [13]byte
+	for i := 12; i >= 0; i-- {
+		out[i] = crockford[id&31]
+		id >>= 5
+	}
+	return string(out[:])
+}
+
+// Random generates IDs of 128 random bits as 32 hex digits, for IDs that
+// are the only key to what they name. ULIDs are no good for that: the
+// IDs of one millisecond count up from each other.
+type Random struct{}
+
+// NewRandom creates a Random generator
+func NewRandom() Random {
+	return Random{}
+}
+
+// New implements Generator
+func (Random) New() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("ids: reading random bits: " + err.Error())
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,175 @@
+package ids
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEncodeULID(t *testing.T) {
+	tests := []struct {
+		ms, hi, lo uint64
+		want       string
+	}{
+		{0, 0, 0, "00000000000000000000000000"},
+		{1, 0, 0, "00000000010000000000000000"},
+		{0, 0, 1, "00000000000000000000000001"},
+		{0, 0, 31, "0000000000000000000000000Z"},
+		{0, 0, 32, "00000000000000000000000010"},
+		{0, 1, 0, "0000000000000G000000000000"}, // bit 64
+		{1<<48 - 1, 0xffff, 1<<64 - 1, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+		{1469918176385, 0, 0, "01ARYZ6S410000000000000000"}, // the example of the ULID spec
+	}
+	for _, tt := range tests {
+		if got := encodeULID(tt.ms, tt.hi, tt.lo); got != tt.want {
+			t.Errorf("encodeULID(%d, %#x, %#x) = %s, want %s", tt.ms, tt.hi, tt.lo, got, tt.want)
+		}
+	}
+}
+
+// decodeTime reads the millisecond timestamp of a ULID
+func decodeTime(t *testing.T, id string) time.Time {
+	var ms uint64
+	for _, c := range id[:10] {
+		i := strings.IndexRune(crockford, c)
+		if i < 0 {
+			t.Fatalf("%s: %q is not a base32 digit", id, c)
+		}
+		ms = ms<<5 | uint64(i)
+	}
+	return time.UnixMilli(int64(ms))
+}
+
+func TestULIDOrder(t *testing.T) {
+	g := NewULID()
+	before := time.Now().Truncate(time.Millisecond)
+	prev := ""
+	for range 10000 {
+		id := g.New()
+		if len(id) != 26 || strings.Trim(id, crockford) != "" {
+			t.Fatalf("malformed ULID %q", id)
+		}
+		if id <= prev {
+			t.Fatalf("%s after %s is not increasing", id, prev)
+		}
+		prev = id
+	}
+	if at := decodeTime(t, prev); at.Before(before) || at.After(time.Now()) {
+		t.Errorf("%s has time %s, want about %s", prev, at, before)
+	}
+}
+
+func TestULIDCarry(t *testing.T) {
+	// a clock gone back keeps the previous timestamp and increments, the
+	// low bits carrying into the high ones
+	future := uint64(time.Now().Add(time.Hour).UnixMilli())
+	g := &ULID{last: future, hi: 0x00ff, lo: 1<<64 - 1}
+	prev := encodeULID(future, g.hi, g.lo)
+	id := g.New()
+	if id <= prev || g.hi != 0x0100 || g.lo != 0 {
+		t.Errorf("New() = %s after %s, hi %#x lo %#x", id, prev, g.hi, g.lo)
+	}
+	if decodeTime(t, id).UnixMilli() != int64(future) {
+		t.Errorf("%s lost the timestamp of the previous ID", id)
+	}
+}
+
+func TestNewSnowflakeNode(t *testing.T) {
+	for _, node := range []int{-1, MaxNode + 1} {
+		if _, err := NewSnowflake(node); err == nil {
+			t.Errorf("NewSnowflake(%d) succeeded", node)
+		}
+	}
+	for _, node := range []int{0, MaxNode} {
+		if _, err := NewSnowflake(node); err != nil {
+			t.Errorf("NewSnowflake(%d): %v", node, err)
+		}
+	}
+}
+
+// decodeSnowflake returns the node and sequence of a Snowflake ID
+func decodeSnowflake(t *testing.T, id string) (node, seq uint64) {
+	var v uint64
+	for _, c := range id {
+		i := strings.IndexRune(crockford, c)
+		if i < 0 {
+			t.Fatalf("%s: %q is not a base32 digit", id, c)
+		}
+		v = v<<5 | uint64(i)
+	}
+	return v >> sequenceBits & MaxNode, v & (1<<sequenceBits - 1)
+}
+
+func TestSnowflakeOrder(t *testing.T) {
+	g, err := NewSnowflake(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := ""
+	// more than one millisecond's worth of sequence numbers
+	for range 10000 {
+		id := g.New()
+		if len(id) != 13 {
+			t.Fatalf("malformed Snowflake %q", id)
+		}
+		if id <= prev {
+			t.Fatalf("%s after %s is not increasing", id, prev)
+		}
+		if node, _ := decodeSnowflake(t, id); node != 42 {
+			t.Fatalf("%s has node %d, want 42", id, node)
+		}
+		prev = id
+	}
+}
+
+func TestUniqueAcrossGenerators(t *testing.T) {
+	a, _ := NewSnowflake(1)
+	b, _ := NewSnowflake(2)
+	gens := []Generator{NewULID(), NewULID(), a, b, NewRandom()}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for _, g := range gens {
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ids := make([]string, 2000)
+				for i := range ids {
+					ids[i] = g.New()
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, id := range ids {
+					if seen[id] {
+						t.Errorf("duplicate ID %s", id)
+					}
+					seen[id] = true
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestRandom(t *testing.T) {
+	g := NewRandom()
+	a, b := g.New(), g.New()
+	for _, id := range []string{a, b} {
+		if len(id) != 32 || strings.Trim(id, "0123456789abcdef") != "" {
+			t.Fatalf("%s is not 32 hex digits", id)
+		}
+	}
+	// 128 random bits leave two IDs in a row far apart, unlike ULIDs
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	if same > 16 {
+		t.Errorf("%s and %s share %d digits", a, b, same)
+	}
+}
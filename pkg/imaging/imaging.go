@@ -0,0 +1,224 @@
+// Package imaging prepares uploaded images for sharing: it strips EXIF
+// and other metadata that may reveal where and with what a photo was
+// taken, and renders thumbnails so clients can show previews without
+// downloading the original.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultThumbnailSizes are the bounding boxes, in pixels, thumbnails are
+// rendered at unless configured otherwise
+var DefaultThumbnailSizes = []int{160, 480}
+
+// MaxPixels bounds the images decoded for thumbnails, larger ones are
+// stored stripped but without thumbnails
+const MaxPixels = 50_000_000
+
+// MaxThumbnailSize bounds a configured thumbnail size
+const MaxThumbnailSize = 2048
+
+// ErrNotImage is returned by Process for content it cannot handle
+var ErrNotImage = errors.New("imaging: not a JPEG, PNG or GIF image")
+
+// Thumbnail is an image scaled down to fit a Size by Size box
+type Thumbnail struct {
+	Size     int
+	Width    int
+	Height   int
+	MimeType string
+	Data     []byte
+}
+
+// Result is a processed image
+type Result struct {
+	Data       []byte // the image without metadata
+	MimeType   string
+	Width      int
+	Height     int
+	Thumbnails []Thumbnail // smallest first, none for sizes the image already fits
+}
+
+// ValidSizes reports whether sizes can be used as thumbnail sizes
+func ValidSizes(sizes []int) bool {
+	for _, s := range sizes {
+		if s < 16 || s > MaxThumbnailSize {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSizes reads comma separated thumbnail sizes such as "160,480", an
+// empty list disables thumbnails
+func ParseSizes(list string) ([]int, error) {
+	sizes := []int{}
+	for _, f := range strings.Split(list, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || !ValidSizes([]int{n}) {
+			return nil, fmt.Errorf("imaging: thumbnail size %q is not between 16 and %d", f, MaxThumbnailSize)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+// Process strips the metadata of a JPEG, PNG or GIF image and renders
+// its thumbnails at sizes. JPEGs turned by their EXIF orientation are
+// re-encoded upright, as the orientation goes with the metadata.
+func Process(data []byte, sizes []int) (Result, error) {
+	mimeType := http.DetectContentType(data)
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || !slices.Contains([]string{"jpeg", "png", "gif"}, format) {
+		return Result{}, ErrNotImage
+	}
+	res := Result{MimeType: mimeType, Width: cfg.Width, Height: cfg.Height}
+	orientation := 1
+	switch format {
+	case "jpeg":
+		orientation = jpegOrientation(data)
+		res.Data, err = stripJPEG(data)
+	case "png":
+		res.Data, err = stripPNG(data)
+	default:
+		res.Data = data // GIFs carry no EXIF
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return res, nil
+	}
+
+	var img image.Image
+	if orientation != 1 || len(sizes) > 0 {
+		if img, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+			return Result{}, ErrNotImage
+		}
+	}
+	if orientation != 1 {
+		img = orient(img, orientation)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 92}); err != nil {
+			return Result{}, err
+		}
+		res.Data = buf.Bytes()
+		res.Width, res.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	sizes = slices.Clone(sizes)
+	slices.Sort(sizes)
+	for _, size := range slices.Compact(sizes) {
+		if res.Width <= size && res.Height <= size {
+			break
+		}
+		th, err := thumbnail(img, size, format)
+		if err != nil {
+			return Result{}, err
+		}
+		res.Thumbnails = append(res.Thumbnails, th)
+	}
+	return res, nil
+}
+
+// thumbnail scales img to fit size, PNG for formats that may be
+// transparent and JPEG otherwise
+func thumbnail(img image.Image, size int, format string) (Thumbnail, error) {
+	b := img.Bounds()
+	w, h := size, b.Dy()*size/b.Dx()
+	if b.Dy() > b.Dx() {
+		w, h = b.Dx()*size/b.Dy(), size
+	}
+	scaled := scale(img, max(w, 1), max(h, 1))
+	th := Thumbnail{Size: size, Width: scaled.Bounds().Dx(), Height: scaled.Bounds().Dy()}
+	var buf bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		th.MimeType = "image/jpeg"
+		err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 80})
+	} else {
+		th.MimeType = "image/png"
+		err = png.Encode(&buf, scaled)
+	}
+	th.Data = buf.Bytes()
+	return th, err
+}
+
+// scale shrinks src to w by h, averaging the source pixels that fall in
+// each destination pixel
+func scale(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(b)
+		draw.Draw(rgba, b, src, b.Min, draw.Src)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		y1 = max(y1, y0+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			x1 = max(x1, x0+1)
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := rgba.RGBAAt(sx, sy)
+					r, g, bl, a = r+uint32(c.R), g+uint32(c.G), bl+uint32(c.B), a+uint32(c.A)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(bl / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// orient turns img upright for an EXIF orientation between 2 and 8
+func orient(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dx, dy := x, y
+			switch orientation {
+			case 2:
+				dx = w - 1 - x
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dy = h - 1 - y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = w-1-y, x
+			case 7:
+				dx, dy = w-1-y, h-1-x
+			case 8:
+				dx, dy = y, h-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
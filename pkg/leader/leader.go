@@ -0,0 +1,321 @@
+// Package leader elects one of several server instances to run the jobs
+// that must only run once cluster-wide, such as sweeping expired bans
+// from a shared store. Leadership is a lease in a Lock, renewed while the
+// leader lives and taken over by another instance once it expires.
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long a lease lasts without renewal, the time a
+// crashed leader's jobs stay unattended
+const DefaultTTL = 15 * time.Second
+
+// Lock keeps leases that one holder at a time can have
+type Lock interface {
+	// Acquire takes the lease on key for ttl unless another holder has
+	// it, reporting whether holder has it now. A holder that already has
+	// the lease renews it.
+	Acquire(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	// Renew extends the lease for ttl if holder still has it
+	Renew(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	// Release gives the lease up if holder has it
+	Release(ctx context.Context, key, holder string) error
+	// Holder returns who has the lease, "" when nobody does
+	Holder(ctx context.Context, key string) (string, error)
+}
+
+// Option configures an Elector
+type Option func(*Elector)
+
+// WithID names this instance in the lock, the default is the host name,
+// the process ID and a random suffix
+func WithID(id string) Option {
+	return func(e *Elector) {
+		e.id = id
+	}
+}
+
+// WithTTL replaces DefaultTTL. The leader renews every third of it and
+// steps down when it could not renew before the lease ran out.
+func WithTTL(ttl time.Duration) Option {
+	return func(e *Elector) {
+		e.ttl = ttl
+	}
+}
+
+// Stats describes the leadership as one instance sees it
+type Stats struct {
+	Name    string    `json:"name"`
+	ID      string    `json:"id"`
+	Leader  bool      `json:"leader"`           // this instance leads
+	Holder  string    `json:"holder,omitempty"` // the leader when last checked, "" when nobody leads
+	Since   time.Time `json:"since"`            // of the last change of Leader
+	Elected uint64    `json:"elected"`          // times this instance became leader
+	Lost    uint64    `json:"lost"`             // times it stopped leading other than by Close
+	Errors  uint64    `json:"errors"`           // failed lock calls
+}
+
+// Elector campaigns for the lease named by its key until Close
+type Elector struct {
+	lock Lock
+	key  string
+	id   string
+	ttl  time.Duration
+
+	mu         sync.Mutex
+	validUntil time.Time // the lease surely lasts until then, zero when not leading
+	stats      Stats
+	jobs       []func(ctx context.Context)
+	term       context.Context // of the jobs while leading, nil otherwise
+	endTerm    context.CancelFunc
+	jobsDone   sync.WaitGroup
+	onChange   []func(leader bool)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New starts campaigning for the lease key in lock
+func New(lock Lock, key string, opts ...Option) *Elector {
+	e := &Elector{lock: lock, key: key, ttl: DefaultTTL, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.id == "" {
+		e.id = defaultID()
+	}
+	if e.ttl <= 0 {
+		e.ttl = DefaultTTL
+	}
+	e.stats = Stats{Name: key, ID: e.id, Since: time.Now()}
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	go e.campaign(ctx)
+	return e
+}
+
+func defaultID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// IsLeader reports whether this instance leads, it turns false as soon
+// as the lease may have run out
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Now().Before(e.validUntil)
+}
+
+// Run calls job with a context that is cancelled when this instance
+// stops leading, each time it becomes leader
+func (e *Elector) Run(job func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.jobs = append(e.jobs, job)
+	if e.term != nil {
+		e.startJob(e.term, job)
+	}
+}
+
+// OnChange calls fn whenever this instance becomes or stops being leader
+func (e *Elector) OnChange(fn func(leader bool)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onChange = append(e.onChange, fn)
+}
+
+// Stats returns the leadership counters of this instance
+func (e *Elector) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+// Close stops the jobs, gives up the lease so another instance takes
+// over at once and stops campaigning
+func (e *Elector) Close() {
+	e.cancel()
+	<-e.done
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	defer close(e.done)
+	interval := e.ttl / 3
+	for {
+		e.round(ctx)
+		select {
+		case <-ctx.Done():
+			if e.leading() {
+				e.step(false, false)
+				rctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := e.lock.Release(rctx, e.key, e.id); err != nil {
+					log.Printf("Failed to release leadership of %s: %v", e.key, err)
+				}
+				cancel()
+			}
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (e *Elector) leading() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats.Leader
+}
+
+// round renews the lease of a leader or tries to take it
+func (e *Elector) round(ctx context.Context) {
+	leading := e.leading()
+	start := time.Now()
+	cctx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+	var ok bool
+	var err error
+	if leading {
+		ok, err = e.lock.Renew(cctx, e.key, e.id, e.ttl)
+	} else {
+		ok, err = e.lock.Acquire(cctx, e.key, e.id, e.ttl)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	holder := e.id
+	if err == nil && !ok {
+		holder, err = e.lock.Holder(cctx, e.key)
+	}
+
+	e.mu.Lock()
+	if err != nil {
+		e.stats.Errors++
+		e.mu.Unlock()
+		log.Printf("Leader election for %s: %v", e.key, err)
+		if leading && !e.IsLeader() {
+			e.step(false, true) // could not renew in time
+		}
+		return
+	}
+	e.stats.Holder = holder
+	if ok {
+		// the lease started no earlier than the call
+		e.validUntil = start.Add(e.ttl)
+	}
+	e.mu.Unlock()
+	if ok != leading {
+		e.step(ok, true)
+	}
+}
+
+// step records a change of leadership and starts or stops the jobs,
+// counted tells whether a lost lease counts in Stats.Lost
+func (e *Elector) step(leader, counted bool) {
+	e.mu.Lock()
+	e.stats.Leader, e.stats.Since = leader, time.Now()
+	if leader {
+		e.stats.Elected++
+		e.term, e.endTerm = context.WithCancel(context.Background())
+		for _, job := range e.jobs {
+			e.startJob(e.term, job)
+		}
+	} else {
+		e.validUntil = time.Time{}
+		if counted {
+			e.stats.Lost++
+		}
+		if e.term != nil {
+			e.endTerm()
+			e.term, e.endTerm = nil, nil
+		}
+	}
+	fns := e.onChange
+	e.mu.Unlock()
+	if leader {
+		log.Printf("Became leader of %s as %s", e.key, e.id)
+	} else {
+		log.Printf("No longer leader of %s", e.key)
+		e.jobsDone.Wait()
+	}
+	for _, fn := range fns {
+		fn(leader)
+	}
+}
+
+func (e *Elector) startJob(ctx context.Context, job func(ctx context.Context)) {
+	e.jobsDone.Add(1)
+	go func() {
+		defer e.jobsDone.Done()
+		job(ctx)
+	}()
+}
+
+// MemoryLock keeps leases in process memory, for a single instance or
+// instances sharing one process
+type MemoryLock struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+}
+
+type memoryLease struct {
+	holder  string
+	expires time.Time
+}
+
+// NewMemoryLock creates a MemoryLock without leases
+func NewMemoryLock() *MemoryLock {
+	return &MemoryLock{leases: make(map[string]memoryLease)}
+}
+
+// Acquire implements Lock
+func (m *MemoryLock) Acquire(_ context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.leases[key]; ok && l.holder != holder && time.Now().Before(l.expires) {
+		return false, nil
+	}
+	m.leases[key] = memoryLease{holder: holder, expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// Renew implements Lock
+func (m *MemoryLock) Renew(_ context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.leases[key]; !ok || l.holder != holder || !time.Now().Before(l.expires) {
+		return false, nil
+	}
+	m.leases[key] = memoryLease{holder: holder, expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// Release implements Lock
+func (m *MemoryLock) Release(_ context.Context, key, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leases[key].holder == holder {
+		delete(m.leases, key)
+	}
+	return nil
+}
+
+// Holder implements Lock
+func (m *MemoryLock) Holder(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.leases[key]; ok && time.Now().Before(l.expires) {
+		return l.holder, nil
+	}
+	return "", nil
+}
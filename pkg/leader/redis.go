@@ -0,0 +1,66 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"realTimeChat/pkg/redis"
+)
+
+// The scripts keep the compare and the update of a lease atomic
+const (
+	redisAcquire = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then return 1 end
+return 0`
+	redisRenew = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end
+return 0`
+	redisRelease = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end
+return 0`
+)
+
+// RedisLock keeps leases as Redis keys that expire with them
+type RedisLock struct {
+	c *redis.Client
+}
+
+// NewRedisLock creates a Lock keeping its leases in c
+func NewRedisLock(c *redis.Client) *RedisLock {
+	return &RedisLock{c: c}
+}
+
+// Acquire implements Lock
+func (r *RedisLock) Acquire(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	n, err := r.c.Int(ctx, "EVAL", redisAcquire, "1", key, holder, millis(ttl))
+	return n == 1, wrap(err)
+}
+
+// Renew implements Lock
+func (r *RedisLock) Renew(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	n, err := r.c.Int(ctx, "EVAL", redisRenew, "1", key, holder, millis(ttl))
+	return n == 1, wrap(err)
+}
+
+// Release implements Lock
+func (r *RedisLock) Release(ctx context.Context, key, holder string) error {
+	_, err := r.c.Do(ctx, "EVAL", redisRelease, "1", key, holder)
+	return wrap(err)
+}
+
+// Holder implements Lock
+func (r *RedisLock) Holder(ctx context.Context, key string) (string, error) {
+	s, err := r.c.String(ctx, "GET", key)
+	return s, wrap(err)
+}
+
+func millis(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
+
+func wrap(err error) error {
+	if err != nil {
+		return fmt.Errorf("leader: %w", err)
+	}
+	return nil
+}
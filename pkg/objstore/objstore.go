@@ -0,0 +1,268 @@
+// Package objstore keeps finished attachments, on the local disk or in an
+// S3-compatible bucket such as AWS S3 or MinIO, so several servers and
+// gateways can share them.
+//
+// Objects are written pending and marked committed once the metadata
+// that references them is stored. Pending objects left behind by a crash
+// are orphans: a bucket lifecycle rule expires them, the local store
+// removes them in Sweep.
+package objstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for keys without an object
+var ErrNotFound = errors.New("objstore: object not found")
+
+// ErrNotSupported is returned by stores that cannot presign URLs
+var ErrNotSupported = errors.New("objstore: not supported")
+
+// DefaultPresignExpiry is how long presigned download URLs stay valid
+const DefaultPresignExpiry = 15 * time.Minute
+
+// DefaultOrphanDays is how long pending objects are kept before they
+// count as orphans
+const DefaultOrphanDays = 1
+
+// Info describes a stored object
+type Info struct {
+	Size     int64
+	Modified time.Time
+}
+
+// Download describes the response to a presigned download
+type Download struct {
+	FileName    string // sent as an attachment with this name, inline when empty
+	ContentType string
+}
+
+// ObjectStore keeps objects under slash separated keys
+type ObjectStore interface {
+	// Put stores size bytes from r as a pending object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Commit marks the object as referenced so it is never cleaned up
+	Commit(ctx context.Context, key string) error
+	// Get reads an object from offset, the caller closes the reader. It
+	// is an io.ReadSeeker for stores that can seek.
+	Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (Info, error)
+	Delete(ctx context.Context, key string) error
+	// PresignGet returns a URL clients download the object from
+	// directly, or ErrNotSupported
+	PresignGet(ctx context.Context, key string, d Download) (string, error)
+}
+
+// Config selects and configures a store, it is read from JSON
+type Config struct {
+	Backend string   `json:"backend"` // "local" (default) or "s3"
+	Dir     string   `json:"dir"`     // of the local store, the caller's default when empty
+	S3      S3Config `json:"s3"`
+}
+
+// LoadConfig reads a JSON config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid storage config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks for an unknown backend and incomplete S3 settings
+func (c *Config) Validate() error {
+	switch c.Backend {
+	case "", "local":
+		return nil
+	case "s3":
+		return c.S3.validate()
+	}
+	return fmt.Errorf("backend %q is not local or s3", c.Backend)
+}
+
+// Open creates the configured store, dir is used by the local store when
+// the config names none. For S3 with ManageLifecycle it installs the
+// cleanup rules on the bucket.
+func Open(ctx context.Context, cfg *Config, dir string) (ObjectStore, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Backend != "s3" {
+		if cfg.Dir != "" {
+			dir = cfg.Dir
+		}
+		return NewLocal(dir)
+	}
+	s, err := NewS3(cfg.S3)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.S3.ManageLifecycle {
+		if err := s.PutLifecycle(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Local keeps objects as files below a directory. Pending files are
+// marked by a ".pending" file next to them.
+type Local struct {
+	dir string
+}
+
+// NewLocal creates a store in dir
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir}, nil
+}
+
+// path maps a key to its file, refusing keys that leave the directory
+func (l *Local) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || strings.HasSuffix(key, ".pending") ||
+		strings.Contains("/"+key+"/", "/../") || strings.Contains("/"+key+"/", "/./") {
+		return "", fmt.Errorf("objstore: invalid key %q", key)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put implements ObjectStore, the file appears complete or not at all
+func (l *Local) Put(_ context.Context, key string, r io.Reader, size int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".pending", nil, 0o640); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n != size {
+		err = fmt.Errorf("objstore: wrote %d of %d bytes", n, size)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Commit implements ObjectStore
+func (l *Local) Commit(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path + ".pending"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Get implements ObjectStore, the reader is an *os.File
+func (l *Local) Get(_ context.Context, key string, offset int64) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Stat implements ObjectStore
+func (l *Local) Stat(_ context.Context, key string) (Info, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return Info{}, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Info{}, ErrNotFound
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Size: info.Size(), Modified: info.ModTime()}, nil
+}
+
+// Delete implements ObjectStore, deleting a missing key is not an error
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	os.Remove(path + ".pending")
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// PresignGet implements ObjectStore, local files are served by the caller
+func (l *Local) PresignGet(context.Context, string, Download) (string, error) {
+	return "", ErrNotSupported
+}
+
+// Sweep removes pending objects older than age, the local counterpart of
+// the S3 lifecycle rules
+func (l *Local) Sweep(age time.Duration) error {
+	return filepath.WalkDir(l.dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if !strings.HasSuffix(name, ".pending") && !strings.HasPrefix(name, ".put-") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || time.Since(info.ModTime()) < age {
+			return nil
+		}
+		if strings.HasSuffix(name, ".pending") {
+			os.Remove(strings.TrimSuffix(p, ".pending"))
+		}
+		os.Remove(p)
+		return nil
+	})
+}
@@ -0,0 +1,376 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 part sizes, S3 refuses parts below 5MB except the last one
+const (
+	DefaultPartSize = 8 << 20
+	minPartSize     = 5 << 20
+)
+
+// pendingTag marks objects that are not yet committed, the lifecycle
+// rule expires objects carrying it
+const pendingTag = "realtimechat-pending=true"
+
+// S3Config configures an S3-compatible store
+type S3Config struct {
+	Endpoint        string `json:"endpoint"` // such as https://s3.us-east-1.amazonaws.com or http://localhost:9000 for MinIO
+	Region          string `json:"region"`   // us-east-1 when empty
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"`          // prepended to every key, such as "attachments/"
+	AccessKey       string `json:"accessKey"`       // $AWS_ACCESS_KEY_ID when empty
+	SecretKey       string `json:"secretKey"`       // $AWS_SECRET_ACCESS_KEY when empty
+	PathStyle       bool   `json:"pathStyle"`       // bucket in the path instead of the host name, needed by MinIO
+	PartSize        int64  `json:"partSize"`        // objects above it use a multipart upload, DefaultPartSize when 0
+	PresignSeconds  int    `json:"presignSeconds"`  // lifetime of download URLs, DefaultPresignExpiry when 0
+	ManageLifecycle bool   `json:"manageLifecycle"` // replace the bucket's lifecycle rules with the orphan cleanup rules
+	OrphanDays      int    `json:"orphanDays"`      // pending objects and unfinished multipart uploads expire after, DefaultOrphanDays when 0
+}
+
+func (c S3Config) validate() error {
+	var errs []error
+	if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("s3.endpoint: %q is not an http or https URL", c.Endpoint))
+	}
+	if c.Bucket == "" {
+		errs = append(errs, errors.New("s3.bucket is required"))
+	}
+	if c.PartSize != 0 && c.PartSize < minPartSize {
+		errs = append(errs, fmt.Errorf("s3.partSize must be at least %d bytes", minPartSize))
+	}
+	if c.PresignSeconds < 0 || c.PresignSeconds > 7*24*3600 {
+		errs = append(errs, errors.New("s3.presignSeconds must be between 0 and a week"))
+	}
+	if c.OrphanDays < 0 {
+		errs = append(errs, errors.New("s3.orphanDays cannot be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// S3 keeps objects in an S3-compatible bucket. Requests are signed with
+// Signature Version 4, pending objects carry a tag that ManageLifecycle's
+// rule expires.
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	signer   signer
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3 creates a store for the bucket in cfg
+func NewS3(cfg S3Config) (*S3, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.AccessKey == "" {
+		cfg.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if cfg.SecretKey == "" {
+		cfg.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.OrphanDays == 0 {
+		cfg.OrphanDays = DefaultOrphanDays
+	}
+	endpoint, _ := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	return &S3{
+		cfg:      cfg,
+		endpoint: endpoint,
+		signer:   signer{accessKey: cfg.AccessKey, secretKey: cfg.SecretKey, region: cfg.Region},
+		client:   &http.Client{Timeout: 5 * time.Minute},
+		now:      time.Now,
+	}, nil
+}
+
+// objectURL is the URL of key, or of the bucket when key is ""
+func (s *S3) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	path := ""
+	if key != "" {
+		path = "/" + s.cfg.Prefix + key
+	}
+	if s.cfg.PathStyle {
+		path = "/" + s.cfg.Bucket + path
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	if path == "" {
+		path = "/"
+	}
+	u.Path = path
+	u.RawPath = canonicalPath(&u)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do sends a signed request with body and returns the response, statuses
+// other than 2xx are turned into errors
+func (s *S3) do(ctx context.Context, method string, u *url.URL, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.URL = u
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.ContentLength = int64(len(body))
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	s.signer.sign(req, hashHex(body), s.now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("objstore: %w", err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return nil, fmt.Errorf("objstore: %s %s: %s: %s", method, u.Path, e.Code, e.Message)
+	}
+	return nil, fmt.Errorf("objstore: %s %s: %s", method, u.Path, resp.Status)
+}
+
+// Put implements ObjectStore, objects above the part size are sent as a
+// multipart upload
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	header := http.Header{"X-Amz-Tagging": {pendingTag}}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if size <= s.cfg.PartSize {
+		data, err := io.ReadAll(io.LimitReader(r, size+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) != size {
+			return fmt.Errorf("objstore: read %d of %d bytes", len(data), size)
+		}
+		resp, err := s.do(ctx, http.MethodPut, s.objectURL(key, nil), header, data)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	return s.putMultipart(ctx, key, r, size, header)
+}
+
+// completeUpload is the body of CompleteMultipartUpload
+type completeUpload struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []completePart `xml:"Part"`
+}
+
+type completePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// putMultipart uploads r in parts, an upload that fails is aborted
+func (s *S3) putMultipart(ctx context.Context, key string, r io.Reader, size int64, header http.Header) error {
+	resp, err := s.do(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploads": {""}}), header, nil)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("objstore: start multipart upload of %s: %v", key, err)
+	}
+	if err := s.uploadParts(ctx, key, initiated.UploadID, r, size); err != nil {
+		// best effort, the lifecycle rule aborts what is left
+		if resp, aerr := s.do(context.WithoutCancel(ctx), http.MethodDelete, s.objectURL(key, url.Values{"uploadId": {initiated.UploadID}}), nil, nil); aerr == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *S3) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, size int64) error {
+	var done completeUpload
+	buf := make([]byte, s.cfg.PartSize)
+	for sent, n := int64(0), 1; sent < size; n++ {
+		part := buf[:min(s.cfg.PartSize, size-sent)]
+		if _, err := io.ReadFull(r, part); err != nil {
+			return fmt.Errorf("objstore: read part %d: %w", n, err)
+		}
+		q := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+		resp, err := s.do(ctx, http.MethodPut, s.objectURL(key, q), nil, part)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		done.Parts = append(done.Parts, completePart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+		sent += int64(len(part))
+	}
+	body, _ := xml.Marshal(done)
+	resp, err := s.do(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploadId": {uploadID}}), http.Header{"Content-Type": {"application/xml"}}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// CompleteMultipartUpload may fail after answering 200
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if bytes.Contains(data, []byte("<Error>")) {
+		return fmt.Errorf("objstore: complete multipart upload of %s: %s", key, bytes.TrimSpace(data))
+	}
+	return nil
+}
+
+// Commit implements ObjectStore by removing the pending tag
+func (s *S3) Commit(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key, url.Values{"tagging": {""}}), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get implements ObjectStore
+func (s *S3) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	var header http.Header
+	if offset > 0 {
+		header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key, nil), header, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Stat implements ObjectStore
+func (s *S3) Stat(ctx context.Context, key string) (Info, error) {
+	resp, err := s.do(ctx, http.MethodHead, s.objectURL(key, nil), nil, nil)
+	if err != nil {
+		return Info{}, err
+	}
+	resp.Body.Close()
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Info{Size: resp.ContentLength, Modified: modified}, nil
+}
+
+// Delete implements ObjectStore
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key, nil), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PresignGet implements ObjectStore
+func (s *S3) PresignGet(_ context.Context, key string, d Download) (string, error) {
+	q := url.Values{}
+	if d.FileName != "" {
+		q.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.FileName}))
+	}
+	if d.ContentType != "" {
+		q.Set("response-content-type", d.ContentType)
+	}
+	expiry := DefaultPresignExpiry
+	if s.cfg.PresignSeconds > 0 {
+		expiry = time.Duration(s.cfg.PresignSeconds) * time.Second
+	}
+	u := s.objectURL(key, q)
+	s.signer.presign(u, expiry, s.now().UTC())
+	return u.String(), nil
+}
+
+// lifecycle is the body of PutBucketLifecycleConfiguration
+type lifecycle struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Rules   []lifecycleRule `xml:"Rule"`
+}
+
+type lifecycleRule struct {
+	ID         string          `xml:"ID"`
+	Filter     lifecycleFilter `xml:"Filter"`
+	Status     string          `xml:"Status"`
+	ExpireDays int             `xml:"Expiration>Days,omitempty"`
+	AbortAfter int             `xml:"AbortIncompleteMultipartUpload>DaysAfterInitiation,omitempty"`
+}
+
+type lifecycleFilter struct {
+	Prefix *string       `xml:"Prefix,omitempty"`
+	Tag    *lifecycleTag `xml:"Tag,omitempty"`
+	And    *struct {
+		Prefix string       `xml:"Prefix"`
+		Tag    lifecycleTag `xml:"Tag"`
+	} `xml:"And,omitempty"`
+}
+
+type lifecycleTag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// PutLifecycle replaces the bucket's lifecycle rules with two: pending
+// objects expire after OrphanDays, and multipart uploads that were never
+// completed are aborted after as long
+func (s *S3) PutLifecycle(ctx context.Context) error {
+	tagKey, tagValue, _ := strings.Cut(pendingTag, "=")
+	tag := lifecycleTag{Key: tagKey, Value: tagValue}
+	prefix := s.cfg.Prefix
+	orphans := lifecycleRule{ID: "realtimechat-orphans", Status: "Enabled", ExpireDays: s.cfg.OrphanDays}
+	if prefix == "" {
+		orphans.Filter.Tag = &tag
+	} else {
+		orphans.Filter.And = &struct {
+			Prefix string       `xml:"Prefix"`
+			Tag    lifecycleTag `xml:"Tag"`
+		}{prefix, tag}
+	}
+	uploads := lifecycleRule{ID: "realtimechat-multipart", Status: "Enabled", AbortAfter: s.cfg.OrphanDays, Filter: lifecycleFilter{Prefix: &prefix}}
+	body, _ := xml.Marshal(lifecycle{Rules: []lifecycleRule{orphans, uploads}})
+	sum := md5.Sum(body)
+	header := http.Header{"Content-Type": {"application/xml"}, "Content-Md5": {base64.StdEncoding.EncodeToString(sum[:])}}
+	resp, err := s.do(ctx, http.MethodPut, s.objectURL("", url.Values{"lifecycle": {""}}), header, body)
+	if err != nil {
+		return fmt.Errorf("objstore: set lifecycle rules of %s: %w", s.cfg.Bucket, err)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package redis is a minimal Redis client for the few commands the
+// cluster features need: leases for leader election and the registry of
+// which server has which users.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a command whose context has no deadline
+const DefaultTimeout = 10 * time.Second
+
+// Client sends commands over one connection that is redialled after a
+// failure, one command at a time
+type Client struct {
+	addr     string
+	password string
+	db       int
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// New creates a client for the Redis at rawURL, such as
+// "redis://:password@localhost:6379/0". It connects on first use.
+func New(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	if u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("redis: %q is not a redis:// URL", rawURL)
+	}
+	c := &Client{addr: u.Host}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("redis: bad database %q", db)
+		}
+	}
+	return c, nil
+}
+
+// Do sends one command and returns its reply: an int64, a string, nil
+// or a []any of those
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.dial(ctx); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+	}
+	v, err := c.roundTrip(ctx, args)
+	var re replyError
+	if err != nil && !errors.As(err, &re) {
+		// the connection is out of step with its replies
+		c.conn.Close()
+		c.conn = nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: %s: %w", args[0], err)
+	}
+	return v, nil
+}
+
+// Int returns the reply of Do as an integer
+func (c *Client) Int(ctx context.Context, args ...string) (int64, error) {
+	v, err := c.Do(ctx, args...)
+	n, _ := v.(int64)
+	return n, err
+}
+
+// String returns the reply of Do as a string, "" for nil
+func (c *Client) String(ctx context.Context, args ...string) (string, error) {
+	v, err := c.Do(ctx, args...)
+	s, _ := v.(string)
+	return s, err
+}
+
+// Strings returns the reply of Do as strings, "" for nil elements
+func (c *Client) Strings(ctx context.Context, args ...string) ([]string, error) {
+	v, err := c.Do(ctx, args...)
+	list, _ := v.([]any)
+	out := make([]string, len(list))
+	for i, e := range list {
+		out[i], _ = e.(string)
+	}
+	return out, err
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) dial(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	c.conn, c.rd = conn, bufio.NewReader(conn)
+	setup := [][]string{}
+	if c.password != "" {
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range setup {
+		if _, err := c.roundTrip(ctx, args); err != nil {
+			conn.Close()
+			c.conn = nil
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) roundTrip(ctx context.Context, args []string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	c.conn.SetDeadline(deadline)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.reply()
+}
+
+// replyError is an error reply, it leaves the connection usable
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+
+func (c *Client) reply() (any, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, replyError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err // a nil bulk string
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err // a nil array
+		}
+		out := make([]any, n)
+		for i := range out {
+			// an error element leaves the rest of the array to be read
+			if out[i], err = c.reply(); err != nil && !errors.As(err, new(replyError)) {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
@@ -0,0 +1,141 @@
+// Package shard assigns rooms to chat servers by consistent hashing, so
+// adding or removing a server only moves the rooms it gains or loses.
+package shard
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultVirtualNodes is how many points each server has on the ring,
+// enough to keep every server within about a quarter of an even share
+const DefaultVirtualNodes = 128
+
+// DefaultWatchInterval is how often Watch rereads a list of nodes
+const DefaultWatchInterval = 5 * time.Second
+
+// Ring maps keys to the nodes placed on it. A Ring is immutable, a new
+// topology is a new Ring.
+type Ring struct {
+	nodes  []string
+	points []point // sorted by hash
+}
+
+type point struct {
+	hash uint64
+	node string
+}
+
+// New places nodes on a ring with vnodes points each, 0 means
+// DefaultVirtualNodes. Duplicates and empty names are dropped.
+func New(nodes []string, vnodes int) *Ring {
+	if vnodes <= 0 {
+		vnodes = DefaultVirtualNodes
+	}
+	r := &Ring{}
+	for _, n := range nodes {
+		if n = strings.TrimSpace(n); n != "" && !slices.Contains(r.nodes, n) {
+			r.nodes = append(r.nodes, n)
+		}
+	}
+	slices.Sort(r.nodes)
+	for _, n := range r.nodes {
+		for i := range vnodes {
+			r.points = append(r.points, point{hash: hash(n + "#" + strconv.Itoa(i)), node: n})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i].hash < r.points[j].hash })
+	return r
+}
+
+// Parse reads a comma or newline separated list of nodes, lines starting
+// with # are comments
+func Parse(list string, vnodes int) *Ring {
+	var nodes []string
+	for _, line := range strings.Split(list, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		nodes = append(nodes, strings.Split(line, ",")...)
+	}
+	return New(nodes, vnodes)
+}
+
+// Load reads a list of nodes from path, see Parse
+func Load(path string, vnodes int) (*Ring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(data), vnodes), nil
+}
+
+// Watch rereads path every interval until ctx ends and calls fn with the
+// new ring whenever its nodes change. A file that cannot be read keeps
+// the last ring.
+func Watch(ctx context.Context, path string, vnodes int, interval time.Duration, last *Ring, fn func(*Ring)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		r, err := Load(path, vnodes)
+		if err != nil {
+			log.Printf("Shards: %v, keeping %v", err, last.Nodes())
+			continue
+		}
+		if !r.Equal(last) {
+			last = r
+			fn(r)
+		}
+	}
+}
+
+// Owner returns the node key belongs to, "" on an empty ring
+func (r *Ring) Owner(key string) string {
+	if r == nil || len(r.points) == 0 {
+		return ""
+	}
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].node
+}
+
+// Nodes returns the nodes on the ring, sorted
+func (r *Ring) Nodes() []string {
+	if r == nil {
+		return nil
+	}
+	return slices.Clone(r.nodes)
+}
+
+// Equal reports whether r and o have the same nodes
+func (r *Ring) Equal(o *Ring) bool {
+	return slices.Equal(r.Nodes(), o.Nodes())
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	// FNV alone clusters similar keys such as "node#1" and "node#2"
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
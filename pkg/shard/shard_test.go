@@ -0,0 +1,145 @@
+package shard
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func nodeNames(n int) []string {
+	var nodes []string
+	for i := range n {
+		nodes = append(nodes, "chat-"+strconv.Itoa(i)+":9090")
+	}
+	return nodes
+}
+
+func roomKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = "room-" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+func TestNewAndParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ring *Ring
+		want []string
+	}{
+		{"empty", New(nil, 0), nil},
+		{"dedup and trim", New([]string{" b:1", "a:1", "b:1", "", "  "}, 0), []string{"a:1", "b:1"}},
+		{"commas", Parse("b:1,a:1", 0), []string{"a:1", "b:1"}},
+		{"lines and comments", Parse("# shards\na:1\n\n  # old: z:1\nc:1, b:1\n", 0), []string{"a:1", "b:1", "c:1"}},
+	}
+	for _, tt := range tests {
+		if got := tt.ring.Nodes(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Nodes() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOwnerEmpty(t *testing.T) {
+	var nilRing *Ring
+	for _, r := range []*Ring{nilRing, New(nil, 0)} {
+		if got := r.Owner("general"); got != "" {
+			t.Errorf("Owner on an empty ring = %q", got)
+		}
+	}
+}
+
+func TestOwnerDeterministic(t *testing.T) {
+	nodes := nodeNames(5)
+	a := New(nodes, 0)
+	reversed := slices.Clone(nodes)
+	slices.Reverse(reversed)
+	b := New(reversed, 0)
+	if !a.Equal(b) {
+		t.Fatalf("%v and %v differ", a.Nodes(), b.Nodes())
+	}
+	for _, k := range roomKeys(1000) {
+		if a.Owner(k) != b.Owner(k) {
+			t.Fatalf("%s: %s and %s, the order of nodes matters", k, a.Owner(k), b.Owner(k))
+		}
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	keys := roomKeys(100000)
+	for _, n := range []int{2, 3, 5, 10} {
+		r := New(nodeNames(n), 0)
+		counts := make(map[string]int)
+		for _, k := range keys {
+			counts[r.Owner(k)]++
+		}
+		even := len(keys) / n
+		for _, node := range r.Nodes() {
+			if c := counts[node]; c < even*7/10 || c > even*13/10 {
+				t.Errorf("%d nodes: %s owns %d keys, want about %d", n, node, c, even)
+			}
+		}
+	}
+}
+
+// moved returns the keys whose owner differs between a and b
+func moved(a, b *Ring, keys []string) map[string][2]string {
+	out := make(map[string][2]string)
+	for _, k := range keys {
+		if x, y := a.Owner(k), b.Owner(k); x != y {
+			out[k] = [2]string{x, y}
+		}
+	}
+	return out
+}
+
+func TestStability(t *testing.T) {
+	keys := roomKeys(20000)
+	nodes := nodeNames(4)
+	before := New(nodes, 0)
+
+	added := New(append(slices.Clone(nodes), "chat-new:9090"), 0)
+	m := moved(before, added, keys)
+	for k, owners := range m {
+		if owners[1] != "chat-new:9090" {
+			t.Fatalf("adding a node moved %s from %s to %s", k, owners[0], owners[1])
+		}
+	}
+	if share := float64(len(m)) / float64(len(keys)); share < 0.1 || share > 0.3 {
+		t.Errorf("adding a fifth node moved %.0f%% of the keys, want about 20%%", share*100)
+	}
+
+	removed := New(nodes[1:], 0)
+	m = moved(before, removed, keys)
+	for k, owners := range m {
+		if owners[0] != nodes[0] {
+			t.Fatalf("removing %s moved %s from %s", nodes[0], k, owners[0])
+		}
+	}
+	for _, k := range keys {
+		if before.Owner(k) == nodes[0] {
+			if _, ok := m[k]; !ok {
+				t.Fatalf("%s still owned by the removed node", k)
+			}
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shards")
+	if err := os.WriteFile(path, []byte("b:1\na:1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Load(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Equal(Parse("a:1,b:1", 0)) {
+		t.Errorf("Load = %v", r.Nodes())
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing"), 0); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
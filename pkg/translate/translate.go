@@ -0,0 +1,108 @@
+// Package translate defines the Translator used for on-demand and
+// automatic message translation, with a LibreTranslate-compatible
+// provider.
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds one request to the provider
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnsupportedLanguage is returned for target languages the provider
+// does not offer
+var ErrUnsupportedLanguage = errors.New("translate: unsupported language")
+
+// Result is a translated text
+type Result struct {
+	Text       string
+	SourceLang string // detected language of the original, may be empty
+}
+
+// Translator translates text into the target language, given as a
+// BCP 47 tag such as "en" or "zh-Hans"
+type Translator interface {
+	Translate(ctx context.Context, text, target string) (Result, error)
+}
+
+// Func adapts a plain function to Translator
+type Func func(ctx context.Context, text, target string) (Result, error)
+
+// Translate calls f
+func (f Func) Translate(ctx context.Context, text, target string) (Result, error) {
+	return f(ctx, text, target)
+}
+
+var langTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+
+// ValidLang reports whether lang looks like a language tag
+func ValidLang(lang string) bool {
+	return langTag.MatchString(lang)
+}
+
+// LibreTranslate calls a LibreTranslate server, self-hosted or public
+type LibreTranslate struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewLibreTranslate creates a provider for the server at baseURL, apiKey
+// may be empty for servers that do not require one
+func NewLibreTranslate(baseURL, apiKey string) *LibreTranslate {
+	return &LibreTranslate{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Translate implements Translator
+func (l *LibreTranslate) Translate(ctx context.Context, text, target string) (Result, error) {
+	body, _ := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  "auto",
+		"target":  target,
+		"format":  "text",
+		"api_key": l.apiKey,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.baseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("translate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		TranslatedText   string `json:"translatedText"`
+		DetectedLanguage struct {
+			Language string `json:"language"`
+		} `json:"detectedLanguage"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return Result{}, fmt.Errorf("translate: decode response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(out.Error, "not supported") {
+			return Result{}, ErrUnsupportedLanguage
+		}
+		return Result{}, fmt.Errorf("translate: %s: %s", resp.Status, out.Error)
+	}
+	return Result{Text: out.TranslatedText, SourceLang: out.DetectedLanguage.Language}, nil
+}
@@ -0,0 +1,376 @@
+// Package unfurl fetches OpenGraph metadata for link previews. Requests
+// only go to public addresses, so chat users cannot make the server
+// probe its internal network.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/sync/singleflight"
+)
+
+// Defaults used by New
+const (
+	DefaultTimeout      = 5 * time.Second
+	DefaultMaxBytes     = 512 << 10
+	DefaultCacheTTL     = time.Hour
+	DefaultErrorTTL     = 5 * time.Minute
+	DefaultCacheSize    = 1024
+	DefaultMaxRedirects = 3
+)
+
+// ErrBlocked is returned for URLs that resolve to a non-public address
+var ErrBlocked = errors.New("unfurl: address not allowed")
+
+// ErrNoPreview is returned when a page has no usable metadata
+var ErrNoPreview = errors.New("unfurl: no preview metadata")
+
+// Preview is the metadata shown under a message
+type Preview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Option configures an Unfurler
+type Option func(*Unfurler)
+
+// WithTimeout bounds one fetch including redirects
+func WithTimeout(d time.Duration) Option {
+	return func(u *Unfurler) {
+		u.timeout = d
+	}
+}
+
+// WithMaxBytes limits how much of a page is read
+func WithMaxBytes(n int64) Option {
+	return func(u *Unfurler) {
+		u.maxBytes = n
+	}
+}
+
+// WithCache sets the cache size and how long results and failures are kept
+func WithCache(size int, ttl, errorTTL time.Duration) Option {
+	return func(u *Unfurler) {
+		u.cacheSize = size
+		u.ttl = ttl
+		u.errorTTL = errorTTL
+	}
+}
+
+// AllowPrivateNetworks disables the public address check, for tests and
+// intranet deployments only
+func AllowPrivateNetworks() Option {
+	return func(u *Unfurler) {
+		u.allowPrivate = true
+	}
+}
+
+// Unfurler fetches and caches link previews, it is safe for concurrent use
+type Unfurler struct {
+	timeout      time.Duration
+	maxBytes     int64
+	cacheSize    int
+	ttl          time.Duration
+	errorTTL     time.Duration
+	allowPrivate bool
+	client       *http.Client
+
+	mu     sync.Mutex
+	cache  map[string]cacheEntry
+	flight singleflight.Group
+}
+
+type cacheEntry struct {
+	preview *Preview
+	err     error
+	expires time.Time
+}
+
+// New creates an Unfurler
+func New(opts ...Option) *Unfurler {
+	u := &Unfurler{
+		timeout:   DefaultTimeout,
+		maxBytes:  DefaultMaxBytes,
+		cacheSize: DefaultCacheSize,
+		ttl:       DefaultCacheTTL,
+		errorTTL:  DefaultErrorTTL,
+		cache:     make(map[string]cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	// the check runs on the resolved address, so DNS rebinding and
+	// redirects to internal hosts are caught too
+	dialer := &net.Dialer{Timeout: u.timeout, Control: u.checkAddr}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   u.timeout,
+		ResponseHeaderTimeout: u.timeout,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	u.client = &http.Client{
+		Transport: transport,
+		Timeout:   u.timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= DefaultMaxRedirects {
+				return errors.New("unfurl: too many redirects")
+			}
+			return u.checkURL(req.URL)
+		},
+	}
+	return u
+}
+
+// Unfurl returns the preview for rawURL, serving repeated requests from
+// the cache
+func (u *Unfurler) Unfurl(ctx context.Context, rawURL string) (*Preview, error) {
+	if p, err, ok := u.cached(rawURL); ok {
+		return p, err
+	}
+	v, err, _ := u.flight.Do(rawURL, func() (interface{}, error) {
+		p, err := u.fetch(ctx, rawURL)
+		u.store(rawURL, p, err)
+		return p, err
+	})
+	p, _ := v.(*Preview)
+	return p, err
+}
+
+func (u *Unfurler) cached(key string) (*Preview, error, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	e, ok := u.cache[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, nil, false
+	}
+	return e.preview, e.err, true
+}
+
+func (u *Unfurler) store(key string, p *Preview, err error) {
+	ttl := u.ttl
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return // the caller gave up, nothing learnt about the page
+		}
+		ttl = u.errorTTL
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+	if len(u.cache) >= u.cacheSize {
+		// drop expired entries first, then arbitrary ones
+		for k, e := range u.cache {
+			if now.After(e.expires) {
+				delete(u.cache, k)
+			}
+		}
+		for k := range u.cache {
+			if len(u.cache) < u.cacheSize {
+				break
+			}
+			delete(u.cache, k)
+		}
+	}
+	u.cache[key] = cacheEntry{preview: p, err: err, expires: now.Add(ttl)}
+}
+
+// fetch downloads the page and extracts its metadata
+func (u *Unfurler) fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkURL(target); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "realTimeChat-unfurl/1.0")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unfurl: %s returned %s", rawURL, resp.Status)
+	}
+	if ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); ct != "text/html" && ct != "application/xhtml+xml" {
+		return nil, ErrNoPreview
+	}
+
+	p := parse(io.LimitReader(resp.Body, u.maxBytes), resp.Request.URL)
+	if p.Title == "" && p.Description == "" {
+		return nil, ErrNoPreview
+	}
+	p.URL = rawURL
+	return p, nil
+}
+
+// checkURL only allows http and https on their default ports
+func (u *Unfurler) checkURL(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrBlocked, target.Scheme)
+	}
+	if target.Hostname() == "" || target.User != nil {
+		return fmt.Errorf("%w: %s", ErrBlocked, target.Redacted())
+	}
+	if port := target.Port(); port != "" && port != "80" && port != "443" && !u.allowPrivate {
+		return fmt.Errorf("%w: port %s", ErrBlocked, port)
+	}
+	return nil
+}
+
+// checkAddr is the dialer hook that rejects non-public destinations
+func (u *Unfurler) checkAddr(network, address string, _ syscall.RawConn) error {
+	if u.allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrBlocked, address)
+	}
+	return nil
+}
+
+// isPublic reports whether ip is a globally routable unicast address
+func isPublic(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// reserved ranges not covered by the net.IP helpers
+var reserved = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // this network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved, includes broadcast
+		"64:ff9b::/96",  // NAT64, may map to private IPv4
+		"2001:db8::/32", // documentation
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// parse reads OpenGraph tags, falling back to <title> and the
+// description meta tag
+func parse(r io.Reader, base *url.URL) *Preview {
+	p := &Preview{}
+	var title, desc string
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finish(p, title, desc, base)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "meta":
+				key, content := metaAttrs(tok)
+				switch key {
+				case "og:title":
+					p.Title = content
+				case "og:description":
+					p.Description = content
+				case "og:image", "og:image:url":
+					if p.ImageURL == "" {
+						p.ImageURL = content
+					}
+				case "og:site_name":
+					p.SiteName = content
+				case "description":
+					desc = content
+				}
+			case "title":
+				if z.Next() == html.TextToken {
+					title = string(z.Text())
+				}
+			case "body":
+				return finish(p, title, desc, base) // metadata lives in <head>
+			}
+		}
+	}
+}
+
+func metaAttrs(tok html.Token) (key, content string) {
+	for _, a := range tok.Attr {
+		switch a.Key {
+		case "property", "name":
+			key = strings.ToLower(a.Val)
+		case "content":
+			content = a.Val
+		}
+	}
+	return key, content
+}
+
+func finish(p *Preview, title, desc string, base *url.URL) *Preview {
+	if p.Title == "" {
+		p.Title = title
+	}
+	if p.Description == "" {
+		p.Description = desc
+	}
+	p.Title = clip(p.Title, 200)
+	p.Description = clip(p.Description, 500)
+	p.SiteName = clip(p.SiteName, 100)
+	if p.ImageURL != "" {
+		img, err := base.Parse(p.ImageURL)
+		if err != nil || (img.Scheme != "http" && img.Scheme != "https") {
+			p.ImageURL = ""
+		} else {
+			p.ImageURL = img.String()
+		}
+	}
+	return p
+}
+
+// clip trims whitespace and cuts s to at most n runes
+func clip(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
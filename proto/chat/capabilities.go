@@ -0,0 +1,70 @@
+package chat
+
+import "slices"
+
+// ProtocolVersion is the version sent in Hello, raised when the stream
+// changes in ways capabilities cannot describe
+const ProtocolVersion = 1
+
+// Capabilities a client can advertise in Hello, each enables the events
+// of the listed message types
+const (
+	CapRename      = "rename"       // TYPE_RENAME
+	CapLinkPreview = "link-preview" // TYPE_LINK_PREVIEW
+	CapCalls       = "calls"        // TYPE_SIGNAL and TYPE_CALL
+	CapPresence    = "presence"     // TYPE_PRESENCE
+	CapUnread      = "unread"       // TYPE_UNREAD
+	CapAck         = "ack"          // TYPE_ACK
+	CapTranslation = "translation"  // TYPE_TRANSLATION
+	CapRoomChange  = "room-change"  // TYPE_ROOM_CHANGE
+	CapHeartbeat   = "heartbeat"    // TYPE_HEARTBEAT replies
+	CapEdit        = "edit"         // TYPE_EDIT
+	CapRoster      = "roster"       // TYPE_ROSTER
+	CapMembers     = "members"      // TYPE_MEMBER
+	CapKeywords    = "keywords"     // TYPE_KEYWORD_HIT
+	CapMultiRoom   = "multi-room"   // TYPE_SUBSCRIPTIONS
+	CapBatch       = "batch"        // TYPE_BATCH
+	CapRoomMoved   = "room-moved"   // TYPE_ROOM_MOVED
+	CapVoice       = "voice"        // TYPE_VOICE
+	CapThreads     = "threads"      // TYPE_THREAD
+)
+
+var capabilityOf = map[MessageType]string{
+	MessageType_TYPE_RENAME:        CapRename,
+	MessageType_TYPE_LINK_PREVIEW:  CapLinkPreview,
+	MessageType_TYPE_SIGNAL:        CapCalls,
+	MessageType_TYPE_CALL:          CapCalls,
+	MessageType_TYPE_PRESENCE:      CapPresence,
+	MessageType_TYPE_UNREAD:        CapUnread,
+	MessageType_TYPE_ACK:           CapAck,
+	MessageType_TYPE_TRANSLATION:   CapTranslation,
+	MessageType_TYPE_ROOM_CHANGE:   CapRoomChange,
+	MessageType_TYPE_HEARTBEAT:     CapHeartbeat,
+	MessageType_TYPE_EDIT:          CapEdit,
+	MessageType_TYPE_ROSTER:        CapRoster,
+	MessageType_TYPE_MEMBER:        CapMembers,
+	MessageType_TYPE_KEYWORD_HIT:   CapKeywords,
+	MessageType_TYPE_SUBSCRIPTIONS: CapMultiRoom,
+	MessageType_TYPE_BATCH:         CapBatch,
+	MessageType_TYPE_ROOM_MOVED:    CapRoomMoved,
+	MessageType_TYPE_VOICE:         CapVoice,
+	MessageType_TYPE_THREAD:        CapThreads,
+}
+
+// Capabilities returns every capability this version knows, sorted
+func Capabilities() []string {
+	var caps []string
+	for _, c := range capabilityOf {
+		if !slices.Contains(caps, c) {
+			caps = append(caps, c)
+		}
+	}
+	slices.Sort(caps)
+	return caps
+}
+
+// CapabilityOf returns the capability needed to receive messages of type
+// t, "" when every client understands them
+func CapabilityOf(t MessageType) string {
+	return capabilityOf[t]
+}
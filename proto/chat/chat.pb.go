@@ -21,31 +21,10128 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// 消息体
+// 消息类型，由服务器填写；旧服务器发出的消息为 TYPE_UNSPECIFIED，
+// 可按 payload 推断（Go 中使用 chat.TypeOf）
+type MessageType int32
+
+const (
+	MessageType_TYPE_UNSPECIFIED   MessageType = 0
+	MessageType_TYPE_CHAT          MessageType = 1  // 用户消息，可带 code 或 attachment
+	MessageType_TYPE_JOIN          MessageType = 2  // 用户加入聊天
+	MessageType_TYPE_LEAVE         MessageType = 3  // 用户离开聊天
+	MessageType_TYPE_SYSTEM        MessageType = 4  // 其他系统消息
+	MessageType_TYPE_TYPING        MessageType = 5  // 预留：正在输入
+	MessageType_TYPE_PRESENCE      MessageType = 6  // presence
+	MessageType_TYPE_REACTION      MessageType = 7  // 预留：表情回应
+	MessageType_TYPE_RENAME        MessageType = 8  // rename
+	MessageType_TYPE_LINK_PREVIEW  MessageType = 9  // link_preview
+	MessageType_TYPE_SIGNAL        MessageType = 10 // signal
+	MessageType_TYPE_CALL          MessageType = 11 // call_event
+	MessageType_TYPE_UNREAD        MessageType = 12 // unread
+	MessageType_TYPE_ACK           MessageType = 13 // ack
+	MessageType_TYPE_TRANSLATION   MessageType = 14 // translation
+	MessageType_TYPE_ROOM_CHANGE   MessageType = 15 // room_change
+	MessageType_TYPE_HELLO         MessageType = 16 // hello
+	MessageType_TYPE_ACTIVITY      MessageType = 17 // activity，只由客户端发送
+	MessageType_TYPE_HEARTBEAT     MessageType = 18 // heartbeat
+	MessageType_TYPE_EDIT          MessageType = 19 // edit
+	MessageType_TYPE_ROSTER        MessageType = 20 // members，在线用户全集
+	MessageType_TYPE_MEMBER        MessageType = 21 // member，房间成员变化
+	MessageType_TYPE_KEYWORD_HIT   MessageType = 22 // keyword_hit
+	MessageType_TYPE_SUBSCRIPTIONS MessageType = 23 // subscriptions
+	MessageType_TYPE_FILTER        MessageType = 24 // filter，只由客户端发送
+	MessageType_TYPE_BATCH         MessageType = 25 // batch，由服务器发出
+	MessageType_TYPE_ROOM_MOVED    MessageType = 26 // room_moved，只发给该连接
+	MessageType_TYPE_VOICE         MessageType = 27 // voice，语音频道的变化
+	MessageType_TYPE_THREAD        MessageType = 28 // thread，话题的回复数变化
+)
+
+// Enum value maps for MessageType.
+var (
+	MessageType_name = map[int32]string{
+		0:  "TYPE_UNSPECIFIED",
+		1:  "TYPE_CHAT",
+		2:  "TYPE_JOIN",
+		3:  "TYPE_LEAVE",
+		4:  "TYPE_SYSTEM",
+		5:  "TYPE_TYPING",
+		6:  "TYPE_PRESENCE",
+		7:  "TYPE_REACTION",
+		8:  "TYPE_RENAME",
+		9:  "TYPE_LINK_PREVIEW",
+		10: "TYPE_SIGNAL",
+		11: "TYPE_CALL",
+		12: "TYPE_UNREAD",
+		13: "TYPE_ACK",
+		14: "TYPE_TRANSLATION",
+		15: "TYPE_ROOM_CHANGE",
+		16: "TYPE_HELLO",
+		17: "TYPE_ACTIVITY",
+		18: "TYPE_HEARTBEAT",
+		19: "TYPE_EDIT",
+		20: "TYPE_ROSTER",
+		21: "TYPE_MEMBER",
+		22: "TYPE_KEYWORD_HIT",
+		23: "TYPE_SUBSCRIPTIONS",
+		24: "TYPE_FILTER",
+		25: "TYPE_BATCH",
+		26: "TYPE_ROOM_MOVED",
+		27: "TYPE_VOICE",
+		28: "TYPE_THREAD",
+	}
+	MessageType_value = map[string]int32{
+		"TYPE_UNSPECIFIED":   0,
+		"TYPE_CHAT":          1,
+		"TYPE_JOIN":          2,
+		"TYPE_LEAVE":         3,
+		"TYPE_SYSTEM":        4,
+		"TYPE_TYPING":        5,
+		"TYPE_PRESENCE":      6,
+		"TYPE_REACTION":      7,
+		"TYPE_RENAME":        8,
+		"TYPE_LINK_PREVIEW":  9,
+		"TYPE_SIGNAL":        10,
+		"TYPE_CALL":          11,
+		"TYPE_UNREAD":        12,
+		"TYPE_ACK":           13,
+		"TYPE_TRANSLATION":   14,
+		"TYPE_ROOM_CHANGE":   15,
+		"TYPE_HELLO":         16,
+		"TYPE_ACTIVITY":      17,
+		"TYPE_HEARTBEAT":     18,
+		"TYPE_EDIT":          19,
+		"TYPE_ROSTER":        20,
+		"TYPE_MEMBER":        21,
+		"TYPE_KEYWORD_HIT":   22,
+		"TYPE_SUBSCRIPTIONS": 23,
+		"TYPE_FILTER":        24,
+		"TYPE_BATCH":         25,
+		"TYPE_ROOM_MOVED":    26,
+		"TYPE_VOICE":         27,
+		"TYPE_THREAD":        28,
+	}
+)
+
+func (x MessageType) Enum() *MessageType {
+	p := new(MessageType)
+	*p = x
+	return p
+}
+
+func (x MessageType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MessageType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[0].Descriptor()
+}
+
+func (MessageType) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[0]
+}
+
+func (x MessageType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MessageType.Descriptor instead.
+func (MessageType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{0}
+}
+
+// 房间角色，第一个进入新房间的用户成为 owner，其他角色由管理接口设置
+type RoomRole int32
+
+const (
+	RoomRole_ROLE_MEMBER    RoomRole = 0
+	RoomRole_ROLE_MODERATOR RoomRole = 1
+	RoomRole_ROLE_OWNER     RoomRole = 2
+)
+
+// Enum value maps for RoomRole.
+var (
+	RoomRole_name = map[int32]string{
+		0: "ROLE_MEMBER",
+		1: "ROLE_MODERATOR",
+		2: "ROLE_OWNER",
+	}
+	RoomRole_value = map[string]int32{
+		"ROLE_MEMBER":    0,
+		"ROLE_MODERATOR": 1,
+		"ROLE_OWNER":     2,
+	}
+)
+
+func (x RoomRole) Enum() *RoomRole {
+	p := new(RoomRole)
+	*p = x
+	return p
+}
+
+func (x RoomRole) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RoomRole) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[1].Descriptor()
+}
+
+func (RoomRole) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[1]
+}
+
+func (x RoomRole) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RoomRole.Descriptor instead.
+func (RoomRole) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{1}
+}
+
+// 房间允许的附件
+type AttachmentPolicy int32
+
+const (
+	AttachmentPolicy_ATTACHMENTS_ALL   AttachmentPolicy = 0
+	AttachmentPolicy_ATTACHMENTS_MEDIA AttachmentPolicy = 1 // 只允许语音和 GIF，不允许文件
+	AttachmentPolicy_ATTACHMENTS_NONE  AttachmentPolicy = 2
+)
+
+// Enum value maps for AttachmentPolicy.
+var (
+	AttachmentPolicy_name = map[int32]string{
+		0: "ATTACHMENTS_ALL",
+		1: "ATTACHMENTS_MEDIA",
+		2: "ATTACHMENTS_NONE",
+	}
+	AttachmentPolicy_value = map[string]int32{
+		"ATTACHMENTS_ALL":   0,
+		"ATTACHMENTS_MEDIA": 1,
+		"ATTACHMENTS_NONE":  2,
+	}
+)
+
+func (x AttachmentPolicy) Enum() *AttachmentPolicy {
+	p := new(AttachmentPolicy)
+	*p = x
+	return p
+}
+
+func (x AttachmentPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AttachmentPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[2].Descriptor()
+}
+
+func (AttachmentPolicy) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[2]
+}
+
+func (x AttachmentPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AttachmentPolicy.Descriptor instead.
+func (AttachmentPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{2}
+}
+
+// WebRTC 信令类型
+type SignalType int32
+
+const (
+	SignalType_SIGNAL_UNKNOWN            SignalType = 0
+	SignalType_SIGNAL_OFFER              SignalType = 1
+	SignalType_SIGNAL_ANSWER             SignalType = 2
+	SignalType_SIGNAL_ICE_CANDIDATE      SignalType = 3
+	SignalType_SIGNAL_HANGUP             SignalType = 4
+	SignalType_SIGNAL_SCREEN_SHARE_START SignalType = 5 // 通话中开始共享屏幕
+	SignalType_SIGNAL_SCREEN_SHARE_STOP  SignalType = 6
+	// 语音频道，见 VoiceEvent：不需要 recipient_user 和 call_id，加入的是 ChatMessage.room
+	// （当前或已订阅的房间，空表示当前房间）的语音频道，同时只能在一个语音频道中
+	SignalType_SIGNAL_VOICE_JOIN           SignalType = 7
+	SignalType_SIGNAL_VOICE_LEAVE          SignalType = 8
+	SignalType_SIGNAL_VOICE_SPEAKING_START SignalType = 9 // 客户端检测到用户在说话时发送，服务器只转告房间，不处理音频
+	SignalType_SIGNAL_VOICE_SPEAKING_STOP  SignalType = 10
+)
+
+// Enum value maps for SignalType.
+var (
+	SignalType_name = map[int32]string{
+		0:  "SIGNAL_UNKNOWN",
+		1:  "SIGNAL_OFFER",
+		2:  "SIGNAL_ANSWER",
+		3:  "SIGNAL_ICE_CANDIDATE",
+		4:  "SIGNAL_HANGUP",
+		5:  "SIGNAL_SCREEN_SHARE_START",
+		6:  "SIGNAL_SCREEN_SHARE_STOP",
+		7:  "SIGNAL_VOICE_JOIN",
+		8:  "SIGNAL_VOICE_LEAVE",
+		9:  "SIGNAL_VOICE_SPEAKING_START",
+		10: "SIGNAL_VOICE_SPEAKING_STOP",
+	}
+	SignalType_value = map[string]int32{
+		"SIGNAL_UNKNOWN":              0,
+		"SIGNAL_OFFER":                1,
+		"SIGNAL_ANSWER":               2,
+		"SIGNAL_ICE_CANDIDATE":        3,
+		"SIGNAL_HANGUP":               4,
+		"SIGNAL_SCREEN_SHARE_START":   5,
+		"SIGNAL_SCREEN_SHARE_STOP":    6,
+		"SIGNAL_VOICE_JOIN":           7,
+		"SIGNAL_VOICE_LEAVE":          8,
+		"SIGNAL_VOICE_SPEAKING_START": 9,
+		"SIGNAL_VOICE_SPEAKING_STOP":  10,
+	}
+)
+
+func (x SignalType) Enum() *SignalType {
+	p := new(SignalType)
+	*p = x
+	return p
+}
+
+func (x SignalType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SignalType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[3].Descriptor()
+}
+
+func (SignalType) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[3]
+}
+
+func (x SignalType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SignalType.Descriptor instead.
+func (SignalType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{3}
+}
+
+type CallState int32
+
+const (
+	CallState_CALL_STATE_UNKNOWN CallState = 0
+	CallState_CALL_RINGING       CallState = 1
+	CallState_CALL_IN_CALL       CallState = 2
+	CallState_CALL_ENDED         CallState = 3
+)
+
+// Enum value maps for CallState.
+var (
+	CallState_name = map[int32]string{
+		0: "CALL_STATE_UNKNOWN",
+		1: "CALL_RINGING",
+		2: "CALL_IN_CALL",
+		3: "CALL_ENDED",
+	}
+	CallState_value = map[string]int32{
+		"CALL_STATE_UNKNOWN": 0,
+		"CALL_RINGING":       1,
+		"CALL_IN_CALL":       2,
+		"CALL_ENDED":         3,
+	}
+)
+
+func (x CallState) Enum() *CallState {
+	p := new(CallState)
+	*p = x
+	return p
+}
+
+func (x CallState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CallState) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[4].Descriptor()
+}
+
+func (CallState) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[4]
+}
+
+func (x CallState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CallState.Descriptor instead.
+func (CallState) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{4}
+}
+
+type VoiceAction int32
+
+const (
+	VoiceAction_VOICE_JOINED   VoiceAction = 0
+	VoiceAction_VOICE_LEFT     VoiceAction = 1 // 主动离开、离开房间或断开连接
+	VoiceAction_VOICE_SPEAKING VoiceAction = 2
+	VoiceAction_VOICE_SILENT   VoiceAction = 3
+)
+
+// Enum value maps for VoiceAction.
+var (
+	VoiceAction_name = map[int32]string{
+		0: "VOICE_JOINED",
+		1: "VOICE_LEFT",
+		2: "VOICE_SPEAKING",
+		3: "VOICE_SILENT",
+	}
+	VoiceAction_value = map[string]int32{
+		"VOICE_JOINED":   0,
+		"VOICE_LEFT":     1,
+		"VOICE_SPEAKING": 2,
+		"VOICE_SILENT":   3,
+	}
+)
+
+func (x VoiceAction) Enum() *VoiceAction {
+	p := new(VoiceAction)
+	*p = x
+	return p
+}
+
+func (x VoiceAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (VoiceAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[5].Descriptor()
+}
+
+func (VoiceAction) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[5]
+}
+
+func (x VoiceAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use VoiceAction.Descriptor instead.
+func (VoiceAction) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{5}
+}
+
+// 在线状态，通话与屏幕共享由信令驱动
+type PresenceStatus int32
+
+const (
+	PresenceStatus_PRESENCE_AVAILABLE      PresenceStatus = 0
+	PresenceStatus_PRESENCE_IN_CALL        PresenceStatus = 1
+	PresenceStatus_PRESENCE_SHARING_SCREEN PresenceStatus = 2
+	PresenceStatus_PRESENCE_AWAY           PresenceStatus = 3 // 所有连接都空闲，通话中的状态优先
+)
+
+// Enum value maps for PresenceStatus.
+var (
+	PresenceStatus_name = map[int32]string{
+		0: "PRESENCE_AVAILABLE",
+		1: "PRESENCE_IN_CALL",
+		2: "PRESENCE_SHARING_SCREEN",
+		3: "PRESENCE_AWAY",
+	}
+	PresenceStatus_value = map[string]int32{
+		"PRESENCE_AVAILABLE":      0,
+		"PRESENCE_IN_CALL":        1,
+		"PRESENCE_SHARING_SCREEN": 2,
+		"PRESENCE_AWAY":           3,
+	}
+)
+
+func (x PresenceStatus) Enum() *PresenceStatus {
+	p := new(PresenceStatus)
+	*p = x
+	return p
+}
+
+func (x PresenceStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PresenceStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[6].Descriptor()
+}
+
+func (PresenceStatus) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[6]
+}
+
+func (x PresenceStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PresenceStatus.Descriptor instead.
+func (PresenceStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{6}
+}
+
+// 房间的通知级别
+type NotifyLevel int32
+
+const (
+	NotifyLevel_NOTIFY_DEFAULT  NotifyLevel = 0 // 未设置，等同于 NOTIFY_MENTIONS
+	NotifyLevel_NOTIFY_ALL      NotifyLevel = 1 // 每条消息都提醒
+	NotifyLevel_NOTIFY_MENTIONS NotifyLevel = 2 // 仅 @提及 时提醒
+	NotifyLevel_NOTIFY_MUTED    NotifyLevel = 3 // 不提醒
+)
+
+// Enum value maps for NotifyLevel.
+var (
+	NotifyLevel_name = map[int32]string{
+		0: "NOTIFY_DEFAULT",
+		1: "NOTIFY_ALL",
+		2: "NOTIFY_MENTIONS",
+		3: "NOTIFY_MUTED",
+	}
+	NotifyLevel_value = map[string]int32{
+		"NOTIFY_DEFAULT":  0,
+		"NOTIFY_ALL":      1,
+		"NOTIFY_MENTIONS": 2,
+		"NOTIFY_MUTED":    3,
+	}
+)
+
+func (x NotifyLevel) Enum() *NotifyLevel {
+	p := new(NotifyLevel)
+	*p = x
+	return p
+}
+
+func (x NotifyLevel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NotifyLevel) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[7].Descriptor()
+}
+
+func (NotifyLevel) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[7]
+}
+
+func (x NotifyLevel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NotifyLevel.Descriptor instead.
+func (NotifyLevel) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{7}
+}
+
+// 配额的作用范围。租户由服务器按用户名划分，未配置时所有用户属于 default 租户
+type QuotaScope int32
+
+const (
+	QuotaScope_QUOTA_TENANT QuotaScope = 0
+	QuotaScope_QUOTA_ROOM   QuotaScope = 1
+)
+
+// Enum value maps for QuotaScope.
+var (
+	QuotaScope_name = map[int32]string{
+		0: "QUOTA_TENANT",
+		1: "QUOTA_ROOM",
+	}
+	QuotaScope_value = map[string]int32{
+		"QUOTA_TENANT": 0,
+		"QUOTA_ROOM":   1,
+	}
+)
+
+func (x QuotaScope) Enum() *QuotaScope {
+	p := new(QuotaScope)
+	*p = x
+	return p
+}
+
+func (x QuotaScope) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QuotaScope) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[8].Descriptor()
+}
+
+func (QuotaScope) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[8]
+}
+
+func (x QuotaScope) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QuotaScope.Descriptor instead.
+func (QuotaScope) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{8}
+}
+
+type BanScope int32
+
+const (
+	BanScope_BAN_ACCOUNT BanScope = 0 // target 为用户名
+	BanScope_BAN_IP      BanScope = 1 // target 为 IP 地址或 CIDR，经网关的连接按 x-forwarded-for 判断
+)
+
+// Enum value maps for BanScope.
+var (
+	BanScope_name = map[int32]string{
+		0: "BAN_ACCOUNT",
+		1: "BAN_IP",
+	}
+	BanScope_value = map[string]int32{
+		"BAN_ACCOUNT": 0,
+		"BAN_IP":      1,
+	}
+)
+
+func (x BanScope) Enum() *BanScope {
+	p := new(BanScope)
+	*p = x
+	return p
+}
+
+func (x BanScope) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BanScope) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[9].Descriptor()
+}
+
+func (BanScope) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[9]
+}
+
+func (x BanScope) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BanScope.Descriptor instead.
+func (BanScope) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{9}
+}
+
+type BlockAction int32
+
+const (
+	BlockAction_BLOCK_MASK   BlockAction = 0 // 用 * 替换匹配的文字后照常发送
+	BlockAction_BLOCK_REJECT BlockAction = 1 // 拒绝发送并提示发送者
+	BlockAction_BLOCK_FLAG   BlockAction = 2 // 照常发送，并通知房间的管理员
+	BlockAction_BLOCK_ALLOW  BlockAction = 3 // 在房间中关闭同一 pattern 的全局规则
+)
+
+// Enum value maps for BlockAction.
+var (
+	BlockAction_name = map[int32]string{
+		0: "BLOCK_MASK",
+		1: "BLOCK_REJECT",
+		2: "BLOCK_FLAG",
+		3: "BLOCK_ALLOW",
+	}
+	BlockAction_value = map[string]int32{
+		"BLOCK_MASK":   0,
+		"BLOCK_REJECT": 1,
+		"BLOCK_FLAG":   2,
+		"BLOCK_ALLOW":  3,
+	}
+)
+
+func (x BlockAction) Enum() *BlockAction {
+	p := new(BlockAction)
+	*p = x
+	return p
+}
+
+func (x BlockAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BlockAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[10].Descriptor()
+}
+
+func (BlockAction) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[10]
+}
+
+func (x BlockAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BlockAction.Descriptor instead.
+func (BlockAction) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{10}
+}
+
+type PluginHook int32
+
+const (
+	PluginHook_HOOK_UNSPECIFIED PluginHook = 0
+	PluginHook_HOOK_FILTER      PluginHook = 1 // FilterMessage
+	PluginHook_HOOK_DELIVERED   PluginHook = 2 // MessageDelivered
+	PluginHook_HOOK_JOIN        PluginHook = 3 // UserJoining
+	PluginHook_HOOK_COMMAND     PluginHook = 4 // HandleCommand
+)
+
+// Enum value maps for PluginHook.
+var (
+	PluginHook_name = map[int32]string{
+		0: "HOOK_UNSPECIFIED",
+		1: "HOOK_FILTER",
+		2: "HOOK_DELIVERED",
+		3: "HOOK_JOIN",
+		4: "HOOK_COMMAND",
+	}
+	PluginHook_value = map[string]int32{
+		"HOOK_UNSPECIFIED": 0,
+		"HOOK_FILTER":      1,
+		"HOOK_DELIVERED":   2,
+		"HOOK_JOIN":        3,
+		"HOOK_COMMAND":     4,
+	}
+)
+
+func (x PluginHook) Enum() *PluginHook {
+	p := new(PluginHook)
+	*p = x
+	return p
+}
+
+func (x PluginHook) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PluginHook) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[11].Descriptor()
+}
+
+func (PluginHook) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[11]
+}
+
+func (x PluginHook) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PluginHook.Descriptor instead.
+func (PluginHook) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{11}
+}
+
+type ModerationKind int32
+
+const (
+	ModerationKind_MODERATION_FLAGGED    ModerationKind = 0 // 命中 BLOCK_FLAG 规则的房间消息
+	ModerationKind_MODERATION_QUARANTINE ModerationKind = 1 // 病毒扫描隔离的附件
+)
+
+// Enum value maps for ModerationKind.
+var (
+	ModerationKind_name = map[int32]string{
+		0: "MODERATION_FLAGGED",
+		1: "MODERATION_QUARANTINE",
+	}
+	ModerationKind_value = map[string]int32{
+		"MODERATION_FLAGGED":    0,
+		"MODERATION_QUARANTINE": 1,
+	}
+)
+
+func (x ModerationKind) Enum() *ModerationKind {
+	p := new(ModerationKind)
+	*p = x
+	return p
+}
+
+func (x ModerationKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ModerationKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_chat_proto_enumTypes[12].Descriptor()
+}
+
+func (ModerationKind) Type() protoreflect.EnumType {
+	return &file_proto_chat_chat_proto_enumTypes[12]
+}
+
+func (x ModerationKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ModerationKind.Descriptor instead.
+func (ModerationKind) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{12}
+}
+
+// 消息体，payload 中至多一项非空，system 是文本的渲染方式，可与事件同时出现
 type ChatMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`                                        // 发送消息的用户名
-	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`                                        // 消息内容
-	RecipientUser string                 `protobuf:"bytes,3,opt,name=recipient_user,json=recipientUser,proto3" json:"recipient_user,omitempty"` // 接收消息的用户名，空表示广播
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`                                        // 发送消息的用户名
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`                                        // 消息内容
+	RecipientUser string                 `protobuf:"bytes,3,opt,name=recipient_user,json=recipientUser,proto3" json:"recipient_user,omitempty"` // 接收消息的用户名，空表示广播
+	Notify        bool                   `protobuf:"varint,5,opt,name=notify,proto3" json:"notify,omitempty"`                                   // 服务器根据接收者的通知偏好判定需要提醒
+	Id            string                 `protobuf:"bytes,6,opt,name=id,proto3" json:"id,omitempty"`                                            // 服务器分配的消息 ID
+	Room          string                 `protobuf:"bytes,13,opt,name=room,proto3" json:"room,omitempty"`                                       // 公共消息所在房间，由服务器填写
+	Seq           uint64                 `protobuf:"varint,14,opt,name=seq,proto3" json:"seq,omitempty"`                                        // 房间内递增的序号，由服务器分配
+	ClientMsgId   string                 `protobuf:"bytes,16,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`    // 客户端生成的幂等键，重试时保持不变
+	System        *SystemText            `protobuf:"bytes,19,opt,name=system,proto3" json:"system,omitempty"`                                   // 系统消息的文案键和参数，客户端按用户语言渲染，text 为英文文本
+	Timestamp     int64                  `protobuf:"varint,20,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                            // 服务器接收消息的时间，UTC Unix 毫秒，客户端按本地时区显示
+	Type          MessageType            `protobuf:"varint,22,opt,name=type,proto3,enum=chat.MessageType" json:"type,omitempty"`
+	// 扩展数据，如工单 ID、trace ID，服务器原样转发和保存；
+	// 键为字母数字开头的 [A-Za-z0-9_.-/]，最长 64 字节，大小受服务器限制
+	Metadata map[string]string `protobuf:"bytes,24,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// 临时消息的接收者：只投递给该用户，不保存、不分配序号也不进入历史，发送者
+	// 不会收到副本，与私信不同。服务器的命令结果、校验错误和摘要只发给请求的连接；
+	// 客户端发送时接收者须在发送者所在的房间
+	EphemeralTo string `protobuf:"bytes,28,opt,name=ephemeral_to,json=ephemeralTo,proto3" json:"ephemeral_to,omitempty"`
+	// 话题回复：所回复的根消息的 id，根消息须是同一房间的公共消息且本身不是回复。
+	// 回复不分配序号、不进入房间历史，只发给话题的关注者，见 ThreadService
+	ThreadId string `protobuf:"bytes,37,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ChatMessage_Rename
+	//	*ChatMessage_LinkPreview
+	//	*ChatMessage_Code
+	//	*ChatMessage_Attachment
+	//	*ChatMessage_Signal
+	//	*ChatMessage_CallEvent
+	//	*ChatMessage_Presence
+	//	*ChatMessage_Unread
+	//	*ChatMessage_Ack
+	//	*ChatMessage_Translation
+	//	*ChatMessage_RoomChange
+	//	*ChatMessage_Hello
+	//	*ChatMessage_Activity
+	//	*ChatMessage_Heartbeat
+	//	*ChatMessage_Edit
+	//	*ChatMessage_Members
+	//	*ChatMessage_Member
+	//	*ChatMessage_KeywordHit
+	//	*ChatMessage_Subscriptions
+	//	*ChatMessage_Filter
+	//	*ChatMessage_Batch
+	//	*ChatMessage_RoomMoved
+	//	*ChatMessage_Voice
+	//	*ChatMessage_Thread
+	Payload       isChatMessage_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_proto_chat_chat_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChatMessage) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetRecipientUser() string {
+	if x != nil {
+		return x.RecipientUser
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetNotify() bool {
+	if x != nil {
+		return x.Notify
+	}
+	return false
+}
+
+func (x *ChatMessage) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetSystem() *SystemText {
+	if x != nil {
+		return x.System
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetType() MessageType {
+	if x != nil {
+		return x.Type
+	}
+	return MessageType_TYPE_UNSPECIFIED
+}
+
+func (x *ChatMessage) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetEphemeralTo() string {
+	if x != nil {
+		return x.EphemeralTo
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetPayload() isChatMessage_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetRename() *Rename {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Rename); ok {
+			return x.Rename
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetLinkPreview() *LinkPreview {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_LinkPreview); ok {
+			return x.LinkPreview
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetCode() *Code {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Code); ok {
+			return x.Code
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetAttachment() *Attachment {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Attachment); ok {
+			return x.Attachment
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetSignal() *Signal {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Signal); ok {
+			return x.Signal
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetCallEvent() *CallEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_CallEvent); ok {
+			return x.CallEvent
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetPresence() *Presence {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Presence); ok {
+			return x.Presence
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetUnread() *UnreadCounts {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Unread); ok {
+			return x.Unread
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetTranslation() *Translation {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Translation); ok {
+			return x.Translation
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetRoomChange() *RoomChange {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_RoomChange); ok {
+			return x.RoomChange
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetHello() *Hello {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetActivity() *Activity {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Activity); ok {
+			return x.Activity
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetHeartbeat() *Heartbeat {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetEdit() *MessageEdit {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Edit); ok {
+			return x.Edit
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetMembers() *Members {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Members); ok {
+			return x.Members
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetMember() *RoomMember {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Member); ok {
+			return x.Member
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetKeywordHit() *KeywordHit {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_KeywordHit); ok {
+			return x.KeywordHit
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetSubscriptions() *Subscriptions {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Subscriptions); ok {
+			return x.Subscriptions
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetFilter() *StreamFilter {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Filter); ok {
+			return x.Filter
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetBatch() *MessageBatch {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Batch); ok {
+			return x.Batch
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetRoomMoved() *RoomMoved {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_RoomMoved); ok {
+			return x.RoomMoved
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetVoice() *VoiceEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Voice); ok {
+			return x.Voice
+		}
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetThread() *ThreadUpdate {
+	if x != nil {
+		if x, ok := x.Payload.(*ChatMessage_Thread); ok {
+			return x.Thread
+		}
+	}
+	return nil
+}
+
+type isChatMessage_Payload interface {
+	isChatMessage_Payload()
+}
+
+type ChatMessage_Rename struct {
+	Rename *Rename `protobuf:"bytes,4,opt,name=rename,proto3,oneof"` // 改名事件，由服务器发出
+}
+
+type ChatMessage_LinkPreview struct {
+	LinkPreview *LinkPreview `protobuf:"bytes,7,opt,name=link_preview,json=linkPreview,proto3,oneof"` // 链接预览事件，message_id 指向原消息
+}
+
+type ChatMessage_Code struct {
+	Code *Code `protobuf:"bytes,8,opt,name=code,proto3,oneof"` // 代码块消息，内容原样保留
+}
+
+type ChatMessage_Attachment struct {
+	Attachment *Attachment `protobuf:"bytes,9,opt,name=attachment,proto3,oneof"` // 附件，文件本身通过网关上传和下载
+}
+
+type ChatMessage_Signal struct {
+	Signal *Signal `protobuf:"bytes,10,opt,name=signal,proto3,oneof"` // WebRTC 信令，recipient_user 为通话对方
+}
+
+type ChatMessage_CallEvent struct {
+	CallEvent *CallEvent `protobuf:"bytes,11,opt,name=call_event,json=callEvent,proto3,oneof"` // 通话状态变化，由服务器发出
+}
+
+type ChatMessage_Presence struct {
+	Presence *Presence `protobuf:"bytes,12,opt,name=presence,proto3,oneof"` // 用户在线状态变化，由服务器发出
+}
+
+type ChatMessage_Unread struct {
+	Unread *UnreadCounts `protobuf:"bytes,15,opt,name=unread,proto3,oneof"` // 未读数变化，由服务器发给对应用户
+}
+
+type ChatMessage_Ack struct {
+	Ack *Ack `protobuf:"bytes,17,opt,name=ack,proto3,oneof"` // 对带 client_msg_id 消息的确认，只发给发送者
+}
+
+type ChatMessage_Translation struct {
+	Translation *Translation `protobuf:"bytes,18,opt,name=translation,proto3,oneof"` // 翻译事件，只发给请求翻译的用户
+}
+
+type ChatMessage_RoomChange struct {
+	RoomChange *RoomChange `protobuf:"bytes,21,opt,name=room_change,json=roomChange,proto3,oneof"` // 连接切换了房间，只发给切换的连接
+}
+
+type ChatMessage_Hello struct {
+	Hello *Hello `protobuf:"bytes,23,opt,name=hello,proto3,oneof"` // 协议协商，见 Hello
+}
+
+type ChatMessage_Activity struct {
+	Activity *Activity `protobuf:"bytes,25,opt,name=activity,proto3,oneof"` // 客户端的活跃提示，服务器据此判断离开状态，不会转发
+}
+
+type ChatMessage_Heartbeat struct {
+	Heartbeat *Heartbeat `protobuf:"bytes,26,opt,name=heartbeat,proto3,oneof"` // 应用层心跳，服务器原样发回给发送的连接
+}
+
+type ChatMessage_Edit struct {
+	Edit *MessageEdit `protobuf:"bytes,27,opt,name=edit,proto3,oneof"` // 消息内容更新，由服务器发出，如 AI 助手的流式回答
+}
+
+type ChatMessage_Members struct {
+	Members *Members `protobuf:"bytes,29,opt,name=members,proto3,oneof"` // 在线用户变化，见 Members
+}
+
+type ChatMessage_Member struct {
+	Member *RoomMember `protobuf:"bytes,30,opt,name=member,proto3,oneof"` // 房间成员进入、离开或角色变化，room 为所在房间，由服务器发出
+}
+
+type ChatMessage_KeywordHit struct {
+	KeywordHit *KeywordHit `protobuf:"bytes,31,opt,name=keyword_hit,json=keywordHit,proto3,oneof"` // 公共消息命中了接收者在该房间登记的关键词，由服务器发给对应用户
+}
+
+type ChatMessage_Subscriptions struct {
+	Subscriptions *Subscriptions `protobuf:"bytes,32,opt,name=subscriptions,proto3,oneof"` // 连接订阅的房间变化，只发给该连接
+}
+
+type ChatMessage_Filter struct {
+	Filter *StreamFilter `protobuf:"bytes,33,opt,name=filter,proto3,oneof"` // 设置本连接的接收过滤，见 StreamFilter，不会转发
+}
+
+type ChatMessage_Batch struct {
+	Batch *MessageBatch `protobuf:"bytes,34,opt,name=batch,proto3,oneof"` // 服务器合并发送的多条消息，见 MessageBatch
+}
+
+type ChatMessage_RoomMoved struct {
+	RoomMoved *RoomMoved `protobuf:"bytes,35,opt,name=room_moved,json=roomMoved,proto3,oneof"` // 房间由另一个实例负责，见 RoomMoved
+}
+
+type ChatMessage_Voice struct {
+	Voice *VoiceEvent `protobuf:"bytes,36,opt,name=voice,proto3,oneof"` // 语音频道的变化，room 为所在房间，由服务器发出
+}
+
+type ChatMessage_Thread struct {
+	Thread *ThreadUpdate `protobuf:"bytes,38,opt,name=thread,proto3,oneof"` // 话题有了新回复，发给房间内的所有连接，由服务器发出
+}
+
+func (*ChatMessage_Rename) isChatMessage_Payload() {}
+
+func (*ChatMessage_LinkPreview) isChatMessage_Payload() {}
+
+func (*ChatMessage_Code) isChatMessage_Payload() {}
+
+func (*ChatMessage_Attachment) isChatMessage_Payload() {}
+
+func (*ChatMessage_Signal) isChatMessage_Payload() {}
+
+func (*ChatMessage_CallEvent) isChatMessage_Payload() {}
+
+func (*ChatMessage_Presence) isChatMessage_Payload() {}
+
+func (*ChatMessage_Unread) isChatMessage_Payload() {}
+
+func (*ChatMessage_Ack) isChatMessage_Payload() {}
+
+func (*ChatMessage_Translation) isChatMessage_Payload() {}
+
+func (*ChatMessage_RoomChange) isChatMessage_Payload() {}
+
+func (*ChatMessage_Hello) isChatMessage_Payload() {}
+
+func (*ChatMessage_Activity) isChatMessage_Payload() {}
+
+func (*ChatMessage_Heartbeat) isChatMessage_Payload() {}
+
+func (*ChatMessage_Edit) isChatMessage_Payload() {}
+
+func (*ChatMessage_Members) isChatMessage_Payload() {}
+
+func (*ChatMessage_Member) isChatMessage_Payload() {}
+
+func (*ChatMessage_KeywordHit) isChatMessage_Payload() {}
+
+func (*ChatMessage_Subscriptions) isChatMessage_Payload() {}
+
+func (*ChatMessage_Filter) isChatMessage_Payload() {}
+
+func (*ChatMessage_Batch) isChatMessage_Payload() {}
+
+func (*ChatMessage_RoomMoved) isChatMessage_Payload() {}
+
+func (*ChatMessage_Voice) isChatMessage_Payload() {}
+
+func (*ChatMessage_Thread) isChatMessage_Payload() {}
+
+// 协议协商：客户端在加入消息中带上 hello，列出支持的功能；服务器回复一条
+// TYPE_HELLO 消息，列出本连接启用的功能，之后只发送这些功能的事件。
+// 不带 hello 的旧客户端照旧收到全部事件，旧服务器不会回复
+type Hello struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion uint32                 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"` // 发送方的协议版本
+	Capabilities    []string               `protobuf:"bytes,2,rep,name=capabilities,proto3" json:"capabilities,omitempty"`                               // 功能名，如 presence、calls，见 chat.Capabilities
+	Rooms           []string               `protobuf:"bytes,3,rep,name=rooms,proto3" json:"rooms,omitempty"`                                             // 加入时在同一个流上额外订阅的房间，私有房间须之前进入过
+	Filter          *StreamFilter          `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`                                           // 加入时就生效的接收过滤，重连时带上即可恢复
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_proto_chat_chat_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hello) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *Hello) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *Hello) GetRooms() []string {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+func (x *Hello) GetFilter() *StreamFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+// 接收过滤：服务器在分发前丢掉连接不需要的消息，为移动端和机器人节省流量。
+// 客户端随时可以发送带 filter 的消息替换之前的过滤，各项都为空时不过滤。
+// 私信、临时消息和服务器只发给本连接的回复不受影响。过滤掉的公共消息照样占用
+// 房间序号，设置了过滤的客户端不应按序号缺口补拉历史
+type StreamFilter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 只接收 @提及自己的公共消息，自己其他连接发出的也照常收到
+	MentionsOnly bool `protobuf:"varint,1,opt,name=mentions_only,json=mentionsOnly,proto3" json:"mentions_only,omitempty"`
+	// 不接收加入、离开和房间成员变化（TYPE_JOIN、TYPE_LEAVE、TYPE_MEMBER），
+	// 在线列表不再随之更新，需要时用 ListUsers 查询
+	HideMembership bool `protobuf:"varint,2,opt,name=hide_membership,json=hideMembership,proto3" json:"hide_membership,omitempty"`
+	// 只接收这些房间的公共消息和事件，空表示当前房间和订阅的房间都接收
+	Rooms         []string `protobuf:"bytes,3,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamFilter) Reset() {
+	*x = StreamFilter{}
+	mi := &file_proto_chat_chat_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamFilter) ProtoMessage() {}
+
+func (x *StreamFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamFilter.ProtoReflect.Descriptor instead.
+func (*StreamFilter) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamFilter) GetMentionsOnly() bool {
+	if x != nil {
+		return x.MentionsOnly
+	}
+	return false
+}
+
+func (x *StreamFilter) GetHideMembership() bool {
+	if x != nil {
+		return x.HideMembership
+	}
+	return false
+}
+
+func (x *StreamFilter) GetRooms() []string {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+// 写合批：服务器开启合批后，把短时间内发往同一个流的多条消息合成一条
+// TYPE_BATCH 消息发送，减少 HTTP/2 帧和系统调用。只发给启用了 batch 功能的
+// 客户端，客户端应按顺序把 messages 当作逐条收到的消息处理，批内不会再嵌套批
+type MessageBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*ChatMessage         `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageBatch) Reset() {
+	*x = MessageBatch{}
+	mi := &file_proto_chat_chat_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageBatch) ProtoMessage() {}
+
+func (x *MessageBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageBatch.ProtoReflect.Descriptor instead.
+func (*MessageBatch) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MessageBatch) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+// 分片模式下每个房间只由一个实例负责。连接要进入的房间由其他实例负责，或集群
+// 拓扑变化使当前房间迁走时，服务器发送 room_moved，客户端应连到 node 并以 room
+// 为房间重新加入；当前房间迁走的流在一段宽限期后被关闭
+type RoomMoved struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Node          string                 `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"` // 负责该房间的实例的 gRPC 地址
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomMoved) Reset() {
+	*x = RoomMoved{}
+	mi := &file_proto_chat_chat_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomMoved) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomMoved) ProtoMessage() {}
+
+func (x *RoomMoved) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomMoved.ProtoReflect.Descriptor instead.
+func (*RoomMoved) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RoomMoved) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomMoved) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+// 一个流可以同时接收多个房间的公共消息：room 是当前房间，不带 room 的消息发到这里；
+// rooms 是用 /subscribe 或 Hello.rooms 额外订阅的房间，发送时把 ChatMessage.room
+// 设为其中之一即可发到该房间。收到的公共消息都带有 room
+type Subscriptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Rooms         []string               `protobuf:"bytes,2,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscriptions) Reset() {
+	*x = Subscriptions{}
+	mi := &file_proto_chat_chat_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscriptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscriptions) ProtoMessage() {}
+
+func (x *Subscriptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscriptions.ProtoReflect.Descriptor instead.
+func (*Subscriptions) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Subscriptions) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Subscriptions) GetRooms() []string {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+// 连接从 from 房间切换到 to 房间
+type RoomChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomChange) Reset() {
+	*x = RoomChange{}
+	mi := &file_proto_chat_chat_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomChange) ProtoMessage() {}
+
+func (x *RoomChange) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomChange.ProtoReflect.Descriptor instead.
+func (*RoomChange) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RoomChange) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RoomChange) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *RoomChange) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+// room 为空时列出所有在线用户。私有房间只对其成员和带管理令牌的调用者可见：
+// room 为私有房间时返回 PERMISSION_DENIED，各用户的 rooms 中也不含不可见的私有房间
+type ListUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"` // 调用者，非空时须通过认证
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRequest) Reset() {
+	*x = ListUsersRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRequest) ProtoMessage() {}
+
+func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListUsersRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *ListUsersRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type OnlineUser struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Rooms         []string               `protobuf:"bytes,2,rep,name=rooms,proto3" json:"rooms,omitempty"` // 用户各连接所在的房间
+	Status        PresenceStatus         `protobuf:"varint,3,opt,name=status,proto3,enum=chat.PresenceStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OnlineUser) Reset() {
+	*x = OnlineUser{}
+	mi := &file_proto_chat_chat_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OnlineUser) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OnlineUser) ProtoMessage() {}
+
+func (x *OnlineUser) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OnlineUser.ProtoReflect.Descriptor instead.
+func (*OnlineUser) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *OnlineUser) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OnlineUser) GetRooms() []string {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+func (x *OnlineUser) GetStatus() PresenceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PresenceStatus_PRESENCE_AVAILABLE
+}
+
+type UserList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*OnlineUser          `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserList) Reset() {
+	*x = UserList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserList) ProtoMessage() {}
+
+func (x *UserList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserList.ProtoReflect.Descriptor instead.
+func (*UserList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UserList) GetUsers() []*OnlineUser {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type RoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`        // 空表示默认房间
+	History       int32                  `protobuf:"varint,2,opt,name=history,proto3" json:"history,omitempty"` // 先补发最近的 N 条公共消息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomRequest) Reset() {
+	*x = RoomRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomRequest) ProtoMessage() {}
+
+func (x *RoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomRequest.ProtoReflect.Descriptor instead.
+func (*RoomRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RoomRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomRequest) GetHistory() int32 {
+	if x != nil {
+		return x.History
+	}
+	return 0
+}
+
+type ListRoomsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoomsRequest) Reset() {
+	*x = ListRoomsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoomsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoomsRequest) ProtoMessage() {}
+
+func (x *ListRoomsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoomsRequest.ProtoReflect.Descriptor instead.
+func (*ListRoomsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{11}
+}
+
+type RoomInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Members       uint32                 `protobuf:"varint,2,opt,name=members,proto3" json:"members,omitempty"` // 房间内的在线用户数
+	Private       bool                   `protobuf:"varint,3,opt,name=private,proto3" json:"private,omitempty"`
+	Language      string                 `protobuf:"bytes,4,opt,name=language,proto3" json:"language,omitempty"` // 见 RoomSettings
+	Nsfw          bool                   `protobuf:"varint,5,opt,name=nsfw,proto3" json:"nsfw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomInfo) Reset() {
+	*x = RoomInfo{}
+	mi := &file_proto_chat_chat_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomInfo) ProtoMessage() {}
+
+func (x *RoomInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomInfo.ProtoReflect.Descriptor instead.
+func (*RoomInfo) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RoomInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RoomInfo) GetMembers() uint32 {
+	if x != nil {
+		return x.Members
+	}
+	return 0
+}
+
+func (x *RoomInfo) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *RoomInfo) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *RoomInfo) GetNsfw() bool {
+	if x != nil {
+		return x.Nsfw
+	}
+	return false
+}
+
+type RoomList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rooms         []*RoomInfo            `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomList) Reset() {
+	*x = RoomList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomList) ProtoMessage() {}
+
+func (x *RoomList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomList.ProtoReflect.Descriptor instead.
+func (*RoomList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RoomList) GetRooms() []*RoomInfo {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+type RoomMember struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Role          RoomRole               `protobuf:"varint,2,opt,name=role,proto3,enum=chat.RoomRole" json:"role,omitempty"`
+	Online        bool                   `protobuf:"varint,3,opt,name=online,proto3" json:"online,omitempty"`                          // 至少有一个连接在该房间
+	Status        PresenceStatus         `protobuf:"varint,4,opt,name=status,proto3,enum=chat.PresenceStatus" json:"status,omitempty"` // 在线时的状态
+	LastSeen      int64                  `protobuf:"varint,5,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`      // 最后一个连接离开房间的时间，UTC Unix 毫秒，在线时为 0
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomMember) Reset() {
+	*x = RoomMember{}
+	mi := &file_proto_chat_chat_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomMember) ProtoMessage() {}
+
+func (x *RoomMember) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomMember.ProtoReflect.Descriptor instead.
+func (*RoomMember) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RoomMember) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RoomMember) GetRole() RoomRole {
+	if x != nil {
+		return x.Role
+	}
+	return RoomRole_ROLE_MEMBER
+}
+
+func (x *RoomMember) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *RoomMember) GetStatus() PresenceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PresenceStatus_PRESENCE_AVAILABLE
+}
+
+func (x *RoomMember) GetLastSeen() int64 {
+	if x != nil {
+		return x.LastSeen
+	}
+	return 0
+}
+
+// 房间设置，与房间一起保存在快照中，分片迁移时一并转交
+type RoomSettings struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Room        string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`                                             // 空表示默认房间
+	Language    string                 `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`                                     // 房间使用的语言（如 zh、en），供客户端显示和选择翻译目标，空表示未指定
+	Nsfw        bool                   `protobuf:"varint,3,opt,name=nsfw,proto3" json:"nsfw,omitempty"`                                            // 进入时提醒，且不生成链接预览
+	PostRole    RoomRole               `protobuf:"varint,4,opt,name=post_role,json=postRole,proto3,enum=chat.RoomRole" json:"post_role,omitempty"` // 发送公共消息所需的最低角色，ROLE_MEMBER 表示所有人
+	Attachments AttachmentPolicy       `protobuf:"varint,5,opt,name=attachments,proto3,enum=chat.AttachmentPolicy" json:"attachments,omitempty"`
+	// 广播房间（公告、状态频道）：只有 publishers 中的用户和房间的 moderator、owner 可以发送公共消息，
+	// 其他人只能接收，发送时收到 settings.broadcast 系统消息
+	Broadcast     bool     `protobuf:"varint,6,opt,name=broadcast,proto3" json:"broadcast,omitempty"`
+	Publishers    []string `protobuf:"bytes,7,rep,name=publishers,proto3" json:"publishers,omitempty"` // 最多 100 人，改名的用户保留发布权限
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomSettings) Reset() {
+	*x = RoomSettings{}
+	mi := &file_proto_chat_chat_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomSettings) ProtoMessage() {}
+
+func (x *RoomSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomSettings.ProtoReflect.Descriptor instead.
+func (*RoomSettings) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RoomSettings) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomSettings) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *RoomSettings) GetNsfw() bool {
+	if x != nil {
+		return x.Nsfw
+	}
+	return false
+}
+
+func (x *RoomSettings) GetPostRole() RoomRole {
+	if x != nil {
+		return x.PostRole
+	}
+	return RoomRole_ROLE_MEMBER
+}
+
+func (x *RoomSettings) GetAttachments() AttachmentPolicy {
+	if x != nil {
+		return x.Attachments
+	}
+	return AttachmentPolicy_ATTACHMENTS_ALL
+}
+
+func (x *RoomSettings) GetBroadcast() bool {
+	if x != nil {
+		return x.Broadcast
+	}
+	return false
+}
+
+func (x *RoomSettings) GetPublishers() []string {
+	if x != nil {
+		return x.Publishers
+	}
+	return nil
+}
+
+type RoomSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 空表示默认房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomSettingsRequest) Reset() {
+	*x = RoomSettingsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomSettingsRequest) ProtoMessage() {}
+
+func (x *RoomSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomSettingsRequest.ProtoReflect.Descriptor instead.
+func (*RoomSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RoomSettingsRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type RoomMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 空表示默认房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomMembersRequest) Reset() {
+	*x = RoomMembersRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomMembersRequest) ProtoMessage() {}
+
+func (x *RoomMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomMembersRequest.ProtoReflect.Descriptor instead.
+func (*RoomMembersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RoomMembersRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type RoomMembers struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Members       []*RoomMember          `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomMembers) Reset() {
+	*x = RoomMembers{}
+	mi := &file_proto_chat_chat_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomMembers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomMembers) ProtoMessage() {}
+
+func (x *RoomMembers) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomMembers.ProtoReflect.Descriptor instead.
+func (*RoomMembers) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RoomMembers) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomMembers) GetMembers() []*RoomMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+// 系统消息文案，key 对应 pkg/i18n 中的条目，文案中的 {name} 由 args 替换
+type SystemText struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Args          map[string]string      `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SystemText) Reset() {
+	*x = SystemText{}
+	mi := &file_proto_chat_chat_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SystemText) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SystemText) ProtoMessage() {}
+
+func (x *SystemText) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SystemText.ProtoReflect.Descriptor instead.
+func (*SystemText) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SystemText) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SystemText) GetArgs() map[string]string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+// 消息翻译，message_id 指向原消息
+type Translation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Lang          string                 `protobuf:"bytes,2,opt,name=lang,proto3" json:"lang,omitempty"` // 目标语言，如 en、zh、ja
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	SourceLang    string                 `protobuf:"bytes,4,opt,name=source_lang,json=sourceLang,proto3" json:"source_lang,omitempty"` // 服务商检测到的原文语言，可为空
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Translation) Reset() {
+	*x = Translation{}
+	mi := &file_proto_chat_chat_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Translation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Translation) ProtoMessage() {}
+
+func (x *Translation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Translation.ProtoReflect.Descriptor instead.
+func (*Translation) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Translation) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *Translation) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *Translation) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Translation) GetSourceLang() string {
+	if x != nil {
+		return x.SourceLang
+	}
+	return ""
+}
+
+// 消息内容更新，message_id 指向原消息，text 为完整的新内容。
+// 只保留 revision 最大的一次，done 为 true 的是最终内容，此时 ChatMessage.text
+// 同样为最终内容，不支持 edit 的客户端会把它当作系统消息显示
+type MessageEdit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Revision      uint32                 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	Done          bool                   `protobuf:"varint,4,opt,name=done,proto3" json:"done,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageEdit) Reset() {
+	*x = MessageEdit{}
+	mi := &file_proto_chat_chat_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageEdit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageEdit) ProtoMessage() {}
+
+func (x *MessageEdit) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageEdit.ProtoReflect.Descriptor instead.
+func (*MessageEdit) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MessageEdit) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *MessageEdit) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *MessageEdit) GetRevision() uint32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *MessageEdit) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+// 消息确认，返回服务器分配的 ID 供客户端对账
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientMsgId   string                 `protobuf:"bytes,1,opt,name=client_msg_id,json=clientMsgId,proto3" json:"client_msg_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Duplicate     bool                   `protobuf:"varint,4,opt,name=duplicate,proto3" json:"duplicate,omitempty"` // 重复提交，消息没有再次广播
+	Room          string                 `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`            // 公共消息所在房间，seq 属于该房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_proto_chat_chat_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Ack) GetClientMsgId() string {
+	if x != nil {
+		return x.ClientMsgId
+	}
+	return ""
+}
+
+func (x *Ack) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Ack) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Ack) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+func (x *Ack) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+// 查询 room 中序号在 (after_seq, before_seq) 之间的消息，before_seq 为 0 表示不设上限
+type HistoryRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Room      string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	AfterSeq  uint64                 `protobuf:"varint,2,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"`
+	BeforeSeq uint64                 `protobuf:"varint,3,opt,name=before_seq,json=beforeSeq,proto3" json:"before_seq,omitempty"`
+	Limit     uint32                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"` // 最多返回的条数，取最早的消息
+	// 设置后按时间向前翻页：返回该时间（Unix 毫秒）之前最新的 limit 条消息，忽略序号。
+	// 服务器配置了消息存储时从存储读取，可翻到重启前和已转入冷存储的消息
+	BeforeTime    int64 `protobuf:"varint,5,opt,name=before_time,json=beforeTime,proto3" json:"before_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *HistoryRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *HistoryRequest) GetAfterSeq() uint64 {
+	if x != nil {
+		return x.AfterSeq
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetBeforeSeq() uint64 {
+	if x != nil {
+		return x.BeforeSeq
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetBeforeTime() int64 {
+	if x != nil {
+		return x.BeforeTime
+	}
+	return 0
+}
+
+type HistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*ChatMessage         `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"` // 按序号升序
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryResponse) Reset() {
+	*x = HistoryResponse{}
+	mi := &file_proto_chat_chat_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryResponse) ProtoMessage() {}
+
+func (x *HistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryResponse.ProtoReflect.Descriptor instead.
+func (*HistoryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *HistoryResponse) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type CatchupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"` // 统计提及时使用，可为空
+	Rooms         []*CatchupRoom         `protobuf:"bytes,2,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	Max           uint32                 `protobuf:"varint,3,opt,name=max,proto3" json:"max,omitempty"` // 每个房间返回的最新消息条数，默认 20，最多 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CatchupRequest) Reset() {
+	*x = CatchupRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatchupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatchupRequest) ProtoMessage() {}
+
+func (x *CatchupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CatchupRequest.ProtoReflect.Descriptor instead.
+func (*CatchupRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CatchupRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *CatchupRequest) GetRooms() []*CatchupRoom {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+func (x *CatchupRequest) GetMax() uint32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+type CatchupRoom struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	SinceSeq      uint64                 `protobuf:"varint,2,opt,name=since_seq,json=sinceSeq,proto3" json:"since_seq,omitempty"` // 客户端已收到的最新序号
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CatchupRoom) Reset() {
+	*x = CatchupRoom{}
+	mi := &file_proto_chat_chat_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatchupRoom) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatchupRoom) ProtoMessage() {}
+
+func (x *CatchupRoom) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CatchupRoom.ProtoReflect.Descriptor instead.
+func (*CatchupRoom) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CatchupRoom) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *CatchupRoom) GetSinceSeq() uint64 {
+	if x != nil {
+		return x.SinceSeq
+	}
+	return 0
+}
+
+type CatchupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rooms         []*RoomCatchup         `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"` // 与请求的顺序相同
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CatchupResponse) Reset() {
+	*x = CatchupResponse{}
+	mi := &file_proto_chat_chat_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatchupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatchupResponse) ProtoMessage() {}
+
+func (x *CatchupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CatchupResponse.ProtoReflect.Descriptor instead.
+func (*CatchupResponse) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *CatchupResponse) GetRooms() []*RoomCatchup {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+type RoomCatchup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	MessageCount  uint32                 `protobuf:"varint,2,opt,name=message_count,json=messageCount,proto3" json:"message_count,omitempty"` // since_seq 之后的消息数
+	MentionCount  uint32                 `protobuf:"varint,3,opt,name=mention_count,json=mentionCount,proto3" json:"mention_count,omitempty"` // 其中 @提及 user 的条数
+	Messages      []*ChatMessage         `protobuf:"bytes,4,rep,name=messages,proto3" json:"messages,omitempty"`                              // 最新的 max 条，按序号升序
+	LastSeq       uint64                 `protobuf:"varint,5,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`                // 房间最新的序号，没有消息时为 since_seq
+	Truncated     bool                   `protobuf:"varint,6,opt,name=truncated,proto3" json:"truncated,omitempty"`                           // 部分错过的消息已超出服务器保留的历史，计数只含保留的部分
+	Members       []*MembershipChange    `protobuf:"bytes,7,rep,name=members,proto3" json:"members,omitempty"`                                // 期间的成员变化，每人合并为一条，来了又走的不列出
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomCatchup) Reset() {
+	*x = RoomCatchup{}
+	mi := &file_proto_chat_chat_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomCatchup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomCatchup) ProtoMessage() {}
+
+func (x *RoomCatchup) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomCatchup.ProtoReflect.Descriptor instead.
+func (*RoomCatchup) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RoomCatchup) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomCatchup) GetMessageCount() uint32 {
+	if x != nil {
+		return x.MessageCount
+	}
+	return 0
+}
+
+func (x *RoomCatchup) GetMentionCount() uint32 {
+	if x != nil {
+		return x.MentionCount
+	}
+	return 0
+}
+
+func (x *RoomCatchup) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *RoomCatchup) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *RoomCatchup) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *RoomCatchup) GetMembers() []*MembershipChange {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type MembershipChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Joined        bool                   `protobuf:"varint,2,opt,name=joined,proto3" json:"joined,omitempty"` // true 为新进入且仍在房间，false 为离开了房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MembershipChange) Reset() {
+	*x = MembershipChange{}
+	mi := &file_proto_chat_chat_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MembershipChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MembershipChange) ProtoMessage() {}
+
+func (x *MembershipChange) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MembershipChange.ProtoReflect.Descriptor instead.
+func (*MembershipChange) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *MembershipChange) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *MembershipChange) GetJoined() bool {
+	if x != nil {
+		return x.Joined
+	}
+	return false
+}
+
+type UnreadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnreadRequest) Reset() {
+	*x = UnreadRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnreadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnreadRequest) ProtoMessage() {}
+
+func (x *UnreadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnreadRequest.ProtoReflect.Descriptor instead.
+func (*UnreadRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *UnreadRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type MarkReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkReadRequest) Reset() {
+	*x = MarkReadRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkReadRequest) ProtoMessage() {}
+
+func (x *MarkReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkReadRequest.ProtoReflect.Descriptor instead.
+func (*MarkReadRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *MarkReadRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *MarkReadRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *MarkReadRequest) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// 各房间的未读消息数
+type UnreadCounts struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Rooms         map[string]uint32      `protobuf:"bytes,2,rep,name=rooms,proto3" json:"rooms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnreadCounts) Reset() {
+	*x = UnreadCounts{}
+	mi := &file_proto_chat_chat_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnreadCounts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnreadCounts) ProtoMessage() {}
+
+func (x *UnreadCounts) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnreadCounts.ProtoReflect.Descriptor instead.
+func (*UnreadCounts) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *UnreadCounts) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *UnreadCounts) GetRooms() map[string]uint32 {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+// 一对一通话和语音频道的信令，payload 为 SDP 或 ICE candidate（JSON），服务器只转发不解析
+type Signal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CallId        string                 `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"` // 由发起方生成
+	Type          SignalType             `protobuf:"varint,2,opt,name=type,proto3,enum=chat.SignalType" json:"type,omitempty"`
+	Payload       string                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Signal) Reset() {
+	*x = Signal{}
+	mi := &file_proto_chat_chat_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Signal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Signal) ProtoMessage() {}
+
+func (x *Signal) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Signal.ProtoReflect.Descriptor instead.
+func (*Signal) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Signal) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *Signal) GetType() SignalType {
+	if x != nil {
+		return x.Type
+	}
+	return SignalType_SIGNAL_UNKNOWN
+}
+
+func (x *Signal) GetPayload() string {
+	if x != nil {
+		return x.Payload
+	}
+	return ""
+}
+
+// 语音频道成员
+type VoiceMember struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Speaking      bool                   `protobuf:"varint,2,opt,name=speaking,proto3" json:"speaking,omitempty"`
+	JoinedAt      int64                  `protobuf:"varint,3,opt,name=joined_at,json=joinedAt,proto3" json:"joined_at,omitempty"` // 毫秒时间戳
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoiceMember) Reset() {
+	*x = VoiceMember{}
+	mi := &file_proto_chat_chat_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoiceMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoiceMember) ProtoMessage() {}
+
+func (x *VoiceMember) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoiceMember.ProtoReflect.Descriptor instead.
+func (*VoiceMember) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *VoiceMember) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *VoiceMember) GetSpeaking() bool {
+	if x != nil {
+		return x.Speaking
+	}
+	return false
+}
+
+func (x *VoiceMember) GetJoinedAt() int64 {
+	if x != nil {
+		return x.JoinedAt
+	}
+	return 0
+}
+
+// 语音频道的变化，由服务器发给房间内的所有连接（不只是语音频道成员），客户端据此
+// 显示语音面板；媒体仍由客户端之间直连，服务器不转发音频
+type VoiceEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Action        VoiceAction            `protobuf:"varint,2,opt,name=action,proto3,enum=chat.VoiceAction" json:"action,omitempty"`
+	Member        *VoiceMember           `protobuf:"bytes,3,opt,name=member,proto3" json:"member,omitempty"` // 变化后的状态
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoiceEvent) Reset() {
+	*x = VoiceEvent{}
+	mi := &file_proto_chat_chat_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoiceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoiceEvent) ProtoMessage() {}
+
+func (x *VoiceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoiceEvent.ProtoReflect.Descriptor instead.
+func (*VoiceEvent) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *VoiceEvent) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *VoiceEvent) GetAction() VoiceAction {
+	if x != nil {
+		return x.Action
+	}
+	return VoiceAction_VOICE_JOINED
+}
+
+func (x *VoiceEvent) GetMember() *VoiceMember {
+	if x != nil {
+		return x.Member
+	}
+	return nil
+}
+
+type VoiceMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 空表示默认房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoiceMembersRequest) Reset() {
+	*x = VoiceMembersRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoiceMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoiceMembersRequest) ProtoMessage() {}
+
+func (x *VoiceMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoiceMembersRequest.ProtoReflect.Descriptor instead.
+func (*VoiceMembersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *VoiceMembersRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type VoiceMembers struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Members       []*VoiceMember         `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"` // 按加入时间排列
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoiceMembers) Reset() {
+	*x = VoiceMembers{}
+	mi := &file_proto_chat_chat_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoiceMembers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoiceMembers) ProtoMessage() {}
+
+func (x *VoiceMembers) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoiceMembers.ProtoReflect.Descriptor instead.
+func (*VoiceMembers) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *VoiceMembers) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *VoiceMembers) GetMembers() []*VoiceMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type CallEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CallId        string                 `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	State         CallState              `protobuf:"varint,2,opt,name=state,proto3,enum=chat.CallState" json:"state,omitempty"`
+	Caller        string                 `protobuf:"bytes,3,opt,name=caller,proto3" json:"caller,omitempty"`
+	Callee        string                 `protobuf:"bytes,4,opt,name=callee,proto3" json:"callee,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"` // 结束原因，如 hangup、busy、offline、no-answer、disconnected
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallEvent) Reset() {
+	*x = CallEvent{}
+	mi := &file_proto_chat_chat_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallEvent) ProtoMessage() {}
+
+func (x *CallEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallEvent.ProtoReflect.Descriptor instead.
+func (*CallEvent) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CallEvent) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *CallEvent) GetState() CallState {
+	if x != nil {
+		return x.State
+	}
+	return CallState_CALL_STATE_UNKNOWN
+}
+
+func (x *CallEvent) GetCaller() string {
+	if x != nil {
+		return x.Caller
+	}
+	return ""
+}
+
+func (x *CallEvent) GetCallee() string {
+	if x != nil {
+		return x.Callee
+	}
+	return ""
+}
+
+func (x *CallEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// 活跃提示：发消息本身就算活跃；客户端可在用户操作时发送 idle=false，
+// 在页面隐藏等用户显然不在时发送 idle=true。用户的所有连接都空闲
+// （主动报告或超过服务器的空闲时间）时显示为离开，任一连接活跃时恢复
+type Activity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Idle          bool                   `protobuf:"varint,1,opt,name=idle,proto3" json:"idle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Activity) Reset() {
+	*x = Activity{}
+	mi := &file_proto_chat_chat_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Activity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Activity) ProtoMessage() {}
+
+func (x *Activity) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Activity.ProtoReflect.Descriptor instead.
+func (*Activity) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *Activity) GetIdle() bool {
+	if x != nil {
+		return x.Idle
+	}
+	return false
+}
+
+// 应用层心跳：协商了 heartbeat 功能的客户端定期发送，服务器只回给
+// 该连接，不算作活跃也不转发。客户端据此测量往返时间，长时间收不到
+// 任何消息时判定流已失效并重连
+type Heartbeat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SentAt        int64                  `protobuf:"varint,1,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"` // 客户端发送时间，UTC Unix 毫秒，回复中原样带回
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_proto_chat_chat_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *Heartbeat) GetSentAt() int64 {
+	if x != nil {
+		return x.SentAt
+	}
+	return 0
+}
+
+// 在线用户，由服务器发出，是在线列表的唯一来源：TYPE_JOIN 和 TYPE_LEAVE
+// 中是加入或离开的用户（合并提示时有多个），TYPE_ROSTER 中是全部在线用户，
+// 在连接加入后发给该连接。离开提示在宽限期后才发出，之前用户仍算在线
+type Members struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []string               `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Members) Reset() {
+	*x = Members{}
+	mi := &file_proto_chat_chat_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Members) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Members) ProtoMessage() {}
+
+func (x *Members) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Members.ProtoReflect.Descriptor instead.
+func (*Members) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *Members) GetUsers() []string {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type Presence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Status        PresenceStatus         `protobuf:"varint,2,opt,name=status,proto3,enum=chat.PresenceStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Presence) Reset() {
+	*x = Presence{}
+	mi := &file_proto_chat_chat_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Presence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Presence) ProtoMessage() {}
+
+func (x *Presence) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Presence.ProtoReflect.Descriptor instead.
+func (*Presence) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *Presence) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Presence) GetStatus() PresenceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PresenceStatus_PRESENCE_AVAILABLE
+}
+
+// 附件元数据
+type Attachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"` // voice、gif 或 file
+	MimeType      string                 `protobuf:"bytes,3,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`                               // 字节
+	DurationMs    int64                  `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"` // 音频时长
+	Url           string                 `protobuf:"bytes,6,opt,name=url,proto3" json:"url,omitempty"`                                  // 下载地址，支持 Range 请求；gif 为服务商的媒体地址
+	Width         int32                  `protobuf:"varint,7,opt,name=width,proto3" json:"width,omitempty"`                             // 图片尺寸，gif 使用
+	Height        int32                  `protobuf:"varint,8,opt,name=height,proto3" json:"height,omitempty"`
+	PreviewUrl    string                 `protobuf:"bytes,9,opt,name=preview_url,json=previewUrl,proto3" json:"preview_url,omitempty"` // 缩略图
+	Name          string                 `protobuf:"bytes,10,opt,name=name,proto3" json:"name,omitempty"`                              // 原文件名，file 使用
+	Thumbnails    []*Thumbnail           `protobuf:"bytes,11,rep,name=thumbnails,proto3" json:"thumbnails,omitempty"`                  // 图片的缩略图，从小到大；preview_url 为最小的一张
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_proto_chat_chat_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *Attachment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Attachment) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Attachment) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Attachment) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Attachment) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *Attachment) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Attachment) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Attachment) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Attachment) GetPreviewUrl() string {
+	if x != nil {
+		return x.PreviewUrl
+	}
+	return ""
+}
+
+func (x *Attachment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attachment) GetThumbnails() []*Thumbnail {
+	if x != nil {
+		return x.Thumbnails
+	}
+	return nil
+}
+
+// 图片附件的缩略图，按比例缩小到 size × size 以内
+type Thumbnail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int32                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Width         int32                  `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Thumbnail) Reset() {
+	*x = Thumbnail{}
+	mi := &file_proto_chat_chat_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Thumbnail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thumbnail) ProtoMessage() {}
+
+func (x *Thumbnail) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thumbnail.ProtoReflect.Descriptor instead.
+func (*Thumbnail) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *Thumbnail) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Thumbnail) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Thumbnail) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Thumbnail) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// 代码块，不做过滤或 Markdown 渲染
+type Code struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Language      string                 `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"` // 如 go、python，可为空
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Code) Reset() {
+	*x = Code{}
+	mi := &file_proto_chat_chat_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Code) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Code) ProtoMessage() {}
+
+func (x *Code) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Code.ProtoReflect.Descriptor instead.
+func (*Code) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *Code) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Code) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+// 链接预览，来自页面的 OpenGraph 元数据
+type LinkPreview struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	ImageUrl      string                 `protobuf:"bytes,5,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	SiteName      string                 `protobuf:"bytes,6,opt,name=site_name,json=siteName,proto3" json:"site_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkPreview) Reset() {
+	*x = LinkPreview{}
+	mi := &file_proto_chat_chat_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkPreview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkPreview) ProtoMessage() {}
+
+func (x *LinkPreview) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkPreview.ProtoReflect.Descriptor instead.
+func (*LinkPreview) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *LinkPreview) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetSiteName() string {
+	if x != nil {
+		return x.SiteName
+	}
+	return ""
+}
+
+// 改名事件，发给改名者本人的副本 recipient_user 为新名字
+type Rename struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OldUser       string                 `protobuf:"bytes,1,opt,name=old_user,json=oldUser,proto3" json:"old_user,omitempty"`
+	NewUser       string                 `protobuf:"bytes,2,opt,name=new_user,json=newUser,proto3" json:"new_user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rename) Reset() {
+	*x = Rename{}
+	mi := &file_proto_chat_chat_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rename) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rename) ProtoMessage() {}
+
+func (x *Rename) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rename.ProtoReflect.Descriptor instead.
+func (*Rename) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *Rename) GetOldUser() string {
+	if x != nil {
+		return x.OldUser
+	}
+	return ""
+}
+
+func (x *Rename) GetNewUser() string {
+	if x != nil {
+		return x.NewUser
+	}
+	return ""
+}
+
+// 免打扰时段，时间格式为 HH:MM，可跨越午夜
+type QuietHours struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         string                 `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	Timezone      string                 `protobuf:"bytes,3,opt,name=timezone,proto3" json:"timezone,omitempty"` // IANA 时区名，空表示 UTC
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuietHours) Reset() {
+	*x = QuietHours{}
+	mi := &file_proto_chat_chat_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuietHours) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuietHours) ProtoMessage() {}
+
+func (x *QuietHours) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuietHours.ProtoReflect.Descriptor instead.
+func (*QuietHours) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *QuietHours) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *QuietHours) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *QuietHours) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+// 用户的通知偏好，私信不受房间级别影响，但遵守免打扰时段
+type Preferences struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	User            string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Rooms           map[string]NotifyLevel `protobuf:"bytes,2,rep,name=rooms,proto3" json:"rooms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value,enum=chat.NotifyLevel"` // 房间名 -> 通知级别
+	QuietHours      *QuietHours            `protobuf:"bytes,3,opt,name=quiet_hours,json=quietHours,proto3" json:"quiet_hours,omitempty"`
+	AutoTranslate   string                 `protobuf:"bytes,4,opt,name=auto_translate,json=autoTranslate,proto3" json:"auto_translate,omitempty"`          // 非空时把其他人的公共消息自动翻译成该语言
+	Locale          string                 `protobuf:"bytes,5,opt,name=locale,proto3" json:"locale,omitempty"`                                             // 界面语言，如 zh、en，空表示由客户端决定
+	AssistantOptOut bool                   `protobuf:"varint,6,opt,name=assistant_opt_out,json=assistantOptOut,proto3" json:"assistant_opt_out,omitempty"` // 不把自己的公共消息作为上下文发给 AI 助手
+	MessageRequests bool                   `protobuf:"varint,7,opt,name=message_requests,json=messageRequests,proto3" json:"message_requests,omitempty"`   // 非联系人的私信进入消息请求，由自己接受或拒绝
+	// 房间名 -> 关注的关键词。命中时不论通知级别和免打扰时段都会收到 TYPE_KEYWORD_HIT，
+	// 大小写不敏感，由字母、数字和下划线组成的词按整词匹配
+	Keywords      map[string]*Keywords `protobuf:"bytes,8,rep,name=keywords,proto3" json:"keywords,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Preferences) Reset() {
+	*x = Preferences{}
+	mi := &file_proto_chat_chat_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Preferences) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Preferences) ProtoMessage() {}
+
+func (x *Preferences) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Preferences.ProtoReflect.Descriptor instead.
+func (*Preferences) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *Preferences) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Preferences) GetRooms() map[string]NotifyLevel {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+func (x *Preferences) GetQuietHours() *QuietHours {
+	if x != nil {
+		return x.QuietHours
+	}
+	return nil
+}
+
+func (x *Preferences) GetAutoTranslate() string {
+	if x != nil {
+		return x.AutoTranslate
+	}
+	return ""
+}
+
+func (x *Preferences) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *Preferences) GetAssistantOptOut() bool {
+	if x != nil {
+		return x.AssistantOptOut
+	}
+	return false
+}
+
+func (x *Preferences) GetMessageRequests() bool {
+	if x != nil {
+		return x.MessageRequests
+	}
+	return false
+}
+
+func (x *Preferences) GetKeywords() map[string]*Keywords {
+	if x != nil {
+		return x.Keywords
+	}
+	return nil
+}
+
+type Keywords struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Words         []string               `protobuf:"bytes,1,rep,name=words,proto3" json:"words,omitempty"` // 每个房间最多 20 个，每个最长 64 字节
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Keywords) Reset() {
+	*x = Keywords{}
+	mi := &file_proto_chat_chat_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Keywords) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Keywords) ProtoMessage() {}
+
+func (x *Keywords) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Keywords.ProtoReflect.Descriptor instead.
+func (*Keywords) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *Keywords) GetWords() []string {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+type KeywordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	Word          string                 `protobuf:"bytes,3,opt,name=word,proto3" json:"word,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeywordRequest) Reset() {
+	*x = KeywordRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeywordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeywordRequest) ProtoMessage() {}
+
+func (x *KeywordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeywordRequest.ProtoReflect.Descriptor instead.
+func (*KeywordRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *KeywordRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *KeywordRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *KeywordRequest) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+// 关键词命中，发给登记了关键词、在线且是房间成员的用户的所有连接，不论连接在哪个房间
+type KeywordHit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keyword       string                 `protobuf:"bytes,1,opt,name=keyword,proto3" json:"keyword,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	MessageId     string                 `protobuf:"bytes,3,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Sender        string                 `protobuf:"bytes,4,opt,name=sender,proto3" json:"sender,omitempty"`
+	Text          string                 `protobuf:"bytes,5,opt,name=text,proto3" json:"text,omitempty"` // 消息内容
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeywordHit) Reset() {
+	*x = KeywordHit{}
+	mi := &file_proto_chat_chat_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeywordHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeywordHit) ProtoMessage() {}
+
+func (x *KeywordHit) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeywordHit.ProtoReflect.Descriptor instead.
+func (*KeywordHit) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *KeywordHit) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *KeywordHit) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *KeywordHit) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *KeywordHit) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *KeywordHit) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type PreferencesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreferencesRequest) Reset() {
+	*x = PreferencesRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreferencesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreferencesRequest) ProtoMessage() {}
+
+func (x *PreferencesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreferencesRequest.ProtoReflect.Descriptor instead.
+func (*PreferencesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *PreferencesRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type ProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProfileRequest) Reset() {
+	*x = ProfileRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfileRequest) ProtoMessage() {}
+
+func (x *ProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfileRequest.ProtoReflect.Descriptor instead.
+func (*ProfileRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *ProfileRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type Profile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Online        bool                   `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`                          // 至少有一个连接
+	Status        PresenceStatus         `protobuf:"varint,3,opt,name=status,proto3,enum=chat.PresenceStatus" json:"status,omitempty"` // 在线时的状态
+	Pinned        *ChatMessage           `protobuf:"bytes,4,opt,name=pinned,proto3" json:"pinned,omitempty"`                           // 置顶消息的快照，原消息之后编辑或删除不影响；未置顶时为空
+	PinnedAt      int64                  `protobuf:"varint,5,opt,name=pinned_at,json=pinnedAt,proto3" json:"pinned_at,omitempty"`      // 置顶时间，UTC Unix 毫秒
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Profile) Reset() {
+	*x = Profile{}
+	mi := &file_proto_chat_chat_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Profile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Profile) ProtoMessage() {}
+
+func (x *Profile) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Profile.ProtoReflect.Descriptor instead.
+func (*Profile) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *Profile) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Profile) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *Profile) GetStatus() PresenceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PresenceStatus_PRESENCE_AVAILABLE
+}
+
+func (x *Profile) GetPinned() *ChatMessage {
+	if x != nil {
+		return x.Pinned
+	}
+	return nil
+}
+
+func (x *Profile) GetPinnedAt() int64 {
+	if x != nil {
+		return x.PinnedAt
+	}
+	return 0
+}
+
+type SetProfilePinRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	MessageId     string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // 服务器最近历史中的公共消息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetProfilePinRequest) Reset() {
+	*x = SetProfilePinRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetProfilePinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetProfilePinRequest) ProtoMessage() {}
+
+func (x *SetProfilePinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetProfilePinRequest.ProtoReflect.Descriptor instead.
+func (*SetProfilePinRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *SetProfilePinRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *SetProfilePinRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+type MessageRequestsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"` // 收件人
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageRequestsRequest) Reset() {
+	*x = MessageRequestsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageRequestsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRequestsRequest) ProtoMessage() {}
+
+func (x *MessageRequestsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRequestsRequest.ProtoReflect.Descriptor instead.
+func (*MessageRequestsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *MessageRequestsRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+// 收件人待处理的消息请求，按收到时间排序
+type MessageRequests struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Requests      []*MessageRequest      `protobuf:"bytes,2,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageRequests) Reset() {
+	*x = MessageRequests{}
+	mi := &file_proto_chat_chat_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageRequests) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRequests) ProtoMessage() {}
+
+func (x *MessageRequests) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRequests.ProtoReflect.Descriptor instead.
+func (*MessageRequests) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *MessageRequests) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *MessageRequests) GetRequests() []*MessageRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type MessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sender        string                 `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Messages      []*ChatMessage         `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`                        // 只保留最近 20 条
+	ReceivedAt    int64                  `protobuf:"varint,3,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"` // 第一条私信到达的时间，UTC Unix 毫秒
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageRequest) Reset() {
+	*x = MessageRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRequest) ProtoMessage() {}
+
+func (x *MessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRequest.ProtoReflect.Descriptor instead.
+func (*MessageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *MessageRequest) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *MessageRequest) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *MessageRequest) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+type MessageRequestDecision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"` // 收件人
+	Sender        string                 `protobuf:"bytes,2,opt,name=sender,proto3" json:"sender,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageRequestDecision) Reset() {
+	*x = MessageRequestDecision{}
+	mi := &file_proto_chat_chat_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageRequestDecision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRequestDecision) ProtoMessage() {}
+
+func (x *MessageRequestDecision) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRequestDecision.ProtoReflect.Descriptor instead.
+func (*MessageRequestDecision) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *MessageRequestDecision) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *MessageRequestDecision) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+type ContactsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContactsRequest) Reset() {
+	*x = ContactsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactsRequest) ProtoMessage() {}
+
+func (x *ContactsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactsRequest.ProtoReflect.Descriptor instead.
+func (*ContactsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ContactsRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type SaveDraftRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Conversation  string                 `protobuf:"bytes,2,opt,name=conversation,proto3" json:"conversation,omitempty"` // "#房间" 或 "@用户"
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // 客户端编辑的毫秒时间戳，0 为服务器收到的时间，晚于服务器时间的按服务器时间算
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveDraftRequest) Reset() {
+	*x = SaveDraftRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveDraftRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveDraftRequest) ProtoMessage() {}
+
+func (x *SaveDraftRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveDraftRequest.ProtoReflect.Descriptor instead.
+func (*SaveDraftRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *SaveDraftRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *SaveDraftRequest) GetConversation() string {
+	if x != nil {
+		return x.Conversation
+	}
+	return ""
+}
+
+func (x *SaveDraftRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SaveDraftRequest) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type Draft struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conversation  string                 `protobuf:"bytes,1,opt,name=conversation,proto3" json:"conversation,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`                             // 为空表示草稿已删除
+	UpdatedAt     int64                  `protobuf:"varint,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // 毫秒时间戳
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Draft) Reset() {
+	*x = Draft{}
+	mi := &file_proto_chat_chat_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Draft) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Draft) ProtoMessage() {}
+
+func (x *Draft) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Draft.ProtoReflect.Descriptor instead.
+func (*Draft) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *Draft) GetConversation() string {
+	if x != nil {
+		return x.Conversation
+	}
+	return ""
+}
+
+func (x *Draft) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Draft) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type DraftsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DraftsRequest) Reset() {
+	*x = DraftsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DraftsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DraftsRequest) ProtoMessage() {}
+
+func (x *DraftsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DraftsRequest.ProtoReflect.Descriptor instead.
+func (*DraftsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *DraftsRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type Drafts struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Drafts        []*Draft               `protobuf:"bytes,2,rep,name=drafts,proto3" json:"drafts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Drafts) Reset() {
+	*x = Drafts{}
+	mi := &file_proto_chat_chat_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Drafts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Drafts) ProtoMessage() {}
+
+func (x *Drafts) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Drafts.ProtoReflect.Descriptor instead.
+func (*Drafts) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *Drafts) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Drafts) GetDrafts() []*Draft {
+	if x != nil {
+		return x.Drafts
+	}
+	return nil
+}
+
+type ThreadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	ThreadId      string                 `protobuf:"bytes,2,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"` // 根消息的 id
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ThreadRequest) Reset() {
+	*x = ThreadRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreadRequest) ProtoMessage() {}
+
+func (x *ThreadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreadRequest.ProtoReflect.Descriptor instead.
+func (*ThreadRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *ThreadRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ThreadRequest) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+type Thread struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          *ChatMessage           `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Replies       []*ChatMessage         `protobuf:"bytes,2,rep,name=replies,proto3" json:"replies,omitempty"`                          // 最近的回复，按时间排列
+	ReplyCount    uint32                 `protobuf:"varint,3,opt,name=reply_count,json=replyCount,proto3" json:"reply_count,omitempty"` // 全部回复数，可能多于 replies
+	Followers     uint32                 `protobuf:"varint,4,opt,name=followers,proto3" json:"followers,omitempty"`
+	Following     bool                   `protobuf:"varint,5,opt,name=following,proto3" json:"following,omitempty"` // 请求中的用户是否关注
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Thread) Reset() {
+	*x = Thread{}
+	mi := &file_proto_chat_chat_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Thread) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thread) ProtoMessage() {}
+
+func (x *Thread) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thread.ProtoReflect.Descriptor instead.
+func (*Thread) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *Thread) GetRoot() *ChatMessage {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+func (x *Thread) GetReplies() []*ChatMessage {
+	if x != nil {
+		return x.Replies
+	}
+	return nil
+}
+
+func (x *Thread) GetReplyCount() uint32 {
+	if x != nil {
+		return x.ReplyCount
+	}
+	return 0
+}
+
+func (x *Thread) GetFollowers() uint32 {
+	if x != nil {
+		return x.Followers
+	}
+	return 0
+}
+
+func (x *Thread) GetFollowing() bool {
+	if x != nil {
+		return x.Following
+	}
+	return false
+}
+
+// 话题的回复数变化，客户端据此更新根消息上的回复数
+type ThreadUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ThreadId      string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	ReplyCount    uint32                 `protobuf:"varint,3,opt,name=reply_count,json=replyCount,proto3" json:"reply_count,omitempty"`
+	LastUser      string                 `protobuf:"bytes,4,opt,name=last_user,json=lastUser,proto3" json:"last_user,omitempty"`             // 最后回复的用户
+	LastReplyAt   int64                  `protobuf:"varint,5,opt,name=last_reply_at,json=lastReplyAt,proto3" json:"last_reply_at,omitempty"` // 毫秒时间戳
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ThreadUpdate) Reset() {
+	*x = ThreadUpdate{}
+	mi := &file_proto_chat_chat_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreadUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreadUpdate) ProtoMessage() {}
+
+func (x *ThreadUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreadUpdate.ProtoReflect.Descriptor instead.
+func (*ThreadUpdate) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *ThreadUpdate) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *ThreadUpdate) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *ThreadUpdate) GetReplyCount() uint32 {
+	if x != nil {
+		return x.ReplyCount
+	}
+	return 0
+}
+
+func (x *ThreadUpdate) GetLastUser() string {
+	if x != nil {
+		return x.LastUser
+	}
+	return ""
+}
+
+func (x *ThreadUpdate) GetLastReplyAt() int64 {
+	if x != nil {
+		return x.LastReplyAt
+	}
+	return 0
+}
+
+type ContactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Contact       string                 `protobuf:"bytes,2,opt,name=contact,proto3" json:"contact,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContactRequest) Reset() {
+	*x = ContactRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactRequest) ProtoMessage() {}
+
+func (x *ContactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactRequest.ProtoReflect.Descriptor instead.
+func (*ContactRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *ContactRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ContactRequest) GetContact() string {
+	if x != nil {
+		return x.Contact
+	}
+	return ""
+}
+
+// 用户的联系人，按名字排序
+type Contacts struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Contacts      []*Contact             `protobuf:"bytes,2,rep,name=contacts,proto3" json:"contacts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Contacts) Reset() {
+	*x = Contacts{}
+	mi := &file_proto_chat_chat_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Contacts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Contacts) ProtoMessage() {}
+
+func (x *Contacts) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Contacts.ProtoReflect.Descriptor instead.
+func (*Contacts) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *Contacts) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Contacts) GetContacts() []*Contact {
+	if x != nil {
+		return x.Contacts
+	}
+	return nil
+}
+
+type Contact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Online        bool                   `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`
+	Status        PresenceStatus         `protobuf:"varint,3,opt,name=status,proto3,enum=chat.PresenceStatus" json:"status,omitempty"` // 在线时的状态
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Contact) Reset() {
+	*x = Contact{}
+	mi := &file_proto_chat_chat_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Contact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Contact) ProtoMessage() {}
+
+func (x *Contact) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Contact.ProtoReflect.Descriptor instead.
+func (*Contact) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *Contact) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Contact) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *Contact) GetStatus() PresenceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PresenceStatus_PRESENCE_AVAILABLE
+}
+
+// 文件分块，上传和下载共用
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"` // 上传 ID，由客户端生成（16-64 个字母、数字、- 或 _），续传时保持不变
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`                    // 本块在文件中的起始位置
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`                         // 最大 1MB
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`                         // 文件名
+	Size          int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`                        // 文件总字节数
+	Sha256        string                 `protobuf:"bytes,6,opt,name=sha256,proto3" json:"sha256,omitempty"`                     // 整个文件的 SHA-256，十六进制
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_proto_chat_chat_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *Chunk) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *Chunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Chunk) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Chunk) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Chunk) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type AttachmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`       // 从该位置开始下载，用于续传
+	Thumbnail     int32                  `protobuf:"varint,3,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"` // 下载该尺寸的缩略图，0 为原文件
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachmentRequest) Reset() {
+	*x = AttachmentRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachmentRequest) ProtoMessage() {}
+
+func (x *AttachmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachmentRequest.ProtoReflect.Descriptor instead.
+func (*AttachmentRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *AttachmentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AttachmentRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *AttachmentRequest) GetThumbnail() int32 {
+	if x != nil {
+		return x.Thumbnail
+	}
+	return 0
+}
+
+type UploadOffsetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadOffsetRequest) Reset() {
+	*x = UploadOffsetRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadOffsetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadOffsetRequest) ProtoMessage() {}
+
+func (x *UploadOffsetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadOffsetRequest.ProtoReflect.Descriptor instead.
+func (*UploadOffsetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *UploadOffsetRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+type UploadOffset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int64                  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"` // 已收到的字节数，未知的上传为 0
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadOffset) Reset() {
+	*x = UploadOffset{}
+	mi := &file_proto_chat_chat_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadOffset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadOffset) ProtoMessage() {}
+
+func (x *UploadOffset) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadOffset.ProtoReflect.Descriptor instead.
+func (*UploadOffset) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *UploadOffset) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type DownloadUrl struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"` // 预签名地址，过期后需重新获取
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadUrl) Reset() {
+	*x = DownloadUrl{}
+	mi := &file_proto_chat_chat_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadUrl) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadUrl) ProtoMessage() {}
+
+func (x *DownloadUrl) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadUrl.ProtoReflect.Descriptor instead.
+func (*DownloadUrl) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *DownloadUrl) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type ExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`  // 空表示默认房间
+	From          int64                  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"` // 起始时间（含），UTC Unix 毫秒，0 表示不限
+	To            int64                  `protobuf:"varint,3,opt,name=to,proto3" json:"to,omitempty"`     // 结束时间（不含），UTC Unix 毫秒，0 表示不限
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *ExportRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *ExportRequest) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+type ImportSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Imported      int64                  `protobuf:"varint,1,opt,name=imported,proto3" json:"imported,omitempty"`
+	Skipped       int64                  `protobuf:"varint,2,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportSummary) Reset() {
+	*x = ImportSummary{}
+	mi := &file_proto_chat_chat_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportSummary) ProtoMessage() {}
+
+func (x *ImportSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportSummary.ProtoReflect.Descriptor instead.
+func (*ImportSummary) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *ImportSummary) GetImported() int64 {
+	if x != nil {
+		return x.Imported
+	}
+	return 0
+}
+
+func (x *ImportSummary) GetSkipped() int64 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          int64                  `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`                         // 起始时间（含），UTC Unix 毫秒，0 表示 to 之前 24 小时（按天为 30 天）
+	To            int64                  `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`                             // 结束时间（不含），UTC Unix 毫秒，0 表示现在
+	Daily         bool                   `protobuf:"varint,3,opt,name=daily,proto3" json:"daily,omitempty"`                       // 按 UTC 天汇总，默认按小时
+	TopRooms      int32                  `protobuf:"varint,4,opt,name=top_rooms,json=topRooms,proto3" json:"top_rooms,omitempty"` // 返回消息最多的房间数，0 表示 10
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[79]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[79]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *StatsRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetDaily() bool {
+	if x != nil {
+		return x.Daily
+	}
+	return false
+}
+
+func (x *StatsRequest) GetTopRooms() int32 {
+	if x != nil {
+		return x.TopRooms
+	}
+	return 0
+}
+
+type Stats struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Buckets         []*StatsBucket         `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`                             // 按时间顺序，没有活动的时段也会列出
+	Messages        int64                  `protobuf:"varint,2,opt,name=messages,proto3" json:"messages,omitempty"`                          // 范围内的消息数，含私信
+	ActiveUsers     int32                  `protobuf:"varint,3,opt,name=active_users,json=activeUsers,proto3" json:"active_users,omitempty"` // 范围内发过消息的用户数
+	TopRooms        []*RoomCount           `protobuf:"bytes,4,rep,name=top_rooms,json=topRooms,proto3" json:"top_rooms,omitempty"`
+	PeakConcurrency int32                  `protobuf:"varint,5,opt,name=peak_concurrency,json=peakConcurrency,proto3" json:"peak_concurrency,omitempty"` // 范围内同时打开的聊天流的最大数
+	PeakAt          int64                  `protobuf:"varint,6,opt,name=peak_at,json=peakAt,proto3" json:"peak_at,omitempty"`                            // 达到峰值的时间，UTC Unix 毫秒
+	Leadership      *Leadership            `protobuf:"bytes,7,opt,name=leadership,proto3" json:"leadership,omitempty"`                                   // 本实例的选主状态，未开启选主时为空
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Stats) Reset() {
+	*x = Stats{}
+	mi := &file_proto_chat_chat_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stats) ProtoMessage() {}
+
+func (x *Stats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stats.ProtoReflect.Descriptor instead.
+func (*Stats) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *Stats) GetBuckets() []*StatsBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *Stats) GetMessages() int64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *Stats) GetActiveUsers() int32 {
+	if x != nil {
+		return x.ActiveUsers
+	}
+	return 0
+}
+
+func (x *Stats) GetTopRooms() []*RoomCount {
+	if x != nil {
+		return x.TopRooms
+	}
+	return nil
+}
+
+func (x *Stats) GetPeakConcurrency() int32 {
+	if x != nil {
+		return x.PeakConcurrency
+	}
+	return 0
+}
+
+func (x *Stats) GetPeakAt() int64 {
+	if x != nil {
+		return x.PeakAt
+	}
+	return 0
+}
+
+func (x *Stats) GetLeadership() *Leadership {
+	if x != nil {
+		return x.Leadership
+	}
+	return nil
+}
+
+type WatchStatsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IntervalSeconds int32                  `protobuf:"varint,1,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"` // 快照间隔，0 表示 5 秒，最长 3600 秒
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WatchStatsRequest) Reset() {
+	*x = WatchStatsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[81]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStatsRequest) ProtoMessage() {}
+
+func (x *WatchStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[81]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStatsRequest.ProtoReflect.Descriptor instead.
+func (*WatchStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *WatchStatsRequest) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+// 本实例某一时刻的状态
+type StatsSnapshot struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Time              int64                  `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`                                                       // UTC Unix 毫秒
+	Connections       int32                  `protobuf:"varint,2,opt,name=connections,proto3" json:"connections,omitempty"`                                         // 打开的聊天流数
+	Users             int32                  `protobuf:"varint,3,opt,name=users,proto3" json:"users,omitempty"`                                                     // 至少有一个连接的用户数
+	Rooms             int32                  `protobuf:"varint,4,opt,name=rooms,proto3" json:"rooms,omitempty"`                                                     // 至少有一个连接的房间数
+	Messages          int64                  `protobuf:"varint,5,opt,name=messages,proto3" json:"messages,omitempty"`                                               // 启动以来接受的消息数，含私信
+	MessagesPerSecond float64                `protobuf:"fixed64,6,opt,name=messages_per_second,json=messagesPerSecond,proto3" json:"messages_per_second,omitempty"` // 最近一个间隔（最长 60 秒）的平均消息速率
+	PendingSends      int32                  `protobuf:"varint,7,opt,name=pending_sends,json=pendingSends,proto3" json:"pending_sends,omitempty"`                   // 正在扇出、尚未写入各连接的消息份数
+	WatcherBacklog    int32                  `protobuf:"varint,8,opt,name=watcher_backlog,json=watcherBacklog,proto3" json:"watcher_backlog,omitempty"`             // 只读订阅（WatchRoom）中尚未发出的消息数
+	Watchers          int32                  `protobuf:"varint,9,opt,name=watchers,proto3" json:"watchers,omitempty"`                                               // 只读订阅数
+	ClusterQueue      int32                  `protobuf:"varint,10,opt,name=cluster_queue,json=clusterQueue,proto3" json:"cluster_queue,omitempty"`                  // 等待写入注册表的集群更新数，未开启集群时为 0
+	Goroutines        int32                  `protobuf:"varint,11,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *StatsSnapshot) Reset() {
+	*x = StatsSnapshot{}
+	mi := &file_proto_chat_chat_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsSnapshot) ProtoMessage() {}
+
+func (x *StatsSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsSnapshot.ProtoReflect.Descriptor instead.
+func (*StatsSnapshot) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *StatsSnapshot) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetConnections() int32 {
+	if x != nil {
+		return x.Connections
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetUsers() int32 {
+	if x != nil {
+		return x.Users
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetRooms() int32 {
+	if x != nil {
+		return x.Rooms
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetMessages() int64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetMessagesPerSecond() float64 {
+	if x != nil {
+		return x.MessagesPerSecond
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetPendingSends() int32 {
+	if x != nil {
+		return x.PendingSends
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetWatcherBacklog() int32 {
+	if x != nil {
+		return x.WatcherBacklog
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetWatchers() int32 {
+	if x != nil {
+		return x.Watchers
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetClusterQueue() int32 {
+	if x != nil {
+		return x.ClusterQueue
+	}
+	return 0
+}
+
+func (x *StatsSnapshot) GetGoroutines() int32 {
+	if x != nil {
+		return x.Goroutines
+	}
+	return 0
+}
+
+// 多实例部署时只由主实例运行的后台任务（如清理过期封禁）的选主状态
+type Leadership struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`        // 锁的名称
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`            // 本实例在锁中的 ID
+	Leader        bool                   `protobuf:"varint,3,opt,name=leader,proto3" json:"leader,omitempty"`   // 本实例是否为主
+	Holder        string                 `protobuf:"bytes,4,opt,name=holder,proto3" json:"holder,omitempty"`    // 最近一次看到的主实例 ID，无主时为空
+	Since         int64                  `protobuf:"varint,5,opt,name=since,proto3" json:"since,omitempty"`     // 上次成为或不再是主的时间，UTC Unix 毫秒
+	Elected       uint64                 `protobuf:"varint,6,opt,name=elected,proto3" json:"elected,omitempty"` // 成为主的次数
+	Lost          uint64                 `protobuf:"varint,7,opt,name=lost,proto3" json:"lost,omitempty"`       // 非正常关闭而失去主的次数
+	Errors        uint64                 `protobuf:"varint,8,opt,name=errors,proto3" json:"errors,omitempty"`   // 访问锁失败的次数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Leadership) Reset() {
+	*x = Leadership{}
+	mi := &file_proto_chat_chat_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Leadership) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Leadership) ProtoMessage() {}
+
+func (x *Leadership) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Leadership.ProtoReflect.Descriptor instead.
+func (*Leadership) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *Leadership) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Leadership) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Leadership) GetLeader() bool {
+	if x != nil {
+		return x.Leader
+	}
+	return false
+}
+
+func (x *Leadership) GetHolder() string {
+	if x != nil {
+		return x.Holder
+	}
+	return ""
+}
+
+func (x *Leadership) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *Leadership) GetElected() uint64 {
+	if x != nil {
+		return x.Elected
+	}
+	return 0
+}
+
+func (x *Leadership) GetLost() uint64 {
+	if x != nil {
+		return x.Lost
+	}
+	return 0
+}
+
+func (x *Leadership) GetErrors() uint64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+type StatsBucket struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Start           int64                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"` // 时段开始时间，UTC Unix 毫秒
+	Messages        int64                  `protobuf:"varint,2,opt,name=messages,proto3" json:"messages,omitempty"`
+	ActiveUsers     int32                  `protobuf:"varint,3,opt,name=active_users,json=activeUsers,proto3" json:"active_users,omitempty"`
+	PeakConcurrency int32                  `protobuf:"varint,4,opt,name=peak_concurrency,json=peakConcurrency,proto3" json:"peak_concurrency,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StatsBucket) Reset() {
+	*x = StatsBucket{}
+	mi := &file_proto_chat_chat_proto_msgTypes[84]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsBucket) ProtoMessage() {}
+
+func (x *StatsBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[84]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsBucket.ProtoReflect.Descriptor instead.
+func (*StatsBucket) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{84}
+}
+
+func (x *StatsBucket) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *StatsBucket) GetMessages() int64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *StatsBucket) GetActiveUsers() int32 {
+	if x != nil {
+		return x.ActiveUsers
+	}
+	return 0
+}
+
+func (x *StatsBucket) GetPeakConcurrency() int32 {
+	if x != nil {
+		return x.PeakConcurrency
+	}
+	return 0
+}
+
+type RoomCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Messages      int64                  `protobuf:"varint,2,opt,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomCount) Reset() {
+	*x = RoomCount{}
+	mi := &file_proto_chat_chat_proto_msgTypes[85]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomCount) ProtoMessage() {}
+
+func (x *RoomCount) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[85]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomCount.ProtoReflect.Descriptor instead.
+func (*RoomCount) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *RoomCount) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomCount) GetMessages() int64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+// 配额，0 表示不限制。按天的计数以 UTC 日期为界
+type Quota struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MessagesPerDay int64                  `protobuf:"varint,1,opt,name=messages_per_day,json=messagesPerDay,proto3" json:"messages_per_day,omitempty"` // 每天可发送的消息数，租户的私信也计入
+	StorageBytes   int64                  `protobuf:"varint,2,opt,name=storage_bytes,json=storageBytes,proto3" json:"storage_bytes,omitempty"`         // 存储的消息文本、代码和附件的总字节数
+	MaxMembers     int32                  `protobuf:"varint,3,opt,name=max_members,json=maxMembers,proto3" json:"max_members,omitempty"`               // 仅房间：同时在房间中的用户数
+	MaxRooms       int32                  `protobuf:"varint,4,opt,name=max_rooms,json=maxRooms,proto3" json:"max_rooms,omitempty"`                     // 仅租户：其用户同时所在的房间数，默认房间不计入
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Quota) Reset() {
+	*x = Quota{}
+	mi := &file_proto_chat_chat_proto_msgTypes[86]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quota) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quota) ProtoMessage() {}
+
+func (x *Quota) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[86]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quota.ProtoReflect.Descriptor instead.
+func (*Quota) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{86}
+}
+
+func (x *Quota) GetMessagesPerDay() int64 {
+	if x != nil {
+		return x.MessagesPerDay
+	}
+	return 0
+}
+
+func (x *Quota) GetStorageBytes() int64 {
+	if x != nil {
+		return x.StorageBytes
+	}
+	return 0
+}
+
+func (x *Quota) GetMaxMembers() int32 {
+	if x != nil {
+		return x.MaxMembers
+	}
+	return 0
+}
+
+func (x *Quota) GetMaxRooms() int32 {
+	if x != nil {
+		return x.MaxRooms
+	}
+	return 0
+}
+
+type QuotaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         QuotaScope             `protobuf:"varint,1,opt,name=scope,proto3,enum=chat.QuotaScope" json:"scope,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"` // 租户名或房间名
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuotaRequest) Reset() {
+	*x = QuotaRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[87]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuotaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuotaRequest) ProtoMessage() {}
+
+func (x *QuotaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[87]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuotaRequest.ProtoReflect.Descriptor instead.
+func (*QuotaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *QuotaRequest) GetScope() QuotaScope {
+	if x != nil {
+		return x.Scope
+	}
+	return QuotaScope_QUOTA_TENANT
+}
+
+func (x *QuotaRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SetQuotaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         QuotaScope             `protobuf:"varint,1,opt,name=scope,proto3,enum=chat.QuotaScope" json:"scope,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Quota         *Quota                 `protobuf:"bytes,3,opt,name=quota,proto3" json:"quota,omitempty"`  // 整体替换默认配额
+	Clear         bool                   `protobuf:"varint,4,opt,name=clear,proto3" json:"clear,omitempty"` // 删除设置，恢复默认配额
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetQuotaRequest) Reset() {
+	*x = SetQuotaRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetQuotaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetQuotaRequest) ProtoMessage() {}
+
+func (x *SetQuotaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetQuotaRequest.ProtoReflect.Descriptor instead.
+func (*SetQuotaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *SetQuotaRequest) GetScope() QuotaScope {
+	if x != nil {
+		return x.Scope
+	}
+	return QuotaScope_QUOTA_TENANT
+}
+
+func (x *SetQuotaRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SetQuotaRequest) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *SetQuotaRequest) GetClear() bool {
+	if x != nil {
+		return x.Clear
+	}
+	return false
+}
+
+type QuotaUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         QuotaScope             `protobuf:"varint,1,opt,name=scope,proto3,enum=chat.QuotaScope" json:"scope,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Quota         *Quota                 `protobuf:"bytes,3,opt,name=quota,proto3" json:"quota,omitempty"`    // 生效的配额
+	Custom        bool                   `protobuf:"varint,4,opt,name=custom,proto3" json:"custom,omitempty"` // quota 由 SetQuota 设置，而不是服务器的默认值
+	MessagesToday int64                  `protobuf:"varint,5,opt,name=messages_today,json=messagesToday,proto3" json:"messages_today,omitempty"`
+	StorageBytes  int64                  `protobuf:"varint,6,opt,name=storage_bytes,json=storageBytes,proto3" json:"storage_bytes,omitempty"`
+	Members       int32                  `protobuf:"varint,7,opt,name=members,proto3" json:"members,omitempty"` // 仅房间
+	Rooms         int32                  `protobuf:"varint,8,opt,name=rooms,proto3" json:"rooms,omitempty"`     // 仅租户
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuotaUsage) Reset() {
+	*x = QuotaUsage{}
+	mi := &file_proto_chat_chat_proto_msgTypes[89]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuotaUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuotaUsage) ProtoMessage() {}
+
+func (x *QuotaUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[89]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuotaUsage.ProtoReflect.Descriptor instead.
+func (*QuotaUsage) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{89}
+}
+
+func (x *QuotaUsage) GetScope() QuotaScope {
+	if x != nil {
+		return x.Scope
+	}
+	return QuotaScope_QUOTA_TENANT
+}
+
+func (x *QuotaUsage) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *QuotaUsage) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *QuotaUsage) GetCustom() bool {
+	if x != nil {
+		return x.Custom
+	}
+	return false
+}
+
+func (x *QuotaUsage) GetMessagesToday() int64 {
+	if x != nil {
+		return x.MessagesToday
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetStorageBytes() int64 {
+	if x != nil {
+		return x.StorageBytes
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetMembers() int32 {
+	if x != nil {
+		return x.Members
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetRooms() int32 {
+	if x != nil {
+		return x.Rooms
+	}
+	return 0
+}
+
+type SlashCommand struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // 不带 /，小写字母、数字、- 和 _，内置命令和插件命令不能注册
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`   // http 或 https 回调地址
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Secret        string                 `protobuf:"bytes,4,opt,name=secret,proto3" json:"secret,omitempty"`                  // 非空时用于签名回调请求，只写
+	BotName       string                 `protobuf:"bytes,5,opt,name=bot_name,json=botName,proto3" json:"bot_name,omitempty"` // 房间中回复的发送者，默认为命令名
+	Rooms         []string               `protobuf:"bytes,6,rep,name=rooms,proto3" json:"rooms,omitempty"`                    // 只能在这些房间中使用，为空时不限
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SlashCommand) Reset() {
+	*x = SlashCommand{}
+	mi := &file_proto_chat_chat_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SlashCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SlashCommand) ProtoMessage() {}
+
+func (x *SlashCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[90]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SlashCommand.ProtoReflect.Descriptor instead.
+func (*SlashCommand) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *SlashCommand) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SlashCommand) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *SlashCommand) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *SlashCommand) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *SlashCommand) GetBotName() string {
+	if x != nil {
+		return x.BotName
+	}
+	return ""
+}
+
+func (x *SlashCommand) GetRooms() []string {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+type UnregisterCommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnregisterCommandRequest) Reset() {
+	*x = UnregisterCommandRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[91]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnregisterCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnregisterCommandRequest) ProtoMessage() {}
+
+func (x *UnregisterCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[91]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnregisterCommandRequest.ProtoReflect.Descriptor instead.
+func (*UnregisterCommandRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{91}
+}
+
+func (x *UnregisterCommandRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListCommandsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsRequest) Reset() {
+	*x = ListCommandsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[92]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsRequest) ProtoMessage() {}
+
+func (x *ListCommandsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[92]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsRequest.ProtoReflect.Descriptor instead.
+func (*ListCommandsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{92}
+}
+
+type CommandList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commands      []*SlashCommand        `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"` // 按名称排序
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandList) Reset() {
+	*x = CommandList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[93]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandList) ProtoMessage() {}
+
+func (x *CommandList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[93]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandList.ProtoReflect.Descriptor instead.
+func (*CommandList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *CommandList) GetCommands() []*SlashCommand {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+// 一个聊天连接。客户端可在 gRPC 元数据 x-chat-user-agent 中说明设备，否则为
+// gRPC 的 user-agent；网关填写浏览器的 User-Agent，并在 x-forwarded-for 中
+// 带上浏览器的地址
+type Session struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Room          string                 `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,4,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Ip            string                 `protobuf:"bytes,5,opt,name=ip,proto3" json:"ip,omitempty"`                                         // 连接的来源地址，经过网关时为网关的地址
+	ForwardedFor  string                 `protobuf:"bytes,6,opt,name=forwarded_for,json=forwardedFor,proto3" json:"forwarded_for,omitempty"` // 网关报告的浏览器地址，未经验证，仅供参考
+	ConnectedAt   int64                  `protobuf:"varint,7,opt,name=connected_at,json=connectedAt,proto3" json:"connected_at,omitempty"`   // UTC Unix 毫秒
+	Device        string                 `protobuf:"bytes,8,opt,name=device,proto3" json:"device,omitempty"`                                 // 客户端报告的设备名，如主机名或“Firefox on Linux”
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_proto_chat_chat_proto_msgTypes[94]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[94]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *Session) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Session) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Session) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Session) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *Session) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Session) GetForwardedFor() string {
+	if x != nil {
+		return x.ForwardedFor
+	}
+	return ""
+}
+
+func (x *Session) GetConnectedAt() int64 {
+	if x != nil {
+		return x.ConnectedAt
+	}
+	return 0
+}
+
+func (x *Session) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+// 欢迎消息只发给刚加入的连接：全服的每日消息（MOTD）在加入聊天时发送，
+// 房间的欢迎消息（规则、置顶链接等）在加入或进入该房间时发送，
+// 每条作为一条系统消息按顺序发送，带元数据 welcome（"motd" 或 "room"）
+type Welcome struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`         // 空表示每日消息
+	Messages      []string               `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"` // 最多 10 条
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Welcome) Reset() {
+	*x = Welcome{}
+	mi := &file_proto_chat_chat_proto_msgTypes[95]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Welcome) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Welcome) ProtoMessage() {}
+
+func (x *Welcome) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[95]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Welcome.ProtoReflect.Descriptor instead.
+func (*Welcome) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *Welcome) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Welcome) GetMessages() []string {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type WelcomeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WelcomeRequest) Reset() {
+	*x = WelcomeRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[96]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WelcomeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WelcomeRequest) ProtoMessage() {}
+
+func (x *WelcomeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[96]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WelcomeRequest.ProtoReflect.Descriptor instead.
+func (*WelcomeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{96}
+}
+
+func (x *WelcomeRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"` // 空表示所有用户
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[97]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[97]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *ListSessionsRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type SessionList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*Session             `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"` // 按连接时间排序
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionList) Reset() {
+	*x = SessionList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[98]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionList) ProtoMessage() {}
+
+func (x *SessionList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[98]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionList.ProtoReflect.Descriptor instead.
+func (*SessionList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *SessionList) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type SetRoomPrivateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Private       bool                   `protobuf:"varint,2,opt,name=private,proto3" json:"private,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRoomPrivateRequest) Reset() {
+	*x = SetRoomPrivateRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[99]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRoomPrivateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRoomPrivateRequest) ProtoMessage() {}
+
+func (x *SetRoomPrivateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[99]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRoomPrivateRequest.ProtoReflect.Descriptor instead.
+func (*SetRoomPrivateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *SetRoomPrivateRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *SetRoomPrivateRequest) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+type CreateInviteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	MaxUses       int32                  `protobuf:"varint,2,opt,name=max_uses,json=maxUses,proto3" json:"max_uses,omitempty"`          // 0 表示不限次数
+	TtlSeconds    int64                  `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // 有效期，0 表示 7 天
+	CreatedBy     string                 `protobuf:"bytes,4,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`     // 备注创建者，仅供查看
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateInviteRequest) Reset() {
+	*x = CreateInviteRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[100]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateInviteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateInviteRequest) ProtoMessage() {}
+
+func (x *CreateInviteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[100]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateInviteRequest.ProtoReflect.Descriptor instead.
+func (*CreateInviteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *CreateInviteRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *CreateInviteRequest) GetMaxUses() int32 {
+	if x != nil {
+		return x.MaxUses
+	}
+	return 0
+}
+
+func (x *CreateInviteRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *CreateInviteRequest) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+type Invite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"` // 只在创建和管理接口中返回
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	MaxUses       int32                  `protobuf:"varint,3,opt,name=max_uses,json=maxUses,proto3" json:"max_uses,omitempty"`
+	Uses          int32                  `protobuf:"varint,4,opt,name=uses,proto3" json:"uses,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // UTC Unix 毫秒
+	ExpiresAt     int64                  `protobuf:"varint,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // UTC Unix 毫秒
+	CreatedBy     string                 `protobuf:"bytes,7,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Invite) Reset() {
+	*x = Invite{}
+	mi := &file_proto_chat_chat_proto_msgTypes[101]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invite) ProtoMessage() {}
+
+func (x *Invite) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[101]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invite.ProtoReflect.Descriptor instead.
+func (*Invite) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *Invite) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Invite) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Invite) GetMaxUses() int32 {
+	if x != nil {
+		return x.MaxUses
+	}
+	return 0
+}
+
+func (x *Invite) GetUses() int32 {
+	if x != nil {
+		return x.Uses
+	}
+	return 0
+}
+
+func (x *Invite) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Invite) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *Invite) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+type InviteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InviteRequest) Reset() {
+	*x = InviteRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[102]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InviteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InviteRequest) ProtoMessage() {}
+
+func (x *InviteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[102]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InviteRequest.ProtoReflect.Descriptor instead.
+func (*InviteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *InviteRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type ListInvitesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 空表示所有房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInvitesRequest) Reset() {
+	*x = ListInvitesRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[103]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInvitesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInvitesRequest) ProtoMessage() {}
+
+func (x *ListInvitesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[103]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInvitesRequest.ProtoReflect.Descriptor instead.
+func (*ListInvitesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *ListInvitesRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type InviteList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invites       []*Invite              `protobuf:"bytes,1,rep,name=invites,proto3" json:"invites,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InviteList) Reset() {
+	*x = InviteList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[104]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InviteList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InviteList) ProtoMessage() {}
+
+func (x *InviteList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[104]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InviteList.ProtoReflect.Descriptor instead.
+func (*InviteList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *InviteList) GetInvites() []*Invite {
+	if x != nil {
+		return x.Invites
+	}
+	return nil
+}
+
+type SetRoomRoleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Role          RoomRole               `protobuf:"varint,3,opt,name=role,proto3,enum=chat.RoomRole" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRoomRoleRequest) Reset() {
+	*x = SetRoomRoleRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[105]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRoomRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRoomRoleRequest) ProtoMessage() {}
+
+func (x *SetRoomRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[105]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRoomRoleRequest.ProtoReflect.Descriptor instead.
+func (*SetRoomRoleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *SetRoomRoleRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *SetRoomRoleRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *SetRoomRoleRequest) GetRole() RoomRole {
+	if x != nil {
+		return x.Role
+	}
+	return RoomRole_ROLE_MEMBER
+}
+
+type RevokeSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"` // 不填 id 时退出该用户的全部会话
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeSessionRequest) Reset() {
+	*x = RevokeSessionRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[106]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeSessionRequest) ProtoMessage() {}
+
+func (x *RevokeSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[106]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeSessionRequest.ProtoReflect.Descriptor instead.
+func (*RevokeSessionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *RevokeSessionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RevokeSessionRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+// 封禁，服务器拒绝被封禁者加入时作为 PERMISSION_DENIED 错误的详情返回
+type Ban struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Scope         BanScope               `protobuf:"varint,2,opt,name=scope,proto3,enum=chat.BanScope" json:"scope,omitempty"`
+	Target        string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // UTC Unix 毫秒
+	ExpiresAt     int64                  `protobuf:"varint,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // UTC Unix 毫秒，0 为永久
+	CreatedBy     string                 `protobuf:"bytes,7,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	Appeal        string                 `protobuf:"bytes,8,opt,name=appeal,proto3" json:"appeal,omitempty"` // 申诉或处理意见
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ban) Reset() {
+	*x = Ban{}
+	mi := &file_proto_chat_chat_proto_msgTypes[107]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ban) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ban) ProtoMessage() {}
+
+func (x *Ban) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[107]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ban.ProtoReflect.Descriptor instead.
+func (*Ban) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{107}
+}
+
+func (x *Ban) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Ban) GetScope() BanScope {
+	if x != nil {
+		return x.Scope
+	}
+	return BanScope_BAN_ACCOUNT
+}
+
+func (x *Ban) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Ban) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Ban) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Ban) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *Ban) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Ban) GetAppeal() string {
+	if x != nil {
+		return x.Appeal
+	}
+	return ""
+}
+
+type CreateBanRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Scope           BanScope               `protobuf:"varint,1,opt,name=scope,proto3,enum=chat.BanScope" json:"scope,omitempty"`
+	Target          string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Reason          string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	DurationSeconds int64                  `protobuf:"varint,4,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"` // 0 为永久
+	CreatedBy       string                 `protobuf:"bytes,5,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreateBanRequest) Reset() {
+	*x = CreateBanRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[108]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBanRequest) ProtoMessage() {}
+
+func (x *CreateBanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[108]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBanRequest.ProtoReflect.Descriptor instead.
+func (*CreateBanRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *CreateBanRequest) GetScope() BanScope {
+	if x != nil {
+		return x.Scope
+	}
+	return BanScope_BAN_ACCOUNT
+}
+
+func (x *CreateBanRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *CreateBanRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *CreateBanRequest) GetDurationSeconds() int64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *CreateBanRequest) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+type BanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BanRequest) Reset() {
+	*x = BanRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[109]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BanRequest) ProtoMessage() {}
+
+func (x *BanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[109]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BanRequest.ProtoReflect.Descriptor instead.
+func (*BanRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{109}
+}
+
+func (x *BanRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListBansRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"` // 空表示全部
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBansRequest) Reset() {
+	*x = ListBansRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[110]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBansRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBansRequest) ProtoMessage() {}
+
+func (x *ListBansRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[110]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBansRequest.ProtoReflect.Descriptor instead.
+func (*ListBansRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{110}
+}
+
+func (x *ListBansRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type BanList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bans          []*Ban                 `protobuf:"bytes,1,rep,name=bans,proto3" json:"bans,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BanList) Reset() {
+	*x = BanList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[111]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BanList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BanList) ProtoMessage() {}
+
+func (x *BanList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[111]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BanList.ProtoReflect.Descriptor instead.
+func (*BanList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{111}
+}
+
+func (x *BanList) GetBans() []*Ban {
+	if x != nil {
+		return x.Bans
+	}
+	return nil
+}
+
+type SetBanAppealRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Appeal        string                 `protobuf:"bytes,2,opt,name=appeal,proto3" json:"appeal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetBanAppealRequest) Reset() {
+	*x = SetBanAppealRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[112]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetBanAppealRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetBanAppealRequest) ProtoMessage() {}
+
+func (x *SetBanAppealRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[112]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetBanAppealRequest.ProtoReflect.Descriptor instead.
+func (*SetBanAppealRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{112}
+}
+
+func (x *SetBanAppealRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetBanAppealRequest) GetAppeal() string {
+	if x != nil {
+		return x.Appeal
+	}
+	return ""
+}
+
+// 屏蔽词规则，在插件过滤之后、脚本之前检查公开消息和私信，私信只使用全局规则
+type BlockRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`           // 由服务器生成
+	Pattern       string                 `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"` // 整词（不区分大小写）或 RE2 正则
+	Regex         bool                   `protobuf:"varint,3,opt,name=regex,proto3" json:"regex,omitempty"`
+	Action        BlockAction            `protobuf:"varint,4,opt,name=action,proto3,enum=chat.BlockAction" json:"action,omitempty"`
+	Room          string                 `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`                             // 空表示所有房间
+	CreatedAt     int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // UTC Unix 毫秒
+	CreatedBy     string                 `protobuf:"bytes,7,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockRule) Reset() {
+	*x = BlockRule{}
+	mi := &file_proto_chat_chat_proto_msgTypes[113]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockRule) ProtoMessage() {}
+
+func (x *BlockRule) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[113]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockRule.ProtoReflect.Descriptor instead.
+func (*BlockRule) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{113}
+}
+
+func (x *BlockRule) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BlockRule) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *BlockRule) GetRegex() bool {
+	if x != nil {
+		return x.Regex
+	}
+	return false
+}
+
+func (x *BlockRule) GetAction() BlockAction {
+	if x != nil {
+		return x.Action
+	}
+	return BlockAction_BLOCK_MASK
+}
+
+func (x *BlockRule) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *BlockRule) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *BlockRule) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+type BlockRuleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockRuleRequest) Reset() {
+	*x = BlockRuleRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[114]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockRuleRequest) ProtoMessage() {}
+
+func (x *BlockRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[114]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockRuleRequest.ProtoReflect.Descriptor instead.
+func (*BlockRuleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{114}
+}
+
+func (x *BlockRuleRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListBlockRulesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 空表示全部
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBlockRulesRequest) Reset() {
+	*x = ListBlockRulesRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[115]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBlockRulesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBlockRulesRequest) ProtoMessage() {}
+
+func (x *ListBlockRulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[115]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBlockRulesRequest.ProtoReflect.Descriptor instead.
+func (*ListBlockRulesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{115}
+}
+
+func (x *ListBlockRulesRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type BlockRuleList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*BlockRule           `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockRuleList) Reset() {
+	*x = BlockRuleList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[116]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockRuleList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockRuleList) ProtoMessage() {}
+
+func (x *BlockRuleList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[116]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockRuleList.ProtoReflect.Descriptor instead.
+func (*BlockRuleList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{116}
+}
+
+func (x *BlockRuleList) GetRules() []*BlockRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// 被病毒扫描隔离的附件，下载时返回 FAILED_PRECONDITION
+type QuarantineReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AttachmentId  string                 `protobuf:"bytes,1,opt,name=attachment_id,json=attachmentId,proto3" json:"attachment_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Threat        string                 `protobuf:"bytes,4,opt,name=threat,proto3" json:"threat,omitempty"` // 扫描器报告的威胁名称
+	Source        string                 `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"` // 上传者的地址
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuarantineReport) Reset() {
+	*x = QuarantineReport{}
+	mi := &file_proto_chat_chat_proto_msgTypes[117]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuarantineReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarantineReport) ProtoMessage() {}
+
+func (x *QuarantineReport) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[117]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarantineReport.ProtoReflect.Descriptor instead.
+func (*QuarantineReport) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{117}
+}
+
+func (x *QuarantineReport) GetAttachmentId() string {
+	if x != nil {
+		return x.AttachmentId
+	}
+	return ""
+}
+
+func (x *QuarantineReport) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *QuarantineReport) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *QuarantineReport) GetThreat() string {
+	if x != nil {
+		return x.Threat
+	}
+	return ""
+}
+
+func (x *QuarantineReport) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type PluginInfoRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion uint32                 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"` // 服务器的 ProtocolVersion
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PluginInfoRequest) Reset() {
+	*x = PluginInfoRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[118]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginInfoRequest) ProtoMessage() {}
+
+func (x *PluginInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[118]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginInfoRequest.ProtoReflect.Descriptor instead.
+func (*PluginInfoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{118}
+}
+
+func (x *PluginInfoRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+type PluginInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Hooks         []PluginHook           `protobuf:"varint,2,rep,packed,name=hooks,proto3,enum=chat.PluginHook" json:"hooks,omitempty"`
+	Commands      []string               `protobuf:"bytes,3,rep,name=commands,proto3" json:"commands,omitempty"` // 不带 / 的命令名，内置命令（nick、join、leave、translate、summarize）不能被接管
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginInfo) Reset() {
+	*x = PluginInfo{}
+	mi := &file_proto_chat_chat_proto_msgTypes[119]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginInfo) ProtoMessage() {}
+
+func (x *PluginInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[119]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginInfo.ProtoReflect.Descriptor instead.
+func (*PluginInfo) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{119}
+}
+
+func (x *PluginInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetHooks() []PluginHook {
+	if x != nil {
+		return x.Hooks
+	}
+	return nil
+}
+
+func (x *PluginInfo) GetCommands() []string {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+type FilterResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reject        bool                   `protobuf:"varint,1,opt,name=reject,proto3" json:"reject,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`   // 拒绝原因，以系统消息告诉发送者
+	Message       *ChatMessage           `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // 非空时改写消息，只采用 text 和 metadata
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterResult) Reset() {
+	*x = FilterResult{}
+	mi := &file_proto_chat_chat_proto_msgTypes[120]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterResult) ProtoMessage() {}
+
+func (x *FilterResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[120]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterResult.ProtoReflect.Descriptor instead.
+func (*FilterResult) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{120}
+}
+
+func (x *FilterResult) GetReject() bool {
+	if x != nil {
+		return x.Reject
+	}
+	return false
+}
+
+func (x *FilterResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *FilterResult) GetMessage() *ChatMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type PluginAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginAck) Reset() {
+	*x = PluginAck{}
+	mi := &file_proto_chat_chat_proto_msgTypes[121]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginAck) ProtoMessage() {}
+
+func (x *PluginAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[121]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginAck.ProtoReflect.Descriptor instead.
+func (*PluginAck) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{121}
+}
+
+type JoinEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinEvent) Reset() {
+	*x = JoinEvent{}
+	mi := &file_proto_chat_chat_proto_msgTypes[122]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinEvent) ProtoMessage() {}
+
+func (x *JoinEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[122]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinEvent.ProtoReflect.Descriptor instead.
+func (*JoinEvent) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{122}
+}
+
+func (x *JoinEvent) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *JoinEvent) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type JoinDecision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deny          bool                   `protobuf:"varint,1,opt,name=deny,proto3" json:"deny,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` // 拒绝时作为 PERMISSION_DENIED 的错误信息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinDecision) Reset() {
+	*x = JoinDecision{}
+	mi := &file_proto_chat_chat_proto_msgTypes[123]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinDecision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinDecision) ProtoMessage() {}
+
+func (x *JoinDecision) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[123]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinDecision.ProtoReflect.Descriptor instead.
+func (*JoinDecision) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{123}
+}
+
+func (x *JoinDecision) GetDeny() bool {
+	if x != nil {
+		return x.Deny
+	}
+	return false
+}
+
+func (x *JoinDecision) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type PluginCommand struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`       // 发送者所在的房间
+	Command       string                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"` // 不带 /
+	Args          string                 `protobuf:"bytes,4,opt,name=args,proto3" json:"args,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginCommand) Reset() {
+	*x = PluginCommand{}
+	mi := &file_proto_chat_chat_proto_msgTypes[124]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginCommand) ProtoMessage() {}
+
+func (x *PluginCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[124]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginCommand.ProtoReflect.Descriptor instead.
+func (*PluginCommand) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{124}
+}
+
+func (x *PluginCommand) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *PluginCommand) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *PluginCommand) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *PluginCommand) GetArgs() string {
+	if x != nil {
+		return x.Args
+	}
+	return ""
+}
+
+type CommandReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reply         string                 `protobuf:"bytes,1,opt,name=reply,proto3" json:"reply,omitempty"`         // 以系统消息只发给发送者
+	Broadcast     string                 `protobuf:"bytes,2,opt,name=broadcast,proto3" json:"broadcast,omitempty"` // 以系统消息发给发送者所在房间的所有人
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandReply) Reset() {
+	*x = CommandReply{}
+	mi := &file_proto_chat_chat_proto_msgTypes[125]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandReply) ProtoMessage() {}
+
+func (x *CommandReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[125]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandReply.ProtoReflect.Descriptor instead.
+func (*CommandReply) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{125}
+}
+
+func (x *CommandReply) GetReply() string {
+	if x != nil {
+		return x.Reply
+	}
+	return ""
+}
+
+func (x *CommandReply) GetBroadcast() string {
+	if x != nil {
+		return x.Broadcast
+	}
+	return ""
+}
+
+type PeerDelivery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Message       *ChatMessage           `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	FromNode      string                 `protobuf:"bytes,3,opt,name=from_node,json=fromNode,proto3" json:"from_node,omitempty"` // 转发的实例 ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerDelivery) Reset() {
+	*x = PeerDelivery{}
+	mi := &file_proto_chat_chat_proto_msgTypes[126]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerDelivery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerDelivery) ProtoMessage() {}
+
+func (x *PeerDelivery) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[126]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerDelivery.ProtoReflect.Descriptor instead.
+func (*PeerDelivery) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{126}
+}
+
+func (x *PeerDelivery) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *PeerDelivery) GetMessage() *ChatMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *PeerDelivery) GetFromNode() string {
+	if x != nil {
+		return x.FromNode
+	}
+	return ""
+}
+
+type PeerDeliveryResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delivered     bool                   `protobuf:"varint,1,opt,name=delivered,proto3" json:"delivered,omitempty"` // 本实例上有 user 的聊天流
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerDeliveryResult) Reset() {
+	*x = PeerDeliveryResult{}
+	mi := &file_proto_chat_chat_proto_msgTypes[127]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerDeliveryResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerDeliveryResult) ProtoMessage() {}
+
+func (x *PeerDeliveryResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[127]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerDeliveryResult.ProtoReflect.Descriptor instead.
+func (*PeerDeliveryResult) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{127}
+}
+
+func (x *PeerDeliveryResult) GetDelivered() bool {
+	if x != nil {
+		return x.Delivered
+	}
+	return false
+}
+
+// 迁移的房间状态，接收的实例与已有的状态合并
+type RoomState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`        // 房间最新的序号，新实例从其后继续分配
+	History       []*ChatMessage         `protobuf:"bytes,3,rep,name=history,proto3" json:"history,omitempty"` // 内存中的最近消息，按序号排列
+	Members       []*RoomMember          `protobuf:"bytes,4,rep,name=members,proto3" json:"members,omitempty"` // 不含 online 和 status
+	Private       bool                   `protobuf:"varint,5,opt,name=private,proto3" json:"private,omitempty"`
+	Invites       []*Invite              `protobuf:"bytes,6,rep,name=invites,proto3" json:"invites,omitempty"` // 含 token
+	FromNode      string                 `protobuf:"bytes,7,opt,name=from_node,json=fromNode,proto3" json:"from_node,omitempty"`
+	Settings      *RoomSettings          `protobuf:"bytes,8,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomState) Reset() {
+	*x = RoomState{}
+	mi := &file_proto_chat_chat_proto_msgTypes[128]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomState) ProtoMessage() {}
+
+func (x *RoomState) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[128]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomState.ProtoReflect.Descriptor instead.
+func (*RoomState) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{128}
+}
+
+func (x *RoomState) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomState) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *RoomState) GetHistory() []*ChatMessage {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *RoomState) GetMembers() []*RoomMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *RoomState) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *RoomState) GetInvites() []*Invite {
+	if x != nil {
+		return x.Invites
+	}
+	return nil
+}
+
+func (x *RoomState) GetFromNode() string {
+	if x != nil {
+		return x.FromNode
+	}
+	return ""
+}
+
+func (x *RoomState) GetSettings() *RoomSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type RoomStateAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomStateAck) Reset() {
+	*x = RoomStateAck{}
+	mi := &file_proto_chat_chat_proto_msgTypes[129]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomStateAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomStateAck) ProtoMessage() {}
+
+func (x *RoomStateAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[129]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomStateAck.ProtoReflect.Descriptor instead.
+func (*RoomStateAck) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{129}
+}
+
+type SnapshotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[130]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[130]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{130}
+}
+
+// 快照中的一条记录，只设置其中一项
+type SnapshotRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Record:
+	//
+	//	*SnapshotRecord_User
+	//	*SnapshotRecord_Room
+	//	*SnapshotRecord_Message
+	//	*SnapshotRecord_Attachment
+	//	*SnapshotRecord_Ban
+	//	*SnapshotRecord_BlockRule
+	//	*SnapshotRecord_Motd
+	//	*SnapshotRecord_TenantQuota
+	Record        isSnapshotRecord_Record `protobuf_oneof:"record"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRecord) Reset() {
+	*x = SnapshotRecord{}
+	mi := &file_proto_chat_chat_proto_msgTypes[131]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRecord) ProtoMessage() {}
+
+func (x *SnapshotRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[131]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRecord.ProtoReflect.Descriptor instead.
+func (*SnapshotRecord) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{131}
+}
+
+func (x *SnapshotRecord) GetRecord() isSnapshotRecord_Record {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetUser() *SnapshotUser {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_User); ok {
+			return x.User
+		}
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetRoom() *SnapshotRoom {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_Room); ok {
+			return x.Room
+		}
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetMessage() *ChatMessage {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_Message); ok {
+			return x.Message
+		}
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetAttachment() *SnapshotAttachment {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_Attachment); ok {
+			return x.Attachment
+		}
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetBan() *Ban {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_Ban); ok {
+			return x.Ban
+		}
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetBlockRule() *BlockRule {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_BlockRule); ok {
+			return x.BlockRule
+		}
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetMotd() *Welcome {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_Motd); ok {
+			return x.Motd
+		}
+	}
+	return nil
+}
+
+func (x *SnapshotRecord) GetTenantQuota() *SetQuotaRequest {
+	if x != nil {
+		if x, ok := x.Record.(*SnapshotRecord_TenantQuota); ok {
+			return x.TenantQuota
+		}
+	}
+	return nil
+}
+
+type isSnapshotRecord_Record interface {
+	isSnapshotRecord_Record()
+}
+
+type SnapshotRecord_User struct {
+	User *SnapshotUser `protobuf:"bytes,1,opt,name=user,proto3,oneof"`
+}
+
+type SnapshotRecord_Room struct {
+	Room *SnapshotRoom `protobuf:"bytes,2,opt,name=room,proto3,oneof"`
+}
+
+type SnapshotRecord_Message struct {
+	Message *ChatMessage `protobuf:"bytes,3,opt,name=message,proto3,oneof"` // 房间的公共消息，按房间和发送顺序排列
+}
+
+type SnapshotRecord_Attachment struct {
+	Attachment *SnapshotAttachment `protobuf:"bytes,4,opt,name=attachment,proto3,oneof"` // 消息引用的附件，文件本身不在快照中
+}
+
+type SnapshotRecord_Ban struct {
+	Ban *Ban `protobuf:"bytes,5,opt,name=ban,proto3,oneof"`
+}
+
+type SnapshotRecord_BlockRule struct {
+	BlockRule *BlockRule `protobuf:"bytes,6,opt,name=block_rule,json=blockRule,proto3,oneof"`
+}
+
+type SnapshotRecord_Motd struct {
+	Motd *Welcome `protobuf:"bytes,7,opt,name=motd,proto3,oneof"`
+}
+
+type SnapshotRecord_TenantQuota struct {
+	TenantQuota *SetQuotaRequest `protobuf:"bytes,8,opt,name=tenant_quota,json=tenantQuota,proto3,oneof"` // 为租户单独设置的配额
+}
+
+func (*SnapshotRecord_User) isSnapshotRecord_Record() {}
+
+func (*SnapshotRecord_Room) isSnapshotRecord_Record() {}
+
+func (*SnapshotRecord_Message) isSnapshotRecord_Record() {}
+
+func (*SnapshotRecord_Attachment) isSnapshotRecord_Record() {}
+
+func (*SnapshotRecord_Ban) isSnapshotRecord_Record() {}
+
+func (*SnapshotRecord_BlockRule) isSnapshotRecord_Record() {}
+
+func (*SnapshotRecord_Motd) isSnapshotRecord_Record() {}
+
+func (*SnapshotRecord_TenantQuota) isSnapshotRecord_Record() {}
+
+type SnapshotUser struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Profile       *Profile               `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"` // 只含置顶消息
+	Preferences   *Preferences           `protobuf:"bytes,3,opt,name=preferences,proto3" json:"preferences,omitempty"`
+	Contacts      []string               `protobuf:"bytes,4,rep,name=contacts,proto3" json:"contacts,omitempty"`
+	Drafts        []*Draft               `protobuf:"bytes,5,rep,name=drafts,proto3" json:"drafts,omitempty"` // 含已删除（text 为空）的草稿，恢复后旧的保存仍不会覆盖它们
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotUser) Reset() {
+	*x = SnapshotUser{}
+	mi := &file_proto_chat_chat_proto_msgTypes[132]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotUser) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotUser) ProtoMessage() {}
+
+func (x *SnapshotUser) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[132]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotUser.ProtoReflect.Descriptor instead.
+func (*SnapshotUser) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{132}
+}
+
+func (x *SnapshotUser) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *SnapshotUser) GetProfile() *Profile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+func (x *SnapshotUser) GetPreferences() *Preferences {
+	if x != nil {
+		return x.Preferences
+	}
+	return nil
+}
+
+func (x *SnapshotUser) GetContacts() []string {
+	if x != nil {
+		return x.Contacts
+	}
+	return nil
+}
+
+func (x *SnapshotUser) GetDrafts() []*Draft {
+	if x != nil {
+		return x.Drafts
+	}
+	return nil
+}
+
+type SnapshotRoom struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`        // 房间最新的序号，恢复后从其后继续分配
+	Members       []*RoomMember          `protobuf:"bytes,3,rep,name=members,proto3" json:"members,omitempty"` // 不含 online 和 status
+	Private       bool                   `protobuf:"varint,4,opt,name=private,proto3" json:"private,omitempty"`
+	Invites       []*Invite              `protobuf:"bytes,5,rep,name=invites,proto3" json:"invites,omitempty"` // 含 token
+	Welcome       []string               `protobuf:"bytes,6,rep,name=welcome,proto3" json:"welcome,omitempty"`
+	Quota         *Quota                 `protobuf:"bytes,7,opt,name=quota,proto3" json:"quota,omitempty"` // 为房间单独设置的配额
+	Settings      *RoomSettings          `protobuf:"bytes,8,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRoom) Reset() {
+	*x = SnapshotRoom{}
+	mi := &file_proto_chat_chat_proto_msgTypes[133]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRoom) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRoom) ProtoMessage() {}
+
+func (x *SnapshotRoom) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[133]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRoom.ProtoReflect.Descriptor instead.
+func (*SnapshotRoom) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{133}
+}
+
+func (x *SnapshotRoom) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *SnapshotRoom) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SnapshotRoom) GetMembers() []*RoomMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *SnapshotRoom) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *SnapshotRoom) GetInvites() []*Invite {
+	if x != nil {
+		return x.Invites
+	}
+	return nil
+}
+
+func (x *SnapshotRoom) GetWelcome() []string {
+	if x != nil {
+		return x.Welcome
+	}
+	return nil
+}
+
+func (x *SnapshotRoom) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *SnapshotRoom) GetSettings() *RoomSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type SnapshotAttachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Quarantined   bool                   `protobuf:"varint,2,opt,name=quarantined,proto3" json:"quarantined,omitempty"`
+	Meta          []byte                 `protobuf:"bytes,3,opt,name=meta,proto3" json:"meta,omitempty"` // 服务器保存的元数据 JSON
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotAttachment) Reset() {
+	*x = SnapshotAttachment{}
+	mi := &file_proto_chat_chat_proto_msgTypes[134]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotAttachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotAttachment) ProtoMessage() {}
+
+func (x *SnapshotAttachment) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[134]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotAttachment.ProtoReflect.Descriptor instead.
+func (*SnapshotAttachment) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{134}
+}
+
+func (x *SnapshotAttachment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SnapshotAttachment) GetQuarantined() bool {
+	if x != nil {
+		return x.Quarantined
+	}
+	return false
+}
+
+func (x *SnapshotAttachment) GetMeta() []byte {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type RestoreSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         int64                  `protobuf:"varint,1,opt,name=users,proto3" json:"users,omitempty"`
+	Rooms         int64                  `protobuf:"varint,2,opt,name=rooms,proto3" json:"rooms,omitempty"`
+	Messages      int64                  `protobuf:"varint,3,opt,name=messages,proto3" json:"messages,omitempty"`
+	Attachments   int64                  `protobuf:"varint,4,opt,name=attachments,proto3" json:"attachments,omitempty"`
+	Bans          int64                  `protobuf:"varint,5,opt,name=bans,proto3" json:"bans,omitempty"`
+	BlockRules    int64                  `protobuf:"varint,6,opt,name=block_rules,json=blockRules,proto3" json:"block_rules,omitempty"`
+	Skipped       int64                  `protobuf:"varint,7,opt,name=skipped,proto3" json:"skipped,omitempty"` // 无效或已存在的记录
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSummary) Reset() {
+	*x = RestoreSummary{}
+	mi := &file_proto_chat_chat_proto_msgTypes[135]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSummary) ProtoMessage() {}
+
+func (x *RestoreSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[135]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSummary.ProtoReflect.Descriptor instead.
+func (*RestoreSummary) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{135}
+}
+
+func (x *RestoreSummary) GetUsers() int64 {
+	if x != nil {
+		return x.Users
+	}
+	return 0
+}
+
+func (x *RestoreSummary) GetRooms() int64 {
+	if x != nil {
+		return x.Rooms
+	}
+	return 0
+}
+
+func (x *RestoreSummary) GetMessages() int64 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *RestoreSummary) GetAttachments() int64 {
+	if x != nil {
+		return x.Attachments
+	}
+	return 0
+}
+
+func (x *RestoreSummary) GetBans() int64 {
+	if x != nil {
+		return x.Bans
+	}
+	return 0
+}
+
+func (x *RestoreSummary) GetBlockRules() int64 {
+	if x != nil {
+		return x.BlockRules
+	}
+	return 0
+}
+
+func (x *RestoreSummary) GetSkipped() int64 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+type AnnounceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 空表示所有连接
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnnounceRequest) Reset() {
+	*x = AnnounceRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[136]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnnounceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnnounceRequest) ProtoMessage() {}
+
+func (x *AnnounceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[136]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnnounceRequest.ProtoReflect.Descriptor instead.
+func (*AnnounceRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{136}
+}
+
+func (x *AnnounceRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *AnnounceRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type AnnounceResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Connections   int32                  `protobuf:"varint,1,opt,name=connections,proto3" json:"connections,omitempty"` // 收到公告的连接数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnnounceResult) Reset() {
+	*x = AnnounceResult{}
+	mi := &file_proto_chat_chat_proto_msgTypes[137]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnnounceResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnnounceResult) ProtoMessage() {}
+
+func (x *AnnounceResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[137]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnnounceResult.ProtoReflect.Descriptor instead.
+func (*AnnounceResult) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{137}
+}
+
+func (x *AnnounceResult) GetConnections() int32 {
+	if x != nil {
+		return x.Connections
+	}
+	return 0
+}
+
+type RoomStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 空表示所有房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomStatsRequest) Reset() {
+	*x = RoomStatsRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[138]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomStatsRequest) ProtoMessage() {}
+
+func (x *RoomStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[138]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomStatsRequest.ProtoReflect.Descriptor instead.
+func (*RoomStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{138}
+}
+
+func (x *RoomStatsRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type RoomStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Online        int32                  `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`   // 在房间中的在线用户数
+	Members       int32                  `protobuf:"varint,3,opt,name=members,proto3" json:"members,omitempty"` // 进入过房间的用户数
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`         // 最新消息的序号
+	Private       bool                   `protobuf:"varint,5,opt,name=private,proto3" json:"private,omitempty"`
+	Messages_24H  int64                  `protobuf:"varint,6,opt,name=messages_24h,json=messages24h,proto3" json:"messages_24h,omitempty"` // 最近 24 小时的公共消息数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomStats) Reset() {
+	*x = RoomStats{}
+	mi := &file_proto_chat_chat_proto_msgTypes[139]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomStats) ProtoMessage() {}
+
+func (x *RoomStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[139]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomStats.ProtoReflect.Descriptor instead.
+func (*RoomStats) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{139}
+}
+
+func (x *RoomStats) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *RoomStats) GetOnline() int32 {
+	if x != nil {
+		return x.Online
+	}
+	return 0
+}
+
+func (x *RoomStats) GetMembers() int32 {
+	if x != nil {
+		return x.Members
+	}
+	return 0
+}
+
+func (x *RoomStats) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *RoomStats) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *RoomStats) GetMessages_24H() int64 {
+	if x != nil {
+		return x.Messages_24H
+	}
+	return 0
+}
+
+type RoomStatsList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rooms         []*RoomStats           `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"` // 按房间名排序
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomStatsList) Reset() {
+	*x = RoomStatsList{}
+	mi := &file_proto_chat_chat_proto_msgTypes[140]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomStatsList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomStatsList) ProtoMessage() {}
+
+func (x *RoomStatsList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[140]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomStatsList.ProtoReflect.Descriptor instead.
+func (*RoomStatsList) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{140}
+}
+
+func (x *RoomStatsList) GetRooms() []*RoomStats {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+type AuditLogRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`   // 先返回的最近记录数，0 表示 20
+	Follow        bool                   `protobuf:"varint,2,opt,name=follow,proto3" json:"follow,omitempty"` // 返回最近记录后继续推送新的记录
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditLogRequest) Reset() {
+	*x = AuditLogRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[141]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditLogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditLogRequest) ProtoMessage() {}
+
+func (x *AuditLogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[141]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditLogRequest.ProtoReflect.Descriptor instead.
+func (*AuditLogRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{141}
+}
+
+func (x *AuditLogRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *AuditLogRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+// 一次改变状态的管理操作
+type AuditEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          int64                  `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`      // UTC Unix 毫秒
+	Method        string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`   // AdminService 的方法名，如 CreateBan
+	Actor         string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`     // 调用方在 x-admin-actor 元数据中自报的操作者，未经验证
+	Peer          string                 `protobuf:"bytes,4,opt,name=peer,proto3" json:"peer,omitempty"`       // 调用方的地址
+	Request       string                 `protobuf:"bytes,5,opt,name=request,proto3" json:"request,omitempty"` // 请求的 JSON，流式方法为空
+	Code          string                 `protobuf:"bytes,6,opt,name=code,proto3" json:"code,omitempty"`       // 结果的 gRPC 状态码，如 OK、InvalidArgument
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEntry) Reset() {
+	*x = AuditEntry{}
+	mi := &file_proto_chat_chat_proto_msgTypes[142]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEntry) ProtoMessage() {}
+
+func (x *AuditEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[142]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEntry.ProtoReflect.Descriptor instead.
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{142}
+}
+
+func (x *AuditEntry) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetRequest() string {
+	if x != nil {
+		return x.Request
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+// 一条待处理的审核项
+type ModerationItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind          ModerationKind         `protobuf:"varint,2,opt,name=kind,proto3,enum=chat.ModerationKind" json:"kind,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // UTC Unix 毫秒
+	Room          string                 `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`                             // 被标记消息所在的房间
+	User          string                 `protobuf:"bytes,5,opt,name=user,proto3" json:"user,omitempty"`                             // 被标记消息的发送者
+	Text          string                 `protobuf:"bytes,6,opt,name=text,proto3" json:"text,omitempty"`                             // 被标记的消息内容
+	Patterns      []string               `protobuf:"bytes,7,rep,name=patterns,proto3" json:"patterns,omitempty"`                     // 命中的规则
+	Quarantine    *QuarantineReport      `protobuf:"bytes,8,opt,name=quarantine,proto3" json:"quarantine,omitempty"`                 // 隔离的附件
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModerationItem) Reset() {
+	*x = ModerationItem{}
+	mi := &file_proto_chat_chat_proto_msgTypes[143]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModerationItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModerationItem) ProtoMessage() {}
+
+func (x *ModerationItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[143]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModerationItem.ProtoReflect.Descriptor instead.
+func (*ModerationItem) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{143}
+}
+
+func (x *ModerationItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ModerationItem) GetKind() ModerationKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ModerationKind_MODERATION_FLAGGED
+}
+
+func (x *ModerationItem) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *ModerationItem) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *ModerationItem) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ModerationItem) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ModerationItem) GetPatterns() []string {
+	if x != nil {
+		return x.Patterns
+	}
+	return nil
+}
+
+func (x *ModerationItem) GetQuarantine() *QuarantineReport {
+	if x != nil {
+		return x.Quarantine
+	}
+	return nil
+}
+
+type ModerationQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // 只列出该房间的项，空表示全部
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ChatMessage) Reset() {
-	*x = ChatMessage{}
-	mi := &file_proto_chat_chat_proto_msgTypes[0]
+func (x *ModerationQueueRequest) Reset() {
+	*x = ModerationQueueRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[144]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChatMessage) String() string {
+func (x *ModerationQueueRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChatMessage) ProtoMessage() {}
+func (*ModerationQueueRequest) ProtoMessage() {}
 
-func (x *ChatMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chat_chat_proto_msgTypes[0]
+func (x *ModerationQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[144]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,43 +10153,1142 @@ func (x *ChatMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
-func (*ChatMessage) Descriptor() ([]byte, []int) {
-	return file_proto_chat_chat_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use ModerationQueueRequest.ProtoReflect.Descriptor instead.
+func (*ModerationQueueRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{144}
 }
 
-func (x *ChatMessage) GetUser() string {
+func (x *ModerationQueueRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type ModerationQueue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*ModerationItem      `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModerationQueue) Reset() {
+	*x = ModerationQueue{}
+	mi := &file_proto_chat_chat_proto_msgTypes[145]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModerationQueue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModerationQueue) ProtoMessage() {}
+
+func (x *ModerationQueue) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[145]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModerationQueue.ProtoReflect.Descriptor instead.
+func (*ModerationQueue) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{145}
+}
+
+func (x *ModerationQueue) GetItems() []*ModerationItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type ResolveModerationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveModerationRequest) Reset() {
+	*x = ResolveModerationRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[146]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveModerationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveModerationRequest) ProtoMessage() {}
+
+func (x *ResolveModerationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[146]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveModerationRequest.ProtoReflect.Descriptor instead.
+func (*ResolveModerationRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{146}
+}
+
+func (x *ResolveModerationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type FeaturesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"` // 用于确定租户，空表示默认租户
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"` // 空表示默认房间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeaturesRequest) Reset() {
+	*x = FeaturesRequest{}
+	mi := &file_proto_chat_chat_proto_msgTypes[147]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeaturesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeaturesRequest) ProtoMessage() {}
+
+func (x *FeaturesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[147]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeaturesRequest.ProtoReflect.Descriptor instead.
+func (*FeaturesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{147}
+}
+
+func (x *FeaturesRequest) GetUser() string {
 	if x != nil {
 		return x.User
 	}
 	return ""
 }
 
-func (x *ChatMessage) GetText() string {
+func (x *FeaturesRequest) GetRoom() string {
 	if x != nil {
-		return x.Text
+		return x.Room
 	}
 	return ""
 }
 
-func (x *ChatMessage) GetRecipientUser() string {
+type Features struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Room  string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	// 功能名到是否开启；服务器执行的功能（uploads、calls、link_previews、translations）
+	// 总会列出，其余的（如 reactions、threads、e2e）来自开关配置，只供客户端参考
+	Features      map[string]bool `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Features) Reset() {
+	*x = Features{}
+	mi := &file_proto_chat_chat_proto_msgTypes[148]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Features) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Features) ProtoMessage() {}
+
+func (x *Features) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_chat_proto_msgTypes[148]
 	if x != nil {
-		return x.RecipientUser
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Features.ProtoReflect.Descriptor instead.
+func (*Features) Descriptor() ([]byte, []int) {
+	return file_proto_chat_chat_proto_rawDescGZIP(), []int{148}
+}
+
+func (x *Features) GetRoom() string {
+	if x != nil {
+		return x.Room
 	}
 	return ""
 }
 
+func (x *Features) GetFeatures() map[string]bool {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
 var File_proto_chat_chat_proto protoreflect.FileDescriptor
 
 const file_proto_chat_chat_proto_rawDesc = "" +
 	"\n" +
-	"\x15proto/chat/chat.proto\x12\x04chat\"\\\n" +
+	"\x15proto/chat/chat.proto\x12\x04chat\"\xd3\f\n" +
 	"\vChatMessage\x12\x12\n" +
 	"\x04user\x18\x01 \x01(\tR\x04user\x12\x12\n" +
 	"\x04text\x18\x02 \x01(\tR\x04text\x12%\n" +
-	"\x0erecipient_user\x18\x03 \x01(\tR\rrecipientUser2G\n" +
+	"\x0erecipient_user\x18\x03 \x01(\tR\rrecipientUser\x12\x16\n" +
+	"\x06notify\x18\x05 \x01(\bR\x06notify\x12\x0e\n" +
+	"\x02id\x18\x06 \x01(\tR\x02id\x12\x12\n" +
+	"\x04room\x18\r \x01(\tR\x04room\x12\x10\n" +
+	"\x03seq\x18\x0e \x01(\x04R\x03seq\x12\"\n" +
+	"\rclient_msg_id\x18\x10 \x01(\tR\vclientMsgId\x12(\n" +
+	"\x06system\x18\x13 \x01(\v2\x10.chat.SystemTextR\x06system\x12\x1c\n" +
+	"\ttimestamp\x18\x14 \x01(\x03R\ttimestamp\x12%\n" +
+	"\x04type\x18\x16 \x01(\x0e2\x11.chat.MessageTypeR\x04type\x12;\n" +
+	"\bmetadata\x18\x18 \x03(\v2\x1f.chat.ChatMessage.MetadataEntryR\bmetadata\x12!\n" +
+	"\fephemeral_to\x18\x1c \x01(\tR\vephemeralTo\x12\x1b\n" +
+	"\tthread_id\x18% \x01(\tR\bthreadId\x12&\n" +
+	"\x06rename\x18\x04 \x01(\v2\f.chat.RenameH\x00R\x06rename\x126\n" +
+	"\flink_preview\x18\a \x01(\v2\x11.chat.LinkPreviewH\x00R\vlinkPreview\x12 \n" +
+	"\x04code\x18\b \x01(\v2\n" +
+	".chat.CodeH\x00R\x04code\x122\n" +
+	"\n" +
+	"attachment\x18\t \x01(\v2\x10.chat.AttachmentH\x00R\n" +
+	"attachment\x12&\n" +
+	"\x06signal\x18\n" +
+	" \x01(\v2\f.chat.SignalH\x00R\x06signal\x120\n" +
+	"\n" +
+	"call_event\x18\v \x01(\v2\x0f.chat.CallEventH\x00R\tcallEvent\x12,\n" +
+	"\bpresence\x18\f \x01(\v2\x0e.chat.PresenceH\x00R\bpresence\x12,\n" +
+	"\x06unread\x18\x0f \x01(\v2\x12.chat.UnreadCountsH\x00R\x06unread\x12\x1d\n" +
+	"\x03ack\x18\x11 \x01(\v2\t.chat.AckH\x00R\x03ack\x125\n" +
+	"\vtranslation\x18\x12 \x01(\v2\x11.chat.TranslationH\x00R\vtranslation\x123\n" +
+	"\vroom_change\x18\x15 \x01(\v2\x10.chat.RoomChangeH\x00R\n" +
+	"roomChange\x12#\n" +
+	"\x05hello\x18\x17 \x01(\v2\v.chat.HelloH\x00R\x05hello\x12,\n" +
+	"\bactivity\x18\x19 \x01(\v2\x0e.chat.ActivityH\x00R\bactivity\x12/\n" +
+	"\theartbeat\x18\x1a \x01(\v2\x0f.chat.HeartbeatH\x00R\theartbeat\x12'\n" +
+	"\x04edit\x18\x1b \x01(\v2\x11.chat.MessageEditH\x00R\x04edit\x12)\n" +
+	"\amembers\x18\x1d \x01(\v2\r.chat.MembersH\x00R\amembers\x12*\n" +
+	"\x06member\x18\x1e \x01(\v2\x10.chat.RoomMemberH\x00R\x06member\x123\n" +
+	"\vkeyword_hit\x18\x1f \x01(\v2\x10.chat.KeywordHitH\x00R\n" +
+	"keywordHit\x12;\n" +
+	"\rsubscriptions\x18  \x01(\v2\x13.chat.SubscriptionsH\x00R\rsubscriptions\x12,\n" +
+	"\x06filter\x18! \x01(\v2\x12.chat.StreamFilterH\x00R\x06filter\x12*\n" +
+	"\x05batch\x18\" \x01(\v2\x12.chat.MessageBatchH\x00R\x05batch\x120\n" +
+	"\n" +
+	"room_moved\x18# \x01(\v2\x0f.chat.RoomMovedH\x00R\troomMoved\x12(\n" +
+	"\x05voice\x18$ \x01(\v2\x10.chat.VoiceEventH\x00R\x05voice\x12,\n" +
+	"\x06thread\x18& \x01(\v2\x12.chat.ThreadUpdateH\x00R\x06thread\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\t\n" +
+	"\apayload\"\x98\x01\n" +
+	"\x05Hello\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\rR\x0fprotocolVersion\x12\"\n" +
+	"\fcapabilities\x18\x02 \x03(\tR\fcapabilities\x12\x14\n" +
+	"\x05rooms\x18\x03 \x03(\tR\x05rooms\x12*\n" +
+	"\x06filter\x18\x04 \x01(\v2\x12.chat.StreamFilterR\x06filter\"r\n" +
+	"\fStreamFilter\x12#\n" +
+	"\rmentions_only\x18\x01 \x01(\bR\fmentionsOnly\x12'\n" +
+	"\x0fhide_membership\x18\x02 \x01(\bR\x0ehideMembership\x12\x14\n" +
+	"\x05rooms\x18\x03 \x03(\tR\x05rooms\"=\n" +
+	"\fMessageBatch\x12-\n" +
+	"\bmessages\x18\x01 \x03(\v2\x11.chat.ChatMessageR\bmessages\"3\n" +
+	"\tRoomMoved\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x12\n" +
+	"\x04node\x18\x02 \x01(\tR\x04node\"9\n" +
+	"\rSubscriptions\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x14\n" +
+	"\x05rooms\x18\x02 \x03(\tR\x05rooms\"D\n" +
+	"\n" +
+	"RoomChange\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\":\n" +
+	"\x10ListUsersRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\"d\n" +
+	"\n" +
+	"OnlineUser\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05rooms\x18\x02 \x03(\tR\x05rooms\x12,\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x14.chat.PresenceStatusR\x06status\"2\n" +
+	"\bUserList\x12&\n" +
+	"\x05users\x18\x01 \x03(\v2\x10.chat.OnlineUserR\x05users\";\n" +
+	"\vRoomRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x18\n" +
+	"\ahistory\x18\x02 \x01(\x05R\ahistory\"\x12\n" +
+	"\x10ListRoomsRequest\"\x82\x01\n" +
+	"\bRoomInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\amembers\x18\x02 \x01(\rR\amembers\x12\x18\n" +
+	"\aprivate\x18\x03 \x01(\bR\aprivate\x12\x1a\n" +
+	"\blanguage\x18\x04 \x01(\tR\blanguage\x12\x12\n" +
+	"\x04nsfw\x18\x05 \x01(\bR\x04nsfw\"0\n" +
+	"\bRoomList\x12$\n" +
+	"\x05rooms\x18\x01 \x03(\v2\x0e.chat.RoomInfoR\x05rooms\"\xa7\x01\n" +
+	"\n" +
+	"RoomMember\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\"\n" +
+	"\x04role\x18\x02 \x01(\x0e2\x0e.chat.RoomRoleR\x04role\x12\x16\n" +
+	"\x06online\x18\x03 \x01(\bR\x06online\x12,\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x14.chat.PresenceStatusR\x06status\x12\x1b\n" +
+	"\tlast_seen\x18\x05 \x01(\x03R\blastSeen\"\xf7\x01\n" +
+	"\fRoomSettings\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x1a\n" +
+	"\blanguage\x18\x02 \x01(\tR\blanguage\x12\x12\n" +
+	"\x04nsfw\x18\x03 \x01(\bR\x04nsfw\x12+\n" +
+	"\tpost_role\x18\x04 \x01(\x0e2\x0e.chat.RoomRoleR\bpostRole\x128\n" +
+	"\vattachments\x18\x05 \x01(\x0e2\x16.chat.AttachmentPolicyR\vattachments\x12\x1c\n" +
+	"\tbroadcast\x18\x06 \x01(\bR\tbroadcast\x12\x1e\n" +
+	"\n" +
+	"publishers\x18\a \x03(\tR\n" +
+	"publishers\")\n" +
+	"\x13RoomSettingsRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\"(\n" +
+	"\x12RoomMembersRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\"M\n" +
+	"\vRoomMembers\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12*\n" +
+	"\amembers\x18\x02 \x03(\v2\x10.chat.RoomMemberR\amembers\"\x87\x01\n" +
+	"\n" +
+	"SystemText\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12.\n" +
+	"\x04args\x18\x02 \x03(\v2\x1a.chat.SystemText.ArgsEntryR\x04args\x1a7\n" +
+	"\tArgsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"u\n" +
+	"\vTranslation\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x12\n" +
+	"\x04lang\x18\x02 \x01(\tR\x04lang\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12\x1f\n" +
+	"\vsource_lang\x18\x04 \x01(\tR\n" +
+	"sourceLang\"p\n" +
+	"\vMessageEdit\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\rR\brevision\x12\x12\n" +
+	"\x04done\x18\x04 \x01(\bR\x04done\"}\n" +
+	"\x03Ack\x12\"\n" +
+	"\rclient_msg_id\x18\x01 \x01(\tR\vclientMsgId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\x12\x1c\n" +
+	"\tduplicate\x18\x04 \x01(\bR\tduplicate\x12\x12\n" +
+	"\x04room\x18\x05 \x01(\tR\x04room\"\x97\x01\n" +
+	"\x0eHistoryRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x1b\n" +
+	"\tafter_seq\x18\x02 \x01(\x04R\bafterSeq\x12\x1d\n" +
+	"\n" +
+	"before_seq\x18\x03 \x01(\x04R\tbeforeSeq\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\x12\x1f\n" +
+	"\vbefore_time\x18\x05 \x01(\x03R\n" +
+	"beforeTime\"@\n" +
+	"\x0fHistoryResponse\x12-\n" +
+	"\bmessages\x18\x01 \x03(\v2\x11.chat.ChatMessageR\bmessages\"_\n" +
+	"\x0eCatchupRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12'\n" +
+	"\x05rooms\x18\x02 \x03(\v2\x11.chat.CatchupRoomR\x05rooms\x12\x10\n" +
+	"\x03max\x18\x03 \x01(\rR\x03max\">\n" +
+	"\vCatchupRoom\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x1b\n" +
+	"\tsince_seq\x18\x02 \x01(\x04R\bsinceSeq\":\n" +
+	"\x0fCatchupResponse\x12'\n" +
+	"\x05rooms\x18\x01 \x03(\v2\x11.chat.RoomCatchupR\x05rooms\"\x85\x02\n" +
+	"\vRoomCatchup\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12#\n" +
+	"\rmessage_count\x18\x02 \x01(\rR\fmessageCount\x12#\n" +
+	"\rmention_count\x18\x03 \x01(\rR\fmentionCount\x12-\n" +
+	"\bmessages\x18\x04 \x03(\v2\x11.chat.ChatMessageR\bmessages\x12\x19\n" +
+	"\blast_seq\x18\x05 \x01(\x04R\alastSeq\x12\x1c\n" +
+	"\ttruncated\x18\x06 \x01(\bR\ttruncated\x120\n" +
+	"\amembers\x18\a \x03(\v2\x16.chat.MembershipChangeR\amembers\">\n" +
+	"\x10MembershipChange\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06joined\x18\x02 \x01(\bR\x06joined\"#\n" +
+	"\rUnreadRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"K\n" +
+	"\x0fMarkReadRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\"\x91\x01\n" +
+	"\fUnreadCounts\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x123\n" +
+	"\x05rooms\x18\x02 \x03(\v2\x1d.chat.UnreadCounts.RoomsEntryR\x05rooms\x1a8\n" +
+	"\n" +
+	"RoomsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"a\n" +
+	"\x06Signal\x12\x17\n" +
+	"\acall_id\x18\x01 \x01(\tR\x06callId\x12$\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x10.chat.SignalTypeR\x04type\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\tR\apayload\"Z\n" +
+	"\vVoiceMember\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x1a\n" +
+	"\bspeaking\x18\x02 \x01(\bR\bspeaking\x12\x1b\n" +
+	"\tjoined_at\x18\x03 \x01(\x03R\bjoinedAt\"v\n" +
+	"\n" +
+	"VoiceEvent\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12)\n" +
+	"\x06action\x18\x02 \x01(\x0e2\x11.chat.VoiceActionR\x06action\x12)\n" +
+	"\x06member\x18\x03 \x01(\v2\x11.chat.VoiceMemberR\x06member\")\n" +
+	"\x13VoiceMembersRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\"O\n" +
+	"\fVoiceMembers\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12+\n" +
+	"\amembers\x18\x02 \x03(\v2\x11.chat.VoiceMemberR\amembers\"\x93\x01\n" +
+	"\tCallEvent\x12\x17\n" +
+	"\acall_id\x18\x01 \x01(\tR\x06callId\x12%\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x0f.chat.CallStateR\x05state\x12\x16\n" +
+	"\x06caller\x18\x03 \x01(\tR\x06caller\x12\x16\n" +
+	"\x06callee\x18\x04 \x01(\tR\x06callee\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"\x1e\n" +
+	"\bActivity\x12\x12\n" +
+	"\x04idle\x18\x01 \x01(\bR\x04idle\"$\n" +
+	"\tHeartbeat\x12\x17\n" +
+	"\asent_at\x18\x01 \x01(\x03R\x06sentAt\"\x1f\n" +
+	"\aMembers\x12\x14\n" +
+	"\x05users\x18\x01 \x03(\tR\x05users\"L\n" +
+	"\bPresence\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12,\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x14.chat.PresenceStatusR\x06status\"\xa8\x02\n" +
+	"\n" +
+	"Attachment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x1b\n" +
+	"\tmime_type\x18\x03 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x1f\n" +
+	"\vduration_ms\x18\x05 \x01(\x03R\n" +
+	"durationMs\x12\x10\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\x12\x14\n" +
+	"\x05width\x18\a \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\b \x01(\x05R\x06height\x12\x1f\n" +
+	"\vpreview_url\x18\t \x01(\tR\n" +
+	"previewUrl\x12\x12\n" +
+	"\x04name\x18\n" +
+	" \x01(\tR\x04name\x12/\n" +
+	"\n" +
+	"thumbnails\x18\v \x03(\v2\x0f.chat.ThumbnailR\n" +
+	"thumbnails\"_\n" +
+	"\tThumbnail\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x05R\x04size\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x05R\x06height\"<\n" +
+	"\x04Code\x12\x1a\n" +
+	"\blanguage\x18\x01 \x01(\tR\blanguage\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\xb0\x01\n" +
+	"\vLinkPreview\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1b\n" +
+	"\timage_url\x18\x05 \x01(\tR\bimageUrl\x12\x1b\n" +
+	"\tsite_name\x18\x06 \x01(\tR\bsiteName\">\n" +
+	"\x06Rename\x12\x19\n" +
+	"\bold_user\x18\x01 \x01(\tR\aoldUser\x12\x19\n" +
+	"\bnew_user\x18\x02 \x01(\tR\anewUser\"P\n" +
+	"\n" +
+	"QuietHours\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\tR\x03end\x12\x1a\n" +
+	"\btimezone\x18\x03 \x01(\tR\btimezone\"\xf5\x03\n" +
+	"\vPreferences\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x122\n" +
+	"\x05rooms\x18\x02 \x03(\v2\x1c.chat.Preferences.RoomsEntryR\x05rooms\x121\n" +
+	"\vquiet_hours\x18\x03 \x01(\v2\x10.chat.QuietHoursR\n" +
+	"quietHours\x12%\n" +
+	"\x0eauto_translate\x18\x04 \x01(\tR\rautoTranslate\x12\x16\n" +
+	"\x06locale\x18\x05 \x01(\tR\x06locale\x12*\n" +
+	"\x11assistant_opt_out\x18\x06 \x01(\bR\x0fassistantOptOut\x12)\n" +
+	"\x10message_requests\x18\a \x01(\bR\x0fmessageRequests\x12;\n" +
+	"\bkeywords\x18\b \x03(\v2\x1f.chat.Preferences.KeywordsEntryR\bkeywords\x1aK\n" +
+	"\n" +
+	"RoomsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12'\n" +
+	"\x05value\x18\x02 \x01(\x0e2\x11.chat.NotifyLevelR\x05value:\x028\x01\x1aK\n" +
+	"\rKeywordsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12$\n" +
+	"\x05value\x18\x02 \x01(\v2\x0e.chat.KeywordsR\x05value:\x028\x01\" \n" +
+	"\bKeywords\x12\x14\n" +
+	"\x05words\x18\x01 \x03(\tR\x05words\"L\n" +
+	"\x0eKeywordRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x12\x12\n" +
+	"\x04word\x18\x03 \x01(\tR\x04word\"\x85\x01\n" +
+	"\n" +
+	"KeywordHit\x12\x18\n" +
+	"\akeyword\x18\x01 \x01(\tR\akeyword\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x03 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06sender\x18\x04 \x01(\tR\x06sender\x12\x12\n" +
+	"\x04text\x18\x05 \x01(\tR\x04text\"(\n" +
+	"\x12PreferencesRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"$\n" +
+	"\x0eProfileRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"\xab\x01\n" +
+	"\aProfile\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06online\x18\x02 \x01(\bR\x06online\x12,\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x14.chat.PresenceStatusR\x06status\x12)\n" +
+	"\x06pinned\x18\x04 \x01(\v2\x11.chat.ChatMessageR\x06pinned\x12\x1b\n" +
+	"\tpinned_at\x18\x05 \x01(\x03R\bpinnedAt\"I\n" +
+	"\x14SetProfilePinRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\",\n" +
+	"\x16MessageRequestsRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"W\n" +
+	"\x0fMessageRequests\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x120\n" +
+	"\brequests\x18\x02 \x03(\v2\x14.chat.MessageRequestR\brequests\"x\n" +
+	"\x0eMessageRequest\x12\x16\n" +
+	"\x06sender\x18\x01 \x01(\tR\x06sender\x12-\n" +
+	"\bmessages\x18\x02 \x03(\v2\x11.chat.ChatMessageR\bmessages\x12\x1f\n" +
+	"\vreceived_at\x18\x03 \x01(\x03R\n" +
+	"receivedAt\"D\n" +
+	"\x16MessageRequestDecision\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06sender\x18\x02 \x01(\tR\x06sender\"%\n" +
+	"\x0fContactsRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"}\n" +
+	"\x10SaveDraftRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\"\n" +
+	"\fconversation\x18\x02 \x01(\tR\fconversation\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\x03R\tupdatedAt\"^\n" +
+	"\x05Draft\x12\"\n" +
+	"\fconversation\x18\x01 \x01(\tR\fconversation\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\x03R\tupdatedAt\"#\n" +
+	"\rDraftsRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"A\n" +
+	"\x06Drafts\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12#\n" +
+	"\x06drafts\x18\x02 \x03(\v2\v.chat.DraftR\x06drafts\"@\n" +
+	"\rThreadRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x1b\n" +
+	"\tthread_id\x18\x02 \x01(\tR\bthreadId\"\xb9\x01\n" +
+	"\x06Thread\x12%\n" +
+	"\x04root\x18\x01 \x01(\v2\x11.chat.ChatMessageR\x04root\x12+\n" +
+	"\areplies\x18\x02 \x03(\v2\x11.chat.ChatMessageR\areplies\x12\x1f\n" +
+	"\vreply_count\x18\x03 \x01(\rR\n" +
+	"replyCount\x12\x1c\n" +
+	"\tfollowers\x18\x04 \x01(\rR\tfollowers\x12\x1c\n" +
+	"\tfollowing\x18\x05 \x01(\bR\tfollowing\"\xa1\x01\n" +
+	"\fThreadUpdate\x12\x1b\n" +
+	"\tthread_id\x18\x01 \x01(\tR\bthreadId\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x12\x1f\n" +
+	"\vreply_count\x18\x03 \x01(\rR\n" +
+	"replyCount\x12\x1b\n" +
+	"\tlast_user\x18\x04 \x01(\tR\blastUser\x12\"\n" +
+	"\rlast_reply_at\x18\x05 \x01(\x03R\vlastReplyAt\">\n" +
+	"\x0eContactRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x18\n" +
+	"\acontact\x18\x02 \x01(\tR\acontact\"I\n" +
+	"\bContacts\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12)\n" +
+	"\bcontacts\x18\x02 \x03(\v2\r.chat.ContactR\bcontacts\"c\n" +
+	"\aContact\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06online\x18\x02 \x01(\bR\x06online\x12,\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x14.chat.PresenceStatusR\x06status\"\x90\x01\n" +
+	"\x05Chunk\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x06 \x01(\tR\x06sha256\"Y\n" +
+	"\x11AttachmentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x1c\n" +
+	"\tthumbnail\x18\x03 \x01(\x05R\tthumbnail\"2\n" +
+	"\x13UploadOffsetRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\"&\n" +
+	"\fUploadOffset\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x03R\x06offset\"\x1f\n" +
+	"\vDownloadUrl\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\"G\n" +
+	"\rExportRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\x03R\x02to\"E\n" +
+	"\rImportSummary\x12\x1a\n" +
+	"\bimported\x18\x01 \x01(\x03R\bimported\x12\x18\n" +
+	"\askipped\x18\x02 \x01(\x03R\askipped\"e\n" +
+	"\fStatsRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\x03R\x02to\x12\x14\n" +
+	"\x05daily\x18\x03 \x01(\bR\x05daily\x12\x1b\n" +
+	"\ttop_rooms\x18\x04 \x01(\x05R\btopRooms\"\x97\x02\n" +
+	"\x05Stats\x12+\n" +
+	"\abuckets\x18\x01 \x03(\v2\x11.chat.StatsBucketR\abuckets\x12\x1a\n" +
+	"\bmessages\x18\x02 \x01(\x03R\bmessages\x12!\n" +
+	"\factive_users\x18\x03 \x01(\x05R\vactiveUsers\x12,\n" +
+	"\ttop_rooms\x18\x04 \x03(\v2\x0f.chat.RoomCountR\btopRooms\x12)\n" +
+	"\x10peak_concurrency\x18\x05 \x01(\x05R\x0fpeakConcurrency\x12\x17\n" +
+	"\apeak_at\x18\x06 \x01(\x03R\x06peakAt\x120\n" +
+	"\n" +
+	"leadership\x18\a \x01(\v2\x10.chat.LeadershipR\n" +
+	"leadership\">\n" +
+	"\x11WatchStatsRequest\x12)\n" +
+	"\x10interval_seconds\x18\x01 \x01(\x05R\x0fintervalSeconds\"\xec\x02\n" +
+	"\rStatsSnapshot\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\x03R\x04time\x12 \n" +
+	"\vconnections\x18\x02 \x01(\x05R\vconnections\x12\x14\n" +
+	"\x05users\x18\x03 \x01(\x05R\x05users\x12\x14\n" +
+	"\x05rooms\x18\x04 \x01(\x05R\x05rooms\x12\x1a\n" +
+	"\bmessages\x18\x05 \x01(\x03R\bmessages\x12.\n" +
+	"\x13messages_per_second\x18\x06 \x01(\x01R\x11messagesPerSecond\x12#\n" +
+	"\rpending_sends\x18\a \x01(\x05R\fpendingSends\x12'\n" +
+	"\x0fwatcher_backlog\x18\b \x01(\x05R\x0ewatcherBacklog\x12\x1a\n" +
+	"\bwatchers\x18\t \x01(\x05R\bwatchers\x12#\n" +
+	"\rcluster_queue\x18\n" +
+	" \x01(\x05R\fclusterQueue\x12\x1e\n" +
+	"\n" +
+	"goroutines\x18\v \x01(\x05R\n" +
+	"goroutines\"\xbc\x01\n" +
+	"\n" +
+	"Leadership\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x16\n" +
+	"\x06leader\x18\x03 \x01(\bR\x06leader\x12\x16\n" +
+	"\x06holder\x18\x04 \x01(\tR\x06holder\x12\x14\n" +
+	"\x05since\x18\x05 \x01(\x03R\x05since\x12\x18\n" +
+	"\aelected\x18\x06 \x01(\x04R\aelected\x12\x12\n" +
+	"\x04lost\x18\a \x01(\x04R\x04lost\x12\x16\n" +
+	"\x06errors\x18\b \x01(\x04R\x06errors\"\x8d\x01\n" +
+	"\vStatsBucket\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x03R\x05start\x12\x1a\n" +
+	"\bmessages\x18\x02 \x01(\x03R\bmessages\x12!\n" +
+	"\factive_users\x18\x03 \x01(\x05R\vactiveUsers\x12)\n" +
+	"\x10peak_concurrency\x18\x04 \x01(\x05R\x0fpeakConcurrency\";\n" +
+	"\tRoomCount\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x1a\n" +
+	"\bmessages\x18\x02 \x01(\x03R\bmessages\"\x94\x01\n" +
+	"\x05Quota\x12(\n" +
+	"\x10messages_per_day\x18\x01 \x01(\x03R\x0emessagesPerDay\x12#\n" +
+	"\rstorage_bytes\x18\x02 \x01(\x03R\fstorageBytes\x12\x1f\n" +
+	"\vmax_members\x18\x03 \x01(\x05R\n" +
+	"maxMembers\x12\x1b\n" +
+	"\tmax_rooms\x18\x04 \x01(\x05R\bmaxRooms\"J\n" +
+	"\fQuotaRequest\x12&\n" +
+	"\x05scope\x18\x01 \x01(\x0e2\x10.chat.QuotaScopeR\x05scope\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\x86\x01\n" +
+	"\x0fSetQuotaRequest\x12&\n" +
+	"\x05scope\x18\x01 \x01(\x0e2\x10.chat.QuotaScopeR\x05scope\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\x05quota\x18\x03 \x01(\v2\v.chat.QuotaR\x05quota\x12\x14\n" +
+	"\x05clear\x18\x04 \x01(\bR\x05clear\"\xff\x01\n" +
+	"\n" +
+	"QuotaUsage\x12&\n" +
+	"\x05scope\x18\x01 \x01(\x0e2\x10.chat.QuotaScopeR\x05scope\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\x05quota\x18\x03 \x01(\v2\v.chat.QuotaR\x05quota\x12\x16\n" +
+	"\x06custom\x18\x04 \x01(\bR\x06custom\x12%\n" +
+	"\x0emessages_today\x18\x05 \x01(\x03R\rmessagesToday\x12#\n" +
+	"\rstorage_bytes\x18\x06 \x01(\x03R\fstorageBytes\x12\x18\n" +
+	"\amembers\x18\a \x01(\x05R\amembers\x12\x14\n" +
+	"\x05rooms\x18\b \x01(\x05R\x05rooms\"\x9f\x01\n" +
+	"\fSlashCommand\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06secret\x18\x04 \x01(\tR\x06secret\x12\x19\n" +
+	"\bbot_name\x18\x05 \x01(\tR\abotName\x12\x14\n" +
+	"\x05rooms\x18\x06 \x03(\tR\x05rooms\".\n" +
+	"\x18UnregisterCommandRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x15\n" +
+	"\x13ListCommandsRequest\"=\n" +
+	"\vCommandList\x12.\n" +
+	"\bcommands\x18\x01 \x03(\v2\x12.chat.SlashCommandR\bcommands\"\xd0\x01\n" +
+	"\aSession\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x12\n" +
+	"\x04room\x18\x03 \x01(\tR\x04room\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x04 \x01(\tR\tuserAgent\x12\x0e\n" +
+	"\x02ip\x18\x05 \x01(\tR\x02ip\x12#\n" +
+	"\rforwarded_for\x18\x06 \x01(\tR\fforwardedFor\x12!\n" +
+	"\fconnected_at\x18\a \x01(\x03R\vconnectedAt\x12\x16\n" +
+	"\x06device\x18\b \x01(\tR\x06device\"9\n" +
+	"\aWelcome\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x1a\n" +
+	"\bmessages\x18\x02 \x03(\tR\bmessages\"$\n" +
+	"\x0eWelcomeRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\")\n" +
+	"\x13ListSessionsRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\"8\n" +
+	"\vSessionList\x12)\n" +
+	"\bsessions\x18\x01 \x03(\v2\r.chat.SessionR\bsessions\"E\n" +
+	"\x15SetRoomPrivateRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x18\n" +
+	"\aprivate\x18\x02 \x01(\bR\aprivate\"\x84\x01\n" +
+	"\x13CreateInviteRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x19\n" +
+	"\bmax_uses\x18\x02 \x01(\x05R\amaxUses\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03R\n" +
+	"ttlSeconds\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x04 \x01(\tR\tcreatedBy\"\xbe\x01\n" +
+	"\x06Invite\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x12\x19\n" +
+	"\bmax_uses\x18\x03 \x01(\x05R\amaxUses\x12\x12\n" +
+	"\x04uses\x18\x04 \x01(\x05R\x04uses\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\x03R\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\a \x01(\tR\tcreatedBy\"%\n" +
+	"\rInviteRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"(\n" +
+	"\x12ListInvitesRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\"4\n" +
+	"\n" +
+	"InviteList\x12&\n" +
+	"\ainvites\x18\x01 \x03(\v2\f.chat.InviteR\ainvites\"`\n" +
+	"\x12SetRoomRoleRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\"\n" +
+	"\x04role\x18\x03 \x01(\x0e2\x0e.chat.RoomRoleR\x04role\":\n" +
+	"\x14RevokeSessionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\"\xe0\x01\n" +
+	"\x03Ban\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12$\n" +
+	"\x05scope\x18\x02 \x01(\x0e2\x0e.chat.BanScopeR\x05scope\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\x03R\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\a \x01(\tR\tcreatedBy\x12\x16\n" +
+	"\x06appeal\x18\b \x01(\tR\x06appeal\"\xb2\x01\n" +
+	"\x10CreateBanRequest\x12$\n" +
+	"\x05scope\x18\x01 \x01(\x0e2\x0e.chat.BanScopeR\x05scope\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12)\n" +
+	"\x10duration_seconds\x18\x04 \x01(\x03R\x0fdurationSeconds\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x05 \x01(\tR\tcreatedBy\"\x1c\n" +
+	"\n" +
+	"BanRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
+	"\x0fListBansRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\"(\n" +
+	"\aBanList\x12\x1d\n" +
+	"\x04bans\x18\x01 \x03(\v2\t.chat.BanR\x04bans\"=\n" +
+	"\x13SetBanAppealRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06appeal\x18\x02 \x01(\tR\x06appeal\"\xc8\x01\n" +
+	"\tBlockRule\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\apattern\x18\x02 \x01(\tR\apattern\x12\x14\n" +
+	"\x05regex\x18\x03 \x01(\bR\x05regex\x12)\n" +
+	"\x06action\x18\x04 \x01(\x0e2\x11.chat.BlockActionR\x06action\x12\x12\n" +
+	"\x04room\x18\x05 \x01(\tR\x04room\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\a \x01(\tR\tcreatedBy\"\"\n" +
+	"\x10BlockRuleRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"+\n" +
+	"\x15ListBlockRulesRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\"6\n" +
+	"\rBlockRuleList\x12%\n" +
+	"\x05rules\x18\x01 \x03(\v2\x0f.chat.BlockRuleR\x05rules\"\x8f\x01\n" +
+	"\x10QuarantineReport\x12#\n" +
+	"\rattachment_id\x18\x01 \x01(\tR\fattachmentId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06threat\x18\x04 \x01(\tR\x06threat\x12\x16\n" +
+	"\x06source\x18\x05 \x01(\tR\x06source\">\n" +
+	"\x11PluginInfoRequest\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\rR\x0fprotocolVersion\"d\n" +
+	"\n" +
+	"PluginInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12&\n" +
+	"\x05hooks\x18\x02 \x03(\x0e2\x10.chat.PluginHookR\x05hooks\x12\x1a\n" +
+	"\bcommands\x18\x03 \x03(\tR\bcommands\"k\n" +
+	"\fFilterResult\x12\x16\n" +
+	"\x06reject\x18\x01 \x01(\bR\x06reject\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12+\n" +
+	"\amessage\x18\x03 \x01(\v2\x11.chat.ChatMessageR\amessage\"\v\n" +
+	"\tPluginAck\"3\n" +
+	"\tJoinEvent\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\":\n" +
+	"\fJoinDecision\x12\x12\n" +
+	"\x04deny\x18\x01 \x01(\bR\x04deny\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"e\n" +
+	"\rPluginCommand\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x12\x18\n" +
+	"\acommand\x18\x03 \x01(\tR\acommand\x12\x12\n" +
+	"\x04args\x18\x04 \x01(\tR\x04args\"B\n" +
+	"\fCommandReply\x12\x14\n" +
+	"\x05reply\x18\x01 \x01(\tR\x05reply\x12\x1c\n" +
+	"\tbroadcast\x18\x02 \x01(\tR\tbroadcast\"l\n" +
+	"\fPeerDelivery\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12+\n" +
+	"\amessage\x18\x02 \x01(\v2\x11.chat.ChatMessageR\amessage\x12\x1b\n" +
+	"\tfrom_node\x18\x03 \x01(\tR\bfromNode\"2\n" +
+	"\x12PeerDeliveryResult\x12\x1c\n" +
+	"\tdelivered\x18\x01 \x01(\bR\tdelivered\"\x99\x02\n" +
+	"\tRoomState\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x04R\x03seq\x12+\n" +
+	"\ahistory\x18\x03 \x03(\v2\x11.chat.ChatMessageR\ahistory\x12*\n" +
+	"\amembers\x18\x04 \x03(\v2\x10.chat.RoomMemberR\amembers\x12\x18\n" +
+	"\aprivate\x18\x05 \x01(\bR\aprivate\x12&\n" +
+	"\ainvites\x18\x06 \x03(\v2\f.chat.InviteR\ainvites\x12\x1b\n" +
+	"\tfrom_node\x18\a \x01(\tR\bfromNode\x12.\n" +
+	"\bsettings\x18\b \x01(\v2\x12.chat.RoomSettingsR\bsettings\"\x0e\n" +
+	"\fRoomStateAck\"\x11\n" +
+	"\x0fSnapshotRequest\"\x8b\x03\n" +
+	"\x0eSnapshotRecord\x12(\n" +
+	"\x04user\x18\x01 \x01(\v2\x12.chat.SnapshotUserH\x00R\x04user\x12(\n" +
+	"\x04room\x18\x02 \x01(\v2\x12.chat.SnapshotRoomH\x00R\x04room\x12-\n" +
+	"\amessage\x18\x03 \x01(\v2\x11.chat.ChatMessageH\x00R\amessage\x12:\n" +
+	"\n" +
+	"attachment\x18\x04 \x01(\v2\x18.chat.SnapshotAttachmentH\x00R\n" +
+	"attachment\x12\x1d\n" +
+	"\x03ban\x18\x05 \x01(\v2\t.chat.BanH\x00R\x03ban\x120\n" +
+	"\n" +
+	"block_rule\x18\x06 \x01(\v2\x0f.chat.BlockRuleH\x00R\tblockRule\x12#\n" +
+	"\x04motd\x18\a \x01(\v2\r.chat.WelcomeH\x00R\x04motd\x12:\n" +
+	"\ftenant_quota\x18\b \x01(\v2\x15.chat.SetQuotaRequestH\x00R\vtenantQuotaB\b\n" +
+	"\x06record\"\xc1\x01\n" +
+	"\fSnapshotUser\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12'\n" +
+	"\aprofile\x18\x02 \x01(\v2\r.chat.ProfileR\aprofile\x123\n" +
+	"\vpreferences\x18\x03 \x01(\v2\x11.chat.PreferencesR\vpreferences\x12\x1a\n" +
+	"\bcontacts\x18\x04 \x03(\tR\bcontacts\x12#\n" +
+	"\x06drafts\x18\x05 \x03(\v2\v.chat.DraftR\x06drafts\"\x8f\x02\n" +
+	"\fSnapshotRoom\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x04R\x03seq\x12*\n" +
+	"\amembers\x18\x03 \x03(\v2\x10.chat.RoomMemberR\amembers\x12\x18\n" +
+	"\aprivate\x18\x04 \x01(\bR\aprivate\x12&\n" +
+	"\ainvites\x18\x05 \x03(\v2\f.chat.InviteR\ainvites\x12\x18\n" +
+	"\awelcome\x18\x06 \x03(\tR\awelcome\x12!\n" +
+	"\x05quota\x18\a \x01(\v2\v.chat.QuotaR\x05quota\x12.\n" +
+	"\bsettings\x18\b \x01(\v2\x12.chat.RoomSettingsR\bsettings\"Z\n" +
+	"\x12SnapshotAttachment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\vquarantined\x18\x02 \x01(\bR\vquarantined\x12\x12\n" +
+	"\x04meta\x18\x03 \x01(\fR\x04meta\"\xc9\x01\n" +
+	"\x0eRestoreSummary\x12\x14\n" +
+	"\x05users\x18\x01 \x01(\x03R\x05users\x12\x14\n" +
+	"\x05rooms\x18\x02 \x01(\x03R\x05rooms\x12\x1a\n" +
+	"\bmessages\x18\x03 \x01(\x03R\bmessages\x12 \n" +
+	"\vattachments\x18\x04 \x01(\x03R\vattachments\x12\x12\n" +
+	"\x04bans\x18\x05 \x01(\x03R\x04bans\x12\x1f\n" +
+	"\vblock_rules\x18\x06 \x01(\x03R\n" +
+	"blockRules\x12\x18\n" +
+	"\askipped\x18\a \x01(\x03R\askipped\"9\n" +
+	"\x0fAnnounceRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"2\n" +
+	"\x0eAnnounceResult\x12 \n" +
+	"\vconnections\x18\x01 \x01(\x05R\vconnections\"&\n" +
+	"\x10RoomStatsRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\"\xa0\x01\n" +
+	"\tRoomStats\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x16\n" +
+	"\x06online\x18\x02 \x01(\x05R\x06online\x12\x18\n" +
+	"\amembers\x18\x03 \x01(\x05R\amembers\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12\x18\n" +
+	"\aprivate\x18\x05 \x01(\bR\aprivate\x12!\n" +
+	"\fmessages_24h\x18\x06 \x01(\x03R\vmessages24h\"6\n" +
+	"\rRoomStatsList\x12%\n" +
+	"\x05rooms\x18\x01 \x03(\v2\x0f.chat.RoomStatsR\x05rooms\"?\n" +
+	"\x0fAuditLogRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06follow\x18\x02 \x01(\bR\x06follow\"\x90\x01\n" +
+	"\n" +
+	"AuditEntry\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\x03R\x04time\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x12\n" +
+	"\x04peer\x18\x04 \x01(\tR\x04peer\x12\x18\n" +
+	"\arequest\x18\x05 \x01(\tR\arequest\x12\x12\n" +
+	"\x04code\x18\x06 \x01(\tR\x04code\"\xf9\x01\n" +
+	"\x0eModerationItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12(\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x14.chat.ModerationKindR\x04kind\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\x12\x12\n" +
+	"\x04room\x18\x04 \x01(\tR\x04room\x12\x12\n" +
+	"\x04user\x18\x05 \x01(\tR\x04user\x12\x12\n" +
+	"\x04text\x18\x06 \x01(\tR\x04text\x12\x1a\n" +
+	"\bpatterns\x18\a \x03(\tR\bpatterns\x126\n" +
+	"\n" +
+	"quarantine\x18\b \x01(\v2\x16.chat.QuarantineReportR\n" +
+	"quarantine\",\n" +
+	"\x16ModerationQueueRequest\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\"=\n" +
+	"\x0fModerationQueue\x12*\n" +
+	"\x05items\x18\x01 \x03(\v2\x14.chat.ModerationItemR\x05items\"*\n" +
+	"\x18ResolveModerationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"9\n" +
+	"\x0fFeaturesRequest\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\"\x95\x01\n" +
+	"\bFeatures\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x128\n" +
+	"\bfeatures\x18\x02 \x03(\v2\x1c.chat.Features.FeaturesEntryR\bfeatures\x1a;\n" +
+	"\rFeaturesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\bR\x05value:\x028\x01*\x99\x04\n" +
+	"\vMessageType\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\r\n" +
+	"\tTYPE_CHAT\x10\x01\x12\r\n" +
+	"\tTYPE_JOIN\x10\x02\x12\x0e\n" +
+	"\n" +
+	"TYPE_LEAVE\x10\x03\x12\x0f\n" +
+	"\vTYPE_SYSTEM\x10\x04\x12\x0f\n" +
+	"\vTYPE_TYPING\x10\x05\x12\x11\n" +
+	"\rTYPE_PRESENCE\x10\x06\x12\x11\n" +
+	"\rTYPE_REACTION\x10\a\x12\x0f\n" +
+	"\vTYPE_RENAME\x10\b\x12\x15\n" +
+	"\x11TYPE_LINK_PREVIEW\x10\t\x12\x0f\n" +
+	"\vTYPE_SIGNAL\x10\n" +
+	"\x12\r\n" +
+	"\tTYPE_CALL\x10\v\x12\x0f\n" +
+	"\vTYPE_UNREAD\x10\f\x12\f\n" +
+	"\bTYPE_ACK\x10\r\x12\x14\n" +
+	"\x10TYPE_TRANSLATION\x10\x0e\x12\x14\n" +
+	"\x10TYPE_ROOM_CHANGE\x10\x0f\x12\x0e\n" +
+	"\n" +
+	"TYPE_HELLO\x10\x10\x12\x11\n" +
+	"\rTYPE_ACTIVITY\x10\x11\x12\x12\n" +
+	"\x0eTYPE_HEARTBEAT\x10\x12\x12\r\n" +
+	"\tTYPE_EDIT\x10\x13\x12\x0f\n" +
+	"\vTYPE_ROSTER\x10\x14\x12\x0f\n" +
+	"\vTYPE_MEMBER\x10\x15\x12\x14\n" +
+	"\x10TYPE_KEYWORD_HIT\x10\x16\x12\x16\n" +
+	"\x12TYPE_SUBSCRIPTIONS\x10\x17\x12\x0f\n" +
+	"\vTYPE_FILTER\x10\x18\x12\x0e\n" +
+	"\n" +
+	"TYPE_BATCH\x10\x19\x12\x13\n" +
+	"\x0fTYPE_ROOM_MOVED\x10\x1a\x12\x0e\n" +
+	"\n" +
+	"TYPE_VOICE\x10\x1b\x12\x0f\n" +
+	"\vTYPE_THREAD\x10\x1c*?\n" +
+	"\bRoomRole\x12\x0f\n" +
+	"\vROLE_MEMBER\x10\x00\x12\x12\n" +
+	"\x0eROLE_MODERATOR\x10\x01\x12\x0e\n" +
+	"\n" +
+	"ROLE_OWNER\x10\x02*T\n" +
+	"\x10AttachmentPolicy\x12\x13\n" +
+	"\x0fATTACHMENTS_ALL\x10\x00\x12\x15\n" +
+	"\x11ATTACHMENTS_MEDIA\x10\x01\x12\x14\n" +
+	"\x10ATTACHMENTS_NONE\x10\x02*\x9f\x02\n" +
+	"\n" +
+	"SignalType\x12\x12\n" +
+	"\x0eSIGNAL_UNKNOWN\x10\x00\x12\x10\n" +
+	"\fSIGNAL_OFFER\x10\x01\x12\x11\n" +
+	"\rSIGNAL_ANSWER\x10\x02\x12\x18\n" +
+	"\x14SIGNAL_ICE_CANDIDATE\x10\x03\x12\x11\n" +
+	"\rSIGNAL_HANGUP\x10\x04\x12\x1d\n" +
+	"\x19SIGNAL_SCREEN_SHARE_START\x10\x05\x12\x1c\n" +
+	"\x18SIGNAL_SCREEN_SHARE_STOP\x10\x06\x12\x15\n" +
+	"\x11SIGNAL_VOICE_JOIN\x10\a\x12\x16\n" +
+	"\x12SIGNAL_VOICE_LEAVE\x10\b\x12\x1f\n" +
+	"\x1bSIGNAL_VOICE_SPEAKING_START\x10\t\x12\x1e\n" +
+	"\x1aSIGNAL_VOICE_SPEAKING_STOP\x10\n" +
+	"*W\n" +
+	"\tCallState\x12\x16\n" +
+	"\x12CALL_STATE_UNKNOWN\x10\x00\x12\x10\n" +
+	"\fCALL_RINGING\x10\x01\x12\x10\n" +
+	"\fCALL_IN_CALL\x10\x02\x12\x0e\n" +
+	"\n" +
+	"CALL_ENDED\x10\x03*U\n" +
+	"\vVoiceAction\x12\x10\n" +
+	"\fVOICE_JOINED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"VOICE_LEFT\x10\x01\x12\x12\n" +
+	"\x0eVOICE_SPEAKING\x10\x02\x12\x10\n" +
+	"\fVOICE_SILENT\x10\x03*n\n" +
+	"\x0ePresenceStatus\x12\x16\n" +
+	"\x12PRESENCE_AVAILABLE\x10\x00\x12\x14\n" +
+	"\x10PRESENCE_IN_CALL\x10\x01\x12\x1b\n" +
+	"\x17PRESENCE_SHARING_SCREEN\x10\x02\x12\x11\n" +
+	"\rPRESENCE_AWAY\x10\x03*X\n" +
+	"\vNotifyLevel\x12\x12\n" +
+	"\x0eNOTIFY_DEFAULT\x10\x00\x12\x0e\n" +
+	"\n" +
+	"NOTIFY_ALL\x10\x01\x12\x13\n" +
+	"\x0fNOTIFY_MENTIONS\x10\x02\x12\x10\n" +
+	"\fNOTIFY_MUTED\x10\x03*.\n" +
+	"\n" +
+	"QuotaScope\x12\x10\n" +
+	"\fQUOTA_TENANT\x10\x00\x12\x0e\n" +
+	"\n" +
+	"QUOTA_ROOM\x10\x01*'\n" +
+	"\bBanScope\x12\x0f\n" +
+	"\vBAN_ACCOUNT\x10\x00\x12\n" +
+	"\n" +
+	"\x06BAN_IP\x10\x01*P\n" +
+	"\vBlockAction\x12\x0e\n" +
+	"\n" +
+	"BLOCK_MASK\x10\x00\x12\x10\n" +
+	"\fBLOCK_REJECT\x10\x01\x12\x0e\n" +
+	"\n" +
+	"BLOCK_FLAG\x10\x02\x12\x0f\n" +
+	"\vBLOCK_ALLOW\x10\x03*h\n" +
+	"\n" +
+	"PluginHook\x12\x14\n" +
+	"\x10HOOK_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vHOOK_FILTER\x10\x01\x12\x12\n" +
+	"\x0eHOOK_DELIVERED\x10\x02\x12\r\n" +
+	"\tHOOK_JOIN\x10\x03\x12\x10\n" +
+	"\fHOOK_COMMAND\x10\x04*C\n" +
+	"\x0eModerationKind\x12\x16\n" +
+	"\x12MODERATION_FLAGGED\x10\x00\x12\x19\n" +
+	"\x15MODERATION_QUARANTINE\x10\x012G\n" +
 	"\vChatService\x128\n" +
-	"\fRealtimeChat\x12\x11.chat.ChatMessage\x1a\x11.chat.ChatMessage(\x010\x01B\x1eZ\x1crealTimeChat/proto/chat;chatb\x06proto3"
+	"\fRealtimeChat\x12\x11.chat.ChatMessage\x1a\x11.chat.ChatMessage(\x010\x012\xbe\x02\n" +
+	"\x12PreferencesService\x12=\n" +
+	"\x0eGetPreferences\x12\x18.chat.PreferencesRequest\x1a\x11.chat.Preferences\x126\n" +
+	"\x0eSetPreferences\x12\x11.chat.Preferences\x1a\x11.chat.Preferences\x12@\n" +
+	"\x11DeletePreferences\x12\x18.chat.PreferencesRequest\x1a\x11.chat.Preferences\x125\n" +
+	"\n" +
+	"AddKeyword\x12\x14.chat.KeywordRequest\x1a\x11.chat.Preferences\x128\n" +
+	"\rRemoveKeyword\x12\x14.chat.KeywordRequest\x1a\x11.chat.Preferences2\x7f\n" +
+	"\x0eProfileService\x121\n" +
+	"\n" +
+	"GetProfile\x12\x14.chat.ProfileRequest\x1a\r.chat.Profile\x12:\n" +
+	"\rSetProfilePin\x12\x1a.chat.SetProfilePinRequest\x1a\r.chat.Profile2\xb2\x01\n" +
+	"\x0eContactService\x125\n" +
+	"\fListContacts\x12\x15.chat.ContactsRequest\x1a\x0e.chat.Contacts\x122\n" +
+	"\n" +
+	"AddContact\x12\x14.chat.ContactRequest\x1a\x0e.chat.Contacts\x125\n" +
+	"\rRemoveContact\x12\x14.chat.ContactRequest\x1a\x0e.chat.Contacts2\xfe\x01\n" +
+	"\x15MessageRequestService\x12J\n" +
+	"\x13ListMessageRequests\x12\x1c.chat.MessageRequestsRequest\x1a\x15.chat.MessageRequests\x12K\n" +
+	"\x14AcceptMessageRequest\x12\x1c.chat.MessageRequestDecision\x1a\x15.chat.MessageRequests\x12L\n" +
+	"\x15DeclineMessageRequest\x12\x1c.chat.MessageRequestDecision\x1a\x15.chat.MessageRequests2\x82\x01\n" +
+	"\rUnreadService\x12:\n" +
+	"\x0fGetUnreadCounts\x12\x13.chat.UnreadRequest\x1a\x12.chat.UnreadCounts\x125\n" +
+	"\bMarkRead\x12\x15.chat.MarkReadRequest\x1a\x12.chat.UnreadCounts2\x83\x01\n" +
+	"\x0eHistoryService\x129\n" +
+	"\n" +
+	"GetHistory\x12\x14.chat.HistoryRequest\x1a\x15.chat.HistoryResponse\x126\n" +
+	"\aCatchup\x12\x14.chat.CatchupRequest\x1a\x15.chat.CatchupResponse2\xa7\x01\n" +
+	"\rThreadService\x12.\n" +
+	"\tGetThread\x12\x13.chat.ThreadRequest\x1a\f.chat.Thread\x121\n" +
+	"\fFollowThread\x12\x13.chat.ThreadRequest\x1a\f.chat.Thread\x123\n" +
+	"\x0eUnfollowThread\x12\x13.chat.ThreadRequest\x1a\f.chat.Thread2p\n" +
+	"\fDraftService\x120\n" +
+	"\tSaveDraft\x12\x16.chat.SaveDraftRequest\x1a\v.chat.Draft\x12.\n" +
+	"\tGetDrafts\x12\x13.chat.DraftsRequest\x1a\f.chat.Drafts2\x9f\x03\n" +
+	"\vRoomService\x123\n" +
+	"\tListUsers\x12\x16.chat.ListUsersRequest\x1a\x0e.chat.UserList\x123\n" +
+	"\tListRooms\x12\x16.chat.ListRoomsRequest\x1a\x0e.chat.RoomList\x123\n" +
+	"\tWatchRoom\x12\x11.chat.RoomRequest\x1a\x11.chat.ChatMessage0\x01\x12=\n" +
+	"\x0eGetRoomMembers\x12\x18.chat.RoomMembersRequest\x1a\x11.chat.RoomMembers\x12.\n" +
+	"\tGetInvite\x12\x13.chat.InviteRequest\x1a\f.chat.Invite\x12@\n" +
+	"\x0fGetRoomSettings\x12\x19.chat.RoomSettingsRequest\x1a\x12.chat.RoomSettings\x12@\n" +
+	"\x0fGetVoiceMembers\x12\x19.chat.VoiceMembersRequest\x1a\x12.chat.VoiceMembers2F\n" +
+	"\x0eFeatureService\x124\n" +
+	"\vGetFeatures\x12\x15.chat.FeaturesRequest\x1a\x0e.chat.Features2\x86\x02\n" +
+	"\x11AttachmentService\x123\n" +
+	"\x10UploadAttachment\x12\v.chat.Chunk\x1a\x10.chat.Attachment(\x01\x12<\n" +
+	"\x12DownloadAttachment\x12\x17.chat.AttachmentRequest\x1a\v.chat.Chunk0\x01\x12@\n" +
+	"\x0fGetUploadOffset\x12\x19.chat.UploadOffsetRequest\x1a\x12.chat.UploadOffset\x12<\n" +
+	"\x0eGetDownloadUrl\x12\x17.chat.AttachmentRequest\x1a\x11.chat.DownloadUrl2\xc4\x0f\n" +
+	"\fAdminService\x126\n" +
+	"\n" +
+	"ExportRoom\x12\x13.chat.ExportRequest\x1a\x11.chat.ChatMessage0\x01\x12:\n" +
+	"\x0eImportMessages\x12\x11.chat.ChatMessage\x1a\x13.chat.ImportSummary(\x01\x12+\n" +
+	"\bGetStats\x12\x12.chat.StatsRequest\x1a\v.chat.Stats\x12<\n" +
+	"\n" +
+	"WatchStats\x12\x17.chat.WatchStatsRequest\x1a\x13.chat.StatsSnapshot0\x01\x120\n" +
+	"\bGetQuota\x12\x12.chat.QuotaRequest\x1a\x10.chat.QuotaUsage\x123\n" +
+	"\bSetQuota\x12\x15.chat.SetQuotaRequest\x1a\x10.chat.QuotaUsage\x129\n" +
+	"\x0fRegisterCommand\x12\x12.chat.SlashCommand\x1a\x12.chat.SlashCommand\x12G\n" +
+	"\x11UnregisterCommand\x12\x1e.chat.UnregisterCommandRequest\x1a\x12.chat.SlashCommand\x12<\n" +
+	"\fListCommands\x12\x19.chat.ListCommandsRequest\x1a\x11.chat.CommandList\x12<\n" +
+	"\fListSessions\x12\x19.chat.ListSessionsRequest\x1a\x11.chat.SessionList\x12>\n" +
+	"\rRevokeSession\x12\x1a.chat.RevokeSessionRequest\x1a\x11.chat.SessionList\x121\n" +
+	"\n" +
+	"GetWelcome\x12\x14.chat.WelcomeRequest\x1a\r.chat.Welcome\x12*\n" +
+	"\n" +
+	"SetWelcome\x12\r.chat.Welcome\x1a\r.chat.Welcome\x129\n" +
+	"\vSetRoomRole\x12\x18.chat.SetRoomRoleRequest\x1a\x10.chat.RoomMember\x12=\n" +
+	"\x0eSetRoomPrivate\x12\x1b.chat.SetRoomPrivateRequest\x1a\x0e.chat.RoomInfo\x129\n" +
+	"\x0fSetRoomSettings\x12\x12.chat.RoomSettings\x1a\x12.chat.RoomSettings\x127\n" +
+	"\fCreateInvite\x12\x19.chat.CreateInviteRequest\x1a\f.chat.Invite\x121\n" +
+	"\fRevokeInvite\x12\x13.chat.InviteRequest\x1a\f.chat.Invite\x129\n" +
+	"\vListInvites\x12\x18.chat.ListInvitesRequest\x1a\x10.chat.InviteList\x12.\n" +
+	"\tCreateBan\x12\x16.chat.CreateBanRequest\x1a\t.chat.Ban\x12(\n" +
+	"\tRemoveBan\x12\x10.chat.BanRequest\x1a\t.chat.Ban\x120\n" +
+	"\bListBans\x12\x15.chat.ListBansRequest\x1a\r.chat.BanList\x124\n" +
+	"\fSetBanAppeal\x12\x19.chat.SetBanAppealRequest\x1a\t.chat.Ban\x120\n" +
+	"\fAddBlockRule\x12\x0f.chat.BlockRule\x1a\x0f.chat.BlockRule\x12:\n" +
+	"\x0fRemoveBlockRule\x12\x16.chat.BlockRuleRequest\x1a\x0f.chat.BlockRule\x12B\n" +
+	"\x0eListBlockRules\x12\x1b.chat.ListBlockRulesRequest\x1a\x13.chat.BlockRuleList\x12B\n" +
+	"\x10ReportQuarantine\x12\x16.chat.QuarantineReport\x1a\x16.chat.QuarantineReport\x129\n" +
+	"\bSnapshot\x12\x15.chat.SnapshotRequest\x1a\x14.chat.SnapshotRecord0\x01\x127\n" +
+	"\aRestore\x12\x14.chat.SnapshotRecord\x1a\x14.chat.RestoreSummary(\x01\x127\n" +
+	"\bAnnounce\x12\x15.chat.AnnounceRequest\x1a\x14.chat.AnnounceResult\x12<\n" +
+	"\rListRoomStats\x12\x16.chat.RoomStatsRequest\x1a\x13.chat.RoomStatsList\x129\n" +
+	"\fTailAuditLog\x12\x15.chat.AuditLogRequest\x1a\x10.chat.AuditEntry0\x01\x12J\n" +
+	"\x13ListModerationQueue\x12\x1c.chat.ModerationQueueRequest\x1a\x15.chat.ModerationQueue\x12I\n" +
+	"\x11ResolveModeration\x12\x1e.chat.ResolveModerationRequest\x1a\x14.chat.ModerationItem2\x9d\x02\n" +
+	"\x06Plugin\x125\n" +
+	"\bDescribe\x12\x17.chat.PluginInfoRequest\x1a\x10.chat.PluginInfo\x126\n" +
+	"\rFilterMessage\x12\x11.chat.ChatMessage\x1a\x12.chat.FilterResult\x126\n" +
+	"\x10MessageDelivered\x12\x11.chat.ChatMessage\x1a\x0f.chat.PluginAck\x122\n" +
+	"\vUserJoining\x12\x0f.chat.JoinEvent\x1a\x12.chat.JoinDecision\x128\n" +
+	"\rHandleCommand\x12\x13.chat.PluginCommand\x1a\x12.chat.CommandReply2~\n" +
+	"\x0eClusterService\x127\n" +
+	"\aDeliver\x12\x12.chat.PeerDelivery\x1a\x18.chat.PeerDeliveryResult\x123\n" +
+	"\fTransferRoom\x12\x0f.chat.RoomState\x1a\x12.chat.RoomStateAckB\x1eZ\x1crealTimeChat/proto/chat;chatb\x06proto3"
 
 var (
 	file_proto_chat_chat_proto_rawDescOnce sync.Once
@@ -106,18 +11302,445 @@ func file_proto_chat_chat_proto_rawDescGZIP() []byte {
 	return file_proto_chat_chat_proto_rawDescData
 }
 
-var file_proto_chat_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proto_chat_chat_proto_enumTypes = make([]protoimpl.EnumInfo, 13)
+var file_proto_chat_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 155)
 var file_proto_chat_chat_proto_goTypes = []any{
-	(*ChatMessage)(nil), // 0: chat.ChatMessage
+	(MessageType)(0),                 // 0: chat.MessageType
+	(RoomRole)(0),                    // 1: chat.RoomRole
+	(AttachmentPolicy)(0),            // 2: chat.AttachmentPolicy
+	(SignalType)(0),                  // 3: chat.SignalType
+	(CallState)(0),                   // 4: chat.CallState
+	(VoiceAction)(0),                 // 5: chat.VoiceAction
+	(PresenceStatus)(0),              // 6: chat.PresenceStatus
+	(NotifyLevel)(0),                 // 7: chat.NotifyLevel
+	(QuotaScope)(0),                  // 8: chat.QuotaScope
+	(BanScope)(0),                    // 9: chat.BanScope
+	(BlockAction)(0),                 // 10: chat.BlockAction
+	(PluginHook)(0),                  // 11: chat.PluginHook
+	(ModerationKind)(0),              // 12: chat.ModerationKind
+	(*ChatMessage)(nil),              // 13: chat.ChatMessage
+	(*Hello)(nil),                    // 14: chat.Hello
+	(*StreamFilter)(nil),             // 15: chat.StreamFilter
+	(*MessageBatch)(nil),             // 16: chat.MessageBatch
+	(*RoomMoved)(nil),                // 17: chat.RoomMoved
+	(*Subscriptions)(nil),            // 18: chat.Subscriptions
+	(*RoomChange)(nil),               // 19: chat.RoomChange
+	(*ListUsersRequest)(nil),         // 20: chat.ListUsersRequest
+	(*OnlineUser)(nil),               // 21: chat.OnlineUser
+	(*UserList)(nil),                 // 22: chat.UserList
+	(*RoomRequest)(nil),              // 23: chat.RoomRequest
+	(*ListRoomsRequest)(nil),         // 24: chat.ListRoomsRequest
+	(*RoomInfo)(nil),                 // 25: chat.RoomInfo
+	(*RoomList)(nil),                 // 26: chat.RoomList
+	(*RoomMember)(nil),               // 27: chat.RoomMember
+	(*RoomSettings)(nil),             // 28: chat.RoomSettings
+	(*RoomSettingsRequest)(nil),      // 29: chat.RoomSettingsRequest
+	(*RoomMembersRequest)(nil),       // 30: chat.RoomMembersRequest
+	(*RoomMembers)(nil),              // 31: chat.RoomMembers
+	(*SystemText)(nil),               // 32: chat.SystemText
+	(*Translation)(nil),              // 33: chat.Translation
+	(*MessageEdit)(nil),              // 34: chat.MessageEdit
+	(*Ack)(nil),                      // 35: chat.Ack
+	(*HistoryRequest)(nil),           // 36: chat.HistoryRequest
+	(*HistoryResponse)(nil),          // 37: chat.HistoryResponse
+	(*CatchupRequest)(nil),           // 38: chat.CatchupRequest
+	(*CatchupRoom)(nil),              // 39: chat.CatchupRoom
+	(*CatchupResponse)(nil),          // 40: chat.CatchupResponse
+	(*RoomCatchup)(nil),              // 41: chat.RoomCatchup
+	(*MembershipChange)(nil),         // 42: chat.MembershipChange
+	(*UnreadRequest)(nil),            // 43: chat.UnreadRequest
+	(*MarkReadRequest)(nil),          // 44: chat.MarkReadRequest
+	(*UnreadCounts)(nil),             // 45: chat.UnreadCounts
+	(*Signal)(nil),                   // 46: chat.Signal
+	(*VoiceMember)(nil),              // 47: chat.VoiceMember
+	(*VoiceEvent)(nil),               // 48: chat.VoiceEvent
+	(*VoiceMembersRequest)(nil),      // 49: chat.VoiceMembersRequest
+	(*VoiceMembers)(nil),             // 50: chat.VoiceMembers
+	(*CallEvent)(nil),                // 51: chat.CallEvent
+	(*Activity)(nil),                 // 52: chat.Activity
+	(*Heartbeat)(nil),                // 53: chat.Heartbeat
+	(*Members)(nil),                  // 54: chat.Members
+	(*Presence)(nil),                 // 55: chat.Presence
+	(*Attachment)(nil),               // 56: chat.Attachment
+	(*Thumbnail)(nil),                // 57: chat.Thumbnail
+	(*Code)(nil),                     // 58: chat.Code
+	(*LinkPreview)(nil),              // 59: chat.LinkPreview
+	(*Rename)(nil),                   // 60: chat.Rename
+	(*QuietHours)(nil),               // 61: chat.QuietHours
+	(*Preferences)(nil),              // 62: chat.Preferences
+	(*Keywords)(nil),                 // 63: chat.Keywords
+	(*KeywordRequest)(nil),           // 64: chat.KeywordRequest
+	(*KeywordHit)(nil),               // 65: chat.KeywordHit
+	(*PreferencesRequest)(nil),       // 66: chat.PreferencesRequest
+	(*ProfileRequest)(nil),           // 67: chat.ProfileRequest
+	(*Profile)(nil),                  // 68: chat.Profile
+	(*SetProfilePinRequest)(nil),     // 69: chat.SetProfilePinRequest
+	(*MessageRequestsRequest)(nil),   // 70: chat.MessageRequestsRequest
+	(*MessageRequests)(nil),          // 71: chat.MessageRequests
+	(*MessageRequest)(nil),           // 72: chat.MessageRequest
+	(*MessageRequestDecision)(nil),   // 73: chat.MessageRequestDecision
+	(*ContactsRequest)(nil),          // 74: chat.ContactsRequest
+	(*SaveDraftRequest)(nil),         // 75: chat.SaveDraftRequest
+	(*Draft)(nil),                    // 76: chat.Draft
+	(*DraftsRequest)(nil),            // 77: chat.DraftsRequest
+	(*Drafts)(nil),                   // 78: chat.Drafts
+	(*ThreadRequest)(nil),            // 79: chat.ThreadRequest
+	(*Thread)(nil),                   // 80: chat.Thread
+	(*ThreadUpdate)(nil),             // 81: chat.ThreadUpdate
+	(*ContactRequest)(nil),           // 82: chat.ContactRequest
+	(*Contacts)(nil),                 // 83: chat.Contacts
+	(*Contact)(nil),                  // 84: chat.Contact
+	(*Chunk)(nil),                    // 85: chat.Chunk
+	(*AttachmentRequest)(nil),        // 86: chat.AttachmentRequest
+	(*UploadOffsetRequest)(nil),      // 87: chat.UploadOffsetRequest
+	(*UploadOffset)(nil),             // 88: chat.UploadOffset
+	(*DownloadUrl)(nil),              // 89: chat.DownloadUrl
+	(*ExportRequest)(nil),            // 90: chat.ExportRequest
+	(*ImportSummary)(nil),            // 91: chat.ImportSummary
+	(*StatsRequest)(nil),             // 92: chat.StatsRequest
+	(*Stats)(nil),                    // 93: chat.Stats
+	(*WatchStatsRequest)(nil),        // 94: chat.WatchStatsRequest
+	(*StatsSnapshot)(nil),            // 95: chat.StatsSnapshot
+	(*Leadership)(nil),               // 96: chat.Leadership
+	(*StatsBucket)(nil),              // 97: chat.StatsBucket
+	(*RoomCount)(nil),                // 98: chat.RoomCount
+	(*Quota)(nil),                    // 99: chat.Quota
+	(*QuotaRequest)(nil),             // 100: chat.QuotaRequest
+	(*SetQuotaRequest)(nil),          // 101: chat.SetQuotaRequest
+	(*QuotaUsage)(nil),               // 102: chat.QuotaUsage
+	(*SlashCommand)(nil),             // 103: chat.SlashCommand
+	(*UnregisterCommandRequest)(nil), // 104: chat.UnregisterCommandRequest
+	(*ListCommandsRequest)(nil),      // 105: chat.ListCommandsRequest
+	(*CommandList)(nil),              // 106: chat.CommandList
+	(*Session)(nil),                  // 107: chat.Session
+	(*Welcome)(nil),                  // 108: chat.Welcome
+	(*WelcomeRequest)(nil),           // 109: chat.WelcomeRequest
+	(*ListSessionsRequest)(nil),      // 110: chat.ListSessionsRequest
+	(*SessionList)(nil),              // 111: chat.SessionList
+	(*SetRoomPrivateRequest)(nil),    // 112: chat.SetRoomPrivateRequest
+	(*CreateInviteRequest)(nil),      // 113: chat.CreateInviteRequest
+	(*Invite)(nil),                   // 114: chat.Invite
+	(*InviteRequest)(nil),            // 115: chat.InviteRequest
+	(*ListInvitesRequest)(nil),       // 116: chat.ListInvitesRequest
+	(*InviteList)(nil),               // 117: chat.InviteList
+	(*SetRoomRoleRequest)(nil),       // 118: chat.SetRoomRoleRequest
+	(*RevokeSessionRequest)(nil),     // 119: chat.RevokeSessionRequest
+	(*Ban)(nil),                      // 120: chat.Ban
+	(*CreateBanRequest)(nil),         // 121: chat.CreateBanRequest
+	(*BanRequest)(nil),               // 122: chat.BanRequest
+	(*ListBansRequest)(nil),          // 123: chat.ListBansRequest
+	(*BanList)(nil),                  // 124: chat.BanList
+	(*SetBanAppealRequest)(nil),      // 125: chat.SetBanAppealRequest
+	(*BlockRule)(nil),                // 126: chat.BlockRule
+	(*BlockRuleRequest)(nil),         // 127: chat.BlockRuleRequest
+	(*ListBlockRulesRequest)(nil),    // 128: chat.ListBlockRulesRequest
+	(*BlockRuleList)(nil),            // 129: chat.BlockRuleList
+	(*QuarantineReport)(nil),         // 130: chat.QuarantineReport
+	(*PluginInfoRequest)(nil),        // 131: chat.PluginInfoRequest
+	(*PluginInfo)(nil),               // 132: chat.PluginInfo
+	(*FilterResult)(nil),             // 133: chat.FilterResult
+	(*PluginAck)(nil),                // 134: chat.PluginAck
+	(*JoinEvent)(nil),                // 135: chat.JoinEvent
+	(*JoinDecision)(nil),             // 136: chat.JoinDecision
+	(*PluginCommand)(nil),            // 137: chat.PluginCommand
+	(*CommandReply)(nil),             // 138: chat.CommandReply
+	(*PeerDelivery)(nil),             // 139: chat.PeerDelivery
+	(*PeerDeliveryResult)(nil),       // 140: chat.PeerDeliveryResult
+	(*RoomState)(nil),                // 141: chat.RoomState
+	(*RoomStateAck)(nil),             // 142: chat.RoomStateAck
+	(*SnapshotRequest)(nil),          // 143: chat.SnapshotRequest
+	(*SnapshotRecord)(nil),           // 144: chat.SnapshotRecord
+	(*SnapshotUser)(nil),             // 145: chat.SnapshotUser
+	(*SnapshotRoom)(nil),             // 146: chat.SnapshotRoom
+	(*SnapshotAttachment)(nil),       // 147: chat.SnapshotAttachment
+	(*RestoreSummary)(nil),           // 148: chat.RestoreSummary
+	(*AnnounceRequest)(nil),          // 149: chat.AnnounceRequest
+	(*AnnounceResult)(nil),           // 150: chat.AnnounceResult
+	(*RoomStatsRequest)(nil),         // 151: chat.RoomStatsRequest
+	(*RoomStats)(nil),                // 152: chat.RoomStats
+	(*RoomStatsList)(nil),            // 153: chat.RoomStatsList
+	(*AuditLogRequest)(nil),          // 154: chat.AuditLogRequest
+	(*AuditEntry)(nil),               // 155: chat.AuditEntry
+	(*ModerationItem)(nil),           // 156: chat.ModerationItem
+	(*ModerationQueueRequest)(nil),   // 157: chat.ModerationQueueRequest
+	(*ModerationQueue)(nil),          // 158: chat.ModerationQueue
+	(*ResolveModerationRequest)(nil), // 159: chat.ResolveModerationRequest
+	(*FeaturesRequest)(nil),          // 160: chat.FeaturesRequest
+	(*Features)(nil),                 // 161: chat.Features
+	nil,                              // 162: chat.ChatMessage.MetadataEntry
+	nil,                              // 163: chat.SystemText.ArgsEntry
+	nil,                              // 164: chat.UnreadCounts.RoomsEntry
+	nil,                              // 165: chat.Preferences.RoomsEntry
+	nil,                              // 166: chat.Preferences.KeywordsEntry
+	nil,                              // 167: chat.Features.FeaturesEntry
 }
 var file_proto_chat_chat_proto_depIdxs = []int32{
-	0, // 0: chat.ChatService.RealtimeChat:input_type -> chat.ChatMessage
-	0, // 1: chat.ChatService.RealtimeChat:output_type -> chat.ChatMessage
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	32,  // 0: chat.ChatMessage.system:type_name -> chat.SystemText
+	0,   // 1: chat.ChatMessage.type:type_name -> chat.MessageType
+	162, // 2: chat.ChatMessage.metadata:type_name -> chat.ChatMessage.MetadataEntry
+	60,  // 3: chat.ChatMessage.rename:type_name -> chat.Rename
+	59,  // 4: chat.ChatMessage.link_preview:type_name -> chat.LinkPreview
+	58,  // 5: chat.ChatMessage.code:type_name -> chat.Code
+	56,  // 6: chat.ChatMessage.attachment:type_name -> chat.Attachment
+	46,  // 7: chat.ChatMessage.signal:type_name -> chat.Signal
+	51,  // 8: chat.ChatMessage.call_event:type_name -> chat.CallEvent
+	55,  // 9: chat.ChatMessage.presence:type_name -> chat.Presence
+	45,  // 10: chat.ChatMessage.unread:type_name -> chat.UnreadCounts
+	35,  // 11: chat.ChatMessage.ack:type_name -> chat.Ack
+	33,  // 12: chat.ChatMessage.translation:type_name -> chat.Translation
+	19,  // 13: chat.ChatMessage.room_change:type_name -> chat.RoomChange
+	14,  // 14: chat.ChatMessage.hello:type_name -> chat.Hello
+	52,  // 15: chat.ChatMessage.activity:type_name -> chat.Activity
+	53,  // 16: chat.ChatMessage.heartbeat:type_name -> chat.Heartbeat
+	34,  // 17: chat.ChatMessage.edit:type_name -> chat.MessageEdit
+	54,  // 18: chat.ChatMessage.members:type_name -> chat.Members
+	27,  // 19: chat.ChatMessage.member:type_name -> chat.RoomMember
+	65,  // 20: chat.ChatMessage.keyword_hit:type_name -> chat.KeywordHit
+	18,  // 21: chat.ChatMessage.subscriptions:type_name -> chat.Subscriptions
+	15,  // 22: chat.ChatMessage.filter:type_name -> chat.StreamFilter
+	16,  // 23: chat.ChatMessage.batch:type_name -> chat.MessageBatch
+	17,  // 24: chat.ChatMessage.room_moved:type_name -> chat.RoomMoved
+	48,  // 25: chat.ChatMessage.voice:type_name -> chat.VoiceEvent
+	81,  // 26: chat.ChatMessage.thread:type_name -> chat.ThreadUpdate
+	15,  // 27: chat.Hello.filter:type_name -> chat.StreamFilter
+	13,  // 28: chat.MessageBatch.messages:type_name -> chat.ChatMessage
+	6,   // 29: chat.OnlineUser.status:type_name -> chat.PresenceStatus
+	21,  // 30: chat.UserList.users:type_name -> chat.OnlineUser
+	25,  // 31: chat.RoomList.rooms:type_name -> chat.RoomInfo
+	1,   // 32: chat.RoomMember.role:type_name -> chat.RoomRole
+	6,   // 33: chat.RoomMember.status:type_name -> chat.PresenceStatus
+	1,   // 34: chat.RoomSettings.post_role:type_name -> chat.RoomRole
+	2,   // 35: chat.RoomSettings.attachments:type_name -> chat.AttachmentPolicy
+	27,  // 36: chat.RoomMembers.members:type_name -> chat.RoomMember
+	163, // 37: chat.SystemText.args:type_name -> chat.SystemText.ArgsEntry
+	13,  // 38: chat.HistoryResponse.messages:type_name -> chat.ChatMessage
+	39,  // 39: chat.CatchupRequest.rooms:type_name -> chat.CatchupRoom
+	41,  // 40: chat.CatchupResponse.rooms:type_name -> chat.RoomCatchup
+	13,  // 41: chat.RoomCatchup.messages:type_name -> chat.ChatMessage
+	42,  // 42: chat.RoomCatchup.members:type_name -> chat.MembershipChange
+	164, // 43: chat.UnreadCounts.rooms:type_name -> chat.UnreadCounts.RoomsEntry
+	3,   // 44: chat.Signal.type:type_name -> chat.SignalType
+	5,   // 45: chat.VoiceEvent.action:type_name -> chat.VoiceAction
+	47,  // 46: chat.VoiceEvent.member:type_name -> chat.VoiceMember
+	47,  // 47: chat.VoiceMembers.members:type_name -> chat.VoiceMember
+	4,   // 48: chat.CallEvent.state:type_name -> chat.CallState
+	6,   // 49: chat.Presence.status:type_name -> chat.PresenceStatus
+	57,  // 50: chat.Attachment.thumbnails:type_name -> chat.Thumbnail
+	165, // 51: chat.Preferences.rooms:type_name -> chat.Preferences.RoomsEntry
+	61,  // 52: chat.Preferences.quiet_hours:type_name -> chat.QuietHours
+	166, // 53: chat.Preferences.keywords:type_name -> chat.Preferences.KeywordsEntry
+	6,   // 54: chat.Profile.status:type_name -> chat.PresenceStatus
+	13,  // 55: chat.Profile.pinned:type_name -> chat.ChatMessage
+	72,  // 56: chat.MessageRequests.requests:type_name -> chat.MessageRequest
+	13,  // 57: chat.MessageRequest.messages:type_name -> chat.ChatMessage
+	76,  // 58: chat.Drafts.drafts:type_name -> chat.Draft
+	13,  // 59: chat.Thread.root:type_name -> chat.ChatMessage
+	13,  // 60: chat.Thread.replies:type_name -> chat.ChatMessage
+	84,  // 61: chat.Contacts.contacts:type_name -> chat.Contact
+	6,   // 62: chat.Contact.status:type_name -> chat.PresenceStatus
+	97,  // 63: chat.Stats.buckets:type_name -> chat.StatsBucket
+	98,  // 64: chat.Stats.top_rooms:type_name -> chat.RoomCount
+	96,  // 65: chat.Stats.leadership:type_name -> chat.Leadership
+	8,   // 66: chat.QuotaRequest.scope:type_name -> chat.QuotaScope
+	8,   // 67: chat.SetQuotaRequest.scope:type_name -> chat.QuotaScope
+	99,  // 68: chat.SetQuotaRequest.quota:type_name -> chat.Quota
+	8,   // 69: chat.QuotaUsage.scope:type_name -> chat.QuotaScope
+	99,  // 70: chat.QuotaUsage.quota:type_name -> chat.Quota
+	103, // 71: chat.CommandList.commands:type_name -> chat.SlashCommand
+	107, // 72: chat.SessionList.sessions:type_name -> chat.Session
+	114, // 73: chat.InviteList.invites:type_name -> chat.Invite
+	1,   // 74: chat.SetRoomRoleRequest.role:type_name -> chat.RoomRole
+	9,   // 75: chat.Ban.scope:type_name -> chat.BanScope
+	9,   // 76: chat.CreateBanRequest.scope:type_name -> chat.BanScope
+	120, // 77: chat.BanList.bans:type_name -> chat.Ban
+	10,  // 78: chat.BlockRule.action:type_name -> chat.BlockAction
+	126, // 79: chat.BlockRuleList.rules:type_name -> chat.BlockRule
+	11,  // 80: chat.PluginInfo.hooks:type_name -> chat.PluginHook
+	13,  // 81: chat.FilterResult.message:type_name -> chat.ChatMessage
+	13,  // 82: chat.PeerDelivery.message:type_name -> chat.ChatMessage
+	13,  // 83: chat.RoomState.history:type_name -> chat.ChatMessage
+	27,  // 84: chat.RoomState.members:type_name -> chat.RoomMember
+	114, // 85: chat.RoomState.invites:type_name -> chat.Invite
+	28,  // 86: chat.RoomState.settings:type_name -> chat.RoomSettings
+	145, // 87: chat.SnapshotRecord.user:type_name -> chat.SnapshotUser
+	146, // 88: chat.SnapshotRecord.room:type_name -> chat.SnapshotRoom
+	13,  // 89: chat.SnapshotRecord.message:type_name -> chat.ChatMessage
+	147, // 90: chat.SnapshotRecord.attachment:type_name -> chat.SnapshotAttachment
+	120, // 91: chat.SnapshotRecord.ban:type_name -> chat.Ban
+	126, // 92: chat.SnapshotRecord.block_rule:type_name -> chat.BlockRule
+	108, // 93: chat.SnapshotRecord.motd:type_name -> chat.Welcome
+	101, // 94: chat.SnapshotRecord.tenant_quota:type_name -> chat.SetQuotaRequest
+	68,  // 95: chat.SnapshotUser.profile:type_name -> chat.Profile
+	62,  // 96: chat.SnapshotUser.preferences:type_name -> chat.Preferences
+	76,  // 97: chat.SnapshotUser.drafts:type_name -> chat.Draft
+	27,  // 98: chat.SnapshotRoom.members:type_name -> chat.RoomMember
+	114, // 99: chat.SnapshotRoom.invites:type_name -> chat.Invite
+	99,  // 100: chat.SnapshotRoom.quota:type_name -> chat.Quota
+	28,  // 101: chat.SnapshotRoom.settings:type_name -> chat.RoomSettings
+	152, // 102: chat.RoomStatsList.rooms:type_name -> chat.RoomStats
+	12,  // 103: chat.ModerationItem.kind:type_name -> chat.ModerationKind
+	130, // 104: chat.ModerationItem.quarantine:type_name -> chat.QuarantineReport
+	156, // 105: chat.ModerationQueue.items:type_name -> chat.ModerationItem
+	167, // 106: chat.Features.features:type_name -> chat.Features.FeaturesEntry
+	7,   // 107: chat.Preferences.RoomsEntry.value:type_name -> chat.NotifyLevel
+	63,  // 108: chat.Preferences.KeywordsEntry.value:type_name -> chat.Keywords
+	13,  // 109: chat.ChatService.RealtimeChat:input_type -> chat.ChatMessage
+	66,  // 110: chat.PreferencesService.GetPreferences:input_type -> chat.PreferencesRequest
+	62,  // 111: chat.PreferencesService.SetPreferences:input_type -> chat.Preferences
+	66,  // 112: chat.PreferencesService.DeletePreferences:input_type -> chat.PreferencesRequest
+	64,  // 113: chat.PreferencesService.AddKeyword:input_type -> chat.KeywordRequest
+	64,  // 114: chat.PreferencesService.RemoveKeyword:input_type -> chat.KeywordRequest
+	67,  // 115: chat.ProfileService.GetProfile:input_type -> chat.ProfileRequest
+	69,  // 116: chat.ProfileService.SetProfilePin:input_type -> chat.SetProfilePinRequest
+	74,  // 117: chat.ContactService.ListContacts:input_type -> chat.ContactsRequest
+	82,  // 118: chat.ContactService.AddContact:input_type -> chat.ContactRequest
+	82,  // 119: chat.ContactService.RemoveContact:input_type -> chat.ContactRequest
+	70,  // 120: chat.MessageRequestService.ListMessageRequests:input_type -> chat.MessageRequestsRequest
+	73,  // 121: chat.MessageRequestService.AcceptMessageRequest:input_type -> chat.MessageRequestDecision
+	73,  // 122: chat.MessageRequestService.DeclineMessageRequest:input_type -> chat.MessageRequestDecision
+	43,  // 123: chat.UnreadService.GetUnreadCounts:input_type -> chat.UnreadRequest
+	44,  // 124: chat.UnreadService.MarkRead:input_type -> chat.MarkReadRequest
+	36,  // 125: chat.HistoryService.GetHistory:input_type -> chat.HistoryRequest
+	38,  // 126: chat.HistoryService.Catchup:input_type -> chat.CatchupRequest
+	79,  // 127: chat.ThreadService.GetThread:input_type -> chat.ThreadRequest
+	79,  // 128: chat.ThreadService.FollowThread:input_type -> chat.ThreadRequest
+	79,  // 129: chat.ThreadService.UnfollowThread:input_type -> chat.ThreadRequest
+	75,  // 130: chat.DraftService.SaveDraft:input_type -> chat.SaveDraftRequest
+	77,  // 131: chat.DraftService.GetDrafts:input_type -> chat.DraftsRequest
+	20,  // 132: chat.RoomService.ListUsers:input_type -> chat.ListUsersRequest
+	24,  // 133: chat.RoomService.ListRooms:input_type -> chat.ListRoomsRequest
+	23,  // 134: chat.RoomService.WatchRoom:input_type -> chat.RoomRequest
+	30,  // 135: chat.RoomService.GetRoomMembers:input_type -> chat.RoomMembersRequest
+	115, // 136: chat.RoomService.GetInvite:input_type -> chat.InviteRequest
+	29,  // 137: chat.RoomService.GetRoomSettings:input_type -> chat.RoomSettingsRequest
+	49,  // 138: chat.RoomService.GetVoiceMembers:input_type -> chat.VoiceMembersRequest
+	160, // 139: chat.FeatureService.GetFeatures:input_type -> chat.FeaturesRequest
+	85,  // 140: chat.AttachmentService.UploadAttachment:input_type -> chat.Chunk
+	86,  // 141: chat.AttachmentService.DownloadAttachment:input_type -> chat.AttachmentRequest
+	87,  // 142: chat.AttachmentService.GetUploadOffset:input_type -> chat.UploadOffsetRequest
+	86,  // 143: chat.AttachmentService.GetDownloadUrl:input_type -> chat.AttachmentRequest
+	90,  // 144: chat.AdminService.ExportRoom:input_type -> chat.ExportRequest
+	13,  // 145: chat.AdminService.ImportMessages:input_type -> chat.ChatMessage
+	92,  // 146: chat.AdminService.GetStats:input_type -> chat.StatsRequest
+	94,  // 147: chat.AdminService.WatchStats:input_type -> chat.WatchStatsRequest
+	100, // 148: chat.AdminService.GetQuota:input_type -> chat.QuotaRequest
+	101, // 149: chat.AdminService.SetQuota:input_type -> chat.SetQuotaRequest
+	103, // 150: chat.AdminService.RegisterCommand:input_type -> chat.SlashCommand
+	104, // 151: chat.AdminService.UnregisterCommand:input_type -> chat.UnregisterCommandRequest
+	105, // 152: chat.AdminService.ListCommands:input_type -> chat.ListCommandsRequest
+	110, // 153: chat.AdminService.ListSessions:input_type -> chat.ListSessionsRequest
+	119, // 154: chat.AdminService.RevokeSession:input_type -> chat.RevokeSessionRequest
+	109, // 155: chat.AdminService.GetWelcome:input_type -> chat.WelcomeRequest
+	108, // 156: chat.AdminService.SetWelcome:input_type -> chat.Welcome
+	118, // 157: chat.AdminService.SetRoomRole:input_type -> chat.SetRoomRoleRequest
+	112, // 158: chat.AdminService.SetRoomPrivate:input_type -> chat.SetRoomPrivateRequest
+	28,  // 159: chat.AdminService.SetRoomSettings:input_type -> chat.RoomSettings
+	113, // 160: chat.AdminService.CreateInvite:input_type -> chat.CreateInviteRequest
+	115, // 161: chat.AdminService.RevokeInvite:input_type -> chat.InviteRequest
+	116, // 162: chat.AdminService.ListInvites:input_type -> chat.ListInvitesRequest
+	121, // 163: chat.AdminService.CreateBan:input_type -> chat.CreateBanRequest
+	122, // 164: chat.AdminService.RemoveBan:input_type -> chat.BanRequest
+	123, // 165: chat.AdminService.ListBans:input_type -> chat.ListBansRequest
+	125, // 166: chat.AdminService.SetBanAppeal:input_type -> chat.SetBanAppealRequest
+	126, // 167: chat.AdminService.AddBlockRule:input_type -> chat.BlockRule
+	127, // 168: chat.AdminService.RemoveBlockRule:input_type -> chat.BlockRuleRequest
+	128, // 169: chat.AdminService.ListBlockRules:input_type -> chat.ListBlockRulesRequest
+	130, // 170: chat.AdminService.ReportQuarantine:input_type -> chat.QuarantineReport
+	143, // 171: chat.AdminService.Snapshot:input_type -> chat.SnapshotRequest
+	144, // 172: chat.AdminService.Restore:input_type -> chat.SnapshotRecord
+	149, // 173: chat.AdminService.Announce:input_type -> chat.AnnounceRequest
+	151, // 174: chat.AdminService.ListRoomStats:input_type -> chat.RoomStatsRequest
+	154, // 175: chat.AdminService.TailAuditLog:input_type -> chat.AuditLogRequest
+	157, // 176: chat.AdminService.ListModerationQueue:input_type -> chat.ModerationQueueRequest
+	159, // 177: chat.AdminService.ResolveModeration:input_type -> chat.ResolveModerationRequest
+	131, // 178: chat.Plugin.Describe:input_type -> chat.PluginInfoRequest
+	13,  // 179: chat.Plugin.FilterMessage:input_type -> chat.ChatMessage
+	13,  // 180: chat.Plugin.MessageDelivered:input_type -> chat.ChatMessage
+	135, // 181: chat.Plugin.UserJoining:input_type -> chat.JoinEvent
+	137, // 182: chat.Plugin.HandleCommand:input_type -> chat.PluginCommand
+	139, // 183: chat.ClusterService.Deliver:input_type -> chat.PeerDelivery
+	141, // 184: chat.ClusterService.TransferRoom:input_type -> chat.RoomState
+	13,  // 185: chat.ChatService.RealtimeChat:output_type -> chat.ChatMessage
+	62,  // 186: chat.PreferencesService.GetPreferences:output_type -> chat.Preferences
+	62,  // 187: chat.PreferencesService.SetPreferences:output_type -> chat.Preferences
+	62,  // 188: chat.PreferencesService.DeletePreferences:output_type -> chat.Preferences
+	62,  // 189: chat.PreferencesService.AddKeyword:output_type -> chat.Preferences
+	62,  // 190: chat.PreferencesService.RemoveKeyword:output_type -> chat.Preferences
+	68,  // 191: chat.ProfileService.GetProfile:output_type -> chat.Profile
+	68,  // 192: chat.ProfileService.SetProfilePin:output_type -> chat.Profile
+	83,  // 193: chat.ContactService.ListContacts:output_type -> chat.Contacts
+	83,  // 194: chat.ContactService.AddContact:output_type -> chat.Contacts
+	83,  // 195: chat.ContactService.RemoveContact:output_type -> chat.Contacts
+	71,  // 196: chat.MessageRequestService.ListMessageRequests:output_type -> chat.MessageRequests
+	71,  // 197: chat.MessageRequestService.AcceptMessageRequest:output_type -> chat.MessageRequests
+	71,  // 198: chat.MessageRequestService.DeclineMessageRequest:output_type -> chat.MessageRequests
+	45,  // 199: chat.UnreadService.GetUnreadCounts:output_type -> chat.UnreadCounts
+	45,  // 200: chat.UnreadService.MarkRead:output_type -> chat.UnreadCounts
+	37,  // 201: chat.HistoryService.GetHistory:output_type -> chat.HistoryResponse
+	40,  // 202: chat.HistoryService.Catchup:output_type -> chat.CatchupResponse
+	80,  // 203: chat.ThreadService.GetThread:output_type -> chat.Thread
+	80,  // 204: chat.ThreadService.FollowThread:output_type -> chat.Thread
+	80,  // 205: chat.ThreadService.UnfollowThread:output_type -> chat.Thread
+	76,  // 206: chat.DraftService.SaveDraft:output_type -> chat.Draft
+	78,  // 207: chat.DraftService.GetDrafts:output_type -> chat.Drafts
+	22,  // 208: chat.RoomService.ListUsers:output_type -> chat.UserList
+	26,  // 209: chat.RoomService.ListRooms:output_type -> chat.RoomList
+	13,  // 210: chat.RoomService.WatchRoom:output_type -> chat.ChatMessage
+	31,  // 211: chat.RoomService.GetRoomMembers:output_type -> chat.RoomMembers
+	114, // 212: chat.RoomService.GetInvite:output_type -> chat.Invite
+	28,  // 213: chat.RoomService.GetRoomSettings:output_type -> chat.RoomSettings
+	50,  // 214: chat.RoomService.GetVoiceMembers:output_type -> chat.VoiceMembers
+	161, // 215: chat.FeatureService.GetFeatures:output_type -> chat.Features
+	56,  // 216: chat.AttachmentService.UploadAttachment:output_type -> chat.Attachment
+	85,  // 217: chat.AttachmentService.DownloadAttachment:output_type -> chat.Chunk
+	88,  // 218: chat.AttachmentService.GetUploadOffset:output_type -> chat.UploadOffset
+	89,  // 219: chat.AttachmentService.GetDownloadUrl:output_type -> chat.DownloadUrl
+	13,  // 220: chat.AdminService.ExportRoom:output_type -> chat.ChatMessage
+	91,  // 221: chat.AdminService.ImportMessages:output_type -> chat.ImportSummary
+	93,  // 222: chat.AdminService.GetStats:output_type -> chat.Stats
+	95,  // 223: chat.AdminService.WatchStats:output_type -> chat.StatsSnapshot
+	102, // 224: chat.AdminService.GetQuota:output_type -> chat.QuotaUsage
+	102, // 225: chat.AdminService.SetQuota:output_type -> chat.QuotaUsage
+	103, // 226: chat.AdminService.RegisterCommand:output_type -> chat.SlashCommand
+	103, // 227: chat.AdminService.UnregisterCommand:output_type -> chat.SlashCommand
+	106, // 228: chat.AdminService.ListCommands:output_type -> chat.CommandList
+	111, // 229: chat.AdminService.ListSessions:output_type -> chat.SessionList
+	111, // 230: chat.AdminService.RevokeSession:output_type -> chat.SessionList
+	108, // 231: chat.AdminService.GetWelcome:output_type -> chat.Welcome
+	108, // 232: chat.AdminService.SetWelcome:output_type -> chat.Welcome
+	27,  // 233: chat.AdminService.SetRoomRole:output_type -> chat.RoomMember
+	25,  // 234: chat.AdminService.SetRoomPrivate:output_type -> chat.RoomInfo
+	28,  // 235: chat.AdminService.SetRoomSettings:output_type -> chat.RoomSettings
+	114, // 236: chat.AdminService.CreateInvite:output_type -> chat.Invite
+	114, // 237: chat.AdminService.RevokeInvite:output_type -> chat.Invite
+	117, // 238: chat.AdminService.ListInvites:output_type -> chat.InviteList
+	120, // 239: chat.AdminService.CreateBan:output_type -> chat.Ban
+	120, // 240: chat.AdminService.RemoveBan:output_type -> chat.Ban
+	124, // 241: chat.AdminService.ListBans:output_type -> chat.BanList
+	120, // 242: chat.AdminService.SetBanAppeal:output_type -> chat.Ban
+	126, // 243: chat.AdminService.AddBlockRule:output_type -> chat.BlockRule
+	126, // 244: chat.AdminService.RemoveBlockRule:output_type -> chat.BlockRule
+	129, // 245: chat.AdminService.ListBlockRules:output_type -> chat.BlockRuleList
+	130, // 246: chat.AdminService.ReportQuarantine:output_type -> chat.QuarantineReport
+	144, // 247: chat.AdminService.Snapshot:output_type -> chat.SnapshotRecord
+	148, // 248: chat.AdminService.Restore:output_type -> chat.RestoreSummary
+	150, // 249: chat.AdminService.Announce:output_type -> chat.AnnounceResult
+	153, // 250: chat.AdminService.ListRoomStats:output_type -> chat.RoomStatsList
+	155, // 251: chat.AdminService.TailAuditLog:output_type -> chat.AuditEntry
+	158, // 252: chat.AdminService.ListModerationQueue:output_type -> chat.ModerationQueue
+	156, // 253: chat.AdminService.ResolveModeration:output_type -> chat.ModerationItem
+	132, // 254: chat.Plugin.Describe:output_type -> chat.PluginInfo
+	133, // 255: chat.Plugin.FilterMessage:output_type -> chat.FilterResult
+	134, // 256: chat.Plugin.MessageDelivered:output_type -> chat.PluginAck
+	136, // 257: chat.Plugin.UserJoining:output_type -> chat.JoinDecision
+	138, // 258: chat.Plugin.HandleCommand:output_type -> chat.CommandReply
+	140, // 259: chat.ClusterService.Deliver:output_type -> chat.PeerDeliveryResult
+	142, // 260: chat.ClusterService.TransferRoom:output_type -> chat.RoomStateAck
+	185, // [185:261] is the sub-list for method output_type
+	109, // [109:185] is the sub-list for method input_type
+	109, // [109:109] is the sub-list for extension type_name
+	109, // [109:109] is the sub-list for extension extendee
+	0,   // [0:109] is the sub-list for field type_name
 }
 
 func init() { file_proto_chat_chat_proto_init() }
@@ -125,18 +11748,55 @@ func file_proto_chat_chat_proto_init() {
 	if File_proto_chat_chat_proto != nil {
 		return
 	}
+	file_proto_chat_chat_proto_msgTypes[0].OneofWrappers = []any{
+		(*ChatMessage_Rename)(nil),
+		(*ChatMessage_LinkPreview)(nil),
+		(*ChatMessage_Code)(nil),
+		(*ChatMessage_Attachment)(nil),
+		(*ChatMessage_Signal)(nil),
+		(*ChatMessage_CallEvent)(nil),
+		(*ChatMessage_Presence)(nil),
+		(*ChatMessage_Unread)(nil),
+		(*ChatMessage_Ack)(nil),
+		(*ChatMessage_Translation)(nil),
+		(*ChatMessage_RoomChange)(nil),
+		(*ChatMessage_Hello)(nil),
+		(*ChatMessage_Activity)(nil),
+		(*ChatMessage_Heartbeat)(nil),
+		(*ChatMessage_Edit)(nil),
+		(*ChatMessage_Members)(nil),
+		(*ChatMessage_Member)(nil),
+		(*ChatMessage_KeywordHit)(nil),
+		(*ChatMessage_Subscriptions)(nil),
+		(*ChatMessage_Filter)(nil),
+		(*ChatMessage_Batch)(nil),
+		(*ChatMessage_RoomMoved)(nil),
+		(*ChatMessage_Voice)(nil),
+		(*ChatMessage_Thread)(nil),
+	}
+	file_proto_chat_chat_proto_msgTypes[131].OneofWrappers = []any{
+		(*SnapshotRecord_User)(nil),
+		(*SnapshotRecord_Room)(nil),
+		(*SnapshotRecord_Message)(nil),
+		(*SnapshotRecord_Attachment)(nil),
+		(*SnapshotRecord_Ban)(nil),
+		(*SnapshotRecord_BlockRule)(nil),
+		(*SnapshotRecord_Motd)(nil),
+		(*SnapshotRecord_TenantQuota)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_chat_chat_proto_rawDesc), len(file_proto_chat_chat_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   1,
+			NumEnums:      13,
+			NumMessages:   155,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   15,
 		},
 		GoTypes:           file_proto_chat_chat_proto_goTypes,
 		DependencyIndexes: file_proto_chat_chat_proto_depIdxs,
+		EnumInfos:         file_proto_chat_chat_proto_enumTypes,
 		MessageInfos:      file_proto_chat_chat_proto_msgTypes,
 	}.Build()
 	File_proto_chat_chat_proto = out.File
@@ -121,3 +121,3965 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "proto/chat/chat.proto",
 }
+
+const (
+	PreferencesService_GetPreferences_FullMethodName    = "/chat.PreferencesService/GetPreferences"
+	PreferencesService_SetPreferences_FullMethodName    = "/chat.PreferencesService/SetPreferences"
+	PreferencesService_DeletePreferences_FullMethodName = "/chat.PreferencesService/DeletePreferences"
+	PreferencesService_AddKeyword_FullMethodName        = "/chat.PreferencesService/AddKeyword"
+	PreferencesService_RemoveKeyword_FullMethodName     = "/chat.PreferencesService/RemoveKeyword"
+)
+
+// PreferencesServiceClient is the client API for PreferencesService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 通知偏好服务，按用户名读写
+type PreferencesServiceClient interface {
+	GetPreferences(ctx context.Context, in *PreferencesRequest, opts ...grpc.CallOption) (*Preferences, error)
+	SetPreferences(ctx context.Context, in *Preferences, opts ...grpc.CallOption) (*Preferences, error)
+	// 删除后恢复默认偏好
+	DeletePreferences(ctx context.Context, in *PreferencesRequest, opts ...grpc.CallOption) (*Preferences, error)
+	// 在房间登记一个关键词，公共消息命中时服务器发出 TYPE_KEYWORD_HIT；已登记的不报错
+	AddKeyword(ctx context.Context, in *KeywordRequest, opts ...grpc.CallOption) (*Preferences, error)
+	RemoveKeyword(ctx context.Context, in *KeywordRequest, opts ...grpc.CallOption) (*Preferences, error)
+}
+
+type preferencesServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPreferencesServiceClient(cc grpc.ClientConnInterface) PreferencesServiceClient {
+	return &preferencesServiceClient{cc}
+}
+
+func (c *preferencesServiceClient) GetPreferences(ctx context.Context, in *PreferencesRequest, opts ...grpc.CallOption) (*Preferences, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Preferences)
+	err := c.cc.Invoke(ctx, PreferencesService_GetPreferences_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *preferencesServiceClient) SetPreferences(ctx context.Context, in *Preferences, opts ...grpc.CallOption) (*Preferences, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Preferences)
+	err := c.cc.Invoke(ctx, PreferencesService_SetPreferences_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *preferencesServiceClient) DeletePreferences(ctx context.Context, in *PreferencesRequest, opts ...grpc.CallOption) (*Preferences, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Preferences)
+	err := c.cc.Invoke(ctx, PreferencesService_DeletePreferences_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *preferencesServiceClient) AddKeyword(ctx context.Context, in *KeywordRequest, opts ...grpc.CallOption) (*Preferences, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Preferences)
+	err := c.cc.Invoke(ctx, PreferencesService_AddKeyword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *preferencesServiceClient) RemoveKeyword(ctx context.Context, in *KeywordRequest, opts ...grpc.CallOption) (*Preferences, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Preferences)
+	err := c.cc.Invoke(ctx, PreferencesService_RemoveKeyword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PreferencesServiceServer is the server API for PreferencesService service.
+// All implementations must embed UnimplementedPreferencesServiceServer
+// for forward compatibility.
+//
+// 通知偏好服务，按用户名读写
+type PreferencesServiceServer interface {
+	GetPreferences(context.Context, *PreferencesRequest) (*Preferences, error)
+	SetPreferences(context.Context, *Preferences) (*Preferences, error)
+	// 删除后恢复默认偏好
+	DeletePreferences(context.Context, *PreferencesRequest) (*Preferences, error)
+	// 在房间登记一个关键词，公共消息命中时服务器发出 TYPE_KEYWORD_HIT；已登记的不报错
+	AddKeyword(context.Context, *KeywordRequest) (*Preferences, error)
+	RemoveKeyword(context.Context, *KeywordRequest) (*Preferences, error)
+	mustEmbedUnimplementedPreferencesServiceServer()
+}
+
+// UnimplementedPreferencesServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPreferencesServiceServer struct{}
+
+func (UnimplementedPreferencesServiceServer) GetPreferences(context.Context, *PreferencesRequest) (*Preferences, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPreferences not implemented")
+}
+func (UnimplementedPreferencesServiceServer) SetPreferences(context.Context, *Preferences) (*Preferences, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPreferences not implemented")
+}
+func (UnimplementedPreferencesServiceServer) DeletePreferences(context.Context, *PreferencesRequest) (*Preferences, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePreferences not implemented")
+}
+func (UnimplementedPreferencesServiceServer) AddKeyword(context.Context, *KeywordRequest) (*Preferences, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddKeyword not implemented")
+}
+func (UnimplementedPreferencesServiceServer) RemoveKeyword(context.Context, *KeywordRequest) (*Preferences, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveKeyword not implemented")
+}
+func (UnimplementedPreferencesServiceServer) mustEmbedUnimplementedPreferencesServiceServer() {}
+func (UnimplementedPreferencesServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafePreferencesServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PreferencesServiceServer will
+// result in compilation errors.
+type UnsafePreferencesServiceServer interface {
+	mustEmbedUnimplementedPreferencesServiceServer()
+}
+
+func RegisterPreferencesServiceServer(s grpc.ServiceRegistrar, srv PreferencesServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPreferencesServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PreferencesService_ServiceDesc, srv)
+}
+
+func _PreferencesService_GetPreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreferencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreferencesServiceServer).GetPreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PreferencesService_GetPreferences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreferencesServiceServer).GetPreferences(ctx, req.(*PreferencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PreferencesService_SetPreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Preferences)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreferencesServiceServer).SetPreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PreferencesService_SetPreferences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreferencesServiceServer).SetPreferences(ctx, req.(*Preferences))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PreferencesService_DeletePreferences_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreferencesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreferencesServiceServer).DeletePreferences(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PreferencesService_DeletePreferences_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreferencesServiceServer).DeletePreferences(ctx, req.(*PreferencesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PreferencesService_AddKeyword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeywordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreferencesServiceServer).AddKeyword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PreferencesService_AddKeyword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreferencesServiceServer).AddKeyword(ctx, req.(*KeywordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PreferencesService_RemoveKeyword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeywordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreferencesServiceServer).RemoveKeyword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PreferencesService_RemoveKeyword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreferencesServiceServer).RemoveKeyword(ctx, req.(*KeywordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PreferencesService_ServiceDesc is the grpc.ServiceDesc for PreferencesService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PreferencesService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.PreferencesService",
+	HandlerType: (*PreferencesServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPreferences",
+			Handler:    _PreferencesService_GetPreferences_Handler,
+		},
+		{
+			MethodName: "SetPreferences",
+			Handler:    _PreferencesService_SetPreferences_Handler,
+		},
+		{
+			MethodName: "DeletePreferences",
+			Handler:    _PreferencesService_DeletePreferences_Handler,
+		},
+		{
+			MethodName: "AddKeyword",
+			Handler:    _PreferencesService_AddKeyword_Handler,
+		},
+		{
+			MethodName: "RemoveKeyword",
+			Handler:    _PreferencesService_RemoveKeyword_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	ProfileService_GetProfile_FullMethodName    = "/chat.ProfileService/GetProfile"
+	ProfileService_SetProfilePin_FullMethodName = "/chat.ProfileService/SetProfilePin"
+)
+
+// ProfileServiceClient is the client API for ProfileService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 用户资料服务，资料卡显示在线状态和用户置顶的一条消息
+type ProfileServiceClient interface {
+	GetProfile(ctx context.Context, in *ProfileRequest, opts ...grpc.CallOption) (*Profile, error)
+	// 把一条公共消息（自己或他人的）置顶到资料，message_id 为空时取消置顶；每人只能置顶一条
+	SetProfilePin(ctx context.Context, in *SetProfilePinRequest, opts ...grpc.CallOption) (*Profile, error)
+}
+
+type profileServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProfileServiceClient(cc grpc.ClientConnInterface) ProfileServiceClient {
+	return &profileServiceClient{cc}
+}
+
+func (c *profileServiceClient) GetProfile(ctx context.Context, in *ProfileRequest, opts ...grpc.CallOption) (*Profile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Profile)
+	err := c.cc.Invoke(ctx, ProfileService_GetProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *profileServiceClient) SetProfilePin(ctx context.Context, in *SetProfilePinRequest, opts ...grpc.CallOption) (*Profile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Profile)
+	err := c.cc.Invoke(ctx, ProfileService_SetProfilePin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProfileServiceServer is the server API for ProfileService service.
+// All implementations must embed UnimplementedProfileServiceServer
+// for forward compatibility.
+//
+// 用户资料服务，资料卡显示在线状态和用户置顶的一条消息
+type ProfileServiceServer interface {
+	GetProfile(context.Context, *ProfileRequest) (*Profile, error)
+	// 把一条公共消息（自己或他人的）置顶到资料，message_id 为空时取消置顶；每人只能置顶一条
+	SetProfilePin(context.Context, *SetProfilePinRequest) (*Profile, error)
+	mustEmbedUnimplementedProfileServiceServer()
+}
+
+// UnimplementedProfileServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedProfileServiceServer struct{}
+
+func (UnimplementedProfileServiceServer) GetProfile(context.Context, *ProfileRequest) (*Profile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProfile not implemented")
+}
+func (UnimplementedProfileServiceServer) SetProfilePin(context.Context, *SetProfilePinRequest) (*Profile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetProfilePin not implemented")
+}
+func (UnimplementedProfileServiceServer) mustEmbedUnimplementedProfileServiceServer() {}
+func (UnimplementedProfileServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeProfileServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProfileServiceServer will
+// result in compilation errors.
+type UnsafeProfileServiceServer interface {
+	mustEmbedUnimplementedProfileServiceServer()
+}
+
+func RegisterProfileServiceServer(s grpc.ServiceRegistrar, srv ProfileServiceServer) {
+	// If the following call pancis, it indicates UnimplementedProfileServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ProfileService_ServiceDesc, srv)
+}
+
+func _ProfileService_GetProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProfileServiceServer).GetProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProfileService_GetProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProfileServiceServer).GetProfile(ctx, req.(*ProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProfileService_SetProfilePin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetProfilePinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProfileServiceServer).SetProfilePin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProfileService_SetProfilePin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProfileServiceServer).SetProfilePin(ctx, req.(*SetProfilePinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProfileService_ServiceDesc is the grpc.ServiceDesc for ProfileService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProfileService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.ProfileService",
+	HandlerType: (*ProfileServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetProfile",
+			Handler:    _ProfileService_GetProfile_Handler,
+		},
+		{
+			MethodName: "SetProfilePin",
+			Handler:    _ProfileService_SetProfilePin_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	ContactService_ListContacts_FullMethodName  = "/chat.ContactService/ListContacts"
+	ContactService_AddContact_FullMethodName    = "/chat.ContactService/AddContact"
+	ContactService_RemoveContact_FullMethodName = "/chat.ContactService/RemoveContact"
+)
+
+// ContactServiceClient is the client API for ContactService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 联系人服务，按用户名读写。上下线和状态变化只发给同房间的用户和把对方加为联系人的用户
+type ContactServiceClient interface {
+	ListContacts(ctx context.Context, in *ContactsRequest, opts ...grpc.CallOption) (*Contacts, error)
+	// 添加已有联系人不报错
+	AddContact(ctx context.Context, in *ContactRequest, opts ...grpc.CallOption) (*Contacts, error)
+	RemoveContact(ctx context.Context, in *ContactRequest, opts ...grpc.CallOption) (*Contacts, error)
+}
+
+type contactServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewContactServiceClient(cc grpc.ClientConnInterface) ContactServiceClient {
+	return &contactServiceClient{cc}
+}
+
+func (c *contactServiceClient) ListContacts(ctx context.Context, in *ContactsRequest, opts ...grpc.CallOption) (*Contacts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Contacts)
+	err := c.cc.Invoke(ctx, ContactService_ListContacts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *contactServiceClient) AddContact(ctx context.Context, in *ContactRequest, opts ...grpc.CallOption) (*Contacts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Contacts)
+	err := c.cc.Invoke(ctx, ContactService_AddContact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *contactServiceClient) RemoveContact(ctx context.Context, in *ContactRequest, opts ...grpc.CallOption) (*Contacts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Contacts)
+	err := c.cc.Invoke(ctx, ContactService_RemoveContact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ContactServiceServer is the server API for ContactService service.
+// All implementations must embed UnimplementedContactServiceServer
+// for forward compatibility.
+//
+// 联系人服务，按用户名读写。上下线和状态变化只发给同房间的用户和把对方加为联系人的用户
+type ContactServiceServer interface {
+	ListContacts(context.Context, *ContactsRequest) (*Contacts, error)
+	// 添加已有联系人不报错
+	AddContact(context.Context, *ContactRequest) (*Contacts, error)
+	RemoveContact(context.Context, *ContactRequest) (*Contacts, error)
+	mustEmbedUnimplementedContactServiceServer()
+}
+
+// UnimplementedContactServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedContactServiceServer struct{}
+
+func (UnimplementedContactServiceServer) ListContacts(context.Context, *ContactsRequest) (*Contacts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListContacts not implemented")
+}
+func (UnimplementedContactServiceServer) AddContact(context.Context, *ContactRequest) (*Contacts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddContact not implemented")
+}
+func (UnimplementedContactServiceServer) RemoveContact(context.Context, *ContactRequest) (*Contacts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveContact not implemented")
+}
+func (UnimplementedContactServiceServer) mustEmbedUnimplementedContactServiceServer() {}
+func (UnimplementedContactServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeContactServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ContactServiceServer will
+// result in compilation errors.
+type UnsafeContactServiceServer interface {
+	mustEmbedUnimplementedContactServiceServer()
+}
+
+func RegisterContactServiceServer(s grpc.ServiceRegistrar, srv ContactServiceServer) {
+	// If the following call pancis, it indicates UnimplementedContactServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ContactService_ServiceDesc, srv)
+}
+
+func _ContactService_ListContacts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContactsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContactServiceServer).ListContacts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContactService_ListContacts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContactServiceServer).ListContacts(ctx, req.(*ContactsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContactService_AddContact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContactServiceServer).AddContact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContactService_AddContact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContactServiceServer).AddContact(ctx, req.(*ContactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContactService_RemoveContact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContactServiceServer).RemoveContact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContactService_RemoveContact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContactServiceServer).RemoveContact(ctx, req.(*ContactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ContactService_ServiceDesc is the grpc.ServiceDesc for ContactService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ContactService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.ContactService",
+	HandlerType: (*ContactServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListContacts",
+			Handler:    _ContactService_ListContacts_Handler,
+		},
+		{
+			MethodName: "AddContact",
+			Handler:    _ContactService_AddContact_Handler,
+		},
+		{
+			MethodName: "RemoveContact",
+			Handler:    _ContactService_RemoveContact_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	MessageRequestService_ListMessageRequests_FullMethodName   = "/chat.MessageRequestService/ListMessageRequests"
+	MessageRequestService_AcceptMessageRequest_FullMethodName  = "/chat.MessageRequestService/AcceptMessageRequest"
+	MessageRequestService_DeclineMessageRequest_FullMethodName = "/chat.MessageRequestService/DeclineMessageRequest"
+)
+
+// MessageRequestServiceClient is the client API for MessageRequestService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 消息请求服务。开启 message_requests 偏好的用户收到非联系人的私信时，私信先进入消息请求，
+// 接受后送达并把发送者加为联系人，拒绝后丢弃，该发送者之后的私信也不再送达
+type MessageRequestServiceClient interface {
+	ListMessageRequests(ctx context.Context, in *MessageRequestsRequest, opts ...grpc.CallOption) (*MessageRequests, error)
+	AcceptMessageRequest(ctx context.Context, in *MessageRequestDecision, opts ...grpc.CallOption) (*MessageRequests, error)
+	DeclineMessageRequest(ctx context.Context, in *MessageRequestDecision, opts ...grpc.CallOption) (*MessageRequests, error)
+}
+
+type messageRequestServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMessageRequestServiceClient(cc grpc.ClientConnInterface) MessageRequestServiceClient {
+	return &messageRequestServiceClient{cc}
+}
+
+func (c *messageRequestServiceClient) ListMessageRequests(ctx context.Context, in *MessageRequestsRequest, opts ...grpc.CallOption) (*MessageRequests, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessageRequests)
+	err := c.cc.Invoke(ctx, MessageRequestService_ListMessageRequests_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageRequestServiceClient) AcceptMessageRequest(ctx context.Context, in *MessageRequestDecision, opts ...grpc.CallOption) (*MessageRequests, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessageRequests)
+	err := c.cc.Invoke(ctx, MessageRequestService_AcceptMessageRequest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageRequestServiceClient) DeclineMessageRequest(ctx context.Context, in *MessageRequestDecision, opts ...grpc.CallOption) (*MessageRequests, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessageRequests)
+	err := c.cc.Invoke(ctx, MessageRequestService_DeclineMessageRequest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MessageRequestServiceServer is the server API for MessageRequestService service.
+// All implementations must embed UnimplementedMessageRequestServiceServer
+// for forward compatibility.
+//
+// 消息请求服务。开启 message_requests 偏好的用户收到非联系人的私信时，私信先进入消息请求，
+// 接受后送达并把发送者加为联系人，拒绝后丢弃，该发送者之后的私信也不再送达
+type MessageRequestServiceServer interface {
+	ListMessageRequests(context.Context, *MessageRequestsRequest) (*MessageRequests, error)
+	AcceptMessageRequest(context.Context, *MessageRequestDecision) (*MessageRequests, error)
+	DeclineMessageRequest(context.Context, *MessageRequestDecision) (*MessageRequests, error)
+	mustEmbedUnimplementedMessageRequestServiceServer()
+}
+
+// UnimplementedMessageRequestServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMessageRequestServiceServer struct{}
+
+func (UnimplementedMessageRequestServiceServer) ListMessageRequests(context.Context, *MessageRequestsRequest) (*MessageRequests, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessageRequests not implemented")
+}
+func (UnimplementedMessageRequestServiceServer) AcceptMessageRequest(context.Context, *MessageRequestDecision) (*MessageRequests, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptMessageRequest not implemented")
+}
+func (UnimplementedMessageRequestServiceServer) DeclineMessageRequest(context.Context, *MessageRequestDecision) (*MessageRequests, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeclineMessageRequest not implemented")
+}
+func (UnimplementedMessageRequestServiceServer) mustEmbedUnimplementedMessageRequestServiceServer() {}
+func (UnimplementedMessageRequestServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeMessageRequestServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MessageRequestServiceServer will
+// result in compilation errors.
+type UnsafeMessageRequestServiceServer interface {
+	mustEmbedUnimplementedMessageRequestServiceServer()
+}
+
+func RegisterMessageRequestServiceServer(s grpc.ServiceRegistrar, srv MessageRequestServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMessageRequestServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MessageRequestService_ServiceDesc, srv)
+}
+
+func _MessageRequestService_ListMessageRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageRequestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageRequestServiceServer).ListMessageRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageRequestService_ListMessageRequests_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageRequestServiceServer).ListMessageRequests(ctx, req.(*MessageRequestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageRequestService_AcceptMessageRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageRequestDecision)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageRequestServiceServer).AcceptMessageRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageRequestService_AcceptMessageRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageRequestServiceServer).AcceptMessageRequest(ctx, req.(*MessageRequestDecision))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageRequestService_DeclineMessageRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageRequestDecision)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageRequestServiceServer).DeclineMessageRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageRequestService_DeclineMessageRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageRequestServiceServer).DeclineMessageRequest(ctx, req.(*MessageRequestDecision))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MessageRequestService_ServiceDesc is the grpc.ServiceDesc for MessageRequestService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MessageRequestService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.MessageRequestService",
+	HandlerType: (*MessageRequestServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListMessageRequests",
+			Handler:    _MessageRequestService_ListMessageRequests_Handler,
+		},
+		{
+			MethodName: "AcceptMessageRequest",
+			Handler:    _MessageRequestService_AcceptMessageRequest_Handler,
+		},
+		{
+			MethodName: "DeclineMessageRequest",
+			Handler:    _MessageRequestService_DeclineMessageRequest_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	UnreadService_GetUnreadCounts_FullMethodName = "/chat.UnreadService/GetUnreadCounts"
+	UnreadService_MarkRead_FullMethodName        = "/chat.UnreadService/MarkRead"
+)
+
+// UnreadServiceClient is the client API for UnreadService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 未读计数服务，按房间记录每个用户已读到的序号
+type UnreadServiceClient interface {
+	GetUnreadCounts(ctx context.Context, in *UnreadRequest, opts ...grpc.CallOption) (*UnreadCounts, error)
+	// 标记 seq 及之前的消息为已读，已读位置只会前进
+	MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*UnreadCounts, error)
+}
+
+type unreadServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUnreadServiceClient(cc grpc.ClientConnInterface) UnreadServiceClient {
+	return &unreadServiceClient{cc}
+}
+
+func (c *unreadServiceClient) GetUnreadCounts(ctx context.Context, in *UnreadRequest, opts ...grpc.CallOption) (*UnreadCounts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnreadCounts)
+	err := c.cc.Invoke(ctx, UnreadService_GetUnreadCounts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *unreadServiceClient) MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*UnreadCounts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnreadCounts)
+	err := c.cc.Invoke(ctx, UnreadService_MarkRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnreadServiceServer is the server API for UnreadService service.
+// All implementations must embed UnimplementedUnreadServiceServer
+// for forward compatibility.
+//
+// 未读计数服务，按房间记录每个用户已读到的序号
+type UnreadServiceServer interface {
+	GetUnreadCounts(context.Context, *UnreadRequest) (*UnreadCounts, error)
+	// 标记 seq 及之前的消息为已读，已读位置只会前进
+	MarkRead(context.Context, *MarkReadRequest) (*UnreadCounts, error)
+	mustEmbedUnimplementedUnreadServiceServer()
+}
+
+// UnimplementedUnreadServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUnreadServiceServer struct{}
+
+func (UnimplementedUnreadServiceServer) GetUnreadCounts(context.Context, *UnreadRequest) (*UnreadCounts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUnreadCounts not implemented")
+}
+func (UnimplementedUnreadServiceServer) MarkRead(context.Context, *MarkReadRequest) (*UnreadCounts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkRead not implemented")
+}
+func (UnimplementedUnreadServiceServer) mustEmbedUnimplementedUnreadServiceServer() {}
+func (UnimplementedUnreadServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeUnreadServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UnreadServiceServer will
+// result in compilation errors.
+type UnsafeUnreadServiceServer interface {
+	mustEmbedUnimplementedUnreadServiceServer()
+}
+
+func RegisterUnreadServiceServer(s grpc.ServiceRegistrar, srv UnreadServiceServer) {
+	// If the following call pancis, it indicates UnimplementedUnreadServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UnreadService_ServiceDesc, srv)
+}
+
+func _UnreadService_GetUnreadCounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UnreadServiceServer).GetUnreadCounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UnreadService_GetUnreadCounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UnreadServiceServer).GetUnreadCounts(ctx, req.(*UnreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UnreadService_MarkRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UnreadServiceServer).MarkRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UnreadService_MarkRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UnreadServiceServer).MarkRead(ctx, req.(*MarkReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UnreadService_ServiceDesc is the grpc.ServiceDesc for UnreadService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UnreadService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.UnreadService",
+	HandlerType: (*UnreadServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUnreadCounts",
+			Handler:    _UnreadService_GetUnreadCounts_Handler,
+		},
+		{
+			MethodName: "MarkRead",
+			Handler:    _UnreadService_MarkRead_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	HistoryService_GetHistory_FullMethodName = "/chat.HistoryService/GetHistory"
+	HistoryService_Catchup_FullMethodName    = "/chat.HistoryService/Catchup"
+)
+
+// HistoryServiceClient is the client API for HistoryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 历史消息服务，供网关补齐丢失的消息
+type HistoryServiceClient interface {
+	GetHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	// 重连后一次性补齐多个房间：返回各房间错过的消息数、提及数、最新几条消息和合并后的成员变化
+	Catchup(ctx context.Context, in *CatchupRequest, opts ...grpc.CallOption) (*CatchupResponse, error)
+}
+
+type historyServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHistoryServiceClient(cc grpc.ClientConnInterface) HistoryServiceClient {
+	return &historyServiceClient{cc}
+}
+
+func (c *historyServiceClient) GetHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, HistoryService_GetHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *historyServiceClient) Catchup(ctx context.Context, in *CatchupRequest, opts ...grpc.CallOption) (*CatchupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CatchupResponse)
+	err := c.cc.Invoke(ctx, HistoryService_Catchup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HistoryServiceServer is the server API for HistoryService service.
+// All implementations must embed UnimplementedHistoryServiceServer
+// for forward compatibility.
+//
+// 历史消息服务，供网关补齐丢失的消息
+type HistoryServiceServer interface {
+	GetHistory(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	// 重连后一次性补齐多个房间：返回各房间错过的消息数、提及数、最新几条消息和合并后的成员变化
+	Catchup(context.Context, *CatchupRequest) (*CatchupResponse, error)
+	mustEmbedUnimplementedHistoryServiceServer()
+}
+
+// UnimplementedHistoryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedHistoryServiceServer struct{}
+
+func (UnimplementedHistoryServiceServer) GetHistory(context.Context, *HistoryRequest) (*HistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedHistoryServiceServer) Catchup(context.Context, *CatchupRequest) (*CatchupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Catchup not implemented")
+}
+func (UnimplementedHistoryServiceServer) mustEmbedUnimplementedHistoryServiceServer() {}
+func (UnimplementedHistoryServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeHistoryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HistoryServiceServer will
+// result in compilation errors.
+type UnsafeHistoryServiceServer interface {
+	mustEmbedUnimplementedHistoryServiceServer()
+}
+
+func RegisterHistoryServiceServer(s grpc.ServiceRegistrar, srv HistoryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedHistoryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&HistoryService_ServiceDesc, srv)
+}
+
+func _HistoryService_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HistoryServiceServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HistoryService_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HistoryServiceServer).GetHistory(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HistoryService_Catchup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CatchupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HistoryServiceServer).Catchup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HistoryService_Catchup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HistoryServiceServer).Catchup(ctx, req.(*CatchupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HistoryService_ServiceDesc is the grpc.ServiceDesc for HistoryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HistoryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.HistoryService",
+	HandlerType: (*HistoryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetHistory",
+			Handler:    _HistoryService_GetHistory_Handler,
+		},
+		{
+			MethodName: "Catchup",
+			Handler:    _HistoryService_Catchup_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	ThreadService_GetThread_FullMethodName      = "/chat.ThreadService/GetThread"
+	ThreadService_FollowThread_FullMethodName   = "/chat.ThreadService/FollowThread"
+	ThreadService_UnfollowThread_FullMethodName = "/chat.ThreadService/UnfollowThread"
+)
+
+// ThreadServiceClient is the client API for ThreadService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 话题服务，按用户名读写。回复话题（发送带 thread_id 的消息）会自动关注，根消息的作者在
+// 话题创建时自动关注；只有关注者收到回复，其他人只收到回复数的变化（ThreadUpdate）。
+// 话题保存在内存中，最多 1000 个，每个保留最近 200 条回复，服务器重启后丢失
+type ThreadServiceClient interface {
+	// 返回根消息、保留的回复、回复数和该用户是否关注；私有房间的话题只有房间成员可以访问
+	GetThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+	// 关注话题，之后收到它的回复
+	FollowThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+	// 取消关注，再次回复时重新关注
+	UnfollowThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+}
+
+type threadServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewThreadServiceClient(cc grpc.ClientConnInterface) ThreadServiceClient {
+	return &threadServiceClient{cc}
+}
+
+func (c *threadServiceClient) GetThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, ThreadService_GetThread_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *threadServiceClient) FollowThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, ThreadService_FollowThread_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *threadServiceClient) UnfollowThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, ThreadService_UnfollowThread_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ThreadServiceServer is the server API for ThreadService service.
+// All implementations must embed UnimplementedThreadServiceServer
+// for forward compatibility.
+//
+// 话题服务，按用户名读写。回复话题（发送带 thread_id 的消息）会自动关注，根消息的作者在
+// 话题创建时自动关注；只有关注者收到回复，其他人只收到回复数的变化（ThreadUpdate）。
+// 话题保存在内存中，最多 1000 个，每个保留最近 200 条回复，服务器重启后丢失
+type ThreadServiceServer interface {
+	// 返回根消息、保留的回复、回复数和该用户是否关注；私有房间的话题只有房间成员可以访问
+	GetThread(context.Context, *ThreadRequest) (*Thread, error)
+	// 关注话题，之后收到它的回复
+	FollowThread(context.Context, *ThreadRequest) (*Thread, error)
+	// 取消关注，再次回复时重新关注
+	UnfollowThread(context.Context, *ThreadRequest) (*Thread, error)
+	mustEmbedUnimplementedThreadServiceServer()
+}
+
+// UnimplementedThreadServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedThreadServiceServer struct{}
+
+func (UnimplementedThreadServiceServer) GetThread(context.Context, *ThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThread not implemented")
+}
+func (UnimplementedThreadServiceServer) FollowThread(context.Context, *ThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FollowThread not implemented")
+}
+func (UnimplementedThreadServiceServer) UnfollowThread(context.Context, *ThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnfollowThread not implemented")
+}
+func (UnimplementedThreadServiceServer) mustEmbedUnimplementedThreadServiceServer() {}
+func (UnimplementedThreadServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeThreadServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ThreadServiceServer will
+// result in compilation errors.
+type UnsafeThreadServiceServer interface {
+	mustEmbedUnimplementedThreadServiceServer()
+}
+
+func RegisterThreadServiceServer(s grpc.ServiceRegistrar, srv ThreadServiceServer) {
+	// If the following call pancis, it indicates UnimplementedThreadServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ThreadService_ServiceDesc, srv)
+}
+
+func _ThreadService_GetThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThreadServiceServer).GetThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ThreadService_GetThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThreadServiceServer).GetThread(ctx, req.(*ThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThreadService_FollowThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThreadServiceServer).FollowThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ThreadService_FollowThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThreadServiceServer).FollowThread(ctx, req.(*ThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThreadService_UnfollowThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThreadServiceServer).UnfollowThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ThreadService_UnfollowThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThreadServiceServer).UnfollowThread(ctx, req.(*ThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ThreadService_ServiceDesc is the grpc.ServiceDesc for ThreadService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ThreadService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.ThreadService",
+	HandlerType: (*ThreadServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetThread",
+			Handler:    _ThreadService_GetThread_Handler,
+		},
+		{
+			MethodName: "FollowThread",
+			Handler:    _ThreadService_FollowThread_Handler,
+		},
+		{
+			MethodName: "UnfollowThread",
+			Handler:    _ThreadService_UnfollowThread_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	DraftService_SaveDraft_FullMethodName = "/chat.DraftService/SaveDraft"
+	DraftService_GetDrafts_FullMethodName = "/chat.DraftService/GetDrafts"
+)
+
+// DraftServiceClient is the client API for DraftService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 草稿服务，按用户名读写。每个会话（"#房间" 或 "@用户"）保存一份草稿，在网页端写了一半的消息
+// 可以在命令行客户端接着写完。冲突时以 updated_at 较新的为准，时间相同时后到的为准
+type DraftServiceClient interface {
+	// 保存草稿，text 为空时删除；比已保存的更旧时不生效，返回已保存的草稿
+	SaveDraft(ctx context.Context, in *SaveDraftRequest, opts ...grpc.CallOption) (*Draft, error)
+	// 按更新时间从新到旧列出草稿
+	GetDrafts(ctx context.Context, in *DraftsRequest, opts ...grpc.CallOption) (*Drafts, error)
+}
+
+type draftServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDraftServiceClient(cc grpc.ClientConnInterface) DraftServiceClient {
+	return &draftServiceClient{cc}
+}
+
+func (c *draftServiceClient) SaveDraft(ctx context.Context, in *SaveDraftRequest, opts ...grpc.CallOption) (*Draft, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Draft)
+	err := c.cc.Invoke(ctx, DraftService_SaveDraft_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *draftServiceClient) GetDrafts(ctx context.Context, in *DraftsRequest, opts ...grpc.CallOption) (*Drafts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Drafts)
+	err := c.cc.Invoke(ctx, DraftService_GetDrafts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DraftServiceServer is the server API for DraftService service.
+// All implementations must embed UnimplementedDraftServiceServer
+// for forward compatibility.
+//
+// 草稿服务，按用户名读写。每个会话（"#房间" 或 "@用户"）保存一份草稿，在网页端写了一半的消息
+// 可以在命令行客户端接着写完。冲突时以 updated_at 较新的为准，时间相同时后到的为准
+type DraftServiceServer interface {
+	// 保存草稿，text 为空时删除；比已保存的更旧时不生效，返回已保存的草稿
+	SaveDraft(context.Context, *SaveDraftRequest) (*Draft, error)
+	// 按更新时间从新到旧列出草稿
+	GetDrafts(context.Context, *DraftsRequest) (*Drafts, error)
+	mustEmbedUnimplementedDraftServiceServer()
+}
+
+// UnimplementedDraftServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDraftServiceServer struct{}
+
+func (UnimplementedDraftServiceServer) SaveDraft(context.Context, *SaveDraftRequest) (*Draft, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveDraft not implemented")
+}
+func (UnimplementedDraftServiceServer) GetDrafts(context.Context, *DraftsRequest) (*Drafts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDrafts not implemented")
+}
+func (UnimplementedDraftServiceServer) mustEmbedUnimplementedDraftServiceServer() {}
+func (UnimplementedDraftServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeDraftServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DraftServiceServer will
+// result in compilation errors.
+type UnsafeDraftServiceServer interface {
+	mustEmbedUnimplementedDraftServiceServer()
+}
+
+func RegisterDraftServiceServer(s grpc.ServiceRegistrar, srv DraftServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDraftServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DraftService_ServiceDesc, srv)
+}
+
+func _DraftService_SaveDraft_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveDraftRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DraftServiceServer).SaveDraft(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DraftService_SaveDraft_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DraftServiceServer).SaveDraft(ctx, req.(*SaveDraftRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DraftService_GetDrafts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DraftsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DraftServiceServer).GetDrafts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DraftService_GetDrafts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DraftServiceServer).GetDrafts(ctx, req.(*DraftsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DraftService_ServiceDesc is the grpc.ServiceDesc for DraftService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DraftService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.DraftService",
+	HandlerType: (*DraftServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SaveDraft",
+			Handler:    _DraftService_SaveDraft_Handler,
+		},
+		{
+			MethodName: "GetDrafts",
+			Handler:    _DraftService_GetDrafts_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	RoomService_ListUsers_FullMethodName       = "/chat.RoomService/ListUsers"
+	RoomService_ListRooms_FullMethodName       = "/chat.RoomService/ListRooms"
+	RoomService_WatchRoom_FullMethodName       = "/chat.RoomService/WatchRoom"
+	RoomService_GetRoomMembers_FullMethodName  = "/chat.RoomService/GetRoomMembers"
+	RoomService_GetInvite_FullMethodName       = "/chat.RoomService/GetInvite"
+	RoomService_GetRoomSettings_FullMethodName = "/chat.RoomService/GetRoomSettings"
+	RoomService_GetVoiceMembers_FullMethodName = "/chat.RoomService/GetVoiceMembers"
+)
+
+// RoomServiceClient is the client API for RoomService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 房间服务，查询在线用户和房间，加入房间通过聊天流中的 /join 命令
+type RoomServiceClient interface {
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*UserList, error)
+	ListRooms(ctx context.Context, in *ListRoomsRequest, opts ...grpc.CallOption) (*RoomList, error)
+	// 只读订阅一个房间的消息和事件，订阅者不会出现在在线用户列表中
+	WatchRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatMessage], error)
+	// 房间成员：进入过该房间的用户及其角色、在线状态和最后在线时间，在线的排在前面
+	GetRoomMembers(ctx context.Context, in *RoomMembersRequest, opts ...grpc.CallOption) (*RoomMembers, error)
+	// 查询邀请链接指向的房间，持有令牌即可查询；无效、过期或已用完时返回 NOT_FOUND
+	GetInvite(ctx context.Context, in *InviteRequest, opts ...grpc.CallOption) (*Invite, error)
+	// 房间设置：语言提示、NSFW 标记、发言所需角色和附件策略；私有房间须带管理令牌
+	GetRoomSettings(ctx context.Context, in *RoomSettingsRequest, opts ...grpc.CallOption) (*RoomSettings, error)
+	// 房间语音频道的当前成员及其说话状态；私有房间须带管理令牌
+	GetVoiceMembers(ctx context.Context, in *VoiceMembersRequest, opts ...grpc.CallOption) (*VoiceMembers, error)
+}
+
+type roomServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRoomServiceClient(cc grpc.ClientConnInterface) RoomServiceClient {
+	return &roomServiceClient{cc}
+}
+
+func (c *roomServiceClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*UserList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserList)
+	err := c.cc.Invoke(ctx, RoomService_ListUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *roomServiceClient) ListRooms(ctx context.Context, in *ListRoomsRequest, opts ...grpc.CallOption) (*RoomList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomList)
+	err := c.cc.Invoke(ctx, RoomService_ListRooms_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *roomServiceClient) WatchRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RoomService_ServiceDesc.Streams[0], RoomService_WatchRoom_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RoomRequest, ChatMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RoomService_WatchRoomClient = grpc.ServerStreamingClient[ChatMessage]
+
+func (c *roomServiceClient) GetRoomMembers(ctx context.Context, in *RoomMembersRequest, opts ...grpc.CallOption) (*RoomMembers, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomMembers)
+	err := c.cc.Invoke(ctx, RoomService_GetRoomMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *roomServiceClient) GetInvite(ctx context.Context, in *InviteRequest, opts ...grpc.CallOption) (*Invite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Invite)
+	err := c.cc.Invoke(ctx, RoomService_GetInvite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *roomServiceClient) GetRoomSettings(ctx context.Context, in *RoomSettingsRequest, opts ...grpc.CallOption) (*RoomSettings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomSettings)
+	err := c.cc.Invoke(ctx, RoomService_GetRoomSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *roomServiceClient) GetVoiceMembers(ctx context.Context, in *VoiceMembersRequest, opts ...grpc.CallOption) (*VoiceMembers, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VoiceMembers)
+	err := c.cc.Invoke(ctx, RoomService_GetVoiceMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RoomServiceServer is the server API for RoomService service.
+// All implementations must embed UnimplementedRoomServiceServer
+// for forward compatibility.
+//
+// 房间服务，查询在线用户和房间，加入房间通过聊天流中的 /join 命令
+type RoomServiceServer interface {
+	ListUsers(context.Context, *ListUsersRequest) (*UserList, error)
+	ListRooms(context.Context, *ListRoomsRequest) (*RoomList, error)
+	// 只读订阅一个房间的消息和事件，订阅者不会出现在在线用户列表中
+	WatchRoom(*RoomRequest, grpc.ServerStreamingServer[ChatMessage]) error
+	// 房间成员：进入过该房间的用户及其角色、在线状态和最后在线时间，在线的排在前面
+	GetRoomMembers(context.Context, *RoomMembersRequest) (*RoomMembers, error)
+	// 查询邀请链接指向的房间，持有令牌即可查询；无效、过期或已用完时返回 NOT_FOUND
+	GetInvite(context.Context, *InviteRequest) (*Invite, error)
+	// 房间设置：语言提示、NSFW 标记、发言所需角色和附件策略；私有房间须带管理令牌
+	GetRoomSettings(context.Context, *RoomSettingsRequest) (*RoomSettings, error)
+	// 房间语音频道的当前成员及其说话状态；私有房间须带管理令牌
+	GetVoiceMembers(context.Context, *VoiceMembersRequest) (*VoiceMembers, error)
+	mustEmbedUnimplementedRoomServiceServer()
+}
+
+// UnimplementedRoomServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRoomServiceServer struct{}
+
+func (UnimplementedRoomServiceServer) ListUsers(context.Context, *ListUsersRequest) (*UserList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedRoomServiceServer) ListRooms(context.Context, *ListRoomsRequest) (*RoomList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRooms not implemented")
+}
+func (UnimplementedRoomServiceServer) WatchRoom(*RoomRequest, grpc.ServerStreamingServer[ChatMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRoom not implemented")
+}
+func (UnimplementedRoomServiceServer) GetRoomMembers(context.Context, *RoomMembersRequest) (*RoomMembers, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoomMembers not implemented")
+}
+func (UnimplementedRoomServiceServer) GetInvite(context.Context, *InviteRequest) (*Invite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInvite not implemented")
+}
+func (UnimplementedRoomServiceServer) GetRoomSettings(context.Context, *RoomSettingsRequest) (*RoomSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoomSettings not implemented")
+}
+func (UnimplementedRoomServiceServer) GetVoiceMembers(context.Context, *VoiceMembersRequest) (*VoiceMembers, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVoiceMembers not implemented")
+}
+func (UnimplementedRoomServiceServer) mustEmbedUnimplementedRoomServiceServer() {}
+func (UnimplementedRoomServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeRoomServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RoomServiceServer will
+// result in compilation errors.
+type UnsafeRoomServiceServer interface {
+	mustEmbedUnimplementedRoomServiceServer()
+}
+
+func RegisterRoomServiceServer(s grpc.ServiceRegistrar, srv RoomServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRoomServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RoomService_ServiceDesc, srv)
+}
+
+func _RoomService_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoomServiceServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoomService_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoomServiceServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoomService_ListRooms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRoomsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoomServiceServer).ListRooms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoomService_ListRooms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoomServiceServer).ListRooms(ctx, req.(*ListRoomsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoomService_WatchRoom_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RoomRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RoomServiceServer).WatchRoom(m, &grpc.GenericServerStream[RoomRequest, ChatMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RoomService_WatchRoomServer = grpc.ServerStreamingServer[ChatMessage]
+
+func _RoomService_GetRoomMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoomServiceServer).GetRoomMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoomService_GetRoomMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoomServiceServer).GetRoomMembers(ctx, req.(*RoomMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoomService_GetInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoomServiceServer).GetInvite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoomService_GetInvite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoomServiceServer).GetInvite(ctx, req.(*InviteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoomService_GetRoomSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoomServiceServer).GetRoomSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoomService_GetRoomSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoomServiceServer).GetRoomSettings(ctx, req.(*RoomSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoomService_GetVoiceMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoiceMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoomServiceServer).GetVoiceMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RoomService_GetVoiceMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoomServiceServer).GetVoiceMembers(ctx, req.(*VoiceMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RoomService_ServiceDesc is the grpc.ServiceDesc for RoomService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RoomService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.RoomService",
+	HandlerType: (*RoomServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListUsers",
+			Handler:    _RoomService_ListUsers_Handler,
+		},
+		{
+			MethodName: "ListRooms",
+			Handler:    _RoomService_ListRooms_Handler,
+		},
+		{
+			MethodName: "GetRoomMembers",
+			Handler:    _RoomService_GetRoomMembers_Handler,
+		},
+		{
+			MethodName: "GetInvite",
+			Handler:    _RoomService_GetInvite_Handler,
+		},
+		{
+			MethodName: "GetRoomSettings",
+			Handler:    _RoomService_GetRoomSettings_Handler,
+		},
+		{
+			MethodName: "GetVoiceMembers",
+			Handler:    _RoomService_GetVoiceMembers_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRoom",
+			Handler:       _RoomService_WatchRoom_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	FeatureService_GetFeatures_FullMethodName = "/chat.FeatureService/GetFeatures"
+)
+
+// FeatureServiceClient is the client API for FeatureService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 功能开关，客户端在连接时查询，按结果显示或隐藏对应的功能
+type FeatureServiceClient interface {
+	// 返回对该用户（按其租户）和房间生效的全部功能开关
+	GetFeatures(ctx context.Context, in *FeaturesRequest, opts ...grpc.CallOption) (*Features, error)
+}
+
+type featureServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFeatureServiceClient(cc grpc.ClientConnInterface) FeatureServiceClient {
+	return &featureServiceClient{cc}
+}
+
+func (c *featureServiceClient) GetFeatures(ctx context.Context, in *FeaturesRequest, opts ...grpc.CallOption) (*Features, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Features)
+	err := c.cc.Invoke(ctx, FeatureService_GetFeatures_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FeatureServiceServer is the server API for FeatureService service.
+// All implementations must embed UnimplementedFeatureServiceServer
+// for forward compatibility.
+//
+// 功能开关，客户端在连接时查询，按结果显示或隐藏对应的功能
+type FeatureServiceServer interface {
+	// 返回对该用户（按其租户）和房间生效的全部功能开关
+	GetFeatures(context.Context, *FeaturesRequest) (*Features, error)
+	mustEmbedUnimplementedFeatureServiceServer()
+}
+
+// UnimplementedFeatureServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFeatureServiceServer struct{}
+
+func (UnimplementedFeatureServiceServer) GetFeatures(context.Context, *FeaturesRequest) (*Features, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFeatures not implemented")
+}
+func (UnimplementedFeatureServiceServer) mustEmbedUnimplementedFeatureServiceServer() {}
+func (UnimplementedFeatureServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeFeatureServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FeatureServiceServer will
+// result in compilation errors.
+type UnsafeFeatureServiceServer interface {
+	mustEmbedUnimplementedFeatureServiceServer()
+}
+
+func RegisterFeatureServiceServer(s grpc.ServiceRegistrar, srv FeatureServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFeatureServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FeatureService_ServiceDesc, srv)
+}
+
+func _FeatureService_GetFeatures_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FeaturesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServiceServer).GetFeatures(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FeatureService_GetFeatures_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServiceServer).GetFeatures(ctx, req.(*FeaturesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FeatureService_ServiceDesc is the grpc.ServiceDesc for FeatureService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FeatureService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.FeatureService",
+	HandlerType: (*FeatureServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFeatures",
+			Handler:    _FeatureService_GetFeatures_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	AttachmentService_UploadAttachment_FullMethodName   = "/chat.AttachmentService/UploadAttachment"
+	AttachmentService_DownloadAttachment_FullMethodName = "/chat.AttachmentService/DownloadAttachment"
+	AttachmentService_GetUploadOffset_FullMethodName    = "/chat.AttachmentService/GetUploadOffset"
+	AttachmentService_GetDownloadUrl_FullMethodName     = "/chat.AttachmentService/GetDownloadUrl"
+)
+
+// AttachmentServiceClient is the client API for AttachmentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 附件服务，供不使用 HTTP 的客户端（命令行、机器人）分块传输文件
+type AttachmentServiceClient interface {
+	// 上传文件，第一块需填写 upload_id、name、size 和 sha256，收齐后校验并返回附件
+	UploadAttachment(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Attachment], error)
+	// 下载文件，从 offset 开始，第一块带有 name、size 和 sha256
+	DownloadAttachment(ctx context.Context, in *AttachmentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error)
+	// 查询未完成的上传已收到多少字节，中断后从该位置续传
+	GetUploadOffset(ctx context.Context, in *UploadOffsetRequest, opts ...grpc.CallOption) (*UploadOffset, error)
+	// 附件存放在 S3 等对象存储时返回有时效的预签名下载地址，客户端直接从存储下载；
+	// 本地存储返回 UNIMPLEMENTED，此时改用 DownloadAttachment
+	GetDownloadUrl(ctx context.Context, in *AttachmentRequest, opts ...grpc.CallOption) (*DownloadUrl, error)
+}
+
+type attachmentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAttachmentServiceClient(cc grpc.ClientConnInterface) AttachmentServiceClient {
+	return &attachmentServiceClient{cc}
+}
+
+func (c *attachmentServiceClient) UploadAttachment(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Attachment], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AttachmentService_ServiceDesc.Streams[0], AttachmentService_UploadAttachment_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Chunk, Attachment]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AttachmentService_UploadAttachmentClient = grpc.ClientStreamingClient[Chunk, Attachment]
+
+func (c *attachmentServiceClient) DownloadAttachment(ctx context.Context, in *AttachmentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AttachmentService_ServiceDesc.Streams[1], AttachmentService_DownloadAttachment_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AttachmentRequest, Chunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AttachmentService_DownloadAttachmentClient = grpc.ServerStreamingClient[Chunk]
+
+func (c *attachmentServiceClient) GetUploadOffset(ctx context.Context, in *UploadOffsetRequest, opts ...grpc.CallOption) (*UploadOffset, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadOffset)
+	err := c.cc.Invoke(ctx, AttachmentService_GetUploadOffset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *attachmentServiceClient) GetDownloadUrl(ctx context.Context, in *AttachmentRequest, opts ...grpc.CallOption) (*DownloadUrl, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DownloadUrl)
+	err := c.cc.Invoke(ctx, AttachmentService_GetDownloadUrl_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AttachmentServiceServer is the server API for AttachmentService service.
+// All implementations must embed UnimplementedAttachmentServiceServer
+// for forward compatibility.
+//
+// 附件服务，供不使用 HTTP 的客户端（命令行、机器人）分块传输文件
+type AttachmentServiceServer interface {
+	// 上传文件，第一块需填写 upload_id、name、size 和 sha256，收齐后校验并返回附件
+	UploadAttachment(grpc.ClientStreamingServer[Chunk, Attachment]) error
+	// 下载文件，从 offset 开始，第一块带有 name、size 和 sha256
+	DownloadAttachment(*AttachmentRequest, grpc.ServerStreamingServer[Chunk]) error
+	// 查询未完成的上传已收到多少字节，中断后从该位置续传
+	GetUploadOffset(context.Context, *UploadOffsetRequest) (*UploadOffset, error)
+	// 附件存放在 S3 等对象存储时返回有时效的预签名下载地址，客户端直接从存储下载；
+	// 本地存储返回 UNIMPLEMENTED，此时改用 DownloadAttachment
+	GetDownloadUrl(context.Context, *AttachmentRequest) (*DownloadUrl, error)
+	mustEmbedUnimplementedAttachmentServiceServer()
+}
+
+// UnimplementedAttachmentServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAttachmentServiceServer struct{}
+
+func (UnimplementedAttachmentServiceServer) UploadAttachment(grpc.ClientStreamingServer[Chunk, Attachment]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadAttachment not implemented")
+}
+func (UnimplementedAttachmentServiceServer) DownloadAttachment(*AttachmentRequest, grpc.ServerStreamingServer[Chunk]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadAttachment not implemented")
+}
+func (UnimplementedAttachmentServiceServer) GetUploadOffset(context.Context, *UploadOffsetRequest) (*UploadOffset, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUploadOffset not implemented")
+}
+func (UnimplementedAttachmentServiceServer) GetDownloadUrl(context.Context, *AttachmentRequest) (*DownloadUrl, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDownloadUrl not implemented")
+}
+func (UnimplementedAttachmentServiceServer) mustEmbedUnimplementedAttachmentServiceServer() {}
+func (UnimplementedAttachmentServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeAttachmentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AttachmentServiceServer will
+// result in compilation errors.
+type UnsafeAttachmentServiceServer interface {
+	mustEmbedUnimplementedAttachmentServiceServer()
+}
+
+func RegisterAttachmentServiceServer(s grpc.ServiceRegistrar, srv AttachmentServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAttachmentServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AttachmentService_ServiceDesc, srv)
+}
+
+func _AttachmentService_UploadAttachment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AttachmentServiceServer).UploadAttachment(&grpc.GenericServerStream[Chunk, Attachment]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AttachmentService_UploadAttachmentServer = grpc.ClientStreamingServer[Chunk, Attachment]
+
+func _AttachmentService_DownloadAttachment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AttachmentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AttachmentServiceServer).DownloadAttachment(m, &grpc.GenericServerStream[AttachmentRequest, Chunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AttachmentService_DownloadAttachmentServer = grpc.ServerStreamingServer[Chunk]
+
+func _AttachmentService_GetUploadOffset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadOffsetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttachmentServiceServer).GetUploadOffset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AttachmentService_GetUploadOffset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttachmentServiceServer).GetUploadOffset(ctx, req.(*UploadOffsetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AttachmentService_GetDownloadUrl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AttachmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttachmentServiceServer).GetDownloadUrl(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AttachmentService_GetDownloadUrl_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttachmentServiceServer).GetDownloadUrl(ctx, req.(*AttachmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AttachmentService_ServiceDesc is the grpc.ServiceDesc for AttachmentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AttachmentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.AttachmentService",
+	HandlerType: (*AttachmentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUploadOffset",
+			Handler:    _AttachmentService_GetUploadOffset_Handler,
+		},
+		{
+			MethodName: "GetDownloadUrl",
+			Handler:    _AttachmentService_GetDownloadUrl_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadAttachment",
+			Handler:       _AttachmentService_UploadAttachment_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadAttachment",
+			Handler:       _AttachmentService_DownloadAttachment_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	AdminService_ExportRoom_FullMethodName          = "/chat.AdminService/ExportRoom"
+	AdminService_ImportMessages_FullMethodName      = "/chat.AdminService/ImportMessages"
+	AdminService_GetStats_FullMethodName            = "/chat.AdminService/GetStats"
+	AdminService_WatchStats_FullMethodName          = "/chat.AdminService/WatchStats"
+	AdminService_GetQuota_FullMethodName            = "/chat.AdminService/GetQuota"
+	AdminService_SetQuota_FullMethodName            = "/chat.AdminService/SetQuota"
+	AdminService_RegisterCommand_FullMethodName     = "/chat.AdminService/RegisterCommand"
+	AdminService_UnregisterCommand_FullMethodName   = "/chat.AdminService/UnregisterCommand"
+	AdminService_ListCommands_FullMethodName        = "/chat.AdminService/ListCommands"
+	AdminService_ListSessions_FullMethodName        = "/chat.AdminService/ListSessions"
+	AdminService_RevokeSession_FullMethodName       = "/chat.AdminService/RevokeSession"
+	AdminService_GetWelcome_FullMethodName          = "/chat.AdminService/GetWelcome"
+	AdminService_SetWelcome_FullMethodName          = "/chat.AdminService/SetWelcome"
+	AdminService_SetRoomRole_FullMethodName         = "/chat.AdminService/SetRoomRole"
+	AdminService_SetRoomPrivate_FullMethodName      = "/chat.AdminService/SetRoomPrivate"
+	AdminService_SetRoomSettings_FullMethodName     = "/chat.AdminService/SetRoomSettings"
+	AdminService_CreateInvite_FullMethodName        = "/chat.AdminService/CreateInvite"
+	AdminService_RevokeInvite_FullMethodName        = "/chat.AdminService/RevokeInvite"
+	AdminService_ListInvites_FullMethodName         = "/chat.AdminService/ListInvites"
+	AdminService_CreateBan_FullMethodName           = "/chat.AdminService/CreateBan"
+	AdminService_RemoveBan_FullMethodName           = "/chat.AdminService/RemoveBan"
+	AdminService_ListBans_FullMethodName            = "/chat.AdminService/ListBans"
+	AdminService_SetBanAppeal_FullMethodName        = "/chat.AdminService/SetBanAppeal"
+	AdminService_AddBlockRule_FullMethodName        = "/chat.AdminService/AddBlockRule"
+	AdminService_RemoveBlockRule_FullMethodName     = "/chat.AdminService/RemoveBlockRule"
+	AdminService_ListBlockRules_FullMethodName      = "/chat.AdminService/ListBlockRules"
+	AdminService_ReportQuarantine_FullMethodName    = "/chat.AdminService/ReportQuarantine"
+	AdminService_Snapshot_FullMethodName            = "/chat.AdminService/Snapshot"
+	AdminService_Restore_FullMethodName             = "/chat.AdminService/Restore"
+	AdminService_Announce_FullMethodName            = "/chat.AdminService/Announce"
+	AdminService_ListRoomStats_FullMethodName       = "/chat.AdminService/ListRoomStats"
+	AdminService_TailAuditLog_FullMethodName        = "/chat.AdminService/TailAuditLog"
+	AdminService_ListModerationQueue_FullMethodName = "/chat.AdminService/ListModerationQueue"
+	AdminService_ResolveModeration_FullMethodName   = "/chat.AdminService/ResolveModeration"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 管理接口，调用方须在 gRPC 元数据 authorization 中带上 "Bearer <管理令牌>"，
+// 服务器未配置管理令牌时全部拒绝
+type AdminServiceClient interface {
+	// 按时间顺序流式导出房间的公共消息，配置了可读回的存储时从存储读取，否则为内存中的历史
+	ExportRoom(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatMessage], error)
+	// 导入其他平台的历史消息（见 cmd/import），写入服务器的存储但不进入实时历史，
+	// 每条消息需填写 room、user、text 和 timestamp，id 由服务器分配，无效的消息计入 skipped
+	ImportMessages(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ChatMessage, ImportSummary], error)
+	// 使用统计，按小时或天汇总消息数、发言用户数和同时在线连接数的峰值，
+	// 由服务器在运行时统计，保留最近 90 天
+	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error)
+	// 实时统计：立即返回一个当前状态的快照，之后每 interval_seconds 秒一个，
+	// 供实时仪表盘使用，与 Prometheus 的抓取间隔无关
+	WatchStats(ctx context.Context, in *WatchStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StatsSnapshot], error)
+	// 查询租户或房间的配额和当前用量
+	GetQuota(ctx context.Context, in *QuotaRequest, opts ...grpc.CallOption) (*QuotaUsage, error)
+	// 为租户或房间设置配额，替换服务器的默认配额；clear 为 true 时恢复默认
+	SetQuota(ctx context.Context, in *SetQuotaRequest, opts ...grpc.CallOption) (*QuotaUsage, error)
+	// 注册外部工具的斜杠命令，同名命令会被替换；用户发送 /<name> 参数 时服务器把调用
+	// POST 到回调地址，并把响应发回房间，格式见 README 的 ChatOps 一节
+	RegisterCommand(ctx context.Context, in *SlashCommand, opts ...grpc.CallOption) (*SlashCommand, error)
+	// 注销斜杠命令，返回被注销的命令
+	UnregisterCommand(ctx context.Context, in *UnregisterCommandRequest, opts ...grpc.CallOption) (*SlashCommand, error)
+	// 列出已注册的斜杠命令，不返回 secret
+	ListCommands(ctx context.Context, in *ListCommandsRequest, opts ...grpc.CallOption) (*CommandList, error)
+	// 列出聊天连接（会话），可按用户筛选
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*SessionList, error)
+	// 强制退出会话：按 id 退出一个，或按 user 退出该用户的全部会话；
+	// 被退出的流以 UNAUTHENTICATED 结束，SDK 不会自动重连
+	RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*SessionList, error)
+	// 查询欢迎消息，room 为空时为全服的每日消息（MOTD）
+	GetWelcome(ctx context.Context, in *WelcomeRequest, opts ...grpc.CallOption) (*Welcome, error)
+	// 替换欢迎消息，messages 为空时删除；已在线的连接不会收到
+	SetWelcome(ctx context.Context, in *Welcome, opts ...grpc.CallOption) (*Welcome, error)
+	// 设置用户在房间中的角色，用户须进入过该房间
+	SetRoomRole(ctx context.Context, in *SetRoomRoleRequest, opts ...grpc.CallOption) (*RoomMember, error)
+	// 设为私有房间后，只有进入过的成员和持有邀请的用户可以进入，
+	// 房间不出现在 ListRooms 中，成员列表（带管理令牌的调用除外）和只读订阅也会被拒绝
+	SetRoomPrivate(ctx context.Context, in *SetRoomPrivateRequest, opts ...grpc.CallOption) (*RoomInfo, error)
+	// 替换房间设置，全部为默认值时删除；房间的 moderator 和 owner 也可以在聊天中用 /settings 修改
+	SetRoomSettings(ctx context.Context, in *RoomSettings, opts ...grpc.CallOption) (*RoomSettings, error)
+	// 创建邀请，用户发送 /join <房间> <令牌> 进入房间，之后作为成员不再需要邀请
+	CreateInvite(ctx context.Context, in *CreateInviteRequest, opts ...grpc.CallOption) (*Invite, error)
+	// 撤销邀请，返回被撤销的邀请
+	RevokeInvite(ctx context.Context, in *InviteRequest, opts ...grpc.CallOption) (*Invite, error)
+	// 列出仍然有效的邀请，可按房间筛选
+	ListInvites(ctx context.Context, in *ListInvitesRequest, opts ...grpc.CallOption) (*InviteList, error)
+	// 封禁账号或 IP（单个地址或 CIDR），须填写 reason，duration_seconds 为 0 时永久封禁；
+	// 被封禁的用户无法加入聊天，已在线的会话立即退出，到期后自动解封
+	CreateBan(ctx context.Context, in *CreateBanRequest, opts ...grpc.CallOption) (*Ban, error)
+	// 解除封禁，返回被解除的封禁
+	RemoveBan(ctx context.Context, in *BanRequest, opts ...grpc.CallOption) (*Ban, error)
+	// 列出仍然有效的封禁，可按对象筛选
+	ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*BanList, error)
+	// 记录用户的申诉或处理意见，替换原有内容
+	SetBanAppeal(ctx context.Context, in *SetBanAppealRequest, opts ...grpc.CallOption) (*Ban, error)
+	// 添加屏蔽词规则（整词或正则），room 非空时只作用于该房间，并覆盖同一 pattern 的全局规则
+	AddBlockRule(ctx context.Context, in *BlockRule, opts ...grpc.CallOption) (*BlockRule, error)
+	// 删除屏蔽词规则，返回被删除的规则
+	RemoveBlockRule(ctx context.Context, in *BlockRuleRequest, opts ...grpc.CallOption) (*BlockRule, error)
+	// 列出屏蔽词规则，可按房间筛选
+	ListBlockRules(ctx context.Context, in *ListBlockRulesRequest, opts ...grpc.CallOption) (*BlockRuleList, error)
+	// 报告病毒扫描隔离的附件，服务器记录日志并通知在线的管理员；网关隔离上传的文件时调用
+	ReportQuarantine(ctx context.Context, in *QuarantineReport, opts ...grpc.CallOption) (*QuarantineReport, error)
+	// 导出灾备快照：用户（资料、偏好、联系人）、房间（成员、私有设置、邀请、欢迎消息、配额、房间设置）、
+	// 公共消息、附件元数据、封禁和屏蔽词规则，按此顺序逐条返回；cmd/chatadmin 把它写成归档
+	Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SnapshotRecord], error)
+	// 从快照恢复，与服务器已有的状态合并：同名的用户、房间设置、封禁和规则被覆盖，
+	// 存储中已有相同 id 的消息被跳过，因此同一份快照可以重复恢复
+	Restore(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SnapshotRecord, RestoreSummary], error)
+	// 以系统消息向一个房间或所有连接发送公告
+	Announce(ctx context.Context, in *AnnounceRequest, opts ...grpc.CallOption) (*AnnounceResult, error)
+	// 各房间的在线人数、成员数、最新序号和最近 24 小时的消息数
+	ListRoomStats(ctx context.Context, in *RoomStatsRequest, opts ...grpc.CallOption) (*RoomStatsList, error)
+	// 管理操作的审计记录：先返回最近的 limit 条，follow 时继续推送新的记录
+	TailAuditLog(ctx context.Context, in *AuditLogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditEntry], error)
+	// 待处理的审核队列：被屏蔽词标记的消息和被隔离的附件，最早的在前，
+	// 只保存在内存中，最多 500 条
+	ListModerationQueue(ctx context.Context, in *ModerationQueueRequest, opts ...grpc.CallOption) (*ModerationQueue, error)
+	// 处理完审核项后将其移出队列，返回被移出的项
+	ResolveModeration(ctx context.Context, in *ResolveModerationRequest, opts ...grpc.CallOption) (*ModerationItem, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) ExportRoom(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[0], AdminService_ExportRoom_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportRequest, ChatMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportRoomClient = grpc.ServerStreamingClient[ChatMessage]
+
+func (c *adminServiceClient) ImportMessages(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ChatMessage, ImportSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[1], AdminService_ImportMessages_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatMessage, ImportSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ImportMessagesClient = grpc.ClientStreamingClient[ChatMessage, ImportSummary]
+
+func (c *adminServiceClient) GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Stats)
+	err := c.cc.Invoke(ctx, AdminService_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) WatchStats(ctx context.Context, in *WatchStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StatsSnapshot], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[2], AdminService_WatchStats_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchStatsRequest, StatsSnapshot]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_WatchStatsClient = grpc.ServerStreamingClient[StatsSnapshot]
+
+func (c *adminServiceClient) GetQuota(ctx context.Context, in *QuotaRequest, opts ...grpc.CallOption) (*QuotaUsage, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuotaUsage)
+	err := c.cc.Invoke(ctx, AdminService_GetQuota_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetQuota(ctx context.Context, in *SetQuotaRequest, opts ...grpc.CallOption) (*QuotaUsage, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuotaUsage)
+	err := c.cc.Invoke(ctx, AdminService_SetQuota_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RegisterCommand(ctx context.Context, in *SlashCommand, opts ...grpc.CallOption) (*SlashCommand, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SlashCommand)
+	err := c.cc.Invoke(ctx, AdminService_RegisterCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) UnregisterCommand(ctx context.Context, in *UnregisterCommandRequest, opts ...grpc.CallOption) (*SlashCommand, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SlashCommand)
+	err := c.cc.Invoke(ctx, AdminService_UnregisterCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListCommands(ctx context.Context, in *ListCommandsRequest, opts ...grpc.CallOption) (*CommandList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandList)
+	err := c.cc.Invoke(ctx, AdminService_ListCommands_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*SessionList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, AdminService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*SessionList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, AdminService_RevokeSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetWelcome(ctx context.Context, in *WelcomeRequest, opts ...grpc.CallOption) (*Welcome, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Welcome)
+	err := c.cc.Invoke(ctx, AdminService_GetWelcome_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetWelcome(ctx context.Context, in *Welcome, opts ...grpc.CallOption) (*Welcome, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Welcome)
+	err := c.cc.Invoke(ctx, AdminService_SetWelcome_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetRoomRole(ctx context.Context, in *SetRoomRoleRequest, opts ...grpc.CallOption) (*RoomMember, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomMember)
+	err := c.cc.Invoke(ctx, AdminService_SetRoomRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetRoomPrivate(ctx context.Context, in *SetRoomPrivateRequest, opts ...grpc.CallOption) (*RoomInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomInfo)
+	err := c.cc.Invoke(ctx, AdminService_SetRoomPrivate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetRoomSettings(ctx context.Context, in *RoomSettings, opts ...grpc.CallOption) (*RoomSettings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomSettings)
+	err := c.cc.Invoke(ctx, AdminService_SetRoomSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) CreateInvite(ctx context.Context, in *CreateInviteRequest, opts ...grpc.CallOption) (*Invite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Invite)
+	err := c.cc.Invoke(ctx, AdminService_CreateInvite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RevokeInvite(ctx context.Context, in *InviteRequest, opts ...grpc.CallOption) (*Invite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Invite)
+	err := c.cc.Invoke(ctx, AdminService_RevokeInvite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListInvites(ctx context.Context, in *ListInvitesRequest, opts ...grpc.CallOption) (*InviteList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteList)
+	err := c.cc.Invoke(ctx, AdminService_ListInvites_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) CreateBan(ctx context.Context, in *CreateBanRequest, opts ...grpc.CallOption) (*Ban, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Ban)
+	err := c.cc.Invoke(ctx, AdminService_CreateBan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RemoveBan(ctx context.Context, in *BanRequest, opts ...grpc.CallOption) (*Ban, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Ban)
+	err := c.cc.Invoke(ctx, AdminService_RemoveBan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*BanList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BanList)
+	err := c.cc.Invoke(ctx, AdminService_ListBans_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetBanAppeal(ctx context.Context, in *SetBanAppealRequest, opts ...grpc.CallOption) (*Ban, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Ban)
+	err := c.cc.Invoke(ctx, AdminService_SetBanAppeal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) AddBlockRule(ctx context.Context, in *BlockRule, opts ...grpc.CallOption) (*BlockRule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockRule)
+	err := c.cc.Invoke(ctx, AdminService_AddBlockRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RemoveBlockRule(ctx context.Context, in *BlockRuleRequest, opts ...grpc.CallOption) (*BlockRule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockRule)
+	err := c.cc.Invoke(ctx, AdminService_RemoveBlockRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListBlockRules(ctx context.Context, in *ListBlockRulesRequest, opts ...grpc.CallOption) (*BlockRuleList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockRuleList)
+	err := c.cc.Invoke(ctx, AdminService_ListBlockRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ReportQuarantine(ctx context.Context, in *QuarantineReport, opts ...grpc.CallOption) (*QuarantineReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuarantineReport)
+	err := c.cc.Invoke(ctx, AdminService_ReportQuarantine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SnapshotRecord], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[3], AdminService_Snapshot_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SnapshotRequest, SnapshotRecord]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_SnapshotClient = grpc.ServerStreamingClient[SnapshotRecord]
+
+func (c *adminServiceClient) Restore(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SnapshotRecord, RestoreSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[4], AdminService_Restore_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SnapshotRecord, RestoreSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_RestoreClient = grpc.ClientStreamingClient[SnapshotRecord, RestoreSummary]
+
+func (c *adminServiceClient) Announce(ctx context.Context, in *AnnounceRequest, opts ...grpc.CallOption) (*AnnounceResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnnounceResult)
+	err := c.cc.Invoke(ctx, AdminService_Announce_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListRoomStats(ctx context.Context, in *RoomStatsRequest, opts ...grpc.CallOption) (*RoomStatsList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomStatsList)
+	err := c.cc.Invoke(ctx, AdminService_ListRoomStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) TailAuditLog(ctx context.Context, in *AuditLogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[5], AdminService_TailAuditLog_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AuditLogRequest, AuditEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_TailAuditLogClient = grpc.ServerStreamingClient[AuditEntry]
+
+func (c *adminServiceClient) ListModerationQueue(ctx context.Context, in *ModerationQueueRequest, opts ...grpc.CallOption) (*ModerationQueue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ModerationQueue)
+	err := c.cc.Invoke(ctx, AdminService_ListModerationQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ResolveModeration(ctx context.Context, in *ResolveModerationRequest, opts ...grpc.CallOption) (*ModerationItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ModerationItem)
+	err := c.cc.Invoke(ctx, AdminService_ResolveModeration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// 管理接口，调用方须在 gRPC 元数据 authorization 中带上 "Bearer <管理令牌>"，
+// 服务器未配置管理令牌时全部拒绝
+type AdminServiceServer interface {
+	// 按时间顺序流式导出房间的公共消息，配置了可读回的存储时从存储读取，否则为内存中的历史
+	ExportRoom(*ExportRequest, grpc.ServerStreamingServer[ChatMessage]) error
+	// 导入其他平台的历史消息（见 cmd/import），写入服务器的存储但不进入实时历史，
+	// 每条消息需填写 room、user、text 和 timestamp，id 由服务器分配，无效的消息计入 skipped
+	ImportMessages(grpc.ClientStreamingServer[ChatMessage, ImportSummary]) error
+	// 使用统计，按小时或天汇总消息数、发言用户数和同时在线连接数的峰值，
+	// 由服务器在运行时统计，保留最近 90 天
+	GetStats(context.Context, *StatsRequest) (*Stats, error)
+	// 实时统计：立即返回一个当前状态的快照，之后每 interval_seconds 秒一个，
+	// 供实时仪表盘使用，与 Prometheus 的抓取间隔无关
+	WatchStats(*WatchStatsRequest, grpc.ServerStreamingServer[StatsSnapshot]) error
+	// 查询租户或房间的配额和当前用量
+	GetQuota(context.Context, *QuotaRequest) (*QuotaUsage, error)
+	// 为租户或房间设置配额，替换服务器的默认配额；clear 为 true 时恢复默认
+	SetQuota(context.Context, *SetQuotaRequest) (*QuotaUsage, error)
+	// 注册外部工具的斜杠命令，同名命令会被替换；用户发送 /<name> 参数 时服务器把调用
+	// POST 到回调地址，并把响应发回房间，格式见 README 的 ChatOps 一节
+	RegisterCommand(context.Context, *SlashCommand) (*SlashCommand, error)
+	// 注销斜杠命令，返回被注销的命令
+	UnregisterCommand(context.Context, *UnregisterCommandRequest) (*SlashCommand, error)
+	// 列出已注册的斜杠命令，不返回 secret
+	ListCommands(context.Context, *ListCommandsRequest) (*CommandList, error)
+	// 列出聊天连接（会话），可按用户筛选
+	ListSessions(context.Context, *ListSessionsRequest) (*SessionList, error)
+	// 强制退出会话：按 id 退出一个，或按 user 退出该用户的全部会话；
+	// 被退出的流以 UNAUTHENTICATED 结束，SDK 不会自动重连
+	RevokeSession(context.Context, *RevokeSessionRequest) (*SessionList, error)
+	// 查询欢迎消息，room 为空时为全服的每日消息（MOTD）
+	GetWelcome(context.Context, *WelcomeRequest) (*Welcome, error)
+	// 替换欢迎消息，messages 为空时删除；已在线的连接不会收到
+	SetWelcome(context.Context, *Welcome) (*Welcome, error)
+	// 设置用户在房间中的角色，用户须进入过该房间
+	SetRoomRole(context.Context, *SetRoomRoleRequest) (*RoomMember, error)
+	// 设为私有房间后，只有进入过的成员和持有邀请的用户可以进入，
+	// 房间不出现在 ListRooms 中，成员列表（带管理令牌的调用除外）和只读订阅也会被拒绝
+	SetRoomPrivate(context.Context, *SetRoomPrivateRequest) (*RoomInfo, error)
+	// 替换房间设置，全部为默认值时删除；房间的 moderator 和 owner 也可以在聊天中用 /settings 修改
+	SetRoomSettings(context.Context, *RoomSettings) (*RoomSettings, error)
+	// 创建邀请，用户发送 /join <房间> <令牌> 进入房间，之后作为成员不再需要邀请
+	CreateInvite(context.Context, *CreateInviteRequest) (*Invite, error)
+	// 撤销邀请，返回被撤销的邀请
+	RevokeInvite(context.Context, *InviteRequest) (*Invite, error)
+	// 列出仍然有效的邀请，可按房间筛选
+	ListInvites(context.Context, *ListInvitesRequest) (*InviteList, error)
+	// 封禁账号或 IP（单个地址或 CIDR），须填写 reason，duration_seconds 为 0 时永久封禁；
+	// 被封禁的用户无法加入聊天，已在线的会话立即退出，到期后自动解封
+	CreateBan(context.Context, *CreateBanRequest) (*Ban, error)
+	// 解除封禁，返回被解除的封禁
+	RemoveBan(context.Context, *BanRequest) (*Ban, error)
+	// 列出仍然有效的封禁，可按对象筛选
+	ListBans(context.Context, *ListBansRequest) (*BanList, error)
+	// 记录用户的申诉或处理意见，替换原有内容
+	SetBanAppeal(context.Context, *SetBanAppealRequest) (*Ban, error)
+	// 添加屏蔽词规则（整词或正则），room 非空时只作用于该房间，并覆盖同一 pattern 的全局规则
+	AddBlockRule(context.Context, *BlockRule) (*BlockRule, error)
+	// 删除屏蔽词规则，返回被删除的规则
+	RemoveBlockRule(context.Context, *BlockRuleRequest) (*BlockRule, error)
+	// 列出屏蔽词规则，可按房间筛选
+	ListBlockRules(context.Context, *ListBlockRulesRequest) (*BlockRuleList, error)
+	// 报告病毒扫描隔离的附件，服务器记录日志并通知在线的管理员；网关隔离上传的文件时调用
+	ReportQuarantine(context.Context, *QuarantineReport) (*QuarantineReport, error)
+	// 导出灾备快照：用户（资料、偏好、联系人）、房间（成员、私有设置、邀请、欢迎消息、配额、房间设置）、
+	// 公共消息、附件元数据、封禁和屏蔽词规则，按此顺序逐条返回；cmd/chatadmin 把它写成归档
+	Snapshot(*SnapshotRequest, grpc.ServerStreamingServer[SnapshotRecord]) error
+	// 从快照恢复，与服务器已有的状态合并：同名的用户、房间设置、封禁和规则被覆盖，
+	// 存储中已有相同 id 的消息被跳过，因此同一份快照可以重复恢复
+	Restore(grpc.ClientStreamingServer[SnapshotRecord, RestoreSummary]) error
+	// 以系统消息向一个房间或所有连接发送公告
+	Announce(context.Context, *AnnounceRequest) (*AnnounceResult, error)
+	// 各房间的在线人数、成员数、最新序号和最近 24 小时的消息数
+	ListRoomStats(context.Context, *RoomStatsRequest) (*RoomStatsList, error)
+	// 管理操作的审计记录：先返回最近的 limit 条，follow 时继续推送新的记录
+	TailAuditLog(*AuditLogRequest, grpc.ServerStreamingServer[AuditEntry]) error
+	// 待处理的审核队列：被屏蔽词标记的消息和被隔离的附件，最早的在前，
+	// 只保存在内存中，最多 500 条
+	ListModerationQueue(context.Context, *ModerationQueueRequest) (*ModerationQueue, error)
+	// 处理完审核项后将其移出队列，返回被移出的项
+	ResolveModeration(context.Context, *ResolveModerationRequest) (*ModerationItem, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) ExportRoom(*ExportRequest, grpc.ServerStreamingServer[ChatMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportRoom not implemented")
+}
+func (UnimplementedAdminServiceServer) ImportMessages(grpc.ClientStreamingServer[ChatMessage, ImportSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportMessages not implemented")
+}
+func (UnimplementedAdminServiceServer) GetStats(context.Context, *StatsRequest) (*Stats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedAdminServiceServer) WatchStats(*WatchStatsRequest, grpc.ServerStreamingServer[StatsSnapshot]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStats not implemented")
+}
+func (UnimplementedAdminServiceServer) GetQuota(context.Context, *QuotaRequest) (*QuotaUsage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuota not implemented")
+}
+func (UnimplementedAdminServiceServer) SetQuota(context.Context, *SetQuotaRequest) (*QuotaUsage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQuota not implemented")
+}
+func (UnimplementedAdminServiceServer) RegisterCommand(context.Context, *SlashCommand) (*SlashCommand, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterCommand not implemented")
+}
+func (UnimplementedAdminServiceServer) UnregisterCommand(context.Context, *UnregisterCommandRequest) (*SlashCommand, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnregisterCommand not implemented")
+}
+func (UnimplementedAdminServiceServer) ListCommands(context.Context, *ListCommandsRequest) (*CommandList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCommands not implemented")
+}
+func (UnimplementedAdminServiceServer) ListSessions(context.Context, *ListSessionsRequest) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedAdminServiceServer) RevokeSession(context.Context, *RevokeSessionRequest) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedAdminServiceServer) GetWelcome(context.Context, *WelcomeRequest) (*Welcome, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWelcome not implemented")
+}
+func (UnimplementedAdminServiceServer) SetWelcome(context.Context, *Welcome) (*Welcome, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWelcome not implemented")
+}
+func (UnimplementedAdminServiceServer) SetRoomRole(context.Context, *SetRoomRoleRequest) (*RoomMember, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRoomRole not implemented")
+}
+func (UnimplementedAdminServiceServer) SetRoomPrivate(context.Context, *SetRoomPrivateRequest) (*RoomInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRoomPrivate not implemented")
+}
+func (UnimplementedAdminServiceServer) SetRoomSettings(context.Context, *RoomSettings) (*RoomSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRoomSettings not implemented")
+}
+func (UnimplementedAdminServiceServer) CreateInvite(context.Context, *CreateInviteRequest) (*Invite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateInvite not implemented")
+}
+func (UnimplementedAdminServiceServer) RevokeInvite(context.Context, *InviteRequest) (*Invite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeInvite not implemented")
+}
+func (UnimplementedAdminServiceServer) ListInvites(context.Context, *ListInvitesRequest) (*InviteList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInvites not implemented")
+}
+func (UnimplementedAdminServiceServer) CreateBan(context.Context, *CreateBanRequest) (*Ban, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBan not implemented")
+}
+func (UnimplementedAdminServiceServer) RemoveBan(context.Context, *BanRequest) (*Ban, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBan not implemented")
+}
+func (UnimplementedAdminServiceServer) ListBans(context.Context, *ListBansRequest) (*BanList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBans not implemented")
+}
+func (UnimplementedAdminServiceServer) SetBanAppeal(context.Context, *SetBanAppealRequest) (*Ban, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetBanAppeal not implemented")
+}
+func (UnimplementedAdminServiceServer) AddBlockRule(context.Context, *BlockRule) (*BlockRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBlockRule not implemented")
+}
+func (UnimplementedAdminServiceServer) RemoveBlockRule(context.Context, *BlockRuleRequest) (*BlockRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBlockRule not implemented")
+}
+func (UnimplementedAdminServiceServer) ListBlockRules(context.Context, *ListBlockRulesRequest) (*BlockRuleList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBlockRules not implemented")
+}
+func (UnimplementedAdminServiceServer) ReportQuarantine(context.Context, *QuarantineReport) (*QuarantineReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportQuarantine not implemented")
+}
+func (UnimplementedAdminServiceServer) Snapshot(*SnapshotRequest, grpc.ServerStreamingServer[SnapshotRecord]) error {
+	return status.Errorf(codes.Unimplemented, "method Snapshot not implemented")
+}
+func (UnimplementedAdminServiceServer) Restore(grpc.ClientStreamingServer[SnapshotRecord, RestoreSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedAdminServiceServer) Announce(context.Context, *AnnounceRequest) (*AnnounceResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Announce not implemented")
+}
+func (UnimplementedAdminServiceServer) ListRoomStats(context.Context, *RoomStatsRequest) (*RoomStatsList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRoomStats not implemented")
+}
+func (UnimplementedAdminServiceServer) TailAuditLog(*AuditLogRequest, grpc.ServerStreamingServer[AuditEntry]) error {
+	return status.Errorf(codes.Unimplemented, "method TailAuditLog not implemented")
+}
+func (UnimplementedAdminServiceServer) ListModerationQueue(context.Context, *ModerationQueueRequest) (*ModerationQueue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListModerationQueue not implemented")
+}
+func (UnimplementedAdminServiceServer) ResolveModeration(context.Context, *ResolveModerationRequest) (*ModerationItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveModeration not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_ExportRoom_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).ExportRoom(m, &grpc.GenericServerStream[ExportRequest, ChatMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportRoomServer = grpc.ServerStreamingServer[ChatMessage]
+
+func _AdminService_ImportMessages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServiceServer).ImportMessages(&grpc.GenericServerStream[ChatMessage, ImportSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ImportMessagesServer = grpc.ClientStreamingServer[ChatMessage, ImportSummary]
+
+func _AdminService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetStats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_WatchStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStatsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).WatchStats(m, &grpc.GenericServerStream[WatchStatsRequest, StatsSnapshot]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_WatchStatsServer = grpc.ServerStreamingServer[StatsSnapshot]
+
+func _AdminService_GetQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetQuota_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetQuota(ctx, req.(*QuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetQuota_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetQuota(ctx, req.(*SetQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RegisterCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SlashCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RegisterCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RegisterCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RegisterCommand(ctx, req.(*SlashCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_UnregisterCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnregisterCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).UnregisterCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_UnregisterCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).UnregisterCommand(ctx, req.(*UnregisterCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListCommands_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCommandsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListCommands(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListCommands_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListCommands(ctx, req.(*ListCommandsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RevokeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RevokeSession(ctx, req.(*RevokeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetWelcome_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WelcomeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetWelcome(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetWelcome_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetWelcome(ctx, req.(*WelcomeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetWelcome_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Welcome)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetWelcome(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetWelcome_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetWelcome(ctx, req.(*Welcome))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetRoomRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRoomRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetRoomRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetRoomRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetRoomRole(ctx, req.(*SetRoomRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetRoomPrivate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRoomPrivateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetRoomPrivate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetRoomPrivate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetRoomPrivate(ctx, req.(*SetRoomPrivateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetRoomSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomSettings)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetRoomSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetRoomSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetRoomSettings(ctx, req.(*RoomSettings))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_CreateInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInviteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).CreateInvite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_CreateInvite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).CreateInvite(ctx, req.(*CreateInviteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RevokeInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RevokeInvite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RevokeInvite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RevokeInvite(ctx, req.(*InviteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListInvites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInvitesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListInvites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListInvites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListInvites(ctx, req.(*ListInvitesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_CreateBan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateBanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).CreateBan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_CreateBan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).CreateBan(ctx, req.(*CreateBanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RemoveBan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RemoveBan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RemoveBan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RemoveBan(ctx, req.(*BanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListBans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListBans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListBans_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListBans(ctx, req.(*ListBansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetBanAppeal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBanAppealRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetBanAppeal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetBanAppeal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetBanAppeal(ctx, req.(*SetBanAppealRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_AddBlockRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRule)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).AddBlockRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_AddBlockRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).AddBlockRule(ctx, req.(*BlockRule))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RemoveBlockRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RemoveBlockRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RemoveBlockRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RemoveBlockRule(ctx, req.(*BlockRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListBlockRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBlockRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListBlockRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListBlockRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListBlockRules(ctx, req.(*ListBlockRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ReportQuarantine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuarantineReport)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ReportQuarantine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ReportQuarantine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ReportQuarantine(ctx, req.(*QuarantineReport))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_Snapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SnapshotRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).Snapshot(m, &grpc.GenericServerStream[SnapshotRequest, SnapshotRecord]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_SnapshotServer = grpc.ServerStreamingServer[SnapshotRecord]
+
+func _AdminService_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServiceServer).Restore(&grpc.GenericServerStream[SnapshotRecord, RestoreSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_RestoreServer = grpc.ClientStreamingServer[SnapshotRecord, RestoreSummary]
+
+func _AdminService_Announce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnnounceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).Announce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_Announce_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).Announce(ctx, req.(*AnnounceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListRoomStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListRoomStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListRoomStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListRoomStats(ctx, req.(*RoomStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_TailAuditLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AuditLogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).TailAuditLog(m, &grpc.GenericServerStream[AuditLogRequest, AuditEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_TailAuditLogServer = grpc.ServerStreamingServer[AuditEntry]
+
+func _AdminService_ListModerationQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModerationQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListModerationQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListModerationQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListModerationQueue(ctx, req.(*ModerationQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ResolveModeration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveModerationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ResolveModeration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ResolveModeration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ResolveModeration(ctx, req.(*ResolveModerationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStats",
+			Handler:    _AdminService_GetStats_Handler,
+		},
+		{
+			MethodName: "GetQuota",
+			Handler:    _AdminService_GetQuota_Handler,
+		},
+		{
+			MethodName: "SetQuota",
+			Handler:    _AdminService_SetQuota_Handler,
+		},
+		{
+			MethodName: "RegisterCommand",
+			Handler:    _AdminService_RegisterCommand_Handler,
+		},
+		{
+			MethodName: "UnregisterCommand",
+			Handler:    _AdminService_UnregisterCommand_Handler,
+		},
+		{
+			MethodName: "ListCommands",
+			Handler:    _AdminService_ListCommands_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _AdminService_ListSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _AdminService_RevokeSession_Handler,
+		},
+		{
+			MethodName: "GetWelcome",
+			Handler:    _AdminService_GetWelcome_Handler,
+		},
+		{
+			MethodName: "SetWelcome",
+			Handler:    _AdminService_SetWelcome_Handler,
+		},
+		{
+			MethodName: "SetRoomRole",
+			Handler:    _AdminService_SetRoomRole_Handler,
+		},
+		{
+			MethodName: "SetRoomPrivate",
+			Handler:    _AdminService_SetRoomPrivate_Handler,
+		},
+		{
+			MethodName: "SetRoomSettings",
+			Handler:    _AdminService_SetRoomSettings_Handler,
+		},
+		{
+			MethodName: "CreateInvite",
+			Handler:    _AdminService_CreateInvite_Handler,
+		},
+		{
+			MethodName: "RevokeInvite",
+			Handler:    _AdminService_RevokeInvite_Handler,
+		},
+		{
+			MethodName: "ListInvites",
+			Handler:    _AdminService_ListInvites_Handler,
+		},
+		{
+			MethodName: "CreateBan",
+			Handler:    _AdminService_CreateBan_Handler,
+		},
+		{
+			MethodName: "RemoveBan",
+			Handler:    _AdminService_RemoveBan_Handler,
+		},
+		{
+			MethodName: "ListBans",
+			Handler:    _AdminService_ListBans_Handler,
+		},
+		{
+			MethodName: "SetBanAppeal",
+			Handler:    _AdminService_SetBanAppeal_Handler,
+		},
+		{
+			MethodName: "AddBlockRule",
+			Handler:    _AdminService_AddBlockRule_Handler,
+		},
+		{
+			MethodName: "RemoveBlockRule",
+			Handler:    _AdminService_RemoveBlockRule_Handler,
+		},
+		{
+			MethodName: "ListBlockRules",
+			Handler:    _AdminService_ListBlockRules_Handler,
+		},
+		{
+			MethodName: "ReportQuarantine",
+			Handler:    _AdminService_ReportQuarantine_Handler,
+		},
+		{
+			MethodName: "Announce",
+			Handler:    _AdminService_Announce_Handler,
+		},
+		{
+			MethodName: "ListRoomStats",
+			Handler:    _AdminService_ListRoomStats_Handler,
+		},
+		{
+			MethodName: "ListModerationQueue",
+			Handler:    _AdminService_ListModerationQueue_Handler,
+		},
+		{
+			MethodName: "ResolveModeration",
+			Handler:    _AdminService_ResolveModeration_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportRoom",
+			Handler:       _AdminService_ExportRoom_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportMessages",
+			Handler:       _AdminService_ImportMessages_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchStats",
+			Handler:       _AdminService_WatchStats_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Snapshot",
+			Handler:       _AdminService_Snapshot_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       _AdminService_Restore_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "TailAuditLog",
+			Handler:       _AdminService_TailAuditLog_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	Plugin_Describe_FullMethodName         = "/chat.Plugin/Describe"
+	Plugin_FilterMessage_FullMethodName    = "/chat.Plugin/FilterMessage"
+	Plugin_MessageDelivered_FullMethodName = "/chat.Plugin/MessageDelivered"
+	Plugin_UserJoining_FullMethodName      = "/chat.Plugin/UserJoining"
+	Plugin_HandleCommand_FullMethodName    = "/chat.Plugin/HandleCommand"
+)
+
+// PluginClient is the client API for Plugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 插件接口，由插件进程实现、聊天服务器调用。插件在 Describe 中声明要接入的
+// 钩子和命令，服务器只调用声明过的钩子；调用出错或超时时服务器放行并记录日志
+type PluginClient interface {
+	// 服务器连接插件后调用一次
+	Describe(ctx context.Context, in *PluginInfoRequest, opts ...grpc.CallOption) (*PluginInfo, error)
+	// 消息发送前调用，可拒绝或改写消息，多个插件按配置顺序依次过滤
+	FilterMessage(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*FilterResult, error)
+	// 消息投递后调用，只用于通知，结果被忽略
+	MessageDelivered(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*PluginAck, error)
+	// 用户打开聊天流时调用，可拒绝加入
+	UserJoining(ctx context.Context, in *JoinEvent, opts ...grpc.CallOption) (*JoinDecision, error)
+	// 处理插件声明的 /命令
+	HandleCommand(ctx context.Context, in *PluginCommand, opts ...grpc.CallOption) (*CommandReply, error)
+}
+
+type pluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPluginClient(cc grpc.ClientConnInterface) PluginClient {
+	return &pluginClient{cc}
+}
+
+func (c *pluginClient) Describe(ctx context.Context, in *PluginInfoRequest, opts ...grpc.CallOption) (*PluginInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PluginInfo)
+	err := c.cc.Invoke(ctx, Plugin_Describe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) FilterMessage(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*FilterResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FilterResult)
+	err := c.cc.Invoke(ctx, Plugin_FilterMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) MessageDelivered(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*PluginAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PluginAck)
+	err := c.cc.Invoke(ctx, Plugin_MessageDelivered_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) UserJoining(ctx context.Context, in *JoinEvent, opts ...grpc.CallOption) (*JoinDecision, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JoinDecision)
+	err := c.cc.Invoke(ctx, Plugin_UserJoining_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) HandleCommand(ctx context.Context, in *PluginCommand, opts ...grpc.CallOption) (*CommandReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandReply)
+	err := c.cc.Invoke(ctx, Plugin_HandleCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PluginServer is the server API for Plugin service.
+// All implementations must embed UnimplementedPluginServer
+// for forward compatibility.
+//
+// 插件接口，由插件进程实现、聊天服务器调用。插件在 Describe 中声明要接入的
+// 钩子和命令，服务器只调用声明过的钩子；调用出错或超时时服务器放行并记录日志
+type PluginServer interface {
+	// 服务器连接插件后调用一次
+	Describe(context.Context, *PluginInfoRequest) (*PluginInfo, error)
+	// 消息发送前调用，可拒绝或改写消息，多个插件按配置顺序依次过滤
+	FilterMessage(context.Context, *ChatMessage) (*FilterResult, error)
+	// 消息投递后调用，只用于通知，结果被忽略
+	MessageDelivered(context.Context, *ChatMessage) (*PluginAck, error)
+	// 用户打开聊天流时调用，可拒绝加入
+	UserJoining(context.Context, *JoinEvent) (*JoinDecision, error)
+	// 处理插件声明的 /命令
+	HandleCommand(context.Context, *PluginCommand) (*CommandReply, error)
+	mustEmbedUnimplementedPluginServer()
+}
+
+// UnimplementedPluginServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPluginServer struct{}
+
+func (UnimplementedPluginServer) Describe(context.Context, *PluginInfoRequest) (*PluginInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Describe not implemented")
+}
+func (UnimplementedPluginServer) FilterMessage(context.Context, *ChatMessage) (*FilterResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FilterMessage not implemented")
+}
+func (UnimplementedPluginServer) MessageDelivered(context.Context, *ChatMessage) (*PluginAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MessageDelivered not implemented")
+}
+func (UnimplementedPluginServer) UserJoining(context.Context, *JoinEvent) (*JoinDecision, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UserJoining not implemented")
+}
+func (UnimplementedPluginServer) HandleCommand(context.Context, *PluginCommand) (*CommandReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandleCommand not implemented")
+}
+func (UnimplementedPluginServer) mustEmbedUnimplementedPluginServer() {}
+func (UnimplementedPluginServer) testEmbeddedByValue()                {}
+
+// UnsafePluginServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PluginServer will
+// result in compilation errors.
+type UnsafePluginServer interface {
+	mustEmbedUnimplementedPluginServer()
+}
+
+func RegisterPluginServer(s grpc.ServiceRegistrar, srv PluginServer) {
+	// If the following call pancis, it indicates UnimplementedPluginServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Plugin_ServiceDesc, srv)
+}
+
+func _Plugin_Describe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).Describe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Plugin_Describe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).Describe(ctx, req.(*PluginInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_FilterMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).FilterMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Plugin_FilterMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).FilterMessage(ctx, req.(*ChatMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_MessageDelivered_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).MessageDelivered(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Plugin_MessageDelivered_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).MessageDelivered(ctx, req.(*ChatMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_UserJoining_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinEvent)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).UserJoining(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Plugin_UserJoining_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).UserJoining(ctx, req.(*JoinEvent))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_HandleCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).HandleCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Plugin_HandleCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).HandleCommand(ctx, req.(*PluginCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Plugin_ServiceDesc is the grpc.ServiceDesc for Plugin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Plugin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.Plugin",
+	HandlerType: (*PluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Describe",
+			Handler:    _Plugin_Describe_Handler,
+		},
+		{
+			MethodName: "FilterMessage",
+			Handler:    _Plugin_FilterMessage_Handler,
+		},
+		{
+			MethodName: "MessageDelivered",
+			Handler:    _Plugin_MessageDelivered_Handler,
+		},
+		{
+			MethodName: "UserJoining",
+			Handler:    _Plugin_UserJoining_Handler,
+		},
+		{
+			MethodName: "HandleCommand",
+			Handler:    _Plugin_HandleCommand_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
+
+const (
+	ClusterService_Deliver_FullMethodName      = "/chat.ClusterService/Deliver"
+	ClusterService_TransferRoom_FullMethodName = "/chat.ClusterService/TransferRoom"
+)
+
+// ClusterServiceClient is the client API for ClusterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 多实例部署时聊天服务器之间的接口。收件人连在其他实例上时，发送者所在的
+// 实例通过集群注册表找到这些实例，再调用它们的 Deliver 投递
+type ClusterServiceClient interface {
+	// 把消息投递给本实例上 user 的所有聊天流，不再转发给其他实例
+	Deliver(ctx context.Context, in *PeerDelivery, opts ...grpc.CallOption) (*PeerDeliveryResult, error)
+	// 分片模式下拓扑变化时，旧实例把房间的状态交给新负责的实例
+	TransferRoom(ctx context.Context, in *RoomState, opts ...grpc.CallOption) (*RoomStateAck, error)
+}
+
+type clusterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClusterServiceClient(cc grpc.ClientConnInterface) ClusterServiceClient {
+	return &clusterServiceClient{cc}
+}
+
+func (c *clusterServiceClient) Deliver(ctx context.Context, in *PeerDelivery, opts ...grpc.CallOption) (*PeerDeliveryResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PeerDeliveryResult)
+	err := c.cc.Invoke(ctx, ClusterService_Deliver_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) TransferRoom(ctx context.Context, in *RoomState, opts ...grpc.CallOption) (*RoomStateAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoomStateAck)
+	err := c.cc.Invoke(ctx, ClusterService_TransferRoom_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClusterServiceServer is the server API for ClusterService service.
+// All implementations must embed UnimplementedClusterServiceServer
+// for forward compatibility.
+//
+// 多实例部署时聊天服务器之间的接口。收件人连在其他实例上时，发送者所在的
+// 实例通过集群注册表找到这些实例，再调用它们的 Deliver 投递
+type ClusterServiceServer interface {
+	// 把消息投递给本实例上 user 的所有聊天流，不再转发给其他实例
+	Deliver(context.Context, *PeerDelivery) (*PeerDeliveryResult, error)
+	// 分片模式下拓扑变化时，旧实例把房间的状态交给新负责的实例
+	TransferRoom(context.Context, *RoomState) (*RoomStateAck, error)
+	mustEmbedUnimplementedClusterServiceServer()
+}
+
+// UnimplementedClusterServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClusterServiceServer struct{}
+
+func (UnimplementedClusterServiceServer) Deliver(context.Context, *PeerDelivery) (*PeerDeliveryResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deliver not implemented")
+}
+func (UnimplementedClusterServiceServer) TransferRoom(context.Context, *RoomState) (*RoomStateAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferRoom not implemented")
+}
+func (UnimplementedClusterServiceServer) mustEmbedUnimplementedClusterServiceServer() {}
+func (UnimplementedClusterServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeClusterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClusterServiceServer will
+// result in compilation errors.
+type UnsafeClusterServiceServer interface {
+	mustEmbedUnimplementedClusterServiceServer()
+}
+
+func RegisterClusterServiceServer(s grpc.ServiceRegistrar, srv ClusterServiceServer) {
+	// If the following call pancis, it indicates UnimplementedClusterServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ClusterService_ServiceDesc, srv)
+}
+
+func _ClusterService_Deliver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerDelivery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).Deliver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_Deliver_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).Deliver(ctx, req.(*PeerDelivery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_TransferRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomState)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).TransferRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_TransferRoom_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).TransferRoom(ctx, req.(*RoomState))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ClusterService_ServiceDesc is the grpc.ServiceDesc for ClusterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClusterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.ClusterService",
+	HandlerType: (*ClusterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Deliver",
+			Handler:    _ClusterService_Deliver_Handler,
+		},
+		{
+			MethodName: "TransferRoom",
+			Handler:    _ClusterService_TransferRoom_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/chat/chat.proto",
+}
@@ -0,0 +1,70 @@
+package chat
+
+// TypeOf returns the type of m, falling back to its payload for messages
+// from servers that do not set Type yet
+func TypeOf(m *ChatMessage) MessageType {
+	if t := m.GetType(); t != MessageType_TYPE_UNSPECIFIED {
+		return t
+	}
+	switch m.GetPayload().(type) {
+	case *ChatMessage_Rename:
+		return MessageType_TYPE_RENAME
+	case *ChatMessage_LinkPreview:
+		return MessageType_TYPE_LINK_PREVIEW
+	case *ChatMessage_Signal:
+		return MessageType_TYPE_SIGNAL
+	case *ChatMessage_CallEvent:
+		return MessageType_TYPE_CALL
+	case *ChatMessage_Presence:
+		return MessageType_TYPE_PRESENCE
+	case *ChatMessage_Unread:
+		return MessageType_TYPE_UNREAD
+	case *ChatMessage_Ack:
+		return MessageType_TYPE_ACK
+	case *ChatMessage_Translation:
+		return MessageType_TYPE_TRANSLATION
+	case *ChatMessage_RoomChange:
+		return MessageType_TYPE_ROOM_CHANGE
+	case *ChatMessage_Hello:
+		return MessageType_TYPE_HELLO
+	case *ChatMessage_Activity:
+		return MessageType_TYPE_ACTIVITY
+	case *ChatMessage_Heartbeat:
+		return MessageType_TYPE_HEARTBEAT
+	case *ChatMessage_Edit:
+		return MessageType_TYPE_EDIT
+	case *ChatMessage_Members:
+		return MessageType_TYPE_ROSTER // joins and leaves always set Type
+	case *ChatMessage_Member:
+		return MessageType_TYPE_MEMBER
+	case *ChatMessage_KeywordHit:
+		return MessageType_TYPE_KEYWORD_HIT
+	case *ChatMessage_Subscriptions:
+		return MessageType_TYPE_SUBSCRIPTIONS
+	case *ChatMessage_Filter:
+		return MessageType_TYPE_FILTER
+	case *ChatMessage_Batch:
+		return MessageType_TYPE_BATCH
+	case *ChatMessage_RoomMoved:
+		return MessageType_TYPE_ROOM_MOVED
+	case *ChatMessage_Voice:
+		return MessageType_TYPE_VOICE
+	case *ChatMessage_Thread:
+		return MessageType_TYPE_THREAD
+	}
+	if m.GetSystem() != nil || m.GetUser() == "System" {
+		return MessageType_TYPE_SYSTEM
+	}
+	return MessageType_TYPE_CHAT
+}
+
+// IsEvent reports types that carry state changes rather than text for
+// the conversation
+func (t MessageType) IsEvent() bool {
+	switch t {
+	case MessageType_TYPE_UNSPECIFIED, MessageType_TYPE_CHAT, MessageType_TYPE_JOIN,
+		MessageType_TYPE_LEAVE, MessageType_TYPE_SYSTEM:
+		return false
+	}
+	return true
+}
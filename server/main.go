@@ -2,173 +2,324 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net"
-	"sync"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"realTimeChat/pkg/assistant"
+	"realTimeChat/pkg/avscan"
+	"realTimeChat/pkg/chatserver"
+	"realTimeChat/pkg/ids"
+	"realTimeChat/pkg/imaging"
+	"realTimeChat/pkg/leader"
+	"realTimeChat/pkg/objstore"
+	"realTimeChat/pkg/redis"
+	"realTimeChat/pkg/shard"
+	"realTimeChat/pkg/translate"
+	"realTimeChat/pkg/unfurl"
 	pb "realTimeChat/proto/chat"
 )
 
-// connection store stream and user info
-type connection struct {
-	stream pb.ChatService_RealtimeChatServer
-	user   string
-}
-
-// ChatServer struct
-type ChatServer struct {
-	pb.UnimplementedChatServiceServer
-	mu          sync.RWMutex          // read write mutex to protect connections map
-	connections map[string]connection // store active connection
-}
+func main() {
+	translateURL := flag.String("translate-url", "", "LibreTranslate server for /translate and auto-translation, disabled when empty")
+	translateKey := flag.String("translate-api-key", os.Getenv("TRANSLATE_API_KEY"), "API key for --translate-url (default $TRANSLATE_API_KEY)")
+	assistantURL := flag.String("assistant-url", "", "OpenAI-compatible API, e.g. https://api.openai.com/v1, answering @assistant questions, disabled when empty")
+	assistantKey := flag.String("assistant-api-key", os.Getenv("ASSISTANT_API_KEY"), "API key for --assistant-url (default $ASSISTANT_API_KEY)")
+	assistantModel := flag.String("assistant-model", assistant.DefaultModel, "model asked by the assistant")
+	linkPreviews := flag.Bool("link-previews", false, "fetch OpenGraph previews for links in messages")
+	adminToken := flag.String("admin-token", os.Getenv("CHAT_ADMIN_TOKEN"), "bearer token for AdminService, disabled when empty (default $CHAT_ADMIN_TOKEN)")
+	auditPath := flag.String("audit-log", "", "append the admin calls that change state to this file as JSON lines, the last 1000 are kept in memory either way")
+	idleTimeout := flag.Duration("idle-timeout", chatserver.DefaultIdleTimeout, "show users as away after this long without activity on any connection, 0 only goes by client hints")
+	storePath := flag.String("store", "", "append messages to this file and read exports and imports from it, no messages are stored when empty")
+	banPath := flag.String("bans", "", "keep account and IP bans in this JSON file so they survive restarts, in memory when empty")
+	leaderRedis := flag.String("leader-redis", "", "elect one server among those sharing --bans to expire bans through the Redis at this URL, such as redis://:password@localhost:6379/0, every server expires them when empty")
+	leaderKey := flag.String("leader-key", "realtimechat:leader", "Redis key of the --leader-redis lease, the same on every server of a cluster")
+	leaderID := flag.String("leader-id", "", "name of this server in the --leader-redis lease (default host name, process ID and a random suffix)")
+	leaderTTL := flag.Duration("leader-ttl", leader.DefaultTTL, "how long the lease outlives a leader that stopped renewing it, before another server takes over")
+	clusterRedis := flag.String("cluster-redis", "", "register this server's users in the Redis at this URL and forward private messages to users connected to other servers registered there, off when empty")
+	clusterAddr := flag.String("cluster-advertise", "", "address the other servers of --cluster-redis reach this server's gRPC port at (default the host name and the port listened on)")
+	clusterSecret := flag.String("cluster-secret", os.Getenv("CLUSTER_SECRET"), "token the servers of a cluster present to each other, every server accepts forwarded messages from anyone when empty (default $CLUSTER_SECRET)")
+	shardsPath := flag.String("shards", "", "serve only the rooms consistent hashing assigns this server among the --cluster-advertise addresses listed in this file, one per line, handing rooms over when it changes; every server serves every room when empty")
+	blockPath := flag.String("blocklist", "", "keep blocklist rules in this JSON file so they survive restarts, in memory when empty")
+	abusePath := flag.String("abuse-config", "", "JSON file of abuse heuristic weights and the scores that put senders in slow mode or shadow ban them, off when empty")
+	scriptDir := flag.String("scripts", "", "directory of Lua scripts run on every message before it is broadcast, reloaded when it changes")
+	featurePath := flag.String("features", "", "JSON file of feature flags per deployment, tenant and room, reloaded when it changes; uploads, calls, link previews and translations are on when empty")
+	idScheme := flag.String("ids", "ulid", "message and session IDs: ulid, or snowflake with --node-id")
+	nodeID := flag.Int("node-id", -1, "node ID of this server for --ids snowflake, 0 to 1023 and unique per server")
+	attachmentDir := flag.String("attachment-dir", "", "directory for files uploaded through AttachmentService (default a directory below the system temp dir)")
+	storageConfig := flag.String("storage-config", "", "JSON file selecting where finished attachments are kept, the local --attachment-dir (default) or an S3-compatible bucket")
+	clamav := flag.String("clamav", "", "scan uploads with the clamd listening on this TCP address, such as localhost:3310")
+	scanURL := flag.String("scan-url", "", "scan uploads by posting them to this HTTP scanning service")
+	scanToken := flag.String("scan-token", os.Getenv("SCAN_TOKEN"), "bearer token for --scan-url (default $SCAN_TOKEN)")
+	thumbnailSizes := flag.String("thumbnail-sizes", "160,480", "comma separated bounding boxes, in pixels, of the thumbnails rendered for image uploads, none when empty")
+	ka := chatserver.DefaultKeepalive
+	flag.DurationVar(&ka.Time, "keepalive-time", ka.Time, "ping clients after this long without activity")
+	flag.DurationVar(&ka.Timeout, "keepalive-timeout", ka.Timeout, "close connections whose ping is not answered in time")
+	flag.DurationVar(&ka.MinClientInterval, "keepalive-min-client-interval", ka.MinClientInterval, "disconnect clients that ping more often than this")
+	flag.DurationVar(&ka.MaxConnectionIdle, "max-connection-idle", 0, "close connections without open streams after this long, 0 disables")
+	flag.DurationVar(&ka.MaxConnectionAge, "max-connection-age", 0, "ask clients to reconnect after this long, 0 disables")
+	flag.DurationVar(&ka.MaxConnectionAgeGrace, "max-connection-age-grace", 30*time.Second, "time open streams get to finish after max-connection-age")
+	writeBatching := flag.Bool("write-batching", false, "coalesce consecutive sends to a stream into fewer writes, clients with the batch capability get them as one message")
+	wb := chatserver.DefaultWriteBatching
+	flag.IntVar(&wb.MaxBytes, "write-batch-bytes", wb.MaxBytes, "write a batch once its messages encode to this many bytes")
+	flag.DurationVar(&wb.Delay, "write-batch-delay", wb.Delay, "how long the first message of a batch waits for more, 0 only waits for the write in flight")
+	var limits chatserver.Limits
+	flag.IntVar(&limits.MaxStreams, "max-streams", 0, "maximum open chat streams, 0 is unlimited")
+	flag.IntVar(&limits.MaxStreamsPerUser, "max-streams-per-user", 0, "maximum chat streams per username, 0 is unlimited")
+	flag.IntVar(&limits.MaxStreamsPerIP, "max-streams-per-ip", 0, "maximum chat streams per source address, 0 is unlimited (a web gateway counts as one address)")
+	quotas := chatserver.Quotas{Tenant: &pb.Quota{}, Room: &pb.Quota{}}
+	flag.Int64Var(&quotas.Tenant.MessagesPerDay, "quota-messages-per-day", 0, "messages a tenant may send per UTC day, 0 is unlimited")
+	flag.Int64Var(&quotas.Tenant.StorageBytes, "quota-storage-bytes", 0, "bytes of message text, code and attachments a tenant may store, 0 is unlimited")
+	flag.Func("quota-max-rooms", "rooms besides the default one a tenant's users may be in at once, 0 is unlimited", intFlag(&quotas.Tenant.MaxRooms))
+	flag.Int64Var(&quotas.Room.MessagesPerDay, "room-messages-per-day", 0, "messages a room may receive per UTC day, 0 is unlimited")
+	flag.Int64Var(&quotas.Room.StorageBytes, "room-storage-bytes", 0, "bytes a room may store, 0 is unlimited")
+	flag.Func("room-max-members", "users a room other than the default one may hold, 0 is unlimited", intFlag(&quotas.Room.MaxMembers))
+	replicaCfg := chatserver.ReplicaConfig{Routes: make(map[chatserver.ReadQuery]chatserver.ReadRoute)}
+	flag.Func("store-replica", "read exports and /summarize scrollback from this copy of the --store file, kept up to date by something else, repeatable", func(v string) error {
+		replicaCfg.Replicas = append(replicaCfg.Replicas, chatserver.NewFileReplica(v))
+		return nil
+	})
+	flag.DurationVar(&replicaCfg.Default.MaxStaleness, "replica-max-staleness", 30*time.Second, "skip replicas further behind the store than this, 0 accepts any lag")
+	flag.Func("replica-route", `route one kind of read, "export", "summarize" or "history": "<query>=primary" or "<query>=<max staleness>", repeatable`, func(v string) error {
+		q, route, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("want <query>=primary or <query>=<duration>, got %q", v)
+		}
+		if route == "primary" {
+			replicaCfg.Routes[chatserver.ReadQuery(q)] = chatserver.ReadRoute{Primary: true}
+			return nil
+		}
+		d, err := time.ParseDuration(route)
+		if err != nil {
+			return err
+		}
+		replicaCfg.Routes[chatserver.ReadQuery(q)] = chatserver.ReadRoute{MaxStaleness: d}
+		return nil
+	})
+	var tiering chatserver.Tiering
+	flag.DurationVar(&tiering.After, "cold-after", 0, "move messages older than this from --store to compressed cold storage, 0 keeps them all in --store")
+	flag.DurationVar(&tiering.Interval, "cold-interval", chatserver.DefaultCompactInterval, "how often old messages are moved to cold storage")
+	coldConfig := flag.String("cold-storage-config", "", "JSON file like --storage-config selecting where cold messages are kept (default a directory next to --store)")
+	var plugins []string
+	var motd []string
+	flag.Func("motd", "message of the day sent to every connection as it joins, repeatable for several messages", func(v string) error {
+		motd = append(motd, v)
+		return nil
+	})
+	flag.Func("plugin", `plugin to start (an executable path) or connect to ("grpc://host:port"), repeatable, filters run in order`, func(v string) error {
+		plugins = append(plugins, v)
+		return nil
+	})
+	flag.Parse()
 
-// NewChatServer creates a new ChatServer
-func NewChatServer() *ChatServer {
-	return &ChatServer{
-		connections: make(map[string]connection),
+	port := ":50051"
+	lis, err := net.Listen("tcp", port)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
 	}
-}
 
-// sendRoutine sends a message to a specific stream
-func (s *ChatServer) sendRoutine(stream pb.ChatService_RealtimeChatServer, msg *pb.ChatMessage, username string) {
-	if err := stream.Send(msg); err != nil {
-		log.Printf("Failed to send PM to %s: %v", username, err)
+	opts := []chatserver.Option{chatserver.WithKeepalive(ka), chatserver.WithLimits(limits), chatserver.WithAdminToken(*adminToken), chatserver.WithIdleTimeout(*idleTimeout), chatserver.WithQuotas(quotas), chatserver.WithWelcome("", motd...)}
+	if *writeBatching {
+		opts = append(opts, chatserver.WithWriteBatching(wb))
 	}
-}
-
-func (s *ChatServer) sendToUser(username string, msg *pb.ChatMessage) bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	found := false
-	for _, conn := range s.connections {
-		if conn.user == username {
-			go s.sendRoutine(conn.stream, msg, username)
-			found = true
+	if *auditPath != "" {
+		opts = append(opts, chatserver.WithAuditLog(*auditPath))
+	}
+	if *storePath != "" {
+		store, err := chatserver.NewFileStore(*storePath)
+		if err != nil {
+			log.Fatalf("Failed to open store: %v", err)
+		}
+		defer store.Close()
+		var st chatserver.Store = store
+		if len(replicaCfg.Replicas) > 0 {
+			replicated := chatserver.NewReplicatedStore(store, replicaCfg)
+			defer replicated.Close()
+			st = replicated
 		}
+		if tiering.After > 0 {
+			tiered, err := openColdTier(st, *coldConfig, *storePath+".cold", tiering)
+			if err != nil {
+				log.Fatalf("Failed to open cold storage: %v", err)
+			}
+			defer tiered.Close()
+			st = tiered
+		}
+		opts = append(opts, chatserver.WithStore(st))
 	}
-	return found
-}
-
-// RealtimeChat define in proto file
-func (s *ChatServer) RealtimeChat(stream pb.ChatService_RealtimeChatServer) error {
-	log.Println("New client connected...")
-
-	// 1. accept the first message which should contain user info
-	firstMsg, err := stream.Recv()
-	if err != nil {
-		log.Printf("Failed to receive first message: %v", err)
-		return status.Error(codes.InvalidArgument, "First message must contain user info")
+	if *banPath != "" {
+		bans, err := chatserver.NewFileBanStore(*banPath)
+		if err != nil {
+			log.Fatalf("Failed to open bans: %v", err)
+		}
+		opts = append(opts, chatserver.WithBanStore(bans))
 	}
-	userName := firstMsg.User
-	if userName == "" {
-		return status.Error(codes.InvalidArgument, "Username cannot be empty")
+	if *leaderRedis != "" {
+		rc, err := redis.New(*leaderRedis)
+		if err != nil {
+			log.Fatalf("Invalid --leader-redis: %v", err)
+		}
+		defer rc.Close()
+		elector := leader.New(leader.NewRedisLock(rc), *leaderKey, leader.WithID(*leaderID), leader.WithTTL(*leaderTTL))
+		defer elector.Close()
+		opts = append(opts, chatserver.WithLeaderElection(elector))
 	}
-
-	// 2. create a unique client ID
-	clientID := fmt.Sprintf("%s_%p", userName, stream)
-
-	// 3. store connection to map
-	s.mu.Lock()
-	s.connections[clientID] = connection{
-		stream: stream,
-		user:   userName,
+	if *clusterRedis != "" {
+		rc, err := redis.New(*clusterRedis)
+		if err != nil {
+			log.Fatalf("Invalid --cluster-redis: %v", err)
+		}
+		defer rc.Close()
+		addr := *clusterAddr
+		if addr == "" {
+			host, err := os.Hostname()
+			if err != nil {
+				log.Fatalf("Failed to find the host name for --cluster-advertise: %v", err)
+			}
+			addr = net.JoinHostPort(host, strings.TrimPrefix(port, ":"))
+		}
+		opts = append(opts, chatserver.WithCluster(chatserver.Cluster{
+			Node:     chatserver.Node{Addr: addr},
+			Registry: chatserver.NewRedisRegistry(rc, "realtimechat:cluster"),
+			Secret:   *clusterSecret,
+		}))
 	}
-	s.mu.Unlock()
-
-	log.Printf("User '%s' (ID: %s) joined.", userName, clientID)
-
-	// 4. broadcast joined msg
-	joinMsg := &pb.ChatMessage{User: "System", Text: fmt.Sprintf("%s has joined the chat", userName)}
-	s.broadcast(joinMsg, clientID)
-
-	// 5. hear from client
-	for {
-		msg, err := stream.Recv()
-		if err == io.EOF {
-			// stream close
-			break
+	var ring *shard.Ring
+	if *shardsPath != "" {
+		if *clusterRedis == "" {
+			log.Fatalf("--shards needs --cluster-redis")
+		}
+		if ring, err = shard.Load(*shardsPath, 0); err != nil {
+			log.Fatalf("Failed to load shards: %v", err)
 		}
+		opts = append(opts, chatserver.WithSharding(chatserver.Sharding{Ring: ring}))
+	}
+	if *blockPath != "" {
+		blocks, err := chatserver.NewFileBlockStore(*blockPath)
 		if err != nil {
-			log.Printf("Error receiving from %s: %v", clientID, err)
-			break
+			log.Fatalf("Failed to open blocklist: %v", err)
 		}
-
-		if msg.RecipientUser == "" {
-			// broadcast message
-			log.Printf("Broadcasting message from %s: %s", msg.User, msg.Text)
-			s.broadcast(msg, clientID)
-		} else {
-			// pm message
-			log.Printf("Private message from %s to %s", msg.User, msg.RecipientUser)
-
-			// 1. send to recipient
-			found := s.sendToUser(msg.RecipientUser, msg)
-
-			// 2. send copy back to sender
-			if err := stream.Send(msg); err != nil {
-				log.Printf("Failed to send PM copy back to sender %s: %v", clientID, err)
-			}
-
-			// 3. notify sender if recipient not found
-			if !found {
-				systemMsg := &pb.ChatMessage{
-					User: "System",
-					Text: fmt.Sprintf("User '%s' not found or is offline.", msg.RecipientUser),
-				}
-				if err := stream.Send(systemMsg); err != nil {
-					log.Printf("Failed to send 'user not found' to %s: %v", clientID, err)
-				}
+		opts = append(opts, chatserver.WithBlockStore(blocks))
+	}
+	if *abusePath != "" {
+		cfg, err := chatserver.LoadAbuseConfig(*abusePath)
+		if err != nil {
+			log.Fatalf("Failed to load abuse config: %v", err)
+		}
+		opts = append(opts, chatserver.WithAbuse(cfg))
+	}
+	switch *idScheme {
+	case "ulid":
+	case "snowflake":
+		gen, err := ids.NewSnowflake(*nodeID)
+		if err != nil {
+			log.Fatalf("--ids snowflake needs --node-id: %v", err)
+		}
+		opts = append(opts, chatserver.WithIDGenerator(gen))
+	default:
+		log.Fatalf("Unknown --ids %q, want ulid or snowflake", *idScheme)
+	}
+	if *scriptDir != "" {
+		opts = append(opts, chatserver.WithScripts(*scriptDir))
+	}
+	if *featurePath != "" {
+		opts = append(opts, chatserver.WithFeatureFlags(*featurePath))
+	}
+	if *attachmentDir != "" {
+		opts = append(opts, chatserver.WithAttachmentDir(*attachmentDir))
+	}
+	if *storageConfig != "" {
+		cfg, err := objstore.LoadConfig(*storageConfig)
+		if err != nil {
+			log.Fatalf("Failed to load storage config: %v", err)
+		}
+		if cfg.Backend == "s3" {
+			store, err := objstore.Open(context.Background(), cfg, "")
+			if err != nil {
+				log.Fatalf("Failed to open object storage: %v", err)
 			}
+			opts = append(opts, chatserver.WithObjectStore(store))
+		} else if cfg.Dir != "" {
+			opts = append(opts, chatserver.WithAttachmentDir(cfg.Dir))
 		}
 	}
+	switch {
+	case *clamav != "":
+		opts = append(opts, chatserver.WithVirusScanner(avscan.NewClamAV(*clamav)))
+	case *scanURL != "":
+		opts = append(opts, chatserver.WithVirusScanner(avscan.NewHTTPScanner(*scanURL, *scanToken)))
+	}
+	sizes, err := imaging.ParseSizes(*thumbnailSizes)
+	if err != nil {
+		log.Fatalf("Invalid --thumbnail-sizes: %v", err)
+	}
+	opts = append(opts, chatserver.WithThumbnailSizes(sizes...))
+	if *linkPreviews {
+		opts = append(opts, chatserver.WithLinkPreviews(unfurl.New()))
+	}
+	if *translateURL != "" {
+		opts = append(opts, chatserver.WithTranslator(translate.NewLibreTranslate(*translateURL, *translateKey)))
+	}
+	if *assistantURL != "" {
+		opts = append(opts, chatserver.WithAssistant(assistant.NewOpenAI(*assistantURL, *assistantKey, *assistantModel)))
+	}
+	for _, spec := range plugins {
+		var p *chatserver.Plugin
+		if addr, ok := strings.CutPrefix(spec, "grpc://"); ok {
+			p, err = chatserver.ConnectPlugin(context.Background(), addr)
+		} else {
+			p, err = chatserver.StartPlugin(context.Background(), spec)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load plugin: %v", err)
+		}
+		defer p.Close()
+		log.Printf("Loaded plugin %s", p.Name())
+		opts = append(opts, chatserver.WithPlugins(p))
+	}
+	chatServer := chatserver.NewChatServer(opts...)
+	if ring != nil {
+		go shard.Watch(context.Background(), *shardsPath, 0, shard.DefaultWatchInterval, ring, chatServer.SetShards)
+	}
 
-	// 7. close connection
-	s.mu.Lock()
-	delete(s.connections, clientID)
-	s.mu.Unlock()
-
-	log.Printf("User '%s' (ID: %s) disconnected.", userName, clientID)
-
-	// 8. broadcast left msg
-	leaveMsg := &pb.ChatMessage{User: "System", Text: fmt.Sprintf("%s has left the chat", userName)}
-	s.broadcast(leaveMsg, "")
-
-	return nil
+	log.Printf("Server listening at %v", lis.Addr())
+	if err := chatServer.Serve(lis); err != nil {
+		log.Fatalf("Failed to serve: %v", err)
+	}
 }
 
-// broadcast message to all clients except the sender
-func (s *ChatServer) broadcast(msg *pb.ChatMessage, excludeID string) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	for id, conn := range s.connections {
-		if id == excludeID {
-			continue // skip sender
+// openColdTier puts a cold tier behind hot, kept as configured in the
+// storage config file at path or in dir
+func openColdTier(hot chatserver.Store, path, dir string, tiering chatserver.Tiering) (*chatserver.TieredStore, error) {
+	cfg := &objstore.Config{}
+	if path != "" {
+		var err error
+		if cfg, err = objstore.LoadConfig(path); err != nil {
+			return nil, err
 		}
-		go s.sendRoutine(conn.stream, msg, conn.user)
 	}
-}
-
-func main() {
-	port := ":50051"
-	lis, err := net.Listen("tcp", port)
+	objects, err := objstore.Open(context.Background(), cfg, dir)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		return nil, err
 	}
+	cold, err := chatserver.NewColdStore(context.Background(), objects, "")
+	if err != nil {
+		return nil, err
+	}
+	return chatserver.NewTieredStore(hot, cold, tiering)
+}
 
-	s := grpc.NewServer()
-	chatServer := NewChatServer()
-	pb.RegisterChatServiceServer(s, chatServer)
-
-	log.Printf("Server listening at %v", lis.Addr())
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("Failed to serve: %v", err)
+// intFlag parses a flag into an int32 quota field
+func intFlag(p *int32) func(string) error {
+	return func(v string) error {
+		n, err := strconv.ParseInt(v, 10, 32)
+		*p = int32(n)
+		return err
 	}
 }
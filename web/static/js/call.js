@@ -0,0 +1,244 @@
+// 一对一音视频通话：信令经聊天连接转发，媒体在浏览器之间直连
+const rtcConfig = { iceServers: [{ urls: 'stun:stun.l.google.com:19302' }] };
+const callReasons = {
+    'offline': '对方不在线',
+    'busy': '对方正在通话中',
+    'no-answer': '无人接听',
+    'hangup': '通话已挂断',
+    'disconnected': '连接已断开'
+};
+
+let currentCall = null;
+
+// 发起通话
+async function startCall(user) {
+    if (currentCall) {
+        showNotification('已在通话中', 'error');
+        return;
+    }
+    if (!window.RTCPeerConnection || !navigator.mediaDevices) {
+        showNotification('您的浏览器不支持通话', 'error');
+        return;
+    }
+    const call = newCall(crypto.randomUUID(), user, false);
+    try {
+        await openMedia(call);
+        const offer = await call.pc.createOffer();
+        await call.pc.setLocalDescription(offer);
+        sendSignal(call, 'offer', JSON.stringify(offer));
+        showCallPanel(`正在呼叫 ${user}...`, false);
+    } catch (error) {
+        console.error('发起通话失败:', error);
+        showNotification('无法使用麦克风或摄像头', 'error');
+        closeCall();
+    }
+}
+
+// 接听来电
+async function acceptCall() {
+    const call = currentCall;
+    if (!call || !call.offer) {
+        return;
+    }
+    try {
+        await openMedia(call);
+        await call.pc.setRemoteDescription(call.offer);
+        call.offer = null;
+        flushCandidates(call);
+        const answer = await call.pc.createAnswer();
+        await call.pc.setLocalDescription(answer);
+        sendSignal(call, 'answer', JSON.stringify(answer));
+        showCallPanel(`正在连接 ${call.peer}...`, false);
+    } catch (error) {
+        console.error('接听失败:', error);
+        showNotification('无法使用麦克风或摄像头', 'error');
+        hangup();
+    }
+}
+
+// 挂断或拒接
+function hangup() {
+    if (!currentCall) {
+        return;
+    }
+    sendSignal(currentCall, 'hangup', '');
+    closeCall();
+}
+
+// 开始或停止共享屏幕，替换视频轨道，无需重新协商
+async function toggleScreenShare() {
+    const call = currentCall;
+    if (!call || !call.stream) {
+        return;
+    }
+    const sender = call.pc.getSenders().find(s => s.track && s.track.kind === 'video');
+    if (!sender) {
+        showNotification('仅视频通话可以共享屏幕', 'error');
+        return;
+    }
+    if (call.screen) {
+        stopScreenShare(call, sender);
+        return;
+    }
+    try {
+        call.screen = await navigator.mediaDevices.getDisplayMedia({ video: true });
+    } catch (error) {
+        console.error('共享屏幕失败:', error);
+        return;
+    }
+    const track = call.screen.getVideoTracks()[0];
+    track.onended = () => stopScreenShare(call, sender); // 浏览器的“停止共享”按钮
+    await sender.replaceTrack(track);
+    sendSignal(call, 'screen-start', '');
+    document.getElementById('screen-share-btn').classList.add('active');
+}
+
+function stopScreenShare(call, sender) {
+    if (!call.screen) {
+        return;
+    }
+    call.screen.getTracks().forEach(track => track.stop());
+    call.screen = null;
+    if (currentCall === call) {
+        sender.replaceTrack(call.stream.getVideoTracks()[0]);
+        sendSignal(call, 'screen-stop', '');
+    }
+    document.getElementById('screen-share-btn').classList.remove('active');
+}
+
+// 处理对方发来的信令
+async function handleSignal(message) {
+    const signal = message.signal;
+    if (signal.type === 'offer') {
+        if (currentCall) {
+            return; // 服务器会告知对方正忙
+        }
+        const call = newCall(signal.callId, message.user, true);
+        call.offer = JSON.parse(signal.payload);
+        showCallPanel(`${message.user} 邀请您通话`, true);
+        notifyUser({ user: message.user, text: '邀请您通话' });
+        return;
+    }
+    if (!currentCall || currentCall.id !== signal.callId) {
+        return;
+    }
+    try {
+        switch (signal.type) {
+            case 'answer':
+                await currentCall.pc.setRemoteDescription(JSON.parse(signal.payload));
+                flushCandidates(currentCall);
+                break;
+            case 'ice':
+                addCandidate(currentCall, JSON.parse(signal.payload));
+                break;
+            case 'screen-start':
+                showCallPanel(`${currentCall.peer} 正在共享屏幕`, false);
+                break;
+            case 'screen-stop':
+                showCallPanel(`与 ${currentCall.peer} 通话中`, false);
+                break;
+        }
+    } catch (error) {
+        console.error('处理信令失败:', error);
+    }
+}
+
+// 处理服务器的通话状态
+function handleCallEvent(event) {
+    const call = currentCall;
+    const peer = event.caller === currentUsername ? event.callee : event.caller;
+    switch (event.state) {
+        case 'in-call':
+            if (call && call.id === event.callId) {
+                showCallPanel(`与 ${peer} 通话中`, false);
+            }
+            break;
+        case 'ended':
+            if (call && call.id === event.callId) {
+                closeCall();
+            }
+            if (!call || call.id === event.callId) {
+                displaySystemMessage(`与 ${peer} 的通话结束：${callReasons[event.reason] || event.reason}`);
+            }
+            break;
+    }
+}
+
+function newCall(id, peer, incoming) {
+    const pc = new RTCPeerConnection(rtcConfig);
+    const call = { id: id, peer: peer, incoming: incoming, pc: pc, stream: null, screen: null, offer: null, candidates: [] };
+    pc.onicecandidate = event => {
+        if (event.candidate) {
+            sendSignal(call, 'ice', JSON.stringify(event.candidate));
+        }
+    };
+    pc.ontrack = event => {
+        document.getElementById('remote-video').srcObject = event.streams[0];
+    };
+    currentCall = call;
+    return call;
+}
+
+// 打开麦克风和摄像头，没有摄像头时只用语音
+async function openMedia(call) {
+    try {
+        call.stream = await navigator.mediaDevices.getUserMedia({ audio: true, video: true });
+    } catch (error) {
+        call.stream = await navigator.mediaDevices.getUserMedia({ audio: true });
+    }
+    call.stream.getTracks().forEach(track => call.pc.addTrack(track, call.stream));
+    document.getElementById('local-video').srcObject = call.stream;
+}
+
+// 远端描述设置前到达的候选先缓存
+function addCandidate(call, candidate) {
+    if (!call.pc.remoteDescription) {
+        call.candidates.push(candidate);
+        return;
+    }
+    call.pc.addIceCandidate(candidate).catch(error => console.error('添加候选失败:', error));
+}
+
+function flushCandidates(call) {
+    const pending = call.candidates;
+    call.candidates = [];
+    pending.forEach(candidate => addCandidate(call, candidate));
+}
+
+function sendSignal(call, type, payload) {
+    if (!socket || socket.readyState !== WebSocket.OPEN) {
+        return;
+    }
+    socket.send(JSON.stringify({
+        type: 'signal',
+        user: currentUsername,
+        recipientUser: call.peer,
+        signal: { callId: call.id, type: type, payload: payload },
+        timestamp: new Date().toISOString()
+    }));
+}
+
+function closeCall() {
+    const call = currentCall;
+    currentCall = null;
+    if (call) {
+        if (call.stream) {
+            call.stream.getTracks().forEach(track => track.stop());
+        }
+        if (call.screen) {
+            call.screen.getTracks().forEach(track => track.stop());
+        }
+        call.pc.close();
+    }
+    document.getElementById('local-video').srcObject = null;
+    document.getElementById('remote-video').srcObject = null;
+    document.getElementById('screen-share-btn').classList.remove('active');
+    document.getElementById('call-panel').style.display = 'none';
+}
+
+function showCallPanel(text, ringing) {
+    document.getElementById('call-status').textContent = text;
+    document.getElementById('call-accept-btn').style.display = ringing ? '' : 'none';
+    document.getElementById('screen-share-btn').style.display = ringing ? 'none' : '';
+    document.getElementById('call-panel').style.display = 'flex';
+}
@@ -3,6 +3,34 @@ let socket = null;
 let currentUsername = '';
 let isConnected = false;
 let onlineUsers = new Set();
+let userStatus = new Map(); // 通话中、共享屏幕或离开的用户
+let lastActivity = 0; // 上次发送活跃提示的时间
+// 网关要求令牌时，从页面地址的 ?token= 读取，本标签页内保留
+const authToken = new URLSearchParams(window.location.search).get('token') || sessionStorage.getItem('chatToken') || '';
+if (authToken) {
+    sessionStorage.setItem('chatToken', authToken);
+}
+
+// 读写自己的偏好、联系人、草稿等数据时带上令牌，网关据此确认是本人
+function authHeaders(headers = {}) {
+    if (authToken) {
+        headers['Authorization'] = 'Bearer ' + authToken;
+    }
+    return headers;
+}
+let lastSeq = {}; // 各房间收到的最新序号
+let pendingMessages = new Map(); // 未确认的消息，按 clientMsgId 索引
+let maintenanceMode = false;
+let recorder = null;
+let recordingStart = 0;
+let catalog = {}; // 系统消息文案，按 key 索引
+let currentRoom = 'general'; // 当前房间，随 subscriptions 帧更新，草稿按房间保存
+let draftTimer = null;
+let draftEditedAt = 0; // 输入框最后一次编辑的时间，草稿冲突时较新的为准
+// 通过 /join/:token 邀请链接打开时，网关带上 ?room= 和 ?invite=
+const pageParams = new URLSearchParams(window.location.search);
+const inviteRoom = pageParams.get('room') || '';
+const inviteToken = pageParams.get('invite') || '';
 
 // DOM 元素
 const loginScreen = document.getElementById('login-screen');
@@ -30,8 +58,57 @@ document.addEventListener('DOMContentLoaded', function() {
     
     // 禁用发送按钮
     updateSendButton();
+    
+    loadCatalog(navigator.language || 'zh');
 });
 
+// 加载系统消息文案
+function loadCatalog(locale) {
+    fetch(`/api/i18n/${encodeURIComponent(locale)}`)
+        .then(resp => resp.ok ? resp.json() : null)
+        .then(data => {
+            if (data) {
+                catalog = data.messages || {};
+            }
+        })
+        .catch(error => console.error('加载文案失败:', error));
+}
+
+// 用户在通知偏好中设置了界面语言时以其为准
+function loadUserLocale() {
+    fetch(`/api/preferences/${encodeURIComponent(currentUsername)}`, { headers: authHeaders() })
+        .then(resp => resp.ok ? resp.json() : null)
+        .then(prefs => {
+            if (prefs && prefs.locale) {
+                loadCatalog(prefs.locale);
+            }
+        })
+        .catch(() => {});
+}
+
+// 按功能开关隐藏关闭的功能，查询失败时保持显示
+function loadFeatures() {
+    fetch(`/api/features?user=${encodeURIComponent(currentUsername)}`)
+        .then(resp => resp.ok ? resp.json() : null)
+        .then(data => {
+            const features = (data && data.features) || {};
+            document.getElementById('voice-btn').style.display = features.uploads === false ? 'none' : '';
+        })
+        .catch(() => {});
+}
+
+// 按文案渲染系统消息，没有对应文案时使用服务器提供的英文文本
+function renderText(message) {
+    const template = message.key && catalog[message.key];
+    if (!template) {
+        return message.text;
+    }
+    return template.replace(/\{(\w+)\}/g, (match, name) => {
+        const args = message.args || {};
+        return name in args ? args[name] : match;
+    });
+}
+
 // 加入聊天
 function joinChat() {
     const username = usernameInput.value.trim();
@@ -64,9 +141,24 @@ function joinChat() {
     
     // 连接到服务器
     connectToServer();
+    loadUserLocale();
+    loadFeatures();
+    if (!inviteRoom) {
+        loadDraft();
+    }
+    
+    // 请求桌面通知权限，用于 @提及 和私信提醒
+    if ('Notification' in window && Notification.permission === 'default') {
+        Notification.requestPermission();
+    }
     
-    // 聚焦消息输入框
+    // 聚焦消息输入框，邀请链接预填加入私有房间的命令
     setTimeout(() => {
+        if (inviteRoom && inviteToken) {
+            messageInput.value = `/join ${inviteRoom} ${inviteToken}`;
+            updateSendButton();
+            showNotification(`按回车加入私有房间 #${inviteRoom}`, 'info');
+        }
         messageInput.focus();
     }, 100);
 }
@@ -90,6 +182,19 @@ function connectToServer() {
             
             // 发送加入消息
             sendJoinMessage();
+            lastActivity = 0;
+            if (document.visibilityState !== 'visible') {
+                sendActivity(true);
+            }
+            
+            // 重发断线前未确认的消息，服务器在 5 分钟内按 clientMsgId 去重
+            pendingMessages.forEach((message, id) => {
+                if (Date.now() - Date.parse(message.timestamp) < 5 * 60 * 1000) {
+                    socket.send(JSON.stringify(message));
+                } else {
+                    pendingMessages.delete(id);
+                }
+            });
             
             showNotification('连接成功！', 'success');
         };
@@ -106,10 +211,23 @@ function connectToServer() {
         socket.onclose = function(event) {
             console.log('WebSocket 连接已关闭', event);
             isConnected = false;
+            closeCall();
+            userStatus.clear(); // 重连后服务器会重新发送
             updateStatus('disconnected');
             updateSendButton();
             
-            if (event.code !== 1000) { // 非正常关闭
+            if (event.code === 1012 || (event.code === 1013 && event.reason === 'maintenance')) { // 服务器维护
+                showNotification('服务器维护中，稍后将自动重连', 'info');
+                setTimeout(connectToServer, 30000);
+            } else if (event.code === 1013) { // 连接过多或接收太慢
+                showNotification('服务器繁忙，30 秒后重连', 'info');
+                setTimeout(connectToServer, 30000);
+            } else if (event.code === 1001) { // 网关重启
+                showNotification('服务器正在重启，稍后将自动重连', 'info');
+                setTimeout(connectToServer, 5000);
+            } else if (event.code === 1008) { // 令牌无效、被拒绝或未及时加入，重连也无济于事
+                showNotification('无法加入聊天：' + (event.reason || '认证失败'), 'error');
+            } else if (event.code !== 1000) { // 非正常关闭
                 showNotification('连接已断开，正在尝试重连...', 'error');
                 // 自动重连
                 setTimeout(connectToServer, 3000);
@@ -129,8 +247,45 @@ function connectToServer() {
     }
 }
 
-// 发送加入消息
-function sendJoinMessage() {
+// 发送聊天消息，收到服务器确认前保留以便重连后重发
+function sendChat(message) {
+    message.clientMsgId = crypto.randomUUID();
+    pendingMessages.set(message.clientMsgId, message);
+    socket.send(JSON.stringify(message));
+    saveDraftSoon(); // 发送后输入框已清空，草稿随之删除
+}
+
+// 读取服务器上保存的当前房间草稿，输入框为空时填入，可在其他设备上接着写
+function loadDraft() {
+    fetch(`/api/drafts/${encodeURIComponent(currentUsername)}`, { headers: authHeaders() })
+        .then(resp => resp.ok ? resp.json() : null)
+        .then(data => {
+            const draft = ((data && data.drafts) || []).find(d => d.conversation === '#' + currentRoom);
+            if (draft && !messageInput.value) {
+                messageInput.value = draft.text;
+                draftEditedAt = Number(draft.updatedAt);
+                updateSendButton();
+                showNotification('已恢复保存的草稿', 'info');
+            }
+        })
+        .catch(() => {});
+}
+
+// 停止输入一秒后把输入框保存为当前房间的草稿，输入框为空时删除草稿
+function saveDraftSoon() {
+    draftEditedAt = Date.now();
+    clearTimeout(draftTimer);
+    draftTimer = setTimeout(() => {
+        fetch(`/api/drafts/${encodeURIComponent(currentUsername)}`, {
+            method: 'PUT',
+            headers: authHeaders({ 'Content-Type': 'application/json' }),
+            body: JSON.stringify({ conversation: '#' + currentRoom, text: messageInput.value, updatedAt: draftEditedAt })
+        }).catch(() => {});
+    }, 1000);
+}
+
+// 发送加入消息，answer 为人机验证的答案
+function sendJoinMessage(answer) {
     if (socket && socket.readyState === WebSocket.OPEN) {
         const joinMessage = {
             type: 'join',
@@ -138,6 +293,12 @@ function sendJoinMessage() {
             text: 'has joined',
             timestamp: new Date().toISOString()
         };
+        if (authToken) {
+            joinMessage.token = authToken;
+        }
+        if (answer) {
+            joinMessage.challenge = answer;
+        }
         
         socket.send(JSON.stringify(joinMessage));
     }
@@ -147,36 +308,383 @@ function sendJoinMessage() {
 function handleMessage(message) {
     switch (message.type) {
         case 'chat':
+        case 'code':
+            if (message.key) {
+                message.text = renderText(message);
+                delete message.html;
+            }
             displayMessage(message);
+            if (message.room && message.seq) {
+                lastSeq[message.room] = Math.max(lastSeq[message.room] || 0, message.seq);
+                markRead();
+            }
+            if (message.notify) {
+                notifyUser(message);
+            }
             break;
         case 'system':
-            displaySystemMessage(message.text);
+            displaySystemMessage(renderText(message));
             break;
         case 'userList':
             updateUserList(message.users);
             break;
         case 'userJoin':
+            // 在线列表以服务器为准，加入提示的文字随后作为系统消息到达
             onlineUsers.add(message.user);
-            updateUserCount();
-            displaySystemMessage(`${message.user} 加入了聊天室`);
+            updateUserList([...onlineUsers]);
             break;
         case 'userLeave':
             onlineUsers.delete(message.user);
-            updateUserCount();
-            displaySystemMessage(`${message.user} 离开了聊天室`);
+            userStatus.delete(message.user);
+            updateUserList([...onlineUsers]);
+            break;
+        case 'link_preview':
+            displayLinkPreview(message);
+            break;
+        case 'translation':
+            displayTranslation(message);
+            break;
+        case 'edit':
+            displayEdit(message);
+            break;
+        case 'userRename':
+            if (message.self) {
+                currentUsername = message.user;
+                currentUsernameSpan.textContent = message.user;
+            }
+            onlineUsers.delete(message.oldUser);
+            onlineUsers.add(message.user);
+            if (userStatus.has(message.oldUser)) {
+                userStatus.set(message.user, userStatus.get(message.oldUser));
+                userStatus.delete(message.oldUser);
+            }
+            updateUserList([...onlineUsers]);
+            displaySystemMessage(`${message.oldUser} 改名为 ${message.user}`);
             break;
         case 'error':
-            showNotification(message.text, 'error');
+            if (message.code === 'too_large') {
+                // 不再重发超长的消息，否则重连后会再次被拒绝
+                const max = Number(message.args && message.args.max);
+                pendingMessages.forEach((pending, id) => {
+                    if (new Blob([JSON.stringify(pending)]).size > max) {
+                        pendingMessages.delete(id);
+                    }
+                });
+            }
+            showNotification(renderText(message), 'error');
+            break;
+        case 'maintenance':
+            handleMaintenance(message);
+            break;
+        case 'challenge':
+            handleChallenge(message);
+            break;
+        case 'upstream':
+            // 网关与聊天服务器之间的连接状态，断开期间网关会自动重连
+            updateStatus(message.state === 'reconnecting' ? 'reconnecting' : 'connected');
+            break;
+        case 'connection_quality':
+            // 网关的 ping 迟迟没有回应，说明网络较差，恢复后会再通知
+            if (isConnected) {
+                updateStatus(message.quality === 'poor' ? 'poor' : 'connected');
+            }
+            break;
+        case 'signal':
+            handleSignal(message);
+            break;
+        case 'call':
+            handleCallEvent(message.call);
+            break;
+        case 'ack':
+            pendingMessages.delete(message.clientMsgId);
+            break;
+        case 'unread_update':
+            updateUnread(message.rooms);
+            break;
+        case 'keywordHit':
+            notifyKeyword(message);
+            break;
+        case 'member':
+            // 房间成员变化，在线列表仍以 userList 为准
+            break;
+        case 'subscriptions':
+            // 同一连接额外订阅的房间，Web 端只在当前房间聊天
+            if (message.room && message.room !== currentRoom) {
+                currentRoom = message.room;
+                loadDraft();
+            }
+            break;
+        case 'presence':
+            if (message.status === 'available') {
+                userStatus.delete(message.user);
+            } else {
+                userStatus.set(message.user, message.status);
+            }
+            updateUserList([...onlineUsers]);
             break;
         default:
             console.log('未知消息类型:', message);
     }
 }
 
+// 页面可见时把收到的消息标记为已读
+function markRead() {
+    if (document.visibilityState !== 'visible' || !socket || socket.readyState !== WebSocket.OPEN) {
+        return;
+    }
+    Object.entries(lastSeq).forEach(([room, seq]) => {
+        socket.send(JSON.stringify({ type: 'read', room: room, seq: seq }));
+    });
+}
+
+// 在标题中显示未读数
+function updateUnread(rooms) {
+    const total = Object.values(rooms || {}).reduce((sum, n) => sum + n, 0);
+    if (total > 0 && document.visibilityState === 'visible') {
+        markRead(); // 计数可能先于消息到达
+    }
+    document.title = total > 0 ? `(${total}) 实时聊天 - Real Time Chat` : '实时聊天 - Real Time Chat';
+}
+
+document.addEventListener('visibilitychange', markRead);
+
+// 在原消息下显示链接预览
+function displayLinkPreview(preview) {
+    const messageDiv = messagesContainer.querySelector(`.message[data-id="${CSS.escape(preview.messageId)}"]`);
+    if (!messageDiv || !/^https?:\/\//.test(preview.url)) {
+        return;
+    }
+    
+    const card = document.createElement('a');
+    card.className = 'link-preview';
+    card.href = preview.url;
+    card.target = '_blank';
+    card.rel = 'noopener noreferrer nofollow';
+    
+    if (preview.imageUrl && /^https?:\/\//.test(preview.imageUrl)) {
+        const img = document.createElement('img');
+        img.src = preview.imageUrl;
+        img.alt = '';
+        img.loading = 'lazy';
+        img.referrerPolicy = 'no-referrer';
+        card.appendChild(img);
+    }
+    
+    const body = document.createElement('div');
+    for (const [cls, text] of [['link-preview-site', preview.siteName], ['link-preview-title', preview.title], ['link-preview-desc', preview.description]]) {
+        if (text) {
+            const el = document.createElement('div');
+            el.className = cls;
+            el.textContent = text;
+            body.appendChild(el);
+        }
+    }
+    card.appendChild(body);
+    
+    const time = messageDiv.querySelector('.message-time');
+    messageDiv.insertBefore(card, time);
+    scrollToBottom();
+}
+
+// 请求把消息翻译成浏览器语言，结果以 translation 事件返回
+function requestTranslation(messageId) {
+    if (!socket || socket.readyState !== WebSocket.OPEN) {
+        return;
+    }
+    socket.send(JSON.stringify({
+        type: 'chat',
+        user: currentUsername,
+        text: `/translate ${messageId} ${navigator.language || 'en'}`,
+        timestamp: new Date().toISOString()
+    }));
+}
+
+// 把消息置顶到自己的资料，替换之前的置顶
+async function pinToProfile(messageId) {
+    try {
+        const response = await fetch(`/api/profiles/${encodeURIComponent(currentUsername)}/pin`, {
+            method: 'PUT',
+            headers: authHeaders({'Content-Type': 'application/json'}),
+            body: JSON.stringify({messageId})
+        });
+        const data = await response.json();
+        if (!response.ok) {
+            showNotification(data.error || '置顶失败', 'error');
+            return;
+        }
+        showNotification('已置顶到你的资料', 'success');
+    } catch (error) {
+        showNotification('置顶失败', 'error');
+    }
+}
+
+// 鼠标移到用户名上时加载资料卡，显示在线状态和置顶消息，每个元素只加载一次
+async function showProfileCard(el) {
+    if (el.dataset.profileLoaded) {
+        return;
+    }
+    el.dataset.profileLoaded = '1';
+    try {
+        const response = await fetch(`/api/profiles/${encodeURIComponent(el.dataset.user)}`);
+        if (!response.ok) {
+            return;
+        }
+        const profile = await response.json();
+        let card = profile.online ? '在线' : '离线';
+        if (profile.pinned && profile.pinned.text) {
+            card += `\n置顶：${profile.pinned.user}：${profile.pinned.text}`;
+        }
+        el.title = card;
+    } catch (error) {
+        delete el.dataset.profileLoaded;
+    }
+}
+
+// 在原消息下显示译文，同一条消息只保留最新的译文
+function displayTranslation(translation) {
+    const messageDiv = messagesContainer.querySelector(`.message[data-id="${CSS.escape(translation.messageId)}"]`);
+    if (!messageDiv) {
+        return;
+    }
+    let div = messageDiv.querySelector('.translation');
+    if (!div) {
+        div = document.createElement('div');
+        div.className = 'translation';
+        messageDiv.insertBefore(div, messageDiv.querySelector('.message-time'));
+    }
+    div.textContent = `[${translation.lang}] ${translation.text}`;
+    scrollToBottom();
+}
+
+// 用新内容替换原消息，如 AI 助手的流式回答；乱序到达的旧版本被忽略
+function displayEdit(edit) {
+    const messageDiv = messagesContainer.querySelector(`.message[data-id="${CSS.escape(edit.messageId)}"]`);
+    if (!messageDiv || Number(messageDiv.dataset.revision || 0) >= edit.revision) {
+        return;
+    }
+    messageDiv.dataset.revision = edit.revision;
+    messageDiv.classList.toggle('streaming', !edit.done);
+    messageDiv.querySelector('.message-text').textContent = edit.text;
+    scrollToBottom();
+}
+
+// 提醒用户（服务器已按通知偏好判定）
+function notifyUser(message) {
+    const title = message.recipientUser ? `${message.user} 的私信` : `${message.user} 提到了你`;
+    if (document.hidden && 'Notification' in window && Notification.permission === 'granted') {
+        new Notification(title, { body: message.text });
+    } else {
+        showNotification(`${title}: ${message.text}`, 'info');
+    }
+}
+
+// 关键词命中提醒，不论当前在哪个房间
+function notifyKeyword(hit) {
+    const title = `#${hit.room} 中出现了 “${hit.keyword}”`;
+    const body = `${hit.sender}: ${hit.text}`;
+    if (document.hidden && 'Notification' in window && Notification.permission === 'granted') {
+        new Notification(title, { body });
+    } else {
+        showNotification(`${title}  ${body}`, 'info');
+    }
+}
+
+// 处理维护通知
+function handleMaintenance(message) {
+    if (!message.enabled) {
+        if (maintenanceMode) {
+            displaySystemMessage('维护已结束');
+        }
+        maintenanceMode = false;
+        return;
+    }
+    maintenanceMode = true;
+    let text = `维护通知: ${message.text}`;
+    if (message.drainAt) {
+        text += `（将于 ${new Date(message.drainAt).toLocaleTimeString()} 断开连接）`;
+    }
+    displaySystemMessage(text);
+    showNotification(text, 'info');
+}
+
+// 同一地址加入过于频繁时，网关要求先完成人机验证：
+// 工作量证明由浏览器自动计算，CAPTCHA 由用户在弹出的面板中完成
+const captchaWidgets = {
+    hcaptcha: { script: 'https://js.hcaptcha.com/1/api.js', global: 'hcaptcha' },
+    turnstile: { script: 'https://challenges.cloudflare.com/turnstile/v0/api.js', global: 'turnstile' },
+    recaptcha: { script: 'https://www.google.com/recaptcha/api.js', global: 'grecaptcha' }
+};
+
+function handleChallenge(challenge) {
+    if (challenge.kind === 'pow') {
+        showNotification('正在进行人机验证...', 'info');
+        solveProofOfWork(challenge.nonce, challenge.difficulty).then(solution => {
+            sendJoinMessage({ nonce: challenge.nonce, solution: solution });
+        });
+    } else if (challenge.kind === 'captcha') {
+        showCaptcha(challenge.provider, challenge.siteKey);
+    }
+}
+
+// 找到使 SHA-256(nonce:solution) 以 difficulty 个 0 比特开头的 solution
+async function solveProofOfWork(nonce, difficulty) {
+    const encoder = new TextEncoder();
+    for (let i = 0; ; i++) {
+        const digest = await crypto.subtle.digest('SHA-256', encoder.encode(`${nonce}:${i}`));
+        if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+            return String(i);
+        }
+    }
+}
+
+function leadingZeroBits(bytes) {
+    let zeros = 0;
+    for (const b of bytes) {
+        if (b !== 0) {
+            return zeros + Math.clz32(b) - 24;
+        }
+        zeros += 8;
+    }
+    return zeros;
+}
+
+function showCaptcha(provider, siteKey) {
+    const widget = captchaWidgets[provider];
+    if (!widget) {
+        showNotification(`不支持的人机验证：${provider}`, 'error');
+        return;
+    }
+    const panel = document.getElementById('captcha-panel');
+    const container = document.getElementById('captcha-widget');
+    const render = () => {
+        container.innerHTML = '';
+        window[widget.global].render(container, {
+            sitekey: siteKey,
+            callback: response => {
+                panel.style.display = 'none';
+                sendJoinMessage({ response: response });
+            }
+        });
+        panel.style.display = 'flex';
+    };
+    if (window[widget.global] && window[widget.global].render) {
+        render();
+        return;
+    }
+    window.onCaptchaLoad = render;
+    const script = document.createElement('script');
+    script.src = `${widget.script}?render=explicit&onload=onCaptchaLoad`;
+    script.async = true;
+    document.head.appendChild(script);
+}
+
 // 显示消息
 function displayMessage(message) {
     const messageDiv = document.createElement('div');
     messageDiv.className = 'message';
+    if (message.id) {
+        messageDiv.dataset.id = message.id;
+    }
     
     // 判断消息类型
     if (message.user === currentUsername) {
@@ -194,12 +702,49 @@ function displayMessage(message) {
     let messageContent = '';
     
     if (message.user !== currentUsername) {
-        messageContent += `<div class="message-header">${escapeHtml(message.user)}</div>`;
+        messageContent += `<div class="message-header" data-user="${escapeHtml(message.user)}" onmouseenter="showProfileCard(this)">${escapeHtml(message.user)}</div>`;
     }
     
-    messageContent += `<div class="message-text">${escapeHtml(message.text)}</div>`;
+    // html 由服务器渲染并清理过，可直接使用
+    let textHtml = message.html ? message.html : escapeHtml(message.text);
+    if (message.code) {
+        // 代码块原样显示
+        const lang = message.code.language ? `<div class="code-lang">${escapeHtml(message.code.language)}</div>` : '';
+        textHtml = `<pre class="code-block">${lang}<code>${escapeHtml(message.code.content)}</code></pre>`;
+    }
+    if (message.attachment && message.attachment.kind === 'voice' && /^\/api\/attachments\/[0-9A-Za-z]+$/.test(message.attachment.url)) {
+        textHtml += `<audio controls preload="metadata" src="${message.attachment.url}"></audio>`;
+    }
+    if (message.attachment && message.attachment.kind === 'gif' && /^https:\/\//.test(message.attachment.url)) {
+        textHtml += `<img class="gif" loading="lazy" alt="GIF" src="${escapeHtml(message.attachment.url)}">`;
+    }
+    if (message.attachment && message.attachment.kind === 'file' && /^\/api\/attachments\/[0-9A-Za-z]+$/.test(message.attachment.url)) {
+        const size = (message.attachment.size / 1024 / 1024).toFixed(1);
+        const thumb = (message.attachment.thumbnails || [])[0];
+        if (thumb && /^\/api\/attachments\/[0-9A-Za-z]+\/thumbnails\/\d+$/.test(thumb.url)) {
+            textHtml += `<a class="image-link" href="${message.attachment.url}" target="_blank" rel="noopener"><img class="thumbnail" loading="lazy" alt="${escapeHtml(message.attachment.name || '图片')}" width="${Number(thumb.width)}" height="${Number(thumb.height)}" src="${thumb.url}"></a>`;
+        }
+        textHtml += `<a class="file-link" href="${message.attachment.url}" download><i class="fas fa-file"></i> ${escapeHtml(message.attachment.name || '文件')} (${size} MB)</a>`;
+    }
+    messageContent += `<div class="message-text">${textHtml}</div>`;
     
-    if (message.recipientUser) {
+    // 别人的公共消息可以翻译成浏览器语言
+    if (message.id && message.text && !message.code && !message.recipientUser && message.user !== currentUsername) {
+        messageContent += `<button class="translate-btn" title="翻译" onclick="requestTranslation('${escapeHtml(message.id)}')"><i class="fas fa-language"></i></button>`;
+    }
+    
+    // 公共消息可以置顶到自己的资料卡
+    if (message.id && !message.recipientUser && !message.ephemeralTo) {
+        messageContent += `<button class="translate-btn" title="置顶到我的资料" onclick="pinToProfile('${escapeHtml(message.id)}')"><i class="fas fa-thumbtack"></i></button>`;
+    }
+    
+    if (message.ephemeralTo) {
+        messageDiv.classList.add('ephemeral');
+        const ephemeralText = message.user === currentUsername
+            ? `仅 ${message.ephemeralTo} 可见`
+            : '仅你可见';
+        messageContent += `<div class="message-time">${escapeHtml(ephemeralText)}</div>`;
+    } else if (message.recipientUser) {
         const recipientText = message.user === currentUsername 
             ? `发送给 ${message.recipientUser}` 
             : '私人消息';
@@ -251,6 +796,25 @@ function sendMessage() {
     let recipientUser = '';
     let messageText = text;
     
+    // 搜索 GIF
+    if (text.startsWith('/gif ')) {
+        searchGifs(text.slice(5).trim());
+        return;
+    }
+    
+    // 发起通话
+    if (text.startsWith('/call ')) {
+        const user = text.slice(6).trim();
+        if (user === currentUsername || !onlineUsers.has(user)) {
+            showNotification(`用户 ${user} 不在线`, 'error');
+            return;
+        }
+        messageInput.value = '';
+        updateSendButton();
+        startCall(user);
+        return;
+    }
+    
     // 处理私人消息
     if (text.startsWith('/pm ')) {
         const parts = text.split(' ');
@@ -284,7 +848,7 @@ function sendMessage() {
     
     // 发送消息
     try {
-        socket.send(JSON.stringify(message));
+        sendChat(message);
         
         // 清空输入框
         messageInput.value = '';
@@ -299,6 +863,157 @@ function sendMessage() {
     }
 }
 
+// 发送代码块，/code 语言 可指定语言
+function sendCode(content) {
+    const match = messageInput.value.trim().match(/^\/code(?:\s+(\S+))?$/);
+    const message = {
+        type: 'code',
+        user: currentUsername,
+        text: '',
+        code: { language: match && match[1] ? match[1] : '', content: content },
+        timestamp: new Date().toISOString()
+    };
+    
+    try {
+        sendChat(message);
+        messageInput.value = '';
+        updateSendButton();
+    } catch (error) {
+        console.error('发送代码失败:', error);
+        showNotification('发送代码失败', 'error');
+    }
+}
+
+// 开始或结束录制语音消息
+async function toggleRecording() {
+    const voiceBtn = document.getElementById('voice-btn');
+    if (recorder) {
+        recorder.stop();
+        return;
+    }
+    if (!isConnected || !navigator.mediaDevices || !window.MediaRecorder) {
+        showNotification('当前无法录制语音', 'error');
+        return;
+    }
+    
+    try {
+        const stream = await navigator.mediaDevices.getUserMedia({ audio: true });
+        const chunks = [];
+        recorder = new MediaRecorder(stream);
+        recorder.ondataavailable = event => chunks.push(event.data);
+        recorder.onstop = () => {
+            stream.getTracks().forEach(track => track.stop());
+            voiceBtn.classList.remove('recording');
+            const durationMs = Date.now() - recordingStart;
+            const blob = new Blob(chunks, { type: recorder.mimeType });
+            recorder = null;
+            uploadVoice(blob, durationMs);
+        };
+        recordingStart = Date.now();
+        recorder.start();
+        voiceBtn.classList.add('recording');
+        // 最长 5 分钟
+        setTimeout(() => {
+            if (recorder && recorder.state === 'recording') {
+                recorder.stop();
+            }
+        }, 5 * 60 * 1000);
+    } catch (error) {
+        console.error('录音失败:', error);
+        showNotification('无法访问麦克风', 'error');
+    }
+}
+
+// 上传语音并发送消息
+async function uploadVoice(blob, durationMs) {
+    const form = new FormData();
+    form.append('file', blob, 'voice');
+    form.append('durationMs', String(durationMs));
+    
+    try {
+        const response = await fetch('/api/uploads/voice', { method: 'POST', body: form });
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('语音上传失败: ' + result.error, 'error');
+            return;
+        }
+        sendChat({
+            type: 'chat',
+            user: currentUsername,
+            text: '',
+            attachment: { id: result.id },
+            timestamp: new Date().toISOString()
+        });
+    } catch (error) {
+        console.error('语音上传失败:', error);
+        showNotification('语音上传失败', 'error');
+    }
+}
+
+// 搜索 GIF 并显示选择面板，API 密钥只保存在服务器
+async function searchGifs(query) {
+    if (!query) {
+        return;
+    }
+    const picker = document.getElementById('gif-picker');
+    try {
+        const response = await fetch('/api/gifs/search?q=' + encodeURIComponent(query));
+        const result = await response.json();
+        if (!response.ok) {
+            showNotification('GIF 搜索失败: ' + result.error, 'error');
+            return;
+        }
+        picker.innerHTML = '';
+        result.results.filter(gif => /^https:\/\//.test(gif.previewUrl || gif.url)).forEach(gif => {
+            const img = document.createElement('img');
+            img.src = gif.previewUrl || gif.url;
+            img.alt = gif.title || 'GIF';
+            img.title = gif.title || '';
+            img.onclick = () => sendGif(gif);
+            picker.appendChild(img);
+        });
+        if (!picker.children.length) {
+            showNotification('没有找到相关 GIF', 'info');
+            return;
+        }
+        picker.style.display = 'flex';
+    } catch (error) {
+        console.error('GIF 搜索失败:', error);
+        showNotification('GIF 搜索失败', 'error');
+    }
+}
+
+// 发送选中的 GIF，服务器按 ID 向服务商确认地址
+function sendGif(gif) {
+    document.getElementById('gif-picker').style.display = 'none';
+    sendChat({
+        type: 'chat',
+        user: currentUsername,
+        text: '',
+        attachment: { id: gif.id, kind: 'gif' },
+        timestamp: new Date().toISOString()
+    });
+    messageInput.value = '';
+    updateSendButton();
+}
+
+// 粘贴多行文本时询问是否作为代码块发送
+messageInput.addEventListener('paste', function(event) {
+    const text = (event.clipboardData || window.clipboardData).getData('text');
+    if (!text.includes('\n') || !isConnected) {
+        return;
+    }
+    if (text.length > 16384) {
+        showNotification('代码块不能超过16KB', 'error');
+        event.preventDefault();
+        return;
+    }
+    if (confirm('检测到多行文本，是否作为代码块发送？')) {
+        event.preventDefault();
+        sendCode(text);
+    }
+});
+
 // 断开连接
 function disconnect() {
     if (socket) {
@@ -309,6 +1024,10 @@ function disconnect() {
     isConnected = false;
     currentUsername = '';
     onlineUsers.clear();
+    userStatus.clear();
+    lastSeq = {};
+    pendingMessages.clear();
+    updateUnread({});
     
     // 清空消息
     messagesContainer.innerHTML = '';
@@ -349,6 +1068,24 @@ function updateUserList(users) {
             <span>${escapeHtml(user)}</span>
         `;
         
+        // 通话和屏幕共享状态
+        const status = userStatus.get(user);
+        if (status) {
+            const badges = {
+                'sharing-screen': ['fas fa-desktop', '正在共享屏幕'],
+                'in-call': ['fas fa-phone', '通话中'],
+                'away': ['fas fa-moon', '离开'],
+            };
+            const [icon, title] = badges[status] || badges['in-call'];
+            const badge = document.createElement('i');
+            badge.className = `${icon} user-status`;
+            badge.title = title;
+            userItem.appendChild(badge);
+            if (status === 'away') {
+                userItem.classList.add('away');
+            }
+        }
+        
         // 点击用户名插入私聊命令
         if (user !== currentUsername) {
             userItem.style.cursor = 'pointer';
@@ -383,6 +1120,12 @@ function updateStatus(status) {
         case 'disconnected':
             statusIndicator.innerHTML = '<i class="fas fa-circle"></i> 已断开';
             break;
+        case 'reconnecting':
+            statusIndicator.innerHTML = '<i class="fas fa-circle"></i> 重连中...';
+            break;
+        case 'poor':
+            statusIndicator.innerHTML = '<i class="fas fa-circle"></i> 网络较差';
+            break;
     }
 }
 
@@ -406,6 +1149,7 @@ function handleKeyPress(event) {
 
 // 监听消息输入框变化
 messageInput.addEventListener('input', updateSendButton);
+messageInput.addEventListener('input', saveDraftSoon);
 
 // 滚动到底部
 function scrollToBottom() {
@@ -453,6 +1197,27 @@ document.addEventListener('visibilitychange', function() {
         // 页面重新变为可见且之前已连接，尝试重连
         setTimeout(connectToServer, 1000);
     }
+    sendActivity(document.visibilityState !== 'visible');
+});
+
+// 告诉服务器用户是否在使用页面，用于自动显示离开状态；操作时每分钟最多发送一次
+function sendActivity(idle) {
+    if (!socket || socket.readyState !== WebSocket.OPEN || !currentUsername) {
+        return;
+    }
+    if (!idle) {
+        if (Date.now() - lastActivity < 60 * 1000) {
+            return;
+        }
+        lastActivity = Date.now();
+    } else {
+        lastActivity = 0;
+    }
+    socket.send(JSON.stringify({ type: 'activity', idle: idle }));
+}
+
+['keydown', 'mousedown', 'mousemove', 'touchstart', 'focus'].forEach(event => {
+    window.addEventListener(event, () => sendActivity(false), { passive: true });
 });
 
 // 开发模式：添加一些测试功能
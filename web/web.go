@@ -0,0 +1,11 @@
+// Package web embeds the browser chat client so the gateway binary can
+// serve it from any working directory.
+package web
+
+import "embed"
+
+// Assets holds index.html, the admin dashboard admin.html and the static
+// directory
+//
+//go:embed index.html admin.html static
+var Assets embed.FS